@@ -2,6 +2,7 @@ package ldclient
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -12,17 +13,25 @@ import (
 
 var validTagKeyOrValueRegex = regexp.MustCompile(`(?s)^[\w.-]*$`)
 
+// newClientContextFromConfig creates the basic client context, which includes the logging and HTTP
+// configurations. Rather than stopping at the first invalid setting, it reports every problem it finds
+// as a single joined error; the returned context is non-nil even if there was an error, so that the
+// caller can go on to validate other components-- but its HTTP property will be empty if the HTTP
+// configuration was invalid.
 func newClientContextFromConfig(
 	sdkKey string,
 	config Config,
 ) (*internal.ClientContextImpl, error) {
+	var errs []error
+
 	if !stringIsValidHTTPHeaderValue(sdkKey) {
-		// We want to fail fast in this case, because if we got as far as trying to make an HTTP request
+		// We want to fail in this case, because if we got as far as trying to make an HTTP request
 		// to LaunchDarkly with a malformed key, the Go HTTP client unfortunately would include the
 		// actual Authorization header value in its error message, which could end up in logs - and the
 		// value might be a real SDK key that just has (for instance) a newline at the end of it, so it
-		// would be sensitive information.
-		return nil, errors.New("SDK key contains invalid characters")
+		// would be sensitive information. No requests are made during configuration, so it is still
+		// safe to go on validating the rest of the configuration.
+		errs = append(errs, errors.New("SDK key contains invalid characters"))
 	}
 
 	basicConfig := subsystems.BasicClientContext{
@@ -37,7 +46,9 @@ func newClientContextFromConfig(
 	}
 	logging, err := loggingFactory.Build(basicConfig)
 	if err != nil {
-		return nil, err
+		errs = append(errs, componentConfigError("Logging", err))
+		// Fall back to the default logging configuration so the remaining components can still be built
+		logging, _ = ldcomponents.Logging().Build(basicConfig)
 	}
 	basicConfig.Logging = logging
 
@@ -52,11 +63,17 @@ func newClientContextFromConfig(
 	}
 	http, err := httpFactory.Build(basicConfig)
 	if err != nil {
-		return nil, err
+		errs = append(errs, componentConfigError("HTTP", err))
 	}
 	basicConfig.HTTP = http
 
-	return &internal.ClientContextImpl{BasicClientContext: basicConfig}, nil
+	return &internal.ClientContextImpl{BasicClientContext: basicConfig}, errors.Join(errs...)
+}
+
+// componentConfigError wraps an error returned by a component factory so that its message identifies
+// the component, while errors.Is and errors.As still match the original error.
+func componentConfigError(component string, err error) error {
+	return fmt.Errorf("%s: %w", component, err)
 }
 
 func stringIsValidHTTPHeaderValue(s string) bool {
@@ -1,6 +1,8 @@
 package ldclient
 
 import (
+	"time"
+
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
@@ -42,6 +44,18 @@ type Config struct {
 	//     config.BigSegmentStore = ldcomponents.BigSegments(ldredis.BigSegmentStore())
 	BigSegments subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration]
 
+	// Sets how long LDClient.TrackConversion avoids recording another feature event for the same flag and
+	// context, after a full feature event has been recorded for them.
+	//
+	// A full feature event is one that is sent individually, rather than only being counted in summary data:
+	// that is, for a flag that has event tracking turned on or that is in an experiment, or for any
+	// evaluation done by TrackConversion. If zero, the default is DefaultConversionDedupWindow. If negative,
+	// TrackConversion always records a feature event.
+	//
+	//     // example: don't send the same flag's evaluation more than once an hour for conversions
+	//     config.ConversionDedupWindow = time.Hour
+	ConversionDedupWindow time.Duration
+
 	// Sets the implementation of DataSource for receiving feature flag updates.
 	//
 	// If Offline is set to true, then DataSource is ignored.
@@ -53,6 +67,8 @@ type Config struct {
 	//     to further configure polling behavior.
 	//   - ldcomponents.ExternalUpdatesOnly(), which turns off all data sources unless an external process is
 	//     providing data via a database.
+	//   - ldcomponents.DaemonModeDataSource(), which copies the data from a database populated by the Relay
+	//     Proxy once at startup, and then does not make any further connections.
 	//   - ldfiledata.DataSource() or ldtestdata.DataSource(), which provide configurable local data sources
 	//     for testing.
 	//   - Or, a custom component that implements ComponentConfigurer[DataSource].
@@ -79,12 +95,65 @@ type Config struct {
 	//
 	// You could also define your own database integration by implementing the PersistentDataStore interface.
 	//
+	// When a persistent data store is used, the client waits briefly for it to load any existing data before
+	// starting the DataSource, so that evaluations made before the DataSource has received data can use it.
+	//
 	//     // example: use Redis, with default properties
 	//     import ldredis "github.com/launchdarkly/go-server-sdk-redis-redigo"
 	//
 	//     config.DataStore = ldcomponents.PersistentDataStore(ldredis.DataStore())
 	DataStore subsystems.ComponentConfigurer[subsystems.DataStore]
 
+	// Sets a listener to be told about every feature flag or segment change that the data source applies.
+	//
+	// Each change is described by an interfaces.DataUpdateRecord, which includes the old and new versions of
+	// the item but not its data. If nil, no records are produced.
+	//
+	//     // example: write a line of JSON to a file for every change
+	//     config.DataUpdateListener = ldcomponents.DataUpdateJSONWriter(auditFile)
+	DataUpdateListener interfaces.DataUpdateListener
+
+	// Sets how many updates from the data source may be rejected in one minute before the SDK reports a data
+	// source error.
+	//
+	// An update is rejected if the data store already has the same or a newer version of the flag or segment.
+	// This is always counted (see interfaces.DataStoreStatusProvider.GetRejectedUpdateCount) and logged, but
+	// if more than this many updates are rejected within one minute, the SDK also sets the data source status
+	// to DataSourceStateInterrupted with the error kind DataSourceErrorKindRejectedUpdates, so that it can be
+	// detected with the data source status API. The status becomes valid again when an update is accepted
+	// after the rate has gone back down. If zero, which is the default, rejected updates never change the
+	// status.
+	//
+	//     // example: report an error if more than 10 updates per minute are out of order
+	//     config.RejectedUpdatesErrorThreshold = 10
+	RejectedUpdatesErrorThreshold int
+
+	// Sets how long after the SDK notifies data source status listeners it may skip notifying them again of
+	// a status that has the same state.
+	//
+	// While a data source keeps failing to reconnect, it reports a new error each time, which would
+	// otherwise be a new notification with the state still DataSourceStateInterrupted. Within this window,
+	// the error is still stored, so interfaces.DataSourceStatusProvider.GetStatus always returns the latest
+	// one, but the listeners are only notified again once the window has passed. A change to a different
+	// state is always notified. If zero, which is the default, every change is notified.
+	//
+	//     // example: notify listeners of repeated errors no more than once every 10 seconds
+	//     config.DataSourceStatusCoalescingWindow = 10 * time.Second
+	DataSourceStatusCoalescingWindow time.Duration
+
+	// Sets how long the data source must be interrupted before the SDK notifies data source status
+	// listeners.
+	//
+	// This is for network connections that flap, briefly dropping and reconnecting many times. If the data
+	// source becomes valid again within this time, the listeners hear about neither the interruption nor
+	// the recovery. interfaces.DataSourceStatusProvider.GetStatus still returns the actual state at all
+	// times. This is separate from ldcomponents.LoggingConfigurationBuilder.LogDataSourceOutageAsErrorAfter,
+	// which only affects logging. If zero, which is the default, interruptions are notified immediately.
+	//
+	//     // example: ignore connection problems that last less than 5 seconds
+	//     config.DataSourceInterruptedReportingDelay = 5 * time.Second
+	DataSourceInterruptedReportingDelay time.Duration
+
 	// Set to true to opt out of sending diagnostic events.
 	//
 	// Unless DiagnosticOptOut is set to true, the client will send some diagnostics data to the LaunchDarkly
@@ -94,11 +163,57 @@ type Config struct {
 	// as dropped events.
 	DiagnosticOptOut bool
 
+	// Enables dry-run mode, in which flags are evaluated as if some of them were in different states.
+	//
+	// The interface type used here is implemented by ldcomponents.DryRunConfigurationBuilder, which you can
+	// create by calling ldcomponents.DryRunOverrides(). In dry-run mode, the flags and segments provided by
+	// the overrides data source are used instead of the ones in the data store, and analytics events are
+	// written to the builder's EventWriter instead of being delivered by the Events component. The data
+	// store itself is not affected. If nil, which is the default, dry-run mode is off.
+	//
+	//     // example: evaluate with the flag states in a file, and log the resulting events
+	//     config.DryRunOverrides = ldcomponents.DryRunOverrides(ldfiledata.DataSource().FilePaths("qa.json")).
+	//         EventWriter(os.Stderr)
+	DryRunOverrides subsystems.ComponentConfigurer[subsystems.DryRunConfiguration]
+
+	// Sets the longest time that a flag evaluation may take before the SDK gives up on it.
+	//
+	// If an evaluation takes longer, usually because a persistent data store is slow to respond, the variation
+	// method returns the default value with the error kind EvalErrorTimeout, and the analytics event for the
+	// evaluation has that reason. This applies to all of the variation methods; the methods ending in Ctx
+	// also stop when their Go context is done, whichever happens first. If zero, which is the default, there
+	// is no timeout.
+	//
+	//     // example: never wait more than 50 milliseconds for a flag value
+	//     config.EvaluationTimeout = 50 * time.Millisecond
+	EvaluationTimeout time.Duration
+
+	// Enables caching of evaluation results, so that evaluations do not have to wait for the data store.
+	//
+	// The interface type used here is implemented by ldcomponents.EvaluationCacheConfigurationBuilder,
+	// which you can create by calling ldcomponents.EvaluationCache(). If nil, results are not cached.
+	//
+	//     config.EvaluationCache = ldcomponents.EvaluationCache().TTL(30 * time.Second)
+	EvaluationCache subsystems.ComponentConfigurer[subsystems.EvaluationCacheConfiguration]
+
+	// Sets a destination for metrics about flag evaluations, such as counts, errors, and durations.
+	//
+	// The interface type used here is implemented by ldcomponents.EvaluationMetricsConfigurationBuilder,
+	// which you can create by calling ldcomponents.EvaluationMetrics(). If nil, no metrics are recorded.
+	//
+	//     // example: publish evaluation metrics with expvar
+	//     // (note: ldexpvar is github.com/launchdarkly/go-server-sdk/v7/ldexpvar)
+	//     metrics := ldexpvar.NewEvaluationMetrics()
+	//     metrics.Publish("launchdarkly")
+	//     config.EvaluationMetrics = ldcomponents.EvaluationMetrics(metrics)
+	EvaluationMetrics subsystems.ComponentConfigurer[subsystems.EvaluationMetricsConfiguration]
+
 	// Sets the SDK's behavior regarding analytics events.
 	//
-	// The interface type for this field allows you to set it to either:
+	// The interface type for this field allows you to set it to any of the following:
 	//   - ldcomponents.SendEvents(), a configuration builder that allows you to customize event behavior;
-	//   - ldcomponents.NoEvents(), which turns off event delivery.
+	//   - ldcomponents.NoEvents(), which turns off event delivery;
+	//   - ldcomponents.NoOpEvents(), which still generates events but silently discards them.
 	//
 	// If this field is unset/nil, the default is ldcomponents.SendEvents() with no custom options.
 	//
@@ -135,8 +250,27 @@ type Config struct {
 	//     config.Logging = ldcomponents.Logging().MinLevel(ldlog.Warn)
 	Logging subsystems.ComponentConfigurer[subsystems.LoggingConfiguration]
 
+	// Sets a collector to be told the duration, variation, and error kind of every flag evaluation.
+	//
+	// This is a simpler and lower-level mechanism than EvaluationMetrics, meant for keeping statistics in
+	// memory within the application. If nil, evaluations are not reported.
+	//
+	//     // example: keep a latency histogram for each flag
+	//     // (note: ldmetrics is github.com/launchdarkly/go-server-sdk/v7/ldmetrics)
+	//     collector := ldmetrics.NewHistogramMetricsCollector()
+	//     config.Metrics = collector
+	//     // later:
+	//     p99 := collector.Snapshot("my-flag").ValueAtPercentile(99)
+	Metrics subsystems.EvaluationMetricsCollector
+
 	// Sets whether this client is offline. An offline client will not make any network connections to LaunchDarkly,
-	// and will return default values for all feature flags.
+	// and will not send analytics events.
+	//
+	// If DataStore is not set to a persistent data store, an offline client will return default values for all
+	// feature flags. If DataStore is set to a persistent data store created with ldcomponents.PersistentDataStore(),
+	// the client behaves as if it were in daemon mode (see ldcomponents.ExternalUpdatesOnly()): it evaluates flags
+	// using whatever data has been put into the store by another process, such as the Relay Proxy, and
+	// LDClient.Initialized() returns true only if the store has been populated.
 	//
 	// For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/offline-mode#go
 	Offline bool
@@ -183,9 +317,30 @@ type Config struct {
 	// the expected paths for LaunchDarkly services.
 	ServiceEndpoints interfaces.ServiceEndpoints
 
+	// Enables checking, in tests, that each flag is always evaluated with the same type.
+	//
+	// If nil, which is the default, no checking is done. See VariationTypeChecker for details.
+	//
+	//     // example: share one checker between all clients in a test run
+	//     config.VariationTypeChecker = typeChecker // created once with ld.NewVariationTypeChecker(0)
+	VariationTypeChecker *VariationTypeChecker
+
 	// Provides configuration of application metadata. See interfaces.ApplicationInfo.
 	//
 	// Application metadata may be used in LaunchDarkly analytics or other product features, but does not
 	// affect feature flag evaluations.
 	ApplicationInfo interfaces.ApplicationInfo
 }
+
+// Validate checks the configuration for the same errors that [MakeCustomClient] would report, without
+// creating a client. It returns nil if the configuration is valid; otherwise, the error combines the errors
+// from all of the components that failed, exactly as MakeCustomClient would return them.
+//
+// To do this, Validate builds each configured component and then closes it. It does not connect to
+// LaunchDarkly or send any events, but a component that connects to an external service when it is
+// created, such as a persistent data store, will still do so. The SDK key is not checked, since it is not
+// part of the Config.
+func (c Config) Validate() error {
+	_, err := makeCustomClient("", c, 0, true)
+	return err
+}
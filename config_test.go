@@ -1,7 +1,24 @@
 package ldclient
 
 import (
+	"errors"
 	"net/http"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type urlAppendingHTTPTransport string
@@ -17,3 +34,102 @@ func (t urlAppendingHTTPTransport) RoundTrip(r *http.Request) (*http.Response, e
 	req.URL.Path = req.URL.Path + string(t)
 	return http.DefaultTransport.RoundTrip(&req)
 }
+
+type closeTrackingDataStore struct {
+	subsystems.DataStore
+	closed bool
+}
+
+func (s *closeTrackingDataStore) Close() error {
+	s.closed = true
+	return s.DataStore.Close()
+}
+
+func TestConfigValidateReturnsSameErrorsAsMakeCustomClient(t *testing.T) {
+	config := Config{
+		DataStore: mocks.ComponentConfigurerThatReturnsError[subsystems.DataStore]{Err: errors.New("bad store")},
+		Events:    mocks.ComponentConfigurerThatReturnsError[ldevents.EventProcessor]{Err: errors.New("bad events")},
+		Logging:   ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	validateErr := config.Validate()
+	require.Error(t, validateErr)
+	assert.Equal(t, "DataStore: bad store\nEvents: bad events", validateErr.Error())
+
+	client, err := MakeCustomClient(testSdkKey, config, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), validateErr.Error())
+}
+
+func TestConfigValidateReportsInvalidHTTPConfiguration(t *testing.T) {
+	config := Config{
+		HTTP:    ldcomponents.HTTPConfiguration().CACert([]byte{1}),
+		Logging: ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "HTTP: invalid CA certificate data", err.Error())
+}
+
+func TestConfigValidateReturnsNilForValidConfigWithoutConnecting(t *testing.T) {
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData())
+	defer server.Close()
+	mockLog := ldlogtest.NewMockLog()
+	store := &closeTrackingDataStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+	config := Config{
+		DataStore:        mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store},
+		Logging:          ldcomponents.Logging().Loggers(mockLog.Loggers),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
+
+	assert.NoError(t, config.Validate())
+
+	assert.True(t, store.closed)
+	assertNoMoreRequests(t, server.DataRequests())
+	assertNoMoreRequests(t, server.EventRequests())
+	assert.Len(t, mockLog.GetOutput(ldlog.Info), 0)
+}
+
+func TestClientIsNotAffectedByChangesToConfigAfterCreation(t *testing.T) {
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+	defer server.Close()
+
+	httpConfig := ldcomponents.HTTPConfiguration().Header("X-Test", "original")
+	config := Config{
+		DiagnosticOptOut: true,
+		HTTP:             httpConfig,
+		Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client.Close()
+	<-server.DataRequests()
+
+	config.Offline = true
+	config.ServiceEndpoints = interfaces.ServiceEndpoints{Events: "http://localhost:1"}
+	config.Events = ldcomponents.NoEvents()
+	httpConfig.Header("X-Test", "changed")
+
+	assert.False(t, client.IsOffline())
+	client.Identify(testUser)
+	client.Flush()
+
+	r := <-server.EventRequests()
+	assert.Equal(t, "/bulk", r.Request.URL.Path)
+	assert.Equal(t, "original", r.Request.Header.Get("X-Test"))
+
+	// A client created from the changed configuration does see the changes.
+	config.Offline = false
+	config.Events = nil
+	config.ServiceEndpoints = server.ServiceEndpoints()
+	client2, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client2.Close()
+	<-server.DataRequests()
+	client2.Identify(testUser)
+	client2.Flush()
+
+	r = <-server.EventRequests()
+	assert.Equal(t, "changed", r.Request.Header.Get("X-Test"))
+}
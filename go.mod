@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/fsnotify/fsnotify v1.4.7
+	github.com/google/uuid v1.1.1
 	github.com/gregjones/httpcache v0.0.0-20171119193500-2bcd89a1743f
 	github.com/launchdarkly/ccache v1.1.0
 	github.com/launchdarkly/eventsource v1.6.2
@@ -22,7 +23,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/google/uuid v1.1.1 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/launchdarkly/go-ntlmssp v1.0.1 // indirect
 	github.com/launchdarkly/go-semver v1.0.2 // indirect
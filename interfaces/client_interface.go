@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	gocontext "context"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
@@ -123,6 +124,27 @@ type LDClientEvaluations interface {
 	JSONVariationDetail(key string, context ldcontext.Context, defaultVal ldvalue.Value) (
 		ldvalue.Value, ldreason.EvaluationDetail, error)
 
+	// BoolVariationCtx, BoolVariationDetailCtx, and the other methods ending in Ctx are the same as the
+	// corresponding methods without Ctx, but also take a Go context for the evaluation. This is used for
+	// adding a prefix to log messages; see ldcomponents.LoggingConfigurationBuilder.WithContextExtractor().
+	// If ctx is done before the evaluation completes, they return the default value with an error.
+	BoolVariationCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal bool) (bool, error)
+	BoolVariationDetailCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal bool) (
+		bool, ldreason.EvaluationDetail, error)
+	IntVariationCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal int) (int, error)
+	IntVariationDetailCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal int) (
+		int, ldreason.EvaluationDetail, error)
+	Float64VariationCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal float64) (float64, error)
+	Float64VariationDetailCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal float64) (
+		float64, ldreason.EvaluationDetail, error)
+	StringVariationCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal string) (string, error)
+	StringVariationDetailCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal string) (
+		string, ldreason.EvaluationDetail, error)
+	JSONVariationCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal ldvalue.Value) (
+		ldvalue.Value, error)
+	JSONVariationDetailCtx(ctx gocontext.Context, key string, context ldcontext.Context, defaultVal ldvalue.Value) (
+		ldvalue.Value, ldreason.EvaluationDetail, error)
+
 	// AllFlagsState returns an object that encapsulates the state of all feature flags for a given evaluation
 	// context.
 	// This includes the flag values, and also metadata that can be used on the front end.
@@ -135,6 +157,14 @@ type LDClientEvaluations interface {
 	//
 	// For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/all-flags#go
 	AllFlagsState(context ldcontext.Context, options ...flagstate.Option) flagstate.AllFlags
+
+	// EvaluateAll evaluates all feature flags for an evaluation context, and keeps evaluating them again
+	// whenever they change, sending the results to a channel.
+	//
+	// It first sends a FlagEvaluationUpdate for every flag, and then sends another one each time a flag's
+	// value or reason for the context changes. It blocks until ctx is cancelled or the client is closed.
+	// No analytics events are generated.
+	EvaluateAll(ctx gocontext.Context, context ldcontext.Context, ch chan<- FlagEvaluationUpdate) error
 }
 
 // LDClientEvents defines the methods implemented by LDClient that are specifically for generating
@@ -169,6 +199,13 @@ type LDClientEvents interface {
 	// For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/events#go
 	TrackData(eventName string, context ldcontext.Context, data ldvalue.Value) error
 
+	// TrackPageView reports that a page of a web application was viewed by an evaluation context.
+	//
+	// This is a convenience wrapper for [LDClientEvents.TrackData]: it sends a custom event with the event
+	// key "pageView", whose data is a JSON object with a "url" property and, if referrer is not empty, a
+	// "referrer" property.
+	TrackPageView(context ldcontext.Context, url, referrer string) error
+
 	// TrackMetric reports an event associated with an evaluation context, and adds a numeric value.
 	// This value is used by the LaunchDarkly experimentation feature in numeric custom metrics, and will also
 	// be returned as part of the custom event for Data Export.
@@ -183,6 +220,15 @@ type LDClientEvents interface {
 	// For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/events#go
 	TrackMetric(eventName string, context ldcontext.Context, metricValue float64, data ldvalue.Value) error
 
+	// TrackConversion reports a conversion event for an experiment, along with the flag variation that the
+	// evaluation context saw.
+	//
+	// It evaluates the flag, and then sends a custom event with a numeric value, like
+	// [LDClientEvents.TrackMetric], whose data has the properties "flagKey", "variationIndex", and "reason"
+	// describing the evaluation. The evaluation's own feature event is not repeated if one was recently
+	// recorded for the same flag and context.
+	TrackConversion(eventName string, context ldcontext.Context, flagKey string, metricValue float64) error
+
 	// TrackMigrationOp reports a migration operation event.
 	//
 	// The measurements included in the event are used by LaunchDarkly to enhance support and
@@ -229,6 +275,46 @@ type LDClientInterface interface {
 	WithEventsDisabled(eventsDisabled bool) LDClientInterface
 }
 
+// ReadonlyLDClient defines the subset of LDClient's methods that evaluate feature flags and describe the
+// client, without any that flush or close it or that send custom events.
+//
+// This is meant for dependency injection into parts of an application that should only read flags, so
+// that a call to a method like Flush, Close, or TrackEvent from that code is a compile-time error:
+//
+//	type PageRenderer struct {
+//	    flags interfaces.ReadonlyLDClient
+//	}
+//
+//	renderer := PageRenderer{flags: client} // client is an *ldclient.LDClient
+type ReadonlyLDClient interface {
+	// BoolVariation is the same as [LDClientEvaluations.BoolVariation].
+	BoolVariation(key string, context ldcontext.Context, defaultVal bool) (bool, error)
+
+	// IntVariation is the same as [LDClientEvaluations.IntVariation].
+	IntVariation(key string, context ldcontext.Context, defaultVal int) (int, error)
+
+	// Float64Variation is the same as [LDClientEvaluations.Float64Variation].
+	Float64Variation(key string, context ldcontext.Context, defaultVal float64) (float64, error)
+
+	// StringVariation is the same as [LDClientEvaluations.StringVariation].
+	StringVariation(key string, context ldcontext.Context, defaultVal string) (string, error)
+
+	// JSONVariation is the same as [LDClientEvaluations.JSONVariation].
+	JSONVariation(key string, context ldcontext.Context, defaultVal ldvalue.Value) (ldvalue.Value, error)
+
+	// AllFlagsState is the same as [LDClientEvaluations.AllFlagsState].
+	AllFlagsState(context ldcontext.Context, options ...flagstate.Option) flagstate.AllFlags
+
+	// Identify is the same as [LDClientEvents.Identify].
+	Identify(context ldcontext.Context) error
+
+	// Initialized returns whether the client has received feature flag data.
+	Initialized() bool
+
+	// Version returns the SDK version.
+	Version() string
+}
+
 // LDMigrationOpTracker defines the required operations implemented by [MigrationOpTracker].
 //
 // These methods allow incrementally constructing a migration operation event for later reporting to
@@ -53,6 +53,9 @@ type DataSourceStatusProvider interface {
 	//
 	// The listener will be notified whenever any property of the status has changed. See DataSourceStatus for
 	// an explanation of the meaning of each property and what could cause it to change.
+	// Notifications can be made less frequent with the DataSourceStatusCoalescingWindow and
+	// DataSourceInterruptedReportingDelay properties of the SDK configuration; GetStatus is not affected by
+	// those.
 	//
 	// It is the caller's responsibility to consume values from the channel. Allowing values to accumulate in
 	// the channel can cause an SDK goroutine to be blocked. If you no longer need the channel, call
@@ -95,6 +98,8 @@ type DataSourceStatus struct {
 	//
 	// The meaning of this depends on the current State:
 	//   - For DataSourceStateInitializing, it is the time that the SDK started initializing.
+	//   - For DataSourceStateInitializedFromStore, it is the time that the SDK started using the data
+	//     that was already in the data store.
 	//   - For DataSourceStateValid, it is the time that the data source most recently entered a valid
 	//     state, after previously having been either Initializing or Interrupted.
 	//   - For DataSourceStateInterrupted, it is the time that the data source most recently entered an
@@ -135,6 +140,16 @@ const (
 	// becomes DataSourceStateOff.
 	DataSourceStateInitializing DataSourceState = "INITIALIZING"
 
+	// DataSourceStateInitializedFromStore indicates that the SDK is evaluating flags with data that it found
+	// in a persistent data store at startup, while the data source is still connecting for the first time.
+	//
+	// The SDK only uses this state if it was configured to do so, as with
+	// ldcomponents.StreamingDataSourceBuilder.UseStoreDataWhileConnecting. The client is considered
+	// initialized, but the data may be out of date, since it was stored by an earlier process. Errors while
+	// connecting do not change the state to DataSourceStateInterrupted; it becomes DataSourceStateValid when
+	// the data source receives its data, or DataSourceStateOff if it permanently fails.
+	DataSourceStateInitializedFromStore DataSourceState = "INITIALIZED_FROM_STORE"
+
 	// DataSourceStateValid indicates that the data source is currently operational and has not had
 	// any problems since the last time it received data.
 	//
@@ -230,4 +245,13 @@ const (
 	// automatically reported by the SDK whenever one of the update methods of DataSourceUpdateSink
 	// encounters a failure.
 	DataSourceErrorKindStoreError DataSourceErrorKind = "STORE_ERROR"
+
+	// DataSourceErrorKindRejectedUpdates means the data source itself is working, but too many of the
+	// updates it sent in the last minute had versions that were not newer than the versions already in the
+	// data store, so the data store ignored them.
+	//
+	// This is only reported if it has been enabled with the RejectedUpdatesErrorThreshold property of the
+	// SDK configuration. As with DataSourceErrorKindStoreError, data source implementations do not need to
+	// report it.
+	DataSourceErrorKindRejectedUpdates DataSourceErrorKind = "REJECTED_UPDATES"
 )
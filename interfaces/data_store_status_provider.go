@@ -60,6 +60,17 @@ type DataStoreStatusProvider interface {
 	// RemoveStatusListener unsubscribes from notifications of status changes. The specified channel must be
 	// one that was previously returned by AddStatusListener(); otherwise, the method has no effect.
 	RemoveStatusListener(<-chan DataStoreStatus)
+
+	// GetRejectedUpdateCount returns the number of times, since the SDK client was created, that the data
+	// source tried to update a flag or segment with a version that was not newer than the version already
+	// in the data store.
+	//
+	// The data store ignores such updates, so they do not cause any harm by themselves; but if this number
+	// keeps increasing, it may mean that a custom data source is sending updates out of order. The SDK also
+	// logs a warning, at most once per minute, when this happens. If several SDK instances share a persistent
+	// data store, an update that another instance has already written is also rejected, so a low rate of
+	// rejections is normal in that case.
+	GetRejectedUpdateCount() int
 }
 
 // DataStoreStatus contains information about the status of a data store, provided by [DataStoreStatusProvider].
@@ -0,0 +1,82 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// DataUpdateListener is an interface for receiving a record of every feature flag or segment change that
+// the SDK applies to its data store.
+//
+// To use it, set the DataUpdateListener field of [github.com/launchdarkly/go-server-sdk/v7.Config].
+// The SDK provides an implementation that writes each record as a line of JSON, which you can get by
+// calling ldcomponents.DataUpdateJSONWriter().
+type DataUpdateListener interface {
+	// OnDataUpdate is called once for each item that was changed by a data source update, after the
+	// update has been stored.
+	//
+	// It is called synchronously on the data source's goroutine, so it should return quickly. If it
+	// returns an error, the SDK logs a warning; the update itself is not affected.
+	OnDataUpdate(record DataUpdateRecord) error
+}
+
+// DataUpdateRecord describes a single change to a feature flag or segment.
+//
+// It contains only version information, not the item's data.
+type DataUpdateRecord struct {
+	// Time is when the change was applied.
+	Time time.Time
+
+	// Kind is the kind of data: "features" or "segments".
+	Kind string
+
+	// Key is the flag or segment key.
+	Key string
+
+	// OldVersion is the version that the SDK previously had for this item, if any.
+	OldVersion ldvalue.OptionalInt
+
+	// NewVersion is the new version of this item. It is undefined if the item was not present at all in a
+	// new full data set.
+	NewVersion ldvalue.OptionalInt
+
+	// Deleted is true if the item was deleted or was not present in a new full data set.
+	Deleted bool
+
+	// Operation is the kind of update that was applied.
+	Operation DataUpdateOperation
+
+	// Source is the kind of data source that provided the update.
+	Source DataUpdateSource
+}
+
+// DataUpdateOperation describes how a DataUpdateRecord was applied.
+type DataUpdateOperation string
+
+const (
+	// DataUpdateOperationInit means that the data source provided a full data set, such as the initial
+	// data from a stream, a polling response, or a reloaded data file.
+	DataUpdateOperationInit DataUpdateOperation = "init"
+
+	// DataUpdateOperationUpsert means that the data source provided an update or deletion for a single
+	// item, such as a stream "patch" or "delete" event.
+	DataUpdateOperationUpsert DataUpdateOperation = "upsert"
+)
+
+// DataUpdateSource describes the kind of data source that provided a DataUpdateRecord.
+type DataUpdateSource string
+
+const (
+	// DataUpdateSourceStreaming means the update came from ldcomponents.StreamingDataSource().
+	DataUpdateSourceStreaming DataUpdateSource = "streaming"
+
+	// DataUpdateSourcePolling means the update came from ldcomponents.PollingDataSource().
+	DataUpdateSourcePolling DataUpdateSource = "polling"
+
+	// DataUpdateSourceFile means the update came from ldfiledata.DataSource().
+	DataUpdateSourceFile DataUpdateSource = "file"
+
+	// DataUpdateSourceCustom means the update came from some other data source.
+	DataUpdateSourceCustom DataUpdateSource = "custom"
+)
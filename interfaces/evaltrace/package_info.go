@@ -0,0 +1,3 @@
+// Package evaltrace contains the data types used by the LDClient.EvaluateWithTrace() method.
+// These types describe the steps that the SDK took to evaluate a feature flag.
+package evaltrace
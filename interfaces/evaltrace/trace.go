@@ -0,0 +1,152 @@
+package evaltrace
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// Trace is a record of the steps the SDK took to evaluate a feature flag for a specific evaluation
+// context. This is the return type of LDClient.EvaluateWithTrace().
+//
+// The steps are recorded in the order they were checked: first the prerequisites, then the individual
+// targets, then the rules, and finally the fallthrough. Evaluation stops at the first step that determines
+// the result, so later steps are omitted; for instance, if a rule matched, Rules ends with that rule and
+// Fallthrough is nil.
+//
+// Serializing this object to JSON using json.Marshal() produces a readable description that can be
+// attached to a support ticket.
+type Trace struct {
+	// FlagKey is the key of the flag that was evaluated.
+	FlagKey string `json:"flagKey"`
+
+	// FlagFound is false if the flag did not exist or could not be evaluated, in which case no other steps
+	// are recorded.
+	FlagFound bool `json:"flagFound"`
+
+	// FlagVersion is the version of the flag that was evaluated.
+	FlagVersion int `json:"flagVersion,omitempty"`
+
+	// FlagOn is false if the flag was turned off, in which case no other steps are recorded.
+	FlagOn bool `json:"flagOn"`
+
+	// Prerequisites contains each prerequisite flag that was checked.
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty"`
+
+	// Targets contains each individual target list that was checked.
+	Targets []Target `json:"targets,omitempty"`
+
+	// Rules contains each rule that was checked.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// Fallthrough describes how the fallthrough variation was chosen, if no target or rule matched.
+	Fallthrough *Result `json:"fallthrough,omitempty"`
+}
+
+// Prerequisite describes a prerequisite flag that was checked.
+type Prerequisite struct {
+	// Key is the key of the prerequisite flag.
+	Key string `json:"key"`
+
+	// Found is false if the prerequisite flag did not exist.
+	Found bool `json:"found"`
+
+	// On is false if the prerequisite flag was turned off.
+	On bool `json:"on"`
+
+	// RequiredVariation is the variation index that the prerequisite flag must return.
+	RequiredVariation int `json:"requiredVariation"`
+
+	// Variation is the variation index that the prerequisite flag actually returned, if any.
+	Variation ldvalue.OptionalInt `json:"variation"`
+
+	// Passed is true if the prerequisite was satisfied.
+	Passed bool `json:"passed"`
+}
+
+// Target describes an individual target list that was checked.
+type Target struct {
+	// ContextKind is the kind of context that the target list applies to.
+	ContextKind ldcontext.Kind `json:"contextKind"`
+
+	// ContextKey is the key that was looked up in the target list. It is empty if the evaluation context
+	// did not include a context of this kind.
+	ContextKey string `json:"contextKey,omitempty"`
+
+	// Variation is the variation index that the target list would return.
+	Variation int `json:"variation"`
+
+	// Matched is true if ContextKey was in the target list.
+	Matched bool `json:"matched"`
+}
+
+// Rule describes a flag rule that was checked.
+type Rule struct {
+	// Index is the zero-based position of the rule in the flag.
+	Index int `json:"index"`
+
+	// ID is the unique identifier of the rule.
+	ID string `json:"id,omitempty"`
+
+	// Clauses contains each clause that was checked. Clauses after the first one that did not match are
+	// not checked.
+	Clauses []Clause `json:"clauses"`
+
+	// Matched is true if all of the clauses matched.
+	Matched bool `json:"matched"`
+
+	// Result describes how the variation was chosen, if the rule matched.
+	Result *Result `json:"result,omitempty"`
+}
+
+// Clause describes a rule clause that was checked.
+type Clause struct {
+	// ContextKind is the kind of context whose attribute was checked.
+	ContextKind ldcontext.Kind `json:"contextKind"`
+
+	// Attribute is the attribute reference that was checked.
+	Attribute string `json:"attribute,omitempty"`
+
+	// Op is the clause operator, such as "in" or "segmentMatch".
+	Op string `json:"op"`
+
+	// Values are the values that the clause compares against.
+	Values []ldvalue.Value `json:"values"`
+
+	// Negate is true if the result of the comparison was inverted.
+	Negate bool `json:"negate,omitempty"`
+
+	// ContextValue is the value of the attribute in the evaluation context, or a null value if the
+	// attribute did not exist. It is always null for a segmentMatch clause.
+	ContextValue ldvalue.Value `json:"contextValue"`
+
+	// Matched is true if the clause matched.
+	Matched bool `json:"matched"`
+
+	// Error is set if the clause could not be evaluated because of malformed flag data.
+	Error ldreason.EvalErrorKind `json:"error,omitempty"`
+}
+
+// Result describes how a variation was chosen for a rule or for the fallthrough.
+type Result struct {
+	// Variation is the variation index that was chosen, if any.
+	Variation ldvalue.OptionalInt `json:"variation"`
+
+	// Rollout is set if the variation was chosen by a percentage rollout or experiment.
+	Rollout *Rollout `json:"rollout,omitempty"`
+}
+
+// Rollout describes the bucketing computation for a percentage rollout or experiment.
+type Rollout struct {
+	// ContextKind is the kind of context that was used for bucketing.
+	ContextKind ldcontext.Kind `json:"contextKind"`
+
+	// BucketBy is the attribute that was used for bucketing.
+	BucketBy string `json:"bucketBy"`
+
+	// BucketValue is the value, in the range [0, 1), that was computed from the context and the flag.
+	BucketValue float64 `json:"bucketValue"`
+
+	// Bucket is the zero-based index of the weighted variation that BucketValue fell into.
+	Bucket int `json:"bucket"`
+}
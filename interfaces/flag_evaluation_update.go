@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// FlagEvaluationUpdate is a parameter type used with LDClient.EvaluateAll().
+//
+// This is not an analytics event to be sent to LaunchDarkly; it is a notification to the application.
+type FlagEvaluationUpdate struct {
+	// Key is the key of the feature flag.
+	Key string
+
+	// Value is the result of evaluating the flag for the specified evaluation context.
+	//
+	// If the flag has been deleted, this is a null value.
+	Value ldvalue.Value
+
+	// Reason describes how the value was determined.
+	//
+	// If the flag has been deleted, this is an error reason with the error kind
+	// ldreason.EvalErrorFlagNotFound.
+	Reason ldreason.EvaluationReason
+}
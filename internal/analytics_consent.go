@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+// AnalyticsConsent is what an AnalyticsConsentFilter decides about the analytics events for a context.
+type AnalyticsConsent int
+
+const (
+	// AnalyticsConsentGiven means that events for the context are recorded as usual.
+	AnalyticsConsentGiven AnalyticsConsent = iota
+
+	// AnalyticsConsentSummaryOnly means that evaluations for the context are only counted in summary
+	// events, for a placeholder context from NonConsentingPlaceholder, and that no other events are
+	// recorded for it.
+	AnalyticsConsentSummaryOnly
+
+	// AnalyticsConsentNotGiven means that no events at all are recorded for the context.
+	AnalyticsConsentNotGiven
+)
+
+// NonConsentingContextKey is the key of the contexts that NonConsentingPlaceholder returns. The event
+// processor that ldcomponents.SendEvents() creates removes the index events for these contexts from its
+// payloads.
+const NonConsentingContextKey = "$ld:non-consenting"
+
+// AnalyticsConsentFilter is implemented by the event processor that ldcomponents.SendEvents() creates,
+// if an analytics consent function was configured. Once an event has been recorded, the event processor
+// has no way to get its context back, so LDClient asks the filter before it records each event.
+type AnalyticsConsentFilter interface {
+	AnalyticsConsent(context ldcontext.Context) AnalyticsConsent
+}
+
+// NonConsentingPlaceholder returns an anonymous context with the same kinds as the specified context,
+// and the key NonConsentingContextKey, to use instead of it in evaluation events. The summary event only
+// reports the context kinds, so the counts are the same as they would have been for the real context.
+func NonConsentingPlaceholder(context ldcontext.Context) ldcontext.Context {
+	if !context.Multiple() {
+		return ldcontext.NewBuilder(NonConsentingContextKey).Kind(context.Kind()).Anonymous(true).Build()
+	}
+	b := ldcontext.NewMultiBuilder()
+	for _, c := range context.GetAllIndividualContexts(nil) {
+		b.Add(ldcontext.NewBuilder(NonConsentingContextKey).Kind(c.Kind()).Anonymous(true).Build())
+	}
+	return b.Build()
+}
@@ -3,6 +3,8 @@ package internal
 import (
 	"sync"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
 	"golang.org/x/exp/slices"
 )
 
@@ -11,83 +13,187 @@ import (
 // The standard pattern is that AddListener returns a new receive-only channel; RemoveListener unsubscribes
 // that channel, and closes the sending end of it; Broadcast sends a value to all of the subscribed channels
 // (if any); and Close unsubscribes and closes all existing channels.
+//
+// Broadcast never waits for a subscriber. Each subscriber has its own queue and its own goroutine that
+// delivers values from the queue to the subscriber's channel, so a subscriber that is not reading its
+// channel only delays its own values. If a subscriber's queue is full, the oldest value in it is dropped.
+
+// DefaultSubscriberBufferSize is the number of undelivered values that a Broadcaster keeps for each
+// subscriber, if not otherwise specified. This is large enough that a consumer that is reading its
+// channel should not miss anything; it is still the consumer's responsibility to read the channel.
+const DefaultSubscriberBufferSize = 100
 
-// Arbitrary buffer size to make it less likely that we'll block when broadcasting to channels. It is still
-// the consumer's responsibility to make sure they're reading the channel.
+// subscriberChannelBufferLength is the buffer size for other channels that relay broadcast values, such
+// as the ones created by FlagTracker.AddFlagValueChangeListener.
 const subscriberChannelBufferLength = 10
 
 // Broadcaster is our generalized implementation of broadcasters.
 type Broadcaster[V any] struct {
-	subscribers []channelPair[V]
+	subscribers []*subscriber[V]
+	bufferSize  int
+	loggers     ldlog.Loggers
 	lock        sync.Mutex
 }
 
-// We need to keep track of both the channel we use for sending (stored as a reflect.Value, because Value
-// has methods for sending and closing), and also the
-type channelPair[V any] struct {
-	sendCh    chan<- V
-	receiveCh <-chan V
+// subscriber holds the queue of values that have not yet been delivered to one subscriber. We need to keep
+// both the channel we use for sending and the receive-only channel that was returned by AddListener; the
+// latter is how RemoveListener finds the subscriber.
+type subscriber[V any] struct {
+	sendCh      chan<- V
+	receiveCh   <-chan V
+	queue       []V
+	overflowing bool
+	lock        sync.Mutex
+	readyCh     chan struct{}
+	closeCh     chan struct{}
+	doneCh      chan struct{}
 }
 
-// NewBroadcaster creates a Broadcaster that operates on the specified value type.
+// NewBroadcaster creates a Broadcaster that operates on the specified value type, with the default buffer
+// size and no logging.
 func NewBroadcaster[V any]() *Broadcaster[V] {
-	return &Broadcaster[V]{}
+	return NewBroadcasterWithOptions[V](ldlog.NewDisabledLoggers(), DefaultSubscriberBufferSize)
+}
+
+// NewBroadcasterWithOptions creates a Broadcaster that operates on the specified value type. The loggers
+// are used to report dropped values. The bufferSize is the maximum number of undelivered values per
+// subscriber; if it is zero or negative, DefaultSubscriberBufferSize is used.
+func NewBroadcasterWithOptions[V any](loggers ldlog.Loggers, bufferSize int) *Broadcaster[V] {
+	if bufferSize <= 0 {
+		bufferSize = DefaultSubscriberBufferSize
+	}
+	return &Broadcaster[V]{bufferSize: bufferSize, loggers: loggers}
 }
 
 // AddListener adds a subscriber and returns a channel for it to receive values.
 func (b *Broadcaster[V]) AddListener() <-chan V {
-	ch := make(chan V, subscriberChannelBufferLength)
-	var receiveCh <-chan V = ch
-	chPair := channelPair[V]{sendCh: ch, receiveCh: receiveCh}
+	ch := make(chan V)
+	s := &subscriber[V]{
+		sendCh:    ch,
+		receiveCh: ch,
+		readyCh:   make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	go b.runSubscriber(s)
 	b.lock.Lock()
 	defer b.lock.Unlock()
-	b.subscribers = append(b.subscribers, chPair)
-	return receiveCh
+	b.subscribers = append(b.subscribers, s)
+	return s.receiveCh
 }
 
 // RemoveListener removes a subscriber. The parameter is the same channel that was returned by
-// AddListener.
+// AddListener. Any values that have not yet been delivered to it are discarded, and the channel is closed
+// before RemoveListener returns.
 func (b *Broadcaster[V]) RemoveListener(ch <-chan V) {
 	b.lock.Lock()
-	defer b.lock.Unlock()
+	var removed *subscriber[V]
 	ss := b.subscribers
 	for i, s := range ss {
-		// The following equality test is the reason why we have to store both the sendCh (chan X) and
-		// the receiveCh (<-chan X) for each subscriber; "s.sendCh == ch" would not be true because
-		// they're of two different types.
 		if s.receiveCh == ch {
 			copy(ss[i:], ss[i+1:])
-			ss[len(ss)-1] = channelPair[V]{}
+			ss[len(ss)-1] = nil
 			b.subscribers = ss[:len(ss)-1]
-			close(s.sendCh)
+			removed = s
 			break
 		}
 	}
+	b.lock.Unlock()
+	if removed != nil {
+		removed.stop()
+	}
 }
 
 // HasListeners returns true if there are any current subscribers.
 func (b *Broadcaster[V]) HasListeners() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
 	return len(b.subscribers) > 0
 }
 
-// Broadcast broadcasts a value to all current subscribers.
+// Broadcast broadcasts a value to all current subscribers. It does not wait for the value to be
+// delivered.
 func (b *Broadcaster[V]) Broadcast(value V) {
 	b.lock.Lock()
 	ss := slices.Clone(b.subscribers)
 	b.lock.Unlock()
-	if len(ss) > 0 {
-		for _, ch := range ss {
-			ch.sendCh <- value
-		}
+	for _, s := range ss {
+		b.enqueue(s, value)
 	}
 }
 
-// Close closes all current subscriber channels.
+// Close closes all current subscriber channels. Any values that have not yet been delivered are
+// discarded, and all of the channels are closed before Close returns.
 func (b *Broadcaster[V]) Close() {
 	b.lock.Lock()
-	defer b.lock.Unlock()
-	for _, s := range b.subscribers {
-		close(s.sendCh)
-	}
+	ss := b.subscribers
 	b.subscribers = nil
+	b.lock.Unlock()
+	for _, s := range ss {
+		s.stop()
+	}
+}
+
+func (b *Broadcaster[V]) enqueue(s *subscriber[V], value V) {
+	s.lock.Lock()
+	if len(s.queue) >= b.bufferSize {
+		var empty V
+		s.queue[0] = empty
+		s.queue = s.queue[1:]
+		if !s.overflowing {
+			s.overflowing = true
+			b.loggers.Warnf("A listener is not reading its channel; dropping the oldest of %d undelivered values",
+				b.bufferSize)
+		}
+	}
+	s.queue = append(s.queue, value)
+	s.lock.Unlock()
+	select {
+	case s.readyCh <- struct{}{}:
+	default: // the subscriber's goroutine has already been signaled
+	}
+}
+
+func (b *Broadcaster[V]) runSubscriber(s *subscriber[V]) {
+	defer close(s.doneCh)
+	defer close(s.sendCh)
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-s.readyCh:
+		}
+		for {
+			s.lock.Lock()
+			if len(s.queue) == 0 {
+				s.overflowing = false
+				s.lock.Unlock()
+				break
+			}
+			value := s.queue[0]
+			var empty V
+			s.queue[0] = empty
+			s.queue = s.queue[1:]
+			s.lock.Unlock()
+			if !b.deliver(s, value) {
+				return
+			}
+		}
+	}
+}
+
+// deliver sends one value to a subscriber's channel, returning false if the subscriber was removed
+// first. Since only runSubscriber closes the channel, the send cannot panic.
+func (b *Broadcaster[V]) deliver(s *subscriber[V], value V) bool {
+	select {
+	case s.sendCh <- value:
+		return true
+	case <-s.closeCh:
+		return false
+	}
+}
+
+func (s *subscriber[V]) stop() {
+	close(s.closeCh)
+	<-s.doneCh
 }
@@ -2,12 +2,17 @@ package internal
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
 	th "github.com/launchdarkly/go-test-helpers/v3"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBroadcaster(t *testing.T) {
@@ -81,3 +86,124 @@ func testBroadcasterGenerically[V any](t *testing.T, broadcasterFactory func() *
 		})
 	})
 }
+
+func TestBroadcasterSlowSubscriberDoesNotDelayOthers(t *testing.T) {
+	b := NewBroadcaster[int]()
+	defer b.Close()
+	_ = b.AddListener() // never read
+	ch := b.AddListener()
+
+	count := DefaultSubscriberBufferSize
+	done := make(chan struct{}, 1)
+	go func() {
+		for i := 0; i < count; i++ {
+			b.Broadcast(i)
+		}
+		done <- struct{}{}
+	}()
+	th.RequireValue(t, done, time.Second, "Broadcast was blocked by a subscriber that is not reading")
+
+	received := 0
+	timeout := time.After(time.Second * 5)
+	for received < count {
+		select {
+		case <-ch:
+			received++
+		case <-timeout:
+			require.Fail(t, "timed out", "received only %d of %d values", received, count)
+		}
+	}
+}
+
+func TestBroadcasterDropsOldestValuesWhenBufferIsFull(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	b := NewBroadcasterWithOptions[int](mockLog.Loggers, 3)
+	defer b.Close()
+	ch := b.AddListener()
+
+	b.Broadcast(1)
+	// The subscriber's goroutine takes the first value from the queue and waits to deliver it
+	require.Eventually(t, func() bool {
+		s := b.subscribers[0]
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return len(s.queue) == 0
+	}, time.Second, time.Millisecond)
+	for i := 2; i <= 6; i++ {
+		b.Broadcast(i)
+	}
+
+	var values []int
+	for i := 0; i < 4; i++ {
+		values = append(values, th.RequireValue(t, ch, time.Second))
+	}
+	assert.Equal(t, []int{1, 4, 5, 6}, values)
+	th.AssertNoMoreValues(t, ch, time.Millisecond*50)
+	assert.Len(t, mockLog.GetOutput(ldlog.Warn), 1)
+}
+
+func TestBroadcasterCloseUnblocksPendingDeliveries(t *testing.T) {
+	b := NewBroadcaster[int]()
+	var channels []<-chan int
+	for i := 0; i < 10; i++ {
+		channels = append(channels, b.AddListener())
+	}
+	for i := 0; i < DefaultSubscriberBufferSize*2; i++ {
+		b.Broadcast(i)
+	}
+
+	closed := make(chan struct{}, 1)
+	go func() {
+		b.Close()
+		closed <- struct{}{}
+	}()
+	th.RequireValue(t, closed, time.Second, "timed out waiting for Close")
+
+	for _, ch := range channels {
+		for range ch { // discard any value that was delivered before Close, then see that the channel is closed
+		}
+	}
+	assert.False(t, b.HasListeners())
+	b.Broadcast(0) // no subscribers, does nothing
+}
+
+func TestBroadcasterWithConcurrentSubscribersAndBroadcasts(t *testing.T) {
+	b := NewBroadcasterWithOptions[int](ldlog.NewDisabledLoggers(), 5)
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(slow bool) {
+			defer wg.Done()
+			ch := b.AddListener()
+			for j := 0; j < 10; j++ {
+				select {
+				case <-ch:
+				case <-time.After(time.Millisecond * 10):
+				}
+				if slow {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			b.RemoveListener(ch)
+			th.AssertChannelClosed(t, ch, time.Millisecond*100)
+		}(i%2 == 0)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Broadcast(n*100 + j)
+			}
+		}(i)
+	}
+	allDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDone)
+	}()
+	select {
+	case <-allDone:
+	case <-time.After(time.Second * 5):
+		require.Fail(t, "timed out waiting for goroutines")
+	}
+}
@@ -10,4 +10,10 @@ type ClientContextImpl struct {
 	subsystems.BasicClientContext
 	// Used internally to share a diagnosticsManager instance between components.
 	DiagnosticsManager *ldevents.DiagnosticsManager
+	// Used internally to keep track of diagnostic data for LDClient.GetDiagnosticReport; it is non-nil
+	// whenever DiagnosticsManager is.
+	DiagnosticsRecorder *DiagnosticsRecorder
+	// Used internally to keep track of event statistics for LDClient.GetStats. The standard event
+	// processor uses it if it is non-nil.
+	EventStatsRecorder *EventStatsRecorder
 }
@@ -0,0 +1,143 @@
+package datadeps
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// KindAndKey identifies a flag or segment.
+type KindAndKey struct {
+	Kind st.DataKind
+	Key  string
+}
+
+// KindAndKeySet is a set of KindAndKey values. It is implemented as a map, but the values do not matter,
+// just the keys.
+type KindAndKeySet map[KindAndKey]bool
+
+// Add adds a value to the set.
+func (s KindAndKeySet) Add(value KindAndKey) {
+	s[value] = true
+}
+
+// Contains returns true if the value is in the set.
+func (s KindAndKeySet) Contains(value KindAndKey) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// ComputeDependenciesFrom finds all the flags and segments that a flag or segment directly references,
+// through prerequisites or segmentMatch clauses. Transitive references are handled by recursive logic at
+// a higher level.
+func ComputeDependenciesFrom(kind st.DataKind, fromItem st.ItemDescriptor) KindAndKeySet {
+	var ret KindAndKeySet
+	checkClauses := func(clauses []ldmodel.Clause) {
+		for _, c := range clauses {
+			if c.Op == ldmodel.OperatorSegmentMatch {
+				for _, v := range c.Values {
+					if v.Type() == ldvalue.StringType {
+						if ret == nil {
+							ret = make(KindAndKeySet)
+						}
+						ret.Add(KindAndKey{datakinds.Segments, v.StringValue()})
+					}
+				}
+			}
+		}
+	}
+	switch kind {
+	case datakinds.Features:
+		if flag, ok := fromItem.Item.(*ldmodel.FeatureFlag); ok {
+			if len(flag.Prerequisites) > 0 {
+				ret = make(KindAndKeySet, len(flag.Prerequisites))
+				for _, p := range flag.Prerequisites {
+					ret.Add(KindAndKey{datakinds.Features, p.Key})
+				}
+			}
+			for _, r := range flag.Rules {
+				checkClauses(r.Clauses)
+			}
+			return ret
+		}
+
+	case datakinds.Segments:
+		if segment, ok := fromItem.Item.(*ldmodel.Segment); ok {
+			for _, r := range segment.Rules {
+				checkClauses(r.Clauses)
+			}
+		}
+	}
+	return ret
+}
+
+// DependencyTracker maintains a bidirectional dependency graph that can be updated whenever an item has
+// changed. It is not safe for concurrent use.
+type DependencyTracker struct {
+	dependenciesFrom map[KindAndKey]KindAndKeySet
+	dependenciesTo   map[KindAndKey]KindAndKeySet
+}
+
+// NewDependencyTracker creates an empty DependencyTracker.
+func NewDependencyTracker() *DependencyTracker {
+	return &DependencyTracker{make(map[KindAndKey]KindAndKeySet), make(map[KindAndKey]KindAndKeySet)}
+}
+
+// UpdateDependenciesFrom updates the dependency graph when an item has changed.
+func (d *DependencyTracker) UpdateDependenciesFrom(
+	kind st.DataKind,
+	fromKey string,
+	fromItem st.ItemDescriptor,
+) {
+	fromWhat := KindAndKey{kind, fromKey}
+	updatedDependencies := ComputeDependenciesFrom(kind, fromItem)
+
+	oldDependencySet := d.dependenciesFrom[fromWhat]
+	for oldDep := range oldDependencySet {
+		depsToThisOldDep := d.dependenciesTo[oldDep]
+		if depsToThisOldDep != nil {
+			delete(depsToThisOldDep, fromWhat)
+		}
+	}
+
+	d.dependenciesFrom[fromWhat] = updatedDependencies
+	for newDep := range updatedDependencies {
+		depsToThisNewDep := d.dependenciesTo[newDep]
+		if depsToThisNewDep == nil {
+			depsToThisNewDep = make(KindAndKeySet)
+			d.dependenciesTo[newDep] = depsToThisNewDep
+		}
+		depsToThisNewDep.Add(fromWhat)
+	}
+}
+
+// Reset removes everything from the dependency graph.
+func (d *DependencyTracker) Reset() {
+	d.dependenciesFrom = make(map[KindAndKey]KindAndKeySet)
+	d.dependenciesTo = make(map[KindAndKey]KindAndKeySet)
+}
+
+// AddAffectedItems populates the given set with the union of the initial item and all items that directly or indirectly
+// depend on it (based on the current state of the dependency graph).
+func (d *DependencyTracker) AddAffectedItems(itemsOut KindAndKeySet, initialModifiedItem KindAndKey) {
+	if !itemsOut.Contains(initialModifiedItem) {
+		itemsOut.Add(initialModifiedItem)
+		affectedItems := d.dependenciesTo[initialModifiedItem]
+		for affectedItem := range affectedItems {
+			d.AddAffectedItems(itemsOut, affectedItem)
+		}
+	}
+}
+
+// AddDependencies populates the given set with all items that the initial item directly or indirectly
+// depends on (based on the current state of the dependency graph). The initial item itself is not added
+// unless it is part of a dependency cycle.
+func (d *DependencyTracker) AddDependencies(itemsOut KindAndKeySet, initialItem KindAndKey) {
+	for dependency := range d.dependenciesFrom[initialItem] {
+		if !itemsOut.Contains(dependency) {
+			itemsOut.Add(dependency)
+			d.AddDependencies(itemsOut, dependency)
+		}
+	}
+}
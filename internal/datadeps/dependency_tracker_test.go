@@ -0,0 +1,300 @@
+package datadeps
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDependenciesFromFlag(t *testing.T) {
+	flag1 := ldbuilders.NewFlagBuilder("key").Build()
+	assert.Len(
+		t,
+		ComputeDependenciesFrom(datakinds.Features, sharedtest.FlagDescriptor(flag1)),
+		0,
+	)
+
+	flag2 := ldbuilders.NewFlagBuilder("key").
+		AddPrerequisite("flag2", 0).
+		AddPrerequisite("flag3", 0).
+		AddRule(
+			ldbuilders.NewRuleBuilder().Clauses(
+				ldbuilders.Clause("key", ldmodel.OperatorIn, ldvalue.String("ignore")),
+				ldbuilders.SegmentMatchClause("segment1", "segment2"),
+			),
+		).
+		AddRule(
+			ldbuilders.NewRuleBuilder().Clauses(
+				ldbuilders.SegmentMatchClause("segment3"),
+			),
+		).
+		Build()
+	assert.Equal(
+		t,
+		KindAndKeySet{
+			{datakinds.Features, "flag2"}:    true,
+			{datakinds.Features, "flag3"}:    true,
+			{datakinds.Segments, "segment1"}: true,
+			{datakinds.Segments, "segment2"}: true,
+			{datakinds.Segments, "segment3"}: true,
+		},
+		ComputeDependenciesFrom(datakinds.Features, sharedtest.FlagDescriptor(flag2)),
+	)
+
+	flag3 := ldbuilders.NewFlagBuilder("key").
+		AddRule(
+			ldbuilders.NewRuleBuilder().Clauses(
+				ldbuilders.Clause("key}", ldmodel.OperatorIn, ldvalue.String("ignore")),
+				ldbuilders.SegmentMatchClause("segment1", "segment2"),
+			),
+		).
+		Build()
+	assert.Equal(
+		t,
+		KindAndKeySet{
+			{datakinds.Segments, "segment1"}: true,
+			{datakinds.Segments, "segment2"}: true,
+		},
+		ComputeDependenciesFrom(datakinds.Features, sharedtest.FlagDescriptor(flag3)),
+	)
+}
+
+func TestComputeDependenciesFromSegment(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment").Build()
+	assert.Len(
+		t,
+		ComputeDependenciesFrom(datakinds.Segments, st.ItemDescriptor{Version: segment.Version, Item: &segment}),
+		0,
+	)
+}
+
+func TestComputeDependenciesFromSegmentWithSegmentReferences(t *testing.T) {
+	segment1 := ldbuilders.NewSegmentBuilder("segment1").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(
+			ldbuilders.SegmentMatchClause("segment2", "segment3"),
+		)).
+		Build()
+	assert.Equal(
+		t,
+		KindAndKeySet{
+			{datakinds.Segments, "segment2"}: true,
+			{datakinds.Segments, "segment3"}: true,
+		},
+		ComputeDependenciesFrom(datakinds.Segments, st.ItemDescriptor{Version: segment1.Version, Item: &segment1}),
+	)
+}
+
+func TestComputeDependenciesFromUnknownDataKind(t *testing.T) {
+	assert.Len(
+		t,
+		ComputeDependenciesFrom(mocks.MockData, st.ItemDescriptor{Version: 1, Item: "x"}),
+		0,
+	)
+}
+
+func TestComputeDependenciesFromNullItem(t *testing.T) {
+	assert.Len(
+		t,
+		ComputeDependenciesFrom(datakinds.Features, st.ItemDescriptor{Version: 1, Item: nil}),
+		0,
+	)
+}
+
+func TestDependencyTrackerReturnsSingleValueResultForUnknownItem(t *testing.T) {
+	dt := NewDependencyTracker()
+
+	// a change to any item with no known depenencies affects only itself
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag1", KindAndKey{datakinds.Features, "flag1"})
+}
+
+func TestDependencyTrackerBuildsGraph(t *testing.T) {
+	dt := NewDependencyTracker()
+
+	segment3 := ldbuilders.NewSegmentBuilder("segment3").Build()
+	segment2 := ldbuilders.NewSegmentBuilder("segment2").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(
+			ldbuilders.SegmentMatchClause(segment3.Key),
+		)).
+		Build()
+	segment1 := ldbuilders.NewSegmentBuilder("segment1").Build()
+
+	flag1 := ldbuilders.NewFlagBuilder("flag1").
+		AddPrerequisite("flag2", 0).
+		AddPrerequisite("flag3", 0).
+		AddRule(
+			ldbuilders.NewRuleBuilder().Clauses(
+				ldbuilders.SegmentMatchClause(segment1.Key, segment2.Key),
+			),
+		).
+		Build()
+
+	flag2 := ldbuilders.NewFlagBuilder("flag2").
+		AddPrerequisite("flag4", 0).
+		AddRule(
+			ldbuilders.NewRuleBuilder().Clauses(
+				ldbuilders.SegmentMatchClause(segment2.Key),
+			),
+		).
+		Build()
+
+	for _, s := range []ldmodel.Segment{segment1, segment2, segment3} {
+		dt.UpdateDependenciesFrom(datakinds.Segments, s.Key, sharedtest.SegmentDescriptor(s))
+	}
+	for _, f := range []ldmodel.FeatureFlag{flag1, flag2} {
+		dt.UpdateDependenciesFrom(datakinds.Features, f.Key, sharedtest.FlagDescriptor(f))
+	}
+
+	// a change to flag1 affects only flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag1",
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	// a change to flag2 affects flag2 and flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag2",
+		KindAndKey{datakinds.Features, "flag2"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	// a change to flag3 affects flag3 and flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag3",
+		KindAndKey{datakinds.Features, "flag3"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	// a change to segment1 affects segment1 and flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Segments, "segment1",
+		KindAndKey{datakinds.Segments, "segment1"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	// a change to segment2 affects segment2, flag1, and flag2
+	verifyDependencyAffectedItems(t, dt, datakinds.Segments, "segment2",
+		KindAndKey{datakinds.Segments, "segment2"},
+		KindAndKey{datakinds.Features, "flag1"},
+		KindAndKey{datakinds.Features, "flag2"},
+	)
+
+	// a change to segment3 affects segment2, which affects flag1 and flag2
+	verifyDependencyAffectedItems(t, dt, datakinds.Segments, "segment3",
+		KindAndKey{datakinds.Segments, "segment3"},
+		KindAndKey{datakinds.Segments, "segment2"},
+		KindAndKey{datakinds.Features, "flag1"},
+		KindAndKey{datakinds.Features, "flag2"},
+	)
+}
+
+func TestDependencyTrackerUpdatesGraph(t *testing.T) {
+	dt := NewDependencyTracker()
+
+	flag1 := ldbuilders.NewFlagBuilder("flag1").
+		AddPrerequisite("flag3", 0).
+		Build()
+	dt.UpdateDependenciesFrom(datakinds.Features, flag1.Key, st.ItemDescriptor{Version: flag1.Version, Item: &flag1})
+
+	flag2 := ldbuilders.NewFlagBuilder("flag2").
+		AddPrerequisite("flag3", 0).
+		Build()
+	dt.UpdateDependenciesFrom(datakinds.Features, flag2.Key, st.ItemDescriptor{Version: flag2.Version, Item: &flag2})
+
+	// at this point, a change to flag3 affects flag3, flag2, and flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag3",
+		KindAndKey{datakinds.Features, "flag3"},
+		KindAndKey{datakinds.Features, "flag2"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	// now make it so flag1 now depends on flag4 instead of flag2
+	flag1v2 := ldbuilders.NewFlagBuilder("flag1").
+		AddPrerequisite("flag4", 0).
+		Build()
+	dt.UpdateDependenciesFrom(datakinds.Features, flag1.Key, st.ItemDescriptor{Version: flag1v2.Version, Item: &flag1v2})
+
+	// now, a change to flag3 affects flag3 and flag2
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag3",
+		KindAndKey{datakinds.Features, "flag3"},
+		KindAndKey{datakinds.Features, "flag2"},
+	)
+
+	// and a change to flag4 affects flag4 and flag1
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag4",
+		KindAndKey{datakinds.Features, "flag4"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+}
+
+func TestDependencyTrackerResetsGraph(t *testing.T) {
+	dt := NewDependencyTracker()
+
+	flag1 := ldbuilders.NewFlagBuilder("flag1").
+		AddPrerequisite("flag3", 0).
+		Build()
+	dt.UpdateDependenciesFrom(datakinds.Features, flag1.Key, st.ItemDescriptor{Version: flag1.Version, Item: &flag1})
+
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag3",
+		KindAndKey{datakinds.Features, "flag3"},
+		KindAndKey{datakinds.Features, "flag1"},
+	)
+
+	dt.Reset()
+
+	verifyDependencyAffectedItems(t, dt, datakinds.Features, "flag3",
+		KindAndKey{datakinds.Features, "flag3"},
+	)
+}
+
+func verifyDependencyAffectedItems(
+	t *testing.T,
+	dt *DependencyTracker,
+	kind st.DataKind,
+	key string,
+	expected ...KindAndKey,
+) {
+	expectedSet := make(KindAndKeySet)
+	for _, value := range expected {
+		expectedSet.Add(value)
+	}
+	result := make(KindAndKeySet)
+	dt.AddAffectedItems(result, KindAndKey{kind, key})
+	assert.Equal(t, expectedSet, result)
+}
+
+func TestDependencyTrackerAddDependencies(t *testing.T) {
+	dt := NewDependencyTracker()
+
+	segment2 := ldbuilders.NewSegmentBuilder("segment2").Build()
+	segment1 := ldbuilders.NewSegmentBuilder("segment1").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(ldbuilders.SegmentMatchClause(segment2.Key))).
+		Build()
+	flag1 := ldbuilders.NewFlagBuilder("flag1").AddPrerequisite("flag2", 0).Build()
+	flag2 := ldbuilders.NewFlagBuilder("flag2").
+		AddPrerequisite("flag1", 0). // a cycle should not cause infinite recursion
+		AddRule(ldbuilders.NewRuleBuilder().Clauses(ldbuilders.SegmentMatchClause(segment1.Key))).
+		Build()
+	for _, s := range []ldmodel.Segment{segment1, segment2} {
+		dt.UpdateDependenciesFrom(datakinds.Segments, s.Key, sharedtest.SegmentDescriptor(s))
+	}
+	for _, f := range []ldmodel.FeatureFlag{flag1, flag2} {
+		dt.UpdateDependenciesFrom(datakinds.Features, f.Key, sharedtest.FlagDescriptor(f))
+	}
+
+	result := make(KindAndKeySet)
+	dt.AddDependencies(result, KindAndKey{datakinds.Features, "flag2"})
+	assert.Equal(t, KindAndKeySet{
+		{datakinds.Features, "flag1"}:    true,
+		{datakinds.Features, "flag2"}:    true,
+		{datakinds.Segments, "segment1"}: true,
+		{datakinds.Segments, "segment2"}: true,
+	}, result)
+
+	result = make(KindAndKeySet)
+	dt.AddDependencies(result, KindAndKey{datakinds.Segments, "segment2"})
+	assert.Len(t, result, 0)
+}
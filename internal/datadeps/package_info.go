@@ -0,0 +1,5 @@
+// Package datadeps is an internal package for computing the dependencies between flags and segments.
+//
+// It is used by the data source update logic for flag change events and for ordering data store updates,
+// and by ldstoreimpl.DependencyIndex.
+package datadeps
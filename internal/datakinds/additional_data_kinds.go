@@ -0,0 +1,115 @@
+package datakinds
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jreader"
+)
+
+// AdditionalDataKind is a data kind other than flags and segments that has been registered with
+// RegisterAdditionalDataKind, along with the name of its property in the data from LaunchDarkly.
+type AdditionalDataKind struct {
+	Kind        ldstoretypes.DataKind
+	PayloadName string
+}
+
+//nolint:gochecknoglobals // the registry applies to every SDK client in the process
+var (
+	additionalDataKinds     []AdditionalDataKind
+	additionalDataKindsLock sync.RWMutex
+)
+
+const (
+	flagsPayloadName    = "flags"
+	segmentsPayloadName = "segments"
+)
+
+// RegisterAdditionalDataKind adds a data kind to the ones that the SDK recognizes in streaming and
+// polling data, in flag data files, and in message streams such as ldkafka's; items of that kind are
+// put in the data store like flags and segments. The payload name is the property that contains the
+// items in a full data set, such as "flags", and the first part of the path of a single item, such as
+// "/flags/my-flag".
+func RegisterAdditionalDataKind(kind ldstoretypes.DataKind, payloadName string) error {
+	if kind == nil || kind.GetName() == "" {
+		return fmt.Errorf("a data kind must have a name")
+	}
+	if payloadName == "" || strings.Contains(payloadName, "/") {
+		return fmt.Errorf("invalid payload name %q for data kind %q", payloadName, kind.GetName())
+	}
+	additionalDataKindsLock.Lock()
+	defer additionalDataKindsLock.Unlock()
+	for _, k := range builtInAndAdditionalDataKinds() {
+		if k.Kind.GetName() == kind.GetName() {
+			return fmt.Errorf("data kind %q is already registered", kind.GetName())
+		}
+		if k.PayloadName == payloadName {
+			return fmt.Errorf("payload name %q is already used by data kind %q", payloadName, k.Kind.GetName())
+		}
+	}
+	additionalDataKinds = append(additionalDataKinds, AdditionalDataKind{Kind: kind, PayloadName: payloadName})
+	return nil
+}
+
+// ResetAdditionalDataKinds removes all data kinds that were registered with RegisterAdditionalDataKind.
+// It is only for tests.
+func ResetAdditionalDataKinds() {
+	additionalDataKindsLock.Lock()
+	additionalDataKinds = nil
+	additionalDataKindsLock.Unlock()
+}
+
+// AdditionalDataKinds returns the data kinds that were registered with RegisterAdditionalDataKind, in the
+// order in which they were registered.
+func AdditionalDataKinds() []AdditionalDataKind {
+	additionalDataKindsLock.RLock()
+	defer additionalDataKindsLock.RUnlock()
+	return append([]AdditionalDataKind(nil), additionalDataKinds...)
+}
+
+// DataKindForPayloadName returns the data kind whose items are in the specified property of a full data
+// set, or in paths that start with that name: Features for "flags", Segments for "segments", or a kind
+// that was registered with RegisterAdditionalDataKind. It returns nil for any other name.
+func DataKindForPayloadName(payloadName string) ldstoretypes.DataKind {
+	additionalDataKindsLock.RLock()
+	defer additionalDataKindsLock.RUnlock()
+	for _, k := range builtInAndAdditionalDataKinds() {
+		if k.PayloadName == payloadName {
+			return k.Kind
+		}
+	}
+	return nil
+}
+
+// DeserializeFromJSONReader reads an item of any data kind. The built-in kinds read it directly; for
+// other kinds, the item is read as a generic value and passed to Deserialize.
+func DeserializeFromJSONReader(
+	kind ldstoretypes.DataKind,
+	reader *jreader.Reader,
+) (ldstoretypes.ItemDescriptor, error) {
+	if k, ok := kind.(DataKindInternal); ok {
+		return k.DeserializeFromJSONReader(reader)
+	}
+	var value ldvalue.Value
+	value.ReadFromJSONReader(reader)
+	if err := reader.Error(); err != nil {
+		return ldstoretypes.ItemDescriptor{}, err
+	}
+	item, err := kind.Deserialize([]byte(value.JSONString()))
+	if err != nil {
+		reader.AddError(err)
+	}
+	return item, err
+}
+
+// builtInAndAdditionalDataKinds must be called with the lock held.
+func builtInAndAdditionalDataKinds() []AdditionalDataKind {
+	return append([]AdditionalDataKind{
+		{Kind: Features, PayloadName: flagsPayloadName},
+		{Kind: Segments, PayloadName: segmentsPayloadName},
+	}, additionalDataKinds...)
+}
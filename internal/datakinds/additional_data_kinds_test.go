@@ -0,0 +1,105 @@
+package datakinds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jreader"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// testDataKind stores items as ldvalue.Value, and can't deserialize anything that isn't a JSON object.
+type testDataKind struct {
+	name string
+}
+
+func (k testDataKind) GetName() string { return k.name }
+
+func (k testDataKind) Serialize(item ldstoretypes.ItemDescriptor) []byte {
+	return []byte(item.Item.(ldvalue.Value).JSONString())
+}
+
+func (k testDataKind) Deserialize(data []byte) (ldstoretypes.ItemDescriptor, error) {
+	value := ldvalue.Parse(data)
+	if value.Type() != ldvalue.ObjectType {
+		return ldstoretypes.ItemDescriptor{}, errors.New("not an object")
+	}
+	return ldstoretypes.ItemDescriptor{Version: value.GetByKey("version").IntValue(), Item: value}, nil
+}
+
+func TestRegisterAdditionalDataKind(t *testing.T) {
+	t.Cleanup(ResetAdditionalDataKinds)
+	kind1, kind2 := testDataKind{"overrides"}, testDataKind{"metrics"}
+
+	require.NoError(t, RegisterAdditionalDataKind(kind1, "configOverrides"))
+	require.NoError(t, RegisterAdditionalDataKind(kind2, "metrics"))
+
+	assert.Equal(t, []ldstoretypes.DataKind{Features, Segments, kind1, kind2}, AllDataKinds())
+	assert.Equal(t, []AdditionalDataKind{{kind1, "configOverrides"}, {kind2, "metrics"}}, AdditionalDataKinds())
+	assert.Equal(t, Features, DataKindForPayloadName("flags"))
+	assert.Equal(t, Segments, DataKindForPayloadName("segments"))
+	assert.Equal(t, kind1, DataKindForPayloadName("configOverrides"))
+	assert.Nil(t, DataKindForPayloadName("overrides"))
+
+	ResetAdditionalDataKinds()
+	assert.Equal(t, []ldstoretypes.DataKind{Features, Segments}, AllDataKinds())
+	assert.Nil(t, DataKindForPayloadName("configOverrides"))
+}
+
+func TestRegisterAdditionalDataKindErrors(t *testing.T) {
+	t.Cleanup(ResetAdditionalDataKinds)
+	require.NoError(t, RegisterAdditionalDataKind(testDataKind{"overrides"}, "configOverrides"))
+
+	for name, params := range map[string]struct {
+		kind        ldstoretypes.DataKind
+		payloadName string
+	}{
+		"nil kind":                  {nil, "x"},
+		"empty name":                {testDataKind{""}, "x"},
+		"empty payload name":        {testDataKind{"x"}, ""},
+		"payload name with slash":   {testDataKind{"x"}, "a/b"},
+		"built-in name":             {testDataKind{"features"}, "x"},
+		"built-in payload name":     {testDataKind{"x"}, "segments"},
+		"already registered name":   {testDataKind{"overrides"}, "x"},
+		"already used payload name": {testDataKind{"x"}, "configOverrides"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, RegisterAdditionalDataKind(params.kind, params.payloadName))
+		})
+	}
+	assert.Len(t, AdditionalDataKinds(), 1)
+}
+
+func TestDeserializeFromJSONReader(t *testing.T) {
+	t.Run("built-in kind", func(t *testing.T) {
+		r := jreader.NewReader([]byte(`{"key": "flagkey", "version": 2}`))
+		item, err := DeserializeFromJSONReader(Features, &r)
+		require.NoError(t, err)
+		assert.Equal(t, 2, item.Version)
+	})
+
+	t.Run("other kind", func(t *testing.T) {
+		r := jreader.NewReader([]byte(`{"version": 3, "value": true}`))
+		item, err := DeserializeFromJSONReader(testDataKind{"overrides"}, &r)
+		require.NoError(t, err)
+		assert.Equal(t, 3, item.Version)
+		assert.Equal(t, ldvalue.Bool(true), item.Item.(ldvalue.Value).GetByKey("value"))
+	})
+
+	t.Run("other kind, malformed JSON", func(t *testing.T) {
+		r := jreader.NewReader([]byte(`{"version": `))
+		_, err := DeserializeFromJSONReader(testDataKind{"overrides"}, &r)
+		assert.Error(t, err)
+	})
+
+	t.Run("other kind, item that can't be deserialized", func(t *testing.T) {
+		r := jreader.NewReader([]byte(`[1, 2]`))
+		_, err := DeserializeFromJSONReader(testDataKind{"overrides"}, &r)
+		assert.Error(t, err)
+		assert.Error(t, r.Error())
+	})
+}
@@ -34,9 +34,14 @@ var Features DataKindInternal = featureFlagStoreDataKind{} //nolint:gochecknoglo
 // Segments is the global StoreDataKind instance for segments.
 var Segments DataKindInternal = segmentStoreDataKind{} //nolint:gochecknoglobals
 
-// AllDataKinds returns all the supported data StoreDataKinds.
+// AllDataKinds returns all the supported data StoreDataKinds: Features, Segments, and any that were
+// registered with RegisterAdditionalDataKind.
 func AllDataKinds() []ldstoretypes.DataKind {
-	return []ldstoretypes.DataKind{Features, Segments}
+	ret := []ldstoretypes.DataKind{Features, Segments}
+	for _, k := range AdditionalDataKinds() {
+		ret = append(ret, k.Kind)
+	}
+	return ret
 }
 
 // GetName returns the unique namespace identifier for feature flag objects.
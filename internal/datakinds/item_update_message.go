@@ -0,0 +1,61 @@
+package datakinds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// itemUpdateMessage is the JSON format of a single flag or segment update, as received by the data
+// sources that consume a message stream, such as ldkafka. It looks like this:
+//
+//	{"kind": "features", "key": "my-flag", "item": {"key": "my-flag", "version": 2, ...}}
+//
+// The kind is the name of a data kind. The item is in the format that Serialize produces, which is also
+// how persistent data stores such as DynamoDB store it; it can be either a JSON object or a string
+// containing one, as in the DynamoDB store's "item" attribute. A deleted item is the placeholder that
+// Serialize produces for it, such as {"key": "my-flag", "version": 3, "deleted": true}.
+type itemUpdateMessage struct {
+	Kind string          `json:"kind"`
+	Key  string          `json:"key"`
+	Item json.RawMessage `json:"item"`
+}
+
+// ParseItemUpdateMessage parses a message in the format described above.
+func ParseItemUpdateMessage(data []byte) (ldstoretypes.DataKind, string, ldstoretypes.ItemDescriptor, error) {
+	var m itemUpdateMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", ldstoretypes.ItemDescriptor{}, err
+	}
+	var kind ldstoretypes.DataKind
+	for _, k := range AllDataKinds() {
+		if k.GetName() == m.Kind {
+			kind = k
+		}
+	}
+	if kind == nil {
+		return nil, "", ldstoretypes.ItemDescriptor{}, fmt.Errorf("unknown data kind %q", m.Kind)
+	}
+	if m.Key == "" {
+		return nil, "", ldstoretypes.ItemDescriptor{}, errors.New("missing key")
+	}
+	itemData := []byte(m.Item)
+	if bytes.HasPrefix(itemData, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(itemData, &s); err != nil {
+			return nil, "", ldstoretypes.ItemDescriptor{}, err // COVERAGE: can't happen, it was valid JSON above
+		}
+		itemData = []byte(s)
+	}
+	if len(itemData) == 0 || string(itemData) == "null" {
+		return nil, "", ldstoretypes.ItemDescriptor{}, errors.New("missing item")
+	}
+	item, err := kind.Deserialize(itemData)
+	if err != nil {
+		return nil, "", ldstoretypes.ItemDescriptor{}, fmt.Errorf("invalid %s item %q: %w", kind, m.Key, err)
+	}
+	return kind, m.Key, item, nil
+}
@@ -0,0 +1,59 @@
+package datakinds
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseItemUpdateMessage(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(2).On(true).Build()
+	segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(3).Build()
+
+	t.Run("flag", func(t *testing.T) {
+		kind, key, item, err := ParseItemUpdateMessage([]byte(
+			`{"kind":"features","key":"flagkey","item":` + string(Features.Serialize(ldstoretypes.ItemDescriptor{Version: 2, Item: &flag})) + `}`))
+		require.NoError(t, err)
+		assert.Equal(t, Features, kind)
+		assert.Equal(t, "flagkey", key)
+		assert.Equal(t, 2, item.Version)
+		assert.Equal(t, &flag, item.Item)
+	})
+
+	t.Run("segment as a string", func(t *testing.T) {
+		kind, key, item, err := ParseItemUpdateMessage([]byte(
+			`{"kind":"segments","key":"segmentkey","item":"{\"key\":\"segmentkey\",\"version\":3}"}`))
+		require.NoError(t, err)
+		assert.Equal(t, Segments, kind)
+		assert.Equal(t, "segmentkey", key)
+		assert.Equal(t, 3, item.Version)
+		assert.Equal(t, segment.Key, item.Item.(*ldmodel.Segment).Key)
+	})
+
+	t.Run("deleted item", func(t *testing.T) {
+		_, key, item, err := ParseItemUpdateMessage([]byte(
+			`{"kind":"features","key":"flagkey","item":{"key":"flagkey","version":4,"deleted":true}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "flagkey", key)
+		assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 4}, item)
+	})
+
+	for name, message := range map[string]string{
+		"malformed JSON": `{"kind":`,
+		"unknown kind":   `{"kind":"widgets","key":"x","item":{"key":"x","version":1}}`,
+		"missing key":    `{"kind":"features","item":{"key":"x","version":1}}`,
+		"missing item":   `{"kind":"features","key":"x"}`,
+		"null item":      `{"kind":"features","key":"x","item":null}`,
+		"invalid item":   `{"kind":"features","key":"x","item":{"key":"x","version":"one"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, _, err := ParseItemUpdateMessage([]byte(message))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -3,73 +3,11 @@ package datasource
 import (
 	"sort"
 
-	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
-	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datadeps"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
-	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
-type kindAndKey struct {
-	kind st.DataKind
-	key  string
-}
-
-// This set type is implemented as a map, but the values do not matter, just the keys.
-type kindAndKeySet map[kindAndKey]bool
-
-func (s kindAndKeySet) add(value kindAndKey) {
-	s[value] = true
-}
-
-func (s kindAndKeySet) contains(value kindAndKey) bool {
-	_, ok := s[value]
-	return ok
-}
-
-func computeDependenciesFrom(kind st.DataKind, fromItem st.ItemDescriptor) kindAndKeySet {
-	// For any given flag or segment, find all the flags/segments that it directly references.
-	// Transitive references are handled by recursive logic at a higher level.
-	var ret kindAndKeySet
-	checkClauses := func(clauses []ldmodel.Clause) {
-		for _, c := range clauses {
-			if c.Op == ldmodel.OperatorSegmentMatch {
-				for _, v := range c.Values {
-					if v.Type() == ldvalue.StringType {
-						if ret == nil {
-							ret = make(kindAndKeySet)
-						}
-						ret.add(kindAndKey{datakinds.Segments, v.StringValue()})
-					}
-				}
-			}
-		}
-	}
-	switch kind {
-	case ldstoreimpl.Features():
-		if flag, ok := fromItem.Item.(*ldmodel.FeatureFlag); ok {
-			if len(flag.Prerequisites) > 0 {
-				ret = make(kindAndKeySet, len(flag.Prerequisites))
-				for _, p := range flag.Prerequisites {
-					ret.add(kindAndKey{ldstoreimpl.Features(), p.Key})
-				}
-			}
-			for _, r := range flag.Rules {
-				checkClauses(r.Clauses)
-			}
-			return ret
-		}
-
-	case ldstoreimpl.Segments():
-		if segment, ok := fromItem.Item.(*ldmodel.Segment); ok {
-			for _, r := range segment.Rules {
-				checkClauses(r.Clauses)
-			}
-		}
-	}
-	return ret
-}
-
 func sortCollectionsForDataStoreInit(allData []st.Collection) []st.Collection {
 	colls := make([]st.Collection, 0, len(allData))
 	for _, coll := range allData {
@@ -117,10 +55,10 @@ func addWithDependenciesFirst(
 ) {
 	startItem := remainingItems[startingKey]
 	delete(remainingItems, startingKey) // we won't need to visit this item again
-	for dep := range computeDependenciesFrom(kind, startItem) {
-		if dep.kind == kind {
-			if _, ok := remainingItems[dep.key]; ok {
-				addWithDependenciesFirst(kind, dep.key, remainingItems, out)
+	for dep := range datadeps.ComputeDependenciesFrom(kind, startItem) {
+		if dep.Kind == kind {
+			if _, ok := remainingItems[dep.Key]; ok {
+				addWithDependenciesFirst(kind, dep.Key, remainingItems, out)
 			}
 		}
 	}
@@ -139,58 +77,3 @@ func dataKindPriority(kind st.DataKind) int {
 		return len(kind.GetName()) + 2
 	}
 }
-
-// Maintains a bidirectional dependency graph that can be updated whenever an item has changed.
-type dependencyTracker struct {
-	dependenciesFrom map[kindAndKey]kindAndKeySet
-	dependenciesTo   map[kindAndKey]kindAndKeySet
-}
-
-func newDependencyTracker() *dependencyTracker {
-	return &dependencyTracker{make(map[kindAndKey]kindAndKeySet), make(map[kindAndKey]kindAndKeySet)}
-}
-
-// Updates the dependency graph when an item has changed.
-func (d *dependencyTracker) updateDependenciesFrom(
-	kind st.DataKind,
-	fromKey string,
-	fromItem st.ItemDescriptor,
-) {
-	fromWhat := kindAndKey{kind, fromKey}
-	updatedDependencies := computeDependenciesFrom(kind, fromItem)
-
-	oldDependencySet := d.dependenciesFrom[fromWhat]
-	for oldDep := range oldDependencySet {
-		depsToThisOldDep := d.dependenciesTo[oldDep]
-		if depsToThisOldDep != nil {
-			delete(depsToThisOldDep, fromWhat)
-		}
-	}
-
-	d.dependenciesFrom[fromWhat] = updatedDependencies
-	for newDep := range updatedDependencies {
-		depsToThisNewDep := d.dependenciesTo[newDep]
-		if depsToThisNewDep == nil {
-			depsToThisNewDep = make(kindAndKeySet)
-			d.dependenciesTo[newDep] = depsToThisNewDep
-		}
-		depsToThisNewDep.add(fromWhat)
-	}
-}
-
-func (d *dependencyTracker) reset() {
-	d.dependenciesFrom = make(map[kindAndKey]kindAndKeySet)
-	d.dependenciesTo = make(map[kindAndKey]kindAndKeySet)
-}
-
-// Populates the given set with the union of the initial item and all items that directly or indirectly
-// depend on it (based on the current state of the dependency graph).
-func (d *dependencyTracker) addAffectedItems(itemsOut kindAndKeySet, initialModifiedItem kindAndKey) {
-	if !itemsOut.contains(initialModifiedItem) {
-		itemsOut.add(initialModifiedItem)
-		affectedItems := d.dependenciesTo[initialModifiedItem]
-		for affectedItem := range affectedItems {
-			d.addAffectedItems(itemsOut, affectedItem)
-		}
-	}
-}
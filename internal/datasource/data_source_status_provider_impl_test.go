@@ -4,8 +4,9 @@ import (
 	"testing"
 	"time"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	intf "github.com/launchdarkly/go-server-sdk/v7/interfaces"
@@ -60,11 +61,9 @@ func TestDataSourceStatusProviderImpl(t *testing.T) {
 			errorInfo := makeDataSourceErrorInfo()
 			p.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateOff, errorInfo)
 
-			require.Len(t, ch1, 1)
-			require.Len(t, ch2, 0)
-			require.Len(t, ch3, 1)
-			status1 := <-ch1
-			status3 := <-ch3
+			status1 := th.RequireValue(t, ch1, time.Second)
+			status3 := th.RequireValue(t, ch3, time.Second)
+			th.AssertChannelClosed(t, ch2, time.Millisecond)
 			assert.Equal(t, intf.DataSourceStateOff, status1.State)
 			assert.Equal(t, errorInfo, status1.LastError)
 			assert.Equal(t, status1, status3)
@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	intf "github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datadeps"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
@@ -21,14 +23,59 @@ type DataSourceUpdateSinkImpl struct {
 	dataStoreStatusProvider     intf.DataStoreStatusProvider
 	dataSourceStatusBroadcaster *internal.Broadcaster[intf.DataSourceStatus]
 	flagChangeEventBroadcaster  *internal.Broadcaster[intf.FlagChangeEvent]
-	dependencyTracker           *dependencyTracker
+	dependencyTracker           *datadeps.DependencyTracker
 	outageTracker               *outageTracker
 	loggers                     ldlog.Loggers
 	currentStatus               intf.DataSourceStatus
+	statusChangedCh             chan struct{} // closed and replaced whenever currentStatus changes
+	statusReporting             statusReportingTracker
 	lastStoreUpdateFailed       bool
+	lastSuccessfulUpdate        time.Time
+	dataUpdateListener          intf.DataUpdateListener
+	dataUpdateSource            intf.DataUpdateSource
+	invalidateFlag              func(flagKey string)
+	invalidateAllFlags          func()
+	rejectedUpdates             rejectedUpdateTracker
 	lock                        sync.Mutex
 }
 
+// rejectedUpdateRecorder is implemented by the SDK's DataStoreStatusProvider, which counts rejected updates.
+type rejectedUpdateRecorder interface {
+	RecordRejectedUpdate()
+}
+
+// dataStoreInitializedChecker is implemented by DataSourceUpdateSinkImpl, for data sources that can use data
+// that was already in the data store before they started.
+type dataStoreInitializedChecker interface {
+	IsDataStoreInitialized() bool
+}
+
+// rejectedUpdateTracker counts the updates that the data store ignored because it already had an equal or
+// newer version, in fixed windows of time, so that warnings can be rate-limited and a high rate can be
+// reported as a data source error. It is protected by the DataSourceUpdateSinkImpl's lock.
+type rejectedUpdateTracker struct {
+	window         time.Duration
+	errorThreshold int
+	windowStart    time.Time
+	windowCount    int
+	loggedInWindow bool
+	notLogged      int
+}
+
+// statusReportingTracker decides which status changes are broadcast to status listeners, so that a data
+// source whose connection is flapping does not flood them with notifications. currentStatus is always
+// updated; only the broadcasts are skipped. With the zero values of coalescingWindow and interruptedDelay,
+// every change is broadcast. It is protected by the DataSourceUpdateSinkImpl's lock.
+type statusReportingTracker struct {
+	coalescingWindow    time.Duration
+	interruptedDelay    time.Duration
+	reportedState       intf.DataSourceState // the state in the last status that was broadcast
+	reportedAt          time.Time
+	pendingInterruption *time.Timer
+	pendingSeq          int  // identifies the current pendingInterruption, in case its timer has already fired
+	closed              bool // nothing is broadcast once the sink is closed
+}
+
 // NewDataSourceUpdateSinkImpl creates the internal implementation of DataSourceUpdateSink.
 func NewDataSourceUpdateSinkImpl(
 	store subsystems.DataStore,
@@ -43,21 +90,68 @@ func NewDataSourceUpdateSinkImpl(
 		dataStoreStatusProvider:     dataStoreStatusProvider,
 		dataSourceStatusBroadcaster: dataSourceStatusBroadcaster,
 		flagChangeEventBroadcaster:  flagChangeEventBroadcaster,
-		dependencyTracker:           newDependencyTracker(),
+		dependencyTracker:           datadeps.NewDependencyTracker(),
 		outageTracker:               newOutageTracker(logDataSourceOutageAsErrorAfter, loggers),
 		loggers:                     loggers,
 		currentStatus: intf.DataSourceStatus{
 			State:      intf.DataSourceStateInitializing,
 			StateSince: time.Now(),
 		},
+		statusChangedCh: make(chan struct{}),
+		statusReporting: statusReportingTracker{reportedState: intf.DataSourceStateInitializing},
+		rejectedUpdates: rejectedUpdateTracker{window: time.Minute},
 	}
 }
 
+// SetDataUpdateListener specifies a listener to be told about every item that is changed by Init or Upsert.
+// This must be called before the data source is started.
+func (d *DataSourceUpdateSinkImpl) SetDataUpdateListener(
+	listener intf.DataUpdateListener,
+	source intf.DataUpdateSource,
+) {
+	d.dataUpdateListener = listener
+	d.dataUpdateSource = source
+}
+
+// SetFlagInvalidationHandlers specifies functions to be called, before Init or Upsert returns, for the
+// flags that the update may have changed: invalidateFlag for each flag affected by an Upsert, including
+// flags that depend on the updated item, and invalidateAllFlags after Init. Unlike flag change events, these
+// calls are never dropped or delayed, so they can be used to keep a cache consistent with the data store.
+// This must be called before the data source is started.
+func (d *DataSourceUpdateSinkImpl) SetFlagInvalidationHandlers(invalidateFlag func(string), invalidateAllFlags func()) {
+	d.invalidateFlag = invalidateFlag
+	d.invalidateAllFlags = invalidateAllFlags
+}
+
+// SetRejectedUpdatesErrorThreshold specifies how many rejected updates in one minute will cause the data
+// source status to be reported as interrupted, with the error kind DataSourceErrorKindRejectedUpdates.
+// Zero, the default, means they are never reported as an error. This must be called before the data
+// source is started.
+func (d *DataSourceUpdateSinkImpl) SetRejectedUpdatesErrorThreshold(threshold int) {
+	d.rejectedUpdates.errorThreshold = threshold
+}
+
+// SetStatusCoalescingWindow specifies how long after a status is broadcast to status listeners a change
+// that leaves the state the same, such as a new error while the state is already interrupted, is not
+// broadcast. Zero, the default, means that every change is broadcast. This must be called before the data
+// source is started.
+func (d *DataSourceUpdateSinkImpl) SetStatusCoalescingWindow(window time.Duration) {
+	d.statusReporting.coalescingWindow = window
+}
+
+// SetInterruptedReportingDelay specifies how long the state must stay interrupted before that is broadcast
+// to status listeners. If the data source becomes valid again sooner, neither change is broadcast. Zero,
+// the default, means that interruptions are broadcast immediately. This must be called before the data
+// source is started.
+func (d *DataSourceUpdateSinkImpl) SetInterruptedReportingDelay(delay time.Duration) {
+	d.statusReporting.interruptedDelay = delay
+}
+
 //nolint:revive // no doc comment for standard method
 func (d *DataSourceUpdateSinkImpl) Init(allData []st.Collection) bool {
 	var oldData map[st.DataKind]map[string]st.ItemDescriptor
 
-	if d.flagChangeEventBroadcaster.HasListeners() {
+	if d.flagChangeEventBroadcaster.HasListeners() || d.dataUpdateListener != nil {
 		// Query the existing data if any, so that after the update we can send events for whatever was changed
 		oldData = make(map[st.DataKind]map[string]st.ItemDescriptor)
 		for _, kind := range datakinds.AllDataKinds() {
@@ -72,6 +166,9 @@ func (d *DataSourceUpdateSinkImpl) Init(allData []st.Collection) bool {
 	}
 
 	err := d.store.Init(sortCollectionsForDataStoreInit(allData))
+	if d.invalidateAllFlags != nil {
+		d.invalidateAllFlags() // even if Init failed, the store may have some of the new data
+	}
 	updated := d.maybeUpdateError(err)
 
 	if updated {
@@ -82,7 +179,13 @@ func (d *DataSourceUpdateSinkImpl) Init(allData []st.Collection) bool {
 		// Now, if we previously queried the old data because someone is listening for flag change events, compare
 		// the versions of all items and generate events for those (and any other items that depend on them)
 		if oldData != nil {
-			d.sendChangeEvents(d.computeChangedItemsForFullDataSet(oldData, fullDataSetToMap(allData)))
+			newData := fullDataSetToMap(allData)
+			if d.flagChangeEventBroadcaster.HasListeners() {
+				d.sendChangeEvents(d.computeChangedItemsForFullDataSet(oldData, newData))
+			}
+			if d.dataUpdateListener != nil {
+				d.sendDataUpdateRecordsForFullDataSet(oldData, newData)
+			}
 		}
 	}
 
@@ -95,26 +198,125 @@ func (d *DataSourceUpdateSinkImpl) Upsert(
 	key string,
 	item st.ItemDescriptor,
 ) bool {
+	var oldVersion ldvalue.OptionalInt
+	if d.dataUpdateListener != nil {
+		if oldItem, err := d.store.Get(kind, key); err == nil && oldItem.Version != -1 {
+			oldVersion = ldvalue.NewOptionalInt(oldItem.Version)
+		}
+	}
+
 	updated, err := d.store.Upsert(kind, key, item)
 	didNotGetError := d.maybeUpdateError(err)
 
 	if updated {
-		d.dependencyTracker.updateDependenciesFrom(kind, key, item)
-		if d.flagChangeEventBroadcaster.HasListeners() {
-			affectedItems := make(kindAndKeySet)
-			d.dependencyTracker.addAffectedItems(affectedItems, kindAndKey{kind, key})
-			d.sendChangeEvents(affectedItems)
+		d.dependencyTracker.UpdateDependenciesFrom(kind, key, item)
+		if d.flagChangeEventBroadcaster.HasListeners() || d.invalidateFlag != nil {
+			affectedItems := make(datadeps.KindAndKeySet)
+			d.dependencyTracker.AddAffectedItems(affectedItems, datadeps.KindAndKey{Kind: kind, Key: key})
+			if d.invalidateFlag != nil {
+				for item := range affectedItems {
+					if item.Kind == datakinds.Features {
+						d.invalidateFlag(item.Key)
+					}
+				}
+			}
+			if d.flagChangeEventBroadcaster.HasListeners() {
+				d.sendChangeEvents(affectedItems)
+			}
+		}
+		if d.dataUpdateListener != nil {
+			d.sendDataUpdateRecord(intf.DataUpdateRecord{
+				Kind:       kind.GetName(),
+				Key:        key,
+				OldVersion: oldVersion,
+				NewVersion: ldvalue.NewOptionalInt(item.Version),
+				Deleted:    item.Item == nil,
+				Operation:  intf.DataUpdateOperationUpsert,
+			})
 		}
+		d.maybeClearRejectedUpdatesError()
+	} else if err == nil {
+		d.recordRejectedUpdate(kind, key, item)
 	}
 
 	return didNotGetError
 }
 
+func (d *DataSourceUpdateSinkImpl) recordRejectedUpdate(kind st.DataKind, key string, item st.ItemDescriptor) {
+	if recorder, ok := d.dataStoreStatusProvider.(rejectedUpdateRecorder); ok {
+		recorder.RecordRejectedUpdate()
+	}
+
+	now := time.Now()
+	d.lock.Lock()
+	t := &d.rejectedUpdates
+	if now.Sub(t.windowStart) >= t.window {
+		t.windowStart = now
+		t.windowCount = 0
+		t.loggedInWindow = false
+	}
+	t.windowCount++
+	shouldLog := !t.loggedInWindow
+	notLogged := t.notLogged
+	if shouldLog {
+		t.loggedInWindow = true
+		t.notLogged = 0
+	} else {
+		t.notLogged++
+	}
+	windowCount, window := t.windowCount, t.window
+	shouldReportError := t.errorThreshold > 0 && windowCount == t.errorThreshold+1
+	d.lock.Unlock()
+
+	if shouldLog {
+		existingVersion := "an equal or newer version"
+		if existingItem, err := d.store.Get(kind, key); err == nil && existingItem.Version >= 0 {
+			existingVersion = fmt.Sprintf("version %d", existingItem.Version)
+		}
+		message := fmt.Sprintf(
+			"Data source sent an update for %s %q with version %d, but the data store already has %s; the update was ignored",
+			kind, key, item.Version, existingVersion)
+		if notLogged > 0 {
+			message += fmt.Sprintf(" (%d other updates were also ignored since the last warning)", notLogged)
+		}
+		d.loggers.Warn(message)
+	}
+	if shouldReportError {
+		d.UpdateStatus(
+			intf.DataSourceStateInterrupted,
+			intf.DataSourceErrorInfo{
+				Kind:    intf.DataSourceErrorKindRejectedUpdates,
+				Message: fmt.Sprintf("data store ignored %d updates within %s because it had newer versions", windowCount, window),
+				Time:    now,
+			},
+		)
+	}
+}
+
+// maybeClearRejectedUpdatesError sets the status back to valid, after an update has been accepted, if the
+// status was set to interrupted by recordRejectedUpdate and rejected updates are no longer over the
+// threshold.
+func (d *DataSourceUpdateSinkImpl) maybeClearRejectedUpdatesError() {
+	d.lock.Lock()
+	status := d.currentStatus
+	t := d.rejectedUpdates
+	d.lock.Unlock()
+	if status.State != intf.DataSourceStateInterrupted ||
+		status.LastError.Kind != intf.DataSourceErrorKindRejectedUpdates {
+		return
+	}
+	if time.Since(t.windowStart) < t.window && t.windowCount > t.errorThreshold {
+		return
+	}
+	d.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+}
+
 func (d *DataSourceUpdateSinkImpl) maybeUpdateError(err error) bool {
 	if err == nil {
 		d.lock.Lock()
 		defer d.lock.Unlock()
 		d.lastStoreUpdateFailed = false
+		d.lastSuccessfulUpdate = time.Now()
 		return true
 	}
 
@@ -147,7 +349,7 @@ func (d *DataSourceUpdateSinkImpl) UpdateStatus(
 	if newState == "" {
 		return
 	}
-	if statusToBroadcast, changed := d.maybeUpdateStatus(newState, newError); changed {
+	if statusToBroadcast, shouldReport := d.maybeUpdateStatus(newState, newError); shouldReport {
 		d.dataSourceStatusBroadcaster.Broadcast(statusToBroadcast)
 	}
 }
@@ -161,8 +363,9 @@ func (d *DataSourceUpdateSinkImpl) maybeUpdateStatus(
 
 	oldStatus := d.currentStatus
 
-	if newState == intf.DataSourceStateInterrupted && oldStatus.State == intf.DataSourceStateInitializing {
-		newState = intf.DataSourceStateInitializing // see comment on DataSourceUpdateSink.UpdateStatus
+	if newState == intf.DataSourceStateInterrupted && (oldStatus.State == intf.DataSourceStateInitializing ||
+		oldStatus.State == intf.DataSourceStateInitializedFromStore) {
+		newState = oldStatus.State // see comment on DataSourceUpdateSink.UpdateStatus
 	}
 
 	if newState == oldStatus.State && newError.Kind == "" {
@@ -183,9 +386,82 @@ func (d *DataSourceUpdateSinkImpl) maybeUpdateStatus(
 		LastError:  lastError,
 	}
 
+	close(d.statusChangedCh)
+	d.statusChangedCh = make(chan struct{})
+
 	d.outageTracker.trackDataSourceState(newState, newError)
 
-	return d.currentStatus, true
+	return d.currentStatus, d.shouldReportStatus()
+}
+
+// shouldReportStatus is called with the lock held, after currentStatus has changed, to decide whether to
+// broadcast it.
+func (d *DataSourceUpdateSinkImpl) shouldReportStatus() bool {
+	r := &d.statusReporting
+	if r.closed {
+		return false
+	}
+	state := d.currentStatus.State
+	if state == intf.DataSourceStateInterrupted && r.reportedState != intf.DataSourceStateInterrupted &&
+		r.interruptedDelay > 0 {
+		if r.pendingInterruption == nil {
+			r.pendingSeq++
+			seq := r.pendingSeq
+			r.pendingInterruption = time.AfterFunc(r.interruptedDelay, func() { d.reportPendingInterruption(seq) })
+		}
+		return false
+	}
+	if r.pendingInterruption != nil {
+		r.pendingInterruption.Stop()
+		r.pendingInterruption = nil
+		if state == r.reportedState {
+			// The listeners never heard about the interruption, so they don't need to hear that it is over.
+			return false
+		}
+	}
+	now := time.Now()
+	if state == r.reportedState && now.Sub(r.reportedAt) < r.coalescingWindow {
+		return false
+	}
+	r.reportedState = state
+	r.reportedAt = now
+	return true
+}
+
+func (d *DataSourceUpdateSinkImpl) reportPendingInterruption(seq int) {
+	d.lock.Lock()
+	r := &d.statusReporting
+	if r.pendingInterruption == nil || r.pendingSeq != seq {
+		// COVERAGE: there is no way to make this happen in unit tests; it is a very unlikely race condition
+		d.lock.Unlock()
+		return
+	}
+	r.pendingInterruption = nil
+	r.reportedState = d.currentStatus.State
+	r.reportedAt = time.Now()
+	status := d.currentStatus
+	d.lock.Unlock()
+	d.dataSourceStatusBroadcaster.Broadcast(status)
+}
+
+// Close stops the timer for an interruption that has not been broadcast yet, if any, and stops any later
+// status changes from being broadcast. This is called when the client is closed, after the data source has
+// been closed; currentStatus is still updated, in case the data source reports something while closing.
+func (d *DataSourceUpdateSinkImpl) Close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	r := &d.statusReporting
+	r.closed = true
+	if r.pendingInterruption != nil {
+		r.pendingInterruption.Stop()
+		r.pendingInterruption = nil
+	}
+}
+
+// IsDataStoreInitialized returns true if the data store has been initialized, either by this data source
+// or, for a persistent data store, by an earlier process.
+func (d *DataSourceUpdateSinkImpl) IsDataStoreInitialized() bool {
+	return d.store.IsInitialized()
 }
 
 //nolint:revive // no doc comment for standard method
@@ -200,54 +476,107 @@ func (d *DataSourceUpdateSinkImpl) GetLastStatus() intf.DataSourceStatus {
 	return d.currentStatus
 }
 
-func (d *DataSourceUpdateSinkImpl) waitFor(desiredState intf.DataSourceState, timeout time.Duration) bool {
+// GetLastSuccessfulUpdateTime returns the time when Init or Upsert last stored data without an error, or
+// the zero value if that has never happened. An Upsert that the store ignored because it already had a
+// newer version still counts, since the data source did receive it.
+func (d *DataSourceUpdateSinkImpl) GetLastSuccessfulUpdateTime() time.Time {
 	d.lock.Lock()
-	if d.currentStatus.State == desiredState {
-		d.lock.Unlock()
-		return true
-	}
-	if d.currentStatus.State == intf.DataSourceStateOff {
-		d.lock.Unlock()
-		return false
-	}
-
-	statusCh := d.dataSourceStatusBroadcaster.AddListener()
-	defer d.dataSourceStatusBroadcaster.RemoveListener(statusCh)
-	d.lock.Unlock()
+	defer d.lock.Unlock()
+	return d.lastSuccessfulUpdate
+}
 
+// waitFor uses statusChangedCh rather than a status listener, because some status changes may not be
+// broadcast; see statusReportingTracker.
+func (d *DataSourceUpdateSinkImpl) waitFor(desiredState intf.DataSourceState, timeout time.Duration) bool {
 	var deadline <-chan time.Time
 	if timeout > 0 {
 		deadline = time.After(timeout)
 	}
 
 	for {
+		d.lock.Lock()
+		state, changedCh := d.currentStatus.State, d.statusChangedCh
+		d.lock.Unlock()
+		if state == desiredState {
+			return true
+		}
+		if state == intf.DataSourceStateOff {
+			return false
+		}
 		select {
-		case newStatus := <-statusCh:
-			if newStatus.State == desiredState {
-				return true
-			}
-			if newStatus.State == intf.DataSourceStateOff {
-				return false
-			}
+		case <-changedCh:
 		case <-deadline:
 			return false
 		}
 	}
 }
 
-func (d *DataSourceUpdateSinkImpl) sendChangeEvents(affectedItems kindAndKeySet) {
+func (d *DataSourceUpdateSinkImpl) sendChangeEvents(affectedItems datadeps.KindAndKeySet) {
 	for item := range affectedItems {
-		if item.kind == datakinds.Features {
-			d.flagChangeEventBroadcaster.Broadcast(intf.FlagChangeEvent{Key: item.key})
+		if item.Kind == datakinds.Features {
+			d.flagChangeEventBroadcaster.Broadcast(intf.FlagChangeEvent{Key: item.Key})
+		}
+	}
+}
+
+func (d *DataSourceUpdateSinkImpl) sendDataUpdateRecordsForFullDataSet(
+	oldDataMap map[st.DataKind]map[string]st.ItemDescriptor,
+	newDataMap map[st.DataKind]map[string]st.ItemDescriptor,
+) {
+	for _, kind := range datakinds.AllDataKinds() {
+		oldItems := oldDataMap[kind]
+		newItems := newDataMap[kind]
+		for key, newItem := range newItems {
+			oldItem, haveOld := oldItems[key]
+			if haveOld && oldItem.Version == newItem.Version {
+				continue
+			}
+			record := intf.DataUpdateRecord{
+				Kind:       kind.GetName(),
+				Key:        key,
+				NewVersion: ldvalue.NewOptionalInt(newItem.Version),
+				Deleted:    newItem.Item == nil,
+				Operation:  intf.DataUpdateOperationInit,
+			}
+			if haveOld {
+				record.OldVersion = ldvalue.NewOptionalInt(oldItem.Version)
+			}
+			d.sendDataUpdateRecord(record)
+		}
+		for key, oldItem := range oldItems {
+			if _, haveNew := newItems[key]; !haveNew && oldItem.Item != nil {
+				d.sendDataUpdateRecord(intf.DataUpdateRecord{
+					Kind:       kind.GetName(),
+					Key:        key,
+					OldVersion: ldvalue.NewOptionalInt(oldItem.Version),
+					Deleted:    true,
+					Operation:  intf.DataUpdateOperationInit,
+				})
+			}
+		}
+	}
+}
+
+// sendDataUpdateRecord is called on the data source's goroutine, so a panic in the listener is logged
+// rather than allowed to stop the data source.
+func (d *DataSourceUpdateSinkImpl) sendDataUpdateRecord(record intf.DataUpdateRecord) {
+	record.Time = time.Now()
+	record.Source = d.dataUpdateSource
+	defer func() {
+		if r := recover(); r != nil {
+			d.loggers.Errorf("Unexpected panic in data update listener for %s %q: %v", record.Kind, record.Key, r)
 		}
+	}()
+	if err := d.dataUpdateListener.OnDataUpdate(record); err != nil {
+		d.loggers.Warnf("Data update listener returned an error for %s %q: %s", record.Kind, record.Key, err)
 	}
 }
 
 func (d *DataSourceUpdateSinkImpl) updateDependencyTrackerFromFullDataSet(allData []st.Collection) {
-	d.dependencyTracker.reset()
+	d.dependencyTracker.Reset()
 	for _, coll := range allData {
 		for _, item := range coll.Items {
-			d.dependencyTracker.updateDependenciesFrom(coll.Kind, item.Key, item.Item)
+			d.dependencyTracker.UpdateDependenciesFrom(coll.Kind, item.Key, item.Item)
 		}
 	}
 }
@@ -267,8 +596,8 @@ func fullDataSetToMap(allData []st.Collection) map[st.DataKind]map[string]st.Ite
 func (d *DataSourceUpdateSinkImpl) computeChangedItemsForFullDataSet(
 	oldDataMap map[st.DataKind]map[string]st.ItemDescriptor,
 	newDataMap map[st.DataKind]map[string]st.ItemDescriptor,
-) kindAndKeySet {
-	affectedItems := make(kindAndKeySet)
+) datadeps.KindAndKeySet {
+	affectedItems := make(datadeps.KindAndKeySet)
 	for _, kind := range datakinds.AllDataKinds() {
 		oldItems := oldDataMap[kind]
 		newItems := newDataMap[kind]
@@ -286,7 +615,7 @@ func (d *DataSourceUpdateSinkImpl) computeChangedItemsForFullDataSet(
 			newItem, haveNew := newItems[key]
 			if haveOld || haveNew {
 				if !haveOld || !haveNew || oldItem.Version < newItem.Version {
-					d.dependencyTracker.addAffectedItems(affectedItems, kindAndKey{kind, key})
+					d.dependencyTracker.AddAffectedItems(affectedItems, datadeps.KindAndKey{Kind: kind, Key: key})
 				}
 			}
 		}
@@ -319,7 +648,8 @@ func (o *outageTracker) trackDataSourceState(newState intf.DataSourceState, newE
 	defer o.lock.Unlock()
 
 	if newState == intf.DataSourceStateInterrupted || newError.Kind != "" ||
-		(newState == intf.DataSourceStateInitializing && o.inOutage) {
+		((newState == intf.DataSourceStateInitializing || newState == intf.DataSourceStateInitializedFromStore) &&
+			o.inOutage) {
 		// We are in a potentially recoverable outage. If that wasn't the case already, and if we've been
 		// configured with a timeout for logging the outage at a higher level, schedule that timeout.
 		if o.inOutage {
@@ -11,7 +11,9 @@ import (
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	intf "github.com/launchdarkly/go-server-sdk/v7/interfaces"
@@ -19,6 +21,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 
 	th "github.com/launchdarkly/go-test-helpers/v3"
@@ -136,7 +139,8 @@ func TestDataSourceUpdateSinkImpl(t *testing.T) {
 
 				// does log the message again if there's another failure later after a success
 				p.store.SetFakeError(nil)
-				assert.True(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, itemDesc))
+				flagV2 := ldbuilders.NewFlagBuilder("key").Version(2).Build()
+				assert.True(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flagV2)))
 				p.store.SetFakeError(storeError)
 				assert.False(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, itemDesc))
 				log3 := p.mockLoggers.GetOutput(ldlog.Warn)
@@ -145,6 +149,26 @@ func TestDataSourceUpdateSinkImpl(t *testing.T) {
 		})
 	})
 
+	t.Run("GetLastSuccessfulUpdateTime", func(t *testing.T) {
+		dataSourceUpdateSinkImplTest(func(p dataSourceUpdateSinkImplTestParams) {
+			assert.True(t, p.dataSourceUpdates.GetLastSuccessfulUpdateTime().IsZero())
+
+			before := time.Now()
+			require.True(t, p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().Build()))
+			initTime := p.dataSourceUpdates.GetLastSuccessfulUpdateTime()
+			assert.False(t, initTime.Before(before))
+
+			p.store.SetFakeError(storeError)
+			flag := ldbuilders.NewFlagBuilder("key").Version(1).Build()
+			assert.False(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag)))
+			assert.Equal(t, initTime, p.dataSourceUpdates.GetLastSuccessfulUpdateTime())
+
+			p.store.SetFakeError(nil)
+			assert.True(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag)))
+			assert.False(t, p.dataSourceUpdates.GetLastSuccessfulUpdateTime().Before(initTime))
+		})
+	})
+
 	t.Run("UpdateStatus", func(t *testing.T) {
 		// broadcaster behavior is covered by DataSourceStatusProviderImpl tests
 
@@ -217,6 +241,22 @@ func TestDataSourceUpdateSinkImpl(t *testing.T) {
 			})
 		})
 
+		t.Run("InitializedFromStore is used instead of Interrupted before first data", func(t *testing.T) {
+			dataSourceUpdateSinkImplTest(func(p dataSourceUpdateSinkImplTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInitializedFromStore, intf.DataSourceErrorInfo{})
+
+				errorInfo := intf.DataSourceErrorInfo{Kind: intf.DataSourceErrorKindNetworkError}
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, errorInfo)
+				status1 := p.dataSourceUpdates.currentStatus
+				assert.Equal(t, intf.DataSourceStateInitializedFromStore, status1.State)
+				assert.Equal(t, errorInfo, status1.LastError)
+
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				status2 := p.dataSourceUpdates.currentStatus
+				assert.Equal(t, intf.DataSourceStateValid, status2.State)
+			})
+		})
+
 		t.Run("can log outage at Error level after timeout", TestDataSourceOutageLoggingTimeout)
 	})
 
@@ -348,6 +388,168 @@ func TestDataSourceUpdatesImplFlagChangeEvents(t *testing.T) {
 	})
 }
 
+func TestDataSourceUpdatesImplFlagInvalidationHandlers(t *testing.T) {
+	withHandlers := func(action func(p dataSourceUpdateSinkImplTestParams, invalidated *[]string)) {
+		dataSourceUpdateSinkImplTest(func(p dataSourceUpdateSinkImplTestParams) {
+			var invalidated []string
+			p.dataSourceUpdates.SetFlagInvalidationHandlers(
+				func(key string) { invalidated = append(invalidated, key) },
+				func() { invalidated = append(invalidated, "*") },
+			)
+			action(p, &invalidated)
+		})
+	}
+
+	t.Run("invalidates all flags on init", func(t *testing.T) {
+		withHandlers(func(p dataSourceUpdateSinkImplTestParams, invalidated *[]string) {
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().
+				Flags(ldbuilders.NewFlagBuilder("flag1").Version(1).Build()).Build())
+
+			assert.Equal(t, []string{"*"}, *invalidated)
+		})
+	})
+
+	t.Run("invalidates updated flag and flags that depend on it, without listeners", func(t *testing.T) {
+		withHandlers(func(p dataSourceUpdateSinkImplTestParams, invalidated *[]string) {
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().Flags(
+				ldbuilders.NewFlagBuilder("flag1").Version(1).Build(),
+				ldbuilders.NewFlagBuilder("flag2").Version(1).AddPrerequisite("flag1", 0).Build(),
+				ldbuilders.NewFlagBuilder("flag3").Version(1).Build(),
+			).Segments(ldbuilders.NewSegmentBuilder("segment1").Version(1).Build()).Build())
+			*invalidated = nil
+
+			flag1 := ldbuilders.NewFlagBuilder("flag1").Version(2).Build()
+			p.dataSourceUpdates.Upsert(datakinds.Features, flag1.Key, st.ItemDescriptor{Version: flag1.Version, Item: &flag1})
+			assert.ElementsMatch(t, []string{"flag1", "flag2"}, *invalidated)
+
+			*invalidated = nil
+			segment1 := ldbuilders.NewSegmentBuilder("segment1").Version(2).Build()
+			p.dataSourceUpdates.Upsert(datakinds.Segments, segment1.Key,
+				st.ItemDescriptor{Version: segment1.Version, Item: &segment1})
+			assert.Len(t, *invalidated, 0) // no flag uses the segment
+		})
+	})
+
+	t.Run("does not invalidate if item was not really updated", func(t *testing.T) {
+		withHandlers(func(p dataSourceUpdateSinkImplTestParams, invalidated *[]string) {
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().
+				Flags(ldbuilders.NewFlagBuilder("flag1").Version(1).Build()).Build())
+			*invalidated = nil
+
+			flag1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+			p.dataSourceUpdates.Upsert(datakinds.Features, flag1.Key, st.ItemDescriptor{Version: flag1.Version, Item: &flag1})
+			assert.Len(t, *invalidated, 0)
+		})
+	})
+}
+
+type recordingDataUpdateListener struct {
+	records  []intf.DataUpdateRecord
+	err      error
+	panicKey string
+}
+
+func (r *recordingDataUpdateListener) OnDataUpdate(record intf.DataUpdateRecord) error {
+	record.Time = time.Time{}
+	r.records = append(r.records, record)
+	if record.Key == r.panicKey {
+		panic("sorry")
+	}
+	return r.err
+}
+
+func TestDataSourceUpdatesImplDataUpdateListener(t *testing.T) {
+	withListener := func(action func(dataSourceUpdateSinkImplTestParams, *recordingDataUpdateListener)) {
+		dataSourceUpdateSinkImplTest(func(p dataSourceUpdateSinkImplTestParams) {
+			listener := &recordingDataUpdateListener{}
+			p.dataSourceUpdates.SetDataUpdateListener(listener, intf.DataUpdateSourceStreaming)
+			action(p, listener)
+		})
+	}
+
+	t.Run("reports changed items on init", func(t *testing.T) {
+		withListener(func(p dataSourceUpdateSinkImplTestParams, listener *recordingDataUpdateListener) {
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().
+				Flags(
+					ldbuilders.NewFlagBuilder("flag1").Version(1).Build(),
+					ldbuilders.NewFlagBuilder("flag2").Version(1).Build(),
+				).
+				Segments(ldbuilders.NewSegmentBuilder("segment1").Version(1).Build()).
+				Build())
+			listener.records = nil
+
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().
+				Flags(
+					ldbuilders.NewFlagBuilder("flag1").Version(2).Build(), // modified
+					ldbuilders.NewFlagBuilder("flag3").Version(1).Build(), // added; flag2 is removed
+				).
+				Segments(ldbuilders.NewSegmentBuilder("segment1").Version(1).Build()). // unchanged
+				Build())
+
+			assert.ElementsMatch(t, []intf.DataUpdateRecord{
+				{Kind: "features", Key: "flag1", OldVersion: ldvalue.NewOptionalInt(1), NewVersion: ldvalue.NewOptionalInt(2),
+					Operation: intf.DataUpdateOperationInit, Source: intf.DataUpdateSourceStreaming},
+				{Kind: "features", Key: "flag2", OldVersion: ldvalue.NewOptionalInt(1), Deleted: true,
+					Operation: intf.DataUpdateOperationInit, Source: intf.DataUpdateSourceStreaming},
+				{Kind: "features", Key: "flag3", NewVersion: ldvalue.NewOptionalInt(1),
+					Operation: intf.DataUpdateOperationInit, Source: intf.DataUpdateSourceStreaming},
+			}, listener.records)
+		})
+	})
+
+	t.Run("reports upserts and deletes", func(t *testing.T) {
+		withListener(func(p dataSourceUpdateSinkImplTestParams, listener *recordingDataUpdateListener) {
+			flag1v1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+			p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().Flags(flag1v1).Build())
+			listener.records = nil
+
+			flag1v2 := ldbuilders.NewFlagBuilder("flag1").Version(2).Build()
+			p.dataSourceUpdates.Upsert(datakinds.Features, flag1v2.Key, sharedtest.FlagDescriptor(flag1v2))
+			p.dataSourceUpdates.Upsert(datakinds.Features, flag1v1.Key, sharedtest.FlagDescriptor(flag1v1)) // ignored
+			p.dataSourceUpdates.Upsert(datakinds.Features, flag1v1.Key, st.ItemDescriptor{Version: 3})
+
+			assert.Equal(t, []intf.DataUpdateRecord{
+				{Kind: "features", Key: "flag1", OldVersion: ldvalue.NewOptionalInt(1), NewVersion: ldvalue.NewOptionalInt(2),
+					Operation: intf.DataUpdateOperationUpsert, Source: intf.DataUpdateSourceStreaming},
+				{Kind: "features", Key: "flag1", OldVersion: ldvalue.NewOptionalInt(2), NewVersion: ldvalue.NewOptionalInt(3),
+					Deleted: true, Operation: intf.DataUpdateOperationUpsert, Source: intf.DataUpdateSourceStreaming},
+			}, listener.records)
+		})
+	})
+
+	t.Run("listener error is logged and does not affect update", func(t *testing.T) {
+		withListener(func(p dataSourceUpdateSinkImplTestParams, listener *recordingDataUpdateListener) {
+			listener.err = errors.New("sorry")
+			flag1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+
+			assert.True(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag1.Key, sharedtest.FlagDescriptor(flag1)))
+
+			item, err := p.store.Get(datakinds.Features, flag1.Key)
+			require.NoError(t, err)
+			assert.Equal(t, 1, item.Version)
+			p.mockLoggers.AssertMessageMatch(t, true, ldlog.Warn, `Data update listener returned an error for features "flag1": sorry`)
+		})
+	})
+
+	t.Run("listener panic is logged and does not affect update", func(t *testing.T) {
+		withListener(func(p dataSourceUpdateSinkImplTestParams, listener *recordingDataUpdateListener) {
+			listener.panicKey = "flag1"
+			flag1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+			flag2 := ldbuilders.NewFlagBuilder("flag2").Version(1).Build()
+
+			assert.True(t, p.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().Flags(flag1, flag2).Build()))
+			assert.True(t, p.dataSourceUpdates.Upsert(datakinds.Features, flag1.Key,
+				sharedtest.FlagDescriptor(ldbuilders.NewFlagBuilder("flag1").Version(2).Build())))
+
+			item, err := p.store.Get(datakinds.Features, flag1.Key)
+			require.NoError(t, err)
+			assert.Equal(t, 2, item.Version)
+			assert.Len(t, listener.records, 3) // the record for flag2 is still delivered
+			p.mockLoggers.AssertMessageMatch(t, true, ldlog.Error, `Unexpected panic in data update listener for features "flag1": sorry`)
+		})
+	})
+}
+
 func TestDataSourceOutageLoggingTimeout(t *testing.T) {
 	t.Run("does not log error if data source recovers before timeout", func(t *testing.T) {
 		dataSourceUpdateSinkImplTest(func(p dataSourceUpdateSinkImplTestParams) {
@@ -388,3 +590,302 @@ func TestDataSourceOutageLoggingTimeout(t *testing.T) {
 		})
 	})
 }
+
+type statusReportingTestParams struct {
+	dataSourceUpdates *DataSourceUpdateSinkImpl
+	statusCh          <-chan intf.DataSourceStatus
+}
+
+func statusReportingTest(
+	coalescingWindow, interruptedDelay time.Duration,
+	action func(statusReportingTestParams),
+) {
+	mockLog := ldlogtest.NewMockLog()
+	store := datastore.NewInMemoryDataStore(mockLog.Loggers)
+	dataStoreStatusProvider := datastore.NewDataStoreStatusProviderImpl(store, datastore.NewDataStoreUpdateSinkImpl(nil))
+	dataSourceStatusBroadcaster := internal.NewBroadcaster[interfaces.DataSourceStatus]()
+	defer dataSourceStatusBroadcaster.Close()
+	flagChangeBroadcaster := internal.NewBroadcaster[interfaces.FlagChangeEvent]()
+	defer flagChangeBroadcaster.Close()
+	p := statusReportingTestParams{
+		dataSourceUpdates: NewDataSourceUpdateSinkImpl(store, dataStoreStatusProvider, dataSourceStatusBroadcaster,
+			flagChangeBroadcaster, 0, mockLog.Loggers),
+		statusCh: dataSourceStatusBroadcaster.AddListener(),
+	}
+	p.dataSourceUpdates.SetStatusCoalescingWindow(coalescingWindow)
+	p.dataSourceUpdates.SetInterruptedReportingDelay(interruptedDelay)
+
+	action(p)
+}
+
+func (p statusReportingTestParams) flap(times int) intf.DataSourceErrorInfo {
+	var lastError intf.DataSourceErrorInfo
+	for i := 0; i < times; i++ {
+		lastError = intf.DataSourceErrorInfo{Kind: intf.DataSourceErrorKindNetworkError, Message: fmt.Sprint(i)}
+		p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, lastError)
+		p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+	}
+	return lastError
+}
+
+func TestDataSourceUpdatesImplStatusReporting(t *testing.T) {
+	networkError := func(message string) intf.DataSourceErrorInfo {
+		return intf.DataSourceErrorInfo{Kind: intf.DataSourceErrorKindNetworkError, Message: message}
+	}
+
+	t.Run("by default, every change is broadcast", func(t *testing.T) {
+		statusReportingTest(0, 0, func(p statusReportingTestParams) {
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+			p.flap(3)
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("a"))
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("b"))
+
+			expected := []intf.DataSourceState{intf.DataSourceStateValid}
+			for i := 0; i < 3; i++ {
+				expected = append(expected, intf.DataSourceStateInterrupted, intf.DataSourceStateValid)
+			}
+			expected = append(expected, intf.DataSourceStateInterrupted, intf.DataSourceStateInterrupted)
+			for _, state := range expected {
+				assert.Equal(t, state, th.RequireValue(t, p.statusCh, time.Second).State)
+			}
+			th.AssertNoMoreValues(t, p.statusCh, 50*time.Millisecond)
+		})
+	})
+
+	t.Run("coalescing window", func(t *testing.T) {
+		window := 200 * time.Millisecond
+		statusReportingTest(window, 0, func(p statusReportingTestParams) {
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+			assert.Equal(t, intf.DataSourceStateValid, th.RequireValue(t, p.statusCh, time.Second).State)
+
+			// a change of state is broadcast even within the window
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("0"))
+			status := th.RequireValue(t, p.statusCh, time.Second)
+			assert.Equal(t, intf.DataSourceStateInterrupted, status.State)
+			assert.Equal(t, networkError("0"), status.LastError)
+
+			for _, message := range []string{"1", "2", "3"} {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError(message))
+			}
+			th.AssertNoMoreValues(t, p.statusCh, 50*time.Millisecond)
+			assert.Equal(t, networkError("3"), p.dataSourceUpdates.GetLastStatus().LastError)
+			assert.Equal(t, status.StateSince, p.dataSourceUpdates.GetLastStatus().StateSince)
+
+			time.Sleep(window)
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("4"))
+			assert.Equal(t, networkError("4"), th.RequireValue(t, p.statusCh, time.Second).LastError)
+
+			p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+			assert.Equal(t, intf.DataSourceStateValid, th.RequireValue(t, p.statusCh, time.Second).State)
+		})
+	})
+
+	t.Run("interrupted reporting delay", func(t *testing.T) {
+		delay := 200 * time.Millisecond
+
+		t.Run("flapping within the delay is not broadcast", func(t *testing.T) {
+			statusReportingTest(0, delay, func(p statusReportingTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				reported := th.RequireValue(t, p.statusCh, time.Second)
+				assert.Equal(t, intf.DataSourceStateValid, reported.State)
+
+				lastError := p.flap(20)
+				th.AssertNoMoreValues(t, p.statusCh, delay+100*time.Millisecond)
+
+				status := p.dataSourceUpdates.GetLastStatus()
+				assert.Equal(t, intf.DataSourceStateValid, status.State)
+				assert.Equal(t, lastError, status.LastError)
+				assert.True(t, status.StateSince.After(reported.StateSince))
+			})
+		})
+
+		t.Run("an interruption that lasts longer than the delay is broadcast once", func(t *testing.T) {
+			statusReportingTest(0, delay, func(p statusReportingTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				th.RequireValue(t, p.statusCh, time.Second)
+
+				p.flap(5)
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("a"))
+				assert.Equal(t, intf.DataSourceStateInterrupted, p.dataSourceUpdates.GetLastStatus().State)
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("b"))
+				th.AssertNoMoreValues(t, p.statusCh, delay/2)
+
+				status := th.RequireValue(t, p.statusCh, time.Second)
+				assert.Equal(t, intf.DataSourceStateInterrupted, status.State)
+				assert.Equal(t, networkError("b"), status.LastError)
+				th.AssertNoMoreValues(t, p.statusCh, 50*time.Millisecond)
+
+				// once the interruption has been reported, more errors are broadcast as usual
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("c"))
+				assert.Equal(t, networkError("c"), th.RequireValue(t, p.statusCh, time.Second).LastError)
+
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				assert.Equal(t, intf.DataSourceStateValid, th.RequireValue(t, p.statusCh, time.Second).State)
+			})
+		})
+
+		t.Run("a change to off is broadcast immediately", func(t *testing.T) {
+			statusReportingTest(0, delay, func(p statusReportingTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				th.RequireValue(t, p.statusCh, time.Second)
+
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("a"))
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateOff, intf.DataSourceErrorInfo{})
+				assert.Equal(t, intf.DataSourceStateOff, th.RequireValue(t, p.statusCh, time.Second).State)
+				th.AssertNoMoreValues(t, p.statusCh, delay+100*time.Millisecond)
+			})
+		})
+
+		t.Run("waitFor sees a recovery that was not broadcast", func(t *testing.T) {
+			statusReportingTest(0, delay, func(p statusReportingTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("a"))
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				}()
+				assert.True(t, p.dataSourceUpdates.waitFor(intf.DataSourceStateValid, time.Second))
+			})
+		})
+
+		t.Run("a pending interruption and later changes are not broadcast after close", func(t *testing.T) {
+			statusReportingTest(0, delay, func(p statusReportingTestParams) {
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				th.RequireValue(t, p.statusCh, time.Second)
+
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("a"))
+				p.dataSourceUpdates.Close()
+				th.AssertNoMoreValues(t, p.statusCh, delay+100*time.Millisecond)
+
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+				p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateInterrupted, networkError("b"))
+				th.AssertNoMoreValues(t, p.statusCh, delay+100*time.Millisecond)
+				assert.Nil(t, p.dataSourceUpdates.statusReporting.pendingInterruption)
+			})
+		})
+	})
+}
+
+func TestDataSourceUpdatesImplRejectedUpdates(t *testing.T) {
+	storeTypes := map[string]func(subsystems.DataStoreUpdateSink, ldlog.Loggers) subsystems.DataStore{
+		"in-memory": func(_ subsystems.DataStoreUpdateSink, loggers ldlog.Loggers) subsystems.DataStore {
+			return datastore.NewInMemoryDataStore(loggers)
+		},
+		"cached persistent": func(updates subsystems.DataStoreUpdateSink, loggers ldlog.Loggers) subsystems.DataStore {
+			return datastore.NewPersistentDataStoreWrapper(mocks.NewMockPersistentDataStore(), updates,
+				30*time.Second, false, nil, loggers)
+		},
+	}
+
+	flagV1 := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	flagV2 := ldbuilders.NewFlagBuilder("flagkey").Version(2).Build()
+	flagV3 := ldbuilders.NewFlagBuilder("flagkey").Version(3).Build()
+	upsertFlag := func(p rejectedUpdatesTestParams, flag ldmodel.FeatureFlag) bool {
+		return p.dataSourceUpdates.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+	}
+
+	for name, makeStore := range storeTypes {
+		t.Run(name, func(t *testing.T) {
+			t.Run("counts and logs rejected updates", func(t *testing.T) {
+				rejectedUpdatesTest(makeStore, 0, func(p rejectedUpdatesTestParams) {
+					assert.True(t, upsertFlag(p, flagV2))
+					assert.True(t, upsertFlag(p, flagV1)) // not an error as far as the data source is concerned
+					assert.True(t, upsertFlag(p, flagV2))
+					assert.Equal(t, 2, p.dataStoreStatusProvider.GetRejectedUpdateCount())
+
+					item, err := p.store.Get(datakinds.Features, "flagkey")
+					require.NoError(t, err)
+					assert.Equal(t, 2, item.Version)
+
+					assert.Equal(t, []string{
+						`Data source sent an update for features "flagkey" with version 1, but the data store already` +
+							` has version 2; the update was ignored`,
+					}, p.mockLog.GetOutput(ldlog.Warn))
+					assert.Equal(t, intf.DataSourceStateInitializing, p.dataSourceUpdates.GetLastStatus().State)
+				})
+			})
+
+			t.Run("logs again in the next window with the number of updates that were not logged", func(t *testing.T) {
+				rejectedUpdatesTest(makeStore, 0, func(p rejectedUpdatesTestParams) {
+					p.dataSourceUpdates.rejectedUpdates.window = 100 * time.Millisecond
+					assert.True(t, upsertFlag(p, flagV2))
+					upsertFlag(p, flagV1)
+					upsertFlag(p, flagV1)
+					upsertFlag(p, flagV2)
+					<-time.After(150 * time.Millisecond)
+					upsertFlag(p, flagV1)
+
+					warnings := p.mockLog.GetOutput(ldlog.Warn)
+					require.Len(t, warnings, 2)
+					assert.Contains(t, warnings[1], "with version 1, but the data store already has version 2")
+					assert.Contains(t, warnings[1], "(2 other updates were also ignored since the last warning)")
+					assert.Equal(t, 4, p.dataStoreStatusProvider.GetRejectedUpdateCount())
+				})
+			})
+
+			t.Run("reports error status when over threshold", func(t *testing.T) {
+				rejectedUpdatesTest(makeStore, 2, func(p rejectedUpdatesTestParams) {
+					p.dataSourceUpdates.UpdateStatus(intf.DataSourceStateValid, intf.DataSourceErrorInfo{})
+					p.dataSourceUpdates.rejectedUpdates.window = 100 * time.Millisecond
+					assert.True(t, upsertFlag(p, flagV2))
+					upsertFlag(p, flagV1)
+					upsertFlag(p, flagV2)
+					assert.Equal(t, intf.DataSourceStateValid, p.dataSourceUpdates.GetLastStatus().State)
+
+					upsertFlag(p, flagV1)
+					status := p.dataSourceUpdates.GetLastStatus()
+					assert.Equal(t, intf.DataSourceStateInterrupted, status.State)
+					assert.Equal(t, intf.DataSourceErrorKindRejectedUpdates, status.LastError.Kind)
+					assert.Equal(t, "data store ignored 3 updates within 100ms because it had newer versions",
+						status.LastError.Message)
+
+					// an accepted update does not clear the error while the rate is still too high
+					assert.True(t, upsertFlag(p, flagV3))
+					assert.Equal(t, intf.DataSourceStateInterrupted, p.dataSourceUpdates.GetLastStatus().State)
+
+					<-time.After(150 * time.Millisecond)
+					flagV4 := ldbuilders.NewFlagBuilder("flagkey").Version(4).Build()
+					assert.True(t, upsertFlag(p, flagV4))
+					assert.Equal(t, intf.DataSourceStateValid, p.dataSourceUpdates.GetLastStatus().State)
+				})
+			})
+		})
+	}
+}
+
+type rejectedUpdatesTestParams struct {
+	store                   subsystems.DataStore
+	dataStoreStatusProvider intf.DataStoreStatusProvider
+	dataSourceUpdates       *DataSourceUpdateSinkImpl
+	mockLog                 *ldlogtest.MockLog
+}
+
+func rejectedUpdatesTest(
+	makeStore func(subsystems.DataStoreUpdateSink, ldlog.Loggers) subsystems.DataStore,
+	errorThreshold int,
+	action func(rejectedUpdatesTestParams),
+) {
+	p := rejectedUpdatesTestParams{mockLog: ldlogtest.NewMockLog()}
+	dataStoreStatusBroadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+	defer dataStoreStatusBroadcaster.Close()
+	dataStoreUpdates := datastore.NewDataStoreUpdateSinkImpl(dataStoreStatusBroadcaster)
+	p.store = makeStore(dataStoreUpdates, p.mockLog.Loggers)
+	defer p.store.Close()
+	_ = p.store.Init(sharedtest.NewDataSetBuilder().Build())
+	p.dataStoreStatusProvider = datastore.NewDataStoreStatusProviderImpl(p.store, dataStoreUpdates)
+	dataSourceStatusBroadcaster := internal.NewBroadcaster[interfaces.DataSourceStatus]()
+	defer dataSourceStatusBroadcaster.Close()
+	flagChangeBroadcaster := internal.NewBroadcaster[interfaces.FlagChangeEvent]()
+	defer flagChangeBroadcaster.Close()
+	p.dataSourceUpdates = NewDataSourceUpdateSinkImpl(
+		p.store,
+		p.dataStoreStatusProvider,
+		dataSourceStatusBroadcaster,
+		flagChangeBroadcaster,
+		0,
+		p.mockLog.Loggers,
+	)
+	p.dataSourceUpdates.SetRejectedUpdatesErrorThreshold(errorThreshold)
+
+	action(p)
+}
@@ -99,24 +99,22 @@ func checkForHTTPError(statusCode int, url string) error {
 // manipulate it as a map. Our data store API instead expects a list of Collections, each of which has
 // a list of data items, so that's what we build here.
 //
+// Besides "flags" and "segments", it reads the items of any data kind that was registered with
+// ldstoreimpl.RegisterDataKind, and skips any other properties.
+//
 // This representation makes up the entirety of a polling response for PollingDataSource, and is a
 // subset of the stream data for StreamingDataSource.
 func parseAllStoreDataFromJSONReader(r *jreader.Reader) []st.Collection {
 	var ret []st.Collection
 	for dataObj := r.Object(); dataObj.Next(); {
-		var dataKind datakinds.DataKindInternal
-		switch string(dataObj.Name()) {
-		case "flags":
-			dataKind = datakinds.Features
-		case "segments":
-			dataKind = datakinds.Segments
-		default: // unrecognized category, skip it
+		dataKind := datakinds.DataKindForPayloadName(string(dataObj.Name()))
+		if dataKind == nil { // unrecognized category, skip it
 			continue
 		}
 		coll := st.Collection{Kind: dataKind}
 		for keysToItemsObj := r.Object(); keysToItemsObj.Next(); {
 			key := string(keysToItemsObj.Name())
-			item, err := dataKind.DeserializeFromJSONReader(r)
+			item, err := datakinds.DeserializeFromJSONReader(dataKind, r)
 			if err == nil {
 				coll.Items = append(coll.Items, st.KeyedItemDescriptor{Key: key, Item: item})
 			}
@@ -20,3 +20,29 @@ func (n nullDataSource) Close() error {
 func (n nullDataSource) Start(closeWhenReady chan<- struct{}) {
 	close(closeWhenReady)
 }
+
+// NewDaemonModeDataSource returns a stub implementation of DataSource that, like the null data source,
+// never makes any connections, but that only reports itself as initialized if the data store has been
+// populated by some other process.
+//
+// This is used when the SDK is configured to be offline but also has a persistent data store, so that
+// it can still serve flags that were put into the store by the Relay Proxy or another SDK instance.
+func NewDaemonModeDataSource(store subsystems.DataStore) subsystems.DataSource {
+	return daemonModeDataSource{store: store}
+}
+
+type daemonModeDataSource struct {
+	store subsystems.DataStore
+}
+
+func (d daemonModeDataSource) IsInitialized() bool {
+	return d.store.IsInitialized()
+}
+
+func (d daemonModeDataSource) Close() error {
+	return nil
+}
+
+func (d daemonModeDataSource) Start(closeWhenReady chan<- struct{}) {
+	close(closeWhenReady)
+}
@@ -3,7 +3,11 @@ package datasource
 import (
 	"testing"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNullDataSource(t *testing.T) {
@@ -17,3 +21,19 @@ func TestNullDataSource(t *testing.T) {
 
 	assert.Nil(t, d.Close())
 }
+
+func TestDaemonModeDataSource(t *testing.T) {
+	store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	d := NewDaemonModeDataSource(store)
+	assert.False(t, d.IsInitialized())
+
+	ch := make(chan struct{})
+	d.Start(ch)
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	require.NoError(t, store.Init(nil))
+	assert.True(t, d.IsInitialized())
+
+	assert.Nil(t, d.Close())
+}
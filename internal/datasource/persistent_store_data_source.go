@@ -0,0 +1,95 @@
+package datasource
+
+import (
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// PersistentStoreDataSource is the internal implementation of ldcomponents.DaemonModeDataSource. When it
+// starts, it copies all of the data from a persistent store that is maintained by another process, such as
+// the Relay Proxy, into the SDK's own data store. It never connects to LaunchDarkly.
+//
+// This type is exported from internal so that the builder tests can verify its configuration. All other
+// code outside of this package should interact with it only via the DataSource interface.
+type PersistentStoreDataSource struct {
+	source            subsystems.DataStore
+	dataSourceUpdates subsystems.DataSourceUpdateSink
+	loggers           ldlog.Loggers
+	isInitialized     internal.AtomicBoolean
+	closeOnce         sync.Once
+}
+
+// NewPersistentStoreDataSource creates the internal implementation of the daemon mode data source. The
+// source store is closed when the data source is closed.
+func NewPersistentStoreDataSource(
+	context subsystems.ClientContext,
+	dataSourceUpdates subsystems.DataSourceUpdateSink,
+	source subsystems.DataStore,
+) *PersistentStoreDataSource {
+	return &PersistentStoreDataSource{
+		source:            source,
+		dataSourceUpdates: dataSourceUpdates,
+		loggers:           context.GetLogging().Loggers,
+	}
+}
+
+//nolint:revive // no doc comment for standard method
+func (d *PersistentStoreDataSource) IsInitialized() bool {
+	return d.isInitialized.Get()
+}
+
+//nolint:revive // no doc comment for standard method
+func (d *PersistentStoreDataSource) Start(closeWhenReady chan<- struct{}) {
+	go func() {
+		defer close(closeWhenReady)
+		d.copyData()
+	}()
+}
+
+func (d *PersistentStoreDataSource) copyData() {
+	if !d.source.IsInitialized() {
+		d.loggers.Warn("Daemon mode data store has not been populated yet; flags will return default values")
+		d.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+		return
+	}
+	var allData []ldstoretypes.Collection
+	for _, kind := range datakinds.AllDataKinds() {
+		items, err := d.source.GetAll(kind)
+		if err != nil {
+			d.loggers.Errorf("Unable to read %s from daemon mode data store: %s", kind, err)
+			d.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateOff, interfaces.DataSourceErrorInfo{
+				Kind:    interfaces.DataSourceErrorKindStoreError,
+				Message: err.Error(),
+				Time:    time.Now(),
+			})
+			return
+		}
+		allData = append(allData, ldstoretypes.Collection{Kind: kind, Items: items})
+	}
+	if d.dataSourceUpdates.Init(allData) {
+		d.isInitialized.Set(true)
+		d.loggers.Info("Loaded feature flag data from daemon mode data store")
+		d.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+	}
+}
+
+//nolint:revive // no doc comment for standard method
+func (d *PersistentStoreDataSource) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		err = d.source.Close()
+	})
+	return err
+}
+
+// GetSourceStore returns the store that the data is copied from, for testing.
+func (d *PersistentStoreDataSource) GetSourceStore() subsystems.DataStore {
+	return d.source
+}
@@ -0,0 +1,89 @@
+package datasource
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingGetAllStore struct {
+	subsystems.DataStore
+}
+
+func (s failingGetAllStore) GetAll(ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
+	return nil, errors.New("sorry")
+}
+
+func TestPersistentStoreDataSource(t *testing.T) {
+	startDataSource := func(
+		t *testing.T,
+		source subsystems.DataStore,
+		action func(*PersistentStoreDataSource, *mocks.MockDataSourceUpdates, *ldlogtest.MockLog),
+	) {
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+		context := sharedtest.NewTestContext("", nil, &subsystems.LoggingConfiguration{Loggers: mockLog.Loggers})
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			ds := NewPersistentStoreDataSource(context, dataSourceUpdates, source)
+			defer ds.Close()
+			closeWhenReady := make(chan struct{})
+			ds.Start(closeWhenReady)
+			if th.AssertChannelClosed(t, closeWhenReady, time.Second, "timed out waiting for data source to start") {
+				action(ds, dataSourceUpdates, mockLog)
+			}
+		})
+	}
+
+	t.Run("copies data from populated store", func(t *testing.T) {
+		flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+		segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(1).Build()
+		data := sharedtest.NewDataSetBuilder().Flags(flag).Segments(segment)
+		source := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+		_ = source.Init(data.Build())
+
+		startDataSource(t, source, func(ds *PersistentStoreDataSource, updates *mocks.MockDataSourceUpdates,
+			_ *ldlogtest.MockLog) {
+			updates.DataStore.WaitForInit(t, data.ToServerSDKData(), time.Second)
+			assert.True(t, ds.IsInitialized())
+			updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+		})
+	})
+
+	t.Run("does not initialize from unpopulated store", func(t *testing.T) {
+		source := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+
+		startDataSource(t, source, func(ds *PersistentStoreDataSource, updates *mocks.MockDataSourceUpdates,
+			mockLog *ldlogtest.MockLog) {
+			assert.False(t, ds.IsInitialized())
+			updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+			mockLog.AssertMessageMatch(t, true, ldlog.Warn, "has not been populated")
+		})
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		source := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+		_ = source.Init(nil)
+
+		startDataSource(t, failingGetAllStore{source}, func(ds *PersistentStoreDataSource,
+			updates *mocks.MockDataSourceUpdates, mockLog *ldlogtest.MockLog) {
+			assert.False(t, ds.IsInitialized())
+			status := updates.RequireStatusOf(t, interfaces.DataSourceStateOff)
+			assert.Equal(t, interfaces.DataSourceErrorKindStoreError, status.LastError.Kind)
+			mockLog.AssertMessageMatch(t, true, ldlog.Error, "sorry")
+		})
+	})
+}
@@ -2,6 +2,7 @@ package datasource
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
@@ -15,6 +16,8 @@ import (
 const (
 	pollingErrorContext     = "on polling request"
 	pollingWillRetryMessage = "will retry at next scheduled poll interval"
+
+	pollingOnDemandWillRetryMessage = "will retry when data is next needed"
 )
 
 // PollingConfig describes the configuration for a polling data source. It is exported so that
@@ -23,6 +26,21 @@ type PollingConfig struct {
 	BaseURI      string
 	PollInterval time.Duration
 	FilterKey    string
+	// OnDemandMaxAge, if greater than zero, turns on on-demand mode: instead of polling at PollInterval, the
+	// data source polls once at startup, and then again only when there is an evaluation after the data
+	// has become older than OnDemandMaxAge.
+	OnDemandMaxAge time.Duration
+	// DeltaPolling, if true, makes the data source request only the changes since its previous request,
+	// if the polling service supports this.
+	DeltaPolling bool
+}
+
+// EvaluationObserver is an optional interface for a DataSource that needs to know whenever the SDK is
+// about to evaluate feature flags. LDClient checks for this interface when it creates the data source.
+//
+// NotifyEvaluation is called on a high-traffic code path, so it must return quickly and must never block.
+type EvaluationObserver interface {
+	NotifyEvaluation()
 }
 
 // Requester allows PollingProcessor to delegate fetching data to another component.
@@ -33,6 +51,13 @@ type Requester interface {
 	FilterKey() string
 }
 
+// DeltaRequester is an optional interface for a Requester that can ask for only the changes since its
+// previous request. If delta is true, the returned data contains only the changed items (with deleted
+// items represented as tombstones); otherwise it is the full data set.
+type DeltaRequester interface {
+	RequestDelta() (data []ldstoretypes.Collection, delta bool, cached bool, err error)
+}
+
 // PollingProcessor is the internal implementation of the polling data source.
 //
 // This type is exported from internal so that the PollingDataSourceBuilder tests can verify its
@@ -41,7 +66,11 @@ type Requester interface {
 type PollingProcessor struct {
 	dataSourceUpdates  subsystems.DataSourceUpdateSink
 	requester          Requester
+	deltaRequester     DeltaRequester
 	pollInterval       time.Duration
+	onDemandMaxAge     time.Duration
+	lastFetchTime      int64 // UnixNano timestamp of most recent on-demand fetch attempt; use atomic access
+	fetching           internal.AtomicBoolean
 	loggers            ldlog.Loggers
 	setInitializedOnce sync.Once
 	isInitialized      internal.AtomicBoolean
@@ -56,7 +85,12 @@ func NewPollingProcessor(
 	cfg PollingConfig,
 ) *PollingProcessor {
 	httpRequester := newPollingRequester(context, context.GetHTTP().CreateHTTPClient(), cfg.BaseURI, cfg.FilterKey)
-	return newPollingProcessor(context, dataSourceUpdates, httpRequester, cfg.PollInterval)
+	pp := newPollingProcessor(context, dataSourceUpdates, httpRequester, cfg.PollInterval)
+	pp.setOnDemandMaxAge(cfg.OnDemandMaxAge)
+	if cfg.DeltaPolling {
+		pp.deltaRequester = httpRequester
+	}
+	return pp
 }
 
 func newPollingProcessor(
@@ -75,8 +109,21 @@ func newPollingProcessor(
 	return pp
 }
 
+func (pp *PollingProcessor) setOnDemandMaxAge(maxAge time.Duration) {
+	pp.onDemandMaxAge = maxAge
+	if maxAge > 0 {
+		// Don't allow NotifyEvaluation to trigger a fetch until the initial fetch in Start has finished
+		pp.fetching.Set(true)
+	}
+}
+
 //nolint:revive // no doc comment for standard method
 func (pp *PollingProcessor) Start(closeWhenReady chan<- struct{}) {
+	if pp.onDemandMaxAge > 0 {
+		pp.startOnDemand(closeWhenReady)
+		return
+	}
+
 	pp.loggers.Infof("Starting LaunchDarkly polling with interval: %+v", pp.pollInterval)
 
 	ticker := newTickerWithInitialTick(pp.pollInterval)
@@ -98,53 +145,116 @@ func (pp *PollingProcessor) Start(closeWhenReady chan<- struct{}) {
 			case <-pp.quit:
 				return
 			case <-ticker.C:
-				if err := pp.poll(); err != nil {
-					if hse, ok := err.(httpStatusError); ok {
-						errorInfo := interfaces.DataSourceErrorInfo{
-							Kind:       interfaces.DataSourceErrorKindErrorResponse,
-							StatusCode: hse.Code,
-							Time:       time.Now(),
-						}
-						recoverable := checkIfErrorIsRecoverableAndLog(
-							pp.loggers,
-							httpErrorDescription(hse.Code),
-							pollingErrorContext,
-							hse.Code,
-							pollingWillRetryMessage,
-						)
-						if recoverable {
-							pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateInterrupted, errorInfo)
-						} else {
-							pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateOff, errorInfo)
-							notifyReady()
-							return
-						}
-					} else {
-						errorInfo := interfaces.DataSourceErrorInfo{
-							Kind:    interfaces.DataSourceErrorKindNetworkError,
-							Message: err.Error(),
-							Time:    time.Now(),
-						}
-						if _, ok := err.(malformedJSONError); ok {
-							errorInfo.Kind = interfaces.DataSourceErrorKindInvalidData
-						}
-						checkIfErrorIsRecoverableAndLog(pp.loggers, err.Error(), pollingErrorContext, 0, pollingWillRetryMessage)
-						pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateInterrupted, errorInfo)
-					}
-					continue
+				if !pp.pollAndUpdateStatus(notifyReady) {
+					return
 				}
-				pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
-				pp.setInitializedOnce.Do(func() {
-					pp.isInitialized.Set(true)
-					pp.loggers.Info("First polling request successful")
-					notifyReady()
-				})
 			}
 		}
 	}()
 }
 
+func (pp *PollingProcessor) startOnDemand(closeWhenReady chan<- struct{}) {
+	pp.loggers.Infof("Starting LaunchDarkly polling in on-demand mode with maximum data age: %+v", pp.onDemandMaxAge)
+
+	go func() {
+		// Ensure we stop waiting for initialization even if initialization fails
+		defer close(closeWhenReady)
+		select {
+		case <-pp.quit:
+			return
+		default:
+			pp.fetchOnDemand(func() {})
+		}
+	}()
+}
+
+// NotifyEvaluation is called by LDClient before each evaluation. In on-demand mode, if the data is older
+// than the configured maximum age, it starts a refresh in the background; the evaluation that triggered it
+// does not wait, and there is never more than one refresh at a time. In regular polling mode it does nothing.
+func (pp *PollingProcessor) NotifyEvaluation() {
+	if pp.onDemandMaxAge <= 0 {
+		return
+	}
+	if time.Since(time.Unix(0, atomic.LoadInt64(&pp.lastFetchTime))) < pp.onDemandMaxAge {
+		return
+	}
+	if pp.fetching.GetAndSet(true) {
+		return // another refresh is already in progress, or the initial fetch hasn't finished
+	}
+	select {
+	case <-pp.quit:
+		return // leave fetching set so that we'll never try again
+	default:
+	}
+	go pp.fetchOnDemand(func() {})
+}
+
+func (pp *PollingProcessor) fetchOnDemand(notifyReady func()) {
+	if pp.pollAndUpdateStatus(notifyReady) {
+		atomic.StoreInt64(&pp.lastFetchTime, time.Now().UnixNano())
+		pp.fetching.Set(false)
+	}
+	// If the error was unrecoverable, we leave fetching set so that no more requests will be made.
+}
+
+// Makes a poll request and updates the data source status based on the result. Returns false if there
+// was an unrecoverable error, meaning that no more requests should be made.
+func (pp *PollingProcessor) pollAndUpdateStatus(notifyReady func()) bool {
+	if err := pp.poll(); err != nil {
+		if hse, ok := err.(httpStatusError); ok {
+			errorInfo := interfaces.DataSourceErrorInfo{
+				Kind:       interfaces.DataSourceErrorKindErrorResponse,
+				StatusCode: hse.Code,
+				Time:       time.Now(),
+			}
+			recoverable := checkIfErrorIsRecoverableAndLog(
+				pp.loggers,
+				httpErrorDescription(hse.Code),
+				pollingErrorContext,
+				hse.Code,
+				pp.willRetryMessage(),
+			)
+			if recoverable {
+				pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateInterrupted, errorInfo)
+			} else {
+				pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateOff, errorInfo)
+				notifyReady()
+				return false
+			}
+		} else {
+			errorInfo := interfaces.DataSourceErrorInfo{
+				Kind:    interfaces.DataSourceErrorKindNetworkError,
+				Message: err.Error(),
+				Time:    time.Now(),
+			}
+			if _, ok := err.(malformedJSONError); ok {
+				errorInfo.Kind = interfaces.DataSourceErrorKindInvalidData
+			}
+			checkIfErrorIsRecoverableAndLog(pp.loggers, err.Error(), pollingErrorContext, 0, pp.willRetryMessage())
+			pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateInterrupted, errorInfo)
+		}
+		return true
+	}
+	pp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+	pp.setInitializedOnce.Do(func() {
+		pp.isInitialized.Set(true)
+		pp.loggers.Info("First polling request successful")
+		notifyReady()
+	})
+	return true
+}
+
+func (pp *PollingProcessor) willRetryMessage() string {
+	if pp.onDemandMaxAge > 0 {
+		return pollingOnDemandWillRetryMessage
+	}
+	return pollingWillRetryMessage
+}
+
 func (pp *PollingProcessor) poll() error {
+	if pp.deltaRequester != nil {
+		return pp.pollDelta()
+	}
 	allData, cached, err := pp.requester.Request()
 
 	if err != nil {
@@ -158,6 +268,23 @@ func (pp *PollingProcessor) poll() error {
 	return nil
 }
 
+func (pp *PollingProcessor) pollDelta() error {
+	data, delta, cached, err := pp.deltaRequester.RequestDelta()
+	if err != nil || cached {
+		return err
+	}
+	if !delta {
+		pp.dataSourceUpdates.Init(data)
+		return nil
+	}
+	for _, coll := range data {
+		for _, item := range coll.Items {
+			pp.dataSourceUpdates.Upsert(coll.Kind, item.Key, item.Item)
+		}
+	}
+	return nil
+}
+
 //nolint:revive // no doc comment for standard method
 func (pp *PollingProcessor) Close() error {
 	pp.closeOnce.Do(func() {
@@ -181,6 +308,16 @@ func (pp *PollingProcessor) GetPollInterval() time.Duration {
 	return pp.pollInterval
 }
 
+// GetOnDemandMaxAge returns the configured maximum data age for on-demand mode, for testing.
+func (pp *PollingProcessor) GetOnDemandMaxAge() time.Duration {
+	return pp.onDemandMaxAge
+}
+
+// IsDeltaPolling returns true if delta polling is enabled, for testing.
+func (pp *PollingProcessor) IsDeltaPolling() bool {
+	return pp.deltaRequester != nil
+}
+
 // GetFilterKey returns the configured filter key, for testing.
 func (pp *PollingProcessor) GetFilterKey() string {
 	return pp.requester.FilterKey()
@@ -3,7 +3,9 @@ package datasource
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -11,12 +13,14 @@ import (
 
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
 
 	th "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -233,3 +237,181 @@ func TestPollingProcessorAppendsFilterParameter(t *testing.T) {
 		})
 	})
 }
+
+func TestPollingProcessorOnDemandMode(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	data := sharedtest.NewDataSetBuilder().Flags(flag)
+	resp := mocks.RequestAllResponse{Data: data.Build()}
+	maxAge := time.Millisecond * 100
+
+	startOnDemand := func(
+		t *testing.T,
+		r *mocks.Requester,
+		dataSourceUpdates *mocks.MockDataSourceUpdates,
+	) *PollingProcessor {
+		p := newPollingProcessor(basicClientContext(), dataSourceUpdates, r, time.Millisecond)
+		p.setOnDemandMaxAge(maxAge)
+		closeWhenReady := make(chan struct{})
+		p.Start(closeWhenReady)
+		waitForReadyWithTimeout(t, closeWhenReady, time.Second)
+		th.RequireValue(t, r.PollsCh, time.Second)
+		return p
+	}
+
+	t.Run("fetches once at startup and not on a timer", func(t *testing.T) {
+		r := mocks.NewPollingRequester()
+		defer r.Close()
+		r.RequestAllRespCh <- resp
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			p := startOnDemand(t, r, dataSourceUpdates)
+			defer p.Close()
+
+			assert.True(t, p.IsInitialized())
+			dataSourceUpdates.DataStore.WaitForInit(t, data.ToServerSDKData(), time.Second)
+			dataSourceUpdates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+
+			r.RequestAllRespCh <- resp
+			th.AssertNoMoreValues(t, r.PollsCh, maxAge*2)
+		})
+	})
+
+	t.Run("does not refresh on evaluation before max age", func(t *testing.T) {
+		r := mocks.NewPollingRequester()
+		defer r.Close()
+		r.RequestAllRespCh <- resp
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			p := startOnDemand(t, r, dataSourceUpdates)
+			defer p.Close()
+
+			r.RequestAllRespCh <- resp
+			p.NotifyEvaluation()
+			th.AssertNoMoreValues(t, r.PollsCh, maxAge/2)
+		})
+	})
+
+	t.Run("refreshes only once on evaluations after max age", func(t *testing.T) {
+		r := mocks.NewPollingRequester()
+		defer r.Close()
+		r.RequestAllRespCh <- resp
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			p := startOnDemand(t, r, dataSourceUpdates)
+			defer p.Close()
+
+			time.Sleep(maxAge)
+			// The requester will block until we give it a response, so all of these evaluations happen
+			// while the first refresh is still in progress.
+			for i := 0; i < 3; i++ {
+				p.NotifyEvaluation()
+			}
+			for i := 0; i < 3; i++ {
+				r.RequestAllRespCh <- resp
+			}
+			th.RequireValue(t, r.PollsCh, time.Second)
+			th.AssertNoMoreValues(t, r.PollsCh, maxAge/2)
+		})
+	})
+
+	t.Run("reports status of failed refresh", func(t *testing.T) {
+		r := mocks.NewPollingRequester()
+		defer r.Close()
+		r.RequestAllRespCh <- resp
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			p := startOnDemand(t, r, dataSourceUpdates)
+			defer p.Close()
+			dataSourceUpdates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+
+			time.Sleep(maxAge)
+			r.RequestAllRespCh <- mocks.RequestAllResponse{Err: httpStatusError{Code: 503}}
+			p.NotifyEvaluation()
+			th.RequireValue(t, r.PollsCh, time.Second)
+			status := dataSourceUpdates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+			assert.Equal(t, 503, status.LastError.StatusCode)
+		})
+	})
+
+	t.Run("does not refresh after unrecoverable error", func(t *testing.T) {
+		r := mocks.NewPollingRequester()
+		defer r.Close()
+		r.RequestAllRespCh <- mocks.RequestAllResponse{Err: httpStatusError{Code: 401}}
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			p := startOnDemand(t, r, dataSourceUpdates)
+			defer p.Close()
+			dataSourceUpdates.RequireStatusOf(t, interfaces.DataSourceStateOff)
+			assert.False(t, p.IsInitialized())
+
+			time.Sleep(maxAge)
+			r.RequestAllRespCh <- resp
+			p.NotifyEvaluation()
+			th.AssertNoMoreValues(t, r.PollsCh, maxAge/2)
+		})
+	})
+}
+
+func TestPollingProcessorDeltaPolling(t *testing.T) {
+	flagV1 := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	flagV2 := ldbuilders.NewFlagBuilder("flagkey").Version(2).Build()
+	segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(1).Build()
+	fullData := sharedtest.NewDataSetBuilder().Flags(flagV1).Segments(segment)
+	deltaJSON := fmt.Sprintf(`{"flags": {"flagkey": %s}, "segments": {"segmentkey": {"version": 2, "deleted": true}}}`,
+		jsonhelpers.ToJSON(flagV2))
+
+	withDeltaServer := func(supportsDelta bool, action func(p *PollingProcessor, updates *mocks.MockDataSourceUpdates,
+		queries <-chan url.Values)) {
+		queries := make(chan url.Values, 100)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries <- r.URL.Query()
+			body := jsonhelpers.ToJSON(fullData.ToServerSDKData())
+			if supportsDelta {
+				switch r.URL.Query().Get("since") {
+				case "":
+					w.Header().Set(deltaPollingVersionHeader, "v1")
+				case "v1":
+					w.Header().Set(deltaPollingVersionHeader, "v2")
+					w.Header().Set(deltaPollingDeltaHeader, "true")
+					body = []byte(deltaJSON)
+				default:
+					w.Header().Set(deltaPollingVersionHeader, "v2")
+					w.Header().Set(deltaPollingDeltaHeader, "true")
+					body = []byte(`{"flags": {}, "segments": {}}`)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		})
+		httphelpers.WithServer(handler, func(ts *httptest.Server) {
+			withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+				p := NewPollingProcessor(basicClientContext(), dataSourceUpdates, PollingConfig{
+					BaseURI:      ts.URL,
+					PollInterval: time.Millisecond * 10,
+					DeltaPolling: true,
+				})
+				defer p.Close()
+				closeWhenReady := make(chan struct{})
+				p.Start(closeWhenReady)
+				<-closeWhenReady
+				action(p, dataSourceUpdates, queries)
+			})
+		})
+	}
+
+	t.Run("server supports delta", func(t *testing.T) {
+		withDeltaServer(true, func(p *PollingProcessor, updates *mocks.MockDataSourceUpdates, queries <-chan url.Values) {
+			assert.True(t, p.IsDeltaPolling())
+			updates.DataStore.WaitForInit(t, fullData.ToServerSDKData(), time.Second)
+			updates.DataStore.WaitForUpsert(t, datakinds.Features, "flagkey", 2, time.Second)
+			updates.DataStore.WaitForDelete(t, datakinds.Segments, "segmentkey", 2, time.Second)
+
+			assert.Equal(t, "", (<-queries).Get("since"))
+			assert.Equal(t, "v1", (<-queries).Get("since"))
+			assert.Equal(t, "v2", (<-queries).Get("since"))
+		})
+	})
+
+	t.Run("server does not support delta", func(t *testing.T) {
+		withDeltaServer(false, func(p *PollingProcessor, updates *mocks.MockDataSourceUpdates, queries <-chan url.Values) {
+			updates.DataStore.WaitForInit(t, fullData.ToServerSDKData(), time.Second)
+			<-queries
+			assert.Equal(t, "", (<-queries).Get("since"))
+		})
+	})
+}
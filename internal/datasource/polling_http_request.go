@@ -17,6 +17,19 @@ import (
 	"golang.org/x/exp/maps"
 )
 
+const (
+	// The polling service sets this header to the version of the data it returned, if it supports
+	// delta requests. The SDK passes the value back in the "since" query parameter.
+	deltaPollingVersionHeader = "X-LD-Data-Version"
+	// The polling service sets this header to "true" if it returned only the changes since the
+	// requested version, rather than the full data set.
+	deltaPollingDeltaHeader = "X-LD-Delta"
+	deltaPollingSinceParam  = "since"
+	// The polling service returns this status if it no longer has the changes since the requested
+	// version, in which case the SDK must request the full data set.
+	deltaPollingVersionTooOldStatus = http.StatusGone
+)
+
 // pollingRequester is the internal implementation of getting flag/segment data from the LD polling endpoints.
 type pollingRequester struct {
 	httpClient *http.Client
@@ -24,6 +37,12 @@ type pollingRequester struct {
 	filterKey  string
 	headers    http.Header
 	loggers    ldlog.Loggers
+
+	// lastVersion is the data version from the most recent delta-capable response, or "" if the server
+	// has not indicated that it supports delta requests. It is only accessed by RequestDelta, which
+	// PollingProcessor never calls concurrently.
+	lastVersion        string
+	loggedDeltaSupport bool
 }
 
 type malformedJSONError struct {
@@ -71,35 +90,92 @@ func (r *pollingRequester) Request() ([]ldstoretypes.Collection, bool, error) {
 		r.loggers.Debug("Polling LaunchDarkly for feature flag updates")
 	}
 
-	body, cached, err := r.makeRequest(endpoints.PollingRequestPath)
+	body, _, cached, err := r.makeRequest(endpoints.PollingRequestPath, nil)
 	if err != nil {
 		return nil, false, err
 	}
 	if cached {
 		return nil, true, nil
 	}
+	data, err := parsePollingResponse(body)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, cached, nil
+}
+
+// RequestDelta is like Request, except that if an earlier response indicated that the server supports
+// delta requests, it asks only for the changes since that response. The delta return value is true if
+// the returned data contains only those changes; otherwise, it is the full data set. If the server says
+// that the previous version is too old, RequestDelta immediately makes another request for all data.
+func (r *pollingRequester) RequestDelta() (data []ldstoretypes.Collection, delta bool, cached bool, err error) {
+	if r.loggers.IsDebugEnabled() {
+		r.loggers.Debug("Polling LaunchDarkly for feature flag updates")
+	}
 
+	var query url.Values
+	requestedVersion := r.lastVersion
+	if requestedVersion != "" {
+		query = url.Values{deltaPollingSinceParam: {requestedVersion}}
+	}
+	body, header, cached, err := r.makeRequest(endpoints.PollingRequestPath, query)
+	if hse, ok := err.(httpStatusError); ok && requestedVersion != "" && hse.Code == deltaPollingVersionTooOldStatus {
+		r.loggers.Infof("Polling service no longer has changes since data version %q; requesting all data",
+			requestedVersion)
+		r.lastVersion = ""
+		return r.RequestDelta()
+	}
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	r.lastVersion = header.Get(deltaPollingVersionHeader)
+	if !r.loggedDeltaSupport {
+		r.loggedDeltaSupport = true
+		if r.lastVersion == "" {
+			r.loggers.Info("Polling service does not support delta requests; will request all data on every poll")
+		} else {
+			r.loggers.Info("Polling service supports delta requests")
+		}
+	}
+	if cached {
+		return nil, false, true, nil
+	}
+	data, err = parsePollingResponse(body)
+	if err != nil {
+		r.lastVersion = "" // we can't be sure what data we have now, so the next request must get all of it
+		return nil, false, false, err
+	}
+	delta = requestedVersion != "" && header.Get(deltaPollingDeltaHeader) == "true"
+	return data, delta, false, nil
+}
+
+func parsePollingResponse(body []byte) ([]ldstoretypes.Collection, error) {
 	reader := jreader.NewReader(body)
 	data := parseAllStoreDataFromJSONReader(&reader)
 	if err := reader.Error(); err != nil {
-		return nil, false, malformedJSONError{err}
+		return nil, malformedJSONError{err}
 	}
-	return data, cached, nil
+	return data, nil
 }
 
-func (r *pollingRequester) makeRequest(resource string) ([]byte, bool, error) {
+func (r *pollingRequester) makeRequest(resource string, query url.Values) ([]byte, http.Header, bool, error) {
 	req, reqErr := http.NewRequest("GET", endpoints.AddPath(r.baseURI, resource), nil)
 	if reqErr != nil {
 		reqErr = fmt.Errorf(
 			"unable to create a poll request; this is not a network problem, most likely a bad base URI: %w",
 			reqErr,
 		)
-		return nil, false, reqErr
+		return nil, nil, false, reqErr
 	}
 	if r.filterKey != "" {
-		req.URL.RawQuery = url.Values{
-			"filter": {r.filterKey},
-		}.Encode()
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("filter", r.filterKey)
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
 	}
 	url := req.URL.String()
 	if r.headers != nil {
@@ -109,7 +185,7 @@ func (r *pollingRequester) makeRequest(resource string) ([]byte, bool, error) {
 	res, resErr := r.httpClient.Do(req)
 
 	if resErr != nil {
-		return nil, false, resErr
+		return nil, nil, false, resErr
 	}
 
 	defer func() {
@@ -118,7 +194,7 @@ func (r *pollingRequester) makeRequest(resource string) ([]byte, bool, error) {
 	}()
 
 	if err := checkForHTTPError(res.StatusCode, url); err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 
 	cached := res.Header.Get(httpcache.XFromCache) != ""
@@ -126,7 +202,7 @@ func (r *pollingRequester) makeRequest(resource string) ([]byte, bool, error) {
 	body, ioErr := io.ReadAll(res.Body)
 
 	if ioErr != nil {
-		return nil, false, ioErr // COVERAGE: there is no way to simulate this condition in unit tests
+		return nil, nil, false, ioErr // COVERAGE: there is no way to simulate this condition in unit tests
 	}
-	return body, cached, nil
+	return body, res.Header, cached, nil
 }
@@ -1,6 +1,7 @@
 package datasource
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,11 +10,13 @@ import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
 
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -222,3 +225,150 @@ func TestRequestorImplCanAppendsFilterParameter(t *testing.T) {
 		})
 	})
 }
+
+func TestRequestorImplRequestDelta(t *testing.T) {
+	flagV1 := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	flagV2 := ldbuilders.NewFlagBuilder("flagkey").Version(2).Build()
+	fullJSON := string(jsonhelpers.ToJSON(sharedtest.NewDataSetBuilder().Flags(flagV1).ToServerSDKData()))
+	deltaJSON := fmt.Sprintf(`{"flags": {"flagkey": %s}, "segments": {}}`, jsonhelpers.ToJSON(flagV2))
+	emptyDeltaJSON := `{"flags": {}, "segments": {}}`
+
+	type fakeResponse struct {
+		status  int
+		version string
+		delta   bool
+		body    string
+	}
+	full := func(version string) fakeResponse { return fakeResponse{version: version, body: fullJSON} }
+	delta := func(version, body string) fakeResponse {
+		return fakeResponse{version: version, delta: true, body: body}
+	}
+	status := func(code int) fakeResponse { return fakeResponse{status: code} }
+
+	type result struct {
+		flagVersion int // 0 means no flags were returned
+		delta       bool
+		errStatus   int // -1 means any non-HTTP error
+	}
+	fullResult := result{flagVersion: 1}
+	deltaResult := result{flagVersion: 2, delta: true}
+	emptyDeltaResult := result{delta: true}
+
+	tests := []struct {
+		name          string
+		responses     []fakeResponse
+		results       []result
+		expectedSince []string
+	}{
+		{
+			name:          "server does not support delta",
+			responses:     []fakeResponse{full(""), full("")},
+			results:       []result{fullResult, fullResult},
+			expectedSince: []string{"", ""},
+		},
+		{
+			name:          "server supports delta",
+			responses:     []fakeResponse{full("v1"), delta("v2", deltaJSON), delta("v3", emptyDeltaJSON)},
+			results:       []result{fullResult, deltaResult, emptyDeltaResult},
+			expectedSince: []string{"", "v1", "v2"},
+		},
+		{
+			name:          "delta header is ignored if no version was requested",
+			responses:     []fakeResponse{delta("v1", fullJSON)},
+			results:       []result{fullResult},
+			expectedSince: []string{""},
+		},
+		{
+			name:          "server returns full data for a delta request",
+			responses:     []fakeResponse{full("v1"), full("v2"), delta("v3", deltaJSON)},
+			results:       []result{fullResult, fullResult, deltaResult},
+			expectedSince: []string{"", "v1", "v2"},
+		},
+		{
+			name:          "server stops supporting delta",
+			responses:     []fakeResponse{full("v1"), full(""), full("")},
+			results:       []result{fullResult, fullResult, fullResult},
+			expectedSince: []string{"", "v1", ""},
+		},
+		{
+			name:          "version too old falls back to full request",
+			responses:     []fakeResponse{full("v1"), status(410), full("v5"), delta("v6", deltaJSON)},
+			results:       []result{fullResult, fullResult, deltaResult},
+			expectedSince: []string{"", "v1", "", "v5"},
+		},
+		{
+			name:          "version too old status is an error if no version was requested",
+			responses:     []fakeResponse{status(410)},
+			results:       []result{{errStatus: 410}},
+			expectedSince: []string{""},
+		},
+		{
+			name:          "HTTP error keeps previous version",
+			responses:     []fakeResponse{full("v1"), status(503), delta("v2", deltaJSON)},
+			results:       []result{fullResult, {errStatus: 503}, deltaResult},
+			expectedSince: []string{"", "v1", "v1"},
+		},
+		{
+			name:          "malformed data discards version",
+			responses:     []fakeResponse{full("v1"), delta("v2", "{"), full("v3")},
+			results:       []result{fullResult, {errStatus: -1}, fullResult},
+			expectedSince: []string{"", "v1", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sinceValues []string
+			responseIndex := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sinceValues = append(sinceValues, r.URL.Query().Get(deltaPollingSinceParam))
+				if !assert.Less(t, responseIndex, len(tt.responses), "too many requests") {
+					w.WriteHeader(500)
+					return
+				}
+				resp := tt.responses[responseIndex]
+				responseIndex++
+				if resp.version != "" {
+					w.Header().Set(deltaPollingVersionHeader, resp.version)
+				}
+				if resp.delta {
+					w.Header().Set(deltaPollingDeltaHeader, "true")
+				}
+				if resp.status != 0 {
+					w.WriteHeader(resp.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(resp.body))
+			})
+			httphelpers.WithServer(handler, func(ts *httptest.Server) {
+				r := newPollingRequester(basicClientContext(), nil, ts.URL, "")
+
+				for i, expected := range tt.results {
+					data, isDelta, cached, err := r.RequestDelta()
+					assert.False(t, cached)
+					switch {
+					case expected.errStatus == -1:
+						assert.Error(t, err, "call %d", i)
+						assert.IsType(t, malformedJSONError{}, err, "call %d", i)
+					case expected.errStatus != 0:
+						if hse, ok := err.(httpStatusError); assert.True(t, ok, "call %d: %s", i, err) {
+							assert.Equal(t, expected.errStatus, hse.Code, "call %d", i)
+						}
+					default:
+						require.NoError(t, err, "call %d", i)
+						assert.Equal(t, expected.delta, isDelta, "call %d", i)
+						flagVersion := 0
+						for _, coll := range data {
+							if coll.Kind == datakinds.Features && len(coll.Items) > 0 {
+								flagVersion = coll.Items[0].Item.Version
+							}
+						}
+						assert.Equal(t, expected.flagVersion, flagVersion, "call %d", i)
+					}
+				}
+				assert.Equal(t, tt.expectedSince, sinceValues)
+			})
+		})
+	}
+}
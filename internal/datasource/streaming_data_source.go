@@ -1,7 +1,9 @@
 package datasource
 
 import (
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"sync"
 	"time"
@@ -42,17 +44,23 @@ import (
 // succeeded (we got an initial payload and successfully stored it) or permanently failed (we got a 401, etc.).
 // Otherwise, the client initialization method may time out but we will still be retrying in the background, and
 // if we succeed then the client can detect that we're initialized now by calling our Initialized method.
+// 5. A "reset" event is not an error: LaunchDarkly sends it before doing maintenance on the stream server, to ask
+// us to reconnect. We restart the stream with the usual backoff and jitter, so that many SDKs don't reconnect at
+// once, but we leave the state as VALID since our data is still up to date.
 
 const (
 	putEvent                 = "put"
 	patchEvent               = "patch"
 	deleteEvent              = "delete"
+	resetEvent               = "reset"
 	streamReadTimeout        = 5 * time.Minute // the LaunchDarkly stream should send a heartbeat comment every 3 minutes
 	streamMaxRetryDelay      = 30 * time.Second
 	streamRetryResetInterval = 60 * time.Second
 	streamJitterRatio        = 0.5
 	defaultStreamRetryDelay  = 1 * time.Second
 
+	streamHeartbeatProbeInterval = time.Second
+
 	streamingErrorContext     = "in stream connection"
 	streamingWillRetryMessage = "will retry"
 )
@@ -60,9 +68,12 @@ const (
 // StreamConfig describes the configuration for a streaming data source. It is exported so that
 // it can be used in the StreamingDataSourceBuilder.
 type StreamConfig struct {
-	URI                   string
-	FilterKey             string
-	InitialReconnectDelay time.Duration
+	URI                         string
+	FilterKey                   string
+	InitialReconnectDelay       time.Duration
+	HeartbeatInterval           time.Duration
+	AcceptEventTypes            []string
+	UseStoreDataWhileConnecting bool
 }
 
 // StreamProcessor is the internal implementation of the streaming data source.
@@ -76,12 +87,16 @@ type StreamProcessor struct {
 	client                     *http.Client
 	headers                    http.Header
 	diagnosticsManager         *ldevents.DiagnosticsManager
+	diagnosticsRecorder        *internal.DiagnosticsRecorder
 	loggers                    ldlog.Loggers
 	isInitialized              internal.AtomicBoolean
+	initializedFromStore       internal.AtomicBoolean
 	halt                       chan struct{}
+	restartCh                  chan struct{}
 	storeStatusCh              <-chan interfaces.DataStoreStatus
 	connectionAttemptStartTime ldtime.UnixMillisecondTime
 	connectionAttemptLock      sync.Mutex
+	heartbeatConn              streamHeartbeatConn
 	readyOnce                  sync.Once
 	closeOnce                  sync.Once
 }
@@ -97,10 +112,13 @@ func NewStreamProcessor(
 		headers:           context.GetHTTP().DefaultHeaders,
 		loggers:           context.GetLogging().Loggers,
 		halt:              make(chan struct{}),
+		restartCh:         make(chan struct{}, 1),
 		cfg:               cfg,
+		heartbeatConn:     streamHeartbeatConn{interval: cfg.HeartbeatInterval},
 	}
 	if cci, ok := context.(*internal.ClientContextImpl); ok {
 		sp.diagnosticsManager = cci.DiagnosticsManager
+		sp.diagnosticsRecorder = cci.DiagnosticsRecorder
 	}
 
 	sp.client = context.GetHTTP().CreateHTTPClient()
@@ -123,10 +141,31 @@ func (sp *StreamProcessor) Start(closeWhenReady chan<- struct{}) {
 	sp.loggers.Info("Starting LaunchDarkly streaming connection")
 	if sp.dataSourceUpdates.GetDataStoreStatusProvider().IsStatusMonitoringEnabled() {
 		sp.storeStatusCh = sp.dataSourceUpdates.GetDataStoreStatusProvider().AddStatusListener()
+		if sp.cfg.UseStoreDataWhileConnecting {
+			sp.useStoreDataIfAvailable(closeWhenReady)
+		}
 	}
 	go sp.subscribe(closeWhenReady)
 }
 
+// useStoreDataIfAvailable implements StreamConfig.UseStoreDataWhileConnecting. It is only called if the
+// data store is a persistent store, which is the only kind that supports status monitoring. If that store
+// has already been initialized by an earlier process, we report that we are initialized right away, so the
+// client can evaluate flags from the stored data; the first "put" event will replace that data as usual.
+func (sp *StreamProcessor) useStoreDataIfAvailable(closeWhenReady chan<- struct{}) {
+	checker, ok := sp.dataSourceUpdates.(dataStoreInitializedChecker)
+	if !ok || !checker.IsDataStoreInitialized() {
+		return
+	}
+	sp.loggers.Info("Using existing data from the persistent data store until the stream connects")
+	sp.initializedFromStore.Set(true)
+	sp.isInitialized.Set(true)
+	sp.dataSourceUpdates.UpdateStatus(interfaces.DataSourceStateInitializedFromStore, interfaces.DataSourceErrorInfo{})
+	sp.readyOnce.Do(func() {
+		close(closeWhenReady)
+	})
+}
+
 func (sp *StreamProcessor) consumeStream(stream *es.Stream, closeWhenReady chan<- struct{}) {
 	// Consume remaining Events and Errors so we can garbage collect
 	defer func() {
@@ -138,6 +177,22 @@ func (sp *StreamProcessor) consumeStream(stream *es.Stream, closeWhenReady chan<
 		}
 	}()
 
+	// A restart that was requested before we connected isn't needed, since the connection we just made
+	// already reflects whatever changed.
+	select {
+	case <-sp.restartCh:
+	default:
+	}
+
+	// If a heartbeat interval is configured, this timer fires when no event has arrived for that long.
+	var heartbeatTimer *time.Timer
+	var heartbeatCh <-chan time.Time
+	if sp.cfg.HeartbeatInterval > 0 {
+		heartbeatTimer = time.NewTimer(sp.cfg.HeartbeatInterval)
+		defer heartbeatTimer.Stop()
+		heartbeatCh = heartbeatTimer.C
+	}
+
 	for {
 		select {
 		case event, ok := <-stream.Events:
@@ -150,6 +205,22 @@ func (sp *StreamProcessor) consumeStream(stream *es.Stream, closeWhenReady chan<
 				return
 			}
 			sp.logConnectionResult(true)
+			if heartbeatTimer != nil {
+				if !heartbeatTimer.Stop() {
+					select {
+					case <-heartbeatTimer.C:
+					default:
+					}
+				}
+				heartbeatTimer.Reset(sp.cfg.HeartbeatInterval)
+				sp.heartbeatConn.setProbing(false)
+			}
+			if !sp.acceptsEventType(event.Event()) {
+				if sp.loggers.IsDebugEnabled() {
+					sp.loggers.Debugf("Ignoring stream event of type %s", event.Event())
+				}
+				continue
+			}
 
 			processedEvent := true
 			shouldRestart := false
@@ -223,6 +294,14 @@ func (sp *StreamProcessor) consumeStream(stream *es.Stream, closeWhenReady chan<
 					storeUpdateFailed("streaming deletion of " + del.Key)
 				}
 
+			case resetEvent:
+				sp.loggers.Info("LaunchDarkly asked for the stream to be reconnected; reconnecting")
+				if sp.diagnosticsRecorder != nil {
+					sp.diagnosticsRecorder.RecordOrderlyStreamReconnect()
+				}
+				sp.logConnectionStarted()
+				shouldRestart = true // scenario 5 in error handling comments at top of file
+
 			default:
 				sp.loggers.Infof("Unexpected event found in stream: %s", event.Event())
 			}
@@ -252,6 +331,16 @@ func (sp *StreamProcessor) consumeStream(stream *es.Stream, closeWhenReady chan<
 				sp.setInitializedAndNotifyClient(true, closeWhenReady)
 			}
 
+		case <-heartbeatCh:
+			// We don't reset the timer here, so this is logged only once until we get another event.
+			sp.loggers.Warnf("No stream event received in %s; checking whether the connection is still alive",
+				sp.cfg.HeartbeatInterval)
+			sp.heartbeatConn.setProbing(true)
+
+		case <-sp.restartCh:
+			sp.loggers.Info("Restarting stream connection")
+			stream.Restart()
+
 		case <-sp.halt:
 			stream.Close()
 			return
@@ -283,6 +372,11 @@ func (sp *StreamProcessor) subscribe(closeWhenReady chan<- struct{}) {
 	if sp.headers != nil {
 		req.Header = maps.Clone(sp.headers)
 	}
+	if sp.cfg.HeartbeatInterval > 0 {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { sp.heartbeatConn.setConn(info.Conn) },
+		}))
+	}
 	sp.loggers.Info("Connecting to LaunchDarkly stream")
 
 	sp.logConnectionStarted()
@@ -359,7 +453,7 @@ func (sp *StreamProcessor) subscribe(closeWhenReady chan<- struct{}) {
 func (sp *StreamProcessor) setInitializedAndNotifyClient(success bool, closeWhenReady chan<- struct{}) {
 	if success {
 		wasAlreadyInitialized := sp.isInitialized.GetAndSet(true)
-		if !wasAlreadyInitialized {
+		if !wasAlreadyInitialized || sp.initializedFromStore.GetAndSet(false) {
 			sp.loggers.Info("LaunchDarkly streaming is active")
 		}
 	}
@@ -383,6 +477,19 @@ func (sp *StreamProcessor) logConnectionResult(success bool) {
 	if startTimeWas > 0 && sp.diagnosticsManager != nil {
 		timestamp := ldtime.UnixMillisNow()
 		sp.diagnosticsManager.RecordStreamInit(timestamp, !success, uint64(timestamp-startTimeWas))
+		if sp.diagnosticsRecorder != nil {
+			sp.diagnosticsRecorder.RecordStreamInit(timestamp, !success, uint64(timestamp-startTimeWas))
+		}
+	}
+}
+
+// RestartStream closes the current stream connection, if any, and opens a new one. This is used when the
+// SDK key has changed, so that the connection uses the new key. It has no effect if the stream has not
+// connected yet.
+func (sp *StreamProcessor) RestartStream() {
+	select {
+	case sp.restartCh <- struct{}{}:
+	default: // a restart is already pending
 	}
 }
 
@@ -398,6 +505,69 @@ func (sp *StreamProcessor) Close() error {
 	return nil
 }
 
+// acceptsEventType returns true if the event type is in the configured AcceptEventTypes list, or if the list
+// is empty. A "put" event is always accepted, since without it the data source could never be initialized, and
+// so is a "reset" event, since ignoring it would leave the stream to be dropped by server maintenance.
+func (sp *StreamProcessor) acceptsEventType(eventType string) bool {
+	if len(sp.cfg.AcceptEventTypes) == 0 || eventType == putEvent || eventType == resetEvent {
+		return true
+	}
+	for _, t := range sp.cfg.AcceptEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// streamHeartbeatConn keeps track of the TCP connection that the stream is using, so that we can control
+// its keepalive probes. Normally the connection sends a probe whenever it has been idle for the heartbeat
+// interval; while we are waiting for an overdue event, it sends them much more often, so that a connection
+// that has been silently dropped by a proxy or firewall is detected quickly. When the probes go unanswered,
+// the operating system closes the connection and the stream reconnects as it would for any network error.
+type streamHeartbeatConn struct {
+	interval time.Duration // set by NewStreamProcessor and not changed after that, so it needs no lock
+	conn     tcpKeepAliveConn
+	probing  bool
+	lock     sync.Mutex
+}
+
+type tcpKeepAliveConn interface {
+	SetKeepAlive(bool) error
+	SetKeepAlivePeriod(time.Duration) error
+}
+
+func (h *streamHeartbeatConn) setConn(conn net.Conn) {
+	if tlsConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = tlsConn.NetConn()
+	}
+	kc, _ := conn.(tcpKeepAliveConn) // if this isn't a TCP connection, we can't use keepalives
+	if kc != nil {
+		_ = kc.SetKeepAlive(true)
+		_ = kc.SetKeepAlivePeriod(h.interval)
+	}
+	h.lock.Lock()
+	h.conn = kc
+	h.probing = false
+	h.lock.Unlock()
+}
+
+func (h *streamHeartbeatConn) setProbing(probing bool) {
+	h.lock.Lock()
+	conn := h.conn
+	changed := h.probing != probing
+	h.probing = probing
+	h.lock.Unlock()
+	if conn == nil || !changed {
+		return
+	}
+	period := h.interval
+	if probing && period > streamHeartbeatProbeInterval {
+		period = streamHeartbeatProbeInterval
+	}
+	_ = conn.SetKeepAlivePeriod(period)
+}
+
 // GetBaseURI returns the configured streaming base URI, for testing.
 func (sp *StreamProcessor) GetBaseURI() string {
 	return sp.cfg.URI
@@ -408,6 +578,16 @@ func (sp *StreamProcessor) GetInitialReconnectDelay() time.Duration {
 	return sp.cfg.InitialReconnectDelay
 }
 
+// GetHeartbeatInterval returns the configured heartbeat interval, for testing.
+func (sp *StreamProcessor) GetHeartbeatInterval() time.Duration {
+	return sp.cfg.HeartbeatInterval
+}
+
+// GetAcceptEventTypes returns the configured event type filter, for testing.
+func (sp *StreamProcessor) GetAcceptEventTypes() []string {
+	return sp.cfg.AcceptEventTypes
+}
+
 // GetFilterKey returns the configured key, for testing.
 func (sp *StreamProcessor) GetFilterKey() string {
 	return sp.cfg.FilterKey
@@ -95,10 +95,10 @@ func parsePutData(data []byte) (putData, error) {
 func parsePatchData(data []byte) (patchData, error) {
 	var ret patchData
 	r := jreader.NewReader(data)
-	var kind datakinds.DataKindInternal
+	var kind ldstoretypes.DataKind
 	var key string
 	parseItem := func() (patchData, error) {
-		item, err := kind.DeserializeFromJSONReader(&r)
+		item, err := datakinds.DeserializeFromJSONReader(kind, &r)
 		if err != nil {
 			return patchData{}, err
 		}
@@ -166,13 +166,17 @@ func parseDeleteData(data []byte) (deleteData, error) {
 	return ret, nil
 }
 
-func parsePath(path string) (datakinds.DataKindInternal, string) {
-	switch {
-	case strings.HasPrefix(path, "/segments/"):
-		return datakinds.Segments, strings.TrimPrefix(path, "/segments/")
-	case strings.HasPrefix(path, "/flags/"):
-		return datakinds.Features, strings.TrimPrefix(path, "/flags/")
-	default:
+// parsePath converts a path such as "/flags/flagkey" into a data kind and key. The first part of the path is
+// "flags", "segments", or the payload name of a data kind that was registered with
+// ldstoreimpl.RegisterDataKind; for anything else, it returns a nil kind.
+func parsePath(path string) (ldstoretypes.DataKind, string) {
+	payloadName, key, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !found || !strings.HasPrefix(path, "/") {
 		return nil, ""
 	}
+	kind := datakinds.DataKindForPayloadName(payloadName)
+	if kind == nil {
+		return nil, ""
+	}
+	return kind, key
 }
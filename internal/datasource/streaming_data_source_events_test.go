@@ -6,9 +6,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
 func TestParsePutData(t *testing.T) {
@@ -152,3 +155,48 @@ func TestParseDeleteData(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestParseDataOfRegisteredKinds(t *testing.T) {
+	require.NoError(t, datakinds.RegisterAdditionalDataKind(mocks.MockJSONData, "mockItems"))
+	defer datakinds.ResetAdditionalDataKinds()
+	item := ldvalue.ObjectBuild().Set("version", ldvalue.Int(2)).Build()
+
+	t.Run("put", func(t *testing.T) {
+		input := []byte(`{"path": "/", "data": {"mockItems": {"item1": {"version": 2}}, "cats": {"lucy": {}}}}`)
+		result, err := parsePutData(input)
+		require.NoError(t, err)
+
+		assert.Equal(t, []ldstoretypes.Collection{{Kind: mocks.MockJSONData, Items: []ldstoretypes.KeyedItemDescriptor{
+			{Key: "item1", Item: ldstoretypes.ItemDescriptor{Version: 2, Item: item}},
+		}}}, result.Data)
+	})
+
+	t.Run("put with item that can't be deserialized", func(t *testing.T) {
+		_, err := parsePutData([]byte(`{"path": "/", "data": {"mockItems": {"item1": "x"}}}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		result, err := parsePatchData([]byte(`{"path": "/mockItems/item1", "data": {"version": 2}}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, mocks.MockJSONData, result.Kind)
+		assert.Equal(t, "item1", result.Key)
+		assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 2, Item: item}, result.Data)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		result, err := parseDeleteData([]byte(`{"path": "/mockItems/item1", "version": 3}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, mocks.MockJSONData, result.Kind)
+		assert.Equal(t, "item1", result.Key)
+		assert.Equal(t, 3, result.Version)
+	})
+
+	t.Run("path without a key is still unrecognized", func(t *testing.T) {
+		result, err := parseDeleteData([]byte(`{"path": "/mockItems", "version": 3}`))
+		require.NoError(t, err)
+		assert.Nil(t, result.Kind)
+	})
+}
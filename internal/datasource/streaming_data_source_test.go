@@ -3,6 +3,7 @@ package datasource
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,8 +18,10 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
 
 	"github.com/launchdarkly/eventsource"
@@ -26,6 +29,7 @@ import (
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -294,6 +298,32 @@ func TestStreamProcessorUnrecognizedDataIsIgnored(t *testing.T) {
 	})
 }
 
+func TestStreamProcessorRegisteredDataKinds(t *testing.T) {
+	// This test is not parallel, because the registered data kinds apply to every test.
+	require.NoError(t, datakinds.RegisterAdditionalDataKind(mocks.MockJSONData, "mockItems"))
+	defer datakinds.ResetAdditionalDataKinds()
+	timeout := 3 * time.Second
+
+	runStreamingTest(t, ldservices.NewServerSDKData(), func(p streamingTestParams) {
+		p.updates.DataStore.WaitForNextInit(t, timeout)
+
+		p.stream.Send(httphelpers.SSEEvent{Event: putEvent,
+			Data: `{"path": "/", "data": {"flags": {}, "mockItems": {"item1": {"version": 1}}}}`})
+		inited := p.updates.DataStore.WaitForNextInit(t, timeout)
+		item1 := ldstoretypes.KeyedItemDescriptor{Key: "item1",
+			Item: ldstoretypes.ItemDescriptor{Version: 1, Item: ldvalue.ObjectBuild().Set("version", ldvalue.Int(1)).Build()}}
+		assert.Contains(t, inited,
+			ldstoretypes.Collection{Kind: mocks.MockJSONData, Items: []ldstoretypes.KeyedItemDescriptor{item1}})
+
+		p.stream.Send(httphelpers.SSEEvent{Event: patchEvent,
+			Data: `{"path": "/mockItems/item1", "data": {"version": 2}}`})
+		p.updates.DataStore.WaitForUpsert(t, mocks.MockJSONData, "item1", 2, timeout)
+
+		p.stream.Send(httphelpers.SSEEvent{Event: deleteEvent, Data: `{"path": "/mockItems/item1", "version": 3}`})
+		p.updates.DataStore.WaitForDelete(t, mocks.MockJSONData, "item1", 3, timeout)
+	})
+}
+
 func TestStreamProcessorStoreUpdateFailureWithStatusTracking(t *testing.T) {
 	// Normally, a data store can only fail if it is a persistent store that uses the standard
 	// PersistentDataStore framework, in which case store status tracking is available and the
@@ -536,6 +566,61 @@ func TestStreamProcessorRestartsStreamIfStoreNeedsRefresh(t *testing.T) {
 	})
 }
 
+func TestStreamProcessorReconnectsWithoutErrorOnResetEvent(t *testing.T) {
+	initialData := ldservices.NewServerSDKData().Flags(ldservices.KeyAndVersionItem("my-flag", 1))
+	updatedData := ldservices.NewServerSDKData().Flags(ldservices.KeyAndVersionItem("my-flag", 2))
+	streamHandler1, stream1 := ldservices.ServerSideStreamingServiceHandler(initialData.ToPutEvent())
+	defer stream1.Close()
+	streamHandler2, _ := ldservices.ServerSideStreamingServiceHandler(updatedData.ToPutEvent())
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.SequentialHandler(streamHandler1, streamHandler2))
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	httphelpers.WithServer(handler, func(ts *httptest.Server) {
+		withMockDataSourceUpdates(func(updates *mocks.MockDataSourceUpdates) {
+			id := ldevents.NewDiagnosticID(testSDKKey)
+			diagnosticsManager := ldevents.NewDiagnosticsManager(id, ldvalue.Null(), ldvalue.Null(), time.Now(), nil)
+			diagnosticsRecorder := internal.NewDiagnosticsRecorder(diagnosticsManager)
+			context := &internal.ClientContextImpl{
+				BasicClientContext: subsystems.BasicClientContext{
+					SDKKey:  testSDKKey,
+					Logging: subsystems.LoggingConfiguration{Loggers: mockLog.Loggers},
+				},
+				DiagnosticsManager:  diagnosticsManager,
+				DiagnosticsRecorder: diagnosticsRecorder,
+			}
+
+			sp := NewStreamProcessor(context, updates, StreamConfig{
+				URI:                   ts.URL,
+				InitialReconnectDelay: briefDelay,
+				AcceptEventTypes:      []string{patchEvent}, // "reset" is always accepted
+			})
+			defer sp.Close()
+
+			closeWhenReady := make(chan struct{})
+			sp.Start(closeWhenReady)
+			updates.DataStore.WaitForInit(t, initialData, time.Second)
+			updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+			<-requestsCh
+
+			stream1.Send(httphelpers.SSEEvent{Event: resetEvent, Data: "{}"})
+
+			th.RequireValue(t, requestsCh, time.Second, "expected stream to reconnect, did not see a new request")
+			updates.DataStore.WaitForInit(t, updatedData, time.Second)
+			th.AssertNoMoreValues(t, updates.Statuses, briefDelay, "data source status should have stayed VALID")
+
+			assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+			assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+			mockLog.AssertMessageMatch(t, true, ldlog.Info, "asked for the stream to be reconnected")
+
+			event := diagnosticsManager.CreateStatsEventAndReset(0, 0, 0)
+			assert.Equal(t, 2, event.GetByKey("streamInits").Count())
+			assert.Equal(t, ldvalue.Bool(false), event.GetByKey("streamInits").GetByIndex(1).GetByKey("failed"))
+			assert.Equal(t, 1, diagnosticsRecorder.CreateReport().GetByKey("orderlyStreamReconnects").IntValue())
+		})
+	})
+}
+
 func TestMalformedStreamBaseURI(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
@@ -587,3 +672,172 @@ func TestStreamProcessorAppendsFilterParameter(t *testing.T) {
 		})
 	})
 }
+
+type fakeKeepAliveConn struct {
+	net.Conn
+	keepAlive bool
+	periods   []time.Duration
+}
+
+func (c *fakeKeepAliveConn) SetKeepAlive(keepAlive bool) error {
+	c.keepAlive = keepAlive
+	return nil
+}
+
+func (c *fakeKeepAliveConn) SetKeepAlivePeriod(period time.Duration) error {
+	c.periods = append(c.periods, period)
+	return nil
+}
+
+func TestStreamHeartbeatConnControlsKeepAlivePeriod(t *testing.T) {
+	conn := &fakeKeepAliveConn{}
+	h := streamHeartbeatConn{interval: time.Minute}
+
+	h.setConn(conn)
+	assert.True(t, conn.keepAlive)
+	assert.Equal(t, []time.Duration{time.Minute}, conn.periods)
+
+	h.setProbing(true)
+	h.setProbing(true)
+	h.setProbing(false)
+	h.setProbing(false)
+	assert.Equal(t, []time.Duration{time.Minute, streamHeartbeatProbeInterval, time.Minute}, conn.periods)
+}
+
+func TestStreamProcessorLogsWarningIfNoEventWithinHeartbeatInterval(t *testing.T) {
+	streamHandler, stream := ldservices.ServerSideStreamingServiceHandler(ldservices.NewServerSDKData().ToPutEvent())
+	defer stream.Close()
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	context := sharedtest.NewTestContext("", nil, &subsystems.LoggingConfiguration{Loggers: mockLog.Loggers})
+
+	httphelpers.WithServer(streamHandler, func(ts *httptest.Server) {
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			sp := NewStreamProcessor(context, dataSourceUpdates, StreamConfig{
+				URI:                   ts.URL,
+				InitialReconnectDelay: briefDelay,
+				HeartbeatInterval:     time.Millisecond * 50,
+			})
+			defer sp.Close()
+
+			closeWhenReady := make(chan struct{})
+			sp.Start(closeWhenReady)
+			<-closeWhenReady
+
+			if !assert.Eventually(t, func() bool {
+				return len(mockLog.GetOutput(ldlog.Warn)) > 0
+			}, time.Second, time.Millisecond*10) {
+				return
+			}
+			mockLog.AssertMessageMatch(t, true, ldlog.Warn, "No stream event received in 50ms")
+
+			sp.heartbeatConn.lock.Lock()
+			defer sp.heartbeatConn.lock.Unlock()
+			assert.NotNil(t, sp.heartbeatConn.conn)
+			assert.True(t, sp.heartbeatConn.probing)
+		})
+	})
+}
+
+func TestStreamProcessorAcceptEventTypes(t *testing.T) {
+	initialData := ldservices.NewServerSDKData().Flags(ldservices.KeyAndVersionItem("my-flag", 2))
+	streamHandler, stream := ldservices.ServerSideStreamingServiceHandler(initialData.ToPutEvent())
+	defer stream.Close()
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	context := sharedtest.NewTestContext("", nil, &subsystems.LoggingConfiguration{Loggers: mockLog.Loggers})
+
+	httphelpers.WithServer(streamHandler, func(ts *httptest.Server) {
+		withMockDataSourceUpdates(func(dataSourceUpdates *mocks.MockDataSourceUpdates) {
+			sp := NewStreamProcessor(context, dataSourceUpdates, StreamConfig{
+				URI:                   ts.URL,
+				InitialReconnectDelay: briefDelay,
+				AcceptEventTypes:      []string{deleteEvent},
+			})
+			defer sp.Close()
+
+			closeWhenReady := make(chan struct{})
+			sp.Start(closeWhenReady)
+			<-closeWhenReady
+			dataSourceUpdates.DataStore.WaitForInit(t, initialData, time.Second) // "put" is always accepted
+
+			// This would cause an error and a stream restart if it were parsed
+			stream.Send(httphelpers.SSEEvent{Event: patchEvent, Data: `{"path": "/flags/my-flag", "data": x}`})
+			stream.Send(httphelpers.SSEEvent{Event: deleteEvent, Data: `{"path": "/flags/my-flag", "version": 4}`})
+
+			dataSourceUpdates.DataStore.WaitForDelete(t, datakinds.Features, "my-flag", 4, time.Second)
+			assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+		})
+	})
+}
+
+func TestStreamProcessorUseStoreDataWhileConnecting(t *testing.T) {
+	initialData := ldservices.NewServerSDKData().Flags(ldservices.KeyAndVersionItem("my-flag", 2))
+
+	withStreamProcessor := func(
+		t *testing.T,
+		storeInited bool,
+		statusMonitoring bool,
+		action func(*StreamProcessor, *mocks.MockDataSourceUpdates, httphelpers.SSEStreamControl, <-chan struct{}),
+	) {
+		streamHandler, stream := httphelpers.SSEHandler(nil) // the "put" is only sent when the test says so
+		defer stream.Close()
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+		context := sharedtest.NewTestContext("", nil, &subsystems.LoggingConfiguration{Loggers: mockLog.Loggers})
+
+		store := datastore.NewInMemoryDataStore(mockLog.Loggers)
+		if storeInited {
+			_ = store.Init(nil)
+		}
+		dataSourceUpdates := mocks.NewMockDataSourceUpdates(store)
+		dataSourceUpdates.DataStore.SetStatusMonitoringEnabled(statusMonitoring)
+
+		httphelpers.WithServer(streamHandler, func(ts *httptest.Server) {
+			sp := NewStreamProcessor(context, dataSourceUpdates, StreamConfig{
+				URI:                         ts.URL,
+				InitialReconnectDelay:       briefDelay,
+				UseStoreDataWhileConnecting: true,
+			})
+			defer sp.Close()
+
+			closeWhenReady := make(chan struct{})
+			sp.Start(closeWhenReady)
+			action(sp, dataSourceUpdates, stream, closeWhenReady)
+		})
+	}
+
+	t.Run("initialized persistent store", func(t *testing.T) {
+		withStreamProcessor(t, true, true, func(sp *StreamProcessor, updates *mocks.MockDataSourceUpdates,
+			stream httphelpers.SSEStreamControl, closeWhenReady <-chan struct{}) {
+			th.AssertChannelClosed(t, closeWhenReady, time.Millisecond*100, "data source should be ready right away")
+			assert.True(t, sp.IsInitialized())
+			updates.RequireStatusOf(t, interfaces.DataSourceStateInitializedFromStore)
+
+			stream.Enqueue(initialData.ToPutEvent())
+			updates.DataStore.WaitForInit(t, initialData, time.Second)
+			updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+			assert.True(t, sp.IsInitialized())
+		})
+	})
+
+	t.Run("uninitialized persistent store", func(t *testing.T) {
+		withStreamProcessor(t, false, true, func(sp *StreamProcessor, updates *mocks.MockDataSourceUpdates,
+			stream httphelpers.SSEStreamControl, closeWhenReady <-chan struct{}) {
+			th.AssertChannelNotClosed(t, closeWhenReady, briefDelay)
+			assert.False(t, sp.IsInitialized())
+
+			stream.Enqueue(initialData.ToPutEvent())
+			th.AssertChannelClosed(t, closeWhenReady, time.Second)
+			updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+		})
+	})
+
+	t.Run("in-memory store", func(t *testing.T) {
+		withStreamProcessor(t, true, false, func(sp *StreamProcessor, updates *mocks.MockDataSourceUpdates,
+			stream httphelpers.SSEStreamControl, closeWhenReady <-chan struct{}) {
+			th.AssertChannelNotClosed(t, closeWhenReady, briefDelay)
+			assert.False(t, sp.IsInitialized())
+		})
+	})
+}
@@ -1,6 +1,8 @@
 package datastore
 
 import (
+	"sync/atomic"
+
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 )
@@ -10,6 +12,7 @@ import (
 type dataStoreStatusProviderImpl struct {
 	store            subsystems.DataStore
 	dataStoreUpdates *DataStoreUpdateSinkImpl
+	rejectedUpdates  atomic.Int64
 }
 
 // NewDataStoreStatusProviderImpl creates the internal implementation of DataStoreStatusProvider.
@@ -38,3 +41,13 @@ func (d *dataStoreStatusProviderImpl) AddStatusListener() <-chan interfaces.Data
 func (d *dataStoreStatusProviderImpl) RemoveStatusListener(ch <-chan interfaces.DataStoreStatus) {
 	d.dataStoreUpdates.getBroadcaster().RemoveListener(ch)
 }
+
+func (d *dataStoreStatusProviderImpl) GetRejectedUpdateCount() int {
+	return int(d.rejectedUpdates.Load())
+}
+
+// RecordRejectedUpdate is called by the SDK's DataSourceUpdateSink when the data store ignores an update
+// because it already has an equal or newer version.
+func (d *dataStoreStatusProviderImpl) RecordRejectedUpdate() {
+	d.rejectedUpdates.Add(1)
+}
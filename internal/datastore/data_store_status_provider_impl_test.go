@@ -2,11 +2,13 @@ package datastore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
@@ -66,11 +68,9 @@ func TestDataStoreStatusProviderImpl(t *testing.T) {
 			newStatus := interfaces.DataStoreStatus{Available: false}
 			p.dataStoreUpdates.UpdateStatus(newStatus)
 
-			require.Len(t, ch1, 1)
-			require.Len(t, ch2, 0)
-			require.Len(t, ch3, 1)
-			assert.Equal(t, newStatus, <-ch1)
-			assert.Equal(t, newStatus, <-ch3)
+			assert.Equal(t, newStatus, th.RequireValue(t, ch1, time.Second))
+			assert.Equal(t, newStatus, th.RequireValue(t, ch3, time.Second))
+			th.AssertChannelClosed(t, ch2, time.Millisecond)
 		})
 	})
 }
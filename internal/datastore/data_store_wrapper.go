@@ -0,0 +1,45 @@
+package datastore
+
+import (
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// DataStoreWrapper is implemented by data stores that delegate to another data store, such as the one
+// created by ldmonitor.DataStoreSizeMonitor, so that the SDK can still find out what kind of store it is.
+type DataStoreWrapper interface {
+	// UnwrapDataStore returns the data store that this one delegates to.
+	UnwrapDataStore() subsystems.DataStore
+}
+
+// DataStoreFactoryWrapper is implemented by data store factories that build a wrapper around the store
+// from another factory; see DataStoreWrapper.
+type DataStoreFactoryWrapper interface {
+	// UnwrapDataStoreFactory returns the factory whose store this one wraps.
+	UnwrapDataStoreFactory() subsystems.ComponentConfigurer[subsystems.DataStore]
+}
+
+// UnwrapDataStore returns the innermost data store of any DataStoreWrappers, or the store itself if it is
+// not a wrapper.
+func UnwrapDataStore(store subsystems.DataStore) subsystems.DataStore {
+	for {
+		w, ok := store.(DataStoreWrapper)
+		if !ok {
+			return store
+		}
+		store = w.UnwrapDataStore()
+	}
+}
+
+// UnwrapDataStoreFactory returns the innermost factory of any DataStoreFactoryWrappers, or the factory
+// itself if it is not a wrapper.
+func UnwrapDataStoreFactory(
+	factory subsystems.ComponentConfigurer[subsystems.DataStore],
+) subsystems.ComponentConfigurer[subsystems.DataStore] {
+	for {
+		w, ok := factory.(DataStoreFactoryWrapper)
+		if !ok {
+			return factory
+		}
+		factory = w.UnwrapDataStoreFactory()
+	}
+}
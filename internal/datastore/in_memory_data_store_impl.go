@@ -1,9 +1,11 @@
 package datastore
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
@@ -34,6 +36,57 @@ func NewInMemoryDataStore(loggers ldlog.Loggers) subsystems.DataStore {
 	}
 }
 
+// IsInMemoryDataStore returns true if the store was created by NewInMemoryDataStore or
+// NewInMemoryDataStoreWithData. Operations on such a store never block for long.
+func IsInMemoryDataStore(store subsystems.DataStore) bool {
+	_, ok := store.(*inMemoryDataStore)
+	return ok
+}
+
+// NewInMemoryDataStoreWithData creates an instance of the in-memory data store that is already initialized
+// with the specified data, as if Init had been called. It returns an error, and no store, if the data has
+// the same kind or key more than once, or an item that has the deleted property but is not a tombstone.
+func NewInMemoryDataStoreWithData(
+	allData []ldstoretypes.Collection,
+	loggers ldlog.Loggers,
+) (subsystems.DataStore, error) {
+	if err := validateInitData(allData); err != nil {
+		return nil, err
+	}
+	store := NewInMemoryDataStore(loggers)
+	_ = store.Init(allData) // the in-memory store's Init never fails
+	return store, nil
+}
+
+func validateInitData(allData []ldstoretypes.Collection) error {
+	kinds := make(map[ldstoretypes.DataKind]bool, len(allData))
+	for _, coll := range allData {
+		if kinds[coll.Kind] {
+			return fmt.Errorf("data kind %s is specified more than once", coll.Kind)
+		}
+		kinds[coll.Kind] = true
+		keys := make(map[string]bool, len(coll.Items))
+		for _, item := range coll.Items {
+			if keys[item.Key] {
+				return fmt.Errorf("%s '%s' is specified more than once", coll.Kind, item.Key)
+			}
+			keys[item.Key] = true
+			deleted := false
+			switch value := item.Item.Item.(type) {
+			case *ldmodel.FeatureFlag:
+				deleted = value.Deleted
+			case *ldmodel.Segment:
+				deleted = value.Deleted
+			}
+			if deleted {
+				return fmt.Errorf("%s '%s' is marked as deleted; a deleted item must be a tombstone with no item",
+					coll.Kind, item.Key)
+			}
+		}
+	}
+	return nil
+}
+
 func (store *inMemoryDataStore) Init(allData []ldstoretypes.Collection) error {
 	store.Lock()
 
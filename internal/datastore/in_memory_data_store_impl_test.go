@@ -8,6 +8,7 @@ import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
@@ -33,6 +34,73 @@ func TestInMemoryDataStore(t *testing.T) {
 	})
 }
 
+func TestNewInMemoryDataStoreWithData(t *testing.T) {
+	t.Run("store is initialized with data", func(t *testing.T) {
+		flag := ldbuilders.NewFlagBuilder("flag").Version(2).Build()
+		segment := ldbuilders.NewSegmentBuilder("segment").Version(3).Build()
+		allData := sharedtest.NewDataSetBuilder().Flags(flag).Segments(segment).Build()
+		allData[0].Items = append(allData[0].Items,
+			ldstoretypes.KeyedItemDescriptor{Key: "deleted-flag", Item: ldstoretypes.ItemDescriptor{Version: 4}})
+
+		store, err := NewInMemoryDataStoreWithData(allData, sharedtest.NewTestLoggers())
+		require.NoError(t, err)
+
+		assert.True(t, store.IsInitialized())
+		result, err := store.Get(datakinds.Features, "flag")
+		require.NoError(t, err)
+		assert.Equal(t, sharedtest.FlagDescriptor(flag), result)
+		result, err = store.Get(datakinds.Segments, "segment")
+		require.NoError(t, err)
+		assert.Equal(t, sharedtest.SegmentDescriptor(segment), result)
+		result, err = store.Get(datakinds.Features, "deleted-flag")
+		require.NoError(t, err)
+		assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 4}, result)
+	})
+
+	t.Run("empty data", func(t *testing.T) {
+		store, err := NewInMemoryDataStoreWithData(nil, sharedtest.NewTestLoggers())
+		require.NoError(t, err)
+		assert.True(t, store.IsInitialized())
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		allData := sharedtest.NewDataSetBuilder().
+			Flags(ldbuilders.NewFlagBuilder("flag").Version(1).Build(), ldbuilders.NewFlagBuilder("flag").Version(2).Build()).
+			Build()
+
+		store, err := NewInMemoryDataStoreWithData(allData, sharedtest.NewTestLoggers())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "features 'flag' is specified more than once")
+		assert.Nil(t, store)
+	})
+
+	t.Run("duplicate kind", func(t *testing.T) {
+		allData := []ldstoretypes.Collection{{Kind: datakinds.Segments}, {Kind: datakinds.Segments}}
+
+		_, err := NewInMemoryDataStoreWithData(allData, sharedtest.NewTestLoggers())
+		assert.Error(t, err)
+	})
+
+	t.Run("deleted item that is not a tombstone", func(t *testing.T) {
+		forAllDataKinds(t, func(t *testing.T, kind ldstoretypes.DataKind, makeItem dataItemCreator) {
+			item := makeItem("key", 1, false)
+			switch i := item.Item.(type) {
+			case *ldmodel.FeatureFlag:
+				i.Deleted = true
+			case *ldmodel.Segment:
+				i.Deleted = true
+			}
+			allData := []ldstoretypes.Collection{
+				{Kind: kind, Items: []ldstoretypes.KeyedItemDescriptor{{Key: "key", Item: item}}},
+			}
+
+			_, err := NewInMemoryDataStoreWithData(allData, sharedtest.NewTestLoggers())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "is marked as deleted")
+		})
+	})
+}
+
 func makeInMemoryStore() subsystems.DataStore {
 	return NewInMemoryDataStore(sharedtest.NewTestLoggers())
 }
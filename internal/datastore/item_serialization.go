@@ -0,0 +1,33 @@
+package datastore
+
+import (
+	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// SerializeItem converts an item to the form that is stored in a PersistentDataStore. A deleted item
+// (one whose Item is nil) is still serialized, as a placeholder that has the version number, so stores
+// that cannot keep the version and deleted state separately can still get them from the data.
+func SerializeItem(kind st.DataKind, item st.ItemDescriptor) st.SerializedItemDescriptor {
+	return st.SerializedItemDescriptor{
+		Version:        item.Version,
+		Deleted:        item.Item == nil,
+		SerializedItem: kind.Serialize(item),
+	}
+}
+
+// DeserializeItem converts an item that was returned by a PersistentDataStore back to the form that the
+// SDK uses. This is the reverse of SerializeItem.
+func DeserializeItem(kind st.DataKind, serializedItemDesc st.SerializedItemDescriptor) (st.ItemDescriptor, error) {
+	if serializedItemDesc.Deleted || serializedItemDesc.SerializedItem == nil {
+		return st.ItemDescriptor{Version: serializedItemDesc.Version}, nil
+	}
+	deserializedItemDesc, err := kind.Deserialize(serializedItemDesc.SerializedItem)
+	if err != nil {
+		return st.ItemDescriptor{}.NotFound(), err
+	}
+	if serializedItemDesc.Version == 0 || serializedItemDesc.Version == deserializedItemDesc.Version {
+		return deserializedItemDesc, nil
+	}
+	// If the store gave us a version number that isn't what was encoded in the object, trust it
+	return st.ItemDescriptor{Version: serializedItemDesc.Version, Item: deserializedItemDesc.Item}, nil
+}
@@ -3,6 +3,7 @@ package datastore
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -23,19 +24,45 @@ type persistentDataStoreWrapper struct {
 	cache            *cache.Cache
 	cacheTTL         time.Duration
 	requests         singleflight.Group
+	metrics          subsystems.DataStoreMetricsSink
+	cacheHits        atomic.Int64
+	cacheMisses      atomic.Int64
 	loggers          ldlog.Loggers
+	incrementalInit  bool
 	inited           bool
+	updateGeneration uint64 // incremented by every Init and Upsert; see Preload
 	initLock         sync.RWMutex
 }
 
 const initCheckedKey = "$initChecked"
 
+// CacheStatsProvider is implemented by the persistent data store wrapper, for LDClient.GetStats.
+type CacheStatsProvider interface {
+	// GetCacheStats returns the number of Get and GetAll queries that were answered from the cache, and the
+	// number that had to query the underlying store. The last return value is false if caching is disabled.
+	GetCacheStats() (hits int64, misses int64, enabled bool)
+}
+
+// PreloadableDataStore is implemented by data stores that can read their existing data ahead of time. The
+// SDK calls Preload once at startup, before the data source is started.
+type PreloadableDataStore interface {
+	// Preload checks whether the store has been initialized and, if so, reads all of its data so that
+	// subsequent queries do not need to access the underlying database.
+	Preload()
+}
+
 // NewPersistentDataStoreWrapper creates the implementation of DataStore that we use for all persistent data
 // stores. This is not visible in the public API; it is always called through ldcomponents.PersistentDataStore().
+//
+// If incrementalInit is true, then once the store is known to be initialized, Init compares the new data
+// with what is already in the store and writes only the items that changed. If metrics is not nil, it
+// receives the duration of each call to the underlying store, and of each query answered from the cache.
 func NewPersistentDataStoreWrapper(
 	core subsystems.PersistentDataStore,
 	dataStoreUpdates subsystems.DataStoreUpdateSink,
 	cacheTTL time.Duration,
+	incrementalInit bool,
+	metrics subsystems.DataStoreMetricsSink,
 	loggers ldlog.Loggers,
 ) subsystems.DataStore {
 	var myCache *cache.Cache
@@ -50,6 +77,8 @@ func NewPersistentDataStoreWrapper(
 		dataStoreUpdates: dataStoreUpdates,
 		cache:            myCache,
 		cacheTTL:         cacheTTL,
+		incrementalInit:  incrementalInit,
+		metrics:          metrics,
 		loggers:          loggers,
 	}
 
@@ -61,11 +90,29 @@ func NewPersistentDataStoreWrapper(
 		loggers,
 	)
 
+	// If the cache never expires, it is always up to date, since this SDK instance must be the only
+	// one that is updating the store.
+	if notifier, ok := core.(subsystems.PersistentDataStoreChangeNotifier); ok && myCache != nil && cacheTTL > 0 {
+		notifier.SetItemChangedHandler(w.itemChanged)
+	}
+
 	return w
 }
 
 func (w *persistentDataStoreWrapper) Init(allData []st.Collection) error {
-	err := w.initCore(allData)
+	w.initLock.Lock()
+	w.updateGeneration++
+	w.initLock.Unlock()
+
+	var err error
+	// We ask the underlying store rather than checking w.inited, because the store may have been
+	// initialized by a previous run of the application, and because w.inited can also be set by an Init
+	// that failed when the cache is infinite, in which case the store still needs a full Init.
+	if w.incrementalInit && w.core.IsInitialized() {
+		err = w.initCoreIncrementally(allData)
+	} else {
+		err = w.initCore(allData)
+	}
 	if w.cache != nil {
 		w.cache.Flush()
 	}
@@ -96,12 +143,16 @@ func (w *persistentDataStoreWrapper) Get(kind st.DataKind, key string) (st.ItemD
 		w.processError(err)
 		return item, err
 	}
+	start := w.startTiming()
 	cacheKey := dataStoreCacheKey(kind, key)
 	if data, present := w.cache.Get(cacheKey); present {
 		if item, ok := data.(st.ItemDescriptor); ok {
+			w.cacheHits.Add(1)
+			w.observe(subsystems.DataStoreOperationGet, start, nil, true)
 			return item, nil
 		}
 	}
+	w.cacheMisses.Add(1)
 	// Item was not cached or cached value was not valid. Use singleflight to ensure that we'll only
 	// do this core query once even if multiple goroutines are requesting it
 	reqKey := fmt.Sprintf("get:%s:%s", kind.GetName(), key)
@@ -132,12 +183,16 @@ func (w *persistentDataStoreWrapper) GetAll(kind st.DataKind) ([]st.KeyedItemDes
 		return items, err
 	}
 	// Check whether we have a cache item for the entire data set
+	start := w.startTiming()
 	cacheKey := dataStoreAllItemsCacheKey(kind)
 	if data, present := w.cache.Get(cacheKey); present {
 		if items, ok := data.([]st.KeyedItemDescriptor); ok {
+			w.cacheHits.Add(1)
+			w.observe(subsystems.DataStoreOperationGetAll, start, nil, true)
 			return items, nil
 		}
 	}
+	w.cacheMisses.Add(1)
 	// Data set was not cached or cached value was not valid. Use singleflight to ensure that we'll only
 	// do this core query once even if multiple goroutines are requesting it
 	reqKey := fmt.Sprintf("all:%s", kind.GetName())
@@ -166,8 +221,13 @@ func (w *persistentDataStoreWrapper) Upsert(
 	key string,
 	newItem st.ItemDescriptor,
 ) (bool, error) {
-	serializedItem := w.serialize(kind, newItem)
-	updated, err := w.core.Upsert(kind, key, serializedItem)
+	if w.cache != nil {
+		w.initLock.Lock()
+		w.updateGeneration++
+		w.initLock.Unlock()
+	}
+	serializedItem := SerializeItem(kind, newItem)
+	updated, err := w.coreUpsert(kind, key, serializedItem)
 	w.processError(err)
 	// Normally, if the underlying store failed to do the update, we do not want to update the cache -
 	// the idea being that it's better to stay in a consistent state of having old data than to act
@@ -250,6 +310,37 @@ func (w *persistentDataStoreWrapper) IsInitialized() bool {
 	return newValue
 }
 
+// Preload may still be running after the data source has started, since the SDK stops waiting for it after
+// a timeout. So that the stored data it read can't replace anything newer, it never overwrites an item that is
+// already in the cache, and it gives up as soon as Init or Upsert is called.
+func (w *persistentDataStoreWrapper) Preload() {
+	if !w.IsInitialized() || w.cache == nil {
+		return
+	}
+	w.initLock.RLock()
+	generation := w.updateGeneration
+	w.initLock.RUnlock()
+	for _, kind := range datakinds.AllDataKinds() {
+		items, err := w.getAllAndDeserialize(kind)
+		w.processError(err)
+		if err != nil {
+			w.loggers.Warnf("Unable to preload %s data from persistent store: %s", kind.GetName(), err)
+			return
+		}
+		// Holding initLock means that Init or Upsert can't start updating the cache until we're done.
+		w.initLock.Lock()
+		stillCurrent := w.updateGeneration == generation
+		if stillCurrent {
+			w.cacheItemsIfAbsent(kind, items)
+		}
+		w.initLock.Unlock()
+		if !stillCurrent {
+			w.loggers.Debug("Stopped preloading persistent store data because the data source has updated the store")
+			return
+		}
+	}
+}
+
 func (w *persistentDataStoreWrapper) IsStatusMonitoringEnabled() bool {
 	return true
 }
@@ -260,7 +351,7 @@ func (w *persistentDataStoreWrapper) Close() error {
 }
 
 func (w *persistentDataStoreWrapper) pollAvailabilityAfterOutage() bool {
-	if !w.core.IsStoreAvailable() {
+	if !w.coreIsStoreAvailable() {
 		return false
 	}
 	if w.hasInfiniteCache() {
@@ -292,6 +383,13 @@ func (w *persistentDataStoreWrapper) pollAvailabilityAfterOutage() bool {
 	return true
 }
 
+// itemChanged is called by a PersistentDataStoreChangeNotifier when an item has changed in the database.
+func (w *persistentDataStoreWrapper) itemChanged(kind st.DataKind, key string) {
+	w.cache.Delete(dataStoreCacheKey(kind, key))
+	w.cache.Delete(dataStoreAllItemsCacheKey(kind))
+	w.cache.Delete(initCheckedKey) // whatever changed it may also have initialized the store
+}
+
 func (w *persistentDataStoreWrapper) hasInfiniteCache() bool {
 	return w.cache != nil && w.cacheTTL < 0
 }
@@ -311,18 +409,155 @@ func (w *persistentDataStoreWrapper) initCore(allData []st.Collection) error {
 			Items: w.serializeAll(coll.Kind, coll.Items),
 		})
 	}
-	err := w.core.Init(serializedAllData)
+	err := w.coreInit(serializedAllData)
 	w.processError(err)
 	return err
 }
 
+// initCoreIncrementally makes the underlying store contain the same data that initCore would have
+// written, but only writes the items that differ from what the store already has. Items that are not in
+// the new data are replaced with deleted item placeholders. If any item in the new data has a lower
+// version than the one in the store, Upsert could not write it, so we fall back to a full Init; likewise if
+// the store rejects one of the updates. This is only called if the store is already initialized, so
+// unlike initCore it does not need to call Init to mark the store as initialized.
+func (w *persistentDataStoreWrapper) initCoreIncrementally(allData []st.Collection) error {
+	type pendingUpsert struct {
+		kind st.DataKind
+		key  string
+		item st.SerializedItemDescriptor
+	}
+	var upserts []pendingUpsert
+	kinds := make([]st.DataKind, 0, len(allData))
+	newItemsByKind := make(map[st.DataKind][]st.KeyedItemDescriptor, len(allData))
+	for _, coll := range allData {
+		kinds = append(kinds, coll.Kind)
+		newItemsByKind[coll.Kind] = coll.Items
+	}
+	for _, kind := range datakinds.AllDataKinds() {
+		if _, ok := newItemsByKind[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	for _, kind := range kinds {
+		oldItems, err := w.coreGetAll(kind)
+		if err != nil {
+			return w.initCore(allData)
+		}
+		oldVersions := make(map[string]st.SerializedItemDescriptor, len(oldItems))
+		for _, item := range oldItems {
+			oldVersions[item.Key] = item.Item
+		}
+		for _, item := range newItemsByKind[kind] {
+			oldItem, found := oldVersions[item.Key]
+			delete(oldVersions, item.Key)
+			if found && oldItem.Version > item.Item.Version {
+				w.loggers.Debugf("Version of %s %q went backward; rewriting all data in persistent store",
+					kind.GetName(), item.Key)
+				return w.initCore(allData)
+			}
+			if !found || oldItem.Version < item.Item.Version || oldItem.Deleted != (item.Item.Item == nil) {
+				upserts = append(upserts, pendingUpsert{kind, item.Key, SerializeItem(kind, item.Item)})
+			}
+		}
+		for key, oldItem := range oldVersions {
+			if !oldItem.Deleted {
+				deletedItem := st.ItemDescriptor{Version: oldItem.Version + 1}
+				upserts = append(upserts, pendingUpsert{kind, key, SerializeItem(kind, deletedItem)})
+			}
+		}
+	}
+	for _, u := range upserts {
+		updated, err := w.coreUpsert(u.kind, u.key, u.item)
+		if err != nil {
+			w.processError(err)
+			return err
+		}
+		if !updated {
+			// This can happen if the item's version is the same but it has been deleted or undeleted, or if
+			// another process has just written a newer version.
+			w.loggers.Debugf("Persistent store did not accept update of %s %q; rewriting all data",
+				u.kind.GetName(), u.key)
+			return w.initCore(allData)
+		}
+	}
+	w.loggers.Infof("Updated persistent store incrementally: %d item(s) changed", len(upserts))
+	return nil
+}
+
+func (w *persistentDataStoreWrapper) GetCacheStats() (hits int64, misses int64, enabled bool) {
+	return w.cacheHits.Load(), w.cacheMisses.Load(), w.cache != nil
+}
+
+// startTiming returns the current time if there is a metrics sink, or the zero time otherwise, so that
+// the metrics cost nothing more than a nil check when they are not enabled.
+func (w *persistentDataStoreWrapper) startTiming() time.Time {
+	if w.metrics == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+func (w *persistentDataStoreWrapper) observe(
+	operation subsystems.DataStoreOperation,
+	start time.Time,
+	err error,
+	cacheHit bool,
+) {
+	if w.metrics != nil {
+		w.metrics.ObserveDataStoreOperation(operation, time.Since(start), err != nil, cacheHit)
+	}
+}
+
+// The core* methods call the underlying store and report the duration to the metrics sink, if any.
+
+func (w *persistentDataStoreWrapper) coreInit(allData []st.SerializedCollection) error {
+	start := w.startTiming()
+	err := w.core.Init(allData)
+	w.observe(subsystems.DataStoreOperationInit, start, err, false)
+	return err
+}
+
+func (w *persistentDataStoreWrapper) coreGet(kind st.DataKind, key string) (st.SerializedItemDescriptor, error) {
+	start := w.startTiming()
+	item, err := w.core.Get(kind, key)
+	w.observe(subsystems.DataStoreOperationGet, start, err, false)
+	return item, err
+}
+
+func (w *persistentDataStoreWrapper) coreGetAll(kind st.DataKind) ([]st.KeyedSerializedItemDescriptor, error) {
+	start := w.startTiming()
+	items, err := w.core.GetAll(kind)
+	w.observe(subsystems.DataStoreOperationGetAll, start, err, false)
+	return items, err
+}
+
+func (w *persistentDataStoreWrapper) coreUpsert(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (bool, error) {
+	start := w.startTiming()
+	updated, err := w.core.Upsert(kind, key, item)
+	w.observe(subsystems.DataStoreOperationUpsert, start, err, false)
+	return updated, err
+}
+
+func (w *persistentDataStoreWrapper) coreIsStoreAvailable() bool {
+	start := w.startTiming()
+	available := w.core.IsStoreAvailable()
+	if w.metrics != nil {
+		w.metrics.ObserveDataStoreOperation(subsystems.DataStoreOperationAvailable, time.Since(start), !available, false)
+	}
+	return available
+}
+
 func (w *persistentDataStoreWrapper) getAndDeserializeItem(
 	kind st.DataKind,
 	key string,
 ) (st.ItemDescriptor, error) {
-	serializedItem, err := w.core.Get(kind, key)
+	serializedItem, err := w.coreGet(kind, key)
 	if err == nil {
-		return w.deserialize(kind, serializedItem)
+		return DeserializeItem(kind, serializedItem)
 	}
 	return st.ItemDescriptor{}.NotFound(), err
 }
@@ -330,11 +565,11 @@ func (w *persistentDataStoreWrapper) getAndDeserializeItem(
 func (w *persistentDataStoreWrapper) getAllAndDeserialize(
 	kind st.DataKind,
 ) ([]st.KeyedItemDescriptor, error) {
-	serializedItems, err := w.core.GetAll(kind)
+	serializedItems, err := w.coreGetAll(kind)
 	if err == nil {
 		ret := make([]st.KeyedItemDescriptor, 0, len(serializedItems))
 		for _, serializedItem := range serializedItems {
-			item, err := w.deserialize(kind, serializedItem.Item)
+			item, err := DeserializeItem(kind, serializedItem.Item)
 			if err != nil {
 				return nil, err
 			}
@@ -359,15 +594,14 @@ func (w *persistentDataStoreWrapper) cacheItems(
 	}
 }
 
-func (w *persistentDataStoreWrapper) serialize(
+// cacheItemsIfAbsent is like cacheItems, but leaves alone any cache entries that already exist.
+func (w *persistentDataStoreWrapper) cacheItemsIfAbsent(
 	kind st.DataKind,
-	item st.ItemDescriptor,
-) st.SerializedItemDescriptor {
-	isDeleted := item.Item == nil
-	return st.SerializedItemDescriptor{
-		Version:        item.Version,
-		Deleted:        isDeleted,
-		SerializedItem: kind.Serialize(item),
+	items []st.KeyedItemDescriptor,
+) {
+	_ = w.cache.Add(dataStoreAllItemsCacheKey(kind), slices.Clone(items), cache.DefaultExpiration)
+	for _, item := range items {
+		_ = w.cache.Add(dataStoreCacheKey(kind, item.Key), item.Item, cache.DefaultExpiration)
 	}
 }
 
@@ -379,30 +613,12 @@ func (w *persistentDataStoreWrapper) serializeAll(
 	for _, item := range items {
 		ret = append(ret, st.KeyedSerializedItemDescriptor{
 			Key:  item.Key,
-			Item: w.serialize(kind, item.Item),
+			Item: SerializeItem(kind, item.Item),
 		})
 	}
 	return ret
 }
 
-func (w *persistentDataStoreWrapper) deserialize(
-	kind st.DataKind,
-	serializedItemDesc st.SerializedItemDescriptor,
-) (st.ItemDescriptor, error) {
-	if serializedItemDesc.Deleted || serializedItemDesc.SerializedItem == nil {
-		return st.ItemDescriptor{Version: serializedItemDesc.Version}, nil
-	}
-	deserializedItemDesc, err := kind.Deserialize(serializedItemDesc.SerializedItem)
-	if err != nil {
-		return st.ItemDescriptor{}.NotFound(), err
-	}
-	if serializedItemDesc.Version == 0 || serializedItemDesc.Version == deserializedItemDesc.Version {
-		return deserializedItemDesc, nil
-	}
-	// If the store gave us a version number that isn't what was encoded in the object, trust it
-	return st.ItemDescriptor{Version: serializedItemDesc.Version, Item: deserializedItemDesc.Item}, nil
-}
-
 func updateSingleItem(
 	items []st.KeyedItemDescriptor,
 	key string,
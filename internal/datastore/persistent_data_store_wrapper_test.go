@@ -3,13 +3,17 @@ package datastore
 import (
 	"errors"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	s "github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
@@ -52,7 +56,7 @@ func makePersistentDataStoreWrapper(
 ) subsystems.DataStore {
 	broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
 	dataStoreUpdates := NewDataStoreUpdateSinkImpl(broadcaster)
-	return NewPersistentDataStoreWrapper(core, dataStoreUpdates, mode.ttl(), s.NewTestLoggers())
+	return NewPersistentDataStoreWrapper(core, dataStoreUpdates, mode.ttl(), true, nil, s.NewTestLoggers())
 }
 
 func TestPersistentDataStoreWrapper(t *testing.T) {
@@ -81,7 +85,14 @@ func TestPersistentDataStoreWrapper(t *testing.T) {
 	runTests("Upsert", testPersistentDataStoreWrapperUpsert, allCacheModes...)
 	runTests("Delete", testPersistentDataStoreWrapperDelete, allCacheModes...)
 	runTests("IsInitialized", testPersistentDataStoreWrapperIsInitialized, allCacheModes...)
+	runTests("incremental Init", testPersistentDataStoreWrapperIncrementalInit, allCacheModes...)
+	runTests("Preload", testPersistentDataStoreWrapperPreload, allCacheModes...)
+	runTests("Preload finishing after update", testPersistentDataStoreWrapperPreloadFinishingAfterUpdate,
+		cachedOnly...)
 	runTests("update failures with cache", testPersistentDataStoreWrapperUpdateFailuresWithCache, cachedOnly...)
+	runTests("change notifications", testPersistentDataStoreWrapperChangeNotifications, allCacheModes...)
+	runTests("metrics", testPersistentDataStoreWrapperMetrics, allCacheModes...)
+	runTests("cache stats", testPersistentDataStoreWrapperCacheStats, allCacheModes...)
 
 	runTests("IsStatusMonitoringEnabled", func(t *testing.T, mode testCacheMode) {
 		testWithMockPersistentDataStore(t, "is always true", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
@@ -447,9 +458,10 @@ func testPersistentDataStoreWrapperIsInitialized(t *testing.T, mode testCacheMod
 		assert.Equal(t, 1, core.InitQueriedCount)
 
 		require.NoError(t, w.Init(mocks.MakeMockDataSet()))
+		countAfterInit := core.InitQueriedCount // Init itself may ask the store, to decide whether to diff
 
 		assert.True(t, w.IsInitialized())
-		assert.Equal(t, 1, core.InitQueriedCount)
+		assert.Equal(t, countAfterInit, core.InitQueriedCount)
 	})
 
 	if mode.isCached() {
@@ -479,6 +491,235 @@ func testPersistentDataStoreWrapperIsInitialized(t *testing.T, mode testCacheMod
 	}
 }
 
+func testPersistentDataStoreWrapperIncrementalInit(t *testing.T, mode testCacheMode) {
+	item1v1 := mocks.MockDataItem{Key: "item1", Version: 1}
+	item1v2 := mocks.MockDataItem{Key: "item1", Version: 2}
+	item2 := mocks.MockDataItem{Key: "item2", Version: 1}
+	item2Marked := mocks.MockDataItem{Key: "item2", Version: 1, Name: "not rewritten"}
+	item3 := mocks.MockDataItem{Key: "item3", Version: 5}
+
+	testWithMockPersistentDataStore(t, "writes only changed items", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2, item3)))
+		// Changing the stored item without changing its version lets us see whether it gets rewritten
+		core.ForceSet(mocks.MockData, item2.Key, item2Marked.ToSerializedItemDescriptor())
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v2, item2)))
+
+		assert.Equal(t, item1v2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item1v2.Key))
+		assert.Equal(t, item2Marked.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item2.Key))
+		deletedItem3 := core.ForceGet(mocks.MockData, item3.Key)
+		assert.True(t, deletedItem3.Deleted)
+		assert.Equal(t, item3.Version+1, deletedItem3.Version)
+		assert.True(t, core.IsInitialized())
+
+		item, err := w.Get(mocks.MockData, item3.Key)
+		require.NoError(t, err)
+		assert.Nil(t, item.Item)
+	})
+
+	testWithMockPersistentDataStore(t, "rewrites everything if a version went backward", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v2, item2, item3)))
+		core.ForceSet(mocks.MockData, item2.Key, item2Marked.ToSerializedItemDescriptor())
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+
+		assert.Equal(t, item1v1.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item1v1.Key))
+		assert.Equal(t, item2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item2.Key))
+		assert.Equal(t, st.SerializedItemDescriptor{}.NotFound(), core.ForceGet(mocks.MockData, item3.Key))
+	})
+
+	testWithMockPersistentDataStore(t, "rewrites everything if store is not initialized", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		core.ForceSet(mocks.MockData, item3.Key, item3.ToSerializedItemDescriptor())
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1)))
+
+		assert.Equal(t, item1v1.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item1v1.Key))
+		assert.Equal(t, st.SerializedItemDescriptor{}.NotFound(), core.ForceGet(mocks.MockData, item3.Key))
+		assert.True(t, core.IsInitialized())
+	})
+
+	testWithMockPersistentDataStore(t, "writes only changed items if store was initialized by another instance", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		core.ForceSet(mocks.MockData, item2.Key, item2Marked.ToSerializedItemDescriptor())
+		core.ForceSet(mocks.MockData, item3.Key, item3.ToSerializedItemDescriptor())
+		core.ForceSetInited(true)
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+
+		assert.Equal(t, item1v1.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item1v1.Key))
+		assert.Equal(t, item2Marked.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item2.Key))
+		assert.True(t, core.ForceGet(mocks.MockData, item3.Key).Deleted)
+	})
+
+	testWithMockPersistentDataStore(t, "rewrites everything if store rejects an update", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+		core.ForceSet(mocks.MockData, item2.Key, SerializeItem(mocks.MockData, st.ItemDescriptor{Version: item2.Version}))
+
+		// item2 has the same version as the deleted placeholder, so Upsert cannot undelete it
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+
+		assert.Equal(t, item2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item2.Key))
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster), mode.ttl(), false, nil,
+			s.NewTestLoggers())
+		defer w.Close()
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+		core.ForceSet(mocks.MockData, item2.Key, item2Marked.ToSerializedItemDescriptor())
+
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(item1v1, item2)))
+
+		assert.Equal(t, item2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, item2.Key))
+	})
+}
+
+func testPersistentDataStoreWrapperPreload(t *testing.T, mode testCacheMode) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	allData := []st.SerializedCollection{
+		{Kind: datakinds.Features, Items: []st.KeyedSerializedItemDescriptor{
+			{Key: flag.Key, Item: st.SerializedItemDescriptor{
+				Version:        flag.Version,
+				SerializedItem: datakinds.Features.Serialize(s.FlagDescriptor(flag)),
+			}},
+		}},
+	}
+
+	testWithMockPersistentDataStore(t, "checks whether store is initialized", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		core.ForceSetInited(true)
+		w.(PreloadableDataStore).Preload()
+
+		core.ForceSetInited(false)
+		assert.True(t, w.IsInitialized())
+	})
+
+	testWithMockPersistentDataStore(t, "does not read data if store is not initialized", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		require.NoError(t, core.Init(allData))
+		core.ForceSetInited(false)
+		w.(PreloadableDataStore).Preload()
+
+		core.ForceRemove(datakinds.Features, flag.Key)
+		item, err := w.Get(datakinds.Features, flag.Key)
+		require.NoError(t, err)
+		assert.Nil(t, item.Item)
+	})
+
+	testWithMockPersistentDataStore(t, "caches existing data", mode, func(t *testing.T, core *mocks.MockPersistentDataStore, w subsystems.DataStore) {
+		require.NoError(t, core.Init(allData))
+		w.(PreloadableDataStore).Preload()
+
+		core.ForceRemove(datakinds.Features, flag.Key)
+		item, err := w.Get(datakinds.Features, flag.Key)
+		require.NoError(t, err)
+		if mode.isCached() {
+			assert.Equal(t, flag.Version, item.Version)
+		} else {
+			assert.Nil(t, item.Item)
+		}
+	})
+}
+
+// slowGetAllPersistentDataStore reads its data as soon as GetAll is first called for flags, but doesn't
+// return the result until release is closed, to simulate a query that finishes after the store has been
+// updated.
+type slowGetAllPersistentDataStore struct {
+	*mocks.MockPersistentDataStore
+	started chan struct{}
+	release chan struct{}
+	blocked atomic.Bool
+}
+
+func (d *slowGetAllPersistentDataStore) GetAll(kind st.DataKind) ([]st.KeyedSerializedItemDescriptor, error) {
+	items, err := d.MockPersistentDataStore.GetAll(kind)
+	if kind == datakinds.Features && d.blocked.CompareAndSwap(false, true) {
+		close(d.started)
+		<-d.release
+	}
+	return items, err
+}
+
+// startSlowPreload starts a Preload of storedData, and returns once it is waiting for its first GetAll. The
+// returned channel is closed when the Preload finishes.
+func startSlowPreload(
+	t *testing.T,
+	mode testCacheMode,
+	storedData []st.SerializedCollection,
+) (*slowGetAllPersistentDataStore, subsystems.DataStore, <-chan struct{}) {
+	core := &slowGetAllPersistentDataStore{
+		MockPersistentDataStore: mocks.NewMockPersistentDataStore(),
+		started:                 make(chan struct{}),
+		release:                 make(chan struct{}),
+	}
+	require.NoError(t, core.Init(storedData))
+	dataStoreUpdates := NewDataStoreUpdateSinkImpl(internal.NewBroadcaster[interfaces.DataStoreStatus]())
+	w := NewPersistentDataStoreWrapper(core, dataStoreUpdates, mode.ttl(), true, nil, s.NewTestLoggers())
+	preloaded := make(chan struct{})
+	go func() {
+		w.(PreloadableDataStore).Preload()
+		close(preloaded)
+	}()
+	<-core.started
+	return core, w, preloaded
+}
+
+func testPersistentDataStoreWrapperPreloadFinishingAfterUpdate(t *testing.T, mode testCacheMode) {
+	flagv1 := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	flagv2 := ldbuilders.NewFlagBuilder("flagkey").Version(2).Build()
+	segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(1).Build()
+	storedData := []st.SerializedCollection{
+		{Kind: datakinds.Features, Items: []st.KeyedSerializedItemDescriptor{
+			{Key: flagv1.Key, Item: st.SerializedItemDescriptor{
+				Version:        flagv1.Version,
+				SerializedItem: datakinds.Features.Serialize(s.FlagDescriptor(flagv1)),
+			}},
+		}},
+		{Kind: datakinds.Segments, Items: []st.KeyedSerializedItemDescriptor{
+			{Key: segment.Key, Item: st.SerializedItemDescriptor{
+				Version:        segment.Version,
+				SerializedItem: datakinds.Segments.Serialize(s.SegmentDescriptor(segment)),
+			}},
+		}},
+	}
+	newData := []st.Collection{
+		{Kind: datakinds.Features, Items: []st.KeyedItemDescriptor{{Key: flagv2.Key, Item: s.FlagDescriptor(flagv2)}}},
+		{Kind: datakinds.Segments, Items: nil},
+	}
+
+	t.Run("Init", func(t *testing.T) {
+		core, w, preloaded := startSlowPreload(t, mode, storedData)
+		defer w.Close()
+		require.NoError(t, w.Init(newData))
+		close(core.release)
+		<-preloaded
+
+		// Removing the flag from the underlying store shows that the cache has the data from Init.
+		core.ForceRemove(datakinds.Features, flagv2.Key)
+		item, err := w.Get(datakinds.Features, flagv2.Key)
+		require.NoError(t, err)
+		assert.Equal(t, flagv2.Version, item.Version)
+		items, err := w.GetAll(datakinds.Features)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, flagv2.Version, items[0].Item.Version)
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		core, w, preloaded := startSlowPreload(t, mode, storedData)
+		defer w.Close()
+		_, err := w.Upsert(datakinds.Features, flagv2.Key, s.FlagDescriptor(flagv2))
+		require.NoError(t, err)
+		close(core.release)
+		<-preloaded
+
+		core.ForceRemove(datakinds.Features, flagv2.Key)
+		item, err := w.Get(datakinds.Features, flagv2.Key)
+		require.NoError(t, err)
+		assert.Equal(t, flagv2.Version, item.Version)
+	})
+}
+
 func testPersistentDataStoreWrapperUpdateFailuresWithCache(t *testing.T, mode testCacheMode) {
 	if mode.isInfiniteTTL() {
 		t.Run("infinite TTL", func(t *testing.T) {
@@ -558,3 +799,156 @@ func testPersistentDataStoreWrapperUpdateFailuresWithCache(t *testing.T, mode te
 		})
 	}
 }
+
+type changeNotifyingPersistentDataStore struct {
+	*mocks.MockPersistentDataStore
+	handler func(st.DataKind, string)
+}
+
+func (c *changeNotifyingPersistentDataStore) SetItemChangedHandler(handler func(st.DataKind, string)) {
+	c.handler = handler
+}
+
+func testPersistentDataStoreWrapperChangeNotifications(t *testing.T, mode testCacheMode) {
+	core := &changeNotifyingPersistentDataStore{MockPersistentDataStore: mocks.NewMockPersistentDataStore()}
+	broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+	w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster), mode.ttl(), true, nil,
+		s.NewTestLoggers())
+	defer w.Close()
+
+	if mode != testCached {
+		// Without a cache there is nothing to invalidate; with an infinite cache, nothing else updates the store.
+		assert.Nil(t, core.handler)
+		return
+	}
+	require.NotNil(t, core.handler)
+
+	itemv1 := mocks.MockDataItem{Key: "item", Version: 1}
+	itemv2 := mocks.MockDataItem{Key: itemv1.Key, Version: 2}
+	core.ForceSet(mocks.MockData, itemv1.Key, itemv1.ToSerializedItemDescriptor())
+	item, err := w.Get(mocks.MockData, itemv1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, itemv1.ToItemDescriptor(), item)
+	items, err := w.GetAll(mocks.MockData)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+	core.ForceSet(mocks.MockData, "other", mocks.MockDataItem{Key: "other", Version: 1}.ToSerializedItemDescriptor())
+	item, err = w.Get(mocks.MockData, itemv1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, itemv1.ToItemDescriptor(), item) // still cached
+
+	core.handler(mocks.MockData, itemv1.Key)
+	item, err = w.Get(mocks.MockData, itemv1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, itemv2.ToItemDescriptor(), item)
+	items, err = w.GetAll(mocks.MockData)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+type capturedDataStoreOperation struct {
+	operation subsystems.DataStoreOperation
+	failed    bool
+	cacheHit  bool
+}
+
+type capturingDataStoreMetricsSink struct {
+	operations []capturedDataStoreOperation
+	lock       sync.Mutex
+}
+
+func (c *capturingDataStoreMetricsSink) ObserveDataStoreOperation(
+	operation subsystems.DataStoreOperation,
+	duration time.Duration,
+	failed bool,
+	cacheHit bool,
+) {
+	c.lock.Lock()
+	c.operations = append(c.operations, capturedDataStoreOperation{operation, failed, cacheHit})
+	c.lock.Unlock()
+}
+
+func (c *capturingDataStoreMetricsSink) take() []capturedDataStoreOperation {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ret := c.operations
+	c.operations = nil
+	return ret
+}
+
+func testPersistentDataStoreWrapperMetrics(t *testing.T, mode testCacheMode) {
+	core := mocks.NewMockPersistentDataStore()
+	sink := &capturingDataStoreMetricsSink{}
+	broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+	w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster), mode.ttl(), false, sink,
+		s.NewTestLoggers())
+	defer w.Close()
+
+	store := func(op subsystems.DataStoreOperation) capturedDataStoreOperation {
+		return capturedDataStoreOperation{operation: op}
+	}
+	cacheHit := func(op subsystems.DataStoreOperation) capturedDataStoreOperation {
+		return capturedDataStoreOperation{operation: op, cacheHit: true}
+	}
+
+	item := mocks.MockDataItem{Key: "item", Version: 1}
+	require.NoError(t, w.Init(mocks.MakeMockDataSet(item)))
+	assert.Equal(t, []capturedDataStoreOperation{store(subsystems.DataStoreOperationInit)}, sink.take())
+
+	_, err := w.Get(mocks.MockData, item.Key)
+	require.NoError(t, err)
+	_, err = w.GetAll(mocks.MockData)
+	require.NoError(t, err)
+	if mode.isCached() {
+		assert.Equal(t, []capturedDataStoreOperation{
+			cacheHit(subsystems.DataStoreOperationGet), cacheHit(subsystems.DataStoreOperationGetAll),
+		}, sink.take())
+	} else {
+		assert.Equal(t, []capturedDataStoreOperation{
+			store(subsystems.DataStoreOperationGet), store(subsystems.DataStoreOperationGetAll),
+		}, sink.take())
+	}
+
+	_, err = w.Upsert(mocks.MockData, item.Key, mocks.MockDataItem{Key: item.Key, Version: 2}.ToItemDescriptor())
+	require.NoError(t, err)
+	assert.Equal(t, []capturedDataStoreOperation{store(subsystems.DataStoreOperationUpsert)}, sink.take())
+
+	if !mode.isInfiniteTTL() {
+		core.SetFakeError(errors.New("sorry"))
+		_, err = w.Get(mocks.MockData, "unknown")
+		require.Error(t, err)
+		assert.Equal(t, []capturedDataStoreOperation{
+			{operation: subsystems.DataStoreOperationGet, failed: true},
+		}, sink.take())
+	}
+}
+
+func testPersistentDataStoreWrapperCacheStats(t *testing.T, mode testCacheMode) {
+	core := mocks.NewMockPersistentDataStore()
+	w := makePersistentDataStoreWrapper(t, mode, core)
+	defer w.Close()
+	stats := w.(CacheStatsProvider)
+
+	item := mocks.MockDataItem{Key: "item", Version: 1}
+	core.ForceSet(mocks.MockData, item.Key, item.ToSerializedItemDescriptor())
+
+	for i := 0; i < 2; i++ {
+		_, err := w.Get(mocks.MockData, item.Key)
+		require.NoError(t, err)
+		_, err = w.GetAll(mocks.MockData)
+		require.NoError(t, err)
+	}
+
+	hits, misses, enabled := stats.GetCacheStats()
+	if mode.isCached() {
+		assert.True(t, enabled)
+		assert.Equal(t, int64(2), hits)
+		assert.Equal(t, int64(2), misses)
+	} else {
+		assert.False(t, enabled)
+		assert.Equal(t, int64(0), hits)
+		assert.Equal(t, int64(0), misses)
+	}
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+// DiagnosticsRecorder keeps its own copy of the statistics that go into periodic diagnostic events, so that
+// the client can describe the next event without sending it.
+//
+// This is necessary because ldevents.DiagnosticsManager resets its statistics whenever it creates an
+// event, and the event processor keeps some of the statistics to itself. The recorder follows along by
+// observing the payloads that the event processor sends: WrapEventSender must be applied to the event
+// processor's EventSender, and the stream processor must report connection attempts with RecordStreamInit.
+//
+// The recorder also counts orderly stream reconnects, which DiagnosticsManager doesn't know about: these are
+// added to each periodic diagnostic event as it is sent, as the orderlyStreamReconnects property.
+type DiagnosticsRecorder struct {
+	id                      ldvalue.Value
+	dataSinceTime           ldtime.UnixMillisecondTime
+	streamInits             []diagnosticsRecorderStreamInit
+	eventsInLastBatch       int
+	orderlyStreamReconnects int
+	lock                    sync.Mutex
+}
+
+type diagnosticsRecorderStreamInit struct {
+	timestamp      ldtime.UnixMillisecondTime
+	failed         bool
+	durationMillis uint64
+}
+
+type diagnosticsRecorderEventSender struct {
+	recorder *DiagnosticsRecorder
+	sender   ldevents.EventSender
+}
+
+// NewDiagnosticsRecorder creates a DiagnosticsRecorder that reports the same diagnostic ID and start time
+// as the specified DiagnosticsManager.
+func NewDiagnosticsRecorder(manager *ldevents.DiagnosticsManager) *DiagnosticsRecorder {
+	initEvent := manager.CreateInitEvent()
+	return &DiagnosticsRecorder{
+		id:            initEvent.GetByKey("id"),
+		dataSinceTime: ldtime.UnixMillisecondTime(initEvent.GetByKey("creationDate").Float64Value()),
+	}
+}
+
+// RecordStreamInit is called by the stream processor when a stream connection has either succeeded or failed.
+func (r *DiagnosticsRecorder) RecordStreamInit(
+	timestamp ldtime.UnixMillisecondTime,
+	failed bool,
+	durationMillis uint64,
+) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.streamInits = append(r.streamInits, diagnosticsRecorderStreamInit{
+		timestamp:      timestamp,
+		failed:         failed,
+		durationMillis: durationMillis,
+	})
+}
+
+// RecordOrderlyStreamReconnect is called by the stream processor when it reconnects because LaunchDarkly
+// asked it to, rather than because of an error.
+func (r *DiagnosticsRecorder) RecordOrderlyStreamReconnect() {
+	r.lock.Lock()
+	r.orderlyStreamReconnects++
+	r.lock.Unlock()
+}
+
+// WrapEventSender returns an EventSender that delegates to sender, and updates the recorder's state
+// according to what is sent.
+func (r *DiagnosticsRecorder) WrapEventSender(sender ldevents.EventSender) ldevents.EventSender {
+	return diagnosticsRecorderEventSender{recorder: r, sender: sender}
+}
+
+// CreateReport returns the same JSON value as the next periodic diagnostic event, except that it does not
+// have the droppedEvents and deduplicatedUsers properties: only the event processor knows those, and it
+// does not make them available until it sends the event.
+func (r *DiagnosticsRecorder) CreateReport() ldvalue.Value {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	streamInitsBuilder := ldvalue.ArrayBuildWithCapacity(len(r.streamInits))
+	for _, si := range r.streamInits {
+		streamInitsBuilder.Add(ldvalue.ObjectBuild().
+			SetFloat64("timestamp", float64(si.timestamp)).
+			SetBool("failed", si.failed).
+			SetFloat64("durationMillis", float64(si.durationMillis)).
+			Build())
+	}
+	return ldvalue.ObjectBuild().
+		SetString("kind", "diagnostic").
+		Set("id", r.id).
+		SetFloat64("creationDate", float64(ldtime.UnixMillisNow())).
+		SetFloat64("dataSinceDate", float64(r.dataSinceTime)).
+		SetInt("eventsInLastBatch", r.eventsInLastBatch).
+		Set("streamInits", streamInitsBuilder.Build()).
+		SetInt("orderlyStreamReconnects", r.orderlyStreamReconnects).
+		Build()
+}
+
+func (r *DiagnosticsRecorder) recordAnalyticsEvents(eventCount int) {
+	r.lock.Lock()
+	r.eventsInLastBatch = eventCount
+	r.lock.Unlock()
+}
+
+// recordDiagnosticEvent resets the statistics that were reported in a diagnostic event, and returns the event
+// data with the orderlyStreamReconnects property added if it is a periodic event.
+func (r *DiagnosticsRecorder) recordDiagnosticEvent(data []byte) []byte {
+	event := ldvalue.Parse(data)
+	if event.GetByKey("kind").StringValue() != "diagnostic" {
+		return data // the diagnostic-init event doesn't reset anything
+	}
+	sentTime := ldtime.UnixMillisecondTime(event.GetByKey("creationDate").Float64Value())
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	// The event was created a little while before it was sent, so a stream connection attempt that was
+	// recorded in the meantime belongs in the next event.
+	kept := r.streamInits[:0]
+	for _, si := range r.streamInits {
+		if si.timestamp > sentTime {
+			kept = append(kept, si)
+		}
+	}
+	r.streamInits = kept
+	r.dataSinceTime = sentTime
+	r.eventsInLastBatch = 0
+
+	keys := event.Keys(nil)
+	builder := ldvalue.ObjectBuildWithCapacity(len(keys) + 1)
+	for _, key := range keys {
+		builder.Set(key, event.GetByKey(key))
+	}
+	builder.SetInt("orderlyStreamReconnects", r.orderlyStreamReconnects)
+	r.orderlyStreamReconnects = 0
+	return []byte(builder.Build().JSONString())
+}
+
+func (s diagnosticsRecorderEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	switch kind {
+	case ldevents.AnalyticsEventDataKind:
+		s.recorder.recordAnalyticsEvents(eventCount)
+	case ldevents.DiagnosticEventDataKind:
+		data = s.recorder.recordDiagnosticEvent(data)
+	}
+	return s.sender.SendEventData(kind, data, eventCount)
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diagnosticsRecorderTestSender struct {
+	kinds []ldevents.EventDataKind
+	data  [][]byte
+}
+
+func (s *diagnosticsRecorderTestSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	s.kinds = append(s.kinds, kind)
+	s.data = append(s.data, data)
+	return ldevents.EventSenderResult{Success: true}
+}
+
+func makeDiagnosticsRecorderForTest(startTime time.Time) (*DiagnosticsRecorder, ldvalue.Value) {
+	id := ldevents.NewDiagnosticID("sdk-key")
+	manager := ldevents.NewDiagnosticsManager(id, ldvalue.Null(), ldvalue.Null(), startTime, nil)
+	return NewDiagnosticsRecorder(manager), id
+}
+
+func TestDiagnosticsRecorderInitialReport(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour)
+	recorder, id := makeDiagnosticsRecorderForTest(startTime)
+
+	report := recorder.CreateReport()
+	assert.Equal(t, "diagnostic", report.GetByKey("kind").StringValue())
+	assert.Equal(t, id, report.GetByKey("id"))
+	assert.Equal(t, float64(ldtime.UnixMillisFromTime(startTime)), report.GetByKey("dataSinceDate").Float64Value())
+	assert.GreaterOrEqual(t, report.GetByKey("creationDate").Float64Value(), float64(ldtime.UnixMillisFromTime(startTime)))
+	assert.Equal(t, 0, report.GetByKey("eventsInLastBatch").IntValue())
+	assert.Equal(t, ldvalue.ArrayOf(), report.GetByKey("streamInits"))
+	assert.Equal(t, 0, report.GetByKey("orderlyStreamReconnects").IntValue())
+}
+
+func TestDiagnosticsRecorderReportsStreamInits(t *testing.T) {
+	recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+	recorder.RecordStreamInit(ldtime.UnixMillisecondTime(1000), true, 100)
+	recorder.RecordStreamInit(ldtime.UnixMillisecondTime(2000), false, 200)
+
+	expected := ldvalue.ArrayOf(
+		ldvalue.ObjectBuild().SetFloat64("timestamp", 1000).SetBool("failed", true).
+			SetFloat64("durationMillis", 100).Build(),
+		ldvalue.ObjectBuild().SetFloat64("timestamp", 2000).SetBool("failed", false).
+			SetFloat64("durationMillis", 200).Build(),
+	)
+	assert.Equal(t, expected, recorder.CreateReport().GetByKey("streamInits"))
+	assert.Equal(t, expected, recorder.CreateReport().GetByKey("streamInits"))
+}
+
+func TestDiagnosticsRecorderReportsOrderlyStreamReconnects(t *testing.T) {
+	recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+	recorder.RecordOrderlyStreamReconnect()
+	recorder.RecordOrderlyStreamReconnect()
+
+	assert.Equal(t, 2, recorder.CreateReport().GetByKey("orderlyStreamReconnects").IntValue())
+}
+
+func TestDiagnosticsRecorderEventSender(t *testing.T) {
+	t.Run("payloads are passed to sender", func(t *testing.T) {
+		recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+		sender := &diagnosticsRecorderTestSender{}
+		wrapped := recorder.WrapEventSender(sender)
+		assert.True(t, wrapped.SendEventData(ldevents.AnalyticsEventDataKind, []byte("[]"), 0).Success)
+		assert.True(t, wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte("{}"), 1).Success)
+		assert.Equal(t, []ldevents.EventDataKind{ldevents.AnalyticsEventDataKind, ldevents.DiagnosticEventDataKind},
+			sender.kinds)
+	})
+
+	t.Run("analytics payload sets eventsInLastBatch", func(t *testing.T) {
+		recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+		wrapped := recorder.WrapEventSender(&diagnosticsRecorderTestSender{})
+		wrapped.SendEventData(ldevents.AnalyticsEventDataKind, []byte("[]"), 3)
+		assert.Equal(t, 3, recorder.CreateReport().GetByKey("eventsInLastBatch").IntValue())
+	})
+
+	t.Run("periodic diagnostic event resets statistics", func(t *testing.T) {
+		recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+		wrapped := recorder.WrapEventSender(&diagnosticsRecorderTestSender{})
+		wrapped.SendEventData(ldevents.AnalyticsEventDataKind, []byte("[]"), 3)
+		recorder.RecordStreamInit(ldtime.UnixMillisecondTime(1000), false, 100)
+		recorder.RecordStreamInit(ldtime.UnixMillisecondTime(3000), false, 100)
+
+		event := ldvalue.ObjectBuild().SetString("kind", "diagnostic").SetFloat64("creationDate", 2000).Build()
+		wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte(event.JSONString()), 1)
+
+		report := recorder.CreateReport()
+		assert.Equal(t, float64(2000), report.GetByKey("dataSinceDate").Float64Value())
+		assert.Equal(t, 0, report.GetByKey("eventsInLastBatch").IntValue())
+		streamInits := report.GetByKey("streamInits")
+		assert.Equal(t, 1, streamInits.Count())
+		assert.Equal(t, float64(3000), streamInits.GetByIndex(0).GetByKey("timestamp").Float64Value())
+	})
+
+	t.Run("diagnostic init event does not reset statistics", func(t *testing.T) {
+		startTime := time.Now()
+		recorder, _ := makeDiagnosticsRecorderForTest(startTime)
+		wrapped := recorder.WrapEventSender(&diagnosticsRecorderTestSender{})
+		recorder.RecordStreamInit(ldtime.UnixMillisecondTime(1000), false, 100)
+
+		event := ldvalue.ObjectBuild().SetString("kind", "diagnostic-init").SetFloat64("creationDate", 2000).Build()
+		wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte(event.JSONString()), 1)
+
+		report := recorder.CreateReport()
+		assert.Equal(t, float64(ldtime.UnixMillisFromTime(startTime)), report.GetByKey("dataSinceDate").Float64Value())
+		assert.Equal(t, 1, report.GetByKey("streamInits").Count())
+	})
+	t.Run("periodic diagnostic event includes and resets orderlyStreamReconnects", func(t *testing.T) {
+		recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+		sender := &diagnosticsRecorderTestSender{}
+		wrapped := recorder.WrapEventSender(sender)
+		recorder.RecordOrderlyStreamReconnect()
+
+		event := ldvalue.ObjectBuild().SetString("kind", "diagnostic").SetFloat64("creationDate", 2000).Build()
+		wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte(event.JSONString()), 1)
+
+		expected := ldvalue.ObjectBuild().SetString("kind", "diagnostic").SetFloat64("creationDate", 2000).
+			SetInt("orderlyStreamReconnects", 1).Build()
+		assert.Equal(t, expected, ldvalue.Parse(sender.data[0]))
+		assert.Equal(t, 0, recorder.CreateReport().GetByKey("orderlyStreamReconnects").IntValue())
+	})
+
+	t.Run("diagnostic init event is passed to sender unchanged", func(t *testing.T) {
+		recorder, _ := makeDiagnosticsRecorderForTest(time.Now())
+		sender := &diagnosticsRecorderTestSender{}
+		wrapped := recorder.WrapEventSender(sender)
+		recorder.RecordOrderlyStreamReconnect()
+
+		data := []byte(ldvalue.ObjectBuild().SetString("kind", "diagnostic-init").Build().JSONString())
+		wrapped.SendEventData(ldevents.DiagnosticEventDataKind, data, 1)
+
+		assert.Equal(t, data, sender.data[0])
+		assert.Equal(t, 1, recorder.CreateReport().GetByKey("orderlyStreamReconnects").IntValue())
+	})
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+// EventStatsRecorder keeps track of the event statistics that LDClient.GetStats reports, which the event
+// processor does not make available: how many events are waiting to be sent, and how many were dropped.
+//
+// Like DiagnosticsRecorder, it does this by following along with the event processor. WrapEventProcessor
+// counts the events that go into the processor, and WrapEventSender notices when a payload is sent, which
+// is when the processor's buffer is emptied. The number of dropped events is only available from the
+// periodic diagnostic events, so it is only updated if diagnostic events are enabled.
+type EventStatsRecorder struct {
+	used    atomic.Bool
+	queued  atomic.Int64
+	dropped atomic.Int64
+}
+
+type eventStatsRecorderEventProcessor struct {
+	ldevents.EventProcessor
+	recorder *EventStatsRecorder
+}
+
+type eventStatsRecorderEventSender struct {
+	recorder *EventStatsRecorder
+	sender   ldevents.EventSender
+}
+
+// NewEventStatsRecorder creates an EventStatsRecorder.
+func NewEventStatsRecorder() *EventStatsRecorder {
+	return &EventStatsRecorder{}
+}
+
+// WrapEventProcessor returns an EventProcessor that delegates to ep, and counts the events that it records.
+func (r *EventStatsRecorder) WrapEventProcessor(ep ldevents.EventProcessor) ldevents.EventProcessor {
+	r.used.Store(true)
+	return eventStatsRecorderEventProcessor{EventProcessor: ep, recorder: r}
+}
+
+// WrapEventSender returns an EventSender that delegates to sender, and updates the recorder's state
+// according to what is sent.
+func (r *EventStatsRecorder) WrapEventSender(sender ldevents.EventSender) ldevents.EventSender {
+	return eventStatsRecorderEventSender{recorder: r, sender: sender}
+}
+
+// IsUsed returns true if WrapEventProcessor has been called; otherwise, the client is not using the
+// standard event processor, and there are no statistics.
+func (r *EventStatsRecorder) IsUsed() bool {
+	return r.used.Load()
+}
+
+// QueuedEvents returns the number of events that have been recorded since the last payload was sent. This
+// is an upper bound on the number of events in the buffer, since evaluations that only go into the summary
+// do not take up space in it.
+func (r *EventStatsRecorder) QueuedEvents() int64 {
+	return r.queued.Load()
+}
+
+// DroppedEvents returns the total number of events that were dropped because the buffer was full, as
+// reported in diagnostic events so far.
+func (r *EventStatsRecorder) DroppedEvents() int64 {
+	return r.dropped.Load()
+}
+
+func (ep eventStatsRecorderEventProcessor) RecordEvaluation(e ldevents.EvaluationData) {
+	ep.recorder.queued.Add(1)
+	ep.EventProcessor.RecordEvaluation(e)
+}
+
+func (ep eventStatsRecorderEventProcessor) RecordIdentifyEvent(e ldevents.IdentifyEventData) {
+	ep.recorder.queued.Add(1)
+	ep.EventProcessor.RecordIdentifyEvent(e)
+}
+
+func (ep eventStatsRecorderEventProcessor) RecordCustomEvent(e ldevents.CustomEventData) {
+	ep.recorder.queued.Add(1)
+	ep.EventProcessor.RecordCustomEvent(e)
+}
+
+func (ep eventStatsRecorderEventProcessor) RecordMigrationOpEvent(e ldevents.MigrationOpEventData) {
+	ep.recorder.queued.Add(1)
+	ep.EventProcessor.RecordMigrationOpEvent(e)
+}
+
+func (ep eventStatsRecorderEventProcessor) RecordRawEvent(data json.RawMessage) {
+	ep.recorder.queued.Add(1)
+	ep.EventProcessor.RecordRawEvent(data)
+}
+
+func (s eventStatsRecorderEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	switch kind {
+	case ldevents.AnalyticsEventDataKind:
+		// The event processor takes everything out of its buffer to make a payload, so any events that
+		// were recorded before now are either in this payload or were dropped.
+		s.recorder.queued.Store(0)
+	case ldevents.DiagnosticEventDataKind:
+		event := ldvalue.Parse(data)
+		if event.GetByKey("kind").StringValue() == "diagnostic" {
+			s.recorder.dropped.Add(int64(event.GetByKey("droppedEvents").IntValue()))
+		}
+	}
+	return s.sender.SendEventData(kind, data, eventCount)
+}
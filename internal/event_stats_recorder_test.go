@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStatsRecorderIsNotUsedUntilEventProcessorIsWrapped(t *testing.T) {
+	recorder := NewEventStatsRecorder()
+	assert.False(t, recorder.IsUsed())
+	recorder.WrapEventProcessor(ldevents.NewNullEventProcessor())
+	assert.True(t, recorder.IsUsed())
+}
+
+func TestEventStatsRecorderCountsQueuedEvents(t *testing.T) {
+	recorder := NewEventStatsRecorder()
+	ep := recorder.WrapEventProcessor(ldevents.NewNullEventProcessor())
+	sender := &diagnosticsRecorderTestSender{}
+	wrapped := recorder.WrapEventSender(sender)
+
+	ep.RecordEvaluation(ldevents.EvaluationData{})
+	ep.RecordIdentifyEvent(ldevents.IdentifyEventData{})
+	ep.RecordCustomEvent(ldevents.CustomEventData{})
+	ep.RecordMigrationOpEvent(ldevents.MigrationOpEventData{})
+	ep.RecordRawEvent([]byte("{}"))
+	assert.Equal(t, int64(5), recorder.QueuedEvents())
+
+	wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte("{}"), 1)
+	assert.Equal(t, int64(5), recorder.QueuedEvents())
+
+	wrapped.SendEventData(ldevents.AnalyticsEventDataKind, []byte("[]"), 5)
+	assert.Equal(t, int64(0), recorder.QueuedEvents())
+	assert.Equal(t, []ldevents.EventDataKind{ldevents.DiagnosticEventDataKind, ldevents.AnalyticsEventDataKind},
+		sender.kinds)
+}
+
+func TestEventStatsRecorderAddsDroppedEventsFromDiagnosticEvents(t *testing.T) {
+	recorder := NewEventStatsRecorder()
+	wrapped := recorder.WrapEventSender(&diagnosticsRecorderTestSender{})
+	send := func(kind string, dropped int) {
+		event := ldvalue.ObjectBuild().SetString("kind", kind).SetInt("droppedEvents", dropped).Build()
+		wrapped.SendEventData(ldevents.DiagnosticEventDataKind, []byte(event.JSONString()), 1)
+	}
+
+	send("diagnostic", 3)
+	send("diagnostic", 0)
+	send("diagnostic", 2)
+	send("diagnostic-init", 10)
+	assert.Equal(t, int64(5), recorder.DroppedEvents())
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 )
 
@@ -54,7 +55,7 @@ func (f *flagTrackerImpl) AddFlagValueChangeListener(
 ) <-chan interfaces.FlagValueChangeEvent {
 	valueCh := make(chan interfaces.FlagValueChangeEvent, subscriberChannelBufferLength)
 	flagCh := f.broadcaster.AddListener()
-	go runValueChangeListener(flagCh, valueCh, f.evaluateFn, flagKey, context, defaultValue)
+	go runValueChangeListener(flagCh, valueCh, f.evaluateFn, f.broadcaster.loggers, flagKey, context, defaultValue)
 
 	f.lock.Lock()
 	f.valueChangeSubscriptions[valueCh] = flagCh
@@ -79,11 +80,23 @@ func runValueChangeListener(
 	flagCh <-chan interfaces.FlagChangeEvent,
 	valueCh chan<- interfaces.FlagValueChangeEvent,
 	evaluateFn func(flagKey string, context ldcontext.Context, defaultValue ldvalue.Value) ldvalue.Value,
+	loggers ldlog.Loggers,
 	flagKey string,
 	context ldcontext.Context,
 	defaultValue ldvalue.Value,
 ) {
-	currentValue := evaluateFn(flagKey, context, defaultValue)
+	// A panic during evaluation is logged and treated as if the value had not changed, so that it does not
+	// silently end the subscription.
+	evaluate := func(previousValue ldvalue.Value) (value ldvalue.Value) {
+		defer func() {
+			if r := recover(); r != nil {
+				loggers.Errorf("Unexpected panic while evaluating flag %q for a value change listener: %v", flagKey, r)
+				value = previousValue
+			}
+		}()
+		return evaluateFn(flagKey, context, defaultValue)
+	}
+	currentValue := evaluate(defaultValue)
 	for {
 		flagChange, ok := <-flagCh
 		if !ok {
@@ -94,7 +107,7 @@ func runValueChangeListener(
 		if flagChange.Key != flagKey {
 			continue
 		}
-		newValue := evaluateFn(flagKey, context, defaultValue)
+		newValue := evaluate(currentValue)
 		if newValue.Equal(currentValue) {
 			continue
 		}
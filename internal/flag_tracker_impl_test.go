@@ -10,6 +10,8 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 
@@ -88,3 +90,33 @@ func TestFlagValueChangeListener(t *testing.T) {
 	broadcaster.Broadcast(intf.FlagChangeEvent{Key: "other-flag"})
 	th.AssertNoMoreValues(t, ch1, timeout)
 }
+
+func TestFlagValueChangeListenerContinuesAfterPanicInEvaluation(t *testing.T) {
+	flagKey := "important-flag"
+	user := lduser.NewUser("important-user")
+	mockLog := ldlogtest.NewMockLog()
+	results := make(chan ldvalue.Value, 10)
+
+	broadcaster := NewBroadcasterWithOptions[interfaces.FlagChangeEvent](mockLog.Loggers, 0)
+	defer broadcaster.Close()
+	tracker := NewFlagTrackerImpl(broadcaster, func(string, ldcontext.Context, ldvalue.Value) ldvalue.Value {
+		value := <-results
+		if value.IsNull() {
+			panic("sorry")
+		}
+		return value
+	})
+
+	results <- ldvalue.Bool(false)
+	ch := tracker.AddFlagValueChangeListener(flagKey, user, ldvalue.Null())
+
+	results <- ldvalue.Null()
+	broadcaster.Broadcast(intf.FlagChangeEvent{Key: flagKey})
+	results <- ldvalue.Bool(true)
+	broadcaster.Broadcast(intf.FlagChangeEvent{Key: flagKey})
+
+	event := th.RequireValue(t, ch, time.Second)
+	assert.Equal(t, ldvalue.Bool(false), event.OldValue)
+	assert.Equal(t, ldvalue.Bool(true), event.NewValue)
+	assert.Len(t, mockLog.GetOutput(ldlog.Error), 1)
+}
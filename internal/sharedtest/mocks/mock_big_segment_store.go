@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 
@@ -26,6 +27,40 @@ type MockBigSegmentStore struct {
 	lock              sync.Mutex
 }
 
+// MockBigSegmentMembership is a BigSegmentMembership that records which segment references were
+// checked. Segment references whose value is true in the included map are included; ones whose value
+// is true in the excluded map, and that are not also included, are excluded.
+type MockBigSegmentMembership struct {
+	included map[string]bool
+	excluded map[string]bool
+	checked  []string
+	lock     sync.Mutex
+}
+
+// NewMockBigSegmentMembership creates a MockBigSegmentMembership. Either map can be nil.
+func NewMockBigSegmentMembership(included, excluded map[string]bool) *MockBigSegmentMembership {
+	return &MockBigSegmentMembership{included: included, excluded: excluded}
+}
+
+func (m *MockBigSegmentMembership) CheckMembership(segmentRef string) ldvalue.OptionalBool { //nolint:revive
+	m.lock.Lock()
+	m.checked = append(m.checked, segmentRef)
+	m.lock.Unlock()
+	if m.included[segmentRef] {
+		return ldvalue.NewOptionalBool(true)
+	}
+	if m.excluded[segmentRef] {
+		return ldvalue.NewOptionalBool(false)
+	}
+	return ldvalue.OptionalBool{}
+}
+
+func (m *MockBigSegmentMembership) TestGetCheckedSegmentRefs() []string { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]string(nil), m.checked...)
+}
+
 func (m *MockBigSegmentStore) Close() error { //nolint:revive
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
@@ -142,3 +143,40 @@ func (sk mockDataKind) Deserialize(data []byte) (ldstoretypes.ItemDescriptor, er
 
 // MockOtherData is an instance of ld.StoreDataKind corresponding to another flavor of MockDataItem.
 var MockOtherData = mockDataKind{isOther: true}
+
+// MockJSONData is an instance of ld.StoreDataKind whose items are arbitrary JSON objects with a "version"
+// property, like the data kinds that can be registered with ldstoreimpl.RegisterDataKind. An item with
+// "deleted": true is a deleted item.
+var MockJSONData = mockJSONDataKind{}
+
+type mockJSONDataKind struct{}
+
+func (sk mockJSONDataKind) GetName() string {
+	return "mockJSON"
+}
+
+func (sk mockJSONDataKind) String() string {
+	return sk.GetName()
+}
+
+func (sk mockJSONDataKind) Serialize(item ldstoretypes.ItemDescriptor) []byte {
+	if item.Item == nil {
+		return []byte(fmt.Sprintf(`{"version":%d,"deleted":true}`, item.Version))
+	}
+	if value, ok := item.Item.(ldvalue.Value); ok {
+		return []byte(value.JSONString())
+	}
+	return nil
+}
+
+func (sk mockJSONDataKind) Deserialize(data []byte) (ldstoretypes.ItemDescriptor, error) {
+	value := ldvalue.Parse(data)
+	if value.Type() != ldvalue.ObjectType {
+		return ldstoretypes.ItemDescriptor{}.NotFound(), fmt.Errorf(`not a valid JSON object: "%s"`, data)
+	}
+	version := value.GetByKey("version").IntValue()
+	if value.GetByKey("deleted").BoolValue() {
+		return ldstoretypes.ItemDescriptor{Version: version}, nil
+	}
+	return ldstoretypes.ItemDescriptor{Version: version, Item: value}, nil
+}
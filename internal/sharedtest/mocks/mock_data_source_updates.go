@@ -80,6 +80,12 @@ func (d *MockDataSourceUpdates) GetDataStoreStatusProvider() interfaces.DataStor
 	return d.dataStoreStatusProvider
 }
 
+// IsDataStoreInitialized in this test implementation, returns the IsInitialized state of the real store
+// that was passed to NewMockDataSourceUpdates.
+func (d *MockDataSourceUpdates) IsDataStoreInitialized() bool {
+	return d.DataStore.realStore.IsInitialized()
+}
+
 // UpdateStoreStatus simulates a change in the data store status.
 func (d *MockDataSourceUpdates) UpdateStoreStatus(newStatus interfaces.DataStoreStatus) {
 	d.dataStoreStatusProvider.statusCh <- newStatus
@@ -124,3 +130,7 @@ func (m *mockDataStoreStatusProvider) AddStatusListener() <-chan interfaces.Data
 
 func (m *mockDataStoreStatusProvider) RemoveStatusListener(ch <-chan interfaces.DataStoreStatus) {
 }
+
+func (m *mockDataStoreStatusProvider) GetRejectedUpdateCount() int {
+	return 0
+}
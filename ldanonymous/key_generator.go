@@ -0,0 +1,120 @@
+package ldanonymous
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// GeneratedKeyAttribute is the name of the attribute that [KeyGenerator.MarkGenerated] adds to the
+// contexts it creates. Its value is always true.
+const GeneratedKeyAttribute = "generatedKey"
+
+// KeyPersistence is an optional interface for recording which keys have already been generated, so that
+// a [KeyGenerator] can detect when two different seeds produce the same key.
+//
+// Such a collision is extremely unlikely, but an application that needs to rule it out can implement this
+// interface with whatever shared storage it already uses for sessions. The KeyGenerator never passes the
+// seed itself to this interface, only a separate hash of it.
+type KeyPersistence interface {
+	// ClaimKey records that key was generated from the seed whose hash is seedHash. If the key was already
+	// recorded with a different seed hash, it returns false. It returns an error if the storage is
+	// unavailable, in which case the KeyGenerator uses the key anyway.
+	ClaimKey(key, seedHash string) (bool, error)
+}
+
+// KeyGenerator creates anonymous contexts whose keys are derived from a caller-provided seed.
+//
+// Create an instance with [NewKeyGenerator] and reuse it; it is safe for concurrent use as long as the
+// KeyPersistence, if any, is too.
+//
+//	generator := ldanonymous.NewKeyGenerator(secret).Loggers(loggers)
+//	context := generator.NewAnonymousContext("web", visitorCookie.Value)
+//	value, _ := client.BoolVariation("my-flag", context, false)
+type KeyGenerator struct {
+	secret        []byte
+	persistence   KeyPersistence
+	loggers       ldlog.Loggers
+	markGenerated bool
+}
+
+// NewKeyGenerator creates a KeyGenerator that uses the specified secret for hashing.
+//
+// The secret should be a random value of at least 32 bytes that is kept the same across all instances of
+// the application; if it changes, every visitor gets a new key.
+func NewKeyGenerator(secret []byte) *KeyGenerator {
+	return &KeyGenerator{secret: append([]byte(nil), secret...)}
+}
+
+// Loggers sets the destination for the warnings that the KeyGenerator logs when it has to use a random key.
+// By default, nothing is logged.
+func (g *KeyGenerator) Loggers(loggers ldlog.Loggers) *KeyGenerator {
+	g.loggers = loggers
+	return g
+}
+
+// Persistence sets a [KeyPersistence] for detecting key collisions. By default, there is none.
+func (g *KeyGenerator) Persistence(persistence KeyPersistence) *KeyGenerator {
+	g.persistence = persistence
+	return g
+}
+
+// MarkGenerated sets whether the contexts created by [KeyGenerator.NewAnonymousContext] should have an
+// attribute named [GeneratedKeyAttribute] with the value true. This allows the key to be recognized as a
+// generated one in analytics events, and by targeting rules. The default is false.
+func (g *KeyGenerator) MarkGenerated(markGenerated bool) *KeyGenerator {
+	g.markGenerated = markGenerated
+	return g
+}
+
+// Key returns the key for the specified scope and seed.
+//
+// The scope distinguishes different uses of the same seed; for instance, if the same device ID is used as
+// a seed by two applications, giving them different scopes gives the visitor a different key in each.
+// The same scope and seed always produce the same key, unless the seed is empty or the key collides with
+// one that was generated from a different seed. In either of those cases, Key logs a warning and returns
+// a random UUID instead, and the stable return value is false.
+func (g *KeyGenerator) Key(scope, seed string) (key string, stable bool) {
+	if seed == "" {
+		g.loggers.Warnf("Empty seed for anonymous context key in scope %q; using a random key", scope)
+		return uuid.New().String(), false
+	}
+	key = g.hash("key", scope, seed)
+	if g.persistence != nil {
+		claimed, err := g.persistence.ClaimKey(key, g.hash("seed", scope, seed))
+		if err != nil {
+			g.loggers.Warnf("Unable to check for anonymous context key collision: %s", err)
+		} else if !claimed {
+			g.loggers.Warnf("Generated anonymous context key in scope %q collided with another; using a random key",
+				scope)
+			return uuid.New().String(), false
+		}
+	}
+	return key, true
+}
+
+// NewAnonymousContext returns an anonymous context of the default kind ("user"), whose key is the result
+// of [KeyGenerator.Key] for the specified scope and seed.
+func (g *KeyGenerator) NewAnonymousContext(scope, seed string) ldcontext.Context {
+	key, _ := g.Key(scope, seed)
+	builder := ldcontext.NewBuilder(key).Anonymous(true)
+	if g.markGenerated {
+		builder.SetBool(GeneratedKeyAttribute, true)
+	}
+	return builder.Build()
+}
+
+func (g *KeyGenerator) hash(purpose, scope, seed string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	// Each part is followed by a zero byte so that different splits of the same string can't collide.
+	for _, part := range []string{purpose, scope, seed} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,120 @@
+package ldanonymous
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeKeyPersistence struct {
+	claims map[string]string
+	err    error
+}
+
+func (p *fakeKeyPersistence) ClaimKey(key, seedHash string) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	if existing, ok := p.claims[key]; ok {
+		return existing == seedHash, nil
+	}
+	p.claims[key] = seedHash
+	return true, nil
+}
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestKeyIsStableForSameScopeAndSeed(t *testing.T) {
+	g := NewKeyGenerator([]byte(testSecret))
+	key1, stable1 := g.Key("web", "cookie-value")
+	key2, stable2 := NewKeyGenerator([]byte(testSecret)).Key("web", "cookie-value")
+	assert.True(t, stable1)
+	assert.True(t, stable2)
+	assert.Equal(t, key1, key2)
+	assert.Len(t, key1, 32)
+	assert.NotContains(t, key1, "cookie-value")
+}
+
+func TestKeyDependsOnScopeSeedAndSecret(t *testing.T) {
+	g := NewKeyGenerator([]byte(testSecret))
+	key, _ := g.Key("web", "cookie-value")
+
+	otherScope, _ := g.Key("mobile", "cookie-value")
+	otherSeed, _ := g.Key("web", "other-cookie-value")
+	otherSecret, _ := NewKeyGenerator([]byte("another secret")).Key("web", "cookie-value")
+	resplit, _ := g.Key("webcookie", "-value")
+	assert.NotEqual(t, key, otherScope)
+	assert.NotEqual(t, key, otherSeed)
+	assert.NotEqual(t, key, otherSecret)
+	assert.NotEqual(t, key, resplit)
+}
+
+func TestEmptySeedUsesRandomKey(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	g := NewKeyGenerator([]byte(testSecret)).Loggers(mockLog.Loggers)
+
+	key1, stable := g.Key("web", "")
+	key2, _ := g.Key("web", "")
+	assert.False(t, stable)
+	assert.NotEqual(t, key1, key2)
+	_, err := uuid.Parse(key1)
+	assert.NoError(t, err)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Empty seed")
+}
+
+func TestCollisionUsesRandomKey(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	persistence := &fakeKeyPersistence{claims: make(map[string]string)}
+	g := NewKeyGenerator([]byte(testSecret)).Loggers(mockLog.Loggers).Persistence(persistence)
+
+	key, stable := g.Key("web", "cookie-value")
+	assert.True(t, stable)
+	key2, stable := g.Key("web", "cookie-value") // the same seed claiming the key again is fine
+	assert.True(t, stable)
+	assert.Equal(t, key, key2)
+	assert.Len(t, mockLog.GetAllOutput(), 0)
+
+	persistence.claims[key] = "something else"
+	key3, stable := g.Key("web", "cookie-value")
+	assert.False(t, stable)
+	assert.NotEqual(t, key, key3)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "collided")
+}
+
+func TestPersistenceErrorStillUsesGeneratedKey(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	persistence := &fakeKeyPersistence{err: errors.New("sorry")}
+	g := NewKeyGenerator([]byte(testSecret)).Loggers(mockLog.Loggers).Persistence(persistence)
+
+	key, stable := g.Key("web", "cookie-value")
+	expected, _ := NewKeyGenerator([]byte(testSecret)).Key("web", "cookie-value")
+	assert.True(t, stable)
+	assert.Equal(t, expected, key)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "sorry")
+}
+
+func TestNewAnonymousContext(t *testing.T) {
+	key, _ := NewKeyGenerator([]byte(testSecret)).Key("web", "cookie-value")
+
+	t.Run("default", func(t *testing.T) {
+		c := NewKeyGenerator([]byte(testSecret)).NewAnonymousContext("web", "cookie-value")
+		assert.NoError(t, c.Err())
+		assert.Equal(t, key, c.Key())
+		assert.True(t, c.Anonymous())
+		assert.Equal(t, ldvalue.Null(), c.GetValue(GeneratedKeyAttribute))
+	})
+
+	t.Run("MarkGenerated", func(t *testing.T) {
+		c := NewKeyGenerator([]byte(testSecret)).MarkGenerated(true).NewAnonymousContext("web", "cookie-value")
+		assert.Equal(t, key, c.Key())
+		assert.True(t, c.Anonymous())
+		assert.Equal(t, ldvalue.Bool(true), c.GetValue(GeneratedKeyAttribute))
+	})
+}
@@ -0,0 +1,10 @@
+// Package ldanonymous helps applications create anonymous evaluation contexts with stable keys.
+//
+// An anonymous visitor still needs a context key. If the application makes up a new key for every request,
+// each request counts as a new context for billing purposes, and the visitor may get a different result each
+// time from a percentage rollout. [KeyGenerator] instead derives the key from something that identifies the
+// visitor's device or session, such as a cookie value, so that the visitor keeps the same key.
+//
+// The keys are computed with a keyed hash (HMAC-SHA256), so they cannot be traced back to the value they were
+// derived from without the secret.
+package ldanonymous
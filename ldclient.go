@@ -1,12 +1,14 @@
 package ldclient
 
 import (
+	gocontext "context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
@@ -18,6 +20,7 @@ import (
 	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/evaltrace"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/bigsegments"
@@ -27,11 +30,19 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
 // Version is the SDK version.
 const Version = internal.SDKVersion
 
+// pageViewEventKey is the custom event key used by TrackPageView.
+const pageViewEventKey = "pageView"
+
+// dataStorePreloadTimeout is how long MakeCustomClient will wait for a persistent data store to load its
+// existing data before starting the data source.
+const dataStorePreloadTimeout = 500 * time.Millisecond
+
 // LDClient is the LaunchDarkly client.
 //
 // This object evaluates feature flags, generates analytics events, and communicates with
@@ -49,9 +60,12 @@ const Version = internal.SDKVersion
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/server-side/go
 type LDClient struct {
 	sdkKey                           string
+	sdkKeys                          *sdkKeyRotator
+	diagnosticsRecorder              *internal.DiagnosticsRecorder
 	loggers                          ldlog.Loggers
 	eventProcessor                   ldevents.EventProcessor
 	dataSource                       subsystems.DataSource
+	dataSourceEvaluationObserver     datasource.EvaluationObserver
 	store                            subsystems.DataStore
 	evaluator                        ldeval.Evaluator
 	dataSourceStatusBroadcaster      *internal.Broadcaster[interfaces.DataSourceStatus]
@@ -67,7 +81,27 @@ type LDClient struct {
 	eventsWithReasons                eventsScope
 	withEventsDisabled               interfaces.LDClientInterface
 	logEvaluationErrors              bool
+	logContextExtractor              func(gocontext.Context) string
 	offline                          bool
+	offlineWithStore                 bool
+	evaluationMetrics                *evaluationMetrics
+	metricsCollector                 subsystems.EvaluationMetricsCollector
+	evaluationTimeout                time.Duration
+	evaluationCannotBlock            bool         // see evaluateWithDeadline
+	abandonedEvaluations             atomic.Int32 // see evaluateWithDeadline
+	evaluationCache                  *evaluationCache
+	conversions                      atomic.Pointer[conversionDeduplicator] // see conversionDeduplicator
+	conversionsWindow                time.Duration
+	bucketByMisses                   *bucketByMissTracker
+	analyticsConsent                 internal.AnalyticsConsentFilter
+	flagsSnapshotTracker             flagsSnapshotTracker
+	variationTypeChecker             *VariationTypeChecker
+	defaultValues                    defaultValuesRegistry
+	evaluationStats                  evaluationStats
+	eventStats                       *internal.EventStatsRecorder
+	dataSourceUpdates                *datasource.DataSourceUpdateSinkImpl
+	dataStoreCacheStats              datastore.CacheStatsProvider
+	dryRun                           *dryRunComponents
 }
 
 // Initialization errors
@@ -148,80 +182,202 @@ func MakeClient(sdkKey string, waitFor time.Duration) (*LDClient, error) {
 //
 // The only time it returns nil instead of a client instance is if the client cannot be created at all due to
 // an invalid configuration. This is rare, but could happen if for instance you specified a custom TLS
-// certificate file that did not contain a valid certificate.
+// certificate file that did not contain a valid certificate. In this case, the SDK tries to report every
+// configuration problem at once rather than just the first one: the error value combines the errors from
+// all of the components that failed (see [errors.Join]), each prefixed with the name of the component,
+// such as "DataStore: ". You can still use [errors.Is] or [errors.As] to check for a specific error. To
+// find these errors ahead of time, without creating a client, use [Config.Validate].
 //
 // For more about the difference between an initialized and uninitialized client, and other ways to monitor
 // the client's status, see [LDClient.Initialized] and [LDClient.GetDataSourceStatusProvider].
 func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDClient, error) {
-	// Ensure that any intermediate components we create will be disposed of if we return an error
-	client := &LDClient{sdkKey: sdkKey}
-	clientValid := false
-	defer func() {
-		if !clientValid {
-			_ = client.Close()
-		}
-	}()
-
-	closeWhenReady := make(chan struct{})
+	return makeCustomClient(sdkKey, config, waitFor, false)
+}
 
+// makeCustomClient implements both MakeCustomClient and Config.Validate. If validateOnly is true, it builds
+// every component to find any configuration errors, and then closes them instead of creating a client.
+func makeCustomClient(sdkKey string, config Config, waitFor time.Duration, validateOnly bool) (*LDClient, error) {
 	eventProcessorFactory := getEventProcessorFactory(config)
 
+	// Configuration errors are collected rather than returned immediately, so that the application can
+	// find out about all of its configuration problems at once. If an error means that some other
+	// component cannot be safely created, we skip that component.
+	var configErrs []error
+
 	clientContext, err := newClientContextFromConfig(sdkKey, config)
 	if err != nil {
-		return nil, err
+		configErrs = append(configErrs, err)
 	}
+	httpValid := clientContext.HTTP.CreateHTTPClient != nil
+	sdkKeys := newSDKKeyRotator(sdkKey)
+	if httpValid {
+		clientContext.HTTP.CreateHTTPClient = sdkKeys.wrapHTTPClientFactory(clientContext.HTTP.CreateHTTPClient)
+	}
+
+	var dryRunConfig subsystems.DryRunConfiguration
+	if config.DryRunOverrides != nil {
+		dryRunConfig, err = config.DryRunOverrides.Build(clientContext)
+		if err != nil {
+			configErrs = append(configErrs, componentConfigError("DryRunOverrides", err))
+		}
+	}
+	dryRunEnabled := dryRunConfig.Overrides != nil
 
 	// Do not create a diagnostics manager if diagnostics are disabled, or if we're not using the standard event processor.
-	if !config.DiagnosticOptOut {
+	clientContext.EventStatsRecorder = internal.NewEventStatsRecorder()
+
+	if !config.DiagnosticOptOut && !dryRunEnabled && !validateOnly && len(configErrs) == 0 {
 		if reflect.TypeOf(eventProcessorFactory) == reflect.TypeOf(ldcomponents.SendEvents()) {
 			clientContext.DiagnosticsManager = createDiagnosticsManager(clientContext, sdkKey, config, waitFor)
+			clientContext.DiagnosticsRecorder = internal.NewDiagnosticsRecorder(clientContext.DiagnosticsManager)
 		}
 	}
 
 	loggers := clientContext.GetLogging().Loggers
-	loggers.Infof("Starting LaunchDarkly client %s", Version)
-
-	client.loggers = loggers
-	client.logEvaluationErrors = clientContext.GetLogging().LogEvaluationErrors
+	if !validateOnly {
+		loggers.Infof("Starting LaunchDarkly client %s", Version)
+	}
 
-	client.offline = config.Offline
+	wiring := NewComponentWiring(clientContext.GetLogging())
+	components := ClientComponents{
+		Offline:                             config.Offline,
+		VariationTypeChecker:                config.VariationTypeChecker,
+		RejectedUpdatesErrorThreshold:       config.RejectedUpdatesErrorThreshold,
+		Metrics:                             config.Metrics,
+		EvaluationTimeout:                   config.EvaluationTimeout,
+		ConversionDedupWindow:               config.ConversionDedupWindow,
+		DataSourceStatusCoalescingWindow:    config.DataSourceStatusCoalescingWindow,
+		DataSourceInterruptedReportingDelay: config.DataSourceInterruptedReportingDelay,
+	}
 
-	client.dataStoreStatusBroadcaster = internal.NewBroadcaster[interfaces.DataStoreStatus]()
-	dataStoreUpdateSink := datastore.NewDataStoreUpdateSinkImpl(client.dataStoreStatusBroadcaster)
 	storeFactory := config.DataStore
 	if storeFactory == nil {
 		storeFactory = ldcomponents.InMemoryDataStore()
 	}
 	clientContextWithDataStoreUpdateSink := clientContext
-	clientContextWithDataStoreUpdateSink.DataStoreUpdateSink = dataStoreUpdateSink
-	store, err := storeFactory.Build(clientContextWithDataStoreUpdateSink)
+	clientContextWithDataStoreUpdateSink.DataStoreUpdateSink = wiring.DataStoreUpdates()
+	components.DataStore, err = storeFactory.Build(clientContextWithDataStoreUpdateSink)
 	if err != nil {
-		return nil, err
+		configErrs = append(configErrs, componentConfigError("DataStore", err))
 	}
-	client.store = store
 
 	bigSegments := config.BigSegments
 	if bigSegments == nil {
 		bigSegments = ldcomponents.BigSegments(nil)
 	}
-	bsConfig, err := bigSegments.Build(clientContext)
+	components.BigSegments, err = bigSegments.Build(clientContext)
 	if err != nil {
-		return nil, err
+		configErrs = append(configErrs, componentConfigError("BigSegments", err))
+	}
+
+	if config.EvaluationCache != nil {
+		components.EvaluationCache, err = config.EvaluationCache.Build(clientContext)
+		if err != nil {
+			configErrs = append(configErrs, componentConfigError("EvaluationCache", err))
+		}
+	}
+
+	if config.EvaluationMetrics != nil {
+		components.EvaluationMetrics, err = config.EvaluationMetrics.Build(clientContext)
+		if err != nil {
+			configErrs = append(configErrs, componentConfigError("EvaluationMetrics", err))
+		}
+	}
+
+	var dryRun *dryRunComponents
+	if httpValid && dryRunEnabled {
+		dryRun, err = createDryRunComponents(dryRunConfig, clientContext)
+		if err != nil {
+			configErrs = append(configErrs, componentConfigError("DryRunOverrides", err))
+		} else {
+			// In dry-run mode, events go to the dry-run event writer instead of the configured processor.
+			components.EventProcessor = dryRun.eventProcessor
+		}
+	}
+
+	if httpValid {
+		// A nil event processor tells makeClientFromComponents that events are disabled.
+		if !isNullEventProcessorFactory(eventProcessorFactory) && !dryRunEnabled {
+			components.EventProcessor, err = eventProcessorFactory.Build(clientContext)
+			if err != nil {
+				configErrs = append(configErrs, componentConfigError("Events", err))
+			}
+		}
+	} else {
+		loggers.Warn("Events configuration was not validated because the HTTP configuration was invalid")
+	}
+
+	// The data source needs both a working HTTP configuration and a data store to write to.
+	if httpValid && components.DataStore != nil {
+		dataSourceUpdateSink := wiring.DataSourceUpdates(components.DataStore)
+		if config.DataUpdateListener != nil {
+			wiring.dataSourceUpdateSink.SetDataUpdateListener(
+				config.DataUpdateListener,
+				getDataUpdateSource(config.DataSource),
+			)
+		}
+		components.DataSource, err = createDataSource(config, clientContext, components.DataStore, dataSourceUpdateSink)
+		if err != nil {
+			configErrs = append(configErrs, componentConfigError("DataSource", err))
+		}
+	} else {
+		loggers.Warn("DataSource configuration was not validated because of a previous configuration error")
+	}
+
+	if len(configErrs) > 0 || validateOnly {
+		if !validateOnly {
+			loggers.Info("Closing LaunchDarkly client")
+		}
+		components.close()
+		wiring.close()
+		if dryRun != nil {
+			dryRun.close()
+		}
+		return nil, errors.Join(configErrs...)
 	}
-	bsStore := bsConfig.GetStore()
-	client.bigSegmentStoreStatusBroadcaster = internal.NewBroadcaster[interfaces.BigSegmentStoreStatus]()
+
+	offlineWithStore := config.Offline && isPersistentDataStoreFactory(config.DataStore)
+	return makeClientFromComponents(clientOptions{
+		sdkKey:              sdkKey,
+		wiring:              wiring,
+		components:          components,
+		waitFor:             waitFor,
+		sdkKeys:             sdkKeys,
+		diagnosticsRecorder: clientContext.DiagnosticsRecorder,
+		eventStats:          clientContext.EventStatsRecorder,
+		dryRun:              dryRun,
+		offlineWithStore:    offlineWithStore,
+	})
+}
+
+// setUpEvaluation creates the evaluator and the other objects that the client uses for evaluations, once
+// the data store and event processor have been set.
+func (client *LDClient) setUpEvaluation(bsConfig subsystems.BigSegmentsConfiguration, eventsEnabled bool) {
+	var bsStore subsystems.BigSegmentStore
+	if bsConfig != nil {
+		bsStore = bsConfig.GetStore()
+	}
+	client.bigSegmentStoreStatusBroadcaster = internal.NewBroadcasterWithOptions[interfaces.BigSegmentStoreStatus](
+		client.loggers, internal.DefaultSubscriberBufferSize)
 	if bsStore != nil {
+		props := ldstoreimpl.BigSegmentsConfigurationProperties{
+			Store:              bsStore,
+			StartPolling:       true,
+			StatusPollInterval: bsConfig.GetStatusPollInterval(),
+			StaleAfter:         bsConfig.GetStaleAfter(),
+			ContextCacheSize:   bsConfig.GetContextCacheSize(),
+			ContextCacheTime:   bsConfig.GetContextCacheTime(),
+		}
+		// These properties are not part of the BigSegmentsConfiguration interface, so they are only available
+		// if the configuration came from BigSegmentsConfigurationBuilder.
+		if builtConfig, ok := bsConfig.(ldstoreimpl.BigSegmentsConfigurationProperties); ok {
+			props.ContextCacheEvictionPolicy = builtConfig.ContextCacheEvictionPolicy
+			props.MaxConcurrentStoreQueries = builtConfig.MaxConcurrentStoreQueries
+		}
 		client.bigSegmentStoreWrapper = ldstoreimpl.NewBigSegmentStoreWrapperWithConfig(
-			ldstoreimpl.BigSegmentsConfigurationProperties{
-				Store:              bsStore,
-				StartPolling:       true,
-				StatusPollInterval: bsConfig.GetStatusPollInterval(),
-				StaleAfter:         bsConfig.GetStaleAfter(),
-				ContextCacheSize:   bsConfig.GetContextCacheSize(),
-				ContextCacheTime:   bsConfig.GetContextCacheTime(),
-			},
+			props,
 			client.bigSegmentStoreStatusBroadcaster.Broadcast,
-			loggers,
+			client.loggers,
 		)
 		client.bigSegmentStoreStatusProvider = bigsegments.NewBigSegmentStoreStatusProviderImpl(
 			client.bigSegmentStoreWrapper.GetStatus,
@@ -233,53 +389,20 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 		)
 	}
 
-	dataProvider := ldstoreimpl.NewDataStoreEvaluatorDataProvider(store, loggers)
-	evalOptions := []ldeval.EvaluatorOption{
-		ldeval.EvaluatorOptionErrorLogger(client.loggers.ForLevel(ldlog.Error)),
-	}
-	if client.bigSegmentStoreWrapper != nil {
-		evalOptions = append(evalOptions, ldeval.EvaluatorOptionBigSegmentProvider(client.bigSegmentStoreWrapper))
-	}
-	client.evaluator = ldeval.NewEvaluatorWithOptions(dataProvider, evalOptions...)
-
-	client.dataStoreStatusProvider = datastore.NewDataStoreStatusProviderImpl(store, dataStoreUpdateSink)
-
-	client.dataSourceStatusBroadcaster = internal.NewBroadcaster[interfaces.DataSourceStatus]()
-	client.flagChangeEventBroadcaster = internal.NewBroadcaster[interfaces.FlagChangeEvent]()
-	dataSourceUpdateSink := datasource.NewDataSourceUpdateSinkImpl(
-		store,
-		client.dataStoreStatusProvider,
-		client.dataSourceStatusBroadcaster,
-		client.flagChangeEventBroadcaster,
-		clientContext.GetLogging().LogDataSourceOutageAsErrorAfter,
-		loggers,
-	)
+	dataProvider := ldstoreimpl.NewDataStoreEvaluatorDataProvider(client.store, client.loggers)
+	client.evaluator = ldeval.NewEvaluatorWithOptions(dataProvider, client.evaluatorOptions()...)
 
-	client.eventProcessor, err = eventProcessorFactory.Build(clientContext)
-	if err != nil {
-		return nil, err
-	}
-	if isNullEventProcessorFactory(eventProcessorFactory) {
-		client.eventsDefault = newDisabledEventsScope()
-		client.eventsWithReasons = newDisabledEventsScope()
-	} else {
+	if eventsEnabled {
 		client.eventsDefault = newEventsScope(client, false)
 		client.eventsWithReasons = newEventsScope(client, true)
+	} else {
+		client.eventsDefault = newDisabledEventsScope()
+		client.eventsWithReasons = newDisabledEventsScope()
 	}
 	// Pre-create the WithEventsDisabled object so that if an application ends up calling WithEventsDisabled
 	// frequently, it won't be causing an allocation each time.
 	client.withEventsDisabled = newClientEventsDisabledDecorator(client)
 
-	dataSource, err := createDataSource(config, clientContext, dataSourceUpdateSink)
-	client.dataSource = dataSource
-	if err != nil {
-		return nil, err
-	}
-	client.dataSourceStatusProvider = datasource.NewDataSourceStatusProviderImpl(
-		client.dataSourceStatusBroadcaster,
-		dataSourceUpdateSink,
-	)
-
 	client.flagTracker = internal.NewFlagTrackerImpl(
 		client.flagChangeEventBroadcaster,
 		func(flagKey string, context ldcontext.Context, defaultValue ldvalue.Value) ldvalue.Value {
@@ -287,10 +410,25 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 			return value
 		},
 	)
+}
 
-	clientValid = true
+func (client *LDClient) evaluatorOptions() []ldeval.EvaluatorOption {
+	evalOptions := []ldeval.EvaluatorOption{
+		ldeval.EvaluatorOptionErrorLogger(client.loggers.ForLevel(ldlog.Error)),
+	}
+	if client.bigSegmentStoreWrapper != nil {
+		evalOptions = append(evalOptions, ldeval.EvaluatorOptionBigSegmentProvider(client.bigSegmentStoreWrapper))
+	}
+	return evalOptions
+}
+
+// startDataSource starts the data source and waits up to waitFor for it to initialize.
+func (client *LDClient) startDataSource(waitFor time.Duration) error {
+	loggers := client.loggers
+	closeWhenReady := make(chan struct{})
 	client.dataSource.Start(closeWhenReady)
-	if waitFor > 0 && client.dataSource != datasource.NewNullDataSource() {
+	_, external := client.dataSource.(externalUpdatesDataSource)
+	if waitFor > 0 && !client.offline && !external && client.dataSource != datasource.NewNullDataSource() {
 		loggers.Infof("Waiting up to %d milliseconds for LaunchDarkly client to start...",
 			waitFor/time.Millisecond)
 		timeout := time.After(waitFor)
@@ -299,30 +437,35 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 			case <-closeWhenReady:
 				if !client.dataSource.IsInitialized() {
 					loggers.Warn("LaunchDarkly client initialization failed")
-					return client, ErrInitializationFailed
+					return ErrInitializationFailed
 				}
 
 				loggers.Info("Initialized LaunchDarkly client")
-				return client, nil
+				return nil
 			case <-timeout:
 				loggers.Warn("Timeout encountered waiting for LaunchDarkly client initialization")
 				go func() { <-closeWhenReady }() // Don't block the DataSource when not waiting
-				return client, ErrInitializationTimeout
+				return ErrInitializationTimeout
 			}
 		}
 	}
 	go func() { <-closeWhenReady }() // Don't block the DataSource when not waiting
-	return client, nil
+	return nil
 }
 
 func createDataSource(
 	config Config,
 	context *internal.ClientContextImpl,
+	store subsystems.DataStore,
 	dataSourceUpdateSink subsystems.DataSourceUpdateSink,
 ) (subsystems.DataSource, error) {
 	if config.Offline {
 		context.GetLogging().Loggers.Info("Starting LaunchDarkly client in offline mode")
 		dataSourceUpdateSink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+		if isPersistentDataStoreFactory(config.DataStore) {
+			context.GetLogging().Loggers.Info("Feature flags will be read from the persistent data store only")
+			return datasource.NewDaemonModeDataSource(store), nil
+		}
 		return datasource.NewNullDataSource(), nil
 	}
 	factory := config.DataSource
@@ -335,6 +478,56 @@ func createDataSource(
 	return factory.Build(&contextCopy)
 }
 
+// preloadDataStore gives a persistent data store a chance to read its existing data before the data source
+// starts, so that evaluations made before the data source has received any data can still use it. If the
+// store takes longer than the timeout, we start the data source anyway and let the load finish on its own; the
+// store is responsible for not letting the data it loads replace anything newer from the data source.
+func preloadDataStore(store subsystems.DataStore, timeout time.Duration, loggers ldlog.Loggers) {
+	preloadable, ok := store.(datastore.PreloadableDataStore)
+	if !ok {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		preloadable.Preload()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		loggers.Warnf("Persistent data store did not finish loading within %s; starting data source anyway", timeout)
+	}
+}
+
+func makePageViewData(url, referrer string) ldvalue.Value {
+	data := ldvalue.ObjectBuild().SetString("url", url)
+	if referrer != "" {
+		data.SetString("referrer", referrer)
+	}
+	return data.Build()
+}
+
+// This hidden interface is implemented by the SDK's own data source builders, so that records sent to a
+// DataUpdateListener can say what kind of data source they came from.
+type dataUpdateSourceDescription interface {
+	DataUpdateSource() interfaces.DataUpdateSource
+}
+
+func getDataUpdateSource(factory subsystems.ComponentConfigurer[subsystems.DataSource]) interfaces.DataUpdateSource {
+	if factory == nil {
+		return interfaces.DataUpdateSourceStreaming
+	}
+	if d, ok := factory.(dataUpdateSourceDescription); ok {
+		return d.DataUpdateSource()
+	}
+	return interfaces.DataUpdateSourceCustom
+}
+
+func isPersistentDataStoreFactory(factory subsystems.ComponentConfigurer[subsystems.DataStore]) bool {
+	_, ok := datastore.UnwrapDataStoreFactory(factory).(*ldcomponents.PersistentDataStoreBuilder)
+	return ok
+}
+
 // MigrationVariation returns the migration stage of the migration feature flag for the given evaluation context.
 //
 // Returns defaultStage if there is an error or if the flag doesn't exist.
@@ -347,8 +540,11 @@ func (client *LDClient) MigrationVariation(
 func (client *LDClient) migrationVariation(
 	key string, context ldcontext.Context, defaultStage ldmigration.Stage, eventsScope eventsScope,
 ) (ldmigration.Stage, interfaces.LDMigrationOpTracker, error) {
-	detail, flag, err := client.variationAndFlag(key, context, ldvalue.String(string(defaultStage)), true, eventsScope)
+	detail, flag, err := client.variationAndFlag(
+		gocontext.TODO(), key, context, ldvalue.String(string(defaultStage)), true, eventsScope, nil)
+	nonConsenting := client.analyticsConsentFor(context) != internal.AnalyticsConsentGiven
 	tracker := NewMigrationOpTracker(key, flag, context, detail, defaultStage)
+	tracker.nonConsenting = nonConsenting
 
 	if err != nil {
 		return defaultStage, tracker, nil
@@ -358,6 +554,7 @@ func (client *LDClient) migrationVariation(
 	if err != nil {
 		detail = ldreason.NewEvaluationDetailForError(ldreason.EvalErrorWrongType, ldvalue.String(string(defaultStage)))
 		tracker := NewMigrationOpTracker(key, flag, context, detail, defaultStage)
+		tracker.nonConsenting = nonConsenting
 		return defaultStage, tracker, fmt.Errorf("%s; returning default stage %s", err, defaultStage)
 	}
 
@@ -366,6 +563,15 @@ func (client *LDClient) migrationVariation(
 
 // Identify reports details about an evaluation context.
 //
+// There is no separate method for associating an anonymous visitor with the account they later sign in
+// to, as there was in older versions of the SDK, because a multi-kind context does this: give the
+// anonymous visitor a context of some other kind than "user", such as "device", and once they have signed
+// in, use a multi-kind context that contains both, for instance with this call:
+//
+//	client.Identify(ldcontext.NewMulti(deviceContext, userContext))
+//
+// LaunchDarkly then knows that the two contexts belong together.
+//
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/identify#go
 func (client *LDClient) Identify(context ldcontext.Context) error {
 	if client.eventsDefault.disabled {
@@ -375,6 +581,9 @@ func (client *LDClient) Identify(context ldcontext.Context) error {
 		client.loggers.Warnf("Identify called with invalid context: %s", err)
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
+	if client.analyticsConsentFor(context) != internal.AnalyticsConsentGiven {
+		return nil
+	}
 
 	// Identify events should always sample
 	evt := client.eventsDefault.factory.NewIdentifyEventData(ldevents.Context(context), ldvalue.NewOptionalInt(1))
@@ -413,6 +622,9 @@ func (client *LDClient) TrackData(eventName string, context ldcontext.Context, d
 		client.loggers.Warnf("Track called with invalid context: %s", err)
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
+	if client.analyticsConsentFor(context) != internal.AnalyticsConsentGiven {
+		return nil
+	}
 
 	client.eventProcessor.RecordCustomEvent(
 		client.eventsDefault.factory.NewCustomEventData(
@@ -426,6 +638,15 @@ func (client *LDClient) TrackData(eventName string, context ldcontext.Context, d
 	return nil
 }
 
+// TrackPageView reports that a page of a web application was viewed by an evaluation context.
+//
+// This is a convenience wrapper for [LDClient.TrackData]: it sends a custom event with the event key
+// "pageView", whose data is a JSON object with a "url" property and, if referrer is not empty, a
+// "referrer" property.
+func (client *LDClient) TrackPageView(context ldcontext.Context, url, referrer string) error {
+	return client.TrackData(pageViewEventKey, context, makePageViewData(url, referrer))
+}
+
 // TrackMetric reports an event associated with an evaluation context, and adds a numeric value.
 // This value is used by the LaunchDarkly experimentation feature in numeric custom metrics, and will also
 // be returned as part of the custom event for Data Export.
@@ -451,6 +672,64 @@ func (client *LDClient) TrackMetric(
 		client.loggers.Warnf("TrackMetric called with invalid context: %s", err)
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
+	if client.analyticsConsentFor(context) != internal.AnalyticsConsentGiven {
+		return nil
+	}
+	client.eventProcessor.RecordCustomEvent(
+		client.eventsDefault.factory.NewCustomEventData(
+			eventName,
+			ldevents.Context(context),
+			data,
+			true,
+			metricValue,
+			ldvalue.NewOptionalInt(1),
+		))
+	return nil
+}
+
+// TrackConversion reports a conversion event for an experiment, along with the flag variation that the
+// evaluation context saw.
+//
+// It evaluates the flag, and then sends a custom event, as [LDClient.TrackMetric] would, whose data is a JSON
+// object with the properties "flagKey", "variationIndex", and "reason" describing the evaluation; the
+// variation index is null if the evaluation returned no variation. The evaluation also records a feature
+// event as usual, unless a full feature event for the same flag and context was recorded within
+// Config.ConversionDedupWindow, in which case that event is not repeated and the evaluation is only counted
+// in the summary. An event for a failed evaluation, for instance for a flag that does not exist, is still
+// recorded. Full feature events are only remembered once TrackConversion has been called for the first time.
+func (client *LDClient) TrackConversion(
+	eventName string,
+	context ldcontext.Context,
+	flagKey string,
+	metricValue float64,
+) error {
+	if client.eventsDefault.disabled {
+		return nil
+	}
+	if err := context.Err(); err != nil {
+		client.loggers.Warnf("TrackConversion called with invalid context: %s", err)
+		return nil // Don't return an error value, for consistency with the other Track methods
+	}
+	consent := client.analyticsConsentFor(context)
+	eventsScope := client.eventsDefault
+	if consent == internal.AnalyticsConsentGiven {
+		// The evaluation records the dedup key itself, if it produces a full feature event.
+		conversions := client.conversionDeduplicator()
+		if conversions != nil && conversions.recentlyRecorded(makeConversionDedupKey(flagKey, context)) {
+			eventsScope.summaryOnly = true
+		}
+	}
+	detail, _, _ := client.variationAndFlag(gocontext.TODO(), flagKey, context, ldvalue.Null(), false,
+		eventsScope, nil)
+	if consent != internal.AnalyticsConsentGiven {
+		return nil
+	}
+
+	data := ldvalue.ObjectBuild().
+		Set("flagKey", ldvalue.String(flagKey)).
+		Set("variationIndex", detail.VariationIndex.AsValue()).
+		Set("reason", ldvalue.FromJSONMarshal(detail.Reason)).
+		Build()
 	client.eventProcessor.RecordCustomEvent(
 		client.eventsDefault.factory.NewCustomEventData(
 			eventName,
@@ -464,10 +743,18 @@ func (client *LDClient) TrackMetric(
 }
 
 // TrackMigrationOp reports a migration operation event.
+//
+// If an analytics consent function was set with ldcomponents.EventProcessorBuilder.AnalyticsConsent, and
+// the event was built by the tracker that [LDClient.MigrationVariation] returned for a context that has
+// not consented, the event is not recorded. The context of an event that was built in some other way
+// cannot be checked.
 func (client *LDClient) TrackMigrationOp(event ldevents.MigrationOpEventData) error {
 	if client.eventsDefault.disabled {
 		return nil
 	}
+	if reflect.DeepEqual(event.Context, nonConsentingMigrationContext) {
+		return nil
+	}
 
 	client.eventProcessor.RecordMigrationOpEvent(event)
 	return nil
@@ -480,6 +767,9 @@ func (client *LDClient) TrackMigrationOp(event ldevents.MigrationOpEventData) er
 // the status of a client that is configured to be online, use [LDClient.Initialized] or
 // [LDClient.GetDataSourceStatusProvider].
 //
+// An offline client that has a persistent data store still evaluates flags using the data in that store;
+// see Config.Offline.
+//
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/offline-mode#go
 func (client *LDClient) IsOffline() bool {
 	return client.offline
@@ -490,6 +780,9 @@ func (client *LDClient) IsOffline() bool {
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/secure-mode#go
 func (client *LDClient) SecureModeHash(context ldcontext.Context) string {
 	key := []byte(client.sdkKey)
+	if client.sdkKeys != nil {
+		key = []byte(client.sdkKeys.current())
+	}
 	h := hmac.New(sha256.New, key)
 	_, _ = h.Write([]byte(context.FullyQualifiedKey()))
 	return hex.EncodeToString(h.Sum(nil))
@@ -511,6 +804,12 @@ func (client *LDClient) Initialized() bool {
 	return client.dataSource.IsInitialized()
 }
 
+// Version returns the SDK version. This is the same as the package's [Version] constant; it is a method so
+// that it can be part of [interfaces.ReadonlyLDClient].
+func (client *LDClient) Version() string {
+	return Version
+}
+
 // Close shuts down the LaunchDarkly client. After calling this, the LaunchDarkly client
 // should no longer be used. The method will block until all pending analytics events (if any)
 // been sent.
@@ -526,9 +825,15 @@ func (client *LDClient) Close() error {
 	if client.dataSource != nil {
 		_ = client.dataSource.Close()
 	}
+	if client.dataSourceUpdates != nil {
+		client.dataSourceUpdates.Close()
+	}
 	if client.store != nil {
 		_ = client.store.Close()
 	}
+	if client.dryRun != nil {
+		client.dryRun.close()
+	}
 	if client.dataSourceStatusBroadcaster != nil {
 		client.dataSourceStatusBroadcaster.Close()
 	}
@@ -580,6 +885,24 @@ func (client *LDClient) FlushAndWait(timeout time.Duration) bool {
 	return client.eventProcessor.FlushBlocking(timeout)
 }
 
+// GetDiagnosticReport returns the diagnostic data that the SDK will send to LaunchDarkly in its next periodic
+// diagnostic event, without sending it or resetting any statistics. This can be used to check the health of
+// the SDK from an administrative endpoint, or to include it in a support request.
+//
+// The value has the same JSON representation as the event, except that it does not include the
+// droppedEvents and deduplicatedUsers counts, which the SDK only computes when it sends the event. Its
+// format is subject to change. The SDK key is not included.
+//
+// If diagnostic events are disabled, because [Config.DiagnosticOptOut] is true or [Config.Events] is not
+// [ldcomponents.SendEvents], or if the client was created with [MakeClientFromComponents], this returns
+// [ldvalue.Null]().
+func (client *LDClient) GetDiagnosticReport() ldvalue.Value {
+	if client.diagnosticsRecorder == nil {
+		return ldvalue.Null()
+	}
+	return client.diagnosticsRecorder.CreateReport()
+}
+
 // Loggers exposes the logging component used by the SDK.
 //
 // This allows users to easily log messages to a shared channel with the SDK.
@@ -599,7 +922,7 @@ func (client *LDClient) Loggers() interfaces.LDLoggers {
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/all-flags#go
 func (client *LDClient) AllFlagsState(context ldcontext.Context, options ...flagstate.Option) flagstate.AllFlags {
 	valid := true
-	if client.IsOffline() {
+	if client.IsOffline() && !client.offlineWithStore {
 		client.loggers.Warn("Called AllFlagsState in offline mode. Returning empty state")
 		valid = false
 	} else if !client.Initialized() {
@@ -615,12 +938,25 @@ func (client *LDClient) AllFlagsState(context ldcontext.Context, options ...flag
 		return flagstate.AllFlags{}
 	}
 
+	if client.dataSourceEvaluationObserver != nil {
+		client.dataSourceEvaluationObserver.NotifyEvaluation()
+	}
+
 	items, err := client.store.GetAll(datakinds.Features)
 	if err != nil {
 		client.loggers.Warn("Unable to fetch flags from data store. Returning empty state. Error: " + err.Error())
 		return flagstate.AllFlags{}
 	}
 
+	return allFlagsStateFromItems(client.evaluator, items, context, options...)
+}
+
+func allFlagsStateFromItems(
+	evaluator ldeval.Evaluator,
+	items []ldstoretypes.KeyedItemDescriptor,
+	context ldcontext.Context,
+	options ...flagstate.Option,
+) flagstate.AllFlags {
 	clientSideOnly := false
 	for _, o := range options {
 		if o == flagstate.OptionClientSideOnly() {
@@ -637,7 +973,7 @@ func (client *LDClient) AllFlagsState(context ldcontext.Context, options ...flag
 					continue
 				}
 
-				result := client.evaluator.Evaluate(flag, context, nil)
+				result := evaluator.Evaluate(flag, context, nil)
 
 				state.AddFlag(
 					item.Key,
@@ -665,7 +1001,22 @@ func (client *LDClient) AllFlagsState(context ldcontext.Context, options ...flag
 //
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/evaluating#go
 func (client *LDClient) BoolVariation(key string, context ldcontext.Context, defaultVal bool) (bool, error) {
-	detail, err := client.variation(key, context, ldvalue.Bool(defaultVal), true, client.eventsDefault)
+	return client.BoolVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// BoolVariationCtx is the same as [LDClient.BoolVariation], but also takes a Go context for the evaluation. If
+// [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are logged during
+// the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) BoolVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal bool,
+) (bool, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Bool(defaultVal), true, client.eventsDefault)
 	return detail.Value.BoolValue(), err
 }
 
@@ -678,7 +1029,22 @@ func (client *LDClient) BoolVariationDetail(
 	context ldcontext.Context,
 	defaultVal bool,
 ) (bool, ldreason.EvaluationDetail, error) {
-	detail, err := client.variation(key, context, ldvalue.Bool(defaultVal), true, client.eventsWithReasons)
+	return client.BoolVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// BoolVariationDetailCtx is the same as [LDClient.BoolVariationDetail], but also takes a Go context for the
+// evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are
+// logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) BoolVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal bool,
+) (bool, ldreason.EvaluationDetail, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Bool(defaultVal), true, client.eventsWithReasons)
 	return detail.Value.BoolValue(), detail, err
 }
 
@@ -692,7 +1058,22 @@ func (client *LDClient) BoolVariationDetail(
 //
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/evaluating#go
 func (client *LDClient) IntVariation(key string, context ldcontext.Context, defaultVal int) (int, error) {
-	detail, err := client.variation(key, context, ldvalue.Int(defaultVal), true, client.eventsDefault)
+	return client.IntVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// IntVariationCtx is the same as [LDClient.IntVariation], but also takes a Go context for the evaluation. If
+// [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are logged during
+// the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) IntVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal int,
+) (int, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Int(defaultVal), true, client.eventsDefault)
 	return detail.Value.IntValue(), err
 }
 
@@ -705,7 +1086,22 @@ func (client *LDClient) IntVariationDetail(
 	context ldcontext.Context,
 	defaultVal int,
 ) (int, ldreason.EvaluationDetail, error) {
-	detail, err := client.variation(key, context, ldvalue.Int(defaultVal), true, client.eventsWithReasons)
+	return client.IntVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// IntVariationDetailCtx is the same as [LDClient.IntVariationDetail], but also takes a Go context for the
+// evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are
+// logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) IntVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal int,
+) (int, ldreason.EvaluationDetail, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Int(defaultVal), true, client.eventsWithReasons)
 	return detail.Value.IntValue(), detail, err
 }
 
@@ -717,7 +1113,22 @@ func (client *LDClient) IntVariationDetail(
 //
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/evaluating#go
 func (client *LDClient) Float64Variation(key string, context ldcontext.Context, defaultVal float64) (float64, error) {
-	detail, err := client.variation(key, context, ldvalue.Float64(defaultVal), true, client.eventsDefault)
+	return client.Float64VariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// Float64VariationCtx is the same as [LDClient.Float64Variation], but also takes a Go context for the
+// evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are
+// logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) Float64VariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal float64,
+) (float64, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Float64(defaultVal), true, client.eventsDefault)
 	return detail.Value.Float64Value(), err
 }
 
@@ -730,7 +1141,22 @@ func (client *LDClient) Float64VariationDetail(
 	context ldcontext.Context,
 	defaultVal float64,
 ) (float64, ldreason.EvaluationDetail, error) {
-	detail, err := client.variation(key, context, ldvalue.Float64(defaultVal), true, client.eventsWithReasons)
+	return client.Float64VariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// Float64VariationDetailCtx is the same as [LDClient.Float64VariationDetail], but also takes a Go context for
+// the evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that
+// are logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) Float64VariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal float64,
+) (float64, ldreason.EvaluationDetail, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.Float64(defaultVal), true, client.eventsWithReasons)
 	return detail.Value.Float64Value(), detail, err
 }
 
@@ -742,7 +1168,22 @@ func (client *LDClient) Float64VariationDetail(
 //
 // For more information, see the Reference Guide: https://docs.launchdarkly.com/sdk/features/evaluating#go
 func (client *LDClient) StringVariation(key string, context ldcontext.Context, defaultVal string) (string, error) {
-	detail, err := client.variation(key, context, ldvalue.String(defaultVal), true, client.eventsDefault)
+	return client.StringVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// StringVariationCtx is the same as [LDClient.StringVariation], but also takes a Go context for the
+// evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are
+// logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) StringVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal string,
+) (string, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.String(defaultVal), true, client.eventsDefault)
 	return detail.Value.StringValue(), err
 }
 
@@ -755,7 +1196,22 @@ func (client *LDClient) StringVariationDetail(
 	context ldcontext.Context,
 	defaultVal string,
 ) (string, ldreason.EvaluationDetail, error) {
-	detail, err := client.variation(key, context, ldvalue.String(defaultVal), true, client.eventsWithReasons)
+	return client.StringVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// StringVariationDetailCtx is the same as [LDClient.StringVariationDetail], but also takes a Go context for
+// the evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that
+// are logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) StringVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal string,
+) (string, ldreason.EvaluationDetail, error) {
+	detail, err := client.variation(ctx, key, context, ldvalue.String(defaultVal), true, client.eventsWithReasons)
 	return detail.Value.StringValue(), detail, err
 }
 
@@ -787,7 +1243,22 @@ func (client *LDClient) JSONVariation(
 	context ldcontext.Context,
 	defaultVal ldvalue.Value,
 ) (ldvalue.Value, error) {
-	detail, err := client.variation(key, context, defaultVal, false, client.eventsDefault)
+	return client.JSONVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// JSONVariationCtx is the same as [LDClient.JSONVariation], but also takes a Go context for the evaluation. If
+// [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are logged during
+// the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) JSONVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+) (ldvalue.Value, error) {
+	detail, err := client.variation(ctx, key, context, defaultVal, false, client.eventsDefault)
 	return detail.Value, err
 }
 
@@ -800,10 +1271,45 @@ func (client *LDClient) JSONVariationDetail(
 	context ldcontext.Context,
 	defaultVal ldvalue.Value,
 ) (ldvalue.Value, ldreason.EvaluationDetail, error) {
-	detail, err := client.variation(key, context, defaultVal, false, client.eventsWithReasons)
+	return client.JSONVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+// JSONVariationDetailCtx is the same as [LDClient.JSONVariationDetail], but also takes a Go context for the
+// evaluation. If [ldcomponents.LoggingConfigurationBuilder.WithContextExtractor] was used, messages that are
+// logged during the evaluation are prefixed with the string that it returns for ctx.
+//
+// If ctx is done before the evaluation completes, it returns the default value with the error kind
+// [EvalErrorTimeout]; see also [Config.EvaluationTimeout].
+func (client *LDClient) JSONVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+) (ldvalue.Value, ldreason.EvaluationDetail, error) {
+	detail, err := client.variation(ctx, key, context, defaultVal, false, client.eventsWithReasons)
 	return detail.Value, detail, err
 }
 
+// EvaluateWithTrace evaluates a feature flag for the given evaluation context, and also returns a
+// record of each step that the SDK took to arrive at the result.
+//
+// This is meant for diagnosing why a particular context received a particular variation; it is
+// considerably slower than the other evaluation methods and should not be used for normal evaluations.
+// The evaluation itself behaves the same as [LDClient.JSONVariationDetail] with a null default value,
+// including sending an analytics event. See [evaltrace.Trace] for a description of what is recorded.
+//
+//	detail, trace := client.EvaluateWithTrace("my-flag", context)
+//	traceJSON, _ := json.MarshalIndent(trace, "", "  ")
+func (client *LDClient) EvaluateWithTrace(
+	key string,
+	context ldcontext.Context,
+) (ldreason.EvaluationDetail, evaltrace.Trace) {
+	tracer := newEvaluationTracer(key)
+	detail, _, _ := client.variationAndFlag(
+		gocontext.TODO(), key, context, ldvalue.Null(), false, client.eventsWithReasons, tracer)
+	return detail, tracer.trace
+}
+
 // GetDataSourceStatusProvider returns an interface for tracking the status of the data source.
 //
 // The data source is the mechanism that the SDK uses to get feature flag configurations, such as a
@@ -870,34 +1376,73 @@ func (client *LDClient) WithEventsDisabled(disabled bool) interfaces.LDClientInt
 
 // Generic method for evaluating a feature flag for a given evaluation context.
 func (client *LDClient) variation(
+	ctx gocontext.Context,
 	key string,
 	context ldcontext.Context,
 	defaultVal ldvalue.Value,
 	checkType bool,
 	eventsScope eventsScope,
 ) (ldreason.EvaluationDetail, error) {
-	detail, _, err := client.variationAndFlag(key, context, defaultVal, checkType, eventsScope)
+	defaultVal = client.defaultValues.get(key, defaultVal, checkType, client.evaluationLoggers(ctx))
+	detail, _, err := client.variationAndFlag(ctx, key, context, defaultVal, checkType, eventsScope, nil)
 	return detail, err
 }
 
 // Generic method for evaluating a feature flag for a given evaluation context,
-// returning both the result and the flag.
+// returning both the result and the flag. The tracer is nil unless this is EvaluateWithTrace.
 func (client *LDClient) variationAndFlag(
+	ctx gocontext.Context,
 	key string,
 	context ldcontext.Context,
 	defaultVal ldvalue.Value,
 	checkType bool,
 	eventsScope eventsScope,
+	tracer *evaluationTracer,
 ) (ldreason.EvaluationDetail, *ldmodel.FeatureFlag, error) {
+	if client.evaluationMetrics == nil && client.metricsCollector == nil {
+		detail, flag, err := client.variationAndFlagUnmetered(ctx, key, context, defaultVal, checkType, eventsScope,
+			tracer)
+		client.evaluationStats.record(detail.Reason)
+		return detail, flag, err
+	}
+	startTime := time.Now()
+	detail, flag, err := client.variationAndFlagUnmetered(ctx, key, context, defaultVal, checkType, eventsScope, tracer)
+	duration := time.Since(startTime)
+	if client.evaluationMetrics != nil {
+		client.evaluationMetrics.record(key, detail.Reason, duration)
+	}
+	if client.metricsCollector != nil {
+		client.metricsCollector.RecordEvaluation(key, duration.Nanoseconds(), detail.VariationIndex.OrElse(-1),
+			detail.Reason.GetErrorKind())
+	}
+	client.evaluationStats.record(detail.Reason)
+	return detail, flag, err
+}
+
+func (client *LDClient) variationAndFlagUnmetered(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+	checkType bool,
+	eventsScope eventsScope,
+	tracer *evaluationTracer,
+) (ldreason.EvaluationDetail, *ldmodel.FeatureFlag, error) {
+	loggers := client.evaluationLoggers(ctx)
 	if err := context.Err(); err != nil {
-		client.loggers.Warnf("Tried to evaluate a flag with an invalid context: %s", err)
+		loggers.Warnf("Tried to evaluate a flag with an invalid context: %s", err)
 		return newEvaluationError(defaultVal, ldreason.EvalErrorUserNotSpecified), nil, err
 	}
-	if client.IsOffline() {
+	if client.IsOffline() && !client.offlineWithStore {
 		return newEvaluationError(defaultVal, ldreason.EvalErrorClientNotReady), nil, nil
 	}
-	result, flag, err := client.evaluateInternal(key, context, defaultVal, eventsScope)
-	if err != nil {
+	inconsistentType := checkType && client.variationTypeChecker != nil &&
+		!client.variationTypeChecker.check(key, defaultVal.Type(), loggers)
+	result, flag, err := client.evaluateWithDeadline(ctx, key, context, defaultVal, eventsScope, tracer, loggers)
+	if inconsistentType {
+		// This takes precedence over other errors, since it points to a problem in the calling code
+		result.Detail = newEvaluationError(defaultVal, EvalErrorInconsistentVariationType)
+	} else if err != nil {
 		result.Detail.Value = defaultVal
 		result.Detail.VariationIndex = ldvalue.OptionalInt{}
 	} else if checkType && defaultVal.Type() != ldvalue.NullType && result.Detail.Value.Type() != defaultVal.Type() {
@@ -930,7 +1475,15 @@ func (client *LDClient) variationAndFlag(
 				flag.ExcludeFromSummaries,
 			)
 		}
-		client.eventProcessor.RecordEvaluation(eval)
+		if eventsScope.summaryOnly {
+			eval.RequireFullEvent = false
+			eval.Reason = ldreason.EvaluationReason{}
+		}
+		if client.recordEvaluationEvent(eval, context) {
+			if conversions := client.conversions.Load(); conversions != nil {
+				conversions.record(makeConversionDedupKey(key, context))
+			}
+		}
 	}
 
 	return result.Detail, flag, err
@@ -943,6 +1496,8 @@ func (client *LDClient) evaluateInternal(
 	context ldcontext.Context,
 	defaultVal ldvalue.Value,
 	eventsScope eventsScope,
+	tracer *evaluationTracer,
+	loggers ldlog.Loggers,
 ) (ldeval.Result, *ldmodel.FeatureFlag, error) {
 	// THIS IS A HIGH-TRAFFIC CODE PATH so performance tuning is important. Please see CONTRIBUTING.md for guidelines
 	// to keep in mind during any changes to the evaluation logic.
@@ -951,6 +1506,10 @@ func (client *LDClient) evaluateInternal(
 	var storeErr error
 	var ok bool
 
+	if client.dataSourceEvaluationObserver != nil {
+		client.dataSourceEvaluationObserver.NotifyEvaluation()
+	}
+
 	evalErrorResult := func(
 		errKind ldreason.EvalErrorKind,
 		flag *ldmodel.FeatureFlag,
@@ -958,23 +1517,40 @@ func (client *LDClient) evaluateInternal(
 	) (ldeval.Result, *ldmodel.FeatureFlag, error) {
 		detail := newEvaluationError(defaultVal, errKind)
 		if client.logEvaluationErrors {
-			client.loggers.Warn(err)
+			loggers.Warn(err)
 		}
 		return ldeval.Result{Detail: detail}, flag, err
 	}
 
 	if !client.Initialized() {
 		if client.store.IsInitialized() {
-			client.loggers.Warn("Feature flag evaluation called before LaunchDarkly client initialization completed; using last known values from data store") //nolint:lll
+			loggers.Warn("Feature flag evaluation called before LaunchDarkly client initialization completed; using last known values from data store") //nolint:lll
 		} else {
 			return evalErrorResult(ldreason.EvalErrorClientNotReady, nil, ErrClientNotInitialized)
 		}
 	}
 
+	var cacheRecorder *evaluationCacheRecorder
+	if client.evaluationCache != nil && tracer == nil {
+		cacheKey := makeEvaluationCacheKey(key, context)
+		if entry, refresh := client.evaluationCache.get(cacheKey); entry != nil {
+			if eventsScope.prerequisiteEventRecorder != nil {
+				for _, event := range entry.prerequisiteEvents {
+					eventsScope.prerequisiteEventRecorder(event)
+				}
+			}
+			if refresh {
+				client.refreshCachedEvaluation(entry, context)
+			}
+			return client.evaluationResult(key, entry.result, defaultVal, loggers), entry.flag, nil
+		}
+		cacheRecorder = client.evaluationCache.newRecorder(cacheKey)
+	}
+
 	itemDesc, storeErr := client.store.Get(datakinds.Features, key)
 
 	if storeErr != nil {
-		client.loggers.Errorf("Encountered error fetching feature from store: %+v", storeErr)
+		loggers.Errorf("Encountered error fetching feature from store: %+v", storeErr)
 		detail := newEvaluationError(defaultVal, ldreason.EvalErrorException)
 		return ldeval.Result{Detail: detail}, nil, storeErr
 	}
@@ -994,15 +1570,54 @@ func (client *LDClient) evaluateInternal(
 			fmt.Errorf("unknown feature key: %s. Verify that this feature key exists. Returning default value", key))
 	}
 
-	result := client.evaluator.Evaluate(feature, context, eventsScope.prerequisiteEventRecorder)
+	prerequisiteEventRecorder := eventsScope.prerequisiteEventRecorder
+	if tracer != nil {
+		prerequisiteEventRecorder = tracer.prerequisiteRecorder(prerequisiteEventRecorder)
+	}
+	if cacheRecorder != nil {
+		prerequisiteEventRecorder = cacheRecorder.wrap(prerequisiteEventRecorder)
+	}
+	result := client.evaluator.Evaluate(feature, context, prerequisiteEventRecorder)
+	client.bucketByMisses.check(feature, context, result.Detail.Reason)
+	if tracer != nil {
+		client.traceEvaluation(tracer, feature, context, result.Detail.Reason)
+	}
+	if cacheRecorder != nil {
+		cacheRecorder.store(result, feature)
+	}
+	return client.evaluationResult(key, result, defaultVal, loggers), feature, nil
+}
+
+// evaluationResult does the last steps of evaluation that depend on the caller's parameters.
+func (client *LDClient) evaluationResult(
+	key string,
+	result ldeval.Result,
+	defaultVal ldvalue.Value,
+	loggers ldlog.Loggers,
+) ldeval.Result {
 	if result.Detail.Reason.GetKind() == ldreason.EvalReasonError && client.logEvaluationErrors {
-		client.loggers.Warnf("Flag evaluation for %s failed with error %s, default value was returned",
+		loggers.Warnf("Flag evaluation for %s failed with error %s, default value was returned",
 			key, result.Detail.Reason.GetErrorKind())
 	}
 	if result.Detail.IsDefaultValue() {
 		result.Detail.Value = defaultVal
 	}
-	return result, feature, nil
+	return result
+}
+
+// evaluationLoggers returns the loggers to use for an evaluation that was done with ctx, adding the
+// prefix from the configured context extractor if any.
+func (client *LDClient) evaluationLoggers(ctx gocontext.Context) ldlog.Loggers {
+	if client.logContextExtractor == nil || ctx == nil {
+		return client.loggers
+	}
+	prefix := client.logContextExtractor(ctx)
+	if prefix == "" {
+		return client.loggers
+	}
+	loggers := client.loggers
+	loggers.SetPrefix(prefix)
+	return loggers
 }
 
 func newEvaluationError(jsonValue ldvalue.Value, errorKind ldreason.EvalErrorKind) ldreason.EvaluationDetail {
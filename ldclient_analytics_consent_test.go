@@ -0,0 +1,234 @@
+package ldclient
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldmigration"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	consentingContext    = ldcontext.NewBuilder("consenting-key").SetBool("consent", true).Build()
+	nonConsentingContext = ldcontext.NewBuilder("non-consenting-key").Name("Lucy").Build()
+)
+
+func hasConsentAttribute(context ldcontext.Context) bool {
+	return context.GetValue("consent").BoolValue()
+}
+
+// withAnalyticsConsentClient creates a client with real event delivery, runs the callback, flushes
+// events, and returns the payload that was sent.
+func withAnalyticsConsentClient(
+	t *testing.T,
+	events *ldcomponents.EventProcessorBuilder,
+	mockLog *ldlogtest.MockLog,
+	callback func(*LDClient),
+) ldvalue.Value {
+	eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+	var payload ldvalue.Value
+	httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+		td := ldtestdata.DataSource()
+		td.Update(td.Flag("tracked-flag").BooleanFlag().TrackEvents(true))
+		td.Update(td.Flag("summarized-flag").BooleanFlag())
+		td.UsePreconfiguredFlag(ldbuilders.NewFlagBuilder("flag-with-prereq").Version(1).On(true).
+			Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).
+			AddPrerequisite("tracked-flag", 0).Build())
+		config := Config{
+			DataSource:       td,
+			DiagnosticOptOut: true,
+			Events:           events,
+			Logging:          ldcomponents.Logging().Loggers(mockLog.Loggers),
+			ServiceEndpoints: interfaces.ServiceEndpoints{Events: server.URL},
+		}
+		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+		require.NoError(t, err)
+		defer client.Close()
+
+		callback(client)
+		client.Flush()
+
+		select {
+		case r := <-requestsCh:
+			assert.NotContains(t, string(r.Body), nonConsentingContext.Key())
+			assert.NotContains(t, string(r.Body), "Lucy")
+			assert.NotContains(t, string(r.Body), internal.NonConsentingContextKey)
+			payload = ldvalue.Parse(r.Body)
+		case <-time.After(time.Second * 5):
+			require.Fail(t, "timed out waiting for events")
+		}
+	})
+	return payload
+}
+
+func generateMixedTraffic(client *LDClient) {
+	for _, context := range []ldcontext.Context{consentingContext, nonConsentingContext} {
+		_ = client.Identify(context)
+		_, _ = client.BoolVariation("tracked-flag", context, false)
+		_, _ = client.BoolVariation("summarized-flag", context, false)
+		_, _ = client.BoolVariation("flag-with-prereq", context, false)
+		_ = client.TrackEvent("event-key", context)
+		_ = client.TrackMetric("metric-key", context, 1, ldvalue.Null())
+		_ = client.TrackConversion("conversion-key", context, "summarized-flag", 1)
+	}
+}
+
+// eventKeysByKind returns, for each kind of event in the payload other than the summary, the flag or event
+// keys of those events, along with the context keys they were for.
+func eventKeysByKind(payload ldvalue.Value) map[string][]string {
+	ret := make(map[string][]string)
+	for _, event := range payload.AsValueArray().AsSlice() {
+		kind := event.GetByKey("kind").StringValue()
+		if kind == "summary" {
+			continue
+		}
+		contextKey := event.GetByKey("context").GetByKey("key").StringValue()
+		if kind == "custom" {
+			contextKey = event.GetByKey("contextKeys").GetByKey("user").StringValue()
+		}
+		if event.GetByKey("key").IsString() {
+			contextKey = event.GetByKey("key").StringValue() + ":" + contextKey
+		}
+		ret[kind] = append(ret[kind], contextKey)
+	}
+	return ret
+}
+
+func summaryFlagCounts(payload ldvalue.Value) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range payload.AsValueArray().AsSlice() {
+		if event.GetByKey("kind").StringValue() != "summary" {
+			continue
+		}
+		features := event.GetByKey("features")
+		for _, key := range features.Keys(nil) {
+			for _, counter := range features.GetByKey(key).GetByKey("counters").AsValueArray().AsSlice() {
+				counts[key] += counter.GetByKey("count").IntValue()
+			}
+		}
+	}
+	return counts
+}
+
+func TestAnalyticsConsentSummarizesNonConsentingContexts(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	payload := withAnalyticsConsentClient(t,
+		ldcomponents.SendEvents().AnalyticsConsent(hasConsentAttribute), mockLog, generateMixedTraffic)
+
+	assert.Equal(t, map[string][]string{
+		"identify": {"consenting-key"},
+		"feature":  {"tracked-flag:consenting-key", "tracked-flag:consenting-key"},
+		"custom": {"event-key:consenting-key", "metric-key:consenting-key",
+			"conversion-key:consenting-key"},
+	}, eventKeysByKind(payload))
+	assert.Equal(t, map[string]int{
+		"tracked-flag":     4, // each context evaluates it directly and as a prerequisite
+		"summarized-flag":  4, // each context evaluates it directly and for a conversion
+		"flag-with-prereq": 2,
+	}, summaryFlagCounts(payload))
+	assert.Len(t, mockLog.GetOutput(ldlog.Error), 0)
+}
+
+func TestAnalyticsConsentCanExcludeNonConsentingContextsEntirely(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	payload := withAnalyticsConsentClient(t,
+		ldcomponents.SendEvents().AnalyticsConsent(hasConsentAttribute).SummarizeNonConsentingContexts(false),
+		mockLog, generateMixedTraffic)
+
+	assert.Equal(t, map[string][]string{
+		"identify": {"consenting-key"},
+		"feature":  {"tracked-flag:consenting-key", "tracked-flag:consenting-key"},
+		"custom": {"event-key:consenting-key", "metric-key:consenting-key",
+			"conversion-key:consenting-key"},
+	}, eventKeysByKind(payload))
+	assert.Equal(t, map[string]int{"tracked-flag": 2, "summarized-flag": 2, "flag-with-prereq": 1},
+		summaryFlagCounts(payload))
+}
+
+func TestAnalyticsConsentForOnlyNonConsentingContexts(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	payload := withAnalyticsConsentClient(t,
+		ldcomponents.SendEvents().AnalyticsConsent(hasConsentAttribute), mockLog, func(client *LDClient) {
+			multi := ldcontext.NewMulti(nonConsentingContext, ldcontext.NewWithKind("org", "org-key"))
+			_, _ = client.BoolVariation("tracked-flag", nonConsentingContext, false)
+			_, _ = client.BoolVariation("tracked-flag", multi, false)
+		})
+
+	assert.Len(t, eventKeysByKind(payload), 0)
+	assert.Equal(t, map[string]int{"tracked-flag": 2}, summaryFlagCounts(payload))
+	summary := payload.GetByIndex(payload.Count() - 1)
+	assert.ElementsMatch(t, []string{"org", "user"},
+		summaryContextKinds(summary.GetByKey("features").GetByKey("tracked-flag")))
+}
+
+func summaryContextKinds(flagSummary ldvalue.Value) []string {
+	var kinds []string
+	for _, kind := range flagSummary.GetByKey("contextKinds").AsValueArray().AsSlice() {
+		kinds = append(kinds, kind.StringValue())
+	}
+	return kinds
+}
+
+func TestAnalyticsConsentPanicIsTreatedAsNoConsent(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	hasConsent := func(context ldcontext.Context) bool {
+		if !context.GetValue("consent").IsBool() {
+			panic("no consent attribute")
+		}
+		return true
+	}
+	payload := withAnalyticsConsentClient(t,
+		ldcomponents.SendEvents().AnalyticsConsent(hasConsent), mockLog, generateMixedTraffic)
+
+	assert.Equal(t, []string{"consenting-key"}, eventKeysByKind(payload)["identify"])
+	assert.Equal(t, 4, summaryFlagCounts(payload)["tracked-flag"])
+	errors := mockLog.GetOutput(ldlog.Error)
+	require.Len(t, errors, 1)
+	assert.True(t, strings.Contains(errors[0], "no consent attribute"))
+}
+
+func TestAnalyticsConsentDropsMigrationOpEventsForNonConsentingContexts(t *testing.T) {
+	for _, summarize := range []bool{true, false} {
+		t.Run(fmt.Sprintf("summarize non-consenting: %t", summarize), func(t *testing.T) {
+			mockLog := ldlogtest.NewMockLog()
+			events := ldcomponents.SendEvents().AnalyticsConsent(hasConsentAttribute).
+				SummarizeNonConsentingContexts(summarize)
+			payload := withAnalyticsConsentClient(t, events, mockLog, func(client *LDClient) {
+				for _, context := range []ldcontext.Context{consentingContext, nonConsentingContext} {
+					_, tracker, err := client.MigrationVariation("migration-flag", context, ldmigration.Off)
+					require.NoError(t, err)
+					tracker.Operation(ldmigration.Read)
+					tracker.TrackInvoked(ldmigration.Old)
+					event, err := tracker.Build()
+					require.NoError(t, err)
+					require.NoError(t, client.TrackMigrationOp(*event))
+				}
+			})
+
+			var contextKeys []string
+			for _, event := range payload.AsValueArray().AsSlice() {
+				if event.GetByKey("kind").StringValue() == "migration_op" {
+					contextKeys = append(contextKeys, event.GetByKey("contextKeys").GetByKey("user").StringValue())
+				}
+			}
+			assert.Equal(t, []string{consentingContext.Key()}, contextKeys)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package ldclient
+
+import "errors"
+
+// ErrBigSegmentsNotConfigured is returned by [LDClient.PrewarmBigSegments] if the client does not have a
+// Big Segment store.
+var ErrBigSegmentsNotConfigured = errors.New("no Big Segment store is configured")
+
+// PrewarmBigSegments loads the Big Segment membership state for each of the specified context keys into
+// the SDK's cache, so that the first evaluations for those contexts do not have to wait for a query to the
+// Big Segment store. It is meant for applications that know ahead of time which contexts will be most
+// active, such as a service with a fixed set of large customers; call it after the client has been
+// created and before serving traffic.
+//
+// The store is queried for several contexts at once, up to the limit set by
+// [ldcomponents.BigSegmentsConfigurationBuilder.MaxConcurrentStoreQueries]. The cached state expires
+// and is evicted in the same way as any other cached state, as described in
+// [ldcomponents.BigSegmentsConfigurationBuilder.ContextCacheSize].
+//
+// This method waits for all of the queries to finish. If any of them failed, it returns an error that
+// describes each failure; the state for the other contexts is still cached. If Big Segments are not
+// configured, it returns [ErrBigSegmentsNotConfigured].
+func (client *LDClient) PrewarmBigSegments(contextKeys []string) error {
+	if client.bigSegmentStoreWrapper == nil {
+		return ErrBigSegmentsNotConfigured
+	}
+	return client.bigSegmentStoreWrapper.Prewarm(contextKeys)
+}
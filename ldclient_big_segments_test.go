@@ -93,6 +93,20 @@ func TestEvalWithBigSegments(t *testing.T) {
 		})
 	})
 
+	t.Run("user excluded", func(t *testing.T) {
+		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
+			segmentRef := makeBigSegmentRef(bigSegmentKey, 1)
+			membership := mocks.NewMockBigSegmentMembership(nil, map[string]bool{segmentRef: true})
+			bsStore.TestSetMembership(bigsegments.HashForContextKey(evalTestUser.Key()), membership)
+
+			value, detail, err := client.BoolVariationDetail(evalFlagKey, evalTestUser, false)
+			require.NoError(t, err)
+			assert.False(t, value)
+			assert.Equal(t, ldreason.BigSegmentsHealthy, detail.Reason.GetBigSegmentsStatus())
+			assert.Equal(t, []string{segmentRef}, membership.TestGetCheckedSegmentRefs())
+		})
+	})
+
 	t.Run("store error", func(t *testing.T) {
 		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
 			bsStore.TestSetMembershipError(errors.New("sorry"))
@@ -116,3 +130,61 @@ func TestEvalWithBigSegments(t *testing.T) {
 		})
 	})
 }
+
+func TestBigSegmentsContextCacheEvictionPolicyIsUsed(t *testing.T) {
+	testData := ldtestdata.DataSource()
+	addBigSegmentAndFlag(testData)
+	bsStore := &mocks.MockBigSegmentStore{}
+	bsStore.TestSetMetadataToCurrentTime()
+	membership := ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{makeBigSegmentRef(bigSegmentKey, 1)}, nil)
+	bsStore.TestSetMembership(bigsegments.HashForContextKey(evalTestUser.Key()), membership)
+	policy := ldcomponents.LFUEvictionPolicy(10)
+
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = testData
+		c.BigSegments = ldcomponents.BigSegments(
+			mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: bsStore},
+		).ContextCacheEvictionPolicy(policy)
+	})
+	defer client.Close()
+
+	value, err := client.BoolVariation(evalFlagKey, evalTestUser, false)
+	require.NoError(t, err)
+	assert.True(t, value)
+	_, cached := policy.Get(evalTestUser.Key())
+	assert.True(t, cached)
+}
+
+func TestPrewarmBigSegments(t *testing.T) {
+	t.Run("evaluations use prewarmed state", func(t *testing.T) {
+		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
+			contextHash := bigsegments.HashForContextKey(evalTestUser.Key())
+			membership := ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(
+				[]string{makeBigSegmentRef(bigSegmentKey, 1)}, nil)
+			bsStore.TestSetMembership(contextHash, membership)
+
+			require.NoError(t, client.PrewarmBigSegments([]string{evalTestUser.Key()}))
+			assert.Equal(t, []string{contextHash}, bsStore.TestGetMembershipQueries())
+
+			value, err := client.BoolVariation(evalFlagKey, evalTestUser, false)
+			require.NoError(t, err)
+			assert.True(t, value)
+			assert.Equal(t, []string{contextHash}, bsStore.TestGetMembershipQueries())
+		})
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
+			bsStore.TestSetMembershipError(errors.New("sorry"))
+
+			assert.Error(t, client.PrewarmBigSegments([]string{evalTestUser.Key()}))
+		})
+	})
+
+	t.Run("store not configured", func(t *testing.T) {
+		client := makeTestClient()
+		defer client.Close()
+
+		assert.Equal(t, ErrBigSegmentsNotConfigured, client.PrewarmBigSegments([]string{evalTestUser.Key()}))
+	})
+}
@@ -0,0 +1,107 @@
+package ldclient
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// bucketByMissWarningInterval is the shortest time between two warnings from bucketByMissTracker about
+// the same flag and attribute.
+const bucketByMissWarningInterval = time.Minute
+
+// bucketByMissTracker notices when a percentage rollout that buckets by an attribute other than the key
+// is used for a context that has no string or integer value for that attribute. As the specification
+// requires, the evaluator puts such a context in the first bucket; that is easy to miss if the attribute
+// name was mistyped, or if most contexts don't have it, since the rollout then quietly gives most of them
+// the first variation. So this counts the occurrences for each flag and attribute, and logs a warning with
+// the count at most once per interval.
+//
+// The check only looks at properties of the flag and context that the evaluator already had to look at,
+// so it is cheap; and it only counts, with atomic operations, in the unusual case that there is a miss.
+type bucketByMissTracker struct {
+	loggers  ldlog.Loggers
+	interval time.Duration
+	counters sync.Map // bucketByMissKey -> *bucketByMissCounter
+}
+
+type bucketByMissKey struct {
+	flagKey   string
+	attribute string
+}
+
+type bucketByMissCounter struct {
+	notLogged    atomic.Int64
+	lastLoggedAt atomic.Int64 // Unix nanoseconds; zero if there has never been a warning
+}
+
+func newBucketByMissTracker(loggers ldlog.Loggers) *bucketByMissTracker {
+	return &bucketByMissTracker{loggers: loggers, interval: bucketByMissWarningInterval}
+}
+
+// check is called after an evaluation with the reason that the evaluator returned.
+func (t *bucketByMissTracker) check(
+	flag *ldmodel.FeatureFlag,
+	context ldcontext.Context,
+	reason ldreason.EvaluationReason,
+) {
+	var vr *ldmodel.VariationOrRollout
+	switch reason.GetKind() {
+	case ldreason.EvalReasonFallthrough:
+		vr = &flag.Fallthrough
+	case ldreason.EvalReasonRuleMatch:
+		index := reason.GetRuleIndex()
+		if index < 0 || index >= len(flag.Rules) {
+			return // COVERAGE: can't happen, the evaluator only returns indexes of the flag's rules
+		}
+		vr = &flag.Rules[index].VariationOrRollout
+	default:
+		return
+	}
+	rollout := &vr.Rollout
+	if vr.Variation.IsDefined() || len(rollout.Variations) == 0 || !rollout.BucketBy.IsDefined() ||
+		rollout.IsExperiment() { // an experiment always buckets by key
+		return
+	}
+	selectedContext := context.IndividualContextByKind(rollout.ContextKind)
+	if !selectedContext.IsDefined() {
+		return
+	}
+	value := selectedContext.GetValueForRef(rollout.BucketBy)
+	if value.IsString() || value.IsInt() {
+		return
+	}
+	t.recordMiss(bucketByMissKey{flagKey: flag.Key, attribute: rollout.BucketBy.String()})
+}
+
+func (t *bucketByMissTracker) recordMiss(key bucketByMissKey) {
+	c, ok := t.counters.Load(key)
+	if !ok {
+		c, _ = t.counters.LoadOrStore(key, &bucketByMissCounter{})
+	}
+	counter := c.(*bucketByMissCounter)
+	counter.notLogged.Add(1)
+
+	now := time.Now().UnixNano()
+	last := counter.lastLoggedAt.Load()
+	if last != 0 && time.Duration(now-last) < t.interval {
+		return
+	}
+	if !counter.lastLoggedAt.CompareAndSwap(last, now) {
+		return // another goroutine is logging it
+	}
+	count := counter.notLogged.Swap(0)
+	if last == 0 {
+		t.loggers.Warnf("Flag %q has a percentage rollout by attribute %q, but a context without a string or integer"+
+			" value for that attribute was put in the first bucket; more occurrences will be counted"+
+			" and reported periodically", key.flagKey, key.attribute)
+		return
+	}
+	t.loggers.Warnf("Flag %q rollout bucketed %d contexts with a missing or non-bucketable attribute %q since the"+
+		" last warning; they were all put in the first bucket", key.flagKey, count, key.attribute)
+}
@@ -0,0 +1,124 @@
+package ldclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeBucketByFlag(bucketBy string) ldmodel.FeatureFlag {
+	rollout := ldbuilders.Rollout(
+		ldbuilders.Bucket(0, 50000),
+		ldbuilders.Bucket(1, 50000),
+	)
+	rollout.Rollout.BucketBy = ldattr.NewLiteralRef(bucketBy)
+	return ldbuilders.NewFlagBuilder("flagkey").On(true).
+		Variations(ldvalue.String("a"), ldvalue.String("b")).
+		Fallthrough(rollout).
+		Build()
+}
+
+func TestBucketByMissIsLoggedWhenAttributeIsMissing(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		flag := makeBucketByFlag("companyId")
+		_, err := p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		require.NoError(t, err)
+
+		value, _ := p.client.StringVariation(flag.Key, ldcontext.New("userkey"), "x")
+		assert.Equal(t, "a", value)
+
+		require.Len(t, p.mockLog.GetOutput(ldlog.Warn), 1)
+		assert.Equal(t, `Flag "flagkey" has a percentage rollout by attribute "companyId", but a context without a`+
+			` string or integer value for that attribute was put in the first bucket; more occurrences will be`+
+			` counted and reported periodically`, p.mockLog.GetOutput(ldlog.Warn)[0])
+
+		// the next warnings are rate-limited
+		for i := 0; i < 10; i++ {
+			_, _ = p.client.StringVariation(flag.Key, ldcontext.New(fmt.Sprint(i)), "x")
+		}
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 1)
+	})
+}
+
+func TestBucketByMissIsNotLoggedWhenAttributeIsBucketable(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		flag := makeBucketByFlag("companyId")
+		_, err := p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		require.NoError(t, err)
+
+		for _, value := range []ldvalue.Value{ldvalue.String("acme"), ldvalue.Int(3)} {
+			context := ldcontext.NewBuilder("userkey").SetValue("companyId", value).Build()
+			_, _ = p.client.StringVariation(flag.Key, context, "x")
+		}
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 0)
+	})
+}
+
+func TestBucketByMissIsNotLoggedForExperimentOrRolloutByKey(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		byKey := makeBucketByFlag(ldattr.KeyAttr)
+		experiment := makeBucketByFlag("companyId")
+		experiment.Key = "experiment"
+		experiment.Fallthrough.Rollout.Kind = ldmodel.RolloutKindExperiment
+		for _, flag := range []ldmodel.FeatureFlag{byKey, experiment} {
+			_, err := p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+			require.NoError(t, err)
+			_, _ = p.client.StringVariation(flag.Key, ldcontext.New("userkey"), "x")
+		}
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 0)
+	})
+}
+
+func TestBucketByMissIsCheckedForRuleRollout(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		rollout := makeBucketByFlag("companyId").Fallthrough
+		flag := ldbuilders.NewFlagBuilder("flagkey").On(true).
+			Variations(ldvalue.String("a"), ldvalue.String("b")).
+			AddRule(ldbuilders.NewRuleBuilder().ID("rule").
+				Clauses(ldbuilders.Clause(ldattr.KeyAttr, ldmodel.OperatorIn, ldvalue.String("userkey"))).
+				VariationOrRollout(rollout)).
+			FallthroughVariation(1).
+			Build()
+		_, err := p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		require.NoError(t, err)
+
+		_, _ = p.client.StringVariation(flag.Key, ldcontext.New("otherkey"), "x") // fallthrough, no rollout
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 0)
+		_, _ = p.client.StringVariation(flag.Key, ldcontext.New("userkey"), "x")
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 1)
+	})
+}
+
+func TestBucketByMissTrackerReportsCountAfterInterval(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	tracker := newBucketByMissTracker(mockLog.Loggers)
+	tracker.interval = 50 * time.Millisecond
+	key := bucketByMissKey{flagKey: "flagkey", attribute: "companyId"}
+
+	tracker.recordMiss(key)
+	for i := 0; i < 5; i++ {
+		tracker.recordMiss(key)
+		tracker.recordMiss(bucketByMissKey{flagKey: "otherflag", attribute: "companyId"})
+	}
+	assert.Len(t, mockLog.GetOutput(ldlog.Warn), 2) // the first for each flag
+
+	time.Sleep(tracker.interval)
+	tracker.recordMiss(key)
+	warnings := mockLog.GetOutput(ldlog.Warn)
+	require.Len(t, warnings, 3)
+	assert.Equal(t, `Flag "flagkey" rollout bucketed 6 contexts with a missing or non-bucketable attribute`+
+		` "companyId" since the last warning; they were all put in the first bucket`, warnings[2])
+}
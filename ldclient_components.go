@@ -0,0 +1,307 @@
+package ldclient
+
+import (
+	"errors"
+	"time"
+
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datasource"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// ComponentWiring holds the objects that connect SDK components to an [LDClient], for use with
+// [MakeClientFromComponents].
+//
+// A data store or data source reports what it is doing through an update sink that belongs to the client,
+// so these sinks must exist before the components are created. Create a ComponentWiring first, pass
+// [ComponentWiring.DataStoreUpdates] to the data store and [ComponentWiring.DataSourceUpdates] to the
+// data source, and then pass the same ComponentWiring to MakeClientFromComponents. A ComponentWiring can
+// only be used for one client.
+type ComponentWiring struct {
+	logging                     subsystems.LoggingConfiguration
+	dataStoreStatusBroadcaster  *internal.Broadcaster[interfaces.DataStoreStatus]
+	dataStoreUpdateSink         *datastore.DataStoreUpdateSinkImpl
+	dataStoreStatusProvider     interfaces.DataStoreStatusProvider
+	dataSourceStatusBroadcaster *internal.Broadcaster[interfaces.DataSourceStatus]
+	flagChangeEventBroadcaster  *internal.Broadcaster[interfaces.FlagChangeEvent]
+	dataSourceUpdateSink        *datasource.DataSourceUpdateSinkImpl
+	dataSourceUpdatesStore      subsystems.DataStore
+	used                        bool
+}
+
+// NewComponentWiring creates a [ComponentWiring]. The logging configuration is used by the update sinks
+// and by the client.
+func NewComponentWiring(logging subsystems.LoggingConfiguration) *ComponentWiring {
+	broadcaster := internal.NewBroadcasterWithOptions[interfaces.DataStoreStatus](
+		logging.Loggers, internal.DefaultSubscriberBufferSize)
+	return &ComponentWiring{
+		logging:                    logging,
+		dataStoreStatusBroadcaster: broadcaster,
+		dataStoreUpdateSink:        datastore.NewDataStoreUpdateSinkImpl(broadcaster),
+	}
+}
+
+// DataStoreUpdates returns the sink that a data store should use to report its status. This is the same
+// value that the SDK would provide in ClientContext.GetDataStoreUpdateSink().
+func (w *ComponentWiring) DataStoreUpdates() subsystems.DataStoreUpdateSink {
+	return w.dataStoreUpdateSink
+}
+
+// DataSourceUpdates returns the sink that a data source should use to write data to the specified data
+// store and to report its status. This is the same value that the SDK would provide in
+// ClientContext.GetDataSourceUpdateSink().
+//
+// The store must be the same one that is passed to [MakeClientFromComponents]. Calling this method again
+// returns the same sink; it cannot be used to change the store.
+func (w *ComponentWiring) DataSourceUpdates(store subsystems.DataStore) subsystems.DataSourceUpdateSink {
+	if w.dataSourceUpdateSink == nil {
+		w.dataStoreStatusProvider = datastore.NewDataStoreStatusProviderImpl(store, w.dataStoreUpdateSink)
+		w.dataSourceStatusBroadcaster = internal.NewBroadcasterWithOptions[interfaces.DataSourceStatus](
+			w.logging.Loggers, internal.DefaultSubscriberBufferSize)
+		w.flagChangeEventBroadcaster = internal.NewBroadcasterWithOptions[interfaces.FlagChangeEvent](
+			w.logging.Loggers, internal.DefaultSubscriberBufferSize)
+		w.dataSourceUpdateSink = datasource.NewDataSourceUpdateSinkImpl(
+			store,
+			w.dataStoreStatusProvider,
+			w.dataSourceStatusBroadcaster,
+			w.flagChangeEventBroadcaster,
+			w.logging.LogDataSourceOutageAsErrorAfter,
+			w.logging.Loggers,
+		)
+		w.dataSourceUpdatesStore = store
+	}
+	return w.dataSourceUpdateSink
+}
+
+func (w *ComponentWiring) close() {
+	w.dataStoreStatusBroadcaster.Close()
+	if w.dataSourceUpdateSink != nil {
+		w.dataSourceUpdateSink.Close()
+	}
+	if w.dataSourceStatusBroadcaster != nil {
+		w.dataSourceStatusBroadcaster.Close()
+		w.flagChangeEventBroadcaster.Close()
+	}
+}
+
+// ClientComponents contains the already-created components for [MakeClientFromComponents].
+//
+// Any component that is nil is replaced by a default; the defaults are different from those of
+// [MakeCustomClient], since MakeClientFromComponents never creates components that connect to
+// LaunchDarkly.
+type ClientComponents struct {
+	// DataStore is the data store for flags and segments. If it is nil, an in-memory store is used.
+	DataStore subsystems.DataStore
+
+	// DataSource is the data source that puts flags and segments into the data store. It must have been
+	// created with [ComponentWiring.DataSourceUpdates] for DataStore.
+	//
+	// If it is nil, the client does not get data from anywhere, and relies on some other process having put
+	// data into DataStore; [LDClient.Initialized] then returns true only once DataStore has been
+	// initialized, and MakeClientFromComponents does not wait for that.
+	DataSource subsystems.DataSource
+
+	// EventProcessor delivers analytics events. If it is nil, analytics events are disabled.
+	EventProcessor ldevents.EventProcessor
+
+	// BigSegments provides the Big Segments store, if any. If it is nil, Big Segments are not used.
+	BigSegments subsystems.BigSegmentsConfiguration
+
+	// EvaluationMetrics describes where to report evaluation metrics. The zero value disables metrics.
+	EvaluationMetrics subsystems.EvaluationMetricsConfiguration
+
+	// Metrics receives a record of every flag evaluation. If it is nil, evaluations are not reported.
+	Metrics subsystems.EvaluationMetricsCollector
+
+	// EvaluationCache describes how to cache evaluation results. The zero value disables caching.
+	EvaluationCache subsystems.EvaluationCacheConfiguration
+
+	// ConversionDedupWindow is the same as the ConversionDedupWindow field in [Config].
+	ConversionDedupWindow time.Duration
+
+	// EvaluationTimeout is the same as the EvaluationTimeout field in [Config].
+	EvaluationTimeout time.Duration
+
+	// VariationTypeChecker is the same as the VariationTypeChecker field in [Config].
+	VariationTypeChecker *VariationTypeChecker
+
+	// RejectedUpdatesErrorThreshold is the same as the RejectedUpdatesErrorThreshold field in [Config].
+	RejectedUpdatesErrorThreshold int
+
+	// DataSourceStatusCoalescingWindow is the same as the DataSourceStatusCoalescingWindow field in [Config].
+	DataSourceStatusCoalescingWindow time.Duration
+
+	// DataSourceInterruptedReportingDelay is the same as the DataSourceInterruptedReportingDelay field in
+	// [Config].
+	DataSourceInterruptedReportingDelay time.Duration
+
+	// Offline is the same as the Offline field in [Config], except that it does not change any
+	// components; it only makes [LDClient.IsOffline] return true and makes MakeClientFromComponents
+	// return without waiting.
+	Offline bool
+}
+
+func (c ClientComponents) close() {
+	if c.EventProcessor != nil {
+		_ = c.EventProcessor.Close()
+	}
+	if c.DataSource != nil {
+		_ = c.DataSource.Close()
+	}
+	if c.DataStore != nil {
+		_ = c.DataStore.Close()
+	}
+	if c.BigSegments != nil && c.BigSegments.GetStore() != nil {
+		_ = c.BigSegments.GetStore().Close()
+	}
+}
+
+// MakeClientFromComponents creates a new client instance from components that the application has
+// already created, such as with a dependency injection framework, instead of from configuration
+// factories like [MakeCustomClient].
+//
+// It only connects the components to each other and to the client, and starts the data source. It does
+// not call any component factories, so component-specific settings in [Config] do not apply. See
+// [ComponentWiring] for how to create a data store and data source that can be used with the client.
+//
+// The waitFor parameter and the return values have the same meaning as in MakeCustomClient. It returns a
+// nil client and an error, without starting anything, if the wiring has already been used or if the data
+// source was not created with wiring.DataSourceUpdates for the same DataStore; in that case, the caller
+// is still responsible for closing the components. Otherwise, the client takes ownership of the
+// components and closes them when it is closed.
+//
+// The SDK key is only used for [LDClient.SecureModeHash]; the components are responsible for their own
+// authentication.
+func MakeClientFromComponents(
+	sdkKey string,
+	wiring *ComponentWiring,
+	components ClientComponents,
+	waitFor time.Duration,
+) (*LDClient, error) {
+	if wiring.used {
+		return nil, errors.New("ComponentWiring has already been used to create a client")
+	}
+	if components.DataSource != nil {
+		if wiring.dataSourceUpdateSink == nil {
+			return nil, errors.New("DataSource was not created with ComponentWiring.DataSourceUpdates")
+		}
+		if components.DataStore == nil || wiring.dataSourceUpdatesStore != components.DataStore {
+			return nil, errors.New("DataSource was created with ComponentWiring.DataSourceUpdates for a different DataStore")
+		}
+	}
+	wiring.logging.Loggers.Infof("Starting LaunchDarkly client %s", Version)
+	return makeClientFromComponents(clientOptions{
+		sdkKey:     sdkKey,
+		wiring:     wiring,
+		components: components,
+		waitFor:    waitFor,
+	})
+}
+
+// clientOptions holds the parameters of makeClientFromComponents. The fields after waitFor are only set
+// by MakeCustomClient, and can be left as zero values.
+type clientOptions struct {
+	sdkKey              string
+	wiring              *ComponentWiring
+	components          ClientComponents
+	waitFor             time.Duration
+	sdkKeys             *sdkKeyRotator
+	diagnosticsRecorder *internal.DiagnosticsRecorder
+	eventStats          *internal.EventStatsRecorder
+	dryRun              *dryRunComponents
+	offlineWithStore    bool
+}
+
+func makeClientFromComponents(opts clientOptions) (*LDClient, error) {
+	wiring, components, dryRun := opts.wiring, opts.components, opts.dryRun
+	wiring.used = true
+	loggers := wiring.logging.Loggers
+
+	client := &LDClient{
+		sdkKey:               opts.sdkKey,
+		sdkKeys:              opts.sdkKeys,
+		diagnosticsRecorder:  opts.diagnosticsRecorder,
+		eventStats:           opts.eventStats,
+		loggers:              loggers,
+		logEvaluationErrors:  wiring.logging.LogEvaluationErrors,
+		logContextExtractor:  wiring.logging.ContextExtractor,
+		offline:              components.Offline,
+		offlineWithStore:     opts.offlineWithStore,
+		variationTypeChecker: components.VariationTypeChecker,
+		evaluationMetrics:    newEvaluationMetrics(components.EvaluationMetrics),
+		metricsCollector:     components.Metrics,
+		evaluationTimeout:    components.EvaluationTimeout,
+		evaluationCache:      newEvaluationCache(components.EvaluationCache),
+		conversionsWindow:    components.ConversionDedupWindow,
+		bucketByMisses:       newBucketByMissTracker(loggers),
+		dryRun:               dryRun,
+	}
+
+	store := components.DataStore
+	if store == nil {
+		store = datastore.NewInMemoryDataStore(loggers)
+	}
+	client.store = store
+	client.dataStoreCacheStats, _ = datastore.UnwrapDataStore(store).(datastore.CacheStatsProvider)
+	if dryRun != nil {
+		// The client's own store is only used for reading; the data source still writes to the real store.
+		client.store = dryRunDataStore{DataStore: store, overrides: dryRun.store}
+	}
+
+	wiring.DataSourceUpdates(store)
+	dataSourceUpdateSink := wiring.dataSourceUpdateSink
+	dataSourceUpdateSink.SetRejectedUpdatesErrorThreshold(components.RejectedUpdatesErrorThreshold)
+	dataSourceUpdateSink.SetStatusCoalescingWindow(components.DataSourceStatusCoalescingWindow)
+	dataSourceUpdateSink.SetInterruptedReportingDelay(components.DataSourceInterruptedReportingDelay)
+	if client.evaluationCache != nil {
+		dataSourceUpdateSink.SetFlagInvalidationHandlers(client.evaluationCache.invalidate,
+			client.evaluationCache.invalidateAll)
+	}
+	client.dataSourceUpdates = dataSourceUpdateSink
+	client.dataStoreStatusBroadcaster = wiring.dataStoreStatusBroadcaster
+	client.dataStoreStatusProvider = wiring.dataStoreStatusProvider
+	client.dataSourceStatusBroadcaster = wiring.dataSourceStatusBroadcaster
+	client.flagChangeEventBroadcaster = wiring.flagChangeEventBroadcaster
+	client.dataSourceStatusProvider = datasource.NewDataSourceStatusProviderImpl(
+		client.dataSourceStatusBroadcaster,
+		dataSourceUpdateSink,
+	)
+
+	client.dataSource = components.DataSource
+	if client.dataSource == nil {
+		client.dataSource = externalUpdatesDataSource{DataSource: datasource.NewNullDataSource(), store: store}
+		dataSourceUpdateSink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+	}
+	client.dataSourceEvaluationObserver, _ = client.dataSource.(datasource.EvaluationObserver)
+
+	client.eventProcessor = components.EventProcessor
+	client.analyticsConsent, _ = client.eventProcessor.(internal.AnalyticsConsentFilter)
+	eventsEnabled := client.eventProcessor != nil
+	if !eventsEnabled {
+		client.eventProcessor = ldevents.NewNullEventProcessor()
+	}
+
+	client.setUpEvaluation(components.BigSegments, eventsEnabled)
+	client.evaluationCannotBlock = datastore.IsInMemoryDataStore(datastore.UnwrapDataStore(store)) &&
+		(components.BigSegments == nil || components.BigSegments.GetStore() == nil)
+
+	preloadDataStore(store, dataStorePreloadTimeout, loggers)
+	if dryRun != nil {
+		dryRun.start(opts.waitFor, client)
+	}
+	return client, client.startDataSource(opts.waitFor)
+}
+
+// externalUpdatesDataSource is used by MakeClientFromComponents if there is no data source. Some other
+// process is responsible for putting data into the store, so the client is initialized once the store is.
+type externalUpdatesDataSource struct {
+	subsystems.DataSource
+	store subsystems.DataStore
+}
+
+func (d externalUpdatesDataSource) IsInitialized() bool {
+	return d.store.IsInitialized()
+}
@@ -0,0 +1,168 @@
+package ldclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeClientFromComponents(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	wiring := NewComponentWiring(subsystems.LoggingConfiguration{Loggers: mockLog.Loggers})
+	store := datastore.NewInMemoryDataStore(mockLog.Loggers)
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flagkey").On(true).VariationForAll(true))
+	dataSource, err := td.Build(subsystems.BasicClientContext{DataSourceUpdateSink: wiring.DataSourceUpdates(store)})
+	require.NoError(t, err)
+	events := &mocks.CapturingEventProcessor{}
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{
+		DataStore:      store,
+		DataSource:     dataSource,
+		EventProcessor: events,
+	}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.True(t, client.Initialized())
+	assert.False(t, client.IsOffline())
+	assert.Equal(t, interfaces.DataSourceStateValid, client.GetDataSourceStatusProvider().GetStatus().State)
+	assert.True(t, client.GetDataStoreStatusProvider().GetStatus().Available)
+	mockLog.AssertMessageMatch(t, true, ldlog.Info, "Starting LaunchDarkly client")
+
+	value, err := client.BoolVariation("flagkey", evalTestUser, false)
+	require.NoError(t, err)
+	assert.True(t, value)
+	assert.Len(t, events.Events, 1)
+
+	flagCh := client.GetFlagTracker().AddFlagChangeListener()
+	td.Update(td.Flag("flagkey").On(false))
+	select {
+	case event := <-flagCh:
+		assert.Equal(t, "flagkey", event.Key)
+	case <-time.After(time.Second):
+		assert.Fail(t, "timed out waiting for flag change event")
+	}
+}
+
+func TestMakeClientFromComponentsWithDefaults(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.False(t, client.Initialized()) // nothing has put any data into the in-memory store
+	assert.Equal(t, interfaces.DataSourceStateValid, client.GetDataSourceStatusProvider().GetStatus().State)
+
+	value, err := client.BoolVariation("flagkey", evalTestUser, false)
+	assert.Error(t, err)
+	assert.False(t, value)
+	assert.True(t, client.eventsDefault.disabled)
+}
+
+func TestMakeClientFromComponentsReadsDataPutIntoStoreElsewhere(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+	store := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).FallthroughVariation(1).Build()
+	_ = store.Init(sharedtest.NewDataSetBuilder().Flags(flag).Build())
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{DataStore: store}, 0)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.True(t, client.Initialized())
+	value, err := client.BoolVariation("flagkey", evalTestUser, false)
+	require.NoError(t, err)
+	assert.True(t, value)
+}
+
+func TestMakeClientFromComponentsWithoutDataSourceIsInitializedWhenStoreIs(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+	store := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{DataStore: store}, time.Second)
+	require.NoError(t, err) // it does not wait for the store
+	defer client.Close()
+	assert.False(t, client.Initialized())
+
+	_ = store.Init(sharedtest.NewDataSetBuilder().Build())
+	assert.True(t, client.Initialized())
+}
+
+func TestMakeClientFromComponentsOffline(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{Offline: true}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.True(t, client.IsOffline())
+}
+
+func TestMakeClientFromComponentsRejectsReusedWiring(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{}, 0)
+	require.NoError(t, err)
+	defer client.Close()
+
+	client2, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{}, 0)
+	assert.Nil(t, client2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already been used")
+}
+
+func TestMakeClientFromComponentsRejectsDataSourceNotCreatedWithWiring(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+	store := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+	dataSource := mocks.DataSourceThatIsAlwaysInitialized().(mocks.SingleComponentConfigurer[subsystems.DataSource]).Instance
+
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{
+		DataStore:  store,
+		DataSource: dataSource,
+	}, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not created with ComponentWiring.DataSourceUpdates")
+}
+
+func TestMakeClientFromComponentsRejectsDataSourceForDifferentStore(t *testing.T) {
+	wiring := NewComponentWiring(sharedtest.TestLoggingConfig())
+	store1 := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+	store2 := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+	td := ldtestdata.DataSource()
+	dataSource, err := td.Build(subsystems.BasicClientContext{DataSourceUpdateSink: wiring.DataSourceUpdates(store1)})
+	require.NoError(t, err)
+
+	for _, store := range []subsystems.DataStore{store2, nil} {
+		client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{
+			DataStore:  store,
+			DataSource: dataSource,
+		}, 0)
+		assert.Nil(t, client)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "different DataStore")
+	}
+
+	// the wiring was not used up by the failed attempts
+	client, err := MakeClientFromComponents(testSdkKey, wiring, ClientComponents{
+		DataStore:  store1,
+		DataSource: dataSource,
+	}, 0)
+	require.NoError(t, err)
+	_ = client.Close()
+}
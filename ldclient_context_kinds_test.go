@@ -0,0 +1,124 @@
+package ldclient
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests use flag and segment JSON of the kind that LaunchDarkly or the Relay Proxy sends, to verify
+// that the context kinds in clauses, rollouts, and segment rules survive deserialization and storage and are
+// used in evaluations.
+
+const contextKindsFlagJSON = `{
+	"key": "context-kinds-flag",
+	"version": 1,
+	"on": true,
+	"variations": ["first-bucket", "org-rule", "second-bucket"],
+	"offVariation": 0,
+	"rules": [
+		{
+			"id": "rule0",
+			"clauses": [{"contextKind": "org", "attribute": "key", "op": "in", "values": ["matching-org"]}],
+			"variation": 1
+		}
+	],
+	"fallthrough": {
+		"rollout": {
+			"contextKind": "org",
+			"variations": [{"variation": 0, "weight": 60000}, {"variation": 2, "weight": 40000}]
+		}
+	},
+	"salt": "salt"
+}`
+
+const contextKindsSegmentJSON = `{
+	"key": "context-kinds-segment",
+	"version": 1,
+	"rules": [
+		{
+			"id": "rule0",
+			"clauses": [{"contextKind": "org", "attribute": "name", "op": "in", "values": ["x"]}],
+			"weight": 50000,
+			"rolloutContextKind": "org"
+		}
+	],
+	"salt": "salt"
+}`
+
+func parseContextKindsFlag(t *testing.T) ldmodel.FeatureFlag {
+	flag, err := ldmodel.NewJSONDataModelSerialization().UnmarshalFeatureFlag([]byte(contextKindsFlagJSON))
+	require.NoError(t, err)
+	return flag
+}
+
+func TestContextKindsInFlagAndSegmentSurviveSerialization(t *testing.T) {
+	flag := parseContextKindsFlag(t)
+	require.Equal(t, ldcontext.Kind("org"), flag.Rules[0].Clauses[0].ContextKind)
+	require.Equal(t, ldcontext.Kind("org"), flag.Fallthrough.Rollout.ContextKind)
+
+	flagItem, err := datakinds.Features.Deserialize(datakinds.Features.Serialize(sharedtest.FlagDescriptor(flag)))
+	require.NoError(t, err)
+	flag1 := flagItem.Item.(*ldmodel.FeatureFlag)
+	assert.Equal(t, ldcontext.Kind("org"), flag1.Rules[0].Clauses[0].ContextKind)
+	assert.Equal(t, ldcontext.Kind("org"), flag1.Fallthrough.Rollout.ContextKind)
+
+	segment, err := ldmodel.NewJSONDataModelSerialization().UnmarshalSegment([]byte(contextKindsSegmentJSON))
+	require.NoError(t, err)
+	segmentItem, err := datakinds.Segments.Deserialize(
+		datakinds.Segments.Serialize(sharedtest.SegmentDescriptor(segment)))
+	require.NoError(t, err)
+	segment1 := segmentItem.Item.(*ldmodel.Segment)
+	assert.Equal(t, ldcontext.Kind("org"), segment1.Rules[0].Clauses[0].ContextKind)
+	assert.Equal(t, ldcontext.Kind("org"), segment1.Rules[0].RolloutContextKind)
+}
+
+func TestClauseWithContextKindDoesNotMatchContextWithoutThatKind(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(parseContextKindsFlag(t))
+		user := ldcontext.New("matching-org") // a user with the same key as the org in the clause
+
+		value, detail, err := p.client.StringVariationDetail("context-kinds-flag", user, "default")
+		require.NoError(t, err)
+		assert.NotEqual(t, "org-rule", value)
+		assert.Equal(t, ldreason.EvalReasonFallthrough, detail.Reason.GetKind())
+
+		multi := ldcontext.NewMulti(user, ldcontext.NewWithKind("org", "matching-org"))
+		value, detail, err = p.client.StringVariationDetail("context-kinds-flag", multi, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "org-rule", value)
+		assert.Equal(t, ldreason.EvalReasonRuleMatch, detail.Reason.GetKind())
+	})
+}
+
+func TestRolloutWithContextKindBucketsByThatKind(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(parseContextKindsFlag(t))
+
+		t.Run("context without that kind gets the first bucket", func(t *testing.T) {
+			for _, key := range []string{"user1", "user2", "user3", "user4"} {
+				value, _ := p.client.StringVariation("context-kinds-flag", ldcontext.New(key), "default")
+				assert.Equal(t, "first-bucket", value, "user %s", key)
+			}
+		})
+
+		t.Run("result depends only on the context of that kind", func(t *testing.T) {
+			for _, orgKey := range []string{"org1", "org2", "org3", "org4"} {
+				org := ldcontext.NewWithKind("org", orgKey)
+				expected, _ := p.client.StringVariation("context-kinds-flag", org, "default")
+				for _, userKey := range []string{"user1", "user2", "user3", "user4"} {
+					multi := ldcontext.NewMulti(ldcontext.New(userKey), org)
+					value, _ := p.client.StringVariation("context-kinds-flag", multi, "default")
+					assert.Equal(t, expected, value, "org %s, user %s", orgKey, userKey)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package ldclient
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+// DefaultConversionDedupWindow is the value that is used for Config.ConversionDedupWindow if it is zero.
+const DefaultConversionDedupWindow = 5 * time.Minute
+
+// conversionDedupCapacity is the most flag and context combinations that a conversionDeduplicator
+// remembers; the least recently used ones are forgotten first.
+const conversionDedupCapacity = 10000
+
+// conversionDeduplicator remembers when a full feature event was last recorded for each combination of
+// flag key and context, so that LDClient.TrackConversion does not record another one within the window.
+// The event processor has similar state of its own, but it only deduplicates contexts, and it does not
+// expose that state.
+//
+// LDClient only creates one the first time TrackConversion is called, so that applications that don't use
+// it don't pay for keeping track of every full feature event; until then, no event counts as a duplicate.
+// If deduplication is disabled, there never is one.
+type conversionDeduplicator struct {
+	window  time.Duration
+	entries map[conversionDedupKey]*list.Element
+	lru     *list.List // of *conversionDedupEntry, most recently used first
+	lock    sync.Mutex
+}
+
+type conversionDedupKey struct {
+	flagKey    string
+	contextKey string
+}
+
+type conversionDedupEntry struct {
+	key      conversionDedupKey
+	recorded time.Time
+}
+
+func newConversionDeduplicator(window time.Duration) *conversionDeduplicator {
+	if window < 0 {
+		return nil
+	}
+	if window == 0 {
+		window = DefaultConversionDedupWindow
+	}
+	return &conversionDeduplicator{
+		window:  window,
+		entries: make(map[conversionDedupKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// conversionDeduplicator returns the client's conversionDeduplicator, creating it if necessary, or nil if
+// deduplication is disabled.
+func (client *LDClient) conversionDeduplicator() *conversionDeduplicator {
+	if d := client.conversions.Load(); d != nil {
+		return d
+	}
+	d := newConversionDeduplicator(client.conversionsWindow)
+	if d == nil || client.conversions.CompareAndSwap(nil, d) {
+		return d
+	}
+	return client.conversions.Load()
+}
+
+func makeConversionDedupKey(flagKey string, context ldcontext.Context) conversionDedupKey {
+	return conversionDedupKey{flagKey: flagKey, contextKey: context.FullyQualifiedKey()}
+}
+
+// recentlyRecorded returns true if a feature event for this flag and context was recorded within the
+// window.
+func (d *conversionDeduplicator) recentlyRecorded(key conversionDedupKey) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	elem, ok := d.entries[key]
+	if !ok {
+		return false
+	}
+	if time.Since(elem.Value.(*conversionDedupEntry).recorded) > d.window {
+		d.lru.Remove(elem)
+		delete(d.entries, key)
+		return false
+	}
+	return true
+}
+
+// record notes that a feature event for this flag and context has just been recorded.
+func (d *conversionDeduplicator) record(key conversionDedupKey) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if elem, ok := d.entries[key]; ok {
+		elem.Value.(*conversionDedupEntry).recorded = time.Now()
+		d.lru.MoveToFront(elem)
+		return
+	}
+	d.entries[key] = d.lru.PushFront(&conversionDedupEntry{key: key, recorded: time.Now()})
+	if d.lru.Len() > conversionDedupCapacity {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.entries, oldest.Value.(*conversionDedupEntry).key)
+	}
+}
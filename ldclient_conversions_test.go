@@ -0,0 +1,224 @@
+package ldclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type conversionTestParams struct {
+	client *LDClient
+	data   *ldtestdata.TestDataSource
+	events *mocks.CapturingEventProcessor
+}
+
+func withConversionTestParams(dedupWindow time.Duration, callback func(conversionTestParams)) {
+	p := conversionTestParams{data: ldtestdata.DataSource(), events: &mocks.CapturingEventProcessor{}}
+	config := Config{
+		DataSource:            p.data,
+		Events:                mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: p.events},
+		Logging:               ldcomponents.Logging().Loggers(ldlogtest.NewMockLog().Loggers),
+		ConversionDedupWindow: dedupWindow,
+	}
+	p.client, _ = MakeCustomClient("sdk_key", config, 0)
+	defer p.client.Close()
+	callback(p)
+}
+
+func (p conversionTestParams) takeEvents() (evaluations []ldevents.EvaluationData, custom []ldevents.CustomEventData) {
+	for _, e := range p.events.Events {
+		switch e := e.(type) {
+		case ldevents.EvaluationData:
+			evaluations = append(evaluations, e)
+		case ldevents.CustomEventData:
+			custom = append(custom, e)
+		}
+	}
+	p.events.Events = nil
+	return
+}
+
+func TestTrackConversionSendsCustomEventWithEvaluation(t *testing.T) {
+	withConversionTestParams(0, func(p conversionTestParams) {
+		p.data.Update(p.data.Flag("flagkey").Variations(ldvalue.String("a"), ldvalue.String("b")).
+			FallthroughVariationIndex(1))
+		user := ldcontext.New("userkey")
+
+		require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 2.5))
+
+		evaluations, custom := p.takeEvents()
+		require.Len(t, evaluations, 1)
+		assert.Equal(t, "flagkey", evaluations[0].Key)
+		require.Len(t, custom, 1)
+		e := custom[0]
+		assert.Equal(t, "eventkey", e.Key)
+		assert.Equal(t, ldevents.Context(user), e.Context)
+		assert.True(t, e.HasMetric)
+		assert.Equal(t, 2.5, e.MetricValue)
+		assert.Equal(t, ldvalue.ObjectBuild().
+			SetString("flagKey", "flagkey").
+			SetInt("variationIndex", 1).
+			Set("reason", ldvalue.FromJSONMarshal(ldreason.NewEvalReasonFallthrough())).
+			Build(), e.Data)
+	})
+}
+
+func TestTrackConversionForUnknownFlag(t *testing.T) {
+	withConversionTestParams(0, func(p conversionTestParams) {
+		require.NoError(t, p.client.TrackConversion("eventkey", ldcontext.New("userkey"), "unknown", 1))
+
+		evaluations, custom := p.takeEvents()
+		assert.Len(t, evaluations, 1)
+		require.Len(t, custom, 1)
+		assert.Equal(t, ldvalue.ObjectBuild().
+			SetString("flagKey", "unknown").
+			Set("variationIndex", ldvalue.Null()).
+			Set("reason", ldvalue.FromJSONMarshal(ldreason.NewEvalReasonError(ldreason.EvalErrorFlagNotFound))).
+			Build(), custom[0].Data)
+	})
+}
+
+func TestTrackConversionDoesNotRepeatFeatureEvent(t *testing.T) {
+	user := ldcontext.New("userkey")
+
+	t.Run("after another conversion", func(t *testing.T) {
+		withConversionTestParams(0, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 2))
+
+			evaluations, custom := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[0].RequireFullEvent)
+			assert.False(t, evaluations[1].RequireFullEvent) // still counted in the summary
+			assert.Equal(t, ldreason.EvaluationReason{}, evaluations[1].Reason)
+			assert.Len(t, custom, 2)
+			assert.Equal(t, custom[0].Data, custom[1].Data)
+
+			// a different context or flag is not a duplicate
+			p.data.Update(p.data.Flag("otherflag").BooleanFlag().TrackEvents(true))
+			require.NoError(t, p.client.TrackConversion("eventkey", ldcontext.New("otheruser"), "flagkey", 1))
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "otherflag", 1))
+			evaluations, _ = p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[0].RequireFullEvent)
+			assert.True(t, evaluations[1].RequireFullEvent)
+		})
+	})
+
+	t.Run("after an evaluation with a full feature event", func(t *testing.T) {
+		withConversionTestParams(0, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "otherflag", 1))
+			_, _ = p.takeEvents()
+
+			_, _ = p.client.BoolVariation("flagkey", user, false)
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+
+			evaluations, custom := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[0].RequireFullEvent)
+			assert.False(t, evaluations[1].RequireFullEvent)
+			require.Len(t, custom, 1)
+			assert.Equal(t, ldvalue.Int(0), custom[0].Data.GetByKey("variationIndex")) // true is variation 0
+		})
+	})
+
+	t.Run("but does after an evaluation before the first conversion", func(t *testing.T) {
+		withConversionTestParams(0, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+
+			_, _ = p.client.BoolVariation("flagkey", user, false)
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+
+			evaluations, _ := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[1].RequireFullEvent)
+		})
+	})
+
+	t.Run("but does after an evaluation that is only summarized", func(t *testing.T) {
+		withConversionTestParams(0, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag())
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+
+			evaluations, _ := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.False(t, evaluations[0].RequireFullEvent)
+			assert.True(t, evaluations[1].RequireFullEvent)
+		})
+	})
+
+	t.Run("after the window", func(t *testing.T) {
+		withConversionTestParams(50*time.Millisecond, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+			time.Sleep(100 * time.Millisecond)
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+
+			evaluations, _ := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[1].RequireFullEvent)
+		})
+	})
+
+	t.Run("when deduplication is disabled", func(t *testing.T) {
+		withConversionTestParams(-1, func(p conversionTestParams) {
+			p.data.Update(p.data.Flag("flagkey").BooleanFlag().TrackEvents(true))
+
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+			require.NoError(t, p.client.TrackConversion("eventkey", user, "flagkey", 1))
+
+			evaluations, _ := p.takeEvents()
+			require.Len(t, evaluations, 2)
+			assert.True(t, evaluations[1].RequireFullEvent)
+			assert.Nil(t, p.client.conversions.Load())
+		})
+	})
+}
+
+func TestTrackConversionWithInvalidContextSendsNoEvent(t *testing.T) {
+	withConversionTestParams(0, func(p conversionTestParams) {
+		require.NoError(t, p.client.TrackConversion("eventkey", ldcontext.New(""), "flagkey", 1))
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestTrackConversionWithEventsDisabled(t *testing.T) {
+	withConversionTestParams(0, func(p conversionTestParams) {
+		p.data.Update(p.data.Flag("flagkey").BooleanFlag())
+
+		ci := p.client.WithEventsDisabled(true)
+		require.NoError(t, ci.TrackConversion("eventkey", ldcontext.New("userkey"), "flagkey", 1))
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestConversionDeduplicatorForgetsLeastRecentlyUsed(t *testing.T) {
+	d := newConversionDeduplicator(time.Hour)
+	first := conversionDedupKey{flagKey: "flag", contextKey: "first"}
+	d.record(first)
+	for i := 0; i < conversionDedupCapacity; i++ {
+		d.record(conversionDedupKey{flagKey: "flag", contextKey: fmt.Sprint(i)})
+	}
+	assert.False(t, d.recentlyRecorded(first))
+	assert.True(t, d.recentlyRecorded(conversionDedupKey{flagKey: "flag", contextKey: "0"}))
+	assert.Len(t, d.entries, conversionDedupCapacity)
+}
@@ -0,0 +1,76 @@
+package ldclient
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// defaultValuesRegistry holds the values set with LDClient.SetDefaultValues. The map is never modified
+// after it is stored, so evaluations can read it without locking.
+type defaultValuesRegistry struct {
+	values atomic.Pointer[registeredDefaultValues]
+}
+
+type registeredDefaultValues struct {
+	values       map[string]ldvalue.Value
+	warnedTypeOf sync.Map // flag keys whose wrong type has been logged; see defaultValuesRegistry.get
+}
+
+// SetDefaultValues specifies the values that the variation methods, such as [LDClient.BoolVariation],
+// return for each of the specified flag keys instead of the default value passed by the caller. This
+// keeps the value that an application uses when a flag cannot be evaluated in one place, rather than at
+// every call site.
+//
+// A registered value is only used in the cases when the caller's default value would have been: the flag
+// does not exist, the client is not initialized, the flag data is malformed, or the flag is off and has
+// no off variation. The Default property of the analytics event is the value that was actually used. If
+// the registered value's type does not match the variation method, as in calling BoolVariation for a flag
+// whose registered value is a string, the client uses the caller's default value and logs a warning, once
+// for each flag key until SetDefaultValues is called again; IntVariation and Float64Variation accept any
+// number, and JSONVariation accepts any type.
+//
+// Each call replaces all of the previously registered values; passing nil removes them. The client keeps
+// its own copy of the map, so the caller may change it afterward. It is safe to call SetDefaultValues at
+// any time, including while other goroutines are evaluating flags.
+func (client *LDClient) SetDefaultValues(values map[string]ldvalue.Value) {
+	if len(values) == 0 {
+		client.defaultValues.values.Store(nil)
+		return
+	}
+	copied := make(map[string]ldvalue.Value, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	client.defaultValues.values.Store(&registeredDefaultValues{values: copied})
+}
+
+// get returns the registered default value for a flag key, or the caller's default value if there is none
+// or the registered value has the wrong type.
+func (r *defaultValuesRegistry) get(
+	key string,
+	defaultVal ldvalue.Value,
+	checkType bool,
+	loggers ldlog.Loggers,
+) ldvalue.Value {
+	registered := r.values.Load()
+	if registered == nil {
+		return defaultVal
+	}
+	value, ok := registered.values[key]
+	if !ok {
+		return defaultVal
+	}
+	if checkType && defaultVal.Type() != ldvalue.NullType && value.Type() != defaultVal.Type() {
+		// This would otherwise be logged for every evaluation of the flag.
+		if _, warned := registered.warnedTypeOf.LoadOrStore(key, struct{}{}); warned {
+			return defaultVal
+		}
+		loggers.Warnf("The default value registered for flag %q is a %s, but the flag was evaluated with %s; using the caller's default value", //nolint:lll
+			key, value.Type(), variationMethodForType(defaultVal.Type()))
+		return defaultVal
+	}
+	return value
+}
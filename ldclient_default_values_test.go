@@ -0,0 +1,189 @@
+package ldclient
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisteredDefaultValueIsUsedForUnknownFlag(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.client.SetDefaultValues(map[string]ldvalue.Value{"no-such-flag": ldvalue.String("registered")})
+
+		value, err := p.client.StringVariation("no-such-flag", evalTestUser, "call-site")
+		assert.Error(t, err)
+		assert.Equal(t, "registered", value)
+
+		e := p.requireSingleEvent(t)
+		assert.Equal(t, ldvalue.String("registered"), e.Value)
+		assert.Equal(t, ldvalue.String("registered"), e.Default)
+	})
+}
+
+func TestRegisteredDefaultValueIsUsedForMalformedFlag(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(false).OffVariation(5).Variations(offValue, onValue).Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+
+		value, detail, err := p.client.StringVariationDetail(evalFlagKey, evalTestUser, "call-site")
+		assert.NoError(t, err)
+		assert.Equal(t, "registered", value)
+		assert.Equal(t, ldreason.NewEvalReasonError(ldreason.EvalErrorMalformedFlag), detail.Reason)
+
+		e := p.requireSingleEvent(t)
+		assert.Equal(t, ldvalue.String("registered"), e.Default)
+	})
+}
+
+func TestRegisteredDefaultValueIsUsedWhenClientIsNotInitialized(t *testing.T) {
+	events := &mocks.CapturingEventProcessor{}
+	client, _ := MakeCustomClient(testSdkKey, Config{
+		DataSource: mocks.DataSourceThatNeverInitializes(),
+		Events:     mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: events},
+		Logging:    ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}, 0)
+	defer client.Close()
+	client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.Bool(true)})
+
+	value, err := client.BoolVariation(evalFlagKey, evalTestUser, false)
+	assert.Equal(t, ErrClientNotInitialized, err)
+	assert.True(t, value)
+
+	require.Len(t, events.Events, 1)
+	assert.Equal(t, ldvalue.Bool(true), events.Events[0].(ldevents.EvaluationData).Default)
+}
+
+func TestRegisteredDefaultValueIsNotUsedIfFlagCanBeEvaluated(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).FallthroughVariation(1).Variations(offValue, onValue).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+
+		value, err := p.client.StringVariation(evalFlagKey, evalTestUser, "call-site")
+		assert.NoError(t, err)
+		assert.Equal(t, "on", value)
+
+		e := p.requireSingleEvent(t)
+		assert.Equal(t, ldvalue.String("registered"), e.Default)
+	})
+}
+
+func TestRegisteredDefaultValueOfWrongTypeIsNotUsed(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+
+		value, _ := p.client.BoolVariation(evalFlagKey, evalTestUser, true)
+		assert.True(t, value)
+		assert.Equal(t, ldvalue.Bool(true), p.requireSingleEvent(t).Default)
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, `registered for flag "flag-key" is a string`)
+	})
+}
+
+func TestRegisteredDefaultValueOfWrongTypeIsLoggedOnce(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+
+		_, _ = p.client.BoolVariation(evalFlagKey, evalTestUser, true)
+		_, _ = p.client.BoolVariation(evalFlagKey, evalTestUser, true)
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 1)
+
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+		value, _ := p.client.BoolVariation(evalFlagKey, evalTestUser, true)
+		assert.True(t, value)
+		assert.Len(t, p.mockLog.GetOutput(ldlog.Warn), 2)
+	})
+}
+
+func TestRegisteredDefaultValueTypes(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.client.SetDefaultValues(map[string]ldvalue.Value{
+			"int-flag":   ldvalue.Int(3),
+			"float-flag": ldvalue.Float64(1.5),
+			"json-flag":  ldvalue.ObjectBuild().Set("a", ldvalue.Int(1)).Build(),
+		})
+
+		intValue, _ := p.client.IntVariation("int-flag", evalTestUser, 1)
+		assert.Equal(t, 3, intValue)
+		floatValue, _ := p.client.Float64Variation("int-flag", evalTestUser, 1)
+		assert.Equal(t, float64(3), floatValue)
+		floatValue, _ = p.client.Float64Variation("float-flag", evalTestUser, 1)
+		assert.Equal(t, 1.5, floatValue)
+		jsonValue, _ := p.client.JSONVariation("json-flag", evalTestUser, ldvalue.String("x"))
+		assert.Equal(t, ldvalue.ObjectBuild().Set("a", ldvalue.Int(1)).Build(), jsonValue)
+		jsonValue, _ = p.client.JSONVariation("int-flag", evalTestUser, ldvalue.Null())
+		assert.Equal(t, ldvalue.Int(3), jsonValue)
+	})
+}
+
+func TestSetDefaultValuesReplacesRegisteredValues(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		values := map[string]ldvalue.Value{"flag1": ldvalue.String("a"), "flag2": ldvalue.String("b")}
+		p.client.SetDefaultValues(values)
+		values["flag1"] = ldvalue.String("changed") // the client has its own copy
+
+		value, _ := p.client.StringVariation("flag1", evalTestUser, "call-site")
+		assert.Equal(t, "a", value)
+
+		p.client.SetDefaultValues(map[string]ldvalue.Value{"flag2": ldvalue.String("c")})
+		value, _ = p.client.StringVariation("flag1", evalTestUser, "call-site")
+		assert.Equal(t, "call-site", value)
+		value, _ = p.client.StringVariation("flag2", evalTestUser, "call-site")
+		assert.Equal(t, "c", value)
+
+		p.client.SetDefaultValues(nil)
+		value, _ = p.client.StringVariation("flag2", evalTestUser, "call-site")
+		assert.Equal(t, "call-site", value)
+	})
+}
+
+func TestRegisteredDefaultValueIsUsedByClientWithEventsDisabled(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("registered")})
+
+		value, _ := p.client.WithEventsDisabled(true).StringVariation(evalFlagKey, evalTestUser, "call-site")
+		assert.Equal(t, "registered", value)
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestSetDefaultValuesDuringEvaluations(t *testing.T) {
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.Events = ldcomponents.NoEvents()
+		c.Logging = ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers())
+	})
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				value, _ := client.StringVariation(evalFlagKey, evalTestUser, "call-site")
+				assert.Contains(t, []string{"call-site", "a", "b"}, value)
+			}
+		}()
+	}
+	for time.Now().Before(deadline) {
+		client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("a")})
+		client.SetDefaultValues(map[string]ldvalue.Value{evalFlagKey: ldvalue.String("b")})
+		client.SetDefaultValues(nil)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,162 @@
+package ldclient
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// dryRunComponents are the components that implement Config.DryRunOverrides. The overrides data source
+// has its own wiring and in-memory store, so that it never writes to the client's data store and its
+// status does not affect the client's data source status.
+type dryRunComponents struct {
+	wiring         *ComponentWiring
+	store          subsystems.DataStore
+	dataSource     subsystems.DataSource
+	eventProcessor ldevents.EventProcessor
+}
+
+// dryRunDataStore is the store that the client evaluates flags from in dry-run mode. It returns the
+// overrides store's version of any item that is in that store, and otherwise the data store's version.
+// Writes go only to the data store, although normally the client does not write to either one directly.
+type dryRunDataStore struct {
+	subsystems.DataStore
+	overrides subsystems.DataStore
+}
+
+// dryRunEventSender writes analytics event payloads to the writer from the dry-run configuration, one
+// line of JSON per event, instead of sending them to LaunchDarkly.
+type dryRunEventSender struct {
+	writer  io.Writer
+	lock    sync.Mutex
+	loggers ldlog.Loggers
+}
+
+func createDryRunComponents(
+	config subsystems.DryRunConfiguration,
+	clientContext *internal.ClientContextImpl,
+) (*dryRunComponents, error) {
+	loggers := clientContext.GetLogging().Loggers
+	wiring := NewComponentWiring(clientContext.GetLogging())
+	store := datastore.NewInMemoryDataStore(loggers)
+
+	contextCopy := *clientContext
+	contextCopy.BasicClientContext.DataSourceUpdateSink = wiring.DataSourceUpdates(store)
+	dataSource, err := config.Overrides.Build(&contextCopy)
+	if err != nil {
+		wiring.close()
+		return nil, err
+	}
+
+	d := &dryRunComponents{wiring: wiring, store: store, dataSource: dataSource}
+	if config.EventWriter == nil {
+		d.eventProcessor = ldevents.NewNullEventProcessor()
+	} else {
+		d.eventProcessor = ldevents.NewDefaultEventProcessor(ldevents.EventsConfiguration{
+			Capacity:              ldcomponents.DefaultEventsCapacity,
+			EventSender:           &dryRunEventSender{writer: config.EventWriter, loggers: loggers},
+			FlushInterval:         ldcomponents.DefaultFlushInterval,
+			Loggers:               loggers,
+			UserKeysCapacity:      ldcomponents.DefaultContextKeysCapacity,
+			UserKeysFlushInterval: ldcomponents.DefaultContextKeysFlushInterval,
+		})
+	}
+	loggers.Warn("Dry-run mode is enabled; flags will be evaluated with overrides, and events will not be sent")
+	return d, nil
+}
+
+// start starts the overrides data source and waits up to waitFor for it to initialize, so that the first
+// evaluations use the overrides. Changes to the overrides are reported to the client's flag change
+// listeners, which also keeps the evaluation cache and flag snapshots up to date.
+func (d *dryRunComponents) start(waitFor time.Duration, client *LDClient) {
+	flagChanges := d.wiring.flagChangeEventBroadcaster.AddListener()
+	go func() {
+		for event := range flagChanges {
+			client.flagChangeEventBroadcaster.Broadcast(event)
+		}
+	}()
+
+	closeWhenReady := make(chan struct{})
+	d.dataSource.Start(closeWhenReady)
+	if waitFor <= 0 {
+		go func() { <-closeWhenReady }() // Don't block the DataSource when not waiting
+		return
+	}
+	select {
+	case <-closeWhenReady:
+	case <-time.After(waitFor):
+		client.loggers.Warn("Timeout encountered waiting for dry-run overrides to load")
+		go func() { <-closeWhenReady }() // Don't block the DataSource when not waiting
+	}
+}
+
+func (d *dryRunComponents) close() {
+	_ = d.dataSource.Close()
+	_ = d.store.Close()
+	d.wiring.close()
+}
+
+func (s dryRunDataStore) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
+	item, err := s.overrides.Get(kind, key)
+	if err == nil && (item.Item != nil || item.Version >= 0) {
+		return item, nil
+	}
+	return s.DataStore.Get(kind, key)
+}
+
+func (s dryRunDataStore) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
+	items, err := s.DataStore.GetAll(kind)
+	if err != nil {
+		return nil, err
+	}
+	overrides, _ := s.overrides.GetAll(kind) // the in-memory store never returns an error
+	if len(overrides) == 0 {
+		return items, nil
+	}
+	overridden := make(map[string]bool, len(overrides))
+	for _, item := range overrides {
+		overridden[item.Key] = true
+	}
+	result := make([]ldstoretypes.KeyedItemDescriptor, 0, len(items)+len(overrides))
+	for _, item := range items {
+		if !overridden[item.Key] {
+			result = append(result, item)
+		}
+	}
+	return append(result, overrides...), nil
+}
+
+func (s *dryRunEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	if kind != ldevents.AnalyticsEventDataKind {
+		return ldevents.EventSenderResult{Success: true}
+	}
+	events := ldvalue.Parse(data)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := 0; i < events.Count(); i++ {
+		event := events.GetByIndex(i)
+		obj := ldvalue.ObjectBuildWithCapacity(event.Count() + 1)
+		for _, key := range event.Keys(nil) {
+			obj.Set(key, event.GetByKey(key))
+		}
+		line := obj.SetBool("dry_run", true).Build().JSONString() + "\n"
+		if _, err := io.WriteString(s.writer, line); err != nil {
+			s.loggers.Warnf("Unable to write dry-run events: %s", err)
+			return ldevents.EventSenderResult{}
+		}
+	}
+	return ldevents.EventSenderResult{Success: true}
+}
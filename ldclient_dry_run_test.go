@@ -0,0 +1,169 @@
+package ldclient
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dryRunTestParams struct {
+	client    *LDClient
+	data      *ldtestdata.TestDataSource
+	overrides *ldtestdata.TestDataSource
+	events    *mocks.CapturingEventProcessor
+}
+
+func withDryRunTestParams(eventWriter *bytes.Buffer, callback func(dryRunTestParams)) {
+	p := dryRunTestParams{
+		data:      ldtestdata.DataSource(),
+		overrides: ldtestdata.DataSource(),
+		events:    &mocks.CapturingEventProcessor{},
+	}
+	dryRun := ldcomponents.DryRunOverrides(p.overrides)
+	if eventWriter != nil {
+		dryRun.EventWriter(eventWriter)
+	}
+	config := Config{
+		DataSource:      p.data,
+		DryRunOverrides: dryRun,
+		Events:          mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: p.events},
+		Logging:         ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	p.client, _ = MakeCustomClient(testSdkKey, config, time.Second)
+	defer p.client.Close()
+	callback(p)
+}
+
+func parseDryRunEvents(t *testing.T, output string) []ldvalue.Value {
+	var events []ldvalue.Value
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		event := ldvalue.Parse([]byte(line))
+		require.Equal(t, ldvalue.ObjectType, event.Type(), "not a JSON object: %s", line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestDryRunOverridesTakePrecedenceOverDataStore(t *testing.T) {
+	withDryRunTestParams(nil, func(p dryRunTestParams) {
+		p.data.Update(p.data.Flag("overridden").VariationForAll(false))
+		p.data.Update(p.data.Flag("not-overridden").VariationForAll(false))
+		p.overrides.Update(p.overrides.Flag("overridden").VariationForAll(true))
+		p.overrides.Update(p.overrides.Flag("only-in-overrides").VariationForAll(true))
+
+		value, err := p.client.BoolVariation("overridden", evalTestUser, false)
+		assert.NoError(t, err)
+		assert.True(t, value)
+
+		value, err = p.client.BoolVariation("not-overridden", evalTestUser, true)
+		assert.NoError(t, err)
+		assert.False(t, value)
+
+		value, err = p.client.BoolVariation("only-in-overrides", evalTestUser, false)
+		assert.NoError(t, err)
+		assert.True(t, value)
+
+		assert.Equal(t, map[string]ldvalue.Value{
+			"overridden":        ldvalue.Bool(true),
+			"not-overridden":    ldvalue.Bool(false),
+			"only-in-overrides": ldvalue.Bool(true),
+		}, p.client.AllFlagsState(evalTestUser).ToValuesMap())
+	})
+}
+
+func TestDryRunDoesNotChangeDataStore(t *testing.T) {
+	withDryRunTestParams(nil, func(p dryRunTestParams) {
+		p.data.Update(p.data.Flag("flag").VariationForAll(false))
+		p.overrides.Update(p.overrides.Flag("flag").VariationForAll(true))
+		p.overrides.Update(p.overrides.Flag("other-flag").VariationForAll(true))
+
+		realStore := p.client.store.(dryRunDataStore).DataStore
+		item, err := realStore.Get(datakinds.Features, "flag")
+		require.NoError(t, err)
+		require.NotNil(t, item.Item)
+		assert.Equal(t, ldvalue.NewOptionalInt(1), item.Item.(*ldmodel.FeatureFlag).Fallthrough.Variation) // false
+
+		item, err = realStore.Get(datakinds.Features, "other-flag")
+		require.NoError(t, err)
+		assert.Nil(t, item.Item)
+	})
+}
+
+func TestDryRunSeesChangesToOverrides(t *testing.T) {
+	withDryRunTestParams(nil, func(p dryRunTestParams) {
+		p.data.Update(p.data.Flag("flag").VariationForAll(false))
+		flagCh := p.client.GetFlagTracker().AddFlagChangeListener()
+
+		p.overrides.Update(p.overrides.Flag("flag").VariationForAll(true))
+
+		event := th.RequireValue(t, flagCh, time.Second)
+		assert.Equal(t, "flag", event.Key)
+		value, _ := p.client.BoolVariation("flag", evalTestUser, false)
+		assert.True(t, value)
+	})
+}
+
+func TestDryRunWritesEventsToWriterInsteadOfEventProcessor(t *testing.T) {
+	var buf bytes.Buffer
+	withDryRunTestParams(&buf, func(p dryRunTestParams) {
+		p.data.Update(p.data.Flag("flag").VariationForAll(false))
+		p.overrides.Update(p.overrides.Flag("flag").VariationForAll(true))
+		p.overrides.Update(p.overrides.Flag("flag").VariationForAll(true)) // override is now version 2
+
+		_, _ = p.client.BoolVariation("flag", evalTestUser, false)
+		require.True(t, p.client.FlushAndWait(time.Second))
+
+		assert.Len(t, p.events.Events, 0)
+
+		events := parseDryRunEvents(t, buf.String())
+		require.NotEmpty(t, events)
+		var summary ldvalue.Value
+		for _, event := range events {
+			assert.Equal(t, ldvalue.Bool(true), event.GetByKey("dry_run"))
+			if event.GetByKey("kind").StringValue() == "summary" {
+				summary = event
+			}
+		}
+		counters := summary.GetByKey("features").GetByKey("flag").GetByKey("counters")
+		require.Equal(t, 1, counters.Count())
+		assert.Equal(t, ldvalue.Int(2), counters.GetByIndex(0).GetByKey("version"))
+		assert.Equal(t, ldvalue.Bool(true), counters.GetByIndex(0).GetByKey("value"))
+	})
+}
+
+func TestDryRunWithoutEventWriterDiscardsEvents(t *testing.T) {
+	withDryRunTestParams(nil, func(p dryRunTestParams) {
+		p.overrides.Update(p.overrides.Flag("flag").VariationForAll(true))
+
+		_, _ = p.client.BoolVariation("flag", evalTestUser, false)
+		p.client.Flush()
+
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestDryRunOverridesWithoutDataSourceIsConfigurationError(t *testing.T) {
+	client, err := MakeCustomClient(testSdkKey, Config{
+		DataSource:      mocks.DataSourceThatIsAlwaysInitialized(),
+		DryRunOverrides: ldcomponents.DryRunOverrides(nil),
+		Logging:         ldcomponents.NoLogging(),
+	}, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DryRunOverrides")
+}
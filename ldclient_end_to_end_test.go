@@ -5,17 +5,24 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
 
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
@@ -44,27 +51,92 @@ func assertNoMoreRequests(t *testing.T, requestsCh <-chan httphelpers.HTTPReques
 }
 
 func TestDefaultDataSourceIsStreaming(t *testing.T) {
-	data := ldservices.NewServerSDKData().Flags(&alwaysTrueFlag)
-	streamHandler, _ := ldservices.ServerSideStreamingServiceHandler(data.ToPutEvent())
-	httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
-		logCapture := ldlogtest.NewMockLog()
-		defer logCapture.DumpIfTestFailed(t)
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+	defer server.Close()
 
-		config := Config{
-			Events:           ldcomponents.NoEvents(),
-			Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
-			ServiceEndpoints: interfaces.ServiceEndpoints{Streaming: streamServer.URL},
-		}
+	logCapture := ldlogtest.NewMockLog()
+	defer logCapture.DumpIfTestFailed(t)
 
-		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
-		require.NoError(t, err)
-		defer client.Close()
+	config := Config{
+		Events:           ldcomponents.NoEvents(),
+		Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
 
-		assert.Equal(t, string(interfaces.DataSourceStateValid), string(client.GetDataSourceStatusProvider().GetStatus().State))
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client.Close()
 
-		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
-		assert.True(t, value)
-	})
+	assert.Equal(t, string(interfaces.DataSourceStateValid), string(client.GetDataSourceStatusProvider().GetStatus().State))
+
+	value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
+	assert.True(t, value)
+
+	r := <-server.DataRequests()
+	assert.Equal(t, ldservices.ServerSideSDKStreamingPath, r.Request.URL.Path)
+}
+
+func TestClientReceivesStreamUpdates(t *testing.T) {
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+	defer server.Close()
+
+	config := Config{
+		Events:           ldcomponents.NoEvents(),
+		Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
+
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client.Close()
+
+	updatedFlag := ldbuilders.NewFlagBuilder(alwaysTrueFlag.Key).Version(alwaysTrueFlag.Version + 1).
+		SingleVariation(ldvalue.Bool(false)).Build()
+	server.PatchFlag(&updatedFlag)
+	assert.Eventually(t, func() bool {
+		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, true)
+		return !value
+	}, time.Second, time.Millisecond*10)
+
+	server.DeleteFlag(alwaysTrueFlag.Key, alwaysTrueFlag.Version+2)
+	assert.Eventually(t, func() bool {
+		_, detail, _ := client.BoolVariationDetail(alwaysTrueFlag.Key, testUser, false)
+		return detail.Reason.GetErrorKind() == ldreason.EvalErrorFlagNotFound
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestClientReconnectsAfterStreamIsDropped(t *testing.T) {
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+	defer server.Close()
+
+	config := Config{
+		DataSource:       ldcomponents.StreamingDataSource().InitialReconnectDelay(time.Millisecond * 10),
+		Events:           ldcomponents.NoEvents(),
+		Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
+
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client.Close()
+	<-server.DataRequests()
+
+	updatedFlag := ldbuilders.NewFlagBuilder(alwaysTrueFlag.Key).Version(alwaysTrueFlag.Version + 1).
+		SingleVariation(ldvalue.Bool(false)).Build()
+	server.FailWithBrokenConnection()
+	server.PutData(ldservices.NewServerSDKData().Flags(&updatedFlag))
+	select {
+	case <-server.DataRequests():
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for reconnection attempt")
+	}
+
+	server.Recover()
+	assert.Eventually(t, func() bool {
+		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, true)
+		return !value
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, string(interfaces.DataSourceStateValid), string(client.GetDataSourceStatusProvider().GetStatus().State))
 }
 
 func TestClientStartsInStreamingMode(t *testing.T) {
@@ -100,36 +172,38 @@ func TestClientStartsInStreamingMode(t *testing.T) {
 }
 
 func TestClientFailsToStartInStreamingModeWith401Error(t *testing.T) {
-	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(401))
-	httphelpers.WithServer(handler, func(streamServer *httptest.Server) {
-		logCapture := ldlogtest.NewMockLog()
+	server := ldservices.NewMockServer(nil)
+	defer server.Close()
+	server.FailWithStatus(401)
+	requestsCh := server.DataRequests()
 
-		config := Config{
-			Events:           ldcomponents.NoEvents(),
-			Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
-			ServiceEndpoints: interfaces.ServiceEndpoints{Streaming: streamServer.URL},
-		}
+	logCapture := ldlogtest.NewMockLog()
 
-		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
-		require.Error(t, err)
-		require.NotNil(t, client)
-		defer client.Close()
+	config := Config{
+		Events:           ldcomponents.NoEvents(),
+		Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
 
-		assert.Equal(t, initializationFailedErrorMessage, err.Error())
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.Error(t, err)
+	require.NotNil(t, client)
+	defer client.Close()
 
-		assert.Equal(t, string(interfaces.DataSourceStateOff), string(client.GetDataSourceStatusProvider().GetStatus().State))
+	assert.Equal(t, initializationFailedErrorMessage, err.Error())
 
-		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
-		assert.False(t, value)
+	assert.Equal(t, string(interfaces.DataSourceStateOff), string(client.GetDataSourceStatusProvider().GetStatus().State))
 
-		r := <-requestsCh
-		assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
-		assertNoMoreRequests(t, requestsCh)
+	value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
+	assert.False(t, value)
 
-		expectedError := "Error in stream connection (giving up permanently): HTTP error 401 (invalid SDK key)"
-		assert.Equal(t, []string{expectedError}, logCapture.GetOutput(ldlog.Error))
-		assert.Equal(t, []string{initializationFailedErrorMessage}, logCapture.GetOutput(ldlog.Warn))
-	})
+	r := <-requestsCh
+	assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
+	assertNoMoreRequests(t, requestsCh)
+
+	expectedError := "Error in stream connection (giving up permanently): HTTP error 401 (invalid SDK key)"
+	assert.Equal(t, []string{expectedError}, logCapture.GetOutput(ldlog.Error))
+	assert.Equal(t, []string{initializationFailedErrorMessage}, logCapture.GetOutput(ldlog.Warn))
 }
 
 func TestClientRetriesConnectionInStreamingModeWithNonFatalError(t *testing.T) {
@@ -198,6 +272,40 @@ func TestClientStartsInPollingMode(t *testing.T) {
 	})
 }
 
+func TestClientInOnDemandPollingModeRefreshesOnEvaluation(t *testing.T) {
+	data := ldservices.NewServerSDKData().Flags(&alwaysTrueFlag)
+	pollHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSidePollingServiceHandler(data))
+	httphelpers.WithServer(pollHandler, func(pollServer *httptest.Server) {
+		maxAge := 50 * time.Millisecond
+		config := Config{
+			DataSource:       ldcomponents.PollingDataSource().OnDemand(maxAge),
+			Events:           ldcomponents.NoEvents(),
+			Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+			ServiceEndpoints: interfaces.ServiceEndpoints{Polling: pollServer.URL},
+		}
+
+		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+		require.NoError(t, err)
+		defer client.Close()
+
+		<-requestsCh
+		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
+		assert.True(t, value)
+		assertNoMoreRequests(t, requestsCh)
+
+		time.Sleep(maxAge * 2)
+		assertNoMoreRequests(t, requestsCh)
+
+		value, _ = client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
+		assert.True(t, value)
+		select {
+		case <-requestsCh:
+		case <-time.After(time.Second):
+			assert.Fail(t, "timed out waiting for on-demand poll request")
+		}
+	})
+}
+
 func TestClientFailsToStartInPollingModeWith401Error(t *testing.T) {
 	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(401))
 	httphelpers.WithServer(handler, func(pollServer *httptest.Server) {
@@ -233,16 +341,43 @@ func TestClientFailsToStartInPollingModeWith401Error(t *testing.T) {
 }
 
 func TestClientSendsEventWithoutDiagnostics(t *testing.T) {
+	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+	defer server.Close()
+
+	logCapture := ldlogtest.NewMockLog()
+
+	config := Config{
+		DiagnosticOptOut: true,
+		Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
+		ServiceEndpoints: server.ServiceEndpoints(),
+	}
+
+	client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.Identify(testUser)
+	client.Flush()
+
+	r := <-server.EventRequests()
+	assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
+	assert.Equal(t, "/bulk", r.Request.URL.Path)
+	assertNoMoreRequests(t, server.EventRequests())
+
+	var jsonValue ldvalue.Value
+	err = json.Unmarshal(r.Body, &jsonValue)
+	assert.NoError(t, err)
+	assert.Equal(t, ldvalue.String("identify"), jsonValue.GetByIndex(0).GetByKey("kind"))
+}
+
+func TestClientSendsDiagnostics(t *testing.T) {
 	eventsHandler, eventRequestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
 	httphelpers.WithServer(eventsHandler, func(eventsServer *httptest.Server) {
 		data := ldservices.NewServerSDKData().Flags(&alwaysTrueFlag)
 		streamHandler, _ := ldservices.ServerSideStreamingServiceHandler(data.ToPutEvent())
 		httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
-			logCapture := ldlogtest.NewMockLog()
-
 			config := Config{
-				DiagnosticOptOut: true,
-				Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
+				Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
 				ServiceEndpoints: interfaces.ServiceEndpoints{Streaming: streamServer.URL, Events: eventsServer.URL},
 			}
 
@@ -250,23 +385,18 @@ func TestClientSendsEventWithoutDiagnostics(t *testing.T) {
 			require.NoError(t, err)
 			defer client.Close()
 
-			client.Identify(testUser)
-			client.Flush()
-
 			r := <-eventRequestsCh
 			assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
-			assert.Equal(t, "/bulk", r.Request.URL.Path)
-			assertNoMoreRequests(t, eventRequestsCh)
-
+			assert.Equal(t, "/diagnostic", r.Request.URL.Path)
 			var jsonValue ldvalue.Value
 			err = json.Unmarshal(r.Body, &jsonValue)
 			assert.NoError(t, err)
-			assert.Equal(t, ldvalue.String("identify"), jsonValue.GetByIndex(0).GetByKey("kind"))
+			assert.Equal(t, ldvalue.String("diagnostic-init"), jsonValue.GetByKey("kind"))
 		})
 	})
 }
 
-func TestClientSendsDiagnostics(t *testing.T) {
+func TestClientDiagnosticReport(t *testing.T) {
 	eventsHandler, eventRequestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
 	httphelpers.WithServer(eventsHandler, func(eventsServer *httptest.Server) {
 		data := ldservices.NewServerSDKData().Flags(&alwaysTrueFlag)
@@ -282,16 +412,34 @@ func TestClientSendsDiagnostics(t *testing.T) {
 			defer client.Close()
 
 			r := <-eventRequestsCh
-			assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
-			assert.Equal(t, "/diagnostic", r.Request.URL.Path)
-			var jsonValue ldvalue.Value
-			err = json.Unmarshal(r.Body, &jsonValue)
-			assert.NoError(t, err)
-			assert.Equal(t, ldvalue.String("diagnostic-init"), jsonValue.GetByKey("kind"))
+			var initEvent ldvalue.Value
+			require.NoError(t, json.Unmarshal(r.Body, &initEvent))
+
+			report := client.GetDiagnosticReport()
+			assert.Equal(t, "diagnostic", report.GetByKey("kind").StringValue())
+			assert.Equal(t, initEvent.GetByKey("id"), report.GetByKey("id"))
+			assert.Equal(t, initEvent.GetByKey("creationDate"), report.GetByKey("dataSinceDate"))
+			streamInits := report.GetByKey("streamInits")
+			require.Equal(t, 1, streamInits.Count())
+			assert.False(t, streamInits.GetByIndex(0).GetByKey("failed").BoolValue())
+			assert.NotContains(t, report.JSONString(), testSdkKey)
 		})
 	})
 }
 
+func TestClientDiagnosticReportIsNullIfDiagnosticsAreDisabled(t *testing.T) {
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.Events = ldcomponents.SendEvents()
+		c.DiagnosticOptOut = true
+	})
+	defer client.Close()
+	assert.Equal(t, ldvalue.Null(), client.GetDiagnosticReport())
+
+	client2 := makeTestClient()
+	defer client2.Close()
+	assert.Equal(t, ldvalue.Null(), client2.GetDiagnosticReport())
+}
+
 func TestClientUsesCustomTLSConfiguration(t *testing.T) {
 	data := ldservices.NewServerSDKData().Flags(&alwaysTrueFlag)
 	streamHandler, _ := ldservices.ServerSideStreamingServiceHandler(data.ToPutEvent())
@@ -344,3 +492,89 @@ func TestClientStartupTimesOut(t *testing.T) {
 		assert.Len(t, logCapture.GetOutput(ldlog.Error), 0)
 	})
 }
+
+func TestClientStartsFromPersistentStoreWhileStreamIsConnecting(t *testing.T) {
+	persistentStore := mocks.NewMockPersistentDataStore()
+	_ = persistentStore.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: alwaysTrueFlag.Key, Item: ldstoretypes.SerializedItemDescriptor{
+				Version:        alwaysTrueFlag.Version,
+				SerializedItem: datakinds.Features.Serialize(sharedtest.FlagDescriptor(alwaysTrueFlag)),
+			}},
+		}},
+	})
+	streamHandler, _ := httphelpers.SSEHandler(nil) // never sends a put event
+
+	httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
+		logCapture := ldlogtest.NewMockLog()
+		defer logCapture.DumpIfTestFailed(t)
+
+		config := Config{
+			DataSource: ldcomponents.StreamingDataSource().UseStoreDataWhileConnecting(true),
+			DataStore: ldcomponents.PersistentDataStore(
+				mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: persistentStore},
+			),
+			Events:           ldcomponents.NoEvents(),
+			Logging:          ldcomponents.Logging().Loggers(logCapture.Loggers),
+			ServiceEndpoints: interfaces.ServiceEndpoints{Streaming: streamServer.URL},
+		}
+
+		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.True(t, client.Initialized())
+		assert.Equal(t, string(interfaces.DataSourceStateInitializedFromStore),
+			string(client.GetDataSourceStatusProvider().GetStatus().State))
+
+		value, _ := client.BoolVariation(alwaysTrueFlag.Key, testUser, false)
+		assert.True(t, value)
+	})
+}
+
+func TestDebugLoggingOfEventsOmitsContextAttributes(t *testing.T) {
+	context := ldcontext.NewBuilder("context-key").
+		SetString("public", "public-value").
+		SetString("secret", "secret-value").Private("secret").
+		Build()
+
+	getLogOutput := func(t *testing.T, logging *ldcomponents.LoggingConfigurationBuilder) string {
+		logCapture := ldlogtest.NewMockLog()
+		server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+		defer server.Close()
+		config := Config{
+			DiagnosticOptOut: true,
+			Logging:          logging.Loggers(logCapture.Loggers).MinLevel(ldlog.Debug),
+			ServiceEndpoints: server.ServiceEndpoints(),
+		}
+		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+		require.NoError(t, err)
+
+		_, _ = client.BoolVariation(alwaysTrueFlag.Key, context, false)
+		client.Flush()
+		r := <-server.EventRequests()
+		require.Contains(t, string(r.Body), "public-value") // the payload is the same either way
+		require.NotContains(t, string(r.Body), "secret-value")
+		require.NoError(t, client.Close())
+
+		var lines []string
+		for _, item := range logCapture.GetAllOutput() {
+			lines = append(lines, item.Message)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	t.Run("by default", func(t *testing.T) {
+		output := getLogOutput(t, ldcomponents.Logging())
+		assert.Contains(t, output, "Sending analytics payload: 2 event(s)") // an index event and a summary event
+		assert.NotContains(t, output, "public-value")
+		assert.NotContains(t, output, "secret-value")
+	})
+
+	t.Run("with LogContextAttributes", func(t *testing.T) {
+		output := getLogOutput(t, ldcomponents.Logging().LogContextAttributes(true))
+		assert.NotContains(t, output, "Sending analytics payload:")
+		assert.Contains(t, output, "public-value")
+		assert.NotContains(t, output, "secret-value")
+	})
+}
@@ -0,0 +1,98 @@
+package ldclient
+
+import (
+	gocontext "context"
+	"errors"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+)
+
+// EvaluateAll evaluates all feature flags for an evaluation context, and keeps evaluating them again
+// whenever they change, sending the results to a channel. This is meant for pushing flag values to
+// another process, such as a browser connected with server-sent events.
+//
+// It first sends a [interfaces.FlagEvaluationUpdate] for every flag, using the same data as
+// [LDClient.AllFlagsState]. Then, whenever the SDK receives a change to a flag (or to a prerequisite flag
+// or segment that it depends on), it evaluates that flag again, and sends an update if the value or the
+// reason has changed. If a flag is deleted, the update has a null value and a FLAG_NOT_FOUND error reason.
+//
+// EvaluateAll blocks until ctx is cancelled, in which case it returns ctx.Err(), or until the client is
+// closed, in which case it returns nil. It returns an error right away if the flags cannot be evaluated,
+// for the same reasons that AllFlagsState would return an invalid state. It never closes ch.
+//
+// Like AllFlagsState, this method does not generate analytics events.
+func (client *LDClient) EvaluateAll(
+	ctx gocontext.Context,
+	context ldcontext.Context,
+	ch chan<- interfaces.FlagEvaluationUpdate,
+) error {
+	// Subscribe before getting the initial state, so that no changes can be missed in between.
+	flagCh := client.flagTracker.AddFlagChangeListener()
+	defer client.flagTracker.RemoveFlagChangeListener(flagCh)
+
+	state := client.AllFlagsState(context, flagstate.OptionWithReasons())
+	if !state.IsValid() {
+		return errors.New("unable to evaluate flags; see log for details")
+	}
+	lastSent := make(map[string]interfaces.FlagEvaluationUpdate)
+	send := func(update interfaces.FlagEvaluationUpdate) bool {
+		select {
+		case ch <- update:
+			lastSent[update.Key] = update
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for key := range state.ToValuesMap() {
+		flag, _ := state.GetFlag(key)
+		if !send(interfaces.FlagEvaluationUpdate{Key: key, Value: flag.Value, Reason: flag.Reason}) {
+			return ctx.Err()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-flagCh:
+			if !ok {
+				return nil // the client has been closed
+			}
+			update := client.evaluateForUpdate(event.Key, context)
+			if previous, found := lastSent[event.Key]; found &&
+				previous.Value.Equal(update.Value) && previous.Reason == update.Reason {
+				continue
+			}
+			if !send(update) {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (client *LDClient) evaluateForUpdate(key string, context ldcontext.Context) interfaces.FlagEvaluationUpdate {
+	update := interfaces.FlagEvaluationUpdate{
+		Key:    key,
+		Value:  ldvalue.Null(),
+		Reason: ldreason.NewEvalReasonError(ldreason.EvalErrorFlagNotFound),
+	}
+	item, err := client.store.Get(datakinds.Features, key)
+	if err != nil {
+		client.loggers.Warnf("Unable to get flag %q from data store: %s", key, err)
+		update.Reason = ldreason.NewEvalReasonError(ldreason.EvalErrorException)
+		return update
+	}
+	if flag, ok := item.Item.(*ldmodel.FeatureFlag); ok {
+		result := client.evaluator.Evaluate(flag, context, nil)
+		update.Value = result.Detail.Value
+		update.Reason = result.Detail.Reason
+	}
+	return update
+}
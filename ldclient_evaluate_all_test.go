@@ -0,0 +1,122 @@
+package ldclient
+
+import (
+	gocontext "context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeEvaluateAllTestClient(t *testing.T, td *ldtestdata.TestDataSource) *LDClient {
+	config := Config{
+		DataSource: td,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	client, err := MakeCustomClient("", config, time.Second)
+	require.NoError(t, err)
+	return client
+}
+
+type evaluateAllResult struct {
+	updates <-chan interfaces.FlagEvaluationUpdate
+	done    <-chan error
+	cancel  func()
+}
+
+func startEvaluateAll(client *LDClient, context ldcontext.Context) evaluateAllResult {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	updates := make(chan interfaces.FlagEvaluationUpdate, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.EvaluateAll(ctx, context, updates)
+	}()
+	return evaluateAllResult{updates: updates, done: done, cancel: cancel}
+}
+
+func TestEvaluateAllSendsInitialValuesAndChanges(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flag1").On(true))
+	td.Update(td.Flag("flag2").Variations(ldvalue.String("a"), ldvalue.String("b")).VariationForAllIndex(0))
+	client := makeEvaluateAllTestClient(t, td)
+	defer client.Close()
+
+	r := startEvaluateAll(client, evalTestUser)
+	defer r.cancel()
+	fallthroughReason := ldreason.NewEvalReasonFallthrough()
+
+	initial := []interfaces.FlagEvaluationUpdate{
+		th.RequireValue(t, r.updates, time.Second),
+		th.RequireValue(t, r.updates, time.Second),
+	}
+	sort.Slice(initial, func(i, j int) bool { return initial[i].Key < initial[j].Key })
+	assert.Equal(t, []interfaces.FlagEvaluationUpdate{
+		{Key: "flag1", Value: ldvalue.Bool(true), Reason: fallthroughReason},
+		{Key: "flag2", Value: ldvalue.String("a"), Reason: fallthroughReason},
+	}, initial)
+	th.AssertNoMoreValues(t, r.updates, time.Millisecond*50)
+
+	td.Update(td.Flag("flag2").Variations(ldvalue.String("a"), ldvalue.String("b")).VariationForAllIndex(1))
+	assert.Equal(t,
+		interfaces.FlagEvaluationUpdate{Key: "flag2", Value: ldvalue.String("b"), Reason: fallthroughReason},
+		th.RequireValue(t, r.updates, time.Second))
+
+	td.Update(td.Flag("flag3").On(false))
+	assert.Equal(t,
+		interfaces.FlagEvaluationUpdate{Key: "flag3", Value: ldvalue.Bool(false), Reason: ldreason.NewEvalReasonOff()},
+		th.RequireValue(t, r.updates, time.Second))
+
+	r.cancel()
+	assert.Equal(t, gocontext.Canceled, th.RequireValue(t, r.done, time.Second))
+}
+
+func TestEvaluateAllDoesNotSendUpdateIfResultIsUnchanged(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flag1").On(true))
+	client := makeEvaluateAllTestClient(t, td)
+	defer client.Close()
+
+	r := startEvaluateAll(client, evalTestUser)
+	defer r.cancel()
+	th.RequireValue(t, r.updates, time.Second)
+
+	// this changes the flag version, but the result for this context is the same
+	td.Update(td.Flag("flag1").On(true).VariationForUser("other-user", false))
+	th.AssertNoMoreValues(t, r.updates, time.Millisecond*50)
+}
+
+func TestEvaluateAllReturnsWhenClientIsClosed(t *testing.T) {
+	td := ldtestdata.DataSource()
+	client := makeEvaluateAllTestClient(t, td)
+
+	r := startEvaluateAll(client, evalTestUser)
+	defer r.cancel()
+
+	// give EvaluateAll time to subscribe before closing the client
+	time.Sleep(time.Millisecond * 50)
+	require.NoError(t, client.Close())
+	assert.Nil(t, th.RequireValue(t, r.done, time.Second))
+}
+
+func TestEvaluateAllReturnsErrorInOfflineMode(t *testing.T) {
+	client, err := MakeCustomClient("", Config{Offline: true}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	updates := make(chan interfaces.FlagEvaluationUpdate, 10)
+	assert.Error(t, client.EvaluateAll(gocontext.Background(), evalTestUser, updates))
+	assert.Len(t, updates, 0)
+}
@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
@@ -98,6 +101,12 @@ func (f benchmarkStubEventProcessorFactory) Build(context subsystems.ClientConte
 	return ldcomponents.NoEvents().Build(context)
 }
 
+type benchmarkStubEvaluationMetricsSink struct{}
+
+func (benchmarkStubEvaluationMetricsSink) IncEvaluationCount(string)                       {}
+func (benchmarkStubEvaluationMetricsSink) IncErrorCount(string, ldreason.EvalErrorKind)    {}
+func (benchmarkStubEvaluationMetricsSink) ObserveEvaluationDuration(string, time.Duration) {}
+
 func makeEvalBenchmarkUser(bc evalBenchmarkCase) ldcontext.Context {
 	if bc.shouldMatch {
 		builder := lduser.NewUserBuilder("user-match")
@@ -315,10 +324,11 @@ var (
 	// Always record the result of an operation to prevent the compiler eliminating the function call.
 	//
 	// Always store the result to a package level variable so the compiler cannot eliminate the benchmark itself.
-	boolResult   bool
-	intResult    int
-	stringResult string
-	jsonResult   ldvalue.Value
+	boolResult     bool
+	intResult      int
+	stringResult   string
+	jsonResult     ldvalue.Value
+	allFlagsResult flagstate.AllFlags
 )
 
 func benchmarkEval(
@@ -377,6 +387,20 @@ func BenchmarkBoolVariationWithEvents(b *testing.B) {
 	})
 }
 
+// The ___WithMetrics version of the benchmark enables evaluation metrics with a sink that does nothing,
+// so we can measure the overhead of timing the evaluation and looking up the flag key label. Without
+// metrics, the other benchmarks measure the cost of the check that skips this step.
+func BenchmarkBoolVariationWithMetricsNoAlloc(b *testing.B) {
+	metrics := newEvaluationMetrics(subsystems.EvaluationMetricsConfiguration{
+		Sink:         benchmarkStubEvaluationMetricsSink{},
+		FlagKeyLimit: ldcomponents.DefaultEvaluationMetricsFlagKeyLimit,
+	})
+	benchmarkEval(b, false, makeBoolVariation, ruleEvalBenchmarkCases, func(env *evalBenchmarkEnv) {
+		env.client.evaluationMetrics = metrics
+		boolResult, _ = env.client.BoolVariation(env.targetFeatureKey, env.evalUser, false)
+	})
+}
+
 func BenchmarkIntVariationNoAlloc(b *testing.B) {
 	benchmarkEval(b, false, makeIntVariation, ruleEvalBenchmarkCases, func(env *evalBenchmarkEnv) {
 		intResult, _ = env.client.IntVariation(env.targetFeatureKey, env.evalUser, 0)
@@ -426,6 +450,49 @@ func BenchmarkUserMatchesRuleNoAlloc(b *testing.B) {
 		})
 }
 
+// These cases are used to compare AllFlagsState with FlagsSnapshot.AllFlagsState, for a project with
+// 1000 flags and with or without prerequisites.
+var allFlagsBenchmarkCases = []evalBenchmarkCase{
+	{
+		numFlags:      1000,
+		numVariations: 2,
+		numTargets:    1,
+	},
+	{
+		numFlags:      1000,
+		numVariations: 2,
+		numRules:      1,
+		numClauses:    1,
+		prereqsWidth:  5,
+		prereqsDepth:  1,
+	},
+}
+
+func BenchmarkAllFlagsState(b *testing.B) {
+	benchmarkEval(b, false, makeBoolVariation, allFlagsBenchmarkCases, func(env *evalBenchmarkEnv) {
+		allFlagsResult = env.client.AllFlagsState(env.evalUser)
+	})
+}
+
+func BenchmarkFlagsSnapshotAllFlagsState(b *testing.B) {
+	env := newEvalBenchmarkEnv()
+	for _, bc := range allFlagsBenchmarkCases {
+		variations := []ldvalue.Value{makeBoolVariation(0), makeBoolVariation(1)}
+		env.setUp(false, bc, variations)
+		snapshot, err := env.client.NewFlagsSnapshot()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("%+v", bc), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				allFlagsResult = snapshot.AllFlagsState(env.evalUser)
+			}
+		})
+		env.tearDown()
+	}
+}
+
 // Input data creation
 
 // Except for when we're running BenchmarkUserMatchesRule, the flag rules and clauses we create here are
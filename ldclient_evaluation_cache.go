@@ -0,0 +1,240 @@
+package ldclient
+
+import (
+	"container/list"
+	"hash"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"golang.org/x/exp/slices"
+)
+
+// evaluationCache keeps recent evaluation results, as configured by ldcomponents.EvaluationCache(). A nil
+// *evaluationCache means that caching is disabled.
+//
+// Results are invalidated by flag key rather than removed: each flag has a generation number that is
+// incremented by the data source update sink, before an update that may affect the flag returns, and a
+// result only counts if it was computed in the current generation. An evaluation that was in progress when
+// the flag changed therefore cannot put an outdated result into the cache. A full data set invalidates
+// every flag, by incrementing allGeneration. Entries that are no longer valid are left for the LRU eviction
+// to remove.
+type evaluationCache struct {
+	capacity      int
+	ttl           time.Duration
+	entries       map[evaluationCacheKey]*list.Element
+	lru           *list.List // of *evaluationCacheEntry, most recently used first
+	generations   map[string]uint64
+	allGeneration uint64
+	lock          sync.Mutex
+}
+
+// evaluationCacheKey identifies a result by the flag key, the context's fully qualified key, and a hash of
+// the context's other attributes, so that contexts with the same key but different attributes have
+// separate results without the cost of serializing the context for every evaluation.
+type evaluationCacheKey struct {
+	flagKey    string
+	context    string
+	attributes uint64
+}
+
+type evaluationCacheEntry struct {
+	key                evaluationCacheKey
+	generation         uint64
+	created            time.Time
+	result             ldeval.Result
+	flag               *ldmodel.FeatureFlag
+	prerequisiteEvents []ldeval.PrerequisiteFlagEvent
+	refreshing         bool
+}
+
+// evaluationCacheRecorder collects what an evaluation produced so that it can be added to the cache. It
+// records the flag's generation before the evaluation starts.
+type evaluationCacheRecorder struct {
+	cache              *evaluationCache
+	key                evaluationCacheKey
+	generation         uint64
+	prerequisiteEvents []ldeval.PrerequisiteFlagEvent
+}
+
+func newEvaluationCache(config subsystems.EvaluationCacheConfiguration) *evaluationCache {
+	if config.Capacity <= 0 {
+		return nil
+	}
+	return &evaluationCache{
+		capacity:    config.Capacity,
+		ttl:         config.TTL,
+		entries:     make(map[evaluationCacheKey]*list.Element),
+		lru:         list.New(),
+		generations: make(map[string]uint64),
+	}
+}
+
+func makeEvaluationCacheKey(flagKey string, context ldcontext.Context) evaluationCacheKey {
+	h := fnv.New64a()
+	if context.Multiple() {
+		for i := 0; i < context.IndividualContextCount(); i++ {
+			hashContextAttributes(h, context.IndividualContextByIndex(i))
+		}
+	} else {
+		hashContextAttributes(h, context)
+	}
+	return evaluationCacheKey{flagKey: flagKey, context: context.FullyQualifiedKey(), attributes: h.Sum64()}
+}
+
+// hashContextAttributes adds the attributes of a single context that can affect an evaluation to h. The
+// kind and key are already in the fully qualified key.
+func hashContextAttributes(h hash.Hash64, context ldcontext.Context) {
+	var namesArray [16]string
+	names := context.GetOptionalAttributeNames(namesArray[:0])
+	slices.Sort(names) // the attributes are kept in a map, so the order would otherwise vary
+	var buf []byte
+	if context.Anonymous() {
+		buf = append(buf, 1)
+	}
+	for _, name := range names {
+		buf = append(buf, 0)
+		buf = append(buf, name...)
+		buf = append(buf, 0)
+		value := context.GetValue(name)
+		switch value.Type() {
+		case ldvalue.StringType:
+			buf = append(buf, 's')
+			buf = append(buf, value.StringValue()...)
+		case ldvalue.NumberType:
+			buf = strconv.AppendFloat(append(buf, 'n'), value.Float64Value(), 'g', -1, 64)
+		case ldvalue.BoolType:
+			buf = strconv.AppendBool(append(buf, 'b'), value.BoolValue())
+		default:
+			buf = append(buf, value.JSONString()...)
+		}
+	}
+	buf = append(buf, 0xff) // separates the attributes of the contexts in a multi-context
+	_, _ = h.Write(buf)
+}
+
+// get returns a valid cached result, if any, and whether the caller should refresh it. Only one refresh
+// is requested at a time for each entry.
+func (c *evaluationCache) get(key evaluationCacheKey) (*evaluationCacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*evaluationCacheEntry)
+	if entry.generation != c.generation(key.flagKey) || time.Since(entry.created) > c.ttl {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	if entry.refreshing {
+		return entry, false
+	}
+	entry.refreshing = true
+	return entry, true
+}
+
+func (c *evaluationCache) newRecorder(key evaluationCacheKey) *evaluationCacheRecorder {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return &evaluationCacheRecorder{cache: c, key: key, generation: c.generation(key.flagKey)}
+}
+
+// generation returns the current generation of a flag. Both counters only ever increase, so their sum
+// changes whenever either of them does. The caller must hold the lock.
+func (c *evaluationCache) generation(flagKey string) uint64 {
+	return c.allGeneration + c.generations[flagKey]
+}
+
+// invalidate discards all results for a flag.
+func (c *evaluationCache) invalidate(flagKey string) {
+	c.lock.Lock()
+	c.generations[flagKey]++
+	c.lock.Unlock()
+}
+
+// invalidateAll discards all results.
+func (c *evaluationCache) invalidateAll() {
+	c.lock.Lock()
+	c.allGeneration++
+	c.lock.Unlock()
+}
+
+// refreshFailed allows another refresh of an entry to be requested after one did not produce a result.
+func (c *evaluationCache) refreshFailed(entry *evaluationCacheEntry) {
+	c.lock.Lock()
+	entry.refreshing = false
+	c.lock.Unlock()
+}
+
+func (c *evaluationCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*evaluationCacheEntry).key)
+}
+
+// wrap returns a prerequisite event recorder that also keeps the events for the cache, and passes them on
+// to recorder if it is not nil.
+func (r *evaluationCacheRecorder) wrap(
+	recorder ldeval.PrerequisiteFlagEventRecorder,
+) ldeval.PrerequisiteFlagEventRecorder {
+	return func(event ldeval.PrerequisiteFlagEvent) {
+		r.prerequisiteEvents = append(r.prerequisiteEvents, event)
+		if recorder != nil {
+			recorder(event)
+		}
+	}
+}
+
+// store adds the result to the cache, unless the flag has changed since the recorder was created.
+func (r *evaluationCacheRecorder) store(result ldeval.Result, flag *ldmodel.FeatureFlag) {
+	c := r.cache
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if r.generation != c.generation(r.key.flagKey) {
+		return
+	}
+	entry := &evaluationCacheEntry{
+		key:                r.key,
+		generation:         r.generation,
+		created:            time.Now(),
+		result:             result,
+		flag:               flag,
+		prerequisiteEvents: r.prerequisiteEvents,
+	}
+	if elem, ok := c.entries[r.key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[r.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// refreshCachedEvaluation evaluates a flag again in the background after its cached result was used. No
+// analytics events are generated for this evaluation; its prerequisite events are kept with the new result,
+// to be sent whenever that result is used.
+func (client *LDClient) refreshCachedEvaluation(entry *evaluationCacheEntry, context ldcontext.Context) {
+	go func() {
+		recorder := client.evaluationCache.newRecorder(entry.key)
+		itemDesc, err := client.store.Get(datakinds.Features, entry.key.flagKey)
+		flag, ok := itemDesc.Item.(*ldmodel.FeatureFlag)
+		if err != nil || !ok {
+			// If the flag was deleted, the data source update sink has already invalidated the entry.
+			client.evaluationCache.refreshFailed(entry)
+			return
+		}
+		result := client.evaluator.Evaluate(flag, context, recorder.wrap(nil))
+		recorder.store(result, flag)
+	}()
+}
@@ -0,0 +1,344 @@
+package ldclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withEvaluationCacheTestParams(cacheConfig *ldcomponents.EvaluationCacheConfigurationBuilder,
+	callback func(clientEvalTestParams)) {
+	p := clientEvalTestParams{}
+	p.store = datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	p.data = ldtestdata.DataSource()
+	p.events = &mocks.CapturingEventProcessor{}
+	config := Config{
+		DataStore:       mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: p.store},
+		DataSource:      p.data,
+		Events:          mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: p.events},
+		EvaluationCache: cacheConfig,
+		Logging:         ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}
+	p.client, _ = MakeCustomClient("sdk_key", config, 0)
+	defer p.client.Close()
+	callback(p)
+}
+
+// upsertFlagWithoutChangeEvent changes a flag in the store without going through the data source, so the
+// evaluation cache is not told about the change.
+func upsertFlagWithoutChangeEvent(t *testing.T, p clientEvalTestParams, key string, value ldvalue.Value) {
+	flag := ldbuilders.NewFlagBuilder(key).Version(100).SingleVariation(value).Build()
+	_, err := p.store.Upsert(datakinds.Features, key, sharedtest.FlagDescriptor(flag))
+	require.NoError(t, err)
+}
+
+func TestEvaluationCacheIsDisabledByDefault(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		assert.Nil(t, p.client.evaluationCache)
+
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "b", value)
+	})
+}
+
+func TestEvaluationCacheReturnsCachedResultAndRefreshesIt(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "a", value)
+
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		value, _ = p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "a", value)
+
+		assert.Eventually(t, func() bool {
+			value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+			return value == "b"
+		}, time.Second, time.Millisecond*10)
+	})
+}
+
+func TestEvaluationCacheDiscardsResultsWhenFlagChanges(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "a", value)
+
+		p.setupSingleValueFlag("flag", ldvalue.String("b")) // the result is invalidated before this returns
+		value, _ = p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "b", value)
+	})
+}
+
+func TestEvaluationCacheDiscardsResultsWhenPrerequisiteChanges(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.data.Update(p.data.Flag("prereq").VariationForAll(true))
+		flag := ldbuilders.NewFlagBuilder("flag").Version(10).On(true).
+			Variations(ldvalue.String("off"), ldvalue.String("on")).OffVariation(0).FallthroughVariation(1).
+			AddPrerequisite("prereq", 0).Build()
+		p.data.UsePreconfiguredFlag(flag)
+		value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "on", value)
+
+		p.data.Update(p.data.Flag("prereq").VariationForAll(false))
+		value, _ = p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "off", value)
+	})
+}
+
+func TestEvaluationCacheDiscardsAllResultsOnFullUpdate(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		value, _ := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "a", value)
+
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		p.client.dataSourceUpdates.Init(sharedtest.NewDataSetBuilder().Flags(
+			ldbuilders.NewFlagBuilder("flag").Version(200).SingleVariation(ldvalue.String("c")).Build()).Build())
+		value, _ = p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Equal(t, "c", value)
+	})
+}
+
+func TestEvaluationCacheKeepsResultsForEachContext(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		context1 := ldcontext.New("user1")
+		context2 := ldcontext.NewBuilder("user1").Name("different").Build()
+		value, _ := p.client.StringVariation("flag", context1, "x")
+		assert.Equal(t, "a", value)
+
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		value, _ = p.client.StringVariation("flag", context2, "x")
+		assert.Equal(t, "b", value)
+	})
+}
+
+func TestEvaluationCacheDoesNotCacheUnknownFlag(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		value, err := p.client.StringVariation("flag", evalTestUser, "x")
+		assert.Error(t, err)
+		assert.Equal(t, "x", value)
+
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		value, err = p.client.StringVariation("flag", evalTestUser, "x")
+		assert.NoError(t, err)
+		assert.Equal(t, "b", value)
+	})
+}
+
+func TestEvaluationCacheSendsEventsForCachedResult(t *testing.T) {
+	flag0 := ldbuilders.NewFlagBuilder("flag0").
+		On(true).
+		FallthroughVariation(1).
+		Variations(ldvalue.String("a"), ldvalue.String("b")).
+		AddPrerequisite("flag1", 1).
+		Build()
+	flag1 := ldbuilders.NewFlagBuilder("flag1").
+		On(true).
+		FallthroughVariation(1).
+		Variations(ldvalue.String("c"), ldvalue.String("d")).
+		Build()
+
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag0)
+		p.data.UsePreconfiguredFlag(flag1)
+
+		_, _ = p.client.StringVariation(flag0.Key, evalTestUser, "x")
+		require.Len(t, p.events.Events, 2)
+		firstEvents := p.events.Events
+		p.events.Events = nil
+
+		value, _ := p.client.StringVariation(flag0.Key, evalTestUser, "x")
+		assert.Equal(t, "b", value)
+		require.Len(t, p.events.Events, 2)
+		for i, e := range p.events.Events {
+			expected := firstEvents[i].(ldevents.EvaluationData)
+			actual := e.(ldevents.EvaluationData)
+			expected.CreationDate = actual.CreationDate
+			assert.Equal(t, expected, actual)
+		}
+	})
+}
+
+func TestEvaluationCacheIsNotUsedByEvaluateWithTrace(t *testing.T) {
+	withEvaluationCacheTestParams(ldcomponents.EvaluationCache(), func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("flag", ldvalue.String("a"))
+		_, _ = p.client.StringVariation("flag", evalTestUser, "x")
+
+		upsertFlagWithoutChangeEvent(t, p, "flag", ldvalue.String("b"))
+		detail, _ := p.client.EvaluateWithTrace("flag", evalTestUser)
+		assert.Equal(t, "b", detail.Value.StringValue())
+	})
+}
+
+func TestEvaluationCache(t *testing.T) {
+	key := func(flagKey string) evaluationCacheKey {
+		return makeEvaluationCacheKey(flagKey, evalTestUser)
+	}
+	store := func(c *evaluationCache, flagKey string, value string) {
+		c.newRecorder(key(flagKey)).store(ldeval.Result{Detail: ldreason.NewEvaluationDetail(
+			ldvalue.String(value), 0, ldreason.NewEvalReasonFallthrough())}, nil)
+	}
+
+	t.Run("capacity of zero disables cache", func(t *testing.T) {
+		assert.Nil(t, newEvaluationCache(subsystems.EvaluationCacheConfiguration{}))
+	})
+
+	t.Run("refresh is requested once until result is replaced", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Minute})
+		entry, refresh := c.get(key("flag"))
+		assert.Nil(t, entry)
+		assert.False(t, refresh)
+
+		store(c, "flag", "a")
+		entry, refresh = c.get(key("flag"))
+		require.NotNil(t, entry)
+		assert.Equal(t, "a", entry.result.Detail.Value.StringValue())
+		assert.True(t, refresh)
+		_, refresh = c.get(key("flag"))
+		assert.False(t, refresh)
+
+		store(c, "flag", "b")
+		entry, refresh = c.get(key("flag"))
+		require.NotNil(t, entry)
+		assert.Equal(t, "b", entry.result.Detail.Value.StringValue())
+		assert.True(t, refresh)
+
+		c.refreshFailed(entry)
+		_, refresh = c.get(key("flag"))
+		assert.True(t, refresh)
+	})
+
+	t.Run("invalidate discards results for flag", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Minute})
+		store(c, "flag1", "a")
+		store(c, "flag2", "b")
+		c.invalidate("flag1")
+		entry, _ := c.get(key("flag1"))
+		assert.Nil(t, entry)
+		entry, _ = c.get(key("flag2"))
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("invalidateAll discards all results", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Minute})
+		store(c, "flag1", "a")
+		c.invalidate("flag2")
+		store(c, "flag2", "b")
+		c.invalidateAll()
+		entry, _ := c.get(key("flag1"))
+		assert.Nil(t, entry)
+		entry, _ = c.get(key("flag2"))
+		assert.Nil(t, entry)
+
+		store(c, "flag1", "c")
+		entry, _ = c.get(key("flag1"))
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("result is not stored if all flags changed during evaluation", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Minute})
+		recorder := c.newRecorder(key("flag"))
+		c.invalidateAll()
+		recorder.store(ldeval.Result{}, nil)
+		entry, _ := c.get(key("flag"))
+		assert.Nil(t, entry)
+	})
+
+	t.Run("result is not stored if flag changed during evaluation", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Minute})
+		recorder := c.newRecorder(key("flag"))
+		c.invalidate("flag")
+		recorder.store(ldeval.Result{}, nil)
+		entry, _ := c.get(key("flag"))
+		assert.Nil(t, entry)
+	})
+
+	t.Run("least recently used result is evicted", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 2, TTL: time.Minute})
+		store(c, "flag1", "a")
+		store(c, "flag2", "b")
+		_, _ = c.get(key("flag1"))
+		store(c, "flag3", "c")
+		entry, _ := c.get(key("flag2"))
+		assert.Nil(t, entry)
+		entry, _ = c.get(key("flag1"))
+		assert.NotNil(t, entry)
+		entry, _ = c.get(key("flag3"))
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("expired result is discarded", func(t *testing.T) {
+		c := newEvaluationCache(subsystems.EvaluationCacheConfiguration{Capacity: 10, TTL: time.Millisecond})
+		store(c, "flag", "a")
+		time.Sleep(time.Millisecond * 5)
+		entry, _ := c.get(key("flag"))
+		assert.Nil(t, entry)
+		assert.Equal(t, 0, c.lru.Len())
+	})
+}
+
+func TestEvaluationCacheKey(t *testing.T) {
+	t.Run("is the same for equal contexts", func(t *testing.T) {
+		context1 := ldcontext.NewBuilder("key").Name("a").SetString("x", "1").SetInt("y", 2).Build()
+		context2 := ldcontext.NewBuilder("key").SetInt("y", 2).SetString("x", "1").Name("a").Build()
+		assert.Equal(t, makeEvaluationCacheKey("flag", context1), makeEvaluationCacheKey("flag", context2))
+	})
+
+	t.Run("is the same if only private attributes differ", func(t *testing.T) {
+		context1 := ldcontext.NewBuilder("key").SetString("x", "1").Build()
+		context2 := ldcontext.NewBuilder("key").SetString("x", "1").Private("x").Build()
+		assert.Equal(t, makeEvaluationCacheKey("flag", context1), makeEvaluationCacheKey("flag", context2))
+	})
+
+	t.Run("is different for different attributes of the same context key", func(t *testing.T) {
+		base := ldcontext.NewBuilder("key").SetString("x", "1").Build()
+		for name, context := range map[string]ldcontext.Context{
+			"flag key":        base,
+			"context key":     ldcontext.NewBuilder("other").SetString("x", "1").Build(),
+			"kind":            ldcontext.NewBuilder("key").Kind("org").SetString("x", "1").Build(),
+			"attribute value": ldcontext.NewBuilder("key").SetString("x", "2").Build(),
+			"attribute type":  ldcontext.NewBuilder("key").SetInt("x", 1).Build(),
+			"attribute name":  ldcontext.NewBuilder("key").SetString("y", "1").Build(),
+			"name":            ldcontext.NewBuilder("key").SetString("x", "1").Name("a").Build(),
+			"anonymous":       ldcontext.NewBuilder("key").SetString("x", "1").Anonymous(true).Build(),
+			"array":           ldcontext.NewBuilder("key").SetValue("x", ldvalue.ArrayOf(ldvalue.String("1"))).Build(),
+			"multi-context": ldcontext.NewMulti(base,
+				ldcontext.NewBuilder("key").Kind("org").SetString("x", "1").Build()),
+		} {
+			flagKey := "flag"
+			if name == "flag key" {
+				flagKey = "other-flag"
+			}
+			assert.NotEqual(t, makeEvaluationCacheKey("flag", base), makeEvaluationCacheKey(flagKey, context), name)
+		}
+	})
+
+	t.Run("separates the attributes of each context in a multi-context", func(t *testing.T) {
+		context1 := ldcontext.NewMulti(ldcontext.NewBuilder("a").SetString("x", "1").Build(),
+			ldcontext.NewBuilder("b").Kind("org").Build())
+		context2 := ldcontext.NewMulti(ldcontext.NewBuilder("a").Build(),
+			ldcontext.NewBuilder("b").Kind("org").SetString("x", "1").Build())
+		assert.NotEqual(t, makeEvaluationCacheKey("flag", context1), makeEvaluationCacheKey("flag", context2))
+	})
+}
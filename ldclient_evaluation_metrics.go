@@ -0,0 +1,67 @@
+package ldclient
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// evaluationMetrics reports evaluations to an EvaluationMetricsSink. A nil *evaluationMetrics means that
+// metrics are disabled, in which case the variation methods skip timing altogether.
+//
+// Flag keys are interned so that the sink always receives the same string for a given flag, and so that
+// the number of distinct labels stays within the configured limit.
+type evaluationMetrics struct {
+	sink  subsystems.EvaluationMetricsSink
+	limit int
+	keys  map[string]string
+	lock  sync.RWMutex
+}
+
+func newEvaluationMetrics(config subsystems.EvaluationMetricsConfiguration) *evaluationMetrics {
+	if config.Sink == nil {
+		return nil
+	}
+	return &evaluationMetrics{
+		sink:  config.Sink,
+		limit: config.FlagKeyLimit,
+		keys:  make(map[string]string),
+	}
+}
+
+func (m *evaluationMetrics) record(flagKey string, reason ldreason.EvaluationReason, duration time.Duration) {
+	label := m.flagLabel(flagKey)
+	m.sink.IncEvaluationCount(label)
+	if reason.GetKind() == ldreason.EvalReasonError {
+		m.sink.IncErrorCount(label, reason.GetErrorKind())
+	}
+	m.sink.ObserveEvaluationDuration(label, duration)
+}
+
+func (m *evaluationMetrics) flagLabel(flagKey string) string {
+	m.lock.RLock()
+	label, ok := m.keys[flagKey]
+	full := len(m.keys) >= m.limit
+	m.lock.RUnlock()
+	if ok {
+		return label
+	}
+	if full {
+		return subsystems.EvaluationMetricsOtherFlagKey
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if label, ok := m.keys[flagKey]; ok {
+		return label
+	}
+	if len(m.keys) >= m.limit {
+		return subsystems.EvaluationMetricsOtherFlagKey
+	}
+	label = strings.Clone(flagKey) // don't retain a reference to whatever larger string the key came from
+	m.keys[label] = label
+	return label
+}
@@ -0,0 +1,187 @@
+package ldclient
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingEvaluationMetricsSink struct {
+	evaluations map[string]int
+	errors      map[string][]ldreason.EvalErrorKind
+	durations   map[string]int
+	lock        sync.Mutex
+}
+
+func newRecordingEvaluationMetricsSink() *recordingEvaluationMetricsSink {
+	return &recordingEvaluationMetricsSink{
+		evaluations: make(map[string]int),
+		errors:      make(map[string][]ldreason.EvalErrorKind),
+		durations:   make(map[string]int),
+	}
+}
+
+func (s *recordingEvaluationMetricsSink) IncEvaluationCount(flagKey string) {
+	s.lock.Lock()
+	s.evaluations[flagKey]++
+	s.lock.Unlock()
+}
+
+func (s *recordingEvaluationMetricsSink) IncErrorCount(flagKey string, errorKind ldreason.EvalErrorKind) {
+	s.lock.Lock()
+	s.errors[flagKey] = append(s.errors[flagKey], errorKind)
+	s.lock.Unlock()
+}
+
+func (s *recordingEvaluationMetricsSink) ObserveEvaluationDuration(flagKey string, duration time.Duration) {
+	s.lock.Lock()
+	s.durations[flagKey]++
+	s.lock.Unlock()
+}
+
+func makeTestClientWithEvaluationMetrics(
+	metrics *ldcomponents.EvaluationMetricsConfigurationBuilder,
+) (*LDClient, *ldtestdata.TestDataSource) {
+	data := ldtestdata.DataSource()
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = data
+		c.EvaluationMetrics = metrics
+	})
+	return client, data
+}
+
+func TestEvaluationMetricsAreRecordedForEachEvaluation(t *testing.T) {
+	sink := newRecordingEvaluationMetricsSink()
+	client, data := makeTestClientWithEvaluationMetrics(ldcomponents.EvaluationMetrics(sink))
+	defer client.Close()
+	data.Update(data.Flag("flag1").VariationForAll(true))
+	data.Update(data.Flag("flag2").ValueForAll(ldvalue.String("x")))
+
+	_, _ = client.BoolVariation("flag1", evalTestUser, false)
+	_, _, _ = client.BoolVariationDetail("flag1", evalTestUser, false)
+	_, _ = client.StringVariation("flag2", evalTestUser, "")
+
+	assert.Equal(t, map[string]int{"flag1": 2, "flag2": 1}, sink.evaluations)
+	assert.Equal(t, map[string]int{"flag1": 2, "flag2": 1}, sink.durations)
+	assert.Len(t, sink.errors, 0)
+}
+
+func TestEvaluationMetricsRecordErrorsByKind(t *testing.T) {
+	sink := newRecordingEvaluationMetricsSink()
+	client, data := makeTestClientWithEvaluationMetrics(ldcomponents.EvaluationMetrics(sink))
+	defer client.Close()
+	data.Update(data.Flag("flag1").VariationForAll(true))
+
+	_, _ = client.StringVariation("flag1", evalTestUser, "")
+	_, _ = client.BoolVariation("unknown-flag", evalTestUser, false)
+
+	assert.Equal(t, map[string]int{"flag1": 1, "unknown-flag": 1}, sink.evaluations)
+	assert.Equal(t, map[string][]ldreason.EvalErrorKind{
+		"flag1":        {ldreason.EvalErrorWrongType},
+		"unknown-flag": {ldreason.EvalErrorFlagNotFound},
+	}, sink.errors)
+}
+
+func TestEvaluationMetricsFlagKeysBeyondLimitAreGrouped(t *testing.T) {
+	sink := newRecordingEvaluationMetricsSink()
+	client, _ := makeTestClientWithEvaluationMetrics(ldcomponents.EvaluationMetrics(sink).FlagKeyLimit(2))
+	defer client.Close()
+
+	for _, key := range []string{"flag1", "flag2", "flag3", "flag1", "flag4"} {
+		_, _ = client.BoolVariation(key, evalTestUser, false)
+	}
+
+	assert.Equal(t, map[string]int{
+		"flag1":                                  2,
+		"flag2":                                  1,
+		subsystems.EvaluationMetricsOtherFlagKey: 2,
+	}, sink.evaluations)
+}
+
+func TestEvaluationMetricsWithNilSinkAreDisabled(t *testing.T) {
+	client, data := makeTestClientWithEvaluationMetrics(ldcomponents.EvaluationMetrics(nil))
+	defer client.Close()
+	data.Update(data.Flag("flag1").VariationForAll(true))
+
+	assert.Nil(t, client.evaluationMetrics)
+	value, err := client.BoolVariation("flag1", evalTestUser, false)
+	assert.NoError(t, err)
+	assert.True(t, value)
+}
+
+type evaluationMetricsCollectorRecord struct {
+	flagKey   string
+	variation int
+	errorKind ldreason.EvalErrorKind
+}
+
+type recordingEvaluationMetricsCollector struct {
+	records   []evaluationMetricsCollectorRecord
+	durations []int64
+	lock      sync.Mutex
+}
+
+func (c *recordingEvaluationMetricsCollector) RecordEvaluation(
+	flagKey string,
+	durationNano int64,
+	variation int,
+	errorKind ldreason.EvalErrorKind,
+) {
+	c.lock.Lock()
+	c.records = append(c.records, evaluationMetricsCollectorRecord{flagKey, variation, errorKind})
+	c.durations = append(c.durations, durationNano)
+	c.lock.Unlock()
+}
+
+func TestEvaluationMetricsCollectorIsCalledForEachEvaluation(t *testing.T) {
+	collector := &recordingEvaluationMetricsCollector{}
+	data := ldtestdata.DataSource()
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = data
+		c.Metrics = collector
+	})
+	defer client.Close()
+	data.Update(data.Flag("flag1").VariationForAll(true))
+	data.Update(data.Flag("flag2").ValueForAll(ldvalue.String("x")))
+
+	_, _ = client.BoolVariation("flag1", evalTestUser, false)
+	_, _ = client.StringVariation("flag2", evalTestUser, "")
+	_, _ = client.IntVariation("flag2", evalTestUser, 0)
+	_, _ = client.BoolVariation("unknown-flag", evalTestUser, false)
+
+	assert.Equal(t, []evaluationMetricsCollectorRecord{
+		{"flag1", 0, ""},
+		{"flag2", 0, ""},
+		{"flag2", -1, ldreason.EvalErrorWrongType},
+		{"unknown-flag", -1, ldreason.EvalErrorFlagNotFound},
+	}, collector.records)
+	for _, d := range collector.durations {
+		assert.GreaterOrEqual(t, d, int64(0))
+	}
+}
+
+func TestEvaluationMetricsCollectorAndSinkCanBothBeUsed(t *testing.T) {
+	collector := &recordingEvaluationMetricsCollector{}
+	sink := newRecordingEvaluationMetricsSink()
+	data := ldtestdata.DataSource()
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = data
+		c.EvaluationMetrics = ldcomponents.EvaluationMetrics(sink)
+		c.Metrics = collector
+	})
+	defer client.Close()
+	data.Update(data.Flag("flag1").VariationForAll(true))
+
+	_, _ = client.BoolVariation("flag1", evalTestUser, false)
+
+	assert.Len(t, collector.records, 1)
+	assert.Equal(t, map[string]int{"flag1": 1}, sink.evaluations)
+}
@@ -1,9 +1,11 @@
 package ldclient
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 
@@ -20,10 +22,13 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldfiledata"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -698,6 +703,59 @@ func TestEventTrackingAndReasonAreNotForcedForFallthroughIfReasonIsNotFallthroug
 	})
 }
 
+func TestEventTrackingAndReasonCanBeForcedWithTestDataBuilder(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.Update(p.data.Flag(evalFlagKey).Variations(offValue, onValue).FallthroughVariationIndex(0).
+			TrackEventsFallthrough(true).
+			IfMatch("key", ldvalue.String(evalTestUser.Key())).TrackEvents(true).ThenReturnIndex(1))
+
+		_, _ = p.client.StringVariation(evalFlagKey, evalTestUser, "default")
+		e := p.requireSingleEvent(t)
+		assert.True(t, e.RequireFullEvent)
+		assert.Equal(t, ldreason.NewEvalReasonRuleMatch(0, "rule0"), e.Reason)
+
+		p.events.Events = nil
+		_, _ = p.client.StringVariation(evalFlagKey, lduser.NewUser("other-user"), "default")
+		e = p.requireSingleEvent(t)
+		assert.True(t, e.RequireFullEvent)
+		assert.Equal(t, ldreason.NewEvalReasonFallthrough(), e.Reason)
+	})
+}
+
+func TestEventTrackingAndReasonCanBeForcedForFlagFromFile(t *testing.T) {
+	fileData := []byte(`{"flags": {"` + evalFlagKey + `": {
+		"key": "` + evalFlagKey + `", "version": 1, "on": true, "variations": ["off", "on"],
+		"fallthrough": {"variation": 0}, "trackEventsFallthrough": true,
+		"rules": [{
+			"id": "rule-id", "variation": 1, "trackEvents": true,
+			"clauses": [{"attribute": "key", "op": "in", "values": ["` + evalTestUser.Key() + `"]}]
+		}]
+	}}}`)
+	th.WithTempFileData(fileData, func(filename string) {
+		events := &mocks.CapturingEventProcessor{}
+		client, err := MakeCustomClient(testSdkKey, Config{
+			DataSource: ldfiledata.DataSource().FilePaths(filename),
+			Events:     mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: events},
+			Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+		}, time.Second)
+		require.NoError(t, err)
+		defer client.Close()
+
+		value, _ := client.StringVariation(evalFlagKey, evalTestUser, "default")
+		assert.Equal(t, "on", value)
+		value, _ = client.StringVariation(evalFlagKey, lduser.NewUser("other-user"), "default")
+		assert.Equal(t, "off", value)
+
+		require.Len(t, events.Events, 2)
+		e := events.Events[0].(ldevents.EvaluationData)
+		assert.True(t, e.RequireFullEvent)
+		assert.Equal(t, ldreason.NewEvalReasonRuleMatch(0, "rule-id"), e.Reason)
+		e = events.Events[1].(ldevents.EvaluationData)
+		assert.True(t, e.RequireFullEvent)
+		assert.Equal(t, ldreason.NewEvalReasonFallthrough(), e.Reason)
+	})
+}
+
 func TestEvaluatingUnknownFlagSendsEvent(t *testing.T) {
 	withClientEvalTestParams(func(p clientEvalTestParams) {
 		_, err := p.client.StringVariation("no-such-flag", evalTestUser, "x")
@@ -888,3 +946,102 @@ func TestEvalUsesStoreAndLogsWarningIfClientIsNotInitializedButStoreIsInitialize
 	assert.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
 	assert.Contains(t, mockLoggers.GetOutput(ldlog.Warn)[0], "using last known values")
 }
+
+type testRequestIDKey struct{}
+
+func TestEvaluationLogContextExtractor(t *testing.T) {
+	extractor := func(ctx gocontext.Context) string {
+		if id, ok := ctx.Value(testRequestIDKey{}).(string); ok {
+			return "[request " + id + "]"
+		}
+		return ""
+	}
+	ctx := gocontext.WithValue(gocontext.Background(), testRequestIDKey{}, "abc")
+
+	makeClient := func(mockLoggers *ldlogtest.MockLog) *LDClient {
+		return makeTestClientWithConfig(func(c *Config) {
+			c.Logging = ldcomponents.Logging().Loggers(mockLoggers.Loggers).LogEvaluationErrors(true).
+				WithContextExtractor(extractor)
+		})
+	}
+
+	t.Run("messages from an evaluation with a Go context are prefixed", func(t *testing.T) {
+		mockLoggers := ldlogtest.NewMockLog()
+		client := makeClient(mockLoggers)
+		defer client.Close()
+
+		value, _ := client.StringVariationCtx(ctx, "unknown-flag", evalTestUser, "default")
+		assert.Equal(t, "default", value)
+		require.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
+		assert.Regexp(t, `^\[request abc\] unknown feature key: unknown-flag`, mockLoggers.GetOutput(ldlog.Warn)[0])
+	})
+
+	t.Run("messages are not prefixed if the extractor returns an empty string", func(t *testing.T) {
+		mockLoggers := ldlogtest.NewMockLog()
+		client := makeClient(mockLoggers)
+		defer client.Close()
+
+		_, _ = client.BoolVariationCtx(gocontext.Background(), "unknown-flag", evalTestUser, false)
+		require.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
+		assert.Regexp(t, `^unknown feature key`, mockLoggers.GetOutput(ldlog.Warn)[0])
+	})
+
+	t.Run("messages from an evaluation without a Go context are not prefixed", func(t *testing.T) {
+		mockLoggers := ldlogtest.NewMockLog()
+		client := makeClient(mockLoggers)
+		defer client.Close()
+
+		_, _ = client.BoolVariation("unknown-flag", evalTestUser, false)
+		require.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
+		assert.Regexp(t, `^unknown feature key`, mockLoggers.GetOutput(ldlog.Warn)[0])
+	})
+
+	t.Run("messages are prefixed when events are disabled", func(t *testing.T) {
+		mockLoggers := ldlogtest.NewMockLog()
+		client := makeClient(mockLoggers)
+		defer client.Close()
+
+		_, _ = client.WithEventsDisabled(true).IntVariationCtx(ctx, "unknown-flag", evalTestUser, 0)
+		require.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
+		assert.Regexp(t, `^\[request abc\] unknown feature key`, mockLoggers.GetOutput(ldlog.Warn)[0])
+	})
+}
+
+func TestVariationCtxMethodsReturnSameResults(t *testing.T) {
+	ctx := gocontext.Background()
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.setupSingleValueFlag("bool", ldvalue.Bool(true))
+		p.setupSingleValueFlag("int", ldvalue.Int(3))
+		p.setupSingleValueFlag("float", ldvalue.Float64(2.5))
+		p.setupSingleValueFlag("string", ldvalue.String("x"))
+		p.setupSingleValueFlag("json", ldvalue.ArrayOf(ldvalue.Int(1)))
+
+		b, _ := p.client.BoolVariationCtx(ctx, "bool", evalTestUser, false)
+		assert.True(t, b)
+		b, detail, _ := p.client.BoolVariationDetailCtx(ctx, "bool", evalTestUser, false)
+		assert.True(t, b)
+		assert.Equal(t, ldreason.NewEvalReasonFallthrough(), detail.Reason)
+
+		i, _ := p.client.IntVariationCtx(ctx, "int", evalTestUser, 0)
+		assert.Equal(t, 3, i)
+		i, _, _ = p.client.IntVariationDetailCtx(ctx, "int", evalTestUser, 0)
+		assert.Equal(t, 3, i)
+
+		f, _ := p.client.Float64VariationCtx(ctx, "float", evalTestUser, 0)
+		assert.Equal(t, 2.5, f)
+		f, _, _ = p.client.Float64VariationDetailCtx(ctx, "float", evalTestUser, 0)
+		assert.Equal(t, 2.5, f)
+
+		s, _ := p.client.StringVariationCtx(ctx, "string", evalTestUser, "")
+		assert.Equal(t, "x", s)
+		s, _, _ = p.client.StringVariationDetailCtx(ctx, "string", evalTestUser, "")
+		assert.Equal(t, "x", s)
+
+		j, _ := p.client.JSONVariationCtx(ctx, "json", evalTestUser, ldvalue.Null())
+		assert.Equal(t, ldvalue.ArrayOf(ldvalue.Int(1)), j)
+		j, _, _ = p.client.JSONVariationDetailCtx(ctx, "json", evalTestUser, ldvalue.Null())
+		assert.Equal(t, ldvalue.ArrayOf(ldvalue.Int(1)), j)
+
+		assert.Len(t, p.events.Events, 10)
+	})
+}
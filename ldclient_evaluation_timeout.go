@@ -0,0 +1,126 @@
+package ldclient
+
+import (
+	gocontext "context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// EvalErrorTimeout is the error kind in the EvaluationReason when an evaluation did not complete before the
+// Go context passed to one of the Ctx variation methods was done, or within [Config.EvaluationTimeout]. The
+// default value is returned in this case.
+const EvalErrorTimeout ldreason.EvalErrorKind = "TIMEOUT"
+
+// ErrTooManyAbandonedEvaluations is returned, with the error kind [EvalErrorTimeout], by an evaluation that
+// had a deadline but was not started because too many earlier evaluations that timed out are still waiting
+// for the data store. This keeps a data store that has stopped responding from using up an unlimited number
+// of goroutines.
+var ErrTooManyAbandonedEvaluations = errors.New(
+	"too many evaluations that timed out are still waiting for the data store")
+
+// maxAbandonedEvaluations is how many evaluations that timed out can still be running before new
+// evaluations with a deadline fail immediately with ErrTooManyAbandonedEvaluations.
+const maxAbandonedEvaluations = 100
+
+const (
+	evaluationRunning int32 = iota
+	evaluationAbandoned
+	evaluationFinished
+)
+
+type evaluationOutcome struct {
+	result ldeval.Result
+	flag   *ldmodel.FeatureFlag
+	err    error
+}
+
+// evaluateWithDeadline is the same as evaluateInternal, except that it stops waiting for the evaluation
+// once ctx is done or the configured evaluation timeout has elapsed. If there is neither a timeout nor a
+// cancellable ctx, it calls evaluateInternal directly, so that the usual code path has no extra overhead.
+//
+// The data store interfaces do not take a Go context, so an evaluation that was given up on keeps running in
+// its own goroutine until the store returns; its result is then discarded. It does not produce any events.
+// Once there are maxAbandonedEvaluations of these, new evaluations with a deadline fail immediately instead
+// of starting another goroutine. If the data store is the in-memory
+// one and there is no Big Segment store, an evaluation cannot block, so there is no goroutine and no
+// timeout; only a ctx that is already done is checked.
+func (client *LDClient) evaluateWithDeadline(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+	eventsScope eventsScope,
+	tracer *evaluationTracer,
+	loggers ldlog.Loggers,
+) (ldeval.Result, *ldmodel.FeatureFlag, error) {
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	if tracer != nil || (client.evaluationTimeout <= 0 && ctx.Done() == nil) {
+		return client.evaluateInternal(key, context, defaultVal, eventsScope, tracer, loggers)
+	}
+	if err := ctx.Err(); err != nil {
+		return client.evaluationTimedOut(key, defaultVal, err, loggers)
+	}
+	if client.evaluationCannotBlock {
+		return client.evaluateInternal(key, context, defaultVal, eventsScope, nil, loggers)
+	}
+	if client.abandonedEvaluations.Load() >= maxAbandonedEvaluations {
+		return client.evaluationTimedOut(key, defaultVal, ErrTooManyAbandonedEvaluations, loggers)
+	}
+	if client.evaluationTimeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, client.evaluationTimeout)
+		defer cancel()
+	}
+
+	// Prerequisite events are held back until we know that the evaluation finished in time.
+	var prerequisiteEvents []ldeval.PrerequisiteFlagEvent
+	deferredScope := eventsScope
+	if eventsScope.prerequisiteEventRecorder != nil {
+		deferredScope.prerequisiteEventRecorder = func(event ldeval.PrerequisiteFlagEvent) {
+			prerequisiteEvents = append(prerequisiteEvents, event)
+		}
+	}
+	done := make(chan evaluationOutcome, 1) // buffered so that an abandoned evaluation never blocks
+	var state atomic.Int32
+	go func() {
+		result, flag, err := client.evaluateInternal(key, context, defaultVal, deferredScope, nil, loggers)
+		done <- evaluationOutcome{result: result, flag: flag, err: err}
+		if !state.CompareAndSwap(evaluationRunning, evaluationFinished) {
+			client.abandonedEvaluations.Add(-1)
+		}
+	}()
+
+	select {
+	case outcome := <-done:
+		for _, event := range prerequisiteEvents {
+			eventsScope.prerequisiteEventRecorder(event)
+		}
+		return outcome.result, outcome.flag, outcome.err
+	case <-ctx.Done():
+		// The count is incremented first, so that it cannot be decremented by the goroutine before that.
+		client.abandonedEvaluations.Add(1)
+		if !state.CompareAndSwap(evaluationRunning, evaluationAbandoned) {
+			client.abandonedEvaluations.Add(-1) // it finished just now
+		}
+		return client.evaluationTimedOut(key, defaultVal, ctx.Err(), loggers)
+	}
+}
+
+func (client *LDClient) evaluationTimedOut(
+	key string,
+	defaultVal ldvalue.Value,
+	err error,
+	loggers ldlog.Loggers,
+) (ldeval.Result, *ldmodel.FeatureFlag, error) {
+	result := ldeval.Result{Detail: newEvaluationError(defaultVal, EvalErrorTimeout)}
+	return client.evaluationResult(key, result, defaultVal, loggers), nil, err
+}
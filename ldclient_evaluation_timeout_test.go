@@ -0,0 +1,240 @@
+package ldclient
+
+import (
+	gocontext "context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldmonitor"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDataStore makes Get block for the keys in slowKeys until release is closed.
+type slowDataStore struct {
+	subsystems.DataStore
+	slowKeys map[string]bool
+	release  chan struct{}
+	returned chan string
+}
+
+func (s *slowDataStore) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
+	if s.slowKeys[key] {
+		<-s.release
+		defer func() { s.returned <- key }()
+	}
+	return s.DataStore.Get(kind, key)
+}
+
+type evaluationTimeoutTestParams struct {
+	client *LDClient
+	store  *slowDataStore
+	events *mocks.CapturingEventProcessor
+}
+
+func withEvaluationTimeoutTestParams(
+	timeout time.Duration,
+	slowKeys []string,
+	flags []ldmodel.FeatureFlag,
+	callback func(evaluationTimeoutTestParams),
+) {
+	p := evaluationTimeoutTestParams{
+		store: &slowDataStore{
+			DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers()),
+			slowKeys:  make(map[string]bool),
+			release:   make(chan struct{}),
+			returned:  make(chan string, maxAbandonedEvaluations+10),
+		},
+		events: &mocks.CapturingEventProcessor{},
+	}
+	for _, key := range slowKeys {
+		p.store.slowKeys[key] = true
+	}
+	_ = p.store.Init(nil)
+	for _, flag := range flags {
+		_, _ = p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+	}
+	config := Config{
+		DataStore:         mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: p.store},
+		DataSource:        mocks.DataSourceThatIsAlwaysInitialized(),
+		Events:            mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: p.events},
+		EvaluationTimeout: timeout,
+		Logging:           ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}
+	p.client, _ = MakeCustomClient(testSdkKey, config, 0)
+	defer p.client.Close()
+	callback(p)
+}
+
+func (p evaluationTimeoutTestParams) expectStoreCallToReturn(t *testing.T, key string) {
+	close(p.store.release)
+	select {
+	case k := <-p.store.returned:
+		assert.Equal(t, key, k)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for abandoned store call to return")
+	}
+}
+
+var timeoutTestFlag = ldbuilders.NewFlagBuilder("flag").Version(1).On(true).
+	Variations(ldvalue.Bool(true)).FallthroughVariation(0).Build()
+
+var timeoutTestFlagWithPrereq = ldbuilders.NewFlagBuilder("flag-with-prereq").Version(1).On(true).
+	Variations(ldvalue.Bool(false), ldvalue.Bool(true)).FallthroughVariation(1).
+	AddPrerequisite(timeoutTestFlag.Key, 0).Build()
+
+func TestEvaluationReturnsDefaultValueWhenCtxDeadlineExpires(t *testing.T) {
+	withEvaluationTimeoutTestParams(0, []string{timeoutTestFlag.Key}, []ldmodel.FeatureFlag{timeoutTestFlag},
+		func(p evaluationTimeoutTestParams) {
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Millisecond*10)
+			defer cancel()
+			value, detail, err := p.client.BoolVariationDetailCtx(ctx, timeoutTestFlag.Key, evalTestUser, false)
+			assert.False(t, value)
+			assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorTimeout), detail.Reason)
+			assert.Equal(t, gocontext.DeadlineExceeded, err)
+
+			require.Len(t, p.events.Events, 1)
+			e := p.events.Events[0].(ldevents.EvaluationData)
+			assert.Equal(t, timeoutTestFlag.Key, e.Key)
+			assert.Equal(t, ldvalue.Bool(false), e.Value)
+			assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorTimeout), e.Reason)
+
+			p.expectStoreCallToReturn(t, timeoutTestFlag.Key)
+			assert.Len(t, p.events.Events, 1)
+		})
+}
+
+func TestEvaluationReturnsDefaultValueWhenCtxIsAlreadyDone(t *testing.T) {
+	withEvaluationTimeoutTestParams(0, []string{timeoutTestFlag.Key}, []ldmodel.FeatureFlag{timeoutTestFlag},
+		func(p evaluationTimeoutTestParams) {
+			ctx, cancel := gocontext.WithCancel(gocontext.Background())
+			cancel()
+			value, detail, err := p.client.BoolVariationDetailCtx(ctx, timeoutTestFlag.Key, evalTestUser, false)
+			assert.False(t, value)
+			assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorTimeout), detail.Reason)
+			assert.Equal(t, gocontext.Canceled, err)
+
+			close(p.store.release)
+			assert.Len(t, p.store.returned, 0) // the store was never called
+		})
+}
+
+func TestEvaluationTimeoutFromConfigAppliesToMethodsWithoutCtx(t *testing.T) {
+	withEvaluationTimeoutTestParams(time.Millisecond*10, []string{timeoutTestFlag.Key},
+		[]ldmodel.FeatureFlag{timeoutTestFlag}, func(p evaluationTimeoutTestParams) {
+			value, detail, err := p.client.BoolVariationDetail(timeoutTestFlag.Key, evalTestUser, false)
+			assert.False(t, value)
+			assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorTimeout), detail.Reason)
+			assert.Equal(t, gocontext.DeadlineExceeded, err)
+			p.expectStoreCallToReturn(t, timeoutTestFlag.Key)
+		})
+}
+
+func TestEvaluationThatCompletesInTimeIsNotAffectedByDeadline(t *testing.T) {
+	withEvaluationTimeoutTestParams(time.Second, nil,
+		[]ldmodel.FeatureFlag{timeoutTestFlag, timeoutTestFlagWithPrereq}, func(p evaluationTimeoutTestParams) {
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Second)
+			defer cancel()
+			value, detail, err := p.client.BoolVariationDetailCtx(ctx, timeoutTestFlagWithPrereq.Key, evalTestUser, false)
+			assert.NoError(t, err)
+			assert.True(t, value)
+			assert.Equal(t, ldreason.NewEvalReasonFallthrough(), detail.Reason)
+
+			require.Len(t, p.events.Events, 2)
+			assert.Equal(t, timeoutTestFlag.Key, p.events.Events[0].(ldevents.EvaluationData).Key)
+			assert.Equal(t, timeoutTestFlagWithPrereq.Key, p.events.Events[1].(ldevents.EvaluationData).Key)
+		})
+}
+
+func TestAbandonedEvaluationDoesNotSendPrerequisiteEvents(t *testing.T) {
+	withEvaluationTimeoutTestParams(time.Millisecond*10, []string{timeoutTestFlag.Key},
+		[]ldmodel.FeatureFlag{timeoutTestFlag, timeoutTestFlagWithPrereq}, func(p evaluationTimeoutTestParams) {
+			value, _ := p.client.BoolVariation(timeoutTestFlagWithPrereq.Key, evalTestUser, false)
+			assert.False(t, value)
+			p.expectStoreCallToReturn(t, timeoutTestFlag.Key)
+
+			require.Len(t, p.events.Events, 1)
+			e := p.events.Events[0].(ldevents.EvaluationData)
+			assert.Equal(t, timeoutTestFlagWithPrereq.Key, e.Key)
+			assert.Equal(t, ldvalue.Bool(false), e.Value)
+		})
+}
+
+func TestAbandonedEvaluationsAreBounded(t *testing.T) {
+	withEvaluationTimeoutTestParams(time.Millisecond, []string{timeoutTestFlag.Key},
+		[]ldmodel.FeatureFlag{timeoutTestFlag}, func(p evaluationTimeoutTestParams) {
+			goroutinesBefore := runtime.NumGoroutine()
+			for i := 0; i < maxAbandonedEvaluations; i++ {
+				_, _, err := p.client.BoolVariationDetail(timeoutTestFlag.Key, evalTestUser, false)
+				require.Equal(t, gocontext.DeadlineExceeded, err)
+			}
+			for i := 0; i < 50; i++ {
+				value, detail, err := p.client.BoolVariationDetail(timeoutTestFlag.Key, evalTestUser, false)
+				assert.False(t, value)
+				assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorTimeout), detail.Reason)
+				require.Equal(t, ErrTooManyAbandonedEvaluations, err)
+			}
+			assert.LessOrEqual(t, runtime.NumGoroutine(), goroutinesBefore+maxAbandonedEvaluations+5)
+
+			close(p.store.release)
+			assert.Eventually(t, func() bool { return p.client.abandonedEvaluations.Load() == 0 },
+				time.Second, time.Millisecond*10)
+			value, err := p.client.BoolVariation(timeoutTestFlag.Key, evalTestUser, false)
+			assert.NoError(t, err)
+			assert.True(t, value)
+		})
+}
+
+func TestEvaluationWithInMemoryStoreIsNotGivenUpOn(t *testing.T) {
+	store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	_ = store.Init(nil)
+	_, _ = store.Upsert(datakinds.Features, timeoutTestFlag.Key, sharedtest.FlagDescriptor(timeoutTestFlag))
+	config := Config{
+		DataStore:         mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store},
+		DataSource:        mocks.DataSourceThatIsAlwaysInitialized(),
+		Events:            ldcomponents.NoEvents(),
+		EvaluationTimeout: time.Nanosecond, // it would always expire if there were a goroutine to wait for
+		Logging:           ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}
+	client, _ := MakeCustomClient(testSdkKey, config, 0)
+	defer client.Close()
+	require.True(t, client.evaluationCannotBlock)
+
+	for i := 0; i < 10; i++ {
+		value, err := client.BoolVariation(timeoutTestFlag.Key, evalTestUser, false)
+		require.NoError(t, err)
+		assert.True(t, value)
+	}
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	_, err := client.BoolVariationCtx(ctx, timeoutTestFlag.Key, evalTestUser, false)
+	assert.Equal(t, gocontext.Canceled, err)
+}
+
+func TestEvaluationWithMonitoredInMemoryStoreIsNotGivenUpOn(t *testing.T) {
+	config := Config{
+		DataStore:  ldmonitor.DataStoreSizeMonitor(nil, 100, nil),
+		DataSource: mocks.DataSourceThatIsAlwaysInitialized(),
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}
+	client, _ := MakeCustomClient(testSdkKey, config, 0)
+	defer client.Close()
+	assert.True(t, client.evaluationCannotBlock)
+}
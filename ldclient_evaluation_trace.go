@@ -0,0 +1,273 @@
+package ldclient
+
+import (
+	"crypto/sha1" //nolint:gosec // SHA1 is cryptographically weak but we are not using it to hash any credentials
+	"encoding/hex"
+	"strconv"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/evaltrace"
+)
+
+const traceBucketScale = float32(0xFFFFFFFFFFFFFFF)
+
+// evaluationTracer accumulates an evaluation trace. A nil *evaluationTracer means that tracing is not
+// enabled, which is the case for all evaluations except EvaluateWithTrace.
+//
+// The evaluator does not expose its intermediate steps, so the tracer reconstructs them after the real
+// evaluation has produced its result: prerequisite results are captured through the prerequisite event
+// recorder, and each clause is checked by evaluating a minimal flag that contains only that clause. The
+// real evaluation result is always the one returned to the caller.
+type evaluationTracer struct {
+	trace         evaltrace.Trace
+	prerequisites map[string]ldeval.PrerequisiteFlagEvent
+}
+
+func newEvaluationTracer(flagKey string) *evaluationTracer {
+	return &evaluationTracer{
+		trace:         evaltrace.Trace{FlagKey: flagKey},
+		prerequisites: make(map[string]ldeval.PrerequisiteFlagEvent),
+	}
+}
+
+func (t *evaluationTracer) prerequisiteRecorder(
+	next ldeval.PrerequisiteFlagEventRecorder,
+) ldeval.PrerequisiteFlagEventRecorder {
+	return func(event ldeval.PrerequisiteFlagEvent) {
+		if event.TargetFlagKey == t.trace.FlagKey {
+			t.prerequisites[event.PrerequisiteFlag.Key] = event
+		}
+		if next != nil {
+			next(event)
+		}
+	}
+}
+
+func (client *LDClient) traceEvaluation(
+	t *evaluationTracer,
+	flag *ldmodel.FeatureFlag,
+	context ldcontext.Context,
+	reason ldreason.EvaluationReason,
+) {
+	tr := &t.trace
+	tr.FlagFound = true
+	tr.FlagVersion = flag.Version
+	tr.FlagOn = flag.On
+	if !flag.On {
+		return
+	}
+
+	for _, p := range flag.Prerequisites {
+		event, checked := t.prerequisites[p.Key]
+		if !checked {
+			// The evaluator only records prerequisites that exist, so if this is the one that failed, it
+			// must have been missing; otherwise evaluation stopped before reaching it.
+			if reason.GetKind() == ldreason.EvalReasonPrerequisiteFailed && reason.GetPrerequisiteKey() == p.Key {
+				tr.Prerequisites = append(tr.Prerequisites,
+					evaltrace.Prerequisite{Key: p.Key, RequiredVariation: p.Variation})
+			}
+			return
+		}
+		pt := evaltrace.Prerequisite{
+			Key:               p.Key,
+			Found:             true,
+			On:                event.PrerequisiteFlag.On,
+			RequiredVariation: p.Variation,
+			Variation:         event.PrerequisiteResult.Detail.VariationIndex,
+		}
+		pt.Passed = pt.On && pt.Variation.IsDefined() && pt.Variation.IntValue() == p.Variation
+		tr.Prerequisites = append(tr.Prerequisites, pt)
+		if !pt.Passed {
+			return
+		}
+	}
+
+	for _, target := range targetsInEvaluationOrder(flag) {
+		tt := evaltrace.Target{ContextKind: target.ContextKind, Variation: target.Variation}
+		if tt.ContextKind == "" {
+			tt.ContextKind = ldcontext.DefaultKind
+		}
+		if c := context.IndividualContextByKind(target.ContextKind); c.IsDefined() {
+			tt.ContextKey = c.Key()
+			tt.Matched = ldmodel.EvaluatorAccessors.TargetFindKey(target, c.Key())
+		}
+		tr.Targets = append(tr.Targets, tt)
+		if tt.Matched {
+			return
+		}
+	}
+
+	for i, rule := range flag.Rules {
+		rt := evaltrace.Rule{Index: i, ID: rule.ID, Clauses: make([]evaltrace.Clause, 0, len(rule.Clauses)), Matched: true}
+		for _, clause := range rule.Clauses {
+			ct := client.traceClause(flag, clause, context)
+			rt.Clauses = append(rt.Clauses, ct)
+			if !ct.Matched {
+				rt.Matched = false
+				break
+			}
+		}
+		if rt.Matched {
+			rt.Result = traceVariationOrRollout(flag, rule.VariationOrRollout, context)
+		}
+		tr.Rules = append(tr.Rules, rt)
+		if rt.Matched || (len(rt.Clauses) != 0 && rt.Clauses[len(rt.Clauses)-1].Error != "") {
+			return
+		}
+	}
+
+	tr.Fallthrough = traceVariationOrRollout(flag, flag.Fallthrough, context)
+}
+
+// targetsInEvaluationOrder returns the target lists in the same order that the evaluator checks them.
+func targetsInEvaluationOrder(flag *ldmodel.FeatureFlag) []*ldmodel.Target {
+	ret := make([]*ldmodel.Target, 0, len(flag.Targets)+len(flag.ContextTargets))
+	if len(flag.ContextTargets) == 0 {
+		for i := range flag.Targets {
+			ret = append(ret, &flag.Targets[i])
+		}
+		return ret
+	}
+	// As in the evaluator, a user-kind entry in ContextTargets with no values refers to the entry in
+	// Targets with the same variation.
+	for i, t := range flag.ContextTargets {
+		if (t.ContextKind == "" || t.ContextKind == ldcontext.DefaultKind) && len(t.Values) == 0 {
+			for j, t1 := range flag.Targets {
+				if t1.Variation == t.Variation {
+					ret = append(ret, &flag.Targets[j])
+					break
+				}
+			}
+		} else {
+			ret = append(ret, &flag.ContextTargets[i])
+		}
+	}
+	return ret
+}
+
+func (client *LDClient) traceClause(
+	flag *ldmodel.FeatureFlag,
+	clause ldmodel.Clause,
+	context ldcontext.Context,
+) evaltrace.Clause {
+	ct := evaltrace.Clause{
+		ContextKind: clause.ContextKind,
+		Attribute:   clause.Attribute.String(),
+		Op:          string(clause.Op),
+		Values:      clause.Values,
+		Negate:      clause.Negate,
+	}
+	if ct.ContextKind == "" {
+		ct.ContextKind = ldcontext.DefaultKind
+	}
+	if clause.Op != ldmodel.OperatorSegmentMatch {
+		if c := context.IndividualContextByKind(clause.ContextKind); c.IsDefined() {
+			ct.ContextValue = c.GetValueForRef(clause.Attribute)
+		}
+	}
+
+	// Rather than duplicating the evaluator's matching logic, we let it evaluate a flag that has just
+	// this one clause: it returns true if the clause matches and false otherwise.
+	probe := ldmodel.FeatureFlag{
+		Key:        flag.Key,
+		On:         true,
+		Variations: []ldvalue.Value{ldvalue.Bool(false), ldvalue.Bool(true)},
+		Rules: []ldmodel.FlagRule{{
+			Clauses:            []ldmodel.Clause{clause},
+			VariationOrRollout: ldmodel.VariationOrRollout{Variation: ldvalue.NewOptionalInt(1)},
+		}},
+		Fallthrough: ldmodel.VariationOrRollout{Variation: ldvalue.NewOptionalInt(0)},
+		Salt:        flag.Salt,
+	}
+	ldmodel.PreprocessFlag(&probe)
+	result := client.evaluator.Evaluate(&probe, context, nil)
+	if result.Detail.Reason.GetKind() == ldreason.EvalReasonError {
+		ct.Error = result.Detail.Reason.GetErrorKind()
+	} else {
+		ct.Matched = result.Detail.Value.BoolValue()
+	}
+	return ct
+}
+
+func traceVariationOrRollout(
+	flag *ldmodel.FeatureFlag,
+	vr ldmodel.VariationOrRollout,
+	context ldcontext.Context,
+) *evaltrace.Result {
+	if vr.Variation.IsDefined() || len(vr.Rollout.Variations) == 0 {
+		return &evaltrace.Result{Variation: vr.Variation}
+	}
+	isExperiment := vr.Rollout.IsExperiment()
+	bucketBy := vr.Rollout.BucketBy
+	if isExperiment || !bucketBy.IsDefined() { // always bucket by key in an experiment
+		bucketBy = ldattr.NewLiteralRef(ldattr.KeyAttr)
+	}
+	rt := &evaltrace.Rollout{
+		ContextKind: vr.Rollout.ContextKind,
+		BucketBy:    bucketBy.String(),
+	}
+	if rt.ContextKind == "" {
+		rt.ContextKind = ldcontext.DefaultKind
+	}
+
+	bucketValue := computeTraceBucketValue(vr.Rollout, flag.Key, flag.Salt, bucketBy, context)
+	rt.BucketValue = float64(bucketValue)
+	rt.Bucket = len(vr.Rollout.Variations) - 1 // as in the evaluator, a value past the end goes in the last bucket
+	var sum float32
+	for i, wv := range vr.Rollout.Variations {
+		sum += float32(wv.Weight) / 100000.0
+		if bucketValue < sum {
+			rt.Bucket = i
+			break
+		}
+	}
+	return &evaltrace.Result{
+		Variation: ldvalue.NewOptionalInt(vr.Rollout.Variations[rt.Bucket].Variation),
+		Rollout:   rt,
+	}
+}
+
+// computeTraceBucketValue repeats the evaluator's bucketing computation so that the trace can report the
+// bucket value, which the evaluator does not expose. If the context cannot be bucketed, the value is zero,
+// which is also what the evaluator uses.
+func computeTraceBucketValue(
+	rollout ldmodel.Rollout,
+	key, salt string,
+	bucketBy ldattr.Ref,
+	context ldcontext.Context,
+) float32 {
+	if bucketBy.Err() != nil {
+		return 0
+	}
+	selectedContext := context.IndividualContextByKind(rollout.ContextKind)
+	if !selectedContext.IsDefined() {
+		return 0
+	}
+	var hashInput []byte
+	if rollout.Seed.IsDefined() {
+		hashInput = strconv.AppendInt(hashInput, int64(rollout.Seed.IntValue()), 10)
+	} else {
+		hashInput = append(hashInput, key...)
+		hashInput = append(hashInput, '.')
+		hashInput = append(hashInput, salt...)
+	}
+	hashInput = append(hashInput, '.')
+	value := selectedContext.GetValueForRef(bucketBy)
+	switch {
+	case value.IsString():
+		hashInput = append(hashInput, value.StringValue()...)
+	case value.IsInt():
+		hashInput = strconv.AppendInt(hashInput, int64(value.IntValue()), 10)
+	default:
+		return 0
+	}
+	hashOutput := sha1.Sum(hashInput) //nolint:gosec // just used for insecure hashing
+	hexChars := hex.EncodeToString(hashOutput[:])
+	intVal, _ := strconv.ParseUint(hexChars[:15], 16, 64)
+	return float32(intVal) / traceBucketScale
+}
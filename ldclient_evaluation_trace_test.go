@@ -0,0 +1,251 @@
+package ldclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/evaltrace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateWithTraceUnknownFlag(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		detail, trace := p.client.EvaluateWithTrace("unknown-flag", evalTestUser)
+
+		assert.Equal(t, ldreason.EvalErrorFlagNotFound, detail.Reason.GetErrorKind())
+		assert.Equal(t, evaltrace.Trace{FlagKey: "unknown-flag"}, trace)
+	})
+}
+
+func TestEvaluateWithTraceFlagOff(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).Version(3).On(false).OffVariation(0).
+		Variations(offValue, onValue).AddRule(ldbuilders.NewRuleBuilder().Variation(1)).Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		detail, trace := p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+
+		assert.Equal(t, offValue, detail.Value)
+		assert.Equal(t, evaltrace.Trace{FlagKey: evalFlagKey, FlagFound: true, FlagVersion: 3}, trace)
+	})
+}
+
+func TestEvaluateWithTracePrerequisites(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).OffVariation(0).FallthroughVariation(1).
+		Variations(offValue, onValue).AddPrerequisite("prereq1", 1).AddPrerequisite("prereq2", 1).
+		AddPrerequisite("prereq3", 1).Build()
+	prereq1 := ldbuilders.NewFlagBuilder("prereq1").On(true).FallthroughVariation(1).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).Build()
+	prereq2 := ldbuilders.NewFlagBuilder("prereq2").On(false).OffVariation(1).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+		p.data.UsePreconfiguredFlag(prereq1)
+		p.data.UsePreconfiguredFlag(prereq2)
+
+		detail, trace := p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+
+		assert.Equal(t, ldreason.NewEvalReasonPrerequisiteFailed("prereq2"), detail.Reason)
+		assert.Equal(t, []evaltrace.Prerequisite{
+			{Key: "prereq1", Found: true, On: true, RequiredVariation: 1, Variation: ldvalue.NewOptionalInt(1), Passed: true},
+			// a prerequisite that is off never passes, even if its off variation is the required one
+			{Key: "prereq2", Found: true, On: false, RequiredVariation: 1, Variation: ldvalue.NewOptionalInt(1)},
+		}, trace.Prerequisites)
+		assert.Nil(t, trace.Targets)
+		assert.Nil(t, trace.Rules)
+		assert.Nil(t, trace.Fallthrough)
+	})
+}
+
+func TestEvaluateWithTraceMissingPrerequisite(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).OffVariation(0).FallthroughVariation(1).
+		Variations(offValue, onValue).AddPrerequisite("missing", 1).Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		_, trace := p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+
+		assert.Equal(t, []evaltrace.Prerequisite{{Key: "missing", RequiredVariation: 1}}, trace.Prerequisites)
+	})
+}
+
+func TestEvaluateWithTraceTargets(t *testing.T) {
+	orgContext := ldcontext.NewWithKind("org", "org-key")
+	context := ldcontext.NewMulti(evalTestUser, orgContext)
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).FallthroughVariation(0).
+		Variations(fallthroughValue, onValue, offValue).
+		AddTarget(2, "other-user").
+		AddContextTarget(ldcontext.DefaultKind, 2).
+		AddContextTarget("org", 1, "org-key").
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		detail, trace := p.client.EvaluateWithTrace(evalFlagKey, context)
+
+		assert.Equal(t, onValue, detail.Value)
+		assert.Equal(t, ldreason.NewEvalReasonTargetMatch(), detail.Reason)
+		assert.Equal(t, []evaltrace.Target{
+			{ContextKind: ldcontext.DefaultKind, ContextKey: evalTestUser.Key(), Variation: 2},
+			{ContextKind: "org", ContextKey: "org-key", Variation: 1, Matched: true},
+		}, trace.Targets)
+		assert.Nil(t, trace.Rules)
+	})
+}
+
+func TestEvaluateWithTraceRules(t *testing.T) {
+	user := lduser.NewUserBuilder("userkey").Name("Lucy").Build()
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).FallthroughVariation(0).
+		Variations(fallthroughValue, onValue).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule0").Variation(1).Clauses(
+			ldbuilders.Clause("name", ldmodel.OperatorIn, ldvalue.String("Lucy")),
+			ldbuilders.Negate(ldbuilders.Clause("key", ldmodel.OperatorIn, ldvalue.String("userkey"))),
+			ldbuilders.Clause("name", ldmodel.OperatorIn, ldvalue.String("not checked")),
+		)).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule1").Variation(1).Clauses(
+			ldbuilders.Clause("name", ldmodel.OperatorStartsWith, ldvalue.String("Lu")),
+		)).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule2").Variation(0)).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		detail, trace := p.client.EvaluateWithTrace(evalFlagKey, user)
+
+		assert.Equal(t, ldreason.NewEvalReasonRuleMatch(1, "rule1"), detail.Reason)
+		assert.Equal(t, []evaltrace.Rule{
+			{
+				Index: 0,
+				ID:    "rule0",
+				Clauses: []evaltrace.Clause{
+					{ContextKind: ldcontext.DefaultKind, Attribute: "name", Op: "in",
+						Values: []ldvalue.Value{ldvalue.String("Lucy")}, ContextValue: ldvalue.String("Lucy"), Matched: true},
+					{ContextKind: ldcontext.DefaultKind, Attribute: "key", Op: "in", Negate: true,
+						Values: []ldvalue.Value{ldvalue.String("userkey")}, ContextValue: ldvalue.String("userkey")},
+				},
+			},
+			{
+				Index: 1,
+				ID:    "rule1",
+				Clauses: []evaltrace.Clause{
+					{ContextKind: ldcontext.DefaultKind, Attribute: "name", Op: "startsWith",
+						Values: []ldvalue.Value{ldvalue.String("Lu")}, ContextValue: ldvalue.String("Lucy"), Matched: true},
+				},
+				Matched: true,
+				Result:  &evaltrace.Result{Variation: ldvalue.NewOptionalInt(1)},
+			},
+		}, trace.Rules)
+		assert.Nil(t, trace.Fallthrough)
+	})
+}
+
+func TestEvaluateWithTraceSegmentMatchClause(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment1").Included(evalTestUser.Key()).Build()
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).On(true).FallthroughVariation(0).
+		Variations(fallthroughValue, onValue).
+		AddRule(ldbuilders.NewRuleBuilder().Variation(1).Clauses(ldbuilders.SegmentMatchClause("segment1"))).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+		p.data.UsePreconfiguredSegment(segment)
+
+		detail, trace := p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+
+		assert.Equal(t, onValue, detail.Value)
+		require.Len(t, trace.Rules, 1)
+		require.Len(t, trace.Rules[0].Clauses, 1)
+		assert.Equal(t, "segmentMatch", trace.Rules[0].Clauses[0].Op)
+		assert.Equal(t, ldvalue.Null(), trace.Rules[0].Clauses[0].ContextValue)
+		assert.True(t, trace.Rules[0].Clauses[0].Matched)
+	})
+}
+
+func TestEvaluateWithTraceFallthroughRollout(t *testing.T) {
+	// The expected bucket value for this flag key, salt, and context key is known from the evaluator's tests.
+	user := lduser.NewUser("userKeyA")
+	flag := ldbuilders.NewFlagBuilder("hashKey").Salt("saltyA").On(true).
+		Variations(fallthroughValue, onValue).
+		Fallthrough(ldbuilders.Rollout(ldbuilders.Bucket(0, 40000), ldbuilders.Bucket(1, 60000))).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		detail, trace := p.client.EvaluateWithTrace(flag.Key, user)
+
+		assert.Equal(t, onValue, detail.Value)
+		require.NotNil(t, trace.Fallthrough)
+		assert.Equal(t, ldvalue.NewOptionalInt(1), trace.Fallthrough.Variation)
+		require.NotNil(t, trace.Fallthrough.Rollout)
+		assert.Equal(t, ldcontext.DefaultKind, trace.Fallthrough.Rollout.ContextKind)
+		assert.Equal(t, "key", trace.Fallthrough.Rollout.BucketBy)
+		assert.InEpsilon(t, 0.42157587, trace.Fallthrough.Rollout.BucketValue, 0.0000001)
+		assert.Equal(t, 1, trace.Fallthrough.Rollout.Bucket)
+	})
+}
+
+func TestEvaluateWithTraceSendsEvent(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.setupSingleValueFlag(evalFlagKey, onValue)
+
+		_, _ = p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+
+		p.expectSingleEvaluationEvent(t, evalFlagKey, onValue, ldvalue.Null(), expectedReasonForSingleValueFlag)
+	})
+}
+
+func TestEvaluationTraceJSON(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder(evalFlagKey).Version(2).On(true).FallthroughVariation(0).
+		Variations(fallthroughValue, onValue).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule0").Variation(1).Clauses(
+			ldbuilders.Clause("key", ldmodel.OperatorIn, ldvalue.String("other")),
+		)).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag)
+
+		_, trace := p.client.EvaluateWithTrace(evalFlagKey, evalTestUser)
+		data, err := json.Marshal(trace)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"flagKey": "flag-key",
+			"flagFound": true,
+			"flagVersion": 2,
+			"flagOn": true,
+			"rules": [
+				{
+					"index": 0,
+					"id": "rule0",
+					"clauses": [
+						{
+							"contextKind": "user",
+							"attribute": "key",
+							"op": "in",
+							"values": ["other"],
+							"contextValue": "userkey",
+							"matched": false
+						}
+					],
+					"matched": false
+				}
+			],
+			"fallthrough": {"variation": 0}
+		}`, string(data))
+	})
+}
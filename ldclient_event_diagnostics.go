@@ -0,0 +1,193 @@
+package ldclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+)
+
+// DefaultEventFormattingSampleSize is the value that is used for EventFormattingOptions.SampleSize if it
+// is zero.
+const DefaultEventFormattingSampleSize = 10
+
+// redactedValue replaces context attribute values and keys in the sample from FormatEventsForDiagnostics.
+const redactedValue = "<redacted>"
+
+// EventFormattingOptions are the parameters for [FormatEventsForDiagnostics]. The first two have the same
+// meaning as the ldcomponents.EventProcessorBuilder methods of the same names.
+type EventFormattingOptions struct {
+	// AllAttributesPrivate is the same as ldcomponents.EventProcessorBuilder.AllAttributesPrivate.
+	AllAttributesPrivate bool
+
+	// PrivateAttributes is the same as ldcomponents.EventProcessorBuilder.PrivateAttributes.
+	PrivateAttributes []ldattr.Ref
+
+	// SampleSize is how many output events are included in the redacted sample. If it is zero,
+	// DefaultEventFormattingSampleSize is used; if it is negative, the sample is empty.
+	SampleSize int
+}
+
+// EventPayloadStats describes the payload that [FormatEventsForDiagnostics] produced.
+type EventPayloadStats struct {
+	// PayloadBytes is the size of the payload, in bytes.
+	PayloadBytes int
+
+	// EventCount is the number of output events in the payload, including index and summary events.
+	EventCount int
+
+	// EventCountsByKind is the number of output events of each kind, such as "feature" or "index".
+	EventCountsByKind map[string]int
+
+	// BytesByKind is the total size, in bytes, of the output events of each kind.
+	BytesByKind map[string]int
+}
+
+// FormatEventsForDiagnostics produces the analytics event payload that the SDK would send for the
+// specified events, so that an application can see the effect of a configuration change, such as adding
+// private attributes, on real traffic before making the change in production. Nothing is sent anywhere.
+//
+// Each event must be an ldevents.EvaluationData, ldevents.IdentifyEventData, ldevents.CustomEventData,
+// ldevents.MigrationOpEventData, or json.RawMessage: the values that an ldevents.EventProcessor receives.
+// The events are passed through the same event processor that ldcomponents.SendEvents creates, with an
+// event sender that captures the payload instead of delivering it, so the output is exactly what would
+// have been sent, including index events, summary events, and the effect of any sampling ratios.
+//
+// It returns a sample of the payload's events, in which the values of context attributes and context
+// keys are replaced with "<redacted>", and statistics about the whole payload. It returns an error if
+// any event is of an unsupported type.
+func FormatEventsForDiagnostics(
+	events []interface{},
+	options EventFormattingOptions,
+) ([]byte, EventPayloadStats, error) {
+	for i, e := range events {
+		switch e.(type) {
+		case ldevents.EvaluationData, ldevents.IdentifyEventData, ldevents.CustomEventData,
+			ldevents.MigrationOpEventData, json.RawMessage:
+		default:
+			return nil, EventPayloadStats{}, fmt.Errorf("event %d has unsupported type %T", i, e)
+		}
+	}
+
+	sender := &capturingEventSender{}
+	ep := ldevents.NewDefaultEventProcessor(ldevents.EventsConfiguration{
+		AllAttributesPrivate: options.AllAttributesPrivate,
+		// Each input event can produce at most an index event, a feature event, and a debug event.
+		Capacity:              3*len(events) + 1,
+		EventSender:           sender,
+		FlushInterval:         time.Hour,
+		Loggers:               ldlog.NewDisabledLoggers(),
+		PrivateAttributes:     options.PrivateAttributes,
+		UserKeysCapacity:      ldcomponents.DefaultContextKeysCapacity,
+		UserKeysFlushInterval: time.Hour,
+	})
+	for _, e := range events {
+		switch e := e.(type) {
+		case ldevents.EvaluationData:
+			ep.RecordEvaluation(e)
+		case ldevents.IdentifyEventData:
+			ep.RecordIdentifyEvent(e)
+		case ldevents.CustomEventData:
+			ep.RecordCustomEvent(e)
+		case ldevents.MigrationOpEventData:
+			ep.RecordMigrationOpEvent(e)
+		case json.RawMessage:
+			ep.RecordRawEvent(e)
+		}
+	}
+	ep.FlushBlocking(0)
+	_ = ep.Close()
+
+	payload := sender.payload()
+	stats := EventPayloadStats{
+		PayloadBytes:      len(payload),
+		EventCountsByKind: make(map[string]int),
+		BytesByKind:       make(map[string]int),
+	}
+	if len(payload) == 0 {
+		return []byte("[]"), stats, nil
+	}
+	var outputEvents []json.RawMessage
+	if err := json.Unmarshal(payload, &outputEvents); err != nil {
+		return nil, stats, err // COVERAGE: can't happen, the event processor always sends an array
+	}
+	sampleSize := options.SampleSize
+	if sampleSize == 0 {
+		sampleSize = DefaultEventFormattingSampleSize
+	}
+	sample := make([]map[string]interface{}, 0)
+	for _, rawEvent := range outputEvents {
+		var event map[string]interface{}
+		if err := json.Unmarshal(rawEvent, &event); err != nil {
+			return nil, stats, err // COVERAGE: as above; a raw event that isn't an object would also fail here
+		}
+		kind, _ := event["kind"].(string)
+		stats.EventCount++
+		stats.EventCountsByKind[kind]++
+		stats.BytesByKind[kind] += len(rawEvent)
+		if len(sample) < sampleSize {
+			sample = append(sample, redactEventForSample(event))
+		}
+	}
+	sampleBytes, err := json.Marshal(sample)
+	return sampleBytes, stats, err
+}
+
+// redactEventForSample replaces the values in an output event that could identify a context, while keeping
+// the event's shape: its property names, context kinds, and the list of redacted attributes.
+func redactEventForSample(event map[string]interface{}) map[string]interface{} {
+	if context, ok := event["context"].(map[string]interface{}); ok {
+		redactContextForSample(context, context["kind"] == "multi")
+	}
+	if keys, ok := event["contextKeys"].(map[string]interface{}); ok {
+		for kind := range keys {
+			keys[kind] = redactedValue
+		}
+	}
+	return event
+}
+
+func redactContextForSample(context map[string]interface{}, isMulti bool) {
+	for name, value := range context {
+		switch {
+		case name == "kind" || name == "_meta" || name == "anonymous":
+		case isMulti:
+			if individual, ok := value.(map[string]interface{}); ok {
+				redactContextForSample(individual, false)
+			}
+		default:
+			context[name] = redactedValue
+		}
+	}
+}
+
+// capturingEventSender is the event sender for FormatEventsForDiagnostics. The event processor only
+// produces one analytics payload there, because it is flushed once.
+type capturingEventSender struct {
+	data []byte
+	lock sync.Mutex
+}
+
+func (s *capturingEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	if kind == ldevents.AnalyticsEventDataKind {
+		s.lock.Lock()
+		s.data = append([]byte(nil), data...)
+		s.lock.Unlock()
+	}
+	return ldevents.EventSenderResult{Success: true}
+}
+
+func (s *capturingEventSender) payload() []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.data
+}
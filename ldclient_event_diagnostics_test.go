@@ -0,0 +1,140 @@
+package ldclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeDiagnosticsTestEvents() []interface{} {
+	factory := ldevents.NewEventFactory(false, nil)
+	context := ldevents.Context(ldcontext.NewBuilder("user-key").
+		Name("Lucy").SetString("email", "lucy@example.com").Build())
+	detail := ldreason.NewEvaluationDetail(ldvalue.Bool(true), 0, ldreason.NewEvalReasonFallthrough())
+	evaluate := func(flagKey string, requireFullEvent bool) ldevents.EvaluationData {
+		return factory.NewEvaluationData(
+			ldevents.FlagEventProperties{Key: flagKey, Version: 1, RequireFullEvent: requireFullEvent},
+			context, detail, false, ldvalue.Bool(false), "", ldvalue.OptionalInt{}, false)
+	}
+	return []interface{}{
+		evaluate("tracked-flag", true),
+		evaluate("summarized-flag", false),
+		factory.NewCustomEventData("event-key", context, ldvalue.Null(), false, 0, ldvalue.OptionalInt{}),
+	}
+}
+
+func TestFormatEventsForDiagnostics(t *testing.T) {
+	sample, stats, err := FormatEventsForDiagnostics(makeDiagnosticsTestEvents(), EventFormattingOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, stats.EventCount)
+	assert.Equal(t, map[string]int{"index": 1, "feature": 1, "custom": 1, "summary": 1}, stats.EventCountsByKind)
+	total := 0
+	for _, n := range stats.BytesByKind {
+		total += n
+	}
+	// The payload is a JSON array of the events, so it also has brackets and commas.
+	assert.Equal(t, total+2+stats.EventCount-1, stats.PayloadBytes)
+
+	var sampleEvents []ldvalue.Value
+	require.NoError(t, json.Unmarshal(sample, &sampleEvents))
+	require.Len(t, sampleEvents, 4)
+	assert.Equal(t, "index", sampleEvents[0].GetByKey("kind").StringValue())
+	assert.Equal(t, "feature", sampleEvents[1].GetByKey("kind").StringValue())
+	redactedContext := ldvalue.ObjectBuild().
+		SetString("kind", "user").
+		SetString("key", redactedValue).
+		SetString("name", redactedValue).
+		SetString("email", redactedValue).
+		Build()
+	assert.Equal(t, redactedContext, sampleEvents[0].GetByKey("context"))
+	assert.Equal(t, redactedContext, sampleEvents[1].GetByKey("context"))
+	assert.Equal(t, ldvalue.ObjectBuild().SetString("user", redactedValue).Build(),
+		sampleEvents[2].GetByKey("contextKeys"))
+	assert.NotContains(t, string(sample), "user-key")
+	assert.NotContains(t, string(sample), "Lucy")
+}
+
+func TestFormatEventsForDiagnosticsIncludesDebugEvents(t *testing.T) {
+	factory := ldevents.NewEventFactory(false, nil)
+	detail := ldreason.NewEvaluationDetail(ldvalue.Bool(true), 0, ldreason.NewEvalReasonFallthrough())
+	flag := ldevents.FlagEventProperties{Key: "debugged-flag", Version: 1, RequireFullEvent: true,
+		DebugEventsUntilDate: ldtime.UnixMillisNow() + 1000000}
+	var events []interface{}
+	for _, key := range []string{"user-a", "user-b", "user-c"} {
+		events = append(events, factory.NewEvaluationData(flag, ldevents.Context(ldcontext.New(key)), detail, false,
+			ldvalue.Bool(false), "", ldvalue.OptionalInt{}, false))
+	}
+
+	_, stats, err := FormatEventsForDiagnostics(events, EventFormattingOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"index": 3, "feature": 3, "debug": 3, "summary": 1}, stats.EventCountsByKind)
+}
+
+func TestFormatEventsForDiagnosticsUsesPrivateAttributes(t *testing.T) {
+	sample, _, err := FormatEventsForDiagnostics(makeDiagnosticsTestEvents(),
+		EventFormattingOptions{PrivateAttributes: []ldattr.Ref{ldattr.NewLiteralRef("email")}})
+	require.NoError(t, err)
+
+	var sampleEvents []ldvalue.Value
+	require.NoError(t, json.Unmarshal(sample, &sampleEvents))
+	context := sampleEvents[1].GetByKey("context")
+	assert.Equal(t, ldvalue.Null(), context.GetByKey("email"))
+	assert.Equal(t, ldvalue.String(redactedValue), context.GetByKey("name"))
+	assert.Equal(t, ldvalue.ArrayOf(ldvalue.String("email")), context.GetByKey("_meta").GetByKey("redactedAttributes"))
+}
+
+func TestFormatEventsForDiagnosticsRedactsMultiKindContexts(t *testing.T) {
+	factory := ldevents.NewEventFactory(false, nil)
+	context := ldcontext.NewMulti(ldcontext.New("user-key"),
+		ldcontext.NewBuilder("org-key").Kind("org").Name("Org").Build())
+	events := []interface{}{factory.NewIdentifyEventData(ldevents.Context(context), ldvalue.OptionalInt{})}
+
+	sample, stats, err := FormatEventsForDiagnostics(events, EventFormattingOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"identify": 1}, stats.EventCountsByKind)
+	assert.JSONEq(t, `{"kind":"multi","user":{"key":"<redacted>"},"org":{"key":"<redacted>","name":"<redacted>"}}`,
+		ldvalue.Parse(sample).GetByIndex(0).GetByKey("context").JSONString())
+}
+
+func TestFormatEventsForDiagnosticsSampleSize(t *testing.T) {
+	sample, stats, err := FormatEventsForDiagnostics(makeDiagnosticsTestEvents(), EventFormattingOptions{SampleSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.EventCount)
+	assert.Equal(t, 1, ldvalue.Parse(sample).Count())
+
+	sample, _, err = FormatEventsForDiagnostics(makeDiagnosticsTestEvents(), EventFormattingOptions{SampleSize: -1})
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(sample))
+}
+
+func TestFormatEventsForDiagnosticsWithNoEvents(t *testing.T) {
+	sample, stats, err := FormatEventsForDiagnostics(nil, EventFormattingOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(sample))
+	assert.Equal(t, 0, stats.PayloadBytes)
+	assert.Equal(t, 0, stats.EventCount)
+}
+
+func TestFormatEventsForDiagnosticsIncludesRawEvents(t *testing.T) {
+	events := []interface{}{json.RawMessage(`{"kind":"custom","key":"raw-event"}`)}
+	sample, stats, err := FormatEventsForDiagnostics(events, EventFormattingOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"custom": 1}, stats.EventCountsByKind)
+	assert.JSONEq(t, `[{"kind":"custom","key":"raw-event"}]`, string(sample))
+}
+
+func TestFormatEventsForDiagnosticsRejectsUnsupportedType(t *testing.T) {
+	events := []interface{}{makeDiagnosticsTestEvents()[0], "not an event"}
+	_, _, err := FormatEventsForDiagnostics(events, EventFormattingOptions{})
+	assert.EqualError(t, err, "event 1 has unsupported type string")
+}
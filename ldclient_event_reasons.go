@@ -0,0 +1,44 @@
+package ldclient
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// IsExperiment returns true if an evaluation of the flag that had the specified reason is part of an
+// experiment, in which case the SDK always sends a full feature event with the reason, even if events
+// for the flag are not otherwise tracked.
+//
+// That is the case if the reason says that the context is in an experiment; or, for a flag that is not
+// using an experiment rollout, if the reason is a fallthrough and the flag has TrackEventsFallthrough
+// set, or if the reason is a rule match and that rule has TrackEvents set. The evaluator in
+// go-server-sdk-evaluation does not expose this decision except as part of an evaluation result, so
+// this function mirrors its logic, and the SDK's tests check that the two agree; an application that
+// generates its own analytics events can use it to include reasons when the SDK would.
+func IsExperiment(flag *ldmodel.FeatureFlag, reason ldreason.EvaluationReason) bool {
+	if reason.IsInExperiment() {
+		return true
+	}
+	if flag == nil {
+		return false
+	}
+	switch reason.GetKind() {
+	case ldreason.EvalReasonFallthrough:
+		return flag.TrackEventsFallthrough
+	case ldreason.EvalReasonRuleMatch:
+		i := reason.GetRuleIndex()
+		if i >= 0 && i < len(flag.Rules) {
+			return flag.Rules[i].TrackEvents
+		}
+	}
+	return false
+}
+
+// ShouldIncludeReason returns true if the SDK includes the evaluation reason in the feature event for
+// an evaluation of the flag that had the specified reason. explicitlyRequested is true for the
+// "Detail" variation methods such as [LDClient.BoolVariationDetail], which always include the reason;
+// otherwise the reason is only included if [IsExperiment] returns true. The flag is nil if it was not
+// found.
+func ShouldIncludeReason(flag *ldmodel.FeatureFlag, reason ldreason.EvaluationReason, explicitlyRequested bool) bool {
+	return explicitlyRequested || IsExperiment(flag, reason)
+}
@@ -0,0 +1,107 @@
+package ldclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsExperimentAndShouldIncludeReason(t *testing.T) {
+	trackedFlag := ldbuilders.NewFlagBuilder(evalFlagKey).TrackEventsFallthrough(true).
+		AddRule(ldbuilders.NewRuleBuilder().ID("untracked")).
+		AddRule(ldbuilders.NewRuleBuilder().ID("tracked").TrackEvents(true)).
+		Build()
+	untrackedFlag := ldbuilders.NewFlagBuilder(evalFlagKey).TrackEvents(true).
+		AddRule(ldbuilders.NewRuleBuilder().ID("untracked")).
+		Build()
+
+	for _, c := range []struct {
+		name         string
+		flag         *ldmodel.FeatureFlag
+		reason       ldreason.EvaluationReason
+		isExperiment bool
+	}{
+		{"off", &trackedFlag, ldreason.NewEvalReasonOff(), false},
+		{"target match", &trackedFlag, ldreason.NewEvalReasonTargetMatch(), false},
+		{"prerequisite failed", &trackedFlag, ldreason.NewEvalReasonPrerequisiteFailed("prereq"), false},
+		{"error", &trackedFlag, ldreason.NewEvalReasonError(ldreason.EvalErrorMalformedFlag), false},
+		{"fallthrough, tracked", &trackedFlag, ldreason.NewEvalReasonFallthrough(), true},
+		{"fallthrough, untracked", &untrackedFlag, ldreason.NewEvalReasonFallthrough(), false},
+		{"fallthrough, in experiment", &untrackedFlag, ldreason.NewEvalReasonFallthroughExperiment(true), true},
+		{"fallthrough, not in experiment", &untrackedFlag, ldreason.NewEvalReasonFallthroughExperiment(false), false},
+		{"rule match, tracked", &trackedFlag, ldreason.NewEvalReasonRuleMatch(1, "tracked"), true},
+		{"rule match, untracked", &trackedFlag, ldreason.NewEvalReasonRuleMatch(0, "untracked"), false},
+		{"rule match, in experiment", &untrackedFlag,
+			ldreason.NewEvalReasonRuleMatchExperiment(0, "untracked", true), true},
+		{"rule match, not in experiment", &untrackedFlag,
+			ldreason.NewEvalReasonRuleMatchExperiment(0, "untracked", false), false},
+		{"rule match, index out of range", &trackedFlag, ldreason.NewEvalReasonRuleMatch(2, "rule2"), false},
+		{"rule match, negative index", &trackedFlag, ldreason.NewEvalReasonRuleMatch(-1, "rule"), false},
+		{"unknown flag", nil, ldreason.NewEvalReasonError(ldreason.EvalErrorFlagNotFound), false},
+		{"no reason", &trackedFlag, ldreason.EvaluationReason{}, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.isExperiment, IsExperiment(c.flag, c.reason))
+			assert.Equal(t, c.isExperiment, ShouldIncludeReason(c.flag, c.reason, false))
+			assert.True(t, ShouldIncludeReason(c.flag, c.reason, true))
+		})
+	}
+}
+
+func TestShouldIncludeReasonMatchesFeatureEvents(t *testing.T) {
+	rule := ldbuilders.NewRuleBuilder().ID("rule").
+		Clauses(ldbuilders.Clause("key", ldmodel.OperatorIn, ldvalue.String(evalTestUser.Key())))
+	base := func() *ldbuilders.FlagBuilder {
+		return ldbuilders.NewFlagBuilder(evalFlagKey).Version(1).On(true).
+			Variations(offValue, onValue).OffVariation(0).FallthroughVariation(1)
+	}
+	flags := []ldmodel.FeatureFlag{
+		base().On(false).Build(),
+		base().AddTarget(1, evalTestUser.Key()).Build(),
+		base().AddPrerequisite("no-such-flag", 1).Build(),
+		base().OffVariation(5).On(false).Build(),
+		base().Build(),
+		base().TrackEventsFallthrough(true).Build(),
+		base().Fallthrough(ldbuilders.Experiment(ldvalue.OptionalInt{}, ldbuilders.Bucket(1, 100000))).Build(),
+		base().Fallthrough(ldbuilders.Experiment(ldvalue.OptionalInt{}, ldbuilders.BucketUntracked(1, 100000))).Build(),
+		base().AddRule(rule.Variation(1)).Build(),
+		base().AddRule(rule.Variation(1).TrackEvents(true)).Build(),
+		base().AddRule(rule.VariationOrRollout(
+			ldbuilders.Experiment(ldvalue.OptionalInt{}, ldbuilders.Bucket(1, 100000)))).Build(),
+	}
+
+	for i, flag := range flags {
+		flag := flag
+		for _, explicitlyRequested := range []bool{false, true} {
+			t.Run(fmt.Sprintf("flag %d, explicitly requested: %t", i, explicitlyRequested), func(t *testing.T) {
+				withClientEvalTestParams(func(p clientEvalTestParams) {
+					p.data.UsePreconfiguredFlag(flag)
+					_, detail, _ := p.client.StringVariationDetail(evalFlagKey, evalTestUser, "default")
+					if !explicitlyRequested {
+						p.events.Events = nil
+						_, _ = p.client.StringVariation(evalFlagKey, evalTestUser, "default")
+					}
+
+					var e ldevents.EvaluationData
+					for _, event := range p.events.Events {
+						if ed, ok := event.(ldevents.EvaluationData); ok && ed.Key == evalFlagKey {
+							e = ed
+						}
+					}
+					require.Equal(t, evalFlagKey, e.Key)
+					assert.Equal(t, ShouldIncludeReason(&flag, detail.Reason, explicitlyRequested),
+						e.Reason.GetKind() != "", "reason: %s", detail.Reason)
+					assert.Equal(t, IsExperiment(&flag, detail.Reason) || flag.TrackEvents, e.RequireFullEvent)
+				})
+			})
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package ldclient
 
 import (
+	gocontext "context"
+
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldmigration"
 	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
@@ -9,6 +11,7 @@ import (
 	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 )
@@ -56,6 +59,7 @@ func getEventProcessorFactory(config Config) subsystems.ComponentConfigurer[ldev
 // CONTRIBUTING.md for performance issues with closures.
 type eventsScope struct {
 	disabled                  bool
+	summaryOnly               bool // the flag's own event can't be a full event, as for a repeated conversion
 	factory                   ldevents.EventFactory
 	prerequisiteEventRecorder ldeval.PrerequisiteFlagEventRecorder
 }
@@ -69,7 +73,7 @@ func newEventsScope(client *LDClient, withReasons bool) eventsScope {
 	return eventsScope{
 		factory: factory,
 		prerequisiteEventRecorder: func(params ldeval.PrerequisiteFlagEvent) {
-			client.eventProcessor.RecordEvaluation(factory.NewEvaluationData(
+			client.recordEvaluationEvent(factory.NewEvaluationData(
 				ldevents.FlagEventProperties{
 					Key:                  params.PrerequisiteFlag.Key,
 					Version:              params.PrerequisiteFlag.Version,
@@ -83,11 +87,47 @@ func newEventsScope(client *LDClient, withReasons bool) eventsScope {
 				params.TargetFlagKey,
 				params.PrerequisiteFlag.SamplingRatio,
 				params.ExcludeFromSummaries,
-			))
+			), params.Context)
 		},
 	}
 }
 
+// analyticsConsentFor returns the decision of the analytics consent function that was configured with
+// ldcomponents.EventProcessorBuilder.AnalyticsConsent, if any. The event processor can't see the context
+// of an event once it has been recorded, so LDClient has to ask first.
+func (client *LDClient) analyticsConsentFor(context ldcontext.Context) internal.AnalyticsConsent {
+	if client.analyticsConsent == nil {
+		return internal.AnalyticsConsentGiven
+	}
+	return client.analyticsConsent.AnalyticsConsent(context)
+}
+
+// nonConsentingMigrationContext replaces the context in a migration op event that MigrationOpTracker.Build
+// produces for a context that has not consented, so that LDClient.TrackMigrationOp can recognize it; the
+// event data has no way to read the real context.
+var nonConsentingMigrationContext = ldevents.Context( //nolint:gochecknoglobals
+	internal.NonConsentingPlaceholder(ldcontext.New(internal.NonConsentingContextKey)))
+
+// recordEvaluationEvent records an evaluation event, subject to analytics consent for the context. If the
+// context has not consented but can still be summarized, the event is recorded for a placeholder context
+// instead, and it can only go into the summary. It returns true if a full feature event was recorded.
+func (client *LDClient) recordEvaluationEvent(eval ldevents.EvaluationData, context ldcontext.Context) bool {
+	switch client.analyticsConsentFor(context) {
+	case internal.AnalyticsConsentGiven:
+		client.eventProcessor.RecordEvaluation(eval)
+		return eval.RequireFullEvent
+	case internal.AnalyticsConsentSummaryOnly:
+		if eval.ExcludeFromSummaries {
+			return false
+		}
+		eval.Context = ldevents.Context(internal.NonConsentingPlaceholder(context))
+		eval.RequireFullEvent = false
+		eval.DebugEventsUntilDate = 0
+		client.eventProcessor.RecordEvaluation(eval)
+	}
+	return false
+}
+
 // This implementation of interfaces.LDClientInterface delegates all client operations to the
 // underlying LDClient, but suppresses the generation of analytics events.
 type clientEventsDisabledDecorator struct {
@@ -104,13 +144,31 @@ func (c *clientEventsDisabledDecorator) BoolVariation(
 	context ldcontext.Context,
 	defaultVal bool,
 ) (bool, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Bool(defaultVal), true, c.scope)
+	return c.BoolVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) BoolVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal bool,
+) (bool, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Bool(defaultVal), true, c.scope)
 	return detail.Value.BoolValue(), err
 }
 
 func (c *clientEventsDisabledDecorator) BoolVariationDetail(key string, context ldcontext.Context, defaultVal bool) (
 	bool, ldreason.EvaluationDetail, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Bool(defaultVal), true, c.scope)
+	return c.BoolVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) BoolVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal bool,
+) (bool, ldreason.EvaluationDetail, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Bool(defaultVal), true, c.scope)
 	return detail.Value.BoolValue(), detail, err
 }
 
@@ -119,19 +177,46 @@ func (c *clientEventsDisabledDecorator) IntVariation(
 	context ldcontext.Context,
 	defaultVal int,
 ) (int, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Int(defaultVal), true, c.scope)
+	return c.IntVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) IntVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal int,
+) (int, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Int(defaultVal), true, c.scope)
 	return detail.Value.IntValue(), err
 }
 
 func (c *clientEventsDisabledDecorator) IntVariationDetail(key string, context ldcontext.Context, defaultVal int) (
 	int, ldreason.EvaluationDetail, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Int(defaultVal), true, c.scope)
+	return c.IntVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) IntVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal int,
+) (int, ldreason.EvaluationDetail, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Int(defaultVal), true, c.scope)
 	return detail.Value.IntValue(), detail, err
 }
 
 func (c *clientEventsDisabledDecorator) Float64Variation(key string, context ldcontext.Context, defaultVal float64) (
 	float64, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Float64(defaultVal), true, c.scope)
+	return c.Float64VariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) Float64VariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal float64,
+) (float64, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Float64(defaultVal), true, c.scope)
 	return detail.Value.Float64Value(), err
 }
 
@@ -141,13 +226,31 @@ func (c *clientEventsDisabledDecorator) Float64VariationDetail(
 	defaultVal float64,
 ) (
 	float64, ldreason.EvaluationDetail, error) {
-	detail, err := c.client.variation(key, context, ldvalue.Float64(defaultVal), true, c.scope)
+	return c.Float64VariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) Float64VariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal float64,
+) (float64, ldreason.EvaluationDetail, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.Float64(defaultVal), true, c.scope)
 	return detail.Value.Float64Value(), detail, err
 }
 
 func (c *clientEventsDisabledDecorator) StringVariation(key string, context ldcontext.Context, defaultVal string) (
 	string, error) {
-	detail, err := c.client.variation(key, context, ldvalue.String(defaultVal), true, c.scope)
+	return c.StringVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) StringVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal string,
+) (string, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.String(defaultVal), true, c.scope)
 	return detail.Value.StringValue(), err
 }
 
@@ -157,7 +260,16 @@ func (c *clientEventsDisabledDecorator) StringVariationDetail(
 	defaultVal string,
 ) (
 	string, ldreason.EvaluationDetail, error) {
-	detail, err := c.client.variation(key, context, ldvalue.String(defaultVal), true, c.scope)
+	return c.StringVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) StringVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal string,
+) (string, ldreason.EvaluationDetail, error) {
+	detail, err := c.client.variation(ctx, key, context, ldvalue.String(defaultVal), true, c.scope)
 	return detail.Value.StringValue(), detail, err
 }
 
@@ -169,7 +281,16 @@ func (c *clientEventsDisabledDecorator) MigrationVariation(
 
 func (c *clientEventsDisabledDecorator) JSONVariation(key string, context ldcontext.Context, defaultVal ldvalue.Value) (
 	ldvalue.Value, error) {
-	detail, err := c.client.variation(key, context, defaultVal, true, c.scope)
+	return c.JSONVariationCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) JSONVariationCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+) (ldvalue.Value, error) {
+	detail, err := c.client.variation(ctx, key, context, defaultVal, true, c.scope)
 	return detail.Value, err
 }
 
@@ -179,7 +300,16 @@ func (c *clientEventsDisabledDecorator) JSONVariationDetail(
 	defaultVal ldvalue.Value,
 ) (
 	ldvalue.Value, ldreason.EvaluationDetail, error) {
-	detail, err := c.client.variation(key, context, defaultVal, true, c.scope)
+	return c.JSONVariationDetailCtx(gocontext.TODO(), key, context, defaultVal)
+}
+
+func (c *clientEventsDisabledDecorator) JSONVariationDetailCtx(
+	ctx gocontext.Context,
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+) (ldvalue.Value, ldreason.EvaluationDetail, error) {
+	detail, err := c.client.variation(ctx, key, context, defaultVal, true, c.scope)
 	return detail.Value, detail, err
 }
 
@@ -191,6 +321,15 @@ func (c *clientEventsDisabledDecorator) AllFlagsState(
 	return c.client.AllFlagsState(context, options...)
 }
 
+func (c *clientEventsDisabledDecorator) EvaluateAll(
+	ctx gocontext.Context,
+	context ldcontext.Context,
+	ch chan<- interfaces.FlagEvaluationUpdate,
+) error {
+	// EvaluateAll never generates events anyway, so nothing is different here
+	return c.client.EvaluateAll(ctx, context, ch)
+}
+
 func (c *clientEventsDisabledDecorator) Identify(context ldcontext.Context) error {
 	return nil
 }
@@ -207,11 +346,20 @@ func (c *clientEventsDisabledDecorator) TrackData(
 	return nil
 }
 
+func (c *clientEventsDisabledDecorator) TrackPageView(context ldcontext.Context, url, referrer string) error {
+	return nil
+}
+
 func (c *clientEventsDisabledDecorator) TrackMetric(eventName string, context ldcontext.Context, metricValue float64,
 	data ldvalue.Value) error {
 	return nil
 }
 
+func (c *clientEventsDisabledDecorator) TrackConversion(eventName string, context ldcontext.Context, flagKey string,
+	metricValue float64) error {
+	return nil
+}
+
 func (c *clientEventsDisabledDecorator) TrackMigrationOp(event ldevents.MigrationOpEventData) error {
 	return nil
 }
@@ -103,6 +103,26 @@ func TestTrackDataSendsCustomEventWithData(t *testing.T) {
 	assert.False(t, e.HasMetric)
 }
 
+func TestTrackPageViewSendsCustomEventWithURLAndReferrer(t *testing.T) {
+	client := makeTestClient()
+	defer client.Close()
+
+	user := lduser.NewUser("userKey")
+	require.NoError(t, client.TrackPageView(user, "https://example.com/a", "https://example.com/"))
+	require.NoError(t, client.TrackPageView(user, "https://example.com/b", ""))
+
+	events := client.eventProcessor.(*mocks.CapturingEventProcessor).Events
+	require.Equal(t, 2, len(events))
+	e0 := events[0].(ldevents.CustomEventData)
+	assert.Equal(t, ldevents.Context(user), e0.Context)
+	assert.Equal(t, "pageView", e0.Key)
+	assert.Equal(t, ldvalue.ObjectBuild().SetString("url", "https://example.com/a").
+		SetString("referrer", "https://example.com/").Build(), e0.Data)
+	assert.False(t, e0.HasMetric)
+	e1 := events[1].(ldevents.CustomEventData)
+	assert.Equal(t, ldvalue.ObjectBuild().SetString("url", "https://example.com/b").Build(), e1.Data)
+}
+
 func TestTrackMetricSendsCustomEventWithMetricAndData(t *testing.T) {
 	client := makeTestClient()
 	defer client.Close()
@@ -209,6 +229,7 @@ func TestWithEventsDisabledDecorator(t *testing.T) {
 			checkEvents(func() { ci.TrackEvent("eventkey", user) })
 			checkEvents(func() { ci.TrackData("eventkey", user, ldvalue.Bool(true)) })
 			checkEvents(func() { ci.TrackMetric("eventkey", user, 1.5, ldvalue.Null()) })
+			checkEvents(func() { ci.TrackPageView(user, "https://example.com/", "") })
 
 			state := ci.AllFlagsState(user)
 			assert.True(t, state.IsValid())
@@ -316,3 +337,19 @@ func makeTestClientWithEventSender(s ldevents.EventSender) *LDClient {
 		c.Events = mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: ep}
 	})
 }
+
+func TestNoOpEventsDoesNotDisableEventGeneration(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.Events = ldcomponents.NoOpEvents()
+		c.Logging = ldcomponents.Logging().Loggers(mockLog.Loggers)
+	})
+	defer client.Close()
+
+	assert.False(t, client.eventsDefault.disabled)
+	assert.NoError(t, client.Identify(lduser.NewUser("userKey")))
+	assert.NoError(t, client.TrackEvent("eventKey", lduser.NewUser("userKey")))
+	client.Flush()
+
+	assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+}
@@ -0,0 +1,117 @@
+package ldclient
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// Errors returned by [LDClient.GetRawFlagConfig] and [LDClient.GetRawSegmentConfig]
+var (
+	// ErrFlagNotFound means that the data store has no flag with the specified key.
+	ErrFlagNotFound = errors.New("feature flag not found")
+
+	// ErrFlagDeleted means that the flag was deleted; the data store only has a placeholder for it.
+	ErrFlagDeleted = errors.New("feature flag has been deleted")
+
+	// ErrSegmentNotFound means that the data store has no segment with the specified key.
+	ErrSegmentNotFound = errors.New("segment not found")
+
+	// ErrSegmentDeleted means that the segment was deleted; the data store only has a placeholder for it.
+	ErrSegmentDeleted = errors.New("segment has been deleted")
+)
+
+// FlagInventoryItem describes the configuration of a feature flag, as returned by
+// [LDClient.GetFlagInventory].
+type FlagInventoryItem struct {
+	// Key is the flag key.
+	Key string
+	// Version is the flag's version, which increases every time the flag is changed.
+	Version int
+	// On is true if targeting is turned on for the flag.
+	On bool
+	// VariationCount is the number of variations the flag has.
+	VariationCount int
+	// RuleCount is the number of targeting rules the flag has.
+	RuleCount int
+	// PrerequisiteCount is the number of prerequisite flags the flag has.
+	PrerequisiteCount int
+	// HasTargets is true if the flag targets any individual contexts by key.
+	HasTargets bool
+}
+
+// GetFlagInventory returns a summary of every feature flag that the SDK currently has, sorted by key.
+//
+// This is meant for operational and compliance tools that need to know what flags exist and how they are
+// configured, without evaluating them for any particular context. It reads the flags from the data store
+// with a single query; it does not evaluate any flags or generate any analytics events. Deleted flags are
+// not included.
+//
+// It returns an error only if the data store could not be queried.
+func (client *LDClient) GetFlagInventory() ([]FlagInventoryItem, error) {
+	items, err := client.store.GetAll(datakinds.Features)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]FlagInventoryItem, 0, len(items))
+	for _, item := range items {
+		flag, ok := item.Item.Item.(*ldmodel.FeatureFlag)
+		if !ok {
+			continue
+		}
+		ret = append(ret, FlagInventoryItem{
+			Key:               item.Key,
+			Version:           flag.Version,
+			On:                flag.On,
+			VariationCount:    len(flag.Variations),
+			RuleCount:         len(flag.Rules),
+			PrerequisiteCount: len(flag.Prerequisites),
+			HasTargets:        len(flag.Targets) > 0 || len(flag.ContextTargets) > 0,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Key < ret[j].Key })
+	return ret, nil
+}
+
+// GetRawFlagConfig returns the JSON representation of a feature flag's configuration, as the SDK received
+// it. Properties that this version of the SDK does not recognize are not included.
+//
+// This is meant for debugging tools that need to see the whole flag, rather than the result of evaluating
+// it. It does not evaluate the flag or generate any analytics events.
+//
+// It returns [ErrFlagNotFound] if there is no such flag, [ErrFlagDeleted] if the flag has been deleted, or
+// an error from the data store if the store could not be queried.
+func (client *LDClient) GetRawFlagConfig(flagKey string) ([]byte, error) {
+	return client.getRawItemConfig(datakinds.Features, flagKey, ErrFlagNotFound, ErrFlagDeleted)
+}
+
+// GetRawSegmentConfig returns the JSON representation of a segment's configuration, as the SDK received
+// it. This is the same as [LDClient.GetRawFlagConfig], but for segments. For a Big Segment, the result
+// describes the segment itself, such as its rules, but not its members.
+//
+// It returns [ErrSegmentNotFound] if there is no such segment, [ErrSegmentDeleted] if the segment has been
+// deleted, or an error from the data store if the store could not be queried.
+func (client *LDClient) GetRawSegmentConfig(segmentKey string) ([]byte, error) {
+	return client.getRawItemConfig(datakinds.Segments, segmentKey, ErrSegmentNotFound, ErrSegmentDeleted)
+}
+
+func (client *LDClient) getRawItemConfig(
+	kind ldstoretypes.DataKind,
+	key string,
+	notFoundErr, deletedErr error,
+) ([]byte, error) {
+	item, err := client.store.Get(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	if item.Item == nil {
+		if item.Version < 0 {
+			return nil, notFoundErr
+		}
+		return nil, deletedErr
+	}
+	return kind.Serialize(item), nil
+}
@@ -0,0 +1,161 @@
+package ldclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlagInventory(t *testing.T) {
+	flag1 := ldbuilders.NewFlagBuilder("key1").Version(100).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+		AddPrerequisite("key2", 1).
+		AddRule(ldbuilders.NewRuleBuilder().Variation(1).Clauses(ldbuilders.SegmentMatchClause("segment1"))).
+		AddRule(ldbuilders.NewRuleBuilder().Variation(0).Clauses(ldbuilders.SegmentMatchClause("segment2"))).
+		Build()
+	flag2 := ldbuilders.NewFlagBuilder("key2").Version(200).
+		Variations(ldvalue.String("a"), ldvalue.String("b"), ldvalue.String("c")).
+		AddContextTarget("org", 1, "org-key").
+		Build()
+	flag3 := ldbuilders.NewFlagBuilder("key3").Version(300).On(true).
+		Variations(ldvalue.Int(1)).
+		AddTarget(0, "user-key").
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag3)
+		p.data.UsePreconfiguredFlag(flag1)
+		p.data.UsePreconfiguredFlag(flag2)
+		_, _ = p.store.Upsert(datakinds.Features, "key0", ldstoretypes.ItemDescriptor{Version: 400, Item: nil})
+
+		inventory, err := p.client.GetFlagInventory()
+		require.NoError(t, err)
+
+		assert.Equal(t, []FlagInventoryItem{
+			{Key: "key1", Version: 100, On: true, VariationCount: 2, RuleCount: 2, PrerequisiteCount: 1},
+			{Key: "key2", Version: 200, VariationCount: 3, HasTargets: true},
+			{Key: "key3", Version: 300, On: true, VariationCount: 1, HasTargets: true},
+		}, inventory)
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestGetFlagInventoryWithNoFlags(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		inventory, err := p.client.GetFlagInventory()
+		require.NoError(t, err)
+		assert.Len(t, inventory, 0)
+	})
+}
+
+func TestGetFlagInventoryReturnsErrorIfStoreReturnsError(t *testing.T) {
+	myError := errors.New("sorry")
+	store := mocks.NewCapturingDataStore(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+	_ = store.Init(nil)
+	store.SetFakeError(myError)
+
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataStore = mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store}
+	})
+	defer client.Close()
+
+	inventory, err := client.GetFlagInventory()
+	assert.Equal(t, myError, err)
+	assert.Nil(t, inventory)
+}
+
+func TestGetRawFlagConfig(t *testing.T) {
+	flagJSON := `{"key": "flag", "version": 100, "on": true, "variations": [false, true], "fallthrough": {"variation": 1},
+		"futureProperty": {"a": 1}}`
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		item, err := datakinds.Features.Deserialize([]byte(flagJSON))
+		require.NoError(t, err)
+		_, _ = p.store.Upsert(datakinds.Features, "flag", item)
+
+		raw, err := p.client.GetRawFlagConfig("flag")
+		require.NoError(t, err)
+
+		value := ldvalue.Parse(raw)
+		assert.Equal(t, "flag", value.GetByKey("key").StringValue())
+		assert.Equal(t, 100, value.GetByKey("version").IntValue())
+		assert.Equal(t, ldvalue.ArrayOf(ldvalue.Bool(false), ldvalue.Bool(true)), value.GetByKey("variations"))
+		assert.Equal(t, ldvalue.Null(), value.GetByKey("futureProperty"))
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestGetRawFlagConfigReturnsErrorForUnknownOrDeletedFlag(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		_, _ = p.store.Upsert(datakinds.Features, "deleted-flag", ldstoretypes.ItemDescriptor{Version: 400, Item: nil})
+
+		raw, err := p.client.GetRawFlagConfig("unknown-flag")
+		assert.Equal(t, ErrFlagNotFound, err)
+		assert.Nil(t, raw)
+
+		raw, err = p.client.GetRawFlagConfig("deleted-flag")
+		assert.Equal(t, ErrFlagDeleted, err)
+		assert.Nil(t, raw)
+	})
+}
+
+func TestGetRawFlagConfigReturnsErrorIfStoreReturnsError(t *testing.T) {
+	myError := errors.New("sorry")
+	store := mocks.NewCapturingDataStore(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+	_ = store.Init(nil)
+	store.SetFakeError(myError)
+
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataStore = mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store}
+	})
+	defer client.Close()
+
+	raw, err := client.GetRawFlagConfig("flag")
+	assert.Equal(t, myError, err)
+	assert.Nil(t, raw)
+}
+
+func TestGetRawSegmentConfig(t *testing.T) {
+	segmentJSON := `{"key": "segment", "version": 100, "included": ["a"], "unbounded": true, "generation": 2,
+		"futureProperty": {"a": 1}}`
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		item, err := datakinds.Segments.Deserialize([]byte(segmentJSON))
+		require.NoError(t, err)
+		_, _ = p.store.Upsert(datakinds.Segments, "segment", item)
+
+		raw, err := p.client.GetRawSegmentConfig("segment")
+		require.NoError(t, err)
+
+		value := ldvalue.Parse(raw)
+		assert.Equal(t, "segment", value.GetByKey("key").StringValue())
+		assert.Equal(t, 100, value.GetByKey("version").IntValue())
+		assert.Equal(t, ldvalue.ArrayOf(ldvalue.String("a")), value.GetByKey("included"))
+		assert.True(t, value.GetByKey("unbounded").BoolValue())
+		assert.Equal(t, 2, value.GetByKey("generation").IntValue())
+		assert.Equal(t, ldvalue.Null(), value.GetByKey("futureProperty"))
+	})
+}
+
+func TestGetRawSegmentConfigReturnsErrorForUnknownOrDeletedSegment(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		_, _ = p.store.Upsert(datakinds.Segments, "deleted-segment", ldstoretypes.ItemDescriptor{Version: 400, Item: nil})
+
+		raw, err := p.client.GetRawSegmentConfig("unknown-segment")
+		assert.Equal(t, ErrSegmentNotFound, err)
+		assert.Nil(t, raw)
+
+		raw, err = p.client.GetRawSegmentConfig("deleted-segment")
+		assert.Equal(t, ErrSegmentDeleted, err)
+		assert.Nil(t, raw)
+	})
+}
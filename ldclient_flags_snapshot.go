@@ -0,0 +1,154 @@
+package ldclient
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// FlagsSnapshot is an immutable copy of all feature flags and segments, as returned by
+// [LDClient.NewFlagsSnapshot]. It can evaluate flags for any number of contexts without querying the
+// data store again.
+//
+// A FlagsSnapshot never changes after it is created; use [FlagsSnapshot.IsStale] to find out whether the
+// client has received flag changes since then. It is safe for concurrent use.
+type FlagsSnapshot struct {
+	client     *LDClient
+	flags      []ldstoretypes.KeyedItemDescriptor
+	provider   flagsSnapshotDataProvider
+	evaluator  ldeval.Evaluator
+	generation uint64
+}
+
+type flagsSnapshotDataProvider struct {
+	flags    map[string]*ldmodel.FeatureFlag
+	segments map[string]*ldmodel.Segment
+}
+
+// flagsSnapshotTracker counts the flag changes that the client has received, so that a FlagsSnapshot can
+// tell whether any happened after it was created. It starts listening when the first snapshot is created.
+type flagsSnapshotTracker struct {
+	generation atomic.Uint64
+	startOnce  sync.Once
+}
+
+// NewFlagsSnapshot reads all feature flags and segments from the data store, and returns a [FlagsSnapshot]
+// that evaluates them without any further data store queries.
+//
+// This is meant for applications that evaluate flags for many contexts in a batch, such as rendering pages
+// for a list of users: calling [LDClient.AllFlagsState] for each context would query the data store each
+// time. The snapshot does not see any flag changes that the client receives after it was created; the
+// application can check [FlagsSnapshot.IsStale] to decide when to create a new one. Big Segment
+// memberships are not part of the snapshot; they are still queried, and cached, as usual.
+//
+// It returns an error if the client is offline, if it has no flag data, or if the data store could not be
+// queried, which are the same conditions in which AllFlagsState returns an invalid state.
+func (client *LDClient) NewFlagsSnapshot() (*FlagsSnapshot, error) {
+	if client.IsOffline() && !client.offlineWithStore {
+		return nil, errors.New("cannot create a flags snapshot in offline mode")
+	}
+	if !client.Initialized() {
+		if !client.store.IsInitialized() {
+			return nil, ErrClientNotInitialized
+		}
+		client.loggers.Warn("Called NewFlagsSnapshot before client initialization; using last known values from data store")
+	}
+
+	if client.dataSourceEvaluationObserver != nil {
+		client.dataSourceEvaluationObserver.NotifyEvaluation()
+	}
+
+	// Get the generation before reading the data, so that a change received in between makes the snapshot
+	// stale rather than being missed.
+	generation := client.flagsSnapshotTracker.start(client.flagTracker)
+	flagItems, err := client.store.GetAll(datakinds.Features)
+	if err != nil {
+		return nil, err
+	}
+	segmentItems, err := client.store.GetAll(datakinds.Segments)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FlagsSnapshot{
+		client: client,
+		flags:  make([]ldstoretypes.KeyedItemDescriptor, 0, len(flagItems)),
+		provider: flagsSnapshotDataProvider{
+			flags:    make(map[string]*ldmodel.FeatureFlag, len(flagItems)),
+			segments: make(map[string]*ldmodel.Segment, len(segmentItems)),
+		},
+		generation: generation,
+	}
+	for _, item := range flagItems {
+		if flag, ok := item.Item.Item.(*ldmodel.FeatureFlag); ok {
+			s.flags = append(s.flags, item)
+			s.provider.flags[item.Key] = flag
+		}
+	}
+	for _, item := range segmentItems {
+		if segment, ok := item.Item.Item.(*ldmodel.Segment); ok {
+			s.provider.segments[item.Key] = segment
+		}
+	}
+	s.evaluator = ldeval.NewEvaluatorWithOptions(s.provider, client.evaluatorOptions()...)
+	return s, nil
+}
+
+// AllFlagsState is the same as [LDClient.AllFlagsState], but uses the flag data in the snapshot. The
+// result is always valid.
+func (s *FlagsSnapshot) AllFlagsState(context ldcontext.Context, options ...flagstate.Option) flagstate.AllFlags {
+	return allFlagsStateFromItems(s.evaluator, s.flags, context, options...)
+}
+
+// Evaluate evaluates a single flag for a context using the flag data in the snapshot. If the flag does not
+// exist in the snapshot, the result has a null value and the error kind EvalErrorFlagNotFound.
+//
+// Like AllFlagsState, this method does not generate analytics events.
+func (s *FlagsSnapshot) Evaluate(key string, context ldcontext.Context) ldreason.EvaluationDetail {
+	flag := s.provider.flags[key]
+	if flag == nil {
+		return ldreason.NewEvaluationDetailForError(ldreason.EvalErrorFlagNotFound, ldvalue.Null())
+	}
+	return s.evaluator.Evaluate(flag, context, nil).Detail
+}
+
+// IsStale returns true if the client has received a change to any flag or segment since the snapshot was
+// created.
+//
+// Changes are detected with the same mechanism as [interfaces.FlagTracker], which receives them
+// asynchronously, so IsStale may still return false for a short time after a change has been stored.
+func (s *FlagsSnapshot) IsStale() bool {
+	return s.client.flagsSnapshotTracker.generation.Load() != s.generation
+}
+
+func (d flagsSnapshotDataProvider) GetFeatureFlag(key string) *ldmodel.FeatureFlag {
+	return d.flags[key]
+}
+
+func (d flagsSnapshotDataProvider) GetSegment(key string) *ldmodel.Segment {
+	return d.segments[key]
+}
+
+// start makes sure that the tracker is listening for flag changes, and returns the current generation. The
+// goroutine exits when the client closes the flag change broadcaster.
+func (t *flagsSnapshotTracker) start(flagTracker interfaces.FlagTracker) uint64 {
+	t.startOnce.Do(func() {
+		flagCh := flagTracker.AddFlagChangeListener()
+		go func() {
+			for range flagCh {
+				t.generation.Add(1)
+			}
+		}()
+	})
+	return t.generation.Load()
+}
@@ -0,0 +1,174 @@
+package ldclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagsSnapshotAllFlagsStateIsSameAsClientAllFlagsState(t *testing.T) {
+	flag1 := ldbuilders.NewFlagBuilder("key1").Version(100).OffVariation(0).
+		Variations(ldvalue.String("value1")).Build()
+	flag2 := ldbuilders.NewFlagBuilder("key2").Version(200).On(true).FallthroughVariation(1).
+		Variations(ldvalue.String("x"), ldvalue.String("value2")).
+		AddPrerequisite("key1", 0).TrackEventsFallthrough(true).Build()
+	flag3 := ldbuilders.NewFlagBuilder("key3").Version(300).OffVariation(0).
+		Variations(ldvalue.String("value3")).ClientSideUsingEnvironmentID(true).Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredFlag(flag1)
+		p.data.UsePreconfiguredFlag(flag2)
+		p.data.UsePreconfiguredFlag(flag3)
+
+		snapshot, err := p.client.NewFlagsSnapshot()
+		require.NoError(t, err)
+
+		user := lduser.NewUser("userkey")
+		for _, options := range [][]flagstate.Option{
+			nil,
+			{flagstate.OptionWithReasons()},
+			{flagstate.OptionClientSideOnly()},
+		} {
+			state := snapshot.AllFlagsState(user, options...)
+			assert.True(t, state.IsValid())
+			assert.Equal(t, p.client.AllFlagsState(user, options...), state)
+		}
+	})
+}
+
+func TestFlagsSnapshotEvaluate(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment").Version(1).Included("userkey").Build()
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).On(true).
+		Variations(ldvalue.String("a"), ldvalue.String("b")).FallthroughVariation(0).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule").Variation(1).
+			Clauses(ldbuilders.SegmentMatchClause(segment.Key))).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredSegment(segment)
+		p.data.UsePreconfiguredFlag(flag)
+
+		snapshot, err := p.client.NewFlagsSnapshot()
+		require.NoError(t, err)
+
+		assert.Equal(t, ldreason.NewEvaluationDetail(ldvalue.String("b"), 1, ldreason.NewEvalReasonRuleMatch(0, "rule")),
+			snapshot.Evaluate(flag.Key, lduser.NewUser("userkey")))
+		assert.Equal(t, ldreason.NewEvaluationDetail(ldvalue.String("a"), 0, ldreason.NewEvalReasonFallthrough()),
+			snapshot.Evaluate(flag.Key, lduser.NewUser("otherkey")))
+		assert.Equal(t, ldreason.NewEvaluationDetailForError(ldreason.EvalErrorFlagNotFound, ldvalue.Null()),
+			snapshot.Evaluate("unknown-flag", lduser.NewUser("userkey")))
+
+		assert.Len(t, p.events.Events, 0)
+	})
+}
+
+func TestFlagsSnapshotDoesNotSeeLaterChanges(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment").Version(1).Included("userkey").Build()
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).FallthroughVariation(0).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule").Variation(1).
+			Clauses(ldbuilders.SegmentMatchClause(segment.Key))).
+		Build()
+
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.UsePreconfiguredSegment(segment)
+		p.data.UsePreconfiguredFlag(flag)
+
+		snapshot, err := p.client.NewFlagsSnapshot()
+		require.NoError(t, err)
+		assert.False(t, snapshot.IsStale())
+
+		// Bypass the data source, so that there are no change events
+		unmatchedSegment := ldbuilders.NewSegmentBuilder("segment").Version(2).Build()
+		_, err = p.store.Upsert(datakinds.Segments, segment.Key, sharedtest.SegmentDescriptor(unmatchedSegment))
+		require.NoError(t, err)
+		_, err = p.store.Upsert(datakinds.Features, "new-flag", sharedtest.FlagDescriptor(
+			ldbuilders.NewFlagBuilder("new-flag").Version(1).SingleVariation(ldvalue.Bool(true)).Build()))
+		require.NoError(t, err)
+
+		user := lduser.NewUser("userkey")
+		assert.Equal(t, ldvalue.Bool(true), snapshot.Evaluate(flag.Key, user).Value)
+		assert.Equal(t, ldreason.EvalErrorFlagNotFound, snapshot.Evaluate("new-flag", user).Reason.GetErrorKind())
+		_, found := snapshot.AllFlagsState(user).GetFlag("new-flag")
+		assert.False(t, found)
+
+		value, _ := p.client.BoolVariation(flag.Key, user, false)
+		assert.False(t, value)
+	})
+}
+
+func TestFlagsSnapshotBecomesStaleWhenFlagChanges(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.Update(p.data.Flag("flag1").VariationForAll(true))
+		p.data.Update(p.data.Flag("flag2").VariationForAll(true))
+
+		snapshot1, err := p.client.NewFlagsSnapshot()
+		require.NoError(t, err)
+		assert.False(t, snapshot1.IsStale())
+
+		p.data.Update(p.data.Flag("flag2").VariationForAll(false))
+		assert.Eventually(t, snapshot1.IsStale, time.Second, time.Millisecond*10)
+		assert.Equal(t, ldvalue.Bool(true), snapshot1.Evaluate("flag2", evalTestUser).Value)
+
+		snapshot2, err := p.client.NewFlagsSnapshot()
+		require.NoError(t, err)
+		assert.False(t, snapshot2.IsStale())
+		assert.Equal(t, ldvalue.Bool(false), snapshot2.Evaluate("flag2", evalTestUser).Value)
+	})
+}
+
+func TestNewFlagsSnapshotReturnsErrorInOfflineMode(t *testing.T) {
+	client, _ := MakeCustomClient(testSdkKey, Config{Offline: true}, 0)
+	defer client.Close()
+
+	snapshot, err := client.NewFlagsSnapshot()
+	assert.Error(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestNewFlagsSnapshotReturnsErrorIfClientAndStoreAreNotInitialized(t *testing.T) {
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = mocks.DataSourceThatNeverInitializes()
+	})
+	defer client.Close()
+
+	snapshot, err := client.NewFlagsSnapshot()
+	assert.Equal(t, ErrClientNotInitialized, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestNewFlagsSnapshotUsesStoreIfClientIsNotInitializedButStoreIsInitialized(t *testing.T) {
+	mockLoggers := ldlogtest.NewMockLog()
+	flag := ldbuilders.NewFlagBuilder("flag").SingleVariation(ldvalue.Bool(true)).Build()
+	store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	_ = store.Init(nil)
+	_, _ = store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = mocks.DataSourceThatNeverInitializes()
+		c.DataStore = mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store}
+		c.Logging = ldcomponents.Logging().Loggers(mockLoggers.Loggers)
+	})
+	defer client.Close()
+
+	snapshot, err := client.NewFlagsSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, ldvalue.Bool(true), snapshot.Evaluate(flag.Key, evalTestUser).Value)
+	mockLoggers.AssertMessageMatch(t, true, ldlog.Warn, "before client initialization")
+}
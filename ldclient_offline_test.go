@@ -1,18 +1,30 @@
 package ldclient
 
 import (
+	"encoding/json"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldmonitor"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type clientOfflineTestParams struct {
@@ -75,3 +87,78 @@ func TestClientOfflineMode(t *testing.T) {
 		})
 	})
 }
+
+func TestClientOfflineModeWithPersistentDataStore(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").SingleVariation(ldvalue.Bool(true)).Build()
+
+	withStore := func(t *testing.T, initStore, monitored bool, action func(*LDClient)) {
+		persistentStore := mocks.NewMockPersistentDataStore()
+		if initStore {
+			flagJSON, _ := json.Marshal(flag)
+			_ = persistentStore.Init([]ldstoretypes.SerializedCollection{
+				{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+					{Key: flag.Key, Item: ldstoretypes.SerializedItemDescriptor{Version: flag.Version, SerializedItem: flagJSON}},
+				}},
+			})
+		}
+		handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(200))
+		httphelpers.WithServer(handler, func(server *httptest.Server) {
+			var dataStore subsystems.ComponentConfigurer[subsystems.DataStore] = ldcomponents.PersistentDataStore(
+				mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: persistentStore},
+			)
+			if monitored {
+				dataStore = ldmonitor.DataStoreSizeMonitor(dataStore, 100, nil)
+			}
+			config := Config{
+				Offline:          true,
+				DataStore:        dataStore,
+				Logging:          ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+				ServiceEndpoints: ldcomponents.RelayProxyEndpoints(server.URL),
+			}
+			client, err := MakeCustomClient("sdk_key", config, time.Second)
+			require.NoError(t, err)
+			defer client.Close()
+			action(client)
+			client.Flush()
+		})
+		assert.Len(t, requestsCh, 0)
+	}
+
+	t.Run("uses data from store", func(t *testing.T) {
+		withStore(t, true, false, func(client *LDClient) {
+			assert.True(t, client.IsOffline())
+			assert.True(t, client.Initialized())
+
+			result, err := client.BoolVariation(flag.Key, evalTestUser, false)
+			assert.NoError(t, err)
+			assert.True(t, result)
+
+			state := client.AllFlagsState(evalTestUser)
+			assert.True(t, state.IsValid())
+			assert.Equal(t, ldvalue.Bool(true), state.GetValue(flag.Key))
+
+			require.NoError(t, client.Identify(evalTestUser))
+		})
+	})
+
+	t.Run("uses data from monitored store", func(t *testing.T) {
+		withStore(t, true, true, func(client *LDClient) {
+			assert.True(t, client.Initialized())
+
+			result, err := client.BoolVariation(flag.Key, evalTestUser, false)
+			assert.NoError(t, err)
+			assert.True(t, result)
+			assert.True(t, client.GetStats().DataStoreCacheStatsAvailable)
+		})
+	})
+
+	t.Run("is not initialized if store is empty", func(t *testing.T) {
+		withStore(t, false, false, func(client *LDClient) {
+			assert.False(t, client.Initialized())
+
+			result, err := client.BoolVariation(flag.Key, evalTestUser, false)
+			assert.Equal(t, ErrClientNotInitialized, err)
+			assert.False(t, result)
+		})
+	})
+}
@@ -0,0 +1,144 @@
+package ldclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UpdateSDKKey changes the SDK key that the client uses, without recreating the client. This is meant
+// for rotating SDK keys: flag data, the data store, and pending analytics events are all kept.
+//
+// All subsequent requests to LaunchDarkly use newKey, and if the client has a streaming connection, it
+// reconnects with newKey right away. Until expiryOfOldKey, a request that is rejected with a 401 error
+// is retried once with the previous key, so that the application keeps working if it starts using the
+// new key before LaunchDarkly accepts it. Pass the same expiration time that you chose when rotating the
+// key in LaunchDarkly, or a zero time to stop using the previous key at once. SecureModeHash also uses
+// newKey from now on.
+//
+// This does not bring back a data source that has already stopped due to an invalid key. It returns an
+// error if newKey contains characters that are not allowed in an HTTP header, or if the client was
+// created with [MakeClientFromComponents], in which case the SDK did not create the HTTP clients that the
+// components use. Neither key is logged or included in diagnostic events.
+func (client *LDClient) UpdateSDKKey(newKey string, expiryOfOldKey time.Time) error {
+	if !stringIsValidHTTPHeaderValue(newKey) {
+		return errors.New("SDK key contains invalid characters")
+	}
+	if client.sdkKeys == nil {
+		return errors.New("the SDK key cannot be changed for a client created with MakeClientFromComponents")
+	}
+	client.sdkKeys.update(newKey, expiryOfOldKey)
+	client.loggers.Info("SDK key was updated")
+	if restarter, ok := client.dataSource.(streamRestarter); ok {
+		restarter.RestartStream()
+	}
+	return nil
+}
+
+// streamRestarter is implemented by data sources that can reconnect when the SDK key changes.
+type streamRestarter interface {
+	RestartStream()
+}
+
+// sdkKeyRotator keeps track of the SDK key that the client's HTTP requests should use, so that the key
+// can be changed with LDClient.UpdateSDKKey without recreating the components that make the requests.
+//
+// The components are created with the original key in their Authorization header, and the round tripper
+// returned by wrapHTTPClientFactory replaces it with the current key. Requests whose Authorization header
+// is something else, because the application overrode it with a custom header, are left alone.
+type sdkKeyRotator struct {
+	originalKey  string
+	currentKey   string
+	oldKey       string
+	oldKeyExpiry time.Time
+	lock         sync.RWMutex
+}
+
+func newSDKKeyRotator(sdkKey string) *sdkKeyRotator {
+	return &sdkKeyRotator{originalKey: sdkKey, currentKey: sdkKey}
+}
+
+func (r *sdkKeyRotator) update(newKey string, expiryOfOldKey time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if newKey == r.currentKey {
+		return
+	}
+	r.oldKey, r.oldKeyExpiry = r.currentKey, expiryOfOldKey
+	r.currentKey = newKey
+}
+
+func (r *sdkKeyRotator) current() string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.currentKey
+}
+
+// keys returns the current key, and the old key if it has not yet expired.
+func (r *sdkKeyRotator) keys() (current, fallback string) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.oldKey != "" && time.Now().Before(r.oldKeyExpiry) {
+		return r.currentKey, r.oldKey
+	}
+	return r.currentKey, ""
+}
+
+func (r *sdkKeyRotator) wrapHTTPClientFactory(clientFactory func() *http.Client) func() *http.Client {
+	return func() *http.Client {
+		client := clientFactory()
+		if client == nil {
+			return nil
+		}
+		modifiedClient := *client
+		modifiedClient.Transport = sdkKeyRoundTripper{rotator: r, transport: client.Transport}
+		return &modifiedClient
+	}
+}
+
+type sdkKeyRoundTripper struct {
+	rotator   *sdkKeyRotator
+	transport http.RoundTripper
+}
+
+func (rt sdkKeyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := rt.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if req.Header.Get("Authorization") != rt.rotator.originalKey {
+		return transport.RoundTrip(req)
+	}
+	current, fallback := rt.rotator.keys()
+	if current == rt.rotator.originalKey && fallback == "" {
+		return transport.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given, so we send a copy.
+	modifiedReq := req.Clone(req.Context())
+	modifiedReq.Header.Set("Authorization", current)
+	resp, err := transport.RoundTrip(modifiedReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || fallback == "" || fallback == current {
+		return resp, err
+	}
+
+	// The new key was rejected, possibly because it was enabled in LaunchDarkly after the application
+	// started using it; retry once with the old key while that is still allowed.
+	retryReq := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil // we can't send the same body again
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+	retryReq.Header.Set("Authorization", fallback)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return transport.RoundTrip(retryReq)
+}
@@ -0,0 +1,175 @@
+package ldclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sdkKeyTestTransport struct {
+	statusForKey map[string]int
+	requests     []*http.Request
+	bodies       []string
+}
+
+func (tt *sdkKeyTestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tt.requests = append(tt.requests, req)
+	body := ""
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		body = string(data)
+	}
+	tt.bodies = append(tt.bodies, body)
+	status := tt.statusForKey[req.Header.Get("Authorization")]
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (tt *sdkKeyTestTransport) authorizationHeaders() []string {
+	var ret []string
+	for _, r := range tt.requests {
+		ret = append(ret, r.Header.Get("Authorization"))
+	}
+	return ret
+}
+
+func makeSDKKeyTestClient(rotator *sdkKeyRotator, transport *sdkKeyTestTransport) *http.Client {
+	return rotator.wrapHTTPClientFactory(func() *http.Client { return &http.Client{Transport: transport} })()
+}
+
+func doSDKKeyTestRequest(t *testing.T, client *http.Client, authorization string, body string) *http.Response {
+	req, err := http.NewRequest("POST", "http://fake", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", authorization)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	return resp
+}
+
+func TestSDKKeyRotatorRoundTripper(t *testing.T) {
+	t.Run("original key is sent if the key was not updated", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{}
+		client := makeSDKKeyTestClient(newSDKKeyRotator("old-key"), transport)
+		doSDKKeyTestRequest(t, client, "old-key", "")
+		assert.Equal(t, []string{"old-key"}, transport.authorizationHeaders())
+	})
+
+	t.Run("current key replaces original key", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{}
+		rotator := newSDKKeyRotator("old-key")
+		client := makeSDKKeyTestClient(rotator, transport)
+		rotator.update("new-key", time.Time{})
+		doSDKKeyTestRequest(t, client, "old-key", "")
+		assert.Equal(t, []string{"new-key"}, transport.authorizationHeaders())
+	})
+
+	t.Run("custom authorization header is not replaced", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{}
+		rotator := newSDKKeyRotator("old-key")
+		client := makeSDKKeyTestClient(rotator, transport)
+		rotator.update("new-key", time.Time{})
+		doSDKKeyTestRequest(t, client, "custom", "")
+		assert.Equal(t, []string{"custom"}, transport.authorizationHeaders())
+	})
+
+	t.Run("401 is retried with old key before it expires", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{statusForKey: map[string]int{"new-key": http.StatusUnauthorized}}
+		rotator := newSDKKeyRotator("old-key")
+		client := makeSDKKeyTestClient(rotator, transport)
+		rotator.update("new-key", time.Now().Add(time.Hour))
+		resp := doSDKKeyTestRequest(t, client, "old-key", "payload")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"new-key", "old-key"}, transport.authorizationHeaders())
+		assert.Equal(t, []string{"payload", "payload"}, transport.bodies)
+	})
+
+	t.Run("401 is not retried after old key expires", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{statusForKey: map[string]int{"new-key": http.StatusUnauthorized}}
+		rotator := newSDKKeyRotator("old-key")
+		client := makeSDKKeyTestClient(rotator, transport)
+		rotator.update("new-key", time.Now().Add(-time.Second))
+		resp := doSDKKeyTestRequest(t, client, "old-key", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, []string{"new-key"}, transport.authorizationHeaders())
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		transport := &sdkKeyTestTransport{statusForKey: map[string]int{"new-key": http.StatusServiceUnavailable}}
+		rotator := newSDKKeyRotator("old-key")
+		client := makeSDKKeyTestClient(rotator, transport)
+		rotator.update("new-key", time.Now().Add(time.Hour))
+		resp := doSDKKeyTestRequest(t, client, "old-key", "")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, []string{"new-key"}, transport.authorizationHeaders())
+	})
+}
+
+func TestUpdateSDKKey(t *testing.T) {
+	t.Run("stream reconnects with new key", func(t *testing.T) {
+		server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(&alwaysTrueFlag))
+		defer server.Close()
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+
+		config := Config{
+			Events:           ldcomponents.NoEvents(),
+			Logging:          ldcomponents.Logging().Loggers(mockLog.Loggers),
+			ServiceEndpoints: server.ServiceEndpoints(),
+		}
+		client, err := MakeCustomClient(testSdkKey, config, time.Second*5)
+		require.NoError(t, err)
+		defer client.Close()
+
+		r := <-server.DataRequests()
+		assert.Equal(t, testSdkKey, r.Request.Header.Get("Authorization"))
+		hashBefore := client.SecureModeHash(lduser.NewUser("userkey"))
+
+		require.NoError(t, client.UpdateSDKKey("new-key", time.Time{}))
+
+		select {
+		case r = <-server.DataRequests():
+			assert.Equal(t, "new-key", r.Request.Header.Get("Authorization"))
+		case <-time.After(time.Second * 5):
+			require.Fail(t, "timed out waiting for stream to reconnect")
+		}
+		assert.NotEqual(t, hashBefore, client.SecureModeHash(lduser.NewUser("userkey")))
+		for _, level := range []ldlog.LogLevel{ldlog.Debug, ldlog.Info, ldlog.Warn, ldlog.Error} {
+			for _, message := range mockLog.GetOutput(level) {
+				assert.NotContains(t, message, "new-key")
+				assert.NotContains(t, message, testSdkKey)
+			}
+		}
+	})
+
+	t.Run("key with invalid characters is rejected", func(t *testing.T) {
+		client := makeTestClient()
+		defer client.Close()
+		err := client.UpdateSDKKey("new-key\n", time.Time{})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "new-key")
+		assert.Equal(t, testSdkKey, client.sdkKeys.current())
+	})
+
+	t.Run("not supported for client created from components", func(t *testing.T) {
+		client, err := MakeClientFromComponents(testSdkKey, NewComponentWiring(sharedtest.TestLoggingConfig()),
+			ClientComponents{}, time.Second)
+		require.NoError(t, err)
+		defer client.Close()
+		assert.Error(t, client.UpdateSDKKey("new-key", time.Time{}))
+	})
+}
@@ -0,0 +1,111 @@
+package ldclient
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+)
+
+// ClientStats is a snapshot of statistics about what the client has been doing, returned by
+// [LDClient.GetStats]. It is meant for exporting to a monitoring system; the ldprometheus module uses it,
+// along with the client's status providers, to report Prometheus metrics. The counts are totals since the
+// client was created.
+type ClientStats struct {
+	// Evaluations is the number of flag evaluations done by the variation methods, such as
+	// [LDClient.BoolVariation]. It does not include [LDClient.AllFlagsState] or [LDClient.EvaluateAll].
+	Evaluations int64
+
+	// EvaluationErrors is the number of those evaluations that returned an error, for each kind of error.
+	// Kinds for which there have been no errors are not included.
+	EvaluationErrors map[ldreason.EvalErrorKind]int64
+
+	// EventStatsAvailable is true if the client is using the standard event processor that is created by
+	// [ldcomponents.SendEvents], outside of dry-run mode. Otherwise, the other event statistics are zero.
+	EventStatsAvailable bool
+
+	// QueuedEvents is the number of analytics events that have been recorded since the last payload was
+	// sent to LaunchDarkly. This is an upper bound on the number of events that are waiting in the buffer,
+	// since an evaluation that only goes into the summary does not take up space in it.
+	QueuedEvents int64
+
+	// DroppedEvents is the number of analytics events that were discarded because the buffer was full. The
+	// event processor only reports this in periodic diagnostic events, so it is only updated when one is
+	// sent, and it is always zero if [Config.DiagnosticOptOut] is true.
+	DroppedEvents int64
+
+	// LastSuccessfulDataUpdate is the last time that the data source stored flag data without getting an
+	// error from the data store, or the zero value if that has not happened yet. This is not updated while
+	// the connection is idle, so an old time is only a problem if the data source status is not valid.
+	LastSuccessfulDataUpdate time.Time
+
+	// DataStoreCacheStatsAvailable is true if the client is using a persistent data store with caching
+	// enabled. Otherwise, DataStoreCacheHits and DataStoreCacheMisses are zero.
+	DataStoreCacheStatsAvailable bool
+
+	// DataStoreCacheHits is the number of persistent data store queries that were answered from the cache.
+	DataStoreCacheHits int64
+
+	// DataStoreCacheMisses is the number of persistent data store queries that had to go to the database.
+	DataStoreCacheMisses int64
+
+	// BigSegmentsEnabled is true if a Big Segment store is configured, so that
+	// [LDClient.GetBigSegmentStoreStatusProvider] reports a meaningful status.
+	BigSegmentsEnabled bool
+}
+
+// evaluationStats counts evaluations for LDClient.GetStats. The total is updated on every evaluation, so it
+// is a single atomic counter; errors are rare enough that a lock is fine.
+type evaluationStats struct {
+	count  atomic.Int64
+	errors map[ldreason.EvalErrorKind]int64
+	lock   sync.Mutex
+}
+
+func (s *evaluationStats) record(reason ldreason.EvaluationReason) {
+	s.count.Add(1)
+	if reason.GetKind() != ldreason.EvalReasonError {
+		return
+	}
+	s.lock.Lock()
+	if s.errors == nil {
+		s.errors = make(map[ldreason.EvalErrorKind]int64)
+	}
+	s.errors[reason.GetErrorKind()]++
+	s.lock.Unlock()
+}
+
+func (s *evaluationStats) errorCounts() map[ldreason.EvalErrorKind]int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ret := make(map[ldreason.EvalErrorKind]int64, len(s.errors))
+	for kind, count := range s.errors {
+		ret[kind] = count
+	}
+	return ret
+}
+
+// GetStats returns statistics about the client's evaluations, analytics events, data source, and data
+// store, for exporting to a monitoring system. See [ClientStats] for details. It is cheap enough to call
+// every time the metrics are scraped.
+func (client *LDClient) GetStats() ClientStats {
+	stats := ClientStats{
+		Evaluations:        client.evaluationStats.count.Load(),
+		EvaluationErrors:   client.evaluationStats.errorCounts(),
+		BigSegmentsEnabled: client.bigSegmentStoreWrapper != nil,
+	}
+	if client.eventStats != nil && client.eventStats.IsUsed() {
+		stats.EventStatsAvailable = true
+		stats.QueuedEvents = client.eventStats.QueuedEvents()
+		stats.DroppedEvents = client.eventStats.DroppedEvents()
+	}
+	if client.dataSourceUpdates != nil {
+		stats.LastSuccessfulDataUpdate = client.dataSourceUpdates.GetLastSuccessfulUpdateTime()
+	}
+	if client.dataStoreCacheStats != nil {
+		stats.DataStoreCacheHits, stats.DataStoreCacheMisses, stats.DataStoreCacheStatsAvailable =
+			client.dataStoreCacheStats.GetCacheStats()
+	}
+	return stats
+}
@@ -0,0 +1,171 @@
+package ldclient
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatsCountsEvaluationsAndErrors(t *testing.T) {
+	withClientEvalTestParams(func(p clientEvalTestParams) {
+		p.data.Update(p.data.Flag(evalFlagKey).BooleanFlag())
+
+		assert.Equal(t, int64(0), p.client.GetStats().Evaluations)
+		assert.Len(t, p.client.GetStats().EvaluationErrors, 0)
+
+		_, _ = p.client.BoolVariation(evalFlagKey, evalTestUser, false)
+		_, _, _ = p.client.BoolVariationDetail(evalFlagKey, evalTestUser, false)
+		_, _ = p.client.StringVariation(evalFlagKey, evalTestUser, "")
+		_, _ = p.client.BoolVariation("no-such-flag", evalTestUser, false)
+		_, _ = p.client.BoolVariation("no-such-flag", evalTestUser, false)
+		_ = p.client.AllFlagsState(evalTestUser)
+
+		stats := p.client.GetStats()
+		assert.Equal(t, int64(5), stats.Evaluations)
+		assert.Equal(t, map[ldreason.EvalErrorKind]int64{
+			ldreason.EvalErrorWrongType:    1,
+			ldreason.EvalErrorFlagNotFound: 2,
+		}, stats.EvaluationErrors)
+	})
+}
+
+func TestGetStatsCountsEvaluationsWhenMetricsAreEnabled(t *testing.T) {
+	client, _ := makeTestClientWithEvaluationMetrics(
+		ldcomponents.EvaluationMetrics(newRecordingEvaluationMetricsSink()))
+	defer client.Close()
+
+	_, _ = client.BoolVariation("no-such-flag", evalTestUser, false)
+
+	stats := client.GetStats()
+	assert.Equal(t, int64(1), stats.Evaluations)
+	assert.Equal(t, map[ldreason.EvalErrorKind]int64{ldreason.EvalErrorFlagNotFound: 1}, stats.EvaluationErrors)
+}
+
+func TestGetStatsReportsEventStatsOnlyForStandardEventProcessor(t *testing.T) {
+	t.Run("custom event processor", func(t *testing.T) {
+		client := makeTestClient()
+		defer client.Close()
+		_, _ = client.BoolVariation(evalFlagKey, evalTestUser, false)
+
+		stats := client.GetStats()
+		assert.False(t, stats.EventStatsAvailable)
+		assert.Equal(t, int64(0), stats.QueuedEvents)
+	})
+
+	t.Run("events disabled", func(t *testing.T) {
+		client := makeTestClientWithConfig(func(c *Config) {
+			c.Events = ldcomponents.NoEvents()
+		})
+		defer client.Close()
+
+		assert.False(t, client.GetStats().EventStatsAvailable)
+	})
+
+	t.Run("standard event processor", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			client := makeTestClientWithConfig(func(c *Config) {
+				c.DiagnosticOptOut = true
+				c.Events = ldcomponents.SendEvents().FlushInterval(time.Hour)
+				c.ServiceEndpoints = interfaces.ServiceEndpoints{Events: server.URL}
+			})
+			defer client.Close()
+
+			_ = client.Identify(evalTestUser)
+			_ = client.TrackEvent("event-key", evalTestUser)
+
+			stats := client.GetStats()
+			assert.True(t, stats.EventStatsAvailable)
+			assert.Equal(t, int64(2), stats.QueuedEvents)
+
+			client.Flush()
+			<-requestsCh
+			require.Eventually(t, func() bool { return client.GetStats().QueuedEvents == 0 },
+				time.Second, time.Millisecond*10)
+		})
+	})
+}
+
+func TestGetStatsReportsLastSuccessfulDataUpdate(t *testing.T) {
+	td := ldtestdata.DataSource()
+	before := time.Now()
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = td
+	})
+	defer client.Close()
+
+	initTime := client.GetStats().LastSuccessfulDataUpdate
+	assert.False(t, initTime.Before(before))
+
+	td.Update(td.Flag(evalFlagKey).BooleanFlag())
+	assert.False(t, client.GetStats().LastSuccessfulDataUpdate.Before(initTime))
+}
+
+func TestGetStatsReportsDataStoreCacheStats(t *testing.T) {
+	t.Run("in-memory store", func(t *testing.T) {
+		client := makeTestClient()
+		defer client.Close()
+
+		assert.False(t, client.GetStats().DataStoreCacheStatsAvailable)
+	})
+
+	t.Run("persistent store", func(t *testing.T) {
+		client := makeTestClientWithConfig(func(c *Config) {
+			c.DataSource = ldtestdata.DataSource()
+			c.DataStore = ldcomponents.PersistentDataStore(
+				mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{
+					Instance: mocks.NewMockPersistentDataStore(),
+				},
+			)
+		})
+		defer client.Close()
+
+		_, _ = client.BoolVariation(evalFlagKey, evalTestUser, false)
+		_, _ = client.BoolVariation(evalFlagKey, evalTestUser, false)
+
+		stats := client.GetStats()
+		assert.True(t, stats.DataStoreCacheStatsAvailable)
+		assert.Equal(t, int64(2), stats.DataStoreCacheHits+stats.DataStoreCacheMisses)
+		assert.GreaterOrEqual(t, stats.DataStoreCacheHits, int64(1))
+	})
+}
+
+func TestGetStatsReportsWhetherBigSegmentsAreEnabled(t *testing.T) {
+	client := makeTestClient()
+	defer client.Close()
+	assert.False(t, client.GetStats().BigSegmentsEnabled)
+
+	bsStore := &mocks.MockBigSegmentStore{}
+	bsClient := makeTestClientWithConfig(func(c *Config) {
+		c.BigSegments = ldcomponents.BigSegments(
+			mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: bsStore},
+		)
+	})
+	defer bsClient.Close()
+	assert.True(t, bsClient.GetStats().BigSegmentsEnabled)
+}
+
+func TestGetStatsForOfflineClient(t *testing.T) {
+	client, err := MakeCustomClient(testSdkKey, Config{Offline: true}, 0)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, _ = client.JSONVariation(evalFlagKey, evalTestUser, ldvalue.Null())
+	stats := client.GetStats()
+	assert.Equal(t, int64(1), stats.Evaluations)
+	assert.False(t, stats.EventStatsAvailable)
+}
@@ -7,11 +7,21 @@ import (
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldfiledata"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,19 +32,84 @@ const testSdkKey = "test-sdk-key"
 func TestErrorFromComponentFactoryStopsClientCreation(t *testing.T) {
 	fakeError := errors.New("sorry")
 
-	doTest := func(name string, config Config, expectedError error) {
+	doTest := func(name string, config Config, expectedMessage string, expectedError error) {
 		t.Run(name, func(t *testing.T) {
 			client, err := MakeCustomClient(testSdkKey, config, 0)
 			assert.Nil(t, client)
 			require.Error(t, err)
-			assert.Equal(t, expectedError.Error(), err.Error())
+			assert.Equal(t, expectedMessage, err.Error())
+			if expectedError != nil {
+				assert.ErrorIs(t, err, expectedError)
+			}
 		})
 	}
 
-	doTest("DataSource", Config{DataSource: mocks.ComponentConfigurerThatReturnsError[subsystems.DataSource]{Err: fakeError}}, fakeError)
-	doTest("DataStore", Config{DataStore: mocks.ComponentConfigurerThatReturnsError[subsystems.DataStore]{Err: fakeError}}, fakeError)
-	doTest("Events", Config{Events: mocks.ComponentConfigurerThatReturnsError[ldevents.EventProcessor]{Err: fakeError}}, fakeError)
-	doTest("HTTP", Config{HTTP: ldcomponents.HTTPConfiguration().CACert([]byte{1})}, errors.New("invalid CA certificate data"))
+	doTest("DataSource", Config{DataSource: mocks.ComponentConfigurerThatReturnsError[subsystems.DataSource]{Err: fakeError}},
+		"DataSource: sorry", fakeError)
+	doTest("DataStore", Config{DataStore: mocks.ComponentConfigurerThatReturnsError[subsystems.DataStore]{Err: fakeError}},
+		"DataStore: sorry", fakeError)
+	doTest("Events", Config{Events: mocks.ComponentConfigurerThatReturnsError[ldevents.EventProcessor]{Err: fakeError}},
+		"Events: sorry", fakeError)
+	doTest("HTTP", Config{HTTP: ldcomponents.HTTPConfiguration().CACert([]byte{1})},
+		"HTTP: invalid CA certificate data", nil)
+}
+
+func TestMultipleConfigurationErrorsAreAllReported(t *testing.T) {
+	storeError := errors.New("bad store")
+	eventsError := errors.New("bad events")
+	config := Config{
+		DataStore: mocks.ComponentConfigurerThatReturnsError[subsystems.DataStore]{Err: storeError},
+		Events:    mocks.ComponentConfigurerThatReturnsError[ldevents.EventProcessor]{Err: eventsError},
+		Logging:   ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	client, err := MakeCustomClient("my-bad-key\n", config, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, storeError)
+	assert.ErrorIs(t, err, eventsError)
+	assert.Equal(t, "SDK key contains invalid characters\nDataStore: bad store\nEvents: bad events", err.Error())
+}
+
+type closeTrackingEventProcessor struct {
+	ldevents.EventProcessor
+	closed bool
+}
+
+func (p *closeTrackingEventProcessor) Close() error {
+	p.closed = true
+	return p.EventProcessor.Close()
+}
+
+func TestConfigurationErrorClosesComponentsThatWereBuilt(t *testing.T) {
+	store := &closeTrackingDataStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+	eventProcessor := &closeTrackingEventProcessor{EventProcessor: ldevents.NewNullEventProcessor()}
+	config := Config{
+		DataSource: mocks.ComponentConfigurerThatReturnsError[subsystems.DataSource]{Err: errors.New("sorry")},
+		DataStore:  mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store},
+		Events:     mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: eventProcessor},
+		Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	client, err := MakeCustomClient(testSdkKey, config, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.True(t, store.closed)
+	assert.True(t, eventProcessor.closed)
+}
+
+func TestDataSourceIsNotBuiltIfHTTPConfigurationIsInvalid(t *testing.T) {
+	dataSourceFactory := &mocks.ComponentConfigurerThatCapturesClientContext[subsystems.DataSource]{
+		Configurer: mocks.ComponentConfigurerThatReturnsError[subsystems.DataSource]{Err: errors.New("sorry")},
+	}
+	config := Config{
+		DataSource: dataSourceFactory,
+		HTTP:       ldcomponents.HTTPConfiguration().CACert([]byte{1}),
+		Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}
+	client, err := MakeCustomClient(testSdkKey, config, 0)
+	assert.Nil(t, client)
+	require.Error(t, err)
+	assert.Equal(t, "HTTP: invalid CA certificate data", err.Error())
+	assert.Nil(t, dataSourceFactory.ReceivedClientContext)
 }
 
 func TestSecureModeHash(t *testing.T) {
@@ -49,6 +124,18 @@ func TestSecureModeHash(t *testing.T) {
 	assert.Equal(t, expected, hash)
 }
 
+func TestClientCanBeUsedAsReadonlyLDClient(t *testing.T) {
+	client, _ := MakeCustomClient(testSdkKey, Config{Offline: true}, 0)
+	defer client.Close()
+
+	var readonly interfaces.ReadonlyLDClient = client
+	assert.True(t, readonly.Initialized())
+	assert.Equal(t, Version, readonly.Version())
+	value, err := readonly.BoolVariation("flag", lduser.NewUser("key"), true)
+	assert.NoError(t, err)
+	assert.True(t, value)
+}
+
 func TestMakeCustomClientWithFailedInitialization(t *testing.T) {
 	client, err := MakeCustomClient(testSdkKey, Config{
 		Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
@@ -90,3 +177,91 @@ func makeTestClientWithConfig(modConfig func(*Config)) *LDClient {
 	client, _ := MakeCustomClient(testSdkKey, config, time.Duration(0))
 	return client
 }
+
+func TestPersistentDataStoreIsPreloadedBeforeDataSourceStarts(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").SingleVariation(ldvalue.Bool(true)).Build()
+	persistentStore := mocks.NewMockPersistentDataStore()
+	_ = persistentStore.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: flag.Key, Item: ldstoretypes.SerializedItemDescriptor{
+				Version: flag.Version, SerializedItem: datakinds.Features.Serialize(sharedtest.FlagDescriptor(flag)),
+			}},
+		}},
+	})
+
+	client, _ := MakeCustomClient(testSdkKey, Config{
+		DataStore: ldcomponents.PersistentDataStore(
+			mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: persistentStore},
+		),
+		DataSource: mocks.DataSourceThatNeverInitializes(),
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}, 0)
+	defer client.Close()
+
+	// Removing the flag from the underlying store shows that the client is now using the preloaded data
+	persistentStore.ForceRemove(datakinds.Features, flag.Key)
+
+	value, err := client.BoolVariation(flag.Key, evalTestUser, false)
+	assert.NoError(t, err)
+	assert.True(t, value)
+}
+
+type slowPreloadableDataStore struct {
+	subsystems.DataStore
+	release chan struct{}
+}
+
+func (s slowPreloadableDataStore) Preload() { <-s.release }
+
+func TestDataStorePreloadTimesOut(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	store := slowPreloadableDataStore{release: make(chan struct{})}
+	defer close(store.release)
+
+	preloadDataStore(store, time.Millisecond*10, mockLog.Loggers)
+
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "did not finish loading within 10ms")
+}
+
+type recordingDataUpdateListener struct {
+	records []interfaces.DataUpdateRecord
+}
+
+func (r *recordingDataUpdateListener) OnDataUpdate(record interfaces.DataUpdateRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func TestDataUpdateListenerReceivesRecordsFromDataSource(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flagkey").On(true))
+	listener := &recordingDataUpdateListener{}
+
+	client, err := MakeCustomClient(testSdkKey, Config{
+		DataSource:         td,
+		DataUpdateListener: listener,
+		Events:             ldcomponents.NoEvents(),
+		Logging:            ldcomponents.Logging().Loggers(sharedtest.NewTestLoggers()),
+	}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	td.Update(td.Flag("flagkey").On(false))
+
+	require.Len(t, listener.records, 2)
+	assert.Equal(t, interfaces.DataUpdateOperationInit, listener.records[0].Operation)
+	assert.Equal(t, ldvalue.NewOptionalInt(1), listener.records[0].NewVersion)
+	assert.Equal(t, interfaces.DataUpdateOperationUpsert, listener.records[1].Operation)
+	assert.Equal(t, ldvalue.NewOptionalInt(1), listener.records[1].OldVersion)
+	assert.Equal(t, ldvalue.NewOptionalInt(2), listener.records[1].NewVersion)
+	assert.Equal(t, interfaces.DataUpdateSourceCustom, listener.records[1].Source)
+}
+
+func TestGetDataUpdateSource(t *testing.T) {
+	assert.Equal(t, interfaces.DataUpdateSourceStreaming, getDataUpdateSource(nil))
+	assert.Equal(t, interfaces.DataUpdateSourceStreaming, getDataUpdateSource(ldcomponents.StreamingDataSource()))
+	assert.Equal(t, interfaces.DataUpdateSourcePolling, getDataUpdateSource(ldcomponents.PollingDataSource()))
+	assert.Equal(t, interfaces.DataUpdateSourceFile, getDataUpdateSource(ldfiledata.DataSource()))
+	assert.Equal(t, interfaces.DataUpdateSourceCustom, getDataUpdateSource(ldtestdata.DataSource()))
+}
@@ -0,0 +1,49 @@
+package ldcloudevents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// FlagChangedEventType is the type attribute of the events that a [FlagChangePublisher] sends.
+	FlagChangedEventType = "com.launchdarkly.flag.changed"
+
+	// StructuredContentType is the content type of an event in the JSON format of the CloudEvents
+	// structured content mode, which is how the sinks in this package send events.
+	StructuredContentType = "application/cloudevents+json; charset=utf-8"
+
+	specVersion = "1.0"
+)
+
+// CloudEvent is an event in the JSON format of CloudEvents version 1.0.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// FlagChangedData is the data of an event whose type is [FlagChangedEventType]. It has the same content as
+// interfaces.FlagChangeEvent.
+type FlagChangedData struct {
+	// Key is the key of the feature flag whose configuration has changed.
+	Key string `json:"key"`
+}
+
+// SourceForSDKKey returns the source attribute that a [FlagChangePublisher] uses for its events.
+//
+// The source identifies the LaunchDarkly environment, so that a consumer can tell apart events from
+// different environments, but it does not contain the SDK key itself: the SDK key is a secret, and events
+// are often visible to more systems than the application is. Instead it is a URN containing part of a
+// SHA-256 hash of the key, such as "urn:launchdarkly:sdk-key:2c26b46b68ffc68f".
+func SourceForSDKKey(sdkKey string) string {
+	hash := sha256.Sum256([]byte(sdkKey))
+	return "urn:launchdarkly:sdk-key:" + hex.EncodeToString(hash[:8])
+}
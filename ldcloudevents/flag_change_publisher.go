@@ -0,0 +1,91 @@
+package ldcloudevents
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+
+	"github.com/google/uuid"
+)
+
+// CloudEventSink is a destination for the events that a [FlagChangePublisher] sends.
+//
+// Send is called for one event at a time, on a goroutine of the publisher's own. If it returns an error,
+// the publisher logs it and goes on to the next event; it does not retry.
+type CloudEventSink interface {
+	Send(event CloudEvent) error
+}
+
+// FlagChangePublisher sends a CloudEvent to a [CloudEventSink] for each flag change that the SDK reports.
+type FlagChangePublisher struct {
+	flagTracker interfaces.FlagTracker
+	sink        CloudEventSink
+	source      string
+	loggers     interfaces.LDLoggers
+	changeCh    <-chan interfaces.FlagChangeEvent
+	doneCh      chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewFlagChangePublisher starts sending an event to the sink for every flag change that the client's
+// FlagTracker reports, until [FlagChangePublisher.Close] is called.
+//
+// The sdkKey parameter should be the key that the client was created with. It is used to compute the
+// events' source attribute, as described in [SourceForSDKKey]; the key itself is not sent. Each event has
+// the type [FlagChangedEventType], the flag key as its subject, and a [FlagChangedData] as its data.
+//
+//	publisher := ldcloudevents.NewFlagChangePublisher(client, sdkKey,
+//	    ldcloudevents.NewHTTPSink("http://broker-ingress.knative-eventing.svc/default/default", nil))
+//	defer publisher.Close()
+//
+// As with FlagTracker.AddFlagChangeListener, an event is sent when a flag's configuration changes, which
+// does not necessarily mean that the flag now returns a different value for any context. The events are
+// sent in the order that the changes were reported; a sink that is slow to accept an event delays the
+// events after it, but not the SDK.
+func NewFlagChangePublisher(client *ld.LDClient, sdkKey string, sink CloudEventSink) *FlagChangePublisher {
+	p := &FlagChangePublisher{
+		flagTracker: client.GetFlagTracker(),
+		sink:        sink,
+		source:      SourceForSDKKey(sdkKey),
+		loggers:     client.Loggers(),
+		doneCh:      make(chan struct{}),
+	}
+	p.changeCh = p.flagTracker.AddFlagChangeListener()
+	go p.run()
+	return p
+}
+
+// Close stops sending events. If an event is being sent, it waits for the sink to return.
+func (p *FlagChangePublisher) Close() {
+	p.closeOnce.Do(func() {
+		p.flagTracker.RemoveFlagChangeListener(p.changeCh)
+	})
+	<-p.doneCh
+}
+
+func (p *FlagChangePublisher) run() {
+	defer close(p.doneCh)
+	for change := range p.changeCh {
+		event := p.makeEvent(change)
+		if err := p.sink.Send(event); err != nil {
+			p.loggers.Warnf("Unable to publish change to flag %q as a CloudEvent: %s", change.Key, err)
+		}
+	}
+}
+
+func (p *FlagChangePublisher) makeEvent(change interfaces.FlagChangeEvent) CloudEvent {
+	data, _ := json.Marshal(FlagChangedData{Key: change.Key}) // can't fail for a struct with one string
+	return CloudEvent{
+		SpecVersion:     specVersion,
+		ID:              uuid.New().String(),
+		Source:          p.source,
+		Type:            FlagChangedEventType,
+		Subject:         change.Key,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
@@ -0,0 +1,129 @@
+package ldcloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSDKKey = "sdk-key"
+
+type capturingSink struct {
+	events chan CloudEvent
+	err    error
+}
+
+func newCapturingSink() *capturingSink {
+	return &capturingSink{events: make(chan CloudEvent, 10)}
+}
+
+func (s *capturingSink) Send(event CloudEvent) error {
+	s.events <- event
+	return s.err
+}
+
+func makeTestClient(t *testing.T, data *ldtestdata.TestDataSource, mockLog *ldlogtest.MockLog) *ld.LDClient {
+	config := ld.Config{
+		DataSource: data,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(mockLog.Loggers),
+	}
+	client, err := ld.MakeCustomClient(testSDKKey, config, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestFlagChangePublisherSendsEventForFlagChange(t *testing.T) {
+	data := ldtestdata.DataSource()
+	client := makeTestClient(t, data, ldlogtest.NewMockLog())
+	sink := newCapturingSink()
+	publisher := NewFlagChangePublisher(client, testSDKKey, sink)
+	defer publisher.Close()
+
+	before := time.Now()
+	data.Update(data.Flag("flagkey").BooleanFlag())
+
+	event := th.RequireValue(t, sink.events, time.Second)
+	assert.Equal(t, "1.0", event.SpecVersion)
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, SourceForSDKKey(testSDKKey), event.Source)
+	assert.Equal(t, FlagChangedEventType, event.Type)
+	assert.Equal(t, "flagkey", event.Subject)
+	assert.False(t, event.Time.Before(before.Truncate(time.Second)))
+	assert.Equal(t, "application/json", event.DataContentType)
+	assert.JSONEq(t, `{"key":"flagkey"}`, string(event.Data))
+
+	data.Update(data.Flag("flagkey").BooleanFlag().On(false))
+	event2 := th.RequireValue(t, sink.events, time.Second)
+	assert.Equal(t, "flagkey", event2.Subject)
+	assert.NotEqual(t, event.ID, event2.ID)
+}
+
+func TestFlagChangePublisherLogsSinkErrorAndContinues(t *testing.T) {
+	data := ldtestdata.DataSource()
+	mockLog := ldlogtest.NewMockLog()
+	client := makeTestClient(t, data, mockLog)
+	sink := newCapturingSink()
+	sink.err = errors.New("sorry")
+	publisher := NewFlagChangePublisher(client, testSDKKey, sink)
+	defer publisher.Close()
+
+	data.Update(data.Flag("flag1").BooleanFlag())
+	data.Update(data.Flag("flag2").BooleanFlag())
+	assert.Equal(t, "flag1", th.RequireValue(t, sink.events, time.Second).Subject)
+	assert.Equal(t, "flag2", th.RequireValue(t, sink.events, time.Second).Subject)
+
+	publisher.Close()
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `Unable to publish change to flag "flag1" as a CloudEvent: sorry`)
+}
+
+func TestFlagChangePublisherStopsAfterClose(t *testing.T) {
+	data := ldtestdata.DataSource()
+	client := makeTestClient(t, data, ldlogtest.NewMockLog())
+	sink := newCapturingSink()
+	publisher := NewFlagChangePublisher(client, testSDKKey, sink)
+
+	publisher.Close()
+	publisher.Close() // a second call is allowed
+	data.Update(data.Flag("flagkey").BooleanFlag())
+	th.AssertNoMoreValues(t, sink.events, 100*time.Millisecond)
+}
+
+func TestCloudEventJSON(t *testing.T) {
+	event := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              "id",
+		Source:          "source",
+		Type:            FlagChangedEventType,
+		Subject:         "flagkey",
+		Time:            time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		DataContentType: "application/json",
+		Data:            json.RawMessage(`{"key":"flagkey"}`),
+	}
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"id","source":"source","type":"com.launchdarkly.flag.changed",
+		"subject":"flagkey","time":"2024-01-02T03:04:05Z","datacontenttype":"application/json",
+		"data":{"key":"flagkey"}}`, string(data))
+}
+
+func TestSourceForSDKKeyDoesNotContainKey(t *testing.T) {
+	source := SourceForSDKKey("sdk-1234567890")
+	assert.Regexp(t, `^urn:launchdarkly:sdk-key:[0-9a-f]{16}$`, source)
+	assert.NotContains(t, source, "1234567890")
+	assert.Equal(t, source, SourceForSDKKey("sdk-1234567890"))
+	assert.NotEqual(t, source, SourceForSDKKey("sdk-other"))
+}
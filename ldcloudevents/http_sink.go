@@ -0,0 +1,107 @@
+package ldcloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type httpSink struct {
+	targetURL  string
+	httpClient *http.Client
+}
+
+type handlerSink struct {
+	handler http.Handler
+}
+
+// NewHTTPSink returns a [CloudEventSink] that sends each event in a POST request to targetURL, in the
+// CloudEvents HTTP structured content mode. This is the form that Knative brokers, Azure Event Grid, and
+// most other event routers accept. If httpClient is nil, http.DefaultClient is used.
+//
+// A response status other than 2xx is an error.
+func NewHTTPSink(targetURL string, httpClient *http.Client) CloudEventSink {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpSink{targetURL: targetURL, httpClient: httpClient}
+}
+
+// NewHandlerSink returns a [CloudEventSink] that passes each event to an http.Handler in the same process,
+// as the same POST request that [NewHTTPSink] would send. This is for applications that already have a
+// handler for CloudEvents, such as one from the CloudEvents SDK, and want it to receive flag changes
+// directly.
+//
+// A response status other than 2xx is an error. If the handler does not set a status, it is 200 as usual.
+func NewHandlerSink(handler http.Handler) CloudEventSink {
+	return handlerSink{handler: handler}
+}
+
+func (s httpSink) Send(event CloudEvent) error {
+	req, err := makeStructuredRequest(s.targetURL, event)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return checkStatus(resp.StatusCode)
+}
+
+func (s handlerSink) Send(event CloudEvent) error {
+	req, err := makeStructuredRequest("/", event)
+	if err != nil {
+		return err
+	}
+	w := &statusRecorder{header: make(http.Header), status: http.StatusOK}
+	s.handler.ServeHTTP(w, req)
+	return checkStatus(w.status)
+}
+
+func makeStructuredRequest(targetURL string, event CloudEvent) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", StructuredContentType)
+	return req, nil
+}
+
+func checkStatus(status int) error {
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("CloudEvent was rejected with status %d", status)
+	}
+	return nil
+}
+
+// statusRecorder is the http.ResponseWriter for handlerSink. Only the status matters; the body is
+// discarded.
+type statusRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return len(data), nil
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+}
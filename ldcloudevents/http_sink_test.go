@@ -0,0 +1,84 @@
+package ldcloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestEvent() CloudEvent {
+	return CloudEvent{
+		SpecVersion: "1.0",
+		ID:          "id",
+		Source:      SourceForSDKKey(testSDKKey),
+		Type:        FlagChangedEventType,
+		Subject:     "flagkey",
+		Time:        time.Now().UTC(),
+		Data:        json.RawMessage(`{"key":"flagkey"}`),
+	}
+}
+
+func assertStructuredEvent(t *testing.T, header http.Header, body []byte) {
+	assert.Equal(t, StructuredContentType, header.Get("Content-Type"))
+	var received CloudEvent
+	require.NoError(t, json.Unmarshal(body, &received))
+	assert.Equal(t, "id", received.ID)
+	assert.Equal(t, "flagkey", received.Subject)
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Run("posts event", func(t *testing.T) {
+		handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+		httphelpers.WithServer(handler, func(server *httptest.Server) {
+			require.NoError(t, NewHTTPSink(server.URL+"/events", nil).Send(makeTestEvent()))
+
+			r := <-requestsCh
+			assert.Equal(t, "/events", r.Request.URL.Path)
+			assert.Equal(t, http.MethodPost, r.Request.Method)
+			assertStructuredEvent(t, r.Request.Header, r.Body)
+		})
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		httphelpers.WithServer(httphelpers.HandlerWithStatus(400), func(server *httptest.Server) {
+			err := NewHTTPSink(server.URL, server.Client()).Send(makeTestEvent())
+			assert.EqualError(t, err, "CloudEvent was rejected with status 400")
+		})
+	})
+
+	t.Run("network error", func(t *testing.T) {
+		handler := httphelpers.BrokenConnectionHandler()
+		httphelpers.WithServer(handler, func(server *httptest.Server) {
+			assert.Error(t, NewHTTPSink(server.URL, nil).Send(makeTestEvent()))
+		})
+	})
+}
+
+func TestHandlerSink(t *testing.T) {
+	t.Run("passes event to handler", func(t *testing.T) {
+		writesBody := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) })
+		handler, requestsCh := httphelpers.RecordingHandler(writesBody)
+		require.NoError(t, NewHandlerSink(handler).Send(makeTestEvent()))
+
+		r := <-requestsCh
+		assert.Equal(t, http.MethodPost, r.Request.Method)
+		assertStructuredEvent(t, r.Request.Header, r.Body)
+	})
+
+	t.Run("handler that sets no status", func(t *testing.T) {
+		handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+		assert.NoError(t, NewHandlerSink(handler).Send(makeTestEvent()))
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		err := NewHandlerSink(httphelpers.HandlerWithStatus(503)).Send(makeTestEvent())
+		assert.EqualError(t, err, "CloudEvent was rejected with status 503")
+	})
+}
@@ -0,0 +1,40 @@
+package ldcloudevents
+
+import (
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher is the part of *nats.Conn that [NewNATSSink] uses.
+type NATSPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+type natsSink struct {
+	conn    NATSPublisher
+	subject string
+}
+
+// NewNATSSink returns a [CloudEventSink] that publishes each event to a NATS subject, in the CloudEvents
+// NATS structured content mode: the message data is the event in JSON, and the message has a
+// Content-Type header of [StructuredContentType]. The conn parameter is normally a *nats.Conn, which the
+// application is responsible for closing.
+//
+// Publishing only puts the message in the connection's buffer, so an error means that the connection is
+// closed or the buffer is full, not that no subscriber received it. Use JetStream if you need delivery to
+// be acknowledged.
+func NewNATSSink(conn NATSPublisher, subject string) CloudEventSink {
+	return natsSink{conn: conn, subject: subject}
+}
+
+func (s natsSink) Send(event CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(s.subject)
+	msg.Data = data
+	msg.Header.Set("Content-Type", StructuredContentType)
+	return s.conn.PublishMsg(msg)
+}
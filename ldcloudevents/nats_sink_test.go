@@ -0,0 +1,39 @@
+package ldcloudevents
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingNATSPublisher struct {
+	messages []*nats.Msg
+	err      error
+}
+
+func (p *capturingNATSPublisher) PublishMsg(msg *nats.Msg) error {
+	p.messages = append(p.messages, msg)
+	return p.err
+}
+
+func TestNATSSink(t *testing.T) {
+	t.Run("publishes event", func(t *testing.T) {
+		conn := &capturingNATSPublisher{}
+		require.NoError(t, NewNATSSink(conn, "flags.changed").Send(makeTestEvent()))
+
+		require.Len(t, conn.messages, 1)
+		msg := conn.messages[0]
+		assert.Equal(t, "flags.changed", msg.Subject)
+		assertStructuredEvent(t, http.Header(msg.Header), msg.Data)
+	})
+
+	t.Run("publish error", func(t *testing.T) {
+		conn := &capturingNATSPublisher{err: errors.New("nats: connection closed")}
+		assert.EqualError(t, NewNATSSink(conn, "flags.changed").Send(makeTestEvent()), "nats: connection closed")
+	})
+}
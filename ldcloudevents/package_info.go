@@ -0,0 +1,9 @@
+// Package ldcloudevents publishes feature flag changes as CloudEvents (https://cloudevents.io), so that
+// event routers such as Knative Eventing, Azure Event Grid, or Google Eventarc can deliver them to other
+// services.
+//
+// A [FlagChangePublisher] sends an event to a [CloudEventSink] whenever the SDK reports that a flag's
+// configuration has changed. The package has sinks that deliver events over HTTP, or to an in-process
+// http.Handler, or to a NATS subject. It is a separate Go module, so that applications that do not use it
+// do not get NATS as a dependency of the SDK.
+package ldcloudevents
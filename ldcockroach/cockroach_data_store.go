@@ -0,0 +1,449 @@
+package ldcockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	// DefaultTable is the name of the table that is used by the data store, if no other name is specified
+	// with WithTable.
+	DefaultTable = "launchdarkly_items"
+
+	// DefaultPrefix is the value of the prefix column for all rows written by the data store, if no other
+	// prefix is specified with WithPrefix.
+	DefaultPrefix = "launchdarkly"
+
+	// DefaultOperationTimeout is how long each database operation may take, if no other timeout is
+	// specified with WithOperationTimeout.
+	DefaultOperationTimeout = 10 * time.Second
+
+	initedKey = "$inited"
+
+	// maxTxAttempts is how many times a transaction is tried before a serialization failure is returned
+	// as an error.
+	maxTxAttempts = 20
+
+	// maxRowsPerInsert limits the size of each INSERT statement in Init.
+	maxRowsPerInsert = 100
+
+	// columnCount is the number of parameters for each row in upsertStatement.
+	columnCount = 5
+
+	// serializationFailure is the SQLSTATE of the error that CockroachDB returns when a transaction
+	// conflicts with another one and has to be retried.
+	serializationFailure = "40001"
+
+	restartSavepoint = "cockroach_restart"
+)
+
+// CockroachOption is an optional parameter for [NewCockroachDataStoreFactory].
+type CockroachOption func(*cockroachDataStoreFactory)
+
+// WithTable sets the name of the table that is used by the data store, which may include a schema name,
+// as in "myschema.flags". The default is [DefaultTable].
+func WithTable(table string) CockroachOption {
+	return func(f *cockroachDataStoreFactory) {
+		f.table = table
+	}
+}
+
+// WithPrefix sets the value of the prefix column for all rows written by the data store, so that several
+// SDK environments can share one table. The default is [DefaultPrefix].
+func WithPrefix(prefix string) CockroachOption {
+	return func(f *cockroachDataStoreFactory) {
+		f.prefix = prefix
+	}
+}
+
+// WithDB makes the data store use an existing database handle instead of opening one for the DSN, for
+// instance to share a connection pool with the rest of the application. The data store does not close
+// it.
+func WithDB(db *sql.DB) CockroachOption {
+	return func(f *cockroachDataStoreFactory) {
+		f.db = db
+	}
+}
+
+// WithOperationTimeout sets how long each database operation may take before the data store returns an
+// error. The default is [DefaultOperationTimeout].
+func WithOperationTimeout(timeout time.Duration) CockroachOption {
+	return func(f *cockroachDataStoreFactory) {
+		f.operationTimeout = timeout
+	}
+}
+
+type cockroachDataStoreFactory struct {
+	dsn              string
+	table            string
+	prefix           string
+	db               *sql.DB
+	operationTimeout time.Duration
+}
+
+type cockroachDataStore struct {
+	db               *sql.DB
+	ownDB            bool
+	table            string
+	prefix           string
+	operationTimeout time.Duration
+	loggers          ldlog.Loggers
+	testTxHook       func()
+	tableCreated     bool
+	inited           bool
+	lock             sync.Mutex
+}
+
+// NewCockroachDataStoreFactory returns a configuration for a persistent data store that uses a CockroachDB
+// database, such as "postgresql://user@localhost:26257/mydb?sslmode=verify-full". The DSN can be in any
+// form that the pgx driver accepts. To use it, pass it to ldcomponents.PersistentDataStore and store the
+// result in the DataStore field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldcockroach.NewCockroachDataStoreFactory("postgresql://user@localhost:26257/mydb"),
+//	    ),
+//	}
+//
+// The table is created the first time it is needed, if it does not already exist. Each flag or segment
+// is a row with the columns "prefix", "namespace" ("features" or "segments"), "key", "version", and
+// "item" (the same JSON that other persistent data stores use).
+//
+// CockroachDB runs every transaction at SERIALIZABLE isolation, and when two of them conflict, it makes
+// one of them fail with a serialization failure that the client is expected to retry. The data store does
+// this with the cockroach_restart savepoint protocol: for instance, an update that reads an item's version
+// in a transaction, and finds when it writes the item that another process has updated it since then,
+// rolls back to the savepoint and reads the version again, so that a newer version of the item is never
+// replaced with an older one. The SDK replaces the whole data set in one transaction.
+func NewCockroachDataStoreFactory(
+	dsn string,
+	opts ...CockroachOption,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	f := &cockroachDataStoreFactory{
+		dsn:              dsn,
+		table:            DefaultTable,
+		prefix:           DefaultPrefix,
+		operationTimeout: DefaultOperationTimeout,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *cockroachDataStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	db, ownDB := f.db, false
+	if db == nil {
+		if f.dsn == "" {
+			return nil, fmt.Errorf("no CockroachDB DSN was specified")
+		}
+		config, err := pgx.ParseConfig(f.dsn)
+		if err != nil {
+			return nil, err
+		}
+		db, ownDB = stdlib.OpenDB(*config), true
+	}
+	table := f.table
+	if table == "" {
+		table = DefaultTable
+	}
+	prefix := f.prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	timeout := f.operationTimeout
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+	loggers := clientContext.GetLogging().Loggers
+	loggers.SetPrefix("CockroachDataStore:")
+	return &cockroachDataStore{
+		db:               db,
+		ownDB:            ownDB,
+		table:            pgx.Identifier(strings.Split(table, ".")).Sanitize(),
+		prefix:           prefix,
+		operationTimeout: timeout,
+		loggers:          loggers,
+	}, nil
+}
+
+func (store *cockroachDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	if err := store.ensureTable(ctx); err != nil {
+		return err
+	}
+	rows := []interface{}{store.prefix, initedKey, initedKey, 0, ""}
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			rows = append(rows, store.prefix, coll.Kind.GetName(), item.Key, item.Item.Version,
+				string(item.Item.SerializedItem))
+		}
+	}
+	deleteAll := fmt.Sprintf("DELETE FROM %s WHERE prefix = $1", store.table)
+	err := store.runTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, deleteAll, store.prefix); err != nil {
+			return err
+		}
+		for remaining := rows; len(remaining) > 0; {
+			batch := remaining
+			if len(batch) > maxRowsPerInsert*columnCount {
+				batch = batch[:maxRowsPerInsert*columnCount]
+			}
+			remaining = remaining[len(batch):]
+			if _, err := tx.ExecContext(ctx, store.upsertStatement(len(batch)/columnCount), batch...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *cockroachDataStore) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	if err := store.ensureTable(ctx); err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	var version int
+	var item string
+	err := store.db.QueryRowContext(ctx, store.selectItemStatement(), store.prefix, kind.GetName(), key).
+		Scan(&version, &item)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), nil
+	}
+	if err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return ldstoretypes.SerializedItemDescriptor{Version: version, SerializedItem: []byte(item)}, nil
+}
+
+func (store *cockroachDataStore) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	if err := store.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := store.db.QueryContext(ctx,
+		fmt.Sprintf("SELECT key, version, item FROM %s WHERE prefix = $1 AND namespace = $2", store.table),
+		store.prefix, kind.GetName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []ldstoretypes.KeyedSerializedItemDescriptor
+	for rows.Next() {
+		var key, item string
+		var version int
+		if err := rows.Scan(&key, &version, &item); err != nil {
+			return nil, err
+		}
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  key,
+			Item: ldstoretypes.SerializedItemDescriptor{Version: version, SerializedItem: []byte(item)},
+		})
+	}
+	return results, rows.Err()
+}
+
+func (store *cockroachDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	if err := store.ensureTable(ctx); err != nil {
+		return false, err
+	}
+	var updated bool
+	err := store.runTransaction(ctx, func(tx *sql.Tx) error {
+		updated = false
+		var oldVersion int
+		var oldItem string
+		err := tx.QueryRowContext(ctx, store.selectItemStatement(), store.prefix, kind.GetName(), key).
+			Scan(&oldVersion, &oldItem)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && oldVersion >= newItem.Version {
+			return nil
+		}
+
+		if store.testTxHook != nil { // instrumentation for unit tests
+			store.testTxHook()
+		}
+
+		if _, err := tx.ExecContext(ctx, store.upsertStatement(1), store.prefix, kind.GetName(), key,
+			newItem.Version, string(newItem.SerializedItem)); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+func (store *cockroachDataStore) IsInitialized() bool {
+	store.lock.Lock()
+	inited := store.inited
+	store.lock.Unlock()
+	if inited {
+		return true
+	}
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	if err := store.ensureTable(ctx); err != nil {
+		return false
+	}
+	var version int
+	var item string
+	err := store.db.QueryRowContext(ctx, store.selectItemStatement(), store.prefix, initedKey, initedKey).
+		Scan(&version, &item)
+	if err != nil {
+		return false
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return true
+}
+
+func (store *cockroachDataStore) IsStoreAvailable() bool {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	return store.db.PingContext(ctx) == nil
+}
+
+// Close is called automatically when the client is closed.
+func (store *cockroachDataStore) Close() error {
+	if store.ownDB {
+		return store.db.Close()
+	}
+	return nil
+}
+
+// runTransaction calls fn in a transaction, following CockroachDB's protocol for retrying transactions on
+// the client: fn runs after a savepoint, and if it or the release of the savepoint fails with a
+// serialization failure, the transaction is rolled back to the savepoint and fn is called again. Since
+// the transaction is not started over, CockroachDB gives it a higher priority each time, so that it
+// eventually wins against the transactions that it conflicts with.
+func (store *cockroachDataStore) runTransaction(ctx context.Context, fn func(*sql.Tx) error) (err error) {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+restartSavepoint); err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = fn(tx)
+		if err == nil {
+			// In CockroachDB, releasing the savepoint is what commits the transaction.
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+restartSavepoint)
+		}
+		if err == nil {
+			return tx.Commit()
+		}
+		if !isSerializationFailure(err) || attempt == maxTxAttempts {
+			return err
+		}
+		store.loggers.Debug("Transaction conflicted with another one, retrying")
+		if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+restartSavepoint); err != nil {
+			return err
+		}
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailure
+}
+
+// ensureTable creates the table if it does not exist. This is done when the table is first needed, rather
+// than when the data store is created, so that the SDK can start while the database is unavailable.
+func (store *cockroachDataStore) ensureTable(ctx context.Context) error {
+	store.lock.Lock()
+	created := store.tableCreated
+	store.lock.Unlock()
+	if created {
+		return nil
+	}
+	_, err := store.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	prefix TEXT NOT NULL,
+	namespace TEXT NOT NULL,
+	key TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	item TEXT NOT NULL,
+	PRIMARY KEY (prefix, namespace, key)
+)`, store.table))
+	if err != nil {
+		return err
+	}
+	store.lock.Lock()
+	store.tableCreated = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *cockroachDataStore) operationContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.operationTimeout)
+}
+
+func (store *cockroachDataStore) selectItemStatement() string {
+	return fmt.Sprintf("SELECT version, item FROM %s WHERE prefix = $1 AND namespace = $2 AND key = $3",
+		store.table)
+}
+
+// upsertStatement returns an INSERT statement for the specified number of rows, which replaces any rows
+// that have the same keys.
+func (store *cockroachDataStore) upsertStatement(rowCount int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (prefix, namespace, key, version, item) VALUES ", store.table)
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * columnCount
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+	}
+	b.WriteString(" ON CONFLICT (prefix, namespace, key)" +
+		" DO UPDATE SET version = excluded.version, item = excluded.item")
+	return b.String()
+}
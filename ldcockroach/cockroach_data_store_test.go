@@ -0,0 +1,198 @@
+package ldcockroach
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestContext(loggers ldlog.Loggers) subsystems.ClientContext {
+	return subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: loggers}}
+}
+
+func buildStore(t *testing.T, fake *fakeCockroach, opts ...CockroachOption) *cockroachDataStore {
+	store, err := NewCockroachDataStoreFactory("", append([]CockroachOption{WithDB(fake.db())}, opts...)...).
+		Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store.(*cockroachDataStore)
+}
+
+func prefixOrDefault(prefix string) string {
+	if prefix == "" {
+		return DefaultPrefix
+	}
+	return prefix
+}
+
+func TestCockroachDataStoreWithFakeCockroach(t *testing.T) {
+	fake := newFakeCockroach()
+	db := fake.db()
+	defer db.Close()
+	failing := newFakeCockroach()
+	failing.setFailing(true)
+	failingDB := failing.db()
+	defer failingDB.Close()
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewCockroachDataStoreFactory("", WithDB(db), WithPrefix(prefix))
+		},
+		func(prefix string) error {
+			fake.deletePrefix(prefixOrDefault(prefix))
+			return nil
+		},
+	).ErrorStoreFactory(
+		NewCockroachDataStoreFactory("", WithDB(failingDB)),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*cockroachDataStore).testTxHook = hook
+		},
+	).Run(t)
+}
+
+// TestCockroachDataStoreWithCockroachDB runs the same tests against a real database, if the COCKROACH_URL
+// environment variable is set to its DSN; for instance, after "cockroach start-single-node --insecure",
+// set it to "postgresql://root@localhost:26257/defaultdb?sslmode=disable".
+func TestCockroachDataStoreWithCockroachDB(t *testing.T) {
+	dsn := os.Getenv("COCKROACH_URL")
+	if dsn == "" {
+		t.Skip("COCKROACH_URL is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewCockroachDataStoreFactory(dsn, WithPrefix(prefix))
+		},
+		func(prefix string) error {
+			_, err := db.Exec("DELETE FROM "+DefaultTable+" WHERE prefix = $1", prefixOrDefault(prefix))
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "42P01" {
+				return nil // the table hasn't been created yet
+			}
+			return err
+		},
+	).ErrorStoreFactory(
+		NewCockroachDataStoreFactory("postgresql://root@localhost:1/defaultdb?sslmode=disable"),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*cockroachDataStore).testTxHook = hook
+		},
+	).Run(t)
+}
+
+func TestUpsertRetriesAfterSerializationFailure(t *testing.T) {
+	fake := newFakeCockroach()
+	store := buildStore(t, fake)
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	item := ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag))
+
+	fake.failNextReleases(3)
+	updated, err := store.Upsert(datakinds.Features, flag.Key, item)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	result, err := store.Get(datakinds.Features, flag.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Version)
+}
+
+func TestUpsertGivesUpAfterTooManySerializationFailures(t *testing.T) {
+	fake := newFakeCockroach()
+	store := buildStore(t, fake)
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	item := ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag))
+
+	fake.failNextReleases(maxTxAttempts)
+	_, err := store.Upsert(datakinds.Features, flag.Key, item)
+	require.Error(t, err)
+	assert.True(t, isSerializationFailure(err))
+
+	_, found := fake.row(fakeRowKey{`"` + DefaultTable + `"`, DefaultPrefix, "features", flag.Key})
+	assert.False(t, found)
+}
+
+func TestInitWritesMoreRowsThanOneStatementAllows(t *testing.T) {
+	fake := newFakeCockroach()
+	store := buildStore(t, fake)
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(250, 1)},
+	}))
+	assert.Len(t, fake.rowKeys(`"`+DefaultTable+`"`), 251) // including the $inited row
+
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(150, 2)},
+	}))
+	items, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, items, 150)
+	for _, item := range items {
+		assert.Equal(t, 2, item.Item.Version)
+	}
+}
+
+func makeFlagItems(count int, version int) []ldstoretypes.KeyedSerializedItemDescriptor {
+	items := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, count)
+	for i := 0; i < count; i++ {
+		flag := ldbuilders.NewFlagBuilder(fmt.Sprintf("flag%d", i)).Version(version).Build()
+		items = append(items, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  flag.Key,
+			Item: ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)),
+		})
+	}
+	return items
+}
+
+func TestIsStoreAvailable(t *testing.T) {
+	fake := newFakeCockroach()
+	store := buildStore(t, fake)
+	assert.True(t, store.IsStoreAvailable())
+
+	fake.setFailing(true)
+	assert.False(t, store.IsStoreAvailable())
+}
+
+func TestBuildOptions(t *testing.T) {
+	t.Run("no DSN", func(t *testing.T) {
+		_, err := NewCockroachDataStoreFactory("").Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid DSN", func(t *testing.T) {
+		_, err := NewCockroachDataStoreFactory("postgresql://[").Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		assert.Error(t, err)
+	})
+
+	t.Run("table with schema", func(t *testing.T) {
+		fake := newFakeCockroach()
+		store := buildStore(t, fake, WithTable("myschema.flags"))
+		require.NoError(t, store.Init(nil))
+		assert.Equal(t, []fakeRowKey{{`"myschema"."flags"`, DefaultPrefix, initedKey, initedKey}},
+			fake.rowKeys(`"myschema"."flags"`))
+	})
+
+	t.Run("database from WithDB is not closed", func(t *testing.T) {
+		fake := newFakeCockroach()
+		db := fake.db()
+		defer db.Close()
+		store, err := NewCockroachDataStoreFactory("", WithDB(db)).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+		assert.NoError(t, db.Ping())
+	})
+}
@@ -0,0 +1,377 @@
+package ldcockroach
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeCockroach is a minimal in-memory implementation of a CockroachDB database, as a database/sql driver
+// that understands only the statements that the data store uses. Like CockroachDB, it runs transactions
+// at SERIALIZABLE isolation optimistically: releasing the cockroach_restart savepoint, or committing, fails
+// with a serialization failure if any row that the transaction read has changed since then, and the
+// transaction can then roll back to the savepoint and try again.
+type fakeCockroach struct {
+	tables               map[string]bool
+	rows                 map[fakeRowKey]fakeRow
+	revision             int64
+	failing              bool
+	serializationFailure int // how many more times to fail the release of a savepoint regardless
+	lock                 sync.Mutex
+}
+
+type fakeRowKey struct {
+	table, prefix, namespace, key string
+}
+
+type fakeRow struct {
+	version  int64
+	item     string
+	revision int64
+}
+
+func newFakeCockroach() *fakeCockroach {
+	return &fakeCockroach{tables: make(map[string]bool), rows: make(map[fakeRowKey]fakeRow)}
+}
+
+// db returns a database handle that uses this fake.
+func (f *fakeCockroach) db() *sql.DB {
+	return sql.OpenDB(fakeConnector{f})
+}
+
+// setFailing makes every operation fail as if the database could not be reached.
+func (f *fakeCockroach) setFailing(failing bool) {
+	f.lock.Lock()
+	f.failing = failing
+	f.lock.Unlock()
+}
+
+// failNextReleases makes the next count releases of the savepoint fail with a serialization failure.
+func (f *fakeCockroach) failNextReleases(count int) {
+	f.lock.Lock()
+	f.serializationFailure = count
+	f.lock.Unlock()
+}
+
+// deletePrefix deletes every row with the specified prefix.
+func (f *fakeCockroach) deletePrefix(prefix string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for k := range f.rows {
+		if k.prefix == prefix {
+			delete(f.rows, k)
+		}
+	}
+}
+
+// rowKeys returns the keys of all rows in the specified table.
+func (f *fakeCockroach) rowKeys(table string) []fakeRowKey {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var keys []fakeRowKey
+	for k := range f.rows {
+		if k.table == table {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (f *fakeCockroach) row(key fakeRowKey) (fakeRow, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	row, ok := f.rows[key]
+	return row, ok
+}
+
+type fakeConnector struct {
+	f *fakeCockroach
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{f: c.f}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use fakeConnector")
+}
+
+type fakeConn struct {
+	f  *fakeCockroach
+	tx *fakeTx
+}
+
+// fakeTx is the state of a transaction. A nil row in writes means that the row is deleted.
+type fakeTx struct {
+	reads     map[fakeRowKey]int64
+	writes    map[fakeRowKey]*fakeRow
+	savepoint bool
+	released  bool
+	aborted   bool
+}
+
+var errConnectionRefused = errors.New("connection refused") //nolint:gochecknoglobals
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.f.lock.Lock()
+	defer c.f.lock.Unlock()
+	if c.f.failing {
+		return nil, errConnectionRefused
+	}
+	c.tx = &fakeTx{reads: make(map[fakeRowKey]int64), writes: make(map[fakeRowKey]*fakeRow)}
+	return fakeTxHandle{c}, nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	c.f.lock.Lock()
+	defer c.f.lock.Unlock()
+	if c.f.failing {
+		return errConnectionRefused
+	}
+	return nil
+}
+
+type fakeTxHandle struct {
+	c *fakeConn
+}
+
+func (h fakeTxHandle) Commit() error {
+	f, tx := h.c.f, h.c.tx
+	h.c.tx = nil
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if tx.aborted {
+		return &pgconn.PgError{Code: "25P02", Message: "current transaction is aborted"}
+	}
+	if !tx.released {
+		return f.commitWrites(tx)
+	}
+	return nil
+}
+
+func (h fakeTxHandle) Rollback() error {
+	h.c.tx = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.f
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.failing {
+		return nil, errConnectionRefused
+	}
+	values := argValues(args)
+	tx := c.tx
+	switch {
+	case query == "SAVEPOINT "+restartSavepoint:
+		tx.savepoint = true
+	case query == "RELEASE SAVEPOINT "+restartSavepoint:
+		if tx.aborted {
+			return nil, &pgconn.PgError{Code: "25P02", Message: "current transaction is aborted"}
+		}
+		if f.serializationFailure > 0 {
+			f.serializationFailure--
+			tx.aborted = true
+			return nil, &pgconn.PgError{Code: serializationFailure, Message: "restart transaction"}
+		}
+		if err := f.commitWrites(tx); err != nil {
+			tx.aborted = true
+			return nil, err
+		}
+		tx.released = true
+	case query == "ROLLBACK TO SAVEPOINT "+restartSavepoint:
+		if !tx.savepoint {
+			return nil, errors.New("savepoint does not exist")
+		}
+		tx.reads, tx.writes, tx.aborted = make(map[fakeRowKey]int64), make(map[fakeRowKey]*fakeRow), false
+	case tx != nil && tx.aborted:
+		return nil, &pgconn.PgError{Code: "25P02", Message: "current transaction is aborted"}
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS "):
+		f.tables[tableName(query, "EXISTS ")] = true
+	case strings.HasPrefix(query, "DELETE FROM "):
+		table := tableName(query, "FROM ")
+		if !f.tables[table] {
+			return nil, missingTable(table)
+		}
+		for k, row := range f.rows {
+			if k.table == table && k.prefix == values[0] {
+				f.write(tx, k, nil, row.revision)
+			}
+		}
+		if tx != nil {
+			for k := range tx.writes {
+				if k.table == table && k.prefix == values[0] {
+					tx.writes[k] = nil
+				}
+			}
+		}
+	case strings.HasPrefix(query, "INSERT INTO "):
+		table := tableName(query, "INTO ")
+		if !f.tables[table] {
+			return nil, missingTable(table)
+		}
+		for i := 0; i+columnCount <= len(values); i += columnCount {
+			k := fakeRowKey{table, values[i].(string), values[i+1].(string), values[i+2].(string)}
+			f.write(tx, k, &fakeRow{version: values[i+3].(int64), item: values[i+4].(string)}, -1)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", query)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.f
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.failing {
+		return nil, errConnectionRefused
+	}
+	if c.tx != nil && c.tx.aborted {
+		return nil, &pgconn.PgError{Code: "25P02", Message: "current transaction is aborted"}
+	}
+	values := argValues(args)
+	table := tableName(query, "FROM ")
+	if !f.tables[table] {
+		return nil, missingTable(table)
+	}
+	switch {
+	case strings.HasPrefix(query, "SELECT version, item FROM "):
+		k := fakeRowKey{table, values[0].(string), values[1].(string), values[2].(string)}
+		rows := &fakeRows{columns: []string{"version", "item"}}
+		if row, ok := f.read(c.tx, k); ok {
+			rows.values = append(rows.values, []driver.Value{row.version, row.item})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT key, version, item FROM "):
+		if c.tx != nil {
+			return nil, errors.New("queries of several rows in a transaction are not supported")
+		}
+		rows := &fakeRows{columns: []string{"key", "version", "item"}}
+		for k, row := range f.rows {
+			if k.table == table && k.prefix == values[0] && k.namespace == values[1] {
+				rows.values = append(rows.values, []driver.Value{k.key, row.version, row.item})
+			}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unsupported query: %s", query)
+}
+
+// read returns a row as the transaction sees it, if there is a transaction, and records the revision that
+// the transaction saw.
+func (f *fakeCockroach) read(tx *fakeTx, k fakeRowKey) (fakeRow, bool) {
+	if tx != nil {
+		if row, ok := tx.writes[k]; ok {
+			if row == nil {
+				return fakeRow{}, false
+			}
+			return *row, true
+		}
+	}
+	row, ok := f.rows[k]
+	if tx != nil {
+		if _, alreadyRead := tx.reads[k]; !alreadyRead {
+			tx.reads[k] = row.revision
+		}
+	}
+	return row, ok
+}
+
+// write changes a row, or records the change in the transaction if there is one. If readRevision is not
+// -1, the write depends on the row having that revision.
+func (f *fakeCockroach) write(tx *fakeTx, k fakeRowKey, row *fakeRow, readRevision int64) {
+	if tx == nil {
+		if row == nil {
+			delete(f.rows, k)
+		} else {
+			f.revision++
+			row.revision = f.revision
+			f.rows[k] = *row
+		}
+		return
+	}
+	if _, alreadyRead := tx.reads[k]; !alreadyRead && readRevision != -1 {
+		tx.reads[k] = readRevision
+	}
+	tx.writes[k] = row
+}
+
+func (f *fakeCockroach) commitWrites(tx *fakeTx) error {
+	for k, revision := range tx.reads {
+		if f.rows[k].revision != revision {
+			return &pgconn.PgError{Code: serializationFailure, Message: "restart transaction"}
+		}
+	}
+	for k, row := range tx.writes {
+		f.write(nil, k, row, -1)
+	}
+	tx.reads, tx.writes = make(map[fakeRowKey]int64), make(map[fakeRowKey]*fakeRow)
+	return nil
+}
+
+func argValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, 0, len(args))
+	for _, a := range args {
+		values = append(values, a.Value)
+	}
+	return values
+}
+
+// tableName returns the table name that follows the specified keyword in a statement.
+func tableName(query, keyword string) string {
+	rest := query[strings.Index(query, keyword)+len(keyword):]
+	return strings.Fields(rest)[0]
+}
+
+func missingTable(table string) error {
+	return &pgconn.PgError{Code: "42P01", Message: fmt.Sprintf("relation %s does not exist", table)}
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,6 @@
+// Package ldcockroach provides a persistent data store that keeps feature flags and segments in
+// CockroachDB, for applications that already use CockroachDB as their database.
+//
+// See [NewCockroachDataStoreFactory] for how to use it. This is a separate Go module, so that applications
+// that do not use it do not get the pgx PostgreSQL driver as a dependency of the SDK.
+package ldcockroach
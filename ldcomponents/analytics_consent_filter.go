@@ -0,0 +1,123 @@
+package ldcomponents
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+)
+
+// analyticsConsentFilter implements EventProcessorBuilder.AnalyticsConsent. The event processor cannot
+// read the context of an event that has been recorded, so the filtering is done by LDClient, which asks the
+// event processor for a decision through the internal.AnalyticsConsentFilter interface before recording
+// each event.
+//
+// Evaluations for a context that has not consented are recorded for an anonymous placeholder context
+// instead, so that they are still counted in the summary. The event processor then generates an index
+// event for the placeholder, which would do no harm, since it has none of the real context's properties;
+// but to keep from sending a context that does not exist, analyticsConsentFilter also removes those index
+// events from each payload before it is sent.
+type analyticsConsentFilter struct {
+	hasConsent  func(ldcontext.Context) bool
+	summarize   bool
+	loggers     ldlog.Loggers
+	loggedPanic atomic.Bool
+}
+
+type analyticsConsentFilteringEventProcessor struct {
+	ldevents.EventProcessor
+	filter *analyticsConsentFilter
+}
+
+type analyticsConsentFilteringEventSender struct {
+	sender ldevents.EventSender
+}
+
+// placeholderIndexMarker is the JSON that every index event for a placeholder context contains, and that
+// no other event can contain unless an application uses the same key for a real context.
+var placeholderIndexMarker = []byte(`"key":"` + internal.NonConsentingContextKey + `"`) //nolint:gochecknoglobals
+
+func newAnalyticsConsentFilter(
+	hasConsent func(ldcontext.Context) bool,
+	summarize bool,
+	loggers ldlog.Loggers,
+) *analyticsConsentFilter {
+	return &analyticsConsentFilter{hasConsent: hasConsent, summarize: summarize, loggers: loggers}
+}
+
+func (f *analyticsConsentFilter) wrapEventProcessor(ep ldevents.EventProcessor) ldevents.EventProcessor {
+	return analyticsConsentFilteringEventProcessor{EventProcessor: ep, filter: f}
+}
+
+func (f *analyticsConsentFilter) wrapEventSender(sender ldevents.EventSender) ldevents.EventSender {
+	return analyticsConsentFilteringEventSender{sender: sender}
+}
+
+func (p analyticsConsentFilteringEventProcessor) AnalyticsConsent(
+	context ldcontext.Context,
+) internal.AnalyticsConsent {
+	if p.filter.contextHasConsent(context) {
+		return internal.AnalyticsConsentGiven
+	}
+	if p.filter.summarize {
+		return internal.AnalyticsConsentSummaryOnly
+	}
+	return internal.AnalyticsConsentNotGiven
+}
+
+// contextHasConsent calls the application's function, treating a panic as no consent.
+func (f *analyticsConsentFilter) contextHasConsent(context ldcontext.Context) (result bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = false
+			if f.loggedPanic.CompareAndSwap(false, true) {
+				f.loggers.Errorf("The analytics consent function panicked (%v); events for any context that"+
+					" causes a panic are treated as not consented. This is only logged once", r)
+			}
+		}
+	}()
+	return f.hasConsent(context)
+}
+
+func (s analyticsConsentFilteringEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	if kind == ldevents.AnalyticsEventDataKind && bytes.Contains(data, placeholderIndexMarker) {
+		data, eventCount = removePlaceholderIndexEvents(data, eventCount)
+		if eventCount == 0 {
+			return ldevents.EventSenderResult{Success: true}
+		}
+	}
+	return s.sender.SendEventData(kind, data, eventCount)
+}
+
+func removePlaceholderIndexEvents(data []byte, eventCount int) ([]byte, int) {
+	var events []json.RawMessage
+	if err := json.Unmarshal(data, &events); err != nil {
+		return data, eventCount // COVERAGE: can't happen, the event processor always sends an array
+	}
+	kept := events[:0]
+	for _, e := range events {
+		var props struct {
+			Kind string `json:"kind"`
+		}
+		if json.Unmarshal(e, &props) == nil && props.Kind == "index" && bytes.Contains(e, placeholderIndexMarker) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) == len(events) {
+		return data, eventCount
+	}
+	newData, err := json.Marshal(kept)
+	if err != nil {
+		return data, eventCount // COVERAGE: can't happen, these are all valid JSON values
+	}
+	return newData, eventCount - (len(events) - len(kept))
+}
@@ -15,6 +15,10 @@ const DefaultBigSegmentsContextCacheSize = 1000
 // [BigSegmentsConfigurationBuilder.ContextCacheTime].
 const DefaultBigSegmentsContextCacheTime = time.Second * 5
 
+// DefaultBigSegmentsMaxConcurrentStoreQueries is the default value for
+// [BigSegmentsConfigurationBuilder.MaxConcurrentStoreQueries].
+const DefaultBigSegmentsMaxConcurrentStoreQueries = 10
+
 // DefaultBigSegmentsStatusPollInterval is the default value for
 // [BigSegmentsConfigurationBuilder.StatusPollInterval].
 const DefaultBigSegmentsStatusPollInterval = time.Second * 5
@@ -74,10 +78,11 @@ func BigSegments(
 	return &BigSegmentsConfigurationBuilder{
 		storeConfigurer: storeConfigurer,
 		config: ldstoreimpl.BigSegmentsConfigurationProperties{
-			ContextCacheSize:   DefaultBigSegmentsContextCacheSize,
-			ContextCacheTime:   DefaultBigSegmentsContextCacheTime,
-			StatusPollInterval: DefaultBigSegmentsStatusPollInterval,
-			StaleAfter:         DefaultBigSegmentsStaleAfter,
+			ContextCacheSize:          DefaultBigSegmentsContextCacheSize,
+			ContextCacheTime:          DefaultBigSegmentsContextCacheTime,
+			MaxConcurrentStoreQueries: DefaultBigSegmentsMaxConcurrentStoreQueries,
+			StatusPollInterval:        DefaultBigSegmentsStatusPollInterval,
+			StaleAfter:                DefaultBigSegmentsStaleAfter,
 		},
 	}
 }
@@ -95,6 +100,7 @@ func BigSegments(
 // used by the cache.
 //
 // Cache entries can also expire based on the setting of [BigSegmentsConfigurationBuilder.ContextCacheTime].
+// ContextCacheSize has no effect if you set [BigSegmentsConfigurationBuilder.ContextCacheEvictionPolicy].
 func (b *BigSegmentsConfigurationBuilder) ContextCacheSize(
 	contextCacheSize int,
 ) *BigSegmentsConfigurationBuilder {
@@ -115,6 +121,41 @@ func (b *BigSegmentsConfigurationBuilder) ContextCacheTime(
 	return b
 }
 
+// ContextCacheEvictionPolicy replaces the SDK's least-recently-used cache of Big Segment state, described
+// in [BigSegmentsConfigurationBuilder.ContextCacheSize], with a cache that decides in some other way which
+// contexts to discard when it is full. The maximum size of the cache is then set by the policy instead of
+// by ContextCacheSize. Entries still expire according to [BigSegmentsConfigurationBuilder.ContextCacheTime].
+//
+// The SDK provides [LFUEvictionPolicy], which is likely to work better if a few contexts account for most
+// evaluations; you can also provide your own implementation. Each SDK client must have its own instance.
+//
+//	config := ld.Config{
+//	    BigSegments: ldcomponents.BigSegments(ldredis.BigSegmentStore()).
+//	        ContextCacheEvictionPolicy(ldcomponents.LFUEvictionPolicy(2000)),
+//	}
+//
+// Setting it to nil restores the default.
+func (b *BigSegmentsConfigurationBuilder) ContextCacheEvictionPolicy(
+	policy subsystems.CacheEvictionPolicy,
+) *BigSegmentsConfigurationBuilder {
+	b.config.ContextCacheEvictionPolicy = policy
+	return b
+}
+
+// MaxConcurrentStoreQueries sets the maximum number of Big Segment store queries that
+// [github.com/launchdarkly/go-server-sdk/v7.LDClient.PrewarmBigSegments] does at once. The default value
+// is [DefaultBigSegmentsMaxConcurrentStoreQueries]. It does not limit the queries that are done for
+// evaluations.
+func (b *BigSegmentsConfigurationBuilder) MaxConcurrentStoreQueries(
+	maxConcurrentStoreQueries int,
+) *BigSegmentsConfigurationBuilder {
+	if maxConcurrentStoreQueries <= 0 {
+		maxConcurrentStoreQueries = DefaultBigSegmentsMaxConcurrentStoreQueries
+	}
+	b.config.MaxConcurrentStoreQueries = maxConcurrentStoreQueries
+	return b
+}
+
 // StatusPollInterval sets the interval at which the SDK will poll the Big Segment store to make sure
 // it is available and to determine how long ago it was updated. The default value is
 // [DefaultBigSegmentsStatusPollInterval].
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -68,6 +69,27 @@ func TestBigSegmentsConfigurationBuilder(t *testing.T) {
 		assert.Equal(t, time.Second*999, c.GetContextCacheTime())
 	})
 
+	t.Run("ContextCacheEvictionPolicy", func(t *testing.T) {
+		policy := LFUEvictionPolicy(10)
+		c, err := BigSegments(mockBigSegmentStoreFactory{}).
+			ContextCacheEvictionPolicy(policy).
+			Build(context)
+		require.NoError(t, err)
+		assert.Same(t, policy, c.(ldstoreimpl.BigSegmentsConfigurationProperties).ContextCacheEvictionPolicy)
+	})
+
+	t.Run("MaxConcurrentStoreQueries", func(t *testing.T) {
+		maxQueries := func(b *BigSegmentsConfigurationBuilder) int {
+			c, err := b.Build(context)
+			require.NoError(t, err)
+			return c.(ldstoreimpl.BigSegmentsConfigurationProperties).MaxConcurrentStoreQueries
+		}
+		assert.Equal(t, DefaultBigSegmentsMaxConcurrentStoreQueries, maxQueries(BigSegments(mockBigSegmentStoreFactory{})))
+		assert.Equal(t, 3, maxQueries(BigSegments(mockBigSegmentStoreFactory{}).MaxConcurrentStoreQueries(3)))
+		assert.Equal(t, DefaultBigSegmentsMaxConcurrentStoreQueries,
+			maxQueries(BigSegments(mockBigSegmentStoreFactory{}).MaxConcurrentStoreQueries(0)))
+	})
+
 	t.Run("StatusPollInterval", func(t *testing.T) {
 		c, err := BigSegments(mockBigSegmentStoreFactory{}).
 			StatusPollInterval(time.Second * 999).
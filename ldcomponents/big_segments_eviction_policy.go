@@ -0,0 +1,146 @@
+package ldcomponents
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+type policyEntry struct {
+	key   string
+	value subsystems.BigSegmentMembership
+	freq  int
+}
+
+// LRUEvictionPolicy returns a cache for [BigSegmentsConfigurationBuilder.ContextCacheEvictionPolicy] that
+// discards the least recently used entry when it is full. The SDK's default cache behaves the same way; this
+// is for applications that choose between policies in their own configuration.
+//
+// If maxSize is zero or negative, it is [DefaultBigSegmentsContextCacheSize].
+func LRUEvictionPolicy(maxSize int) subsystems.CacheEvictionPolicy {
+	if maxSize <= 0 {
+		maxSize = DefaultBigSegmentsContextCacheSize
+	}
+	return &lruEvictionPolicy{maxSize: maxSize, entries: make(map[string]*list.Element)}
+}
+
+// LFUEvictionPolicy returns a cache for [BigSegmentsConfigurationBuilder.ContextCacheEvictionPolicy] that
+// discards the least frequently used entry when it is full; if several entries have been used equally
+// often, it discards the least recently used of those. This can be more effective than the default
+// behavior if a small number of contexts are evaluated far more often than the others, since it keeps
+// those contexts cached even while many other contexts are being evaluated.
+//
+// The count of uses of an entry is reset when it is discarded. If maxSize is zero or negative, it is
+// [DefaultBigSegmentsContextCacheSize].
+func LFUEvictionPolicy(maxSize int) subsystems.CacheEvictionPolicy {
+	if maxSize <= 0 {
+		maxSize = DefaultBigSegmentsContextCacheSize
+	}
+	return &lfuEvictionPolicy{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		freqs:   make(map[int]*list.List),
+	}
+}
+
+// lruEvictionPolicy keeps its entries in a list ordered from most to least recently used.
+type lruEvictionPolicy struct {
+	maxSize int
+	entries map[string]*list.Element
+	order   list.List
+	lock    sync.Mutex
+}
+
+func (p *lruEvictionPolicy) Add(key string, value subsystems.BigSegmentMembership) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if elem, ok := p.entries[key]; ok {
+		elem.Value.(*policyEntry).value = value
+		p.order.MoveToFront(elem)
+		return
+	}
+	if len(p.entries) >= p.maxSize {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.entries, oldest.Value.(*policyEntry).key)
+	}
+	p.entries[key] = p.order.PushFront(&policyEntry{key: key, value: value})
+}
+
+func (p *lruEvictionPolicy) Get(key string) (subsystems.BigSegmentMembership, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	elem, ok := p.entries[key]
+	if !ok {
+		return nil, false
+	}
+	p.order.MoveToFront(elem)
+	return elem.Value.(*policyEntry).value, true
+}
+
+// lfuEvictionPolicy keeps a list of entries for each use count, ordered from most to least recently used,
+// so that adding, getting, and discarding an entry all take constant time.
+type lfuEvictionPolicy struct {
+	maxSize int
+	entries map[string]*list.Element
+	freqs   map[int]*list.List
+	minFreq int
+	lock    sync.Mutex
+}
+
+func (p *lfuEvictionPolicy) Add(key string, value subsystems.BigSegmentMembership) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if elem, ok := p.entries[key]; ok {
+		elem.Value.(*policyEntry).value = value
+		p.touch(elem)
+		return
+	}
+	if len(p.entries) >= p.maxSize {
+		leastUsed := p.freqs[p.minFreq]
+		oldest := leastUsed.Back()
+		leastUsed.Remove(oldest)
+		if leastUsed.Len() == 0 {
+			delete(p.freqs, p.minFreq)
+		}
+		delete(p.entries, oldest.Value.(*policyEntry).key)
+	}
+	p.entries[key] = p.listForFreq(1).PushFront(&policyEntry{key: key, value: value, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *lfuEvictionPolicy) Get(key string) (subsystems.BigSegmentMembership, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	elem, ok := p.entries[key]
+	if !ok {
+		return nil, false
+	}
+	p.touch(elem)
+	return elem.Value.(*policyEntry).value, true
+}
+
+// touch moves an entry to the list for the next higher use count. The lock must be held.
+func (p *lfuEvictionPolicy) touch(elem *list.Element) {
+	entry := elem.Value.(*policyEntry)
+	oldList := p.freqs[entry.freq]
+	oldList.Remove(elem)
+	if oldList.Len() == 0 {
+		delete(p.freqs, entry.freq)
+		if p.minFreq == entry.freq {
+			p.minFreq++
+		}
+	}
+	entry.freq++
+	p.entries[entry.key] = p.listForFreq(entry.freq).PushFront(entry)
+}
+
+func (p *lfuEvictionPolicy) listForFreq(freq int) *list.List {
+	l, ok := p.freqs[freq]
+	if !ok {
+		l = list.New()
+		p.freqs[freq] = l
+	}
+	return l
+}
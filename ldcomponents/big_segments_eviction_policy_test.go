@@ -0,0 +1,144 @@
+package ldcomponents
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func membershipIncluding(segmentRef string) subsystems.BigSegmentMembership {
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{segmentRef}, nil)
+}
+
+func assertCachedKeys(t *testing.T, policy subsystems.CacheEvictionPolicy, present []string, absent []string) {
+	t.Helper()
+	for _, key := range present {
+		_, ok := policy.Get(key)
+		assert.True(t, ok, "expected %q to be cached", key)
+	}
+	for _, key := range absent {
+		_, ok := policy.Get(key)
+		assert.False(t, ok, "expected %q not to be cached", key)
+	}
+}
+
+func TestEvictionPolicyBasicBehavior(t *testing.T) {
+	for name, newPolicy := range map[string]func(int) subsystems.CacheEvictionPolicy{
+		"LRU": LRUEvictionPolicy,
+		"LFU": LFUEvictionPolicy,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("get returns added value", func(t *testing.T) {
+				policy := newPolicy(10)
+				membership := membershipIncluding("a")
+				policy.Add("key1", membership)
+				policy.Add("key2", nil)
+
+				value, ok := policy.Get("key1")
+				assert.True(t, ok)
+				assert.Equal(t, membership, value)
+				value, ok = policy.Get("key2")
+				assert.True(t, ok)
+				assert.Nil(t, value)
+				value, ok = policy.Get("key3")
+				assert.False(t, ok)
+				assert.Nil(t, value)
+			})
+
+			t.Run("add replaces value", func(t *testing.T) {
+				policy := newPolicy(10)
+				policy.Add("key1", membershipIncluding("a"))
+				policy.Add("key1", membershipIncluding("b"))
+
+				value, _ := policy.Get("key1")
+				assert.Equal(t, membershipIncluding("b"), value)
+			})
+
+			t.Run("size is limited", func(t *testing.T) {
+				policy := newPolicy(3)
+				for i := 0; i < 10; i++ {
+					policy.Add(fmt.Sprintf("key%d", i), nil)
+				}
+				count := 0
+				for i := 0; i < 10; i++ {
+					if _, ok := policy.Get(fmt.Sprintf("key%d", i)); ok {
+						count++
+					}
+				}
+				assert.Equal(t, 3, count)
+			})
+
+			t.Run("default size", func(t *testing.T) {
+				policy := newPolicy(0)
+				for i := 0; i <= DefaultBigSegmentsContextCacheSize; i++ {
+					policy.Add(fmt.Sprintf("key%d", i), nil)
+				}
+				assertCachedKeys(t, policy, []string{fmt.Sprintf("key%d", DefaultBigSegmentsContextCacheSize)},
+					[]string{"key0"})
+			})
+
+			t.Run("concurrent use", func(t *testing.T) {
+				policy := newPolicy(5)
+				var wg sync.WaitGroup
+				for i := 0; i < 4; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						for j := 0; j < 1000; j++ {
+							key := fmt.Sprintf("key%d", (i*j)%8)
+							policy.Add(key, nil)
+							_, _ = policy.Get(key)
+						}
+					}(i)
+				}
+				wg.Wait()
+			})
+		})
+	}
+}
+
+func TestLRUEvictionPolicyDiscardsLeastRecentlyUsed(t *testing.T) {
+	policy := LRUEvictionPolicy(2)
+	policy.Add("key1", nil)
+	policy.Add("key2", nil)
+	_, _ = policy.Get("key1")
+	policy.Add("key3", nil)
+
+	assertCachedKeys(t, policy, []string{"key1", "key3"}, []string{"key2"})
+}
+
+func TestLFUEvictionPolicyDiscardsLeastFrequentlyUsed(t *testing.T) {
+	policy := LFUEvictionPolicy(2)
+	policy.Add("frequent", nil)
+	for i := 0; i < 5; i++ {
+		_, _ = policy.Get("frequent")
+	}
+	for i := 0; i < 10; i++ {
+		policy.Add(fmt.Sprintf("other%d", i), nil)
+	}
+
+	// An LRU cache would have discarded "frequent" as soon as two other keys were added.
+	assertCachedKeys(t, policy, []string{"frequent", "other9"}, []string{"other0", "other8"})
+}
+
+func TestLFUEvictionPolicyDiscardsLeastRecentlyUsedOfEquallyUsedEntries(t *testing.T) {
+	policy := LFUEvictionPolicy(3)
+	policy.Add("key1", nil)
+	policy.Add("key2", nil)
+	policy.Add("key3", nil)
+	_, _ = policy.Get("key1")
+	_, _ = policy.Get("key2") // key1 and key2 have both been used twice, but key1 less recently
+	_, _ = policy.Get("key3")
+	_, _ = policy.Get("key3")
+	policy.Add("key4", nil)
+
+	// The new entry has been used only once, so it is the next to be discarded.
+	policy.Add("key5", nil)
+
+	assertCachedKeys(t, policy, []string{"key2", "key3", "key5"}, []string{"key1", "key4"})
+}
@@ -0,0 +1,75 @@
+package ldcomponents
+
+import (
+	"errors"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datasource"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+type daemonModeDataSourceFactory struct {
+	storeFactory subsystems.ComponentConfigurer[subsystems.DataStore]
+}
+
+// DaemonModeDataSource returns a configuration object for getting feature flag data from a persistent data
+// store that is populated by the Relay Proxy in daemon mode, without connecting to LaunchDarkly.
+//
+// This differs from using [ExternalUpdatesOnly] with a persistent Config.DataStore in that the data is read
+// from the persistent store only once, when the SDK starts, and is then held in the SDK's own data store
+// (in memory, by default). That makes it a good fit for short-lived processes such as serverless
+// functions, where every evaluation can then be served without a database query. Any changes that the
+// Relay Proxy makes to the store after that point are not seen until the SDK is restarted.
+//
+// The storeFactory parameter is built the same way as a Config.DataStore value, for instance with
+// [PersistentDataStore] and a database integration:
+//
+//	config := ld.Config{
+//	    DataSource: ldcomponents.DaemonModeDataSource(
+//	        ldcomponents.PersistentDataStore(lddynamodb.DataStore("my-relay-table")),
+//	    ),
+//	}
+//
+// The SDK is considered initialized only if the persistent store had already been populated when it
+// started. The data source never opens a streaming or polling connection.
+func DaemonModeDataSource(
+	storeFactory subsystems.ComponentConfigurer[subsystems.DataStore],
+) subsystems.ComponentConfigurer[subsystems.DataSource] {
+	return daemonModeDataSourceFactory{storeFactory: storeFactory}
+}
+
+// DataSourceFactory implementation
+func (f daemonModeDataSourceFactory) Build(
+	context subsystems.ClientContext,
+) (subsystems.DataSource, error) {
+	if f.storeFactory == nil {
+		return nil, errors.New("daemon mode data source requires a data store")
+	}
+	// The source store reports its status to a sink of its own, since its status is not the status of
+	// the SDK's data store.
+	sourceContext := subsystems.BasicClientContext{
+		SDKKey:              context.GetSDKKey(),
+		ApplicationInfo:     context.GetApplicationInfo(),
+		HTTP:                context.GetHTTP(),
+		Logging:             context.GetLogging(),
+		Offline:             context.GetOffline(),
+		ServiceEndpoints:    context.GetServiceEndpoints(),
+		DataStoreUpdateSink: datastore.NewDataStoreUpdateSinkImpl(internal.NewBroadcaster[interfaces.DataStoreStatus]()),
+	}
+	source, err := f.storeFactory.Build(sourceContext)
+	if err != nil {
+		return nil, err
+	}
+	context.GetLogging().Loggers.Info("LaunchDarkly client will read feature flag data from the daemon mode data store")
+	return datasource.NewPersistentStoreDataSource(context, context.GetDataSourceUpdateSink(), source), nil
+}
+
+// DiagnosticDescription implementation
+func (f daemonModeDataSourceFactory) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+	return ldvalue.ObjectBuild().
+		SetBool("usingRelayDaemon", true).
+		Build()
+}
@@ -0,0 +1,66 @@
+package ldcomponents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datasource"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonModeDataSource(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	persistentStore := mocks.NewMockPersistentDataStore()
+	require.NoError(t, persistentStore.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: flag.Key, Item: ldstoretypes.SerializedItemDescriptor{
+				Version:        flag.Version,
+				SerializedItem: datakinds.Features.Serialize(sharedtest.FlagDescriptor(flag)),
+			}},
+		}},
+		{Kind: datakinds.Segments},
+	}))
+
+	store := datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers())
+	dsu := mocks.NewMockDataSourceUpdates(store)
+	context := subsystems.BasicClientContext{DataSourceUpdateSink: dsu, Logging: sharedtest.TestLoggingConfig()}
+	ds, err := DaemonModeDataSource(
+		PersistentDataStore(mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: persistentStore}),
+	).Build(context)
+	require.NoError(t, err)
+	defer ds.Close()
+	assert.IsType(t, &datasource.PersistentStoreDataSource{}, ds)
+
+	closeWhenReady := make(chan struct{})
+	ds.Start(closeWhenReady)
+	th.AssertChannelClosed(t, closeWhenReady, time.Second, "timed out waiting for data source to start")
+
+	assert.True(t, ds.IsInitialized())
+	dsu.RequireStatusOf(t, interfaces.DataSourceStateValid)
+	item, err := store.Get(datakinds.Features, flag.Key)
+	require.NoError(t, err)
+	assert.Equal(t, flag.Version, item.Version)
+
+	assert.Equal(t, ldvalue.ObjectBuild().SetBool("usingRelayDaemon", true).Build(),
+		DaemonModeDataSource(nil).(subsystems.DiagnosticDescription).DescribeConfiguration(context))
+}
+
+func TestDaemonModeDataSourceWithoutStore(t *testing.T) {
+	context := subsystems.BasicClientContext{Logging: sharedtest.TestLoggingConfig()}
+	ds, err := DaemonModeDataSource(nil).Build(context)
+	assert.Error(t, err)
+	assert.Nil(t, ds)
+}
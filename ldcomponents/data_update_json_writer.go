@@ -0,0 +1,53 @@
+package ldcomponents
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+)
+
+type dataUpdateJSONWriter struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+// DataUpdateJSONWriter returns an implementation of [interfaces.DataUpdateListener] that writes each
+// change to the specified writer as a single line of JSON.
+//
+// Each line is an object with the properties "time" (in RFC3339 format), "kind", "key", "oldVersion",
+// "newVersion", "deleted", "operation", and "source". The version properties are omitted if there was no
+// such version. Store the return value in the DataUpdateListener field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataUpdateListener: ldcomponents.DataUpdateJSONWriter(auditFile),
+//	}
+func DataUpdateJSONWriter(writer io.Writer) interfaces.DataUpdateListener {
+	return &dataUpdateJSONWriter{writer: writer}
+}
+
+func (w *dataUpdateJSONWriter) OnDataUpdate(record interfaces.DataUpdateRecord) error {
+	obj := ldvalue.ObjectBuild().
+		SetString("time", record.Time.UTC().Format(time.RFC3339Nano)).
+		SetString("kind", record.Kind).
+		SetString("key", record.Key)
+	if record.OldVersion.IsDefined() {
+		obj.SetInt("oldVersion", record.OldVersion.IntValue())
+	}
+	if record.NewVersion.IsDefined() {
+		obj.SetInt("newVersion", record.NewVersion.IntValue())
+	}
+	line := obj.
+		SetBool("deleted", record.Deleted).
+		SetString("operation", string(record.Operation)).
+		SetString("source", string(record.Source)).
+		Build().JSONString() + "\n"
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := io.WriteString(w.writer, line)
+	return err
+}
@@ -0,0 +1,47 @@
+package ldcomponents
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataUpdateJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := DataUpdateJSONWriter(&buf)
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, w.OnDataUpdate(interfaces.DataUpdateRecord{
+		Time: when, Kind: "features", Key: "flag1",
+		OldVersion: ldvalue.NewOptionalInt(1), NewVersion: ldvalue.NewOptionalInt(2),
+		Operation: interfaces.DataUpdateOperationUpsert, Source: interfaces.DataUpdateSourceStreaming,
+	}))
+	require.NoError(t, w.OnDataUpdate(interfaces.DataUpdateRecord{
+		Time: when, Kind: "segments", Key: "segment1", OldVersion: ldvalue.NewOptionalInt(3), Deleted: true,
+		Operation: interfaces.DataUpdateOperationInit, Source: interfaces.DataUpdateSourcePolling,
+	}))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	jsonhelpers.AssertEqual(t, `{"time":"2024-03-01T12:00:00Z","kind":"features","key":"flag1",
+		"oldVersion":1,"newVersion":2,"deleted":false,"operation":"upsert","source":"streaming"}`, lines[0])
+	jsonhelpers.AssertEqual(t, `{"time":"2024-03-01T12:00:00Z","kind":"segments","key":"segment1",
+		"oldVersion":3,"deleted":true,"operation":"init","source":"polling"}`, lines[1])
+}
+
+func TestDataUpdateJSONWriterReturnsWriteError(t *testing.T) {
+	w := DataUpdateJSONWriter(failingWriter{})
+	assert.Error(t, w.OnDataUpdate(interfaces.DataUpdateRecord{Key: "flag1"}))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, assert.AnError }
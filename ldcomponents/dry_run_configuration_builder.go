@@ -0,0 +1,72 @@
+package ldcomponents
+
+import (
+	"errors"
+	"io"
+
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// DryRunConfigurationBuilder contains methods for configuring the SDK's dry-run mode.
+//
+// Create a builder with ldcomponents.[DryRunOverrides](), change its properties with the
+// DryRunConfigurationBuilder methods, and store it in the DryRunOverrides field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DryRunOverrides: ldcomponents.DryRunOverrides(ldfiledata.DataSource().FilePaths("qa-flags.json")).
+//	        EventWriter(dryRunLog),
+//	}
+type DryRunConfigurationBuilder struct {
+	overrides   subsystems.ComponentConfigurer[subsystems.DataSource]
+	eventWriter io.Writer
+}
+
+// DryRunOverrides returns a configuration builder for evaluating flags as if some of them were in
+// different states, without changing the data store and without sending analytics events.
+//
+// The overrides parameter is a data source factory, typically ldfiledata.DataSource(), that provides the
+// flags and segments to use instead of the ones in the data store. It puts them into a separate in-memory
+// store; any flag or segment that it does not provide is read from the data store as usual. The regular
+// data source still updates the data store, so the application can compare its results with those of a
+// client that is not in dry-run mode.
+//
+// In dry-run mode, the client does not deliver analytics events to LaunchDarkly, regardless of the Events
+// setting; see [DryRunConfigurationBuilder.EventWriter].
+func DryRunOverrides(
+	overrides subsystems.ComponentConfigurer[subsystems.DataSource],
+) *DryRunConfigurationBuilder {
+	return &DryRunConfigurationBuilder{overrides: overrides}
+}
+
+// EventWriter sets the destination for the analytics events that the client generates in dry-run mode.
+//
+// The events are in the same format that the SDK would send to LaunchDarkly, with an additional property
+// "dry_run" whose value is true. They are written as one line of JSON per event, whenever the client
+// flushes events. If the writer is nil, which is the default, the events are discarded.
+func (b *DryRunConfigurationBuilder) EventWriter(writer io.Writer) *DryRunConfigurationBuilder {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("DryRunConfigurationBuilder")
+		return b
+	}
+	b.eventWriter = writer
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *DryRunConfigurationBuilder) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.DryRunConfiguration, error) {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("DryRunConfigurationBuilder")
+		return subsystems.DryRunConfiguration{}, nil
+	}
+	if b.overrides == nil {
+		return subsystems.DryRunConfiguration{}, errors.New("dry-run mode requires a data source for the overrides")
+	}
+	return subsystems.DryRunConfiguration{
+		Overrides:   b.overrides,
+		EventWriter: b.eventWriter,
+	}, nil
+}
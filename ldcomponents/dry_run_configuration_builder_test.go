@@ -0,0 +1,40 @@
+package ldcomponents
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunConfigurationBuilder(t *testing.T) {
+	basicConfig := subsystems.BasicClientContext{}
+	overrides := ExternalUpdatesOnly()
+
+	t.Run("defaults", func(t *testing.T) {
+		c, err := DryRunOverrides(overrides).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, overrides, c.Overrides)
+		assert.Nil(t, c.EventWriter)
+	})
+
+	t.Run("EventWriter", func(t *testing.T) {
+		var buf bytes.Buffer
+		c, err := DryRunOverrides(overrides).EventWriter(&buf).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, &buf, c.EventWriter)
+	})
+
+	t.Run("nil overrides", func(t *testing.T) {
+		_, err := DryRunOverrides(nil).Build(basicConfig)
+		assert.Error(t, err)
+	})
+
+	t.Run("nil safety", func(t *testing.T) {
+		var b *DryRunConfigurationBuilder
+		b = b.EventWriter(nil)
+		_, _ = b.Build(basicConfig)
+	})
+}
@@ -0,0 +1,96 @@
+package ldcomponents
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// DefaultEvaluationCacheCapacity is the default value for [EvaluationCacheConfigurationBuilder.Capacity].
+const DefaultEvaluationCacheCapacity = 10000
+
+// DefaultEvaluationCacheTTL is the default value for [EvaluationCacheConfigurationBuilder.TTL].
+const DefaultEvaluationCacheTTL = time.Minute
+
+// EvaluationCacheConfigurationBuilder contains methods for configuring the SDK's evaluation cache.
+//
+// Create a builder with ldcomponents.[EvaluationCache](), change its properties with the
+// EvaluationCacheConfigurationBuilder methods, and store it in the EvaluationCache field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    EvaluationCache: ldcomponents.EvaluationCache().Capacity(50000).TTL(30 * time.Second),
+//	}
+type EvaluationCacheConfigurationBuilder struct {
+	capacity int
+	ttl      time.Duration
+}
+
+// EvaluationCache returns a configuration builder for keeping the results of recent evaluations in
+// memory, so that evaluating the same flag for the same context does not have to wait for the data store.
+//
+// This is meant for latency-sensitive applications that use a persistent data store. When a result is in
+// the cache, the variation methods return it immediately and evaluate the flag again in the background to
+// update the cache; otherwise they evaluate the flag as usual and add the result. Analytics events
+// describe the result that was returned. Results for a flag are discarded as soon as the SDK receives a
+// change to that flag or to anything it depends on, such as a segment.
+//
+// Results are kept separately for each flag and each distinct evaluation context, compared by all of the
+// context's attributes. A result is only cached if the flag was found in the data store.
+//
+// By default, the SDK does not cache evaluations.
+func EvaluationCache() *EvaluationCacheConfigurationBuilder {
+	return &EvaluationCacheConfigurationBuilder{
+		capacity: DefaultEvaluationCacheCapacity,
+		ttl:      DefaultEvaluationCacheTTL,
+	}
+}
+
+// Capacity sets the maximum number of results to keep. When the cache is full, the least recently used
+// result is discarded.
+//
+// The default is [DefaultEvaluationCacheCapacity]. A value of zero or less sets it to the default.
+func (b *EvaluationCacheConfigurationBuilder) Capacity(capacity int) *EvaluationCacheConfigurationBuilder {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("EvaluationCacheConfigurationBuilder")
+		return b
+	}
+	if capacity <= 0 {
+		capacity = DefaultEvaluationCacheCapacity
+	}
+	b.capacity = capacity
+	return b
+}
+
+// TTL sets the maximum age of a result that can be returned from the cache. An older result is
+// discarded, and the flag is evaluated as if there had been no result. Since every use of a cached
+// result also causes it to be refreshed in the background, this mainly affects results that are
+// not used often.
+//
+// The default is [DefaultEvaluationCacheTTL]. A value of zero or less sets it to the default.
+func (b *EvaluationCacheConfigurationBuilder) TTL(ttl time.Duration) *EvaluationCacheConfigurationBuilder {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("EvaluationCacheConfigurationBuilder")
+		return b
+	}
+	if ttl <= 0 {
+		ttl = DefaultEvaluationCacheTTL
+	}
+	b.ttl = ttl
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *EvaluationCacheConfigurationBuilder) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.EvaluationCacheConfiguration, error) {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("EvaluationCacheConfigurationBuilder")
+		return subsystems.EvaluationCacheConfiguration{}, nil
+	}
+	return subsystems.EvaluationCacheConfiguration{
+		Capacity: b.capacity,
+		TTL:      b.ttl,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package ldcomponents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationCacheConfigurationBuilder(t *testing.T) {
+	basicConfig := subsystems.BasicClientContext{}
+
+	t.Run("defaults", func(t *testing.T) {
+		c, err := EvaluationCache().Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultEvaluationCacheCapacity, c.Capacity)
+		assert.Equal(t, DefaultEvaluationCacheTTL, c.TTL)
+	})
+
+	t.Run("Capacity", func(t *testing.T) {
+		c, err := EvaluationCache().Capacity(5).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, c.Capacity)
+
+		c, err = EvaluationCache().Capacity(5).Capacity(0).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultEvaluationCacheCapacity, c.Capacity)
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		c, err := EvaluationCache().TTL(time.Second).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second, c.TTL)
+
+		c, err = EvaluationCache().TTL(time.Second).TTL(-1).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultEvaluationCacheTTL, c.TTL)
+	})
+
+	t.Run("nil safety", func(t *testing.T) {
+		var b *EvaluationCacheConfigurationBuilder
+		b = b.Capacity(5).TTL(time.Second)
+		_, _ = b.Build(basicConfig)
+	})
+}
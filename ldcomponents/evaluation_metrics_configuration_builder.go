@@ -0,0 +1,73 @@
+package ldcomponents
+
+import (
+	"github.com/launchdarkly/go-server-sdk/v7/internal"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// DefaultEvaluationMetricsFlagKeyLimit is the default value for
+// [EvaluationMetricsConfigurationBuilder.FlagKeyLimit].
+const DefaultEvaluationMetricsFlagKeyLimit = 1000
+
+// EvaluationMetricsConfigurationBuilder contains methods for configuring the SDK's evaluation metrics.
+//
+// Create a builder with ldcomponents.[EvaluationMetrics](), change its properties with the
+// EvaluationMetricsConfigurationBuilder methods, and store it in the EvaluationMetrics field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    EvaluationMetrics: ldcomponents.EvaluationMetrics(mySink).FlagKeyLimit(100),
+//	}
+type EvaluationMetricsConfigurationBuilder struct {
+	sink         subsystems.EvaluationMetricsSink
+	flagKeyLimit int
+}
+
+// EvaluationMetrics returns a configuration builder for sending evaluation metrics to the specified
+// [subsystems.EvaluationMetricsSink].
+//
+// By default, the SDK does not record any evaluation metrics, and the variation methods do no extra work.
+// The ldexpvar package provides a sink that publishes the metrics through the standard expvar package.
+//
+//	metrics := ldexpvar.NewEvaluationMetrics()
+//	metrics.Publish("launchdarkly")
+//	config := ld.Config{
+//	    EvaluationMetrics: ldcomponents.EvaluationMetrics(metrics),
+//	}
+func EvaluationMetrics(sink subsystems.EvaluationMetricsSink) *EvaluationMetricsConfigurationBuilder {
+	return &EvaluationMetricsConfigurationBuilder{
+		sink:         sink,
+		flagKeyLimit: DefaultEvaluationMetricsFlagKeyLimit,
+	}
+}
+
+// FlagKeyLimit sets the maximum number of distinct flag keys that will be reported to the sink. After
+// that many keys have been seen, evaluations of any other flag are reported with the flag key
+// [subsystems.EvaluationMetricsOtherFlagKey]. This keeps the cardinality of metrics labels bounded.
+//
+// The default is [DefaultEvaluationMetricsFlagKeyLimit]. A value of zero or less sets it to the default.
+func (b *EvaluationMetricsConfigurationBuilder) FlagKeyLimit(limit int) *EvaluationMetricsConfigurationBuilder {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("EvaluationMetricsConfigurationBuilder")
+		return b
+	}
+	if limit <= 0 {
+		limit = DefaultEvaluationMetricsFlagKeyLimit
+	}
+	b.flagKeyLimit = limit
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *EvaluationMetricsConfigurationBuilder) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.EvaluationMetricsConfiguration, error) {
+	if b == nil {
+		internal.LogErrorNilPointerMethod("EvaluationMetricsConfigurationBuilder")
+		return subsystems.EvaluationMetricsConfiguration{}, nil
+	}
+	return subsystems.EvaluationMetricsConfiguration{
+		Sink:         b.sink,
+		FlagKeyLimit: b.flagKeyLimit,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package ldcomponents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubEvaluationMetricsSink struct{}
+
+func (stubEvaluationMetricsSink) IncEvaluationCount(string)                       {}
+func (stubEvaluationMetricsSink) IncErrorCount(string, ldreason.EvalErrorKind)    {}
+func (stubEvaluationMetricsSink) ObserveEvaluationDuration(string, time.Duration) {}
+
+func TestEvaluationMetricsConfigurationBuilder(t *testing.T) {
+	basicConfig := subsystems.BasicClientContext{}
+	sink := stubEvaluationMetricsSink{}
+
+	t.Run("defaults", func(t *testing.T) {
+		c, err := EvaluationMetrics(sink).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, sink, c.Sink)
+		assert.Equal(t, DefaultEvaluationMetricsFlagKeyLimit, c.FlagKeyLimit)
+	})
+
+	t.Run("FlagKeyLimit", func(t *testing.T) {
+		c, err := EvaluationMetrics(sink).FlagKeyLimit(5).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, c.FlagKeyLimit)
+
+		c, err = EvaluationMetrics(sink).FlagKeyLimit(5).FlagKeyLimit(0).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultEvaluationMetricsFlagKeyLimit, c.FlagKeyLimit)
+	})
+
+	t.Run("nil sink", func(t *testing.T) {
+		c, err := EvaluationMetrics(nil).Build(basicConfig)
+		assert.NoError(t, err)
+		assert.Nil(t, c.Sink)
+	})
+
+	t.Run("nil safety", func(t *testing.T) {
+		var b *EvaluationMetricsConfigurationBuilder
+		b = b.FlagKeyLimit(5)
+		_, _ = b.Build(basicConfig)
+	})
+}
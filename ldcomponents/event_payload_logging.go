@@ -0,0 +1,41 @@
+package ldcomponents
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+// payloadSummaryLoggingEventSender implements the default behavior of
+// LoggingConfigurationBuilder.LogContextAttributes. The event sender logs the whole payload at Debug
+// level, and analytics payloads contain the attributes of every context that is not private, so the
+// event sender is given loggers that do not output Debug messages, and this logs only the size of each
+// payload instead.
+type payloadSummaryLoggingEventSender struct {
+	sender  ldevents.EventSender
+	loggers ldlog.Loggers
+}
+
+// withoutPayloadLogging creates the event sender with makeSender. If context attributes should not be
+// logged and Debug logging is enabled, the sender gets loggers without Debug output instead, and it is
+// wrapped to log the payload sizes.
+func withoutPayloadLogging(
+	makeSender func(ldlog.Loggers) ldevents.EventSender,
+	loggers ldlog.Loggers,
+	logContextAttributes bool,
+) ldevents.EventSender {
+	if logContextAttributes || !loggers.IsDebugEnabled() {
+		return makeSender(loggers)
+	}
+	senderLoggers := loggers
+	senderLoggers.SetMinLevel(ldlog.Info)
+	return payloadSummaryLoggingEventSender{sender: makeSender(senderLoggers), loggers: loggers}
+}
+
+func (s payloadSummaryLoggingEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	s.loggers.Debugf("Sending %s payload: %d event(s), %d bytes", kind, eventCount, len(data))
+	return s.sender.SendEventData(kind, data, eventCount)
+}
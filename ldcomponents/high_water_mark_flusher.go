@@ -0,0 +1,177 @@
+package ldcomponents
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+// highWaterMarkFlusher implements EventProcessorBuilder.FlushHighWaterMark. The event processor only
+// flushes on its timer, or when asked to, and it does not tell us how full its buffer is; so this counts
+// the recorded events that take up space in the buffer, and asks for a flush once the count reaches the
+// threshold. The flush request goes into the event processor's inbox behind those events, so the payload
+// it produces contains all of them.
+//
+// Identify, custom, and migration events each take one place in the buffer. An evaluation takes one for
+// a full event and one for a debug event, if it has either; one that only goes into the summary takes
+// none, unless it needs an index event. Custom events and evaluations need an index event the first time
+// the event processor sees their context, but the context of an event can't be read from outside the
+// event processor; so each of them is counted as needing one, up to as many per flush as the number of
+// context keys that the event processor remembers, after which it is assumed that any more index events
+// would be for contexts that have already been seen. That is not true if there are more distinct contexts
+// than the event processor can remember; so if a payload is delivered with that many index events, every
+// event that could need one is counted until a payload is delivered with fewer.
+//
+// The count starts over when a flush is requested, so any number of events that arrive while that flush
+// is pending are covered by the same request until they reach the threshold again; and it also starts
+// over when any other flush delivers a payload. Flushes that are requested this way are never closer
+// together than minSpacing, so that a sustained overload can't cause a constant stream of small payloads;
+// in that case some events may still be dropped, as they would be without the high-water mark.
+type highWaterMarkFlusher struct {
+	ldevents.EventProcessor
+	threshold           int64
+	minSpacing          time.Duration
+	contextKeysCapacity int64
+	count               atomic.Int64
+	indexEvents         atomic.Int64
+	countAllIndexEvents atomic.Bool
+	flushPending        atomic.Bool
+	lastFlushAt         atomic.Int64 // Unix nanoseconds
+	lock                sync.Mutex
+}
+
+// indexEventMarker is the JSON that every index event in a payload contains. A custom event's data could
+// contain it too, but that would only make us count more index events than there are.
+var indexEventMarker = []byte(`"kind":"` + ldevents.IndexEventKind + `"`) //nolint:gochecknoglobals
+
+type highWaterMarkFlusherEventSender struct {
+	flusher *highWaterMarkFlusher
+	sender  ldevents.EventSender
+}
+
+func newHighWaterMarkFlusher(
+	capacity int,
+	fraction float64,
+	minSpacing time.Duration,
+	contextKeysCapacity int,
+) *highWaterMarkFlusher {
+	threshold := int64(float64(capacity) * fraction)
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &highWaterMarkFlusher{
+		threshold:           threshold,
+		minSpacing:          minSpacing,
+		contextKeysCapacity: int64(contextKeysCapacity),
+	}
+}
+
+// wrapEventProcessor must be called, once, before any events are recorded.
+func (f *highWaterMarkFlusher) wrapEventProcessor(ep ldevents.EventProcessor) ldevents.EventProcessor {
+	f.EventProcessor = ep
+	return f
+}
+
+func (f *highWaterMarkFlusher) wrapEventSender(sender ldevents.EventSender) ldevents.EventSender {
+	return highWaterMarkFlusherEventSender{flusher: f, sender: sender}
+}
+
+func (f *highWaterMarkFlusher) RecordEvaluation(e ldevents.EvaluationData) {
+	f.EventProcessor.RecordEvaluation(e)
+	var count int64
+	if e.RequireFullEvent {
+		count++
+	}
+	if e.DebugEventsUntilDate != 0 && e.DebugEventsUntilDate > ldtime.UnixMillisNow() {
+		count++
+	}
+	f.eventsRecorded(count + f.possibleIndexEvent())
+}
+
+func (f *highWaterMarkFlusher) RecordIdentifyEvent(e ldevents.IdentifyEventData) {
+	f.EventProcessor.RecordIdentifyEvent(e)
+	f.eventsRecorded(1) // this contains the context, so it never needs an index event
+}
+
+func (f *highWaterMarkFlusher) RecordCustomEvent(e ldevents.CustomEventData) {
+	f.EventProcessor.RecordCustomEvent(e)
+	f.eventsRecorded(1 + f.possibleIndexEvent())
+}
+
+func (f *highWaterMarkFlusher) RecordMigrationOpEvent(e ldevents.MigrationOpEventData) {
+	f.EventProcessor.RecordMigrationOpEvent(e)
+	f.eventsRecorded(1)
+}
+
+func (f *highWaterMarkFlusher) RecordRawEvent(data json.RawMessage) {
+	f.EventProcessor.RecordRawEvent(data)
+	f.eventsRecorded(1)
+}
+
+// possibleIndexEvent returns 1 if an event for a context that we can't see should be counted as needing
+// an index event, or 0 if there have already been as many of those since the last flush as the event
+// processor can remember context keys.
+func (f *highWaterMarkFlusher) possibleIndexEvent() int64 {
+	if f.contextKeysCapacity <= 0 || f.countAllIndexEvents.Load() {
+		return 1
+	}
+	if f.indexEvents.Load() >= f.contextKeysCapacity || f.indexEvents.Add(1) > f.contextKeysCapacity {
+		return 0
+	}
+	return 1
+}
+
+func (f *highWaterMarkFlusher) eventsRecorded(n int64) {
+	if n == 0 || f.count.Add(n) < f.threshold {
+		return
+	}
+	now := time.Now()
+	if now.Sub(time.Unix(0, f.lastFlushAt.Load())) < f.minSpacing {
+		return
+	}
+	f.lock.Lock()
+	// Another goroutine may have requested a flush since we checked.
+	count := f.count.Load()
+	if count < f.threshold || now.Sub(time.Unix(0, f.lastFlushAt.Load())) < f.minSpacing {
+		f.lock.Unlock()
+		return
+	}
+	// Subtracting rather than setting to zero keeps any events that were counted after the Load; they
+	// may or may not be ahead of the flush request, so it's safer to count them.
+	f.count.Add(-count)
+	f.indexEvents.Store(0)
+	f.flushPending.Store(true)
+	f.lastFlushAt.Store(now.UnixNano())
+	f.lock.Unlock()
+	f.EventProcessor.Flush()
+}
+
+// payloadSent is called when the event processor delivers a payload. If we requested a flush, this is
+// most likely the payload that it produced, and we already stopped counting its events at the time of
+// the request; otherwise it is from a flush on the event processor's timer, or one that the application
+// asked for, and the events that we counted before it are no longer in the buffer.
+func (f *highWaterMarkFlusher) payloadSent(data []byte) {
+	f.countAllIndexEvents.Store(int64(bytes.Count(data, indexEventMarker)) >= f.contextKeysCapacity)
+	f.lock.Lock()
+	if !f.flushPending.Swap(false) {
+		f.count.Store(0)
+		f.indexEvents.Store(0)
+	}
+	f.lock.Unlock()
+}
+
+func (s highWaterMarkFlusherEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	if kind == ldevents.AnalyticsEventDataKind {
+		s.flusher.payloadSent(data)
+	}
+	return s.sender.SendEventData(kind, data, eventCount)
+}
@@ -1,6 +1,7 @@
 package ldcomponents
 
 import (
+	gocontext "context"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -18,8 +19,9 @@ import (
 //	    Logging: ldcomponents.Logging().MinLevel(ldlog.Warn),
 //	}
 type LoggingConfigurationBuilder struct {
-	inited bool
-	config subsystems.LoggingConfiguration
+	inited              bool
+	config              subsystems.LoggingConfiguration
+	sampledMaxPerMinute int
 }
 
 // DefaultLogDataSourceOutageAsErrorAfter is the default value for
@@ -97,6 +99,20 @@ func (b *LoggingConfigurationBuilder) LogContextKeyInErrors(logContextKeyInError
 	return b
 }
 
+// LogContextAttributes sets whether Debug-level logging can include the attributes of evaluation contexts.
+// By default, it will not, since they might be considered privileged information.
+//
+// The SDK does not log contexts itself, but at Debug level the event sender logs each payload that it
+// sends, and analytics payloads contain every context attribute that is not private. If this is false,
+// only the number of events and the size of each payload are logged. Private attributes are never
+// logged in either case, since they are removed before the payload is created.
+func (b *LoggingConfigurationBuilder) LogContextAttributes(logContextAttributes bool) *LoggingConfigurationBuilder {
+	if b.checkValid() {
+		b.config.LogContextAttributes = logContextAttributes
+	}
+	return b
+}
+
 // Loggers specifies an instance of [ldlog.Loggers] to use for SDK logging. The ldlog package contains
 // methods for customizing the destination and level filtering of log output.
 func (b *LoggingConfigurationBuilder) Loggers(loggers ldlog.Loggers) *LoggingConfigurationBuilder {
@@ -119,6 +135,51 @@ func (b *LoggingConfigurationBuilder) MinLevel(level ldlog.LogLevel) *LoggingCon
 	return b
 }
 
+// SampledOutput limits how often similar log messages are written, so that a problem that causes the
+// SDK to log the same message repeatedly, such as a persistent data store being unavailable when every
+// evaluation needs it, does not flood the log.
+//
+// Messages are similar if they have the same level and were logged with the same format string; for
+// messages logged without a format string, their first 40 characters are compared instead. At most
+// maxPerMinute similar messages are written per minute: once that many have been written, the rest are
+// suppressed until enough time has passed, and then a message saying how many were suppressed is written
+// before the next one.
+//
+// This applies to whichever loggers are configured with [LoggingConfigurationBuilder.Loggers], or the
+// default loggers. If maxPerMinute is zero or negative, which is the default, messages are not limited.
+func (b *LoggingConfigurationBuilder) SampledOutput(maxPerMinute int) *LoggingConfigurationBuilder {
+	if b.checkValid() {
+		b.sampledMaxPerMinute = maxPerMinute
+	}
+	return b
+}
+
+// WithContextExtractor specifies a function that returns a prefix for the log messages from an
+// evaluation, such as a trace or request ID, based on the Go context that was passed to one of the
+// LDClient methods like [github.com/launchdarkly/go-server-sdk/v7.LDClient.BoolVariationCtx].
+//
+//	config := ld.Config{
+//	    Logging: ldcomponents.Logging().WithContextExtractor(func(ctx context.Context) string {
+//	        if id, ok := ctx.Value(requestIDKey).(string); ok {
+//	            return "[request " + id + "]"
+//	        }
+//	        return ""
+//	    }),
+//	}
+//
+// The function is called once for each such evaluation, so it should be fast. If it returns an empty
+// string, messages are logged without a prefix. Evaluations done with methods that do not take a Go
+// context, such as BoolVariation, are not affected. Messages about malformed flag data come from the
+// evaluation engine rather than the client and do not have the prefix.
+func (b *LoggingConfigurationBuilder) WithContextExtractor(
+	fn func(ctx gocontext.Context) string,
+) *LoggingConfigurationBuilder {
+	if b.checkValid() {
+		b.config.ContextExtractor = fn
+	}
+	return b
+}
+
 // Build is called internally by the SDK.
 func (b *LoggingConfigurationBuilder) Build(
 	clientContext subsystems.ClientContext,
@@ -127,7 +188,11 @@ func (b *LoggingConfigurationBuilder) Build(
 		defaults := LoggingConfigurationBuilder{}
 		return defaults.Build(clientContext)
 	}
-	return b.config, nil
+	config := b.config
+	if b.sampledMaxPerMinute > 0 {
+		config.Loggers = sampleLoggers(config.Loggers, newLogSampler(b.sampledMaxPerMinute))
+	}
+	return config, nil
 }
 
 // NoLogging returns a configuration object that disables logging.
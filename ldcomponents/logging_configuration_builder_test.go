@@ -1,6 +1,7 @@
 package ldcomponents
 
 import (
+	gocontext "context"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoggingConfigurationBuilder(t *testing.T) {
@@ -19,6 +21,7 @@ func TestLoggingConfigurationBuilder(t *testing.T) {
 		assert.Nil(t, err)
 		assert.False(t, c.LogEvaluationErrors)
 		assert.False(t, c.LogContextKeyInErrors)
+		assert.False(t, c.LogContextAttributes)
 	})
 
 	t.Run("LogDataSourceOutageAsErrorAfter", func(t *testing.T) {
@@ -39,6 +42,12 @@ func TestLoggingConfigurationBuilder(t *testing.T) {
 		assert.True(t, c.LogContextKeyInErrors)
 	})
 
+	t.Run("LogContextAttributes", func(t *testing.T) {
+		c, err := Logging().LogContextAttributes(true).Build(basicConfig)
+		assert.Nil(t, err)
+		assert.True(t, c.LogContextAttributes)
+	})
+
 	t.Run("Loggers", func(t *testing.T) {
 		mockLoggers := ldlogtest.NewMockLog()
 		c, err := Logging().Loggers(mockLoggers.Loggers).Build(basicConfig)
@@ -56,6 +65,27 @@ func TestLoggingConfigurationBuilder(t *testing.T) {
 		assert.Equal(t, []string{"log this message"}, mockLoggers.GetOutput(ldlog.Error))
 	})
 
+	t.Run("SampledOutput", func(t *testing.T) {
+		mockLoggers := ldlogtest.NewMockLog()
+		c, err := Logging().Loggers(mockLoggers.Loggers).SampledOutput(2).Build(basicConfig)
+		assert.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			c.Loggers.Warnf("store error %d", i)
+			c.Loggers.Warn("a message without a format")
+		}
+		c.Loggers.Errorf("store error %d", 0)
+		assert.Equal(t, []string{"store error 0", "a message without a format", "store error 1",
+			"a message without a format"}, mockLoggers.GetOutput(ldlog.Warn))
+		assert.Equal(t, []string{"store error 0"}, mockLoggers.GetOutput(ldlog.Error))
+	})
+
+	t.Run("WithContextExtractor", func(t *testing.T) {
+		c, err := Logging().WithContextExtractor(func(gocontext.Context) string { return "x" }).Build(basicConfig)
+		assert.Nil(t, err)
+		require.NotNil(t, c.ContextExtractor)
+		assert.Equal(t, "x", c.ContextExtractor(gocontext.Background()))
+	})
+
 	t.Run("NoLogging", func(t *testing.T) {
 		c, err := NoLogging().Build(basicConfig)
 		assert.Nil(t, err)
@@ -64,8 +94,9 @@ func TestLoggingConfigurationBuilder(t *testing.T) {
 
 	t.Run("nil safety", func(t *testing.T) {
 		var b *LoggingConfigurationBuilder = nil
-		b = b.LogContextKeyInErrors(true).LogDataSourceOutageAsErrorAfter(0).LogEvaluationErrors(true).
-			Loggers(ldlog.NewDefaultLoggers()).MinLevel(ldlog.Debug)
+		b = b.LogContextKeyInErrors(true).LogContextAttributes(true).LogDataSourceOutageAsErrorAfter(0).
+			LogEvaluationErrors(true).
+			Loggers(ldlog.NewDefaultLoggers()).MinLevel(ldlog.Debug).SampledOutput(1).WithContextExtractor(nil)
 		_, _ = b.Build(subsystems.BasicClientContext{})
 	})
 }
@@ -0,0 +1,28 @@
+package ldcomponents
+
+import (
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+type noOpEventProcessorFactory struct{}
+
+// NoOpEvents returns a configuration object that discards analytics events without disabling them.
+//
+// Unlike [NoEvents], which tells the SDK not to generate events at all, this causes the SDK to build
+// events exactly as it would with [SendEvents] and then pass them to an EventProcessor that silently
+// discards them. This is mainly useful in tests that should exercise the same evaluation path as a
+// production configuration, but without sending anything to LaunchDarkly.
+//
+//	config := ld.Config{
+//	    Events: ldcomponents.NoOpEvents(),
+//	}
+func NoOpEvents() subsystems.ComponentConfigurer[ldevents.EventProcessor] {
+	return noOpEventProcessorFactory{}
+}
+
+func (f noOpEventProcessorFactory) Build(
+	context subsystems.ClientContext,
+) (ldevents.EventProcessor, error) {
+	return ldevents.NewNullEventProcessor(), nil
+}
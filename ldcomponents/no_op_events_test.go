@@ -0,0 +1,20 @@
+package ldcomponents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+func TestNoOpEvents(t *testing.T) {
+	ep, err := NoOpEvents().Build(basicClientContext())
+	require.NoError(t, err)
+	defer ep.Close()
+	ef := ldevents.NewEventFactory(false, nil)
+	ep.RecordIdentifyEvent(ef.NewIdentifyEventData(ldevents.Context(lduser.NewUser("key")), ldvalue.OptionalInt{}))
+	ep.Flush()
+}
@@ -42,6 +42,7 @@ func PersistentDataStore(
 	return &PersistentDataStoreBuilder{
 		persistentDataStoreFactory: persistentDataStoreFactory,
 		cacheTTL:                   PersistentDataStoreDefaultCacheTime,
+		incrementalInit:            true,
 	}
 }
 
@@ -61,6 +62,8 @@ func PersistentDataStore(
 type PersistentDataStoreBuilder struct {
 	persistentDataStoreFactory subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
 	cacheTTL                   time.Duration
+	incrementalInit            bool
+	metrics                    subsystems.DataStoreMetricsSink
 }
 
 // CacheTime specifies the cache TTL. Items will be evicted from the cache after this amount of time
@@ -97,6 +100,32 @@ func (b *PersistentDataStoreBuilder) NoCaching() *PersistentDataStoreBuilder {
 	return b.CacheTime(0)
 }
 
+// IncrementalInit specifies whether the SDK should avoid rewriting the entire data store whenever it
+// receives a full set of flag data, as it does every time a stream connection is made.
+//
+// If true (the default), then if the store has already been initialized, whether by this SDK instance or
+// a previous one, the SDK compares the new data with what is already in the store and writes only the
+// flags and segments that were added, changed, or removed. This greatly reduces database traffic after a
+// transient network problem or a restart. If some item in the new data has a lower version than the stored
+// one, or the store does not accept one of the changes, the SDK rewrites all of the data as usual.
+//
+// If false, the SDK always replaces the entire contents of the store.
+func (b *PersistentDataStoreBuilder) IncrementalInit(incrementalInit bool) *PersistentDataStoreBuilder {
+	b.incrementalInit = incrementalInit
+	return b
+}
+
+// Metrics specifies an object that receives the duration of each persistent data store operation, and
+// of each query that is answered from the in-memory cache instead; see [subsystems.DataStoreMetricsSink].
+// You can use ldexpvar.NewDataStoreMetrics to publish these as expvar variables, or provide your own
+// implementation for another metrics system.
+//
+// The default is nil, meaning that no timings are taken.
+func (b *PersistentDataStoreBuilder) Metrics(sink subsystems.DataStoreMetricsSink) *PersistentDataStoreBuilder {
+	b.metrics = sink
+	return b
+}
+
 // Build is called internally by the SDK.
 func (b *PersistentDataStoreBuilder) Build(clientContext subsystems.ClientContext) (subsystems.DataStore, error) {
 	core, err := b.persistentDataStoreFactory.Build(clientContext)
@@ -104,7 +133,7 @@ func (b *PersistentDataStoreBuilder) Build(clientContext subsystems.ClientContex
 		return nil, err
 	}
 	return datastore.NewPersistentDataStoreWrapper(core, clientContext.GetDataStoreUpdateSink(), b.cacheTTL,
-		clientContext.GetLogging().Loggers), nil
+		b.incrementalInit, b.metrics, clientContext.GetLogging().Loggers), nil
 }
 
 // DescribeConfiguration is used internally by the SDK to inspect the configuration.
@@ -82,6 +82,25 @@ func TestPersistentDataStoreBuilder(t *testing.T) {
 		assert.Equal(t, time.Duration(0), f.cacheTTL)
 	})
 
+	t.Run("IncrementalInit", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+		assert.True(t, f.incrementalInit)
+
+		f.IncrementalInit(false)
+		assert.False(t, f.incrementalInit)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+		assert.Nil(t, f.metrics)
+
+		sink := &mockDataStoreMetricsSink{}
+		f.Metrics(sink)
+		assert.Same(t, sink, f.metrics)
+	})
+
 	t.Run("diagnostic description", func(t *testing.T) {
 		f1 := PersistentDataStore(&mockPersistentDataStoreFactory{})
 		assert.Equal(t, ldvalue.String("custom"), f1.DescribeConfiguration(basicClientContext()))
@@ -104,6 +123,11 @@ func (m *mockPersistentDataStoreFactory) Build(
 	return m.store, m.fakeError
 }
 
+type mockDataStoreMetricsSink struct{}
+
+func (m *mockDataStoreMetricsSink) ObserveDataStoreOperation(subsystems.DataStoreOperation, time.Duration, bool, bool) {
+}
+
 type mockPersistentDataStoreFactoryWithDescription struct {
 	description ldvalue.Value
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datasource"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/endpoints"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
@@ -20,8 +21,10 @@ const DefaultPollInterval = 30 * time.Second
 //
 // See [PollingDataSource] for usage.
 type PollingDataSourceBuilder struct {
-	pollInterval time.Duration
-	filterKey    ldvalue.OptionalString
+	pollInterval   time.Duration
+	onDemandMaxAge time.Duration
+	deltaPolling   bool
+	filterKey      ldvalue.OptionalString
 }
 
 // PollingDataSource returns a configurable factory for using polling mode to get feature flag data.
@@ -56,6 +59,48 @@ func (b *PollingDataSourceBuilder) PollInterval(pollInterval time.Duration) *Pol
 	return b
 }
 
+// OnDemand turns on on-demand mode, in which the SDK does not poll at regular intervals.
+//
+// This is meant for environments such as AWS Lambda, where a background polling goroutine is undesirable
+// because the process may be suspended between requests. In on-demand mode, the SDK requests flag data
+// once when the client starts. After that, it makes another request only when a flag is evaluated and the
+// data it has is older than maxAge. That evaluation does not wait for the request: it uses the existing
+// data, and later evaluations will see the new data once the request has finished. There is never more
+// than one such request in progress at a time.
+//
+// The data source status (see LDClient.GetDataSourceStatusProvider) reflects the result of the most recent
+// request. If a request fails, the SDK will not try again until there is an evaluation after another maxAge
+// interval has elapsed.
+//
+// When this is set, [PollingDataSourceBuilder.PollInterval] is ignored. A value of zero or less turns
+// on-demand mode off.
+//
+//	config := ld.Config{
+//	    DataSource: ldcomponents.PollingDataSource().OnDemand(5 * time.Minute),
+//	}
+func (b *PollingDataSourceBuilder) OnDemand(maxAge time.Duration) *PollingDataSourceBuilder {
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	b.onDemandMaxAge = maxAge
+	return b
+}
+
+// DeltaPolling sets whether the SDK should request only the flag and segment changes since its previous
+// poll, rather than the full data set every time.
+//
+// This only has an effect if the polling service supports delta requests, which the SDK detects from the
+// first response; otherwise, the SDK continues to request all of the data on every poll. If the service
+// no longer has the changes since the SDK's previous poll, the SDK requests all of the data again. Delta
+// responses are applied as individual updates, so flag change listeners are notified the same way in
+// either case.
+//
+// The default is false.
+func (b *PollingDataSourceBuilder) DeltaPolling(enabled bool) *PollingDataSourceBuilder {
+	b.deltaPolling = enabled
+	return b
+}
+
 // Used in tests to skip parameter validation.
 //
 //nolint:unused // it is used in tests
@@ -92,9 +137,11 @@ func (b *PollingDataSourceBuilder) Build(context subsystems.ClientContext) (subs
 		context.GetLogging().Loggers,
 	)
 	cfg := datasource.PollingConfig{
-		BaseURI:      configuredBaseURI,
-		PollInterval: b.pollInterval,
-		FilterKey:    filterKey,
+		BaseURI:        configuredBaseURI,
+		PollInterval:   b.pollInterval,
+		FilterKey:      filterKey,
+		OnDemandMaxAge: b.onDemandMaxAge,
+		DeltaPolling:   b.deltaPolling,
 	}
 	pp := datasource.NewPollingProcessor(context, context.GetDataSourceUpdateSink(), cfg)
 	return pp, nil
@@ -110,3 +157,8 @@ func (b *PollingDataSourceBuilder) DescribeConfiguration(context subsystems.Clie
 		SetBool("usingRelayDaemon", false).
 		Build()
 }
+
+// DataUpdateSource is used internally by the SDK to describe this data source to a DataUpdateListener.
+func (b *PollingDataSourceBuilder) DataUpdateSource() interfaces.DataUpdateSource {
+	return interfaces.DataUpdateSourcePolling
+}
@@ -29,6 +29,46 @@ func TestPollingDataSourceBuilder(t *testing.T) {
 		assert.Equal(t, time.Second, p.pollInterval)
 	})
 
+	t.Run("OnDemand", func(t *testing.T) {
+		p := PollingDataSource()
+		assert.Equal(t, time.Duration(0), p.onDemandMaxAge)
+
+		p.OnDemand(time.Minute)
+		assert.Equal(t, time.Minute, p.onDemandMaxAge)
+
+		p.OnDemand(-time.Minute)
+		assert.Equal(t, time.Duration(0), p.onDemandMaxAge)
+
+		dsu := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+		clientContext := makeTestContextWithBaseURIs("base")
+		clientContext.BasicClientContext.DataSourceUpdateSink = dsu
+		ds, err := PollingDataSource().OnDemand(time.Minute).Build(clientContext)
+		require.NoError(t, err)
+		defer ds.Close()
+		assert.Equal(t, time.Minute, ds.(*datasource.PollingProcessor).GetOnDemandMaxAge())
+	})
+
+	t.Run("DeltaPolling", func(t *testing.T) {
+		p := PollingDataSource()
+		assert.False(t, p.deltaPolling)
+
+		p.DeltaPolling(true)
+		assert.True(t, p.deltaPolling)
+
+		dsu := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+		clientContext := makeTestContextWithBaseURIs("base")
+		clientContext.BasicClientContext.DataSourceUpdateSink = dsu
+		ds, err := p.Build(clientContext)
+		require.NoError(t, err)
+		defer ds.Close()
+		assert.True(t, ds.(*datasource.PollingProcessor).IsDeltaPolling())
+
+		ds2, err := PollingDataSource().Build(clientContext)
+		require.NoError(t, err)
+		defer ds2.Close()
+		assert.False(t, ds2.(*datasource.PollingProcessor).IsDeltaPolling())
+	})
+
 	t.Run("PayloadFilter", func(t *testing.T) {
 		t.Run("build succeeds with no payload filter", func(t *testing.T) {
 			s := PollingDataSource()
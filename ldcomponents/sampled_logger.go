@@ -0,0 +1,135 @@
+package ldcomponents
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// sampledLogKeyLength is how much of a message logged without a format string is used to decide whether
+// messages are similar.
+const sampledLogKeyLength = 40
+
+// sampledLogMaxKeys limits how many kinds of messages a logSampler keeps track of. Messages of any other
+// kind are never suppressed.
+const sampledLogMaxKeys = 1000
+
+var allLogLevels = []ldlog.LogLevel{ldlog.Debug, ldlog.Info, ldlog.Warn, ldlog.Error} //nolint:gochecknoglobals
+
+// logSampler rate-limits similar log messages with a token bucket for each kind of message.
+type logSampler struct {
+	maxPerMinute int
+	buckets      map[logSampleKey]*logSampleBucket
+	now          func() time.Time
+	lock         sync.Mutex
+}
+
+type logSampleKey struct {
+	level ldlog.LogLevel
+	text  string
+}
+
+type logSampleBucket struct {
+	tokens     float64
+	lastRefill time.Time
+	suppressed int
+}
+
+func newLogSampler(maxPerMinute int) *logSampler {
+	return &logSampler{
+		maxPerMinute: maxPerMinute,
+		buckets:      make(map[logSampleKey]*logSampleBucket),
+		now:          time.Now,
+	}
+}
+
+// allow returns true if a message of this kind should be logged, and the number of messages of the same
+// kind that were suppressed since the last one was logged.
+func (s *logSampler) allow(key logSampleKey) (bool, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= sampledLogMaxKeys {
+			return true, 0
+		}
+		bucket = &logSampleBucket{tokens: float64(s.maxPerMinute), lastRefill: now}
+		s.buckets[key] = bucket
+	}
+	bucket.tokens = min(float64(s.maxPerMinute),
+		bucket.tokens+now.Sub(bucket.lastRefill).Minutes()*float64(s.maxPerMinute))
+	bucket.lastRefill = now
+	if bucket.tokens < 1 {
+		bucket.suppressed++
+		return false, 0
+	}
+	bucket.tokens--
+	suppressed := bucket.suppressed
+	bucket.suppressed = 0
+	return true, suppressed
+}
+
+// sampledLogger is the BaseLogger for one level of the Loggers created by sampleLoggers. It receives
+// messages that already have the level prefix added by ldlog, so it removes that prefix before passing
+// them to the same level of the original Loggers.
+type sampledLogger struct {
+	sampler *logSampler
+	level   ldlog.LogLevel
+	prefix  string
+	target  ldlog.BaseLogger
+}
+
+func (l sampledLogger) Println(values ...interface{}) {
+	// The first value is the level prefix, possibly followed by a prefix that was set with SetPrefix.
+	if len(values) > 0 {
+		if first, ok := values[0].(string); ok && strings.HasPrefix(first, l.prefix) {
+			if rest := strings.TrimPrefix(first, l.prefix+" "); rest != first {
+				values = append([]interface{}{rest}, values[1:]...)
+			} else {
+				values = values[1:]
+			}
+		}
+	}
+	text := fmt.Sprint(values...)
+	if len(text) > sampledLogKeyLength {
+		text = text[:sampledLogKeyLength]
+	}
+	if l.check(text) {
+		l.target.Println(values...)
+	}
+}
+
+func (l sampledLogger) Printf(format string, values ...interface{}) {
+	format = strings.TrimPrefix(format, l.prefix+" ")
+	if l.check(format) {
+		l.target.Printf(format, values...)
+	}
+}
+
+func (l sampledLogger) check(text string) bool {
+	allowed, suppressed := l.sampler.allow(logSampleKey{level: l.level, text: text})
+	if suppressed > 0 {
+		l.target.Printf("%d similar log messages were suppressed", suppressed)
+	}
+	return allowed
+}
+
+// sampleLoggers returns a Loggers that sends output to the same destinations as loggers, but only if the
+// sampler allows it.
+func sampleLoggers(loggers ldlog.Loggers, sampler *logSampler) ldlog.Loggers {
+	ret := ldlog.Loggers{}
+	ret.SetMinLevel(loggers.GetMinLevel())
+	for _, level := range allLogLevels {
+		ret.SetBaseLoggerForLevel(level, sampledLogger{
+			sampler: sampler,
+			level:   level,
+			prefix:  strings.ToUpper(level.Name()) + ":",
+			target:  loggers.ForLevel(level),
+		})
+	}
+	return ret
+}
@@ -0,0 +1,79 @@
+package ldcomponents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeSampledLoggersForTest(maxPerMinute int) (ldlog.Loggers, *ldlogtest.MockLog, *time.Time) {
+	mockLog := ldlogtest.NewMockLog()
+	now := time.Now()
+	sampler := newLogSampler(maxPerMinute)
+	sampler.now = func() time.Time { return now }
+	return sampleLoggers(mockLog.Loggers, sampler), mockLog, &now
+}
+
+func TestSampledLoggersSuppressSimilarMessagesAndReportWhenSuppressionEnds(t *testing.T) {
+	loggers, mockLog, now := makeSampledLoggersForTest(2)
+
+	for i := 0; i < 5; i++ {
+		loggers.Warnf("data store error: %d", i)
+	}
+	assert.Equal(t, []string{"data store error: 0", "data store error: 1"}, mockLog.GetOutput(ldlog.Warn))
+
+	*now = now.Add(20 * time.Second) // not enough for one token
+	loggers.Warnf("data store error: %d", 5)
+	assert.Len(t, mockLog.GetOutput(ldlog.Warn), 2)
+
+	*now = now.Add(20 * time.Second)
+	loggers.Warnf("data store error: %d", 6)
+	assert.Equal(t, []string{"data store error: 0", "data store error: 1",
+		"4 similar log messages were suppressed", "data store error: 6"}, mockLog.GetOutput(ldlog.Warn))
+}
+
+func TestSampledLoggersTreatLevelsAndFormatsSeparately(t *testing.T) {
+	loggers, mockLog, _ := makeSampledLoggersForTest(1)
+
+	loggers.Warnf("first: %s", "a")
+	loggers.Warnf("first: %s", "b")
+	loggers.Warnf("second: %s", "a")
+	loggers.Errorf("first: %s", "a")
+	assert.Equal(t, []string{"first: a", "second: a"}, mockLog.GetOutput(ldlog.Warn))
+	assert.Equal(t, []string{"first: a"}, mockLog.GetOutput(ldlog.Error))
+}
+
+func TestSampledLoggersCompareBeginningOfMessagesWithoutFormat(t *testing.T) {
+	loggers, mockLog, _ := makeSampledLoggersForTest(1)
+
+	long := "this message is longer than the number of characters that are compared"
+	loggers.Warn(long + ": a")
+	loggers.Warn(long + ": b")
+	loggers.Warn("a different message")
+	assert.Equal(t, []string{long + ": a", "a different message"}, mockLog.GetOutput(ldlog.Warn))
+}
+
+func TestSampledLoggersKeepMinLevel(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	mockLog.Loggers.SetMinLevel(ldlog.Warn)
+	loggers := sampleLoggers(mockLog.Loggers, newLogSampler(10))
+
+	assert.Equal(t, ldlog.Warn, loggers.GetMinLevel())
+	loggers.Info("not logged")
+	loggers.Warn("logged")
+	assert.Len(t, mockLog.GetOutput(ldlog.Info), 0)
+	assert.Equal(t, []string{"logged"}, mockLog.GetOutput(ldlog.Warn))
+}
+
+func TestSampledLoggersKeepPrefix(t *testing.T) {
+	loggers, mockLog, _ := makeSampledLoggersForTest(10)
+	loggers.SetPrefix("Component:")
+
+	loggers.Warn("message")
+	loggers.Warnf("message %d", 1)
+	assert.Equal(t, []string{"Component: message", "Component: message 1"}, mockLog.GetOutput(ldlog.Warn))
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
 	"github.com/launchdarkly/go-server-sdk/v7/internal"
@@ -27,6 +29,17 @@ const (
 	DefaultContextKeysFlushInterval = 5 * time.Minute
 	// MinimumDiagnosticRecordingInterval is the minimum value for [EventProcessorBuilder.DiagnosticRecordingInterval].
 	MinimumDiagnosticRecordingInterval = 60 * time.Second
+	// DefaultSummaryCounterLimit is the default value for [EventProcessorBuilder.SummaryCounterLimit].
+	DefaultSummaryCounterLimit = 100000
+	// SummaryOverflowFlagKey is the flag key in summary event data that evaluations are counted under once
+	// the limit set by [EventProcessorBuilder.SummaryCounterLimit] is reached. It cannot be the key of a
+	// real flag, because flag keys cannot contain "$".
+	SummaryOverflowFlagKey = "$overflow"
+	// DefaultFlushHighWaterMark is the default value for [EventProcessorBuilder.FlushHighWaterMark].
+	DefaultFlushHighWaterMark = 0.8
+	// DefaultHighWaterMarkFlushSpacing is the default value for
+	// [EventProcessorBuilder.HighWaterMarkFlushSpacing].
+	DefaultHighWaterMarkFlushSpacing = time.Second
 )
 
 // EventProcessorBuilder provides methods for configuring analytics event behavior.
@@ -41,6 +54,11 @@ type EventProcessorBuilder struct {
 	privateAttributes           []ldattr.Ref
 	contextKeysCapacity         int
 	contextKeysFlushInterval    time.Duration
+	summaryCounterLimit         int
+	flushHighWaterMark          float64
+	highWaterMarkFlushSpacing   time.Duration
+	hasAnalyticsConsent         func(ldcontext.Context) bool
+	summarizeNonConsenting      bool
 }
 
 // SendEvents returns a configuration builder for analytics event delivery.
@@ -61,6 +79,10 @@ func SendEvents() *EventProcessorBuilder {
 		flushInterval:               DefaultFlushInterval,
 		contextKeysCapacity:         DefaultContextKeysCapacity,
 		contextKeysFlushInterval:    DefaultContextKeysFlushInterval,
+		summaryCounterLimit:         DefaultSummaryCounterLimit,
+		flushHighWaterMark:          DefaultFlushHighWaterMark,
+		highWaterMarkFlushSpacing:   DefaultHighWaterMarkFlushSpacing,
+		summarizeNonConsenting:      true,
 	}
 }
 
@@ -77,31 +99,79 @@ func (b *EventProcessorBuilder) Build(
 	)
 
 	headers := context.GetHTTP().DefaultHeaders
-	eventSender := ldevents.NewServerSideEventSender(
-		ldevents.EventSenderConfiguration{
-			Client:      context.GetHTTP().CreateHTTPClient(),
-			BaseURI:     configuredBaseURI,
-			BaseHeaders: func() http.Header { return headers },
-			Loggers:     loggers,
+	eventSender := withoutPayloadLogging(
+		func(senderLoggers ldlog.Loggers) ldevents.EventSender {
+			return ldevents.NewServerSideEventSender(
+				ldevents.EventSenderConfiguration{
+					Client:      context.GetHTTP().CreateHTTPClient(),
+					BaseURI:     configuredBaseURI,
+					BaseHeaders: func() http.Header { return headers },
+					Loggers:     senderLoggers,
+				},
+				context.GetSDKKey(),
+			)
 		},
-		context.GetSDKKey(),
+		loggers,
+		context.GetLogging().LogContextAttributes,
 	)
+	var diagnosticsManager *ldevents.DiagnosticsManager
+	var statsRecorder *internal.EventStatsRecorder
+	if cci, ok := context.(*internal.ClientContextImpl); ok {
+		diagnosticsManager = cci.DiagnosticsManager
+		if cci.DiagnosticsRecorder != nil {
+			eventSender = cci.DiagnosticsRecorder.WrapEventSender(eventSender)
+		}
+		if cci.EventStatsRecorder != nil {
+			statsRecorder = cci.EventStatsRecorder
+			eventSender = statsRecorder.WrapEventSender(eventSender)
+		}
+	}
+	var limiter *summaryCounterLimiter
+	if b.summaryCounterLimit > 0 {
+		limiter = newSummaryCounterLimiter(b.summaryCounterLimit, loggers)
+		eventSender = limiter.wrapEventSender(eventSender)
+	}
+	var consentFilter *analyticsConsentFilter
+	if b.hasAnalyticsConsent != nil {
+		consentFilter = newAnalyticsConsentFilter(b.hasAnalyticsConsent, b.summarizeNonConsenting, loggers)
+		eventSender = consentFilter.wrapEventSender(eventSender)
+	}
+	var flusher *highWaterMarkFlusher
+	if b.flushHighWaterMark > 0 {
+		flusher = newHighWaterMarkFlusher(b.capacity, b.flushHighWaterMark, b.highWaterMarkFlushSpacing,
+			b.contextKeysCapacity)
+		// This goes outside the consent filter's sender, so that it sees the placeholders' index events,
+		// which took up space in the buffer even though they aren't sent.
+		eventSender = flusher.wrapEventSender(eventSender)
+	}
 	eventsConfig := ldevents.EventsConfiguration{
 		AllAttributesPrivate:        b.allAttributesPrivate,
 		Capacity:                    b.capacity,
 		DiagnosticRecordingInterval: b.diagnosticRecordingInterval,
+		DiagnosticsManager:          diagnosticsManager,
 		EventSender:                 eventSender,
 		FlushInterval:               b.flushInterval,
 		Loggers:                     loggers,
 		LogUserKeyInErrors:          b.logContextKeyInErrors,
-		PrivateAttributes:           b.privateAttributes,
+		PrivateAttributes:           append([]ldattr.Ref(nil), b.privateAttributes...),
 		UserKeysCapacity:            b.contextKeysCapacity,
 		UserKeysFlushInterval:       b.contextKeysFlushInterval,
 	}
-	if cci, ok := context.(*internal.ClientContextImpl); ok {
-		eventsConfig.DiagnosticsManager = cci.DiagnosticsManager
+	ep := ldevents.NewDefaultEventProcessor(eventsConfig)
+	if statsRecorder != nil {
+		// This goes inside the other wrappers, so that it only counts the events that the processor gets.
+		ep = statsRecorder.WrapEventProcessor(ep)
+	}
+	if flusher != nil {
+		ep = flusher.wrapEventProcessor(ep)
+	}
+	if limiter != nil {
+		ep = limiter.wrapEventProcessor(ep)
 	}
-	return ldevents.NewDefaultEventProcessor(eventsConfig), nil
+	if consentFilter != nil {
+		ep = consentFilter.wrapEventProcessor(ep)
+	}
+	return ep, nil
 }
 
 // AllAttributesPrivate sets whether or not all optional context attributes should be hidden from LaunchDarkly.
@@ -199,6 +269,86 @@ func (b *EventProcessorBuilder) ContextKeysFlushInterval(interval time.Duration)
 	return b
 }
 
+// SummaryCounterLimit sets the maximum number of counters that summary event data can have between
+// flushes.
+//
+// The SDK counts flag evaluations for each combination of flag key, variation, and flag version, and sends
+// the counts at each flush (see [EventProcessorBuilder.FlushInterval]). If an application evaluates a very
+// large number of different flag keys, such as keys that it builds from user input, these counters could
+// use a lot of memory. Once the limit is reached, any evaluation that would need another counter is
+// counted under [SummaryOverflowFlagKey] instead, and a warning is logged the first time this happens.
+// Full evaluation events are not affected.
+//
+// The default value is [DefaultSummaryCounterLimit]. If limit is zero or negative, there is no limit.
+func (b *EventProcessorBuilder) SummaryCounterLimit(limit int) *EventProcessorBuilder {
+	b.summaryCounterLimit = limit
+	return b
+}
+
+// FlushHighWaterMark sets how full the events buffer can get before it is flushed without waiting for
+// the next [EventProcessorBuilder.FlushInterval].
+//
+// The fraction is relative to [EventProcessorBuilder.Capacity]: with the default capacity and a fraction
+// of 0.8, the SDK starts a flush as soon as 8000 events have been added to the buffer since the last
+// one. This keeps a burst of events from filling the buffer, and being discarded, just because the flush
+// interval has not yet elapsed. Evaluations that are only counted in summary events do not take up space
+// in the buffer, although the index events that some of them cause do. To avoid sending a constant
+// stream of small payloads if events keep arriving faster than they can be delivered, these flushes are
+// spaced apart as set by [EventProcessorBuilder.HighWaterMarkFlushSpacing].
+//
+// The default value is [DefaultFlushHighWaterMark]. If fraction is zero or negative, the buffer is only
+// flushed at the flush interval, or when the application calls Flush.
+func (b *EventProcessorBuilder) FlushHighWaterMark(fraction float64) *EventProcessorBuilder {
+	b.flushHighWaterMark = fraction
+	return b
+}
+
+// HighWaterMarkFlushSpacing sets the minimum time between flushes that are started because the buffer
+// reached the level set by [EventProcessorBuilder.FlushHighWaterMark]. It does not affect flushes at the
+// flush interval, or ones that the application asks for.
+//
+// The default value is [DefaultHighWaterMarkFlushSpacing]. If spacing is zero or negative, there is no
+// minimum.
+func (b *EventProcessorBuilder) HighWaterMarkFlushSpacing(spacing time.Duration) *EventProcessorBuilder {
+	b.highWaterMarkFlushSpacing = spacing
+	return b
+}
+
+// AnalyticsConsent sets a function that decides whether events can be sent for a context.
+//
+// This is for applications that need to ask for consent before sending information about a context to
+// LaunchDarkly. Before recording any event, the SDK calls the function with the event's context; if it
+// returns false, no identify event, custom event, or full evaluation event is recorded for the context,
+// and the context's properties are not sent. Flag evaluations for the context are still counted in
+// summary events, unless [EventProcessorBuilder.SummarizeNonConsentingContexts] is set to false. The
+// summary only records which context kinds were evaluated, so it contains nothing about the context.
+//
+// The function is called on the goroutine that evaluates the flag, so it should be fast. If it panics,
+// the SDK treats the context as not having consented and logs an error the first time this happens.
+//
+// Migration op events are not recorded for a context that has not consented, if they were built by the
+// tracker that LDClient.MigrationVariation returned; the SDK cannot check the context of a migration op
+// event that the application built in some other way.
+//
+// By default, there is no function and events are sent for all contexts.
+func (b *EventProcessorBuilder) AnalyticsConsent(
+	hasConsent func(context ldcontext.Context) bool,
+) *EventProcessorBuilder {
+	b.hasAnalyticsConsent = hasConsent
+	return b
+}
+
+// SummarizeNonConsentingContexts sets whether flag evaluations for contexts that have not consented, as
+// decided by the function set with [EventProcessorBuilder.AnalyticsConsent], are counted in summary
+// events.
+//
+// The default is true. If it is false, no events at all are recorded for these contexts. This setting has
+// no effect unless an AnalyticsConsent function is set.
+func (b *EventProcessorBuilder) SummarizeNonConsentingContexts(value bool) *EventProcessorBuilder {
+	b.summarizeNonConsenting = value
+	return b
+}
+
 // DescribeConfiguration is used internally by the SDK to inspect the configuration.
 func (b *EventProcessorBuilder) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
 	return ldvalue.ObjectBuild().
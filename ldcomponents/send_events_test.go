@@ -2,14 +2,22 @@ package ldcomponents
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
 
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
@@ -85,6 +93,40 @@ func TestEventProcessorBuilder(t *testing.T) {
 		b.ContextKeysFlushInterval(time.Hour)
 		assert.Equal(t, time.Hour, b.contextKeysFlushInterval)
 	})
+
+	t.Run("SummaryCounterLimit", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, DefaultSummaryCounterLimit, b.summaryCounterLimit)
+
+		b.SummaryCounterLimit(333)
+		assert.Equal(t, 333, b.summaryCounterLimit)
+	})
+
+	t.Run("FlushHighWaterMark", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, DefaultFlushHighWaterMark, b.flushHighWaterMark)
+
+		b.FlushHighWaterMark(0.5)
+		assert.Equal(t, 0.5, b.flushHighWaterMark)
+	})
+
+	t.Run("HighWaterMarkFlushSpacing", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, DefaultHighWaterMarkFlushSpacing, b.highWaterMarkFlushSpacing)
+
+		b.HighWaterMarkFlushSpacing(time.Minute)
+		assert.Equal(t, time.Minute, b.highWaterMarkFlushSpacing)
+	})
+
+	t.Run("AnalyticsConsent", func(t *testing.T) {
+		b := SendEvents()
+		assert.Nil(t, b.hasAnalyticsConsent)
+		assert.True(t, b.summarizeNonConsenting)
+
+		b.AnalyticsConsent(func(ldcontext.Context) bool { return false }).SummarizeNonConsentingContexts(false)
+		assert.NotNil(t, b.hasAnalyticsConsent)
+		assert.False(t, b.summarizeNonConsenting)
+	})
 }
 
 func TestDefaultEventsConfigWithoutDiagnostics(t *testing.T) {
@@ -210,3 +252,330 @@ func TestEventsSomeAttributesPrivate(t *testing.T) {
 		))
 	})
 }
+
+func summaryCounts(t *testing.T, events ldvalue.Value) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range events.AsValueArray().AsSlice() {
+		if event.GetByKey("kind").StringValue() != "summary" {
+			continue
+		}
+		features := event.GetByKey("features")
+		for _, key := range features.Keys(nil) {
+			for _, counter := range features.GetByKey(key).GetByKey("counters").AsValueArray().AsSlice() {
+				counts[key] += counter.GetByKey("count").IntValue()
+			}
+		}
+		return counts
+	}
+	require.Fail(t, "no summary event")
+	return nil
+}
+
+func TestEventsSummaryCounterLimit(t *testing.T) {
+	eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+	httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+		mockLog := ldlogtest.NewMockLog()
+		context := makeTestContextWithBaseURIs(server.URL)
+		context.Logging = subsystems.LoggingConfiguration{Loggers: mockLog.Loggers}
+		ep, err := SendEvents().
+			SummaryCounterLimit(3).
+			Build(context)
+		require.NoError(t, err)
+		defer ep.Close()
+
+		ef := ldevents.NewEventFactory(false, nil)
+		user := ldevents.Context(lduser.NewUser("key"))
+		evaluate := func(flagKey string, variation int, requireFullEvent bool) {
+			ep.RecordEvaluation(ef.NewEvaluationData(
+				ldevents.FlagEventProperties{Key: flagKey, Version: 1, RequireFullEvent: requireFullEvent},
+				user,
+				ldreason.NewEvaluationDetail(ldvalue.Int(variation), variation, ldreason.NewEvalReasonFallthrough()),
+				false,
+				ldvalue.Null(),
+				"",
+				ldvalue.OptionalInt{},
+				false,
+			))
+		}
+		evaluate("flag1", 0, false)
+		evaluate("flag1", 1, false)
+		evaluate("flag2", 0, false)
+		evaluate("flag1", 0, false) // existing counter
+		evaluate("flag3", 0, false) // over the limit
+		evaluate("flag1", 2, false) // over the limit
+		evaluate("flag4", 0, true)  // over the limit, with a full event
+		ep.Flush()
+
+		r := <-requestsCh
+		events := ldvalue.Parse(r.Body)
+		assert.Equal(t, map[string]int{"flag1": 3, "flag2": 1, SummaryOverflowFlagKey: 3}, summaryCounts(t, events))
+		var fullEventKeys []string
+		for _, event := range events.AsValueArray().AsSlice() {
+			if event.GetByKey("kind").StringValue() == "feature" {
+				fullEventKeys = append(fullEventKeys, event.GetByKey("key").StringValue())
+			}
+		}
+		assert.Equal(t, []string{"flag4"}, fullEventKeys)
+		assert.Len(t, mockLog.GetOutput(ldlog.Warn), 1)
+
+		// The limit starts over after a flush
+		evaluate("flag3", 0, false)
+		evaluate("flag5", 0, false)
+		ep.Flush()
+
+		r = <-requestsCh
+		assert.Equal(t, map[string]int{"flag3": 1, "flag5": 1}, summaryCounts(t, ldvalue.Parse(r.Body)))
+		assert.Len(t, mockLog.GetOutput(ldlog.Warn), 1)
+	})
+}
+
+func TestSummaryCounterLimiterAllowsLimitWhenConcurrent(t *testing.T) {
+	limiter := newSummaryCounterLimiter(50, ldlog.NewDisabledLoggers())
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				e := ldevents.EvaluationData{Key: fmt.Sprintf("flag%d", i), Variation: ldvalue.NewOptionalInt(0)}
+				if limiter.allow(e) && limiter.allow(e) { // a key that was allowed is always allowed again
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(8*50), allowed.Load())
+
+	limiter.reset()
+	assert.True(t, limiter.allow(ldevents.EvaluationData{Key: "flag99"}))
+}
+
+func recordIdentifyEvents(ep ldevents.EventProcessor, count int) {
+	ef := ldevents.NewEventFactory(false, nil)
+	for i := 0; i < count; i++ {
+		ep.RecordIdentifyEvent(ef.NewIdentifyEventData(ldevents.Context(lduser.NewUser("key")), ldvalue.OptionalInt{}))
+	}
+}
+
+func recordEvaluations(ep ldevents.EventProcessor, count int, requireFullEvent bool, contextKey func(int) string) {
+	ef := ldevents.NewEventFactory(false, nil)
+	for i := 0; i < count; i++ {
+		ep.RecordEvaluation(ef.NewEvaluationData(
+			ldevents.FlagEventProperties{Key: "flag", Version: 1, RequireFullEvent: requireFullEvent},
+			ldevents.Context(lduser.NewUser(contextKey(i))),
+			ldreason.NewEvaluationDetail(ldvalue.Bool(true), 0, ldreason.NewEvalReasonFallthrough()),
+			false,
+			ldvalue.Null(),
+			"",
+			ldvalue.OptionalInt{},
+			false,
+		))
+	}
+}
+
+func sameContextKey(int) string { return "key" }
+
+func distinctContextKeys(i int) string { return fmt.Sprintf("key%d", i) }
+
+func expectNoEventPayload(t *testing.T, requestsCh <-chan httphelpers.HTTPRequestInfo) {
+	select {
+	case <-requestsCh:
+		require.Fail(t, "unexpected flush")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventsFlushHighWaterMark(t *testing.T) {
+	t.Run("buffer is flushed before it fills up", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			mockLog := ldlogtest.NewMockLog()
+			context := makeTestContextWithBaseURIs(server.URL)
+			context.Logging = subsystems.LoggingConfiguration{Loggers: mockLog.Loggers}
+			ep, err := SendEvents().Capacity(1000).FlushInterval(time.Hour).HighWaterMarkFlushSpacing(0).
+				Build(context)
+			require.NoError(t, err)
+			defer ep.Close()
+
+			// The events are recorded in small batches so that the event processor's inbox doesn't overflow,
+			// which is a separate problem; the buffer still fills up ten times faster than the flush interval.
+			for i := 0; i < 200; i++ {
+				recordIdentifyEvents(ep, 50)
+				time.Sleep(time.Millisecond)
+			}
+			require.True(t, ep.FlushBlocking(time.Second))
+
+			total, payloads := 0, 0
+			for total < 10000 {
+				select {
+				case r := <-requestsCh:
+					events := ldvalue.Parse(r.Body)
+					assert.LessOrEqual(t, events.Count(), 1000)
+					total += events.Count()
+					payloads++
+				case <-time.After(time.Second):
+					require.Fail(t, "timed out waiting for events", "received %d of 10000", total)
+				}
+			}
+			assert.Equal(t, 10000, total)
+			assert.GreaterOrEqual(t, payloads, 10)
+			assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+		})
+	})
+
+	t.Run("flush is triggered by default at 80% of capacity", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			recordIdentifyEvents(ep, 79)
+			select {
+			case <-requestsCh:
+				require.Fail(t, "unexpected flush")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			recordIdentifyEvents(ep, 1)
+			r := <-requestsCh
+			assert.Equal(t, 80, ldvalue.Parse(r.Body).Count())
+		})
+	})
+
+	t.Run("triggered flushes have a minimum spacing", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).HighWaterMarkFlushSpacing(time.Hour).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			recordIdentifyEvents(ep, 80)
+			r := <-requestsCh
+			assert.Equal(t, 80, ldvalue.Parse(r.Body).Count())
+
+			recordIdentifyEvents(ep, 200)
+			select {
+			case <-requestsCh:
+				require.Fail(t, "unexpected flush")
+			case <-time.After(100 * time.Millisecond):
+			}
+			ep.Flush()
+			r = <-requestsCh
+			assert.Equal(t, 100, ldvalue.Parse(r.Body).Count()) // the rest were dropped
+		})
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).FlushHighWaterMark(0).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			recordIdentifyEvents(ep, 150)
+			select {
+			case <-requestsCh:
+				require.Fail(t, "unexpected flush")
+			case <-time.After(100 * time.Millisecond):
+			}
+			ep.Flush()
+			r := <-requestsCh
+			assert.Equal(t, 100, ldvalue.Parse(r.Body).Count())
+		})
+	})
+
+	t.Run("evaluations that are only summarized are not counted", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).ContextKeysCapacity(10).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			// Only the first 10 are counted, as possibly needing index events.
+			recordEvaluations(ep, 500, false, sameContextKey)
+			expectNoEventPayload(t, requestsCh)
+
+			// 10 more index events would reach the threshold, but we've already counted as many of those
+			// as the event processor can remember context keys.
+			recordIdentifyEvents(ep, 69)
+			expectNoEventPayload(t, requestsCh)
+
+			recordIdentifyEvents(ep, 1)
+			<-requestsCh
+		})
+	})
+
+	t.Run("full evaluation events are counted", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).ContextKeysCapacity(10).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			// The first 10 are counted twice, as each might need an index event.
+			recordEvaluations(ep, 69, true, sameContextKey)
+			expectNoEventPayload(t, requestsCh)
+
+			recordEvaluations(ep, 1, true, sameContextKey)
+			r := <-requestsCh
+			featureEvents := 0
+			for _, e := range ldvalue.Parse(r.Body).AsValueArray().AsSlice() {
+				if e.GetByKey("kind").StringValue() == "feature" {
+					featureEvents++
+				}
+			}
+			assert.Equal(t, 70, featureEvents)
+		})
+	})
+
+	t.Run("index events are all counted after a payload with more than the context keys capacity", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).ContextKeysCapacity(10).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			recordEvaluations(ep, 20, false, distinctContextKeys)
+			ep.Flush()
+			r := <-requestsCh
+			assert.Equal(t, 21, ldvalue.Parse(r.Body).Count()) // 20 index events and the summary
+
+			recordEvaluations(ep, 79, false, distinctContextKeys)
+			expectNoEventPayload(t, requestsCh)
+
+			recordEvaluations(ep, 1, false, distinctContextKeys)
+			r = <-requestsCh
+			assert.Equal(t, 81, ldvalue.Parse(r.Body).Count())
+		})
+	})
+
+	t.Run("count starts over when the buffer is flushed for another reason", func(t *testing.T) {
+		eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
+		httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+			ep, err := SendEvents().Capacity(100).FlushInterval(time.Hour).
+				Build(makeTestContextWithBaseURIs(server.URL))
+			require.NoError(t, err)
+			defer ep.Close()
+
+			recordIdentifyEvents(ep, 60)
+			ep.Flush()
+			r := <-requestsCh
+			assert.Equal(t, 60, ldvalue.Parse(r.Body).Count())
+
+			recordIdentifyEvents(ep, 60)
+			expectNoEventPayload(t, requestsCh)
+
+			recordIdentifyEvents(ep, 20)
+			r = <-requestsCh
+			assert.Equal(t, 80, ldvalue.Parse(r.Body).Count())
+		})
+	})
+}
@@ -23,10 +23,33 @@ import "github.com/launchdarkly/go-server-sdk/v7/interfaces"
 //
 // See Config.ServiceEndpoints for more details.
 func RelayProxyEndpoints(relayProxyBaseURI string) interfaces.ServiceEndpoints {
+	return CustomEndpoints(relayProxyBaseURI, relayProxyBaseURI, relayProxyBaseURI)
+}
+
+// CustomEndpoints specifies separate base URIs for each of the streaming, polling, and events
+// services.
+//
+// This is useful if you are using a Relay Proxy deployment in which each service has its own
+// hostname (for instance, so that TLS can be terminated independently for each one). If all of
+// the services are at the same base URI, use [RelayProxyEndpoints] instead.
+//
+// Store this value in the ServiceEndpoints field of [github.com/launchdarkly/go-server-sdk/v7.Config].
+// For example:
+//
+//	config := ld.Config{
+//	    ServiceEndpoints: ldcomponents.CustomEndpoints(
+//	        "https://relay-stream.example.com",
+//	        "https://relay-poll.example.com",
+//	        "https://relay-events.example.com",
+//	    ),
+//	}
+//
+// See Config.ServiceEndpoints for more details.
+func CustomEndpoints(streamingBaseURI, pollingBaseURI, eventsBaseURI string) interfaces.ServiceEndpoints {
 	return interfaces.ServiceEndpoints{
-		Streaming: relayProxyBaseURI,
-		Polling:   relayProxyBaseURI,
-		Events:    relayProxyBaseURI,
+		Streaming: streamingBaseURI,
+		Polling:   pollingBaseURI,
+		Events:    eventsBaseURI,
 	}
 }
 
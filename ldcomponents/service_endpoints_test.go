@@ -21,3 +21,11 @@ func TestRelayProxyEndpointsWithoutEvents(t *testing.T) {
 	assert.Equal(t, uri, e.Polling)
 	assert.Equal(t, "", e.Events)
 }
+
+func TestCustomEndpoints(t *testing.T) {
+	e := CustomEndpoints("http://stream:8080", "http://poll:8080", "http://events:8080")
+	assert.Equal(t, "http://stream:8080", e.Streaming)
+	assert.Equal(t, "http://poll:8080", e.Polling)
+	assert.Equal(t, "http://events:8080", e.Events)
+	assert.False(t, e.PartialSpecificationRequested())
+}
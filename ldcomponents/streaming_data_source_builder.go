@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datasource"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/endpoints"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
@@ -21,7 +22,10 @@ const DefaultInitialReconnectDelay = time.Second
 // See StreamingDataSource for usage.
 type StreamingDataSourceBuilder struct {
 	initialReconnectDelay time.Duration
+	heartbeatInterval     time.Duration
+	acceptEventTypes      []string
 	filterKey             ldvalue.OptionalString
+	useStoreData          bool
 }
 
 // StreamingDataSource returns a configurable factory for using streaming mode to get feature flag data.
@@ -58,6 +62,42 @@ func (b *StreamingDataSourceBuilder) InitialReconnectDelay(
 	return b
 }
 
+// AcceptEventTypes limits which types of stream events the SDK will process. Events of any other type are
+// discarded without parsing their data, which can save CPU time on a high-volume stream if the application
+// does not need all of the updates.
+//
+// The event types sent by LaunchDarkly are "put" (the full data set), "patch" (an updated flag or segment),
+// "delete" (a deleted flag or segment), and "reset" (a request to reconnect, sent before server maintenance).
+// A "put" event is always accepted even if it is not in the list, because the SDK cannot initialize without
+// it, and so is a "reset" event. Discarding "patch" or "delete" events means that the SDK will not see
+// changes to flags until the next time the stream reconnects.
+//
+// By default, or if called with no arguments, all event types are accepted.
+func (b *StreamingDataSourceBuilder) AcceptEventTypes(types ...string) *StreamingDataSourceBuilder {
+	b.acceptEventTypes = append([]string(nil), types...)
+	return b
+}
+
+// HeartbeatInterval enables a check for whether the streaming connection is still alive, for networks where
+// a NAT gateway, load balancer, or firewall may silently drop connections that have been idle for a while.
+//
+// If no stream event has been received for this length of time, the SDK logs a warning and sends TCP
+// keepalive probes on the connection. If the probes are not answered, the connection is treated as dead
+// and the SDK reconnects. The connection also sends a keepalive probe whenever it has been idle for this
+// long, which keeps most such devices from considering it idle in the first place.
+//
+// This is independent of the heartbeat comments that the LaunchDarkly service sends on the stream, which
+// are not counted as events. By default, or if the interval is zero or negative, there is no heartbeat
+// check, and the SDK only notices a dead connection when it has received nothing at all (not even a
+// heartbeat comment) for five minutes.
+func (b *StreamingDataSourceBuilder) HeartbeatInterval(heartbeatInterval time.Duration) *StreamingDataSourceBuilder {
+	if heartbeatInterval < 0 {
+		heartbeatInterval = 0
+	}
+	b.heartbeatInterval = heartbeatInterval
+	return b
+}
+
 // PayloadFilter sets the payload filter key for this streaming connection. The filter key
 // cannot be an empty string.
 //
@@ -71,6 +111,22 @@ func (b *StreamingDataSourceBuilder) PayloadFilter(filterKey string) *StreamingD
 	return b
 }
 
+// UseStoreDataWhileConnecting sets whether the SDK can use the data in a persistent data store while
+// the streaming connection is starting, instead of waiting for the stream to provide all of the data.
+//
+// Normally, the client is not initialized until it has received the full data set from the stream, even if
+// a persistent data store already contains data from an earlier process. If this is true, and the data
+// store is a persistent store that has been initialized, then the client is initialized right away and
+// evaluates flags using the stored data, which may be out of date. The data source status is
+// [interfaces.DataSourceStateInitializedFromStore] until the stream provides the full data set, which then
+// replaces the stored data as usual. This has no effect with an in-memory data store.
+//
+// The default value is false.
+func (b *StreamingDataSourceBuilder) UseStoreDataWhileConnecting(useStoreData bool) *StreamingDataSourceBuilder {
+	b.useStoreData = useStoreData
+	return b
+}
+
 // Build is called internally by the SDK.
 func (b *StreamingDataSourceBuilder) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
 	filterKey, wasSet := b.filterKey.Get()
@@ -83,9 +139,12 @@ func (b *StreamingDataSourceBuilder) Build(context subsystems.ClientContext) (su
 		context.GetLogging().Loggers,
 	)
 	cfg := datasource.StreamConfig{
-		URI:                   configuredBaseURI,
-		InitialReconnectDelay: b.initialReconnectDelay,
-		FilterKey:             filterKey,
+		URI:                         configuredBaseURI,
+		InitialReconnectDelay:       b.initialReconnectDelay,
+		HeartbeatInterval:           b.heartbeatInterval,
+		AcceptEventTypes:            append([]string(nil), b.acceptEventTypes...),
+		FilterKey:                   filterKey,
+		UseStoreDataWhileConnecting: b.useStoreData,
 	}
 	return datasource.NewStreamProcessor(
 		context,
@@ -104,3 +163,8 @@ func (b *StreamingDataSourceBuilder) DescribeConfiguration(context subsystems.Cl
 		SetBool("usingRelayDaemon", false).
 		Build()
 }
+
+// DataUpdateSource is used internally by the SDK to describe this data source to a DataUpdateListener.
+func (b *StreamingDataSourceBuilder) DataUpdateSource() interfaces.DataUpdateSource {
+	return interfaces.DataUpdateSourceStreaming
+}
@@ -29,6 +29,41 @@ func TestStreamingDataSourceBuilder(t *testing.T) {
 		assert.Equal(t, DefaultInitialReconnectDelay, s.initialReconnectDelay)
 	})
 
+	t.Run("AcceptEventTypes", func(t *testing.T) {
+		s := StreamingDataSource()
+		assert.Len(t, s.acceptEventTypes, 0)
+
+		types := []string{"patch", "delete"}
+		s.AcceptEventTypes(types...)
+		types[0] = "other"
+		assert.Equal(t, []string{"patch", "delete"}, s.acceptEventTypes)
+
+		s.AcceptEventTypes()
+		assert.Len(t, s.acceptEventTypes, 0)
+	})
+
+	t.Run("HeartbeatInterval", func(t *testing.T) {
+		s := StreamingDataSource()
+		assert.Equal(t, time.Duration(0), s.heartbeatInterval)
+
+		s.HeartbeatInterval(time.Minute)
+		assert.Equal(t, time.Minute, s.heartbeatInterval)
+
+		s.HeartbeatInterval(-1 * time.Millisecond)
+		assert.Equal(t, time.Duration(0), s.heartbeatInterval)
+	})
+
+	t.Run("UseStoreDataWhileConnecting", func(t *testing.T) {
+		s := StreamingDataSource()
+		assert.False(t, s.useStoreData)
+
+		s.UseStoreDataWhileConnecting(true)
+		assert.True(t, s.useStoreData)
+
+		s.UseStoreDataWhileConnecting(false)
+		assert.False(t, s.useStoreData)
+	})
+
 	t.Run("PayloadFilter", func(t *testing.T) {
 		t.Run("build succeeds with no payload filter", func(t *testing.T) {
 			s := StreamingDataSource()
@@ -71,6 +106,8 @@ func TestStreamingDataSourceBuilder(t *testing.T) {
 		assert.Equal(t, baseURI, sp.GetBaseURI())
 		assert.Equal(t, DefaultInitialReconnectDelay, sp.GetInitialReconnectDelay())
 		assert.Equal(t, "", sp.GetFilterKey())
+		assert.Equal(t, time.Duration(0), sp.GetHeartbeatInterval())
+		assert.Len(t, sp.GetAcceptEventTypes(), 0)
 	})
 
 	t.Run("CreateCustomizedDataSource", func(t *testing.T) {
@@ -78,7 +115,8 @@ func TestStreamingDataSourceBuilder(t *testing.T) {
 		delay := time.Hour
 		filter := "microservice-1"
 
-		s := StreamingDataSource().InitialReconnectDelay(delay).PayloadFilter(filter)
+		s := StreamingDataSource().InitialReconnectDelay(delay).PayloadFilter(filter).HeartbeatInterval(time.Minute).
+			AcceptEventTypes("patch")
 
 		dsu := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
 		clientContext := makeTestContextWithBaseURIs(baseURI)
@@ -92,5 +130,7 @@ func TestStreamingDataSourceBuilder(t *testing.T) {
 		assert.Equal(t, baseURI, sp.GetBaseURI())
 		assert.Equal(t, delay, sp.GetInitialReconnectDelay())
 		assert.Equal(t, filter, sp.GetFilterKey())
+		assert.Equal(t, time.Minute, sp.GetHeartbeatInterval())
+		assert.Equal(t, []string{"patch"}, sp.GetAcceptEventTypes())
 	})
 }
@@ -0,0 +1,139 @@
+package ldcomponents
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+)
+
+// summaryCounterLimiter implements EventProcessorBuilder.SummaryCounterLimit. The summarizer in the event
+// processor does not have a limit of its own, so this keeps track of the same counters that it does: one
+// for each combination of flag key, variation, and version. Once the limit is reached, each evaluation
+// that would add a counter is replaced, for the summary only, by an evaluation of SummaryOverflowFlagKey
+// with no variation or version, which all go into a single counter.
+//
+// The event processor clears its summary when it starts a flush, so the limiter starts over whenever an
+// analytics payload is sent. Evaluations that happen while a payload is being delivered are counted
+// against the old summary, so the event processor can briefly hold a few more counters than the limit.
+//
+// Since allow is called for every evaluation, it only takes the lock to add a counter: a key that already has
+// one is found in a sync.Map, and once the limit is reached, full is set.
+type summaryCounterLimiter struct {
+	limit    int
+	loggers  ldlog.Loggers
+	counters atomic.Pointer[sync.Map] // summaryCounterKey -> struct{}; replaced by reset
+	count    int                      // the number of keys in counters, protected by lock
+	full     atomic.Bool
+	warned   bool
+	lock     sync.Mutex
+}
+
+type summaryCounterKey struct {
+	flagKey   string
+	variation ldvalue.OptionalInt
+	version   ldvalue.OptionalInt
+}
+
+type summaryCounterLimitingEventProcessor struct {
+	ldevents.EventProcessor
+	limiter *summaryCounterLimiter
+}
+
+type summaryCounterLimitingEventSender struct {
+	limiter *summaryCounterLimiter
+	sender  ldevents.EventSender
+}
+
+func newSummaryCounterLimiter(limit int, loggers ldlog.Loggers) *summaryCounterLimiter {
+	l := &summaryCounterLimiter{limit: limit, loggers: loggers}
+	l.counters.Store(&sync.Map{})
+	return l
+}
+
+func (l *summaryCounterLimiter) wrapEventProcessor(ep ldevents.EventProcessor) ldevents.EventProcessor {
+	return summaryCounterLimitingEventProcessor{EventProcessor: ep, limiter: l}
+}
+
+func (l *summaryCounterLimiter) wrapEventSender(sender ldevents.EventSender) ldevents.EventSender {
+	return summaryCounterLimitingEventSender{limiter: l, sender: sender}
+}
+
+// allow returns true if the evaluation can be summarized as usual.
+func (l *summaryCounterLimiter) allow(e ldevents.EvaluationData) bool {
+	key := summaryCounterKey{flagKey: e.Key, variation: e.Variation, version: e.Version}
+	if _, ok := l.counters.Load().Load(key); ok {
+		return true
+	}
+	if l.full.Load() {
+		return false
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	counters := l.counters.Load() // reset may have replaced it, or another goroutine added the key
+	if _, ok := counters.Load(key); ok {
+		return true
+	}
+	if l.count < l.limit {
+		counters.Store(key, struct{}{})
+		l.count++
+		return true
+	}
+	l.full.Store(true)
+	if !l.warned {
+		l.warned = true
+		l.loggers.Warnf(
+			"Analytics events have more than %d distinct flag and variation combinations since the last flush;"+
+				" evaluations of any others will be counted under %q until the next flush",
+			l.limit,
+			SummaryOverflowFlagKey,
+		)
+	}
+	return false
+}
+
+func (l *summaryCounterLimiter) reset() {
+	l.lock.Lock()
+	l.counters.Store(&sync.Map{})
+	l.count = 0
+	l.full.Store(false)
+	l.lock.Unlock()
+}
+
+func (p summaryCounterLimitingEventProcessor) RecordEvaluation(e ldevents.EvaluationData) {
+	if e.ExcludeFromSummaries || p.limiter.allow(e) {
+		p.EventProcessor.RecordEvaluation(e)
+		return
+	}
+	if e.RequireFullEvent || e.DebugEventsUntilDate != 0 {
+		// The full or debug event is still needed as it is, but it can't be summarized under its own key.
+		fullEvent := e
+		fullEvent.ExcludeFromSummaries = true
+		p.EventProcessor.RecordEvaluation(fullEvent)
+	}
+	overflow := e
+	overflow.Key = SummaryOverflowFlagKey
+	overflow.Variation = ldvalue.OptionalInt{}
+	overflow.Version = ldvalue.OptionalInt{}
+	overflow.Value = ldvalue.Null()
+	overflow.Default = ldvalue.Null()
+	overflow.Reason = ldreason.EvaluationReason{}
+	overflow.PrereqOf = ldvalue.OptionalString{}
+	overflow.RequireFullEvent = false
+	overflow.DebugEventsUntilDate = 0
+	p.EventProcessor.RecordEvaluation(overflow)
+}
+
+func (s summaryCounterLimitingEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	if kind == ldevents.AnalyticsEventDataKind {
+		s.limiter.reset()
+	}
+	return s.sender.SendEventData(kind, data, eventCount)
+}
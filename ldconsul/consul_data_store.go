@@ -0,0 +1,380 @@
+package ldconsul
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// DefaultPrefix is the string that is prepended to all Consul keys used by the data store, if no
+	// other prefix is specified with WithPrefix.
+	DefaultPrefix = "launchdarkly"
+
+	initedKey = "$inited"
+
+	// maxTxnOps is the most operations that Consul allows in one transaction.
+	maxTxnOps = 64
+
+	initialWatchRetryDelay = time.Second
+	maxWatchRetryDelay     = 30 * time.Second
+)
+
+// ConsulOption is an optional parameter for [NewConsulDataStoreFactory].
+type ConsulOption func(*consulDataStoreFactory)
+
+// WithPrefix sets the string that is prepended to all Consul keys used by the data store, followed by a
+// slash, so that several SDK environments can share one Consul cluster. The default is [DefaultPrefix].
+func WithPrefix(prefix string) ConsulOption {
+	return func(f *consulDataStoreFactory) {
+		f.prefix = prefix
+	}
+}
+
+// WithConfig sets the configuration of the Consul client, for settings such as the ACL token, the
+// datacenter, or TLS. If the address passed to NewConsulDataStoreFactory is not empty, it replaces the
+// Address in this configuration. The default is api.DefaultConfig(), which reads the standard Consul
+// environment variables such as CONSUL_HTTP_ADDR and CONSUL_HTTP_TOKEN.
+func WithConfig(config *api.Config) ConsulOption {
+	return func(f *consulDataStoreFactory) {
+		f.config = config
+	}
+}
+
+type consulDataStoreFactory struct {
+	address         string
+	prefix          string
+	config          *api.Config
+	watchRetryDelay time.Duration
+}
+
+type consulDataStore struct {
+	kv              *api.KV
+	txn             *api.Txn
+	prefix          string
+	loggers         ldlog.Loggers
+	watchRetryDelay time.Duration
+	testTxHook      func()
+	inited          bool
+	cancel          context.CancelFunc
+	doneCh          chan struct{}
+	lock            sync.Mutex
+	closeOnce       sync.Once
+}
+
+// NewConsulDataStoreFactory returns a configuration for a persistent data store that uses the Consul
+// agent at the specified address, such as "localhost:8500". If the address is empty, it uses the address
+// from WithConfig or the standard Consul environment variables. To use it, pass it to
+// ldcomponents.PersistentDataStore and store the result in the DataStore field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(ldconsul.NewConsulDataStoreFactory("localhost:8500")),
+//	}
+//
+// Each flag or segment is a Consul key, such as "launchdarkly/features/my-flag", whose value is the same
+// JSON that other persistent data stores use. Updates use Consul's check-and-set operation, so that a
+// newer version of an item that another process has written at the same time is never replaced with an
+// older one. The SDK replaces the whole data set with transactions of at most 64 operations, since that is
+// Consul's limit; so if the data set is larger than that, other processes may briefly see a mix of the
+// old and new data.
+//
+// The data store also watches its keys with a Consul blocking query. If the SDK is caching items with a
+// limited TTL, it removes an item from the cache as soon as the item changes in Consul; this is useful in
+// daemon mode (ldcomponents.ExternalUpdatesOnly), where the Relay Proxy is what updates Consul. Each
+// change makes the data store read all of its keys again, so this is intended for data sets of a moderate
+// size.
+func NewConsulDataStoreFactory(
+	address string,
+	opts ...ConsulOption,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	f := &consulDataStoreFactory{
+		address:         address,
+		prefix:          DefaultPrefix,
+		watchRetryDelay: initialWatchRetryDelay,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *consulDataStoreFactory) Build(context subsystems.ClientContext) (subsystems.PersistentDataStore, error) {
+	config := api.DefaultConfig()
+	if f.config != nil {
+		copied := *f.config
+		config = &copied
+	}
+	if f.address != "" {
+		config.Address = f.address
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	prefix := f.prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	loggers := context.GetLogging().Loggers
+	loggers.SetPrefix("ConsulDataStore:")
+	return &consulDataStore{
+		kv:              client.KV(),
+		txn:             client.Txn(),
+		prefix:          prefix,
+		loggers:         loggers,
+		watchRetryDelay: f.watchRetryDelay,
+		doneCh:          make(chan struct{}),
+	}, nil
+}
+
+func (store *consulDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	oldKeys, _, err := store.kv.Keys(store.prefix+"/", "", nil)
+	if err != nil {
+		return err
+	}
+	var ops api.TxnOps
+	newKeys := make(map[string]bool)
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			key := store.itemKey(coll.Kind, item.Key)
+			newKeys[key] = true
+			ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVSet, Key: key, Value: item.Item.SerializedItem}})
+		}
+	}
+	for _, key := range oldKeys {
+		if !newKeys[key] && key != store.initedKey() {
+			ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVDelete, Key: key}})
+		}
+	}
+	ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVSet, Key: store.initedKey(), Value: []byte("")}})
+
+	// As PersistentDataStore.Init requires when the update can't be atomic, the items are written in
+	// order, followed by the deletions, and the store is only marked as initialized at the end.
+	for len(ops) > 0 {
+		batch := ops
+		if len(batch) > maxTxnOps {
+			batch = batch[:maxTxnOps]
+		}
+		ops = ops[len(batch):]
+		ok, resp, _, err := store.txn.Txn(batch, nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("failed to update Consul data: %s", describeTxnErrors(resp))
+		}
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *consulDataStore) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	pair, _, err := store.kv.Get(store.itemKey(kind, key), nil)
+	if err != nil || pair == nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	// The version is in the serialized item, which the SDK will deserialize anyway.
+	return ldstoretypes.SerializedItemDescriptor{SerializedItem: pair.Value}, nil
+}
+
+func (store *consulDataStore) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	kindPrefix := store.kindPrefix(kind)
+	pairs, _, err := store.kv.List(kindPrefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(pairs))
+	for _, pair := range pairs {
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  strings.TrimPrefix(pair.Key, kindPrefix),
+			Item: ldstoretypes.SerializedItemDescriptor{SerializedItem: pair.Value},
+		})
+	}
+	return results, nil
+}
+
+func (store *consulDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	itemKey := store.itemKey(kind, key)
+	for {
+		// A ModifyIndex of zero makes the check-and-set operation succeed only if the key does not exist.
+		var modifyIndex uint64
+		oldPair, _, err := store.kv.Get(itemKey, nil)
+		if err != nil {
+			return false, err
+		}
+		if oldPair != nil {
+			oldItem, err := kind.Deserialize(oldPair.Value)
+			if err == nil && oldItem.Version >= newItem.Version {
+				return false, nil
+			}
+			// If the old item can't be parsed, it is replaced, since it is no use to anyone.
+			modifyIndex = oldPair.ModifyIndex
+		}
+
+		if store.testTxHook != nil { // instrumentation for unit tests
+			store.testTxHook()
+		}
+
+		written, _, err := store.kv.CAS(&api.KVPair{Key: itemKey, Value: newItem.SerializedItem,
+			ModifyIndex: modifyIndex}, nil)
+		if err != nil {
+			return false, err
+		}
+		if written {
+			return true, nil
+		}
+		// Another process changed the item after we read it, so we'll read it again and see whether
+		// our version is still newer.
+		store.loggers.Debug("Concurrent modification detected, retrying")
+	}
+}
+
+func (store *consulDataStore) IsInitialized() bool {
+	store.lock.Lock()
+	inited := store.inited
+	store.lock.Unlock()
+	if inited {
+		return true
+	}
+	pair, _, err := store.kv.Get(store.initedKey(), nil)
+	if err != nil || pair == nil {
+		return false
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return true
+}
+
+func (store *consulDataStore) IsStoreAvailable() bool {
+	_, _, err := store.kv.Get(store.initedKey(), nil)
+	return err == nil
+}
+
+// SetItemChangedHandler is called internally by the SDK. It starts watching the data store's keys.
+func (store *consulDataStore) SetItemChangedHandler(handler func(kind ldstoretypes.DataKind, key string)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store.lock.Lock()
+	store.cancel = cancel
+	store.lock.Unlock()
+	go store.watch(ctx, handler)
+}
+
+// Close is called automatically when the client is closed.
+func (store *consulDataStore) Close() error {
+	store.closeOnce.Do(func() {
+		store.lock.Lock()
+		cancel := store.cancel
+		store.lock.Unlock()
+		if cancel != nil {
+			cancel()
+			<-store.doneCh
+		}
+	})
+	return nil
+}
+
+// watch uses a blocking query to wait for any of the data store's keys to change, and calls the handler
+// for each item that has a different ModifyIndex than before, or that no longer exists.
+func (store *consulDataStore) watch(ctx context.Context, handler func(ldstoretypes.DataKind, string)) {
+	defer close(store.doneCh)
+	var lastIndex uint64
+	var modifyIndexes map[string]uint64 // nil until the first query succeeds
+	delay := store.watchRetryDelay
+	for {
+		pairs, meta, err := store.kv.List(store.prefix+"/", (&api.QueryOptions{WaitIndex: lastIndex}).WithContext(ctx))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			store.loggers.Warnf("Error watching Consul for changes: %s", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > maxWatchRetryDelay {
+				delay = maxWatchRetryDelay
+			}
+			continue
+		}
+		delay = store.watchRetryDelay
+		// Consul's documentation says that the index should start over if it ever goes backward.
+		if meta.LastIndex < lastIndex {
+			lastIndex = 0
+		} else {
+			lastIndex = meta.LastIndex
+		}
+		current := make(map[string]uint64, len(pairs))
+		for _, pair := range pairs {
+			current[pair.Key] = pair.ModifyIndex
+		}
+		if modifyIndexes != nil {
+			for key, index := range current {
+				if old, ok := modifyIndexes[key]; !ok || old != index {
+					store.notifyChanged(key, handler)
+				}
+			}
+			for key := range modifyIndexes {
+				if _, ok := current[key]; !ok {
+					store.notifyChanged(key, handler)
+				}
+			}
+		}
+		modifyIndexes = current
+	}
+}
+
+func (store *consulDataStore) notifyChanged(consulKey string, handler func(ldstoretypes.DataKind, string)) {
+	for _, kind := range ldstoreimpl.AllKinds() {
+		if key := strings.TrimPrefix(consulKey, store.kindPrefix(kind)); key != consulKey {
+			handler(kind, key)
+			return
+		}
+	}
+}
+
+func (store *consulDataStore) kindPrefix(kind ldstoretypes.DataKind) string {
+	return store.prefix + "/" + kind.GetName() + "/"
+}
+
+func (store *consulDataStore) itemKey(kind ldstoretypes.DataKind, key string) string {
+	return store.kindPrefix(kind) + key
+}
+
+func (store *consulDataStore) initedKey() string {
+	return store.prefix + "/" + initedKey
+}
+
+func describeTxnErrors(resp *api.TxnResponse) string {
+	if resp == nil || len(resp.Errors) == 0 {
+		return "transaction was rolled back" // COVERAGE: Consul always returns the errors
+	}
+	messages := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		messages = append(messages, e.What)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,243 @@
+package ldconsul
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestContext(loggers ldlog.Loggers) subsystems.ClientContext {
+	return subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: loggers}}
+}
+
+func buildStore(t *testing.T, address string, opts ...ConsulOption) *consulDataStore {
+	store, err := NewConsulDataStoreFactory(address, opts...).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store.(*consulDataStore)
+}
+
+func runTestSuite(t *testing.T, address string, clearPrefix func(prefix string) error) {
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewConsulDataStoreFactory(address, WithPrefix(prefix))
+		},
+		func(prefix string) error {
+			if prefix == "" {
+				prefix = DefaultPrefix
+			}
+			return clearPrefix(prefix + "/")
+		},
+	).ErrorStoreFactory(
+		NewConsulDataStoreFactory(closedServerAddress()),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*consulDataStore).testTxHook = hook
+		},
+	).Run(t)
+}
+
+func closedServerAddress() string {
+	server := httptest.NewServer(nil)
+	server.Close()
+	return server.URL
+}
+
+func TestConsulDataStoreWithFakeConsul(t *testing.T) {
+	fake := newFakeConsul(t)
+	runTestSuite(t, fake.address(), func(prefix string) error {
+		fake.deleteTree(prefix)
+		return nil
+	})
+}
+
+// TestConsulDataStoreWithLocalAgent runs the same tests against a real Consul agent, if the standard
+// CONSUL_HTTP_ADDR environment variable is set; for instance, after "consul agent -dev", set it to
+// "localhost:8500".
+func TestConsulDataStoreWithLocalAgent(t *testing.T) {
+	if os.Getenv(api.HTTPAddrEnvName) == "" {
+		t.Skip(api.HTTPAddrEnvName + " is not set")
+	}
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	runTestSuite(t, "", func(prefix string) error {
+		_, err := client.KV().DeleteTree(prefix, nil)
+		return err
+	})
+}
+
+func TestInitWritesMoreItemsThanOneTransactionAllows(t *testing.T) {
+	fake := newFakeConsul(t)
+	store := buildStore(t, fake.address())
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(100, 1)},
+	}))
+	other := makeFlagItems(130, 2)[100:]
+	_, err := store.Upsert(datakinds.Features, other[0].Key, other[0].Item)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(70, 2)},
+	}))
+
+	items, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, items, 70)
+	for _, item := range items {
+		desc, err := datakinds.Features.Deserialize(item.Item.SerializedItem)
+		require.NoError(t, err)
+		assert.Equal(t, 2, desc.Version)
+	}
+}
+
+func makeFlagItems(count int, version int) []ldstoretypes.KeyedSerializedItemDescriptor {
+	items := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, count)
+	for i := 0; i < count; i++ {
+		flag := ldbuilders.NewFlagBuilder("flag" + string(rune('a'+i/26)) + string(rune('a'+i%26))).
+			Version(version).Build()
+		items = append(items, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key: flag.Key,
+			Item: ldstoreimpl.SerializeDescriptor(datakinds.Features,
+				ldstoretypes.ItemDescriptor{Version: version, Item: &flag}),
+		})
+	}
+	return items
+}
+
+func TestWatchReportsChangedItems(t *testing.T) {
+	fake := newFakeConsul(t)
+	writer := buildStore(t, fake.address())
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(1).Build()
+	require.NoError(t, writer.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: flag.Key, Item: ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag))},
+		}},
+		{Kind: datakinds.Segments, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: segment.Key, Item: ldstoreimpl.SerializeDescriptor(datakinds.Segments,
+				ldstoretypes.ItemDescriptor{Version: 1, Item: &segment})},
+		}},
+	}))
+
+	type change struct {
+		kind ldstoretypes.DataKind
+		key  string
+	}
+	changes := make(chan change, 10)
+	watcher := buildStore(t, fake.address())
+	watcher.SetItemChangedHandler(func(kind ldstoretypes.DataKind, key string) { changes <- change{kind, key} })
+	time.Sleep(100 * time.Millisecond) // let the first query finish, so that it has something to compare with
+
+	flag.Version = 2
+	_, err := writer.Upsert(datakinds.Features, flag.Key,
+		ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)))
+	require.NoError(t, err)
+	assert.Equal(t, change{datakinds.Features, flag.Key}, requireChange(t, changes))
+
+	require.NoError(t, writer.Init([]ldstoretypes.SerializedCollection{{Kind: datakinds.Features}}))
+	received := []change{requireChange(t, changes), requireChange(t, changes)}
+	assert.ElementsMatch(t, []change{{datakinds.Features, flag.Key}, {datakinds.Segments, segment.Key}}, received)
+}
+
+func requireChange[T any](t *testing.T, ch <-chan T) T {
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "timed out waiting for change notification")
+		var empty T
+		return empty
+	}
+}
+
+func TestWatchRetriesAfterError(t *testing.T) {
+	fake := newFakeConsul(t)
+	mockLog := ldlogtest.NewMockLog()
+	factory := NewConsulDataStoreFactory(fake.address()).(*consulDataStoreFactory)
+	factory.watchRetryDelay = 10 * time.Millisecond
+	store, err := factory.Build(makeTestContext(mockLog.Loggers))
+	require.NoError(t, err)
+	defer store.Close()
+
+	changes := make(chan string, 10)
+	fake.setFailing(true)
+	store.(*consulDataStore).SetItemChangedHandler(func(kind ldstoretypes.DataKind, key string) { changes <- key })
+	time.Sleep(50 * time.Millisecond)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Error watching Consul for changes")
+	fake.setFailing(false)
+	time.Sleep(50 * time.Millisecond)
+
+	writer := buildStore(t, fake.address())
+	_, err = writer.Upsert(datakinds.Features, "flagkey", makeFlagItems(1, 1)[0].Item)
+	require.NoError(t, err)
+	assert.Equal(t, "flagkey", requireChange(t, changes))
+}
+
+func TestCloseStopsWatch(t *testing.T) {
+	fake := newFakeConsul(t)
+	store := buildStore(t, fake.address())
+	store.SetItemChangedHandler(func(ldstoretypes.DataKind, string) {})
+	require.NoError(t, store.Close())
+	<-store.doneCh // the watch has ended
+}
+
+func TestClientInDaemonModeSeesExternalUpdatesBeforeCacheExpires(t *testing.T) {
+	fake := newFakeConsul(t)
+	writer := buildStore(t, fake.address())
+	makeFlag := func(version int, value bool) ldstoretypes.SerializedItemDescriptor {
+		flag := ldbuilders.NewFlagBuilder("flagkey").Version(version).On(false).
+			Variations(ldvalue.Bool(false), ldvalue.Bool(true)).OffVariation(boolVariation(value)).Build()
+		return ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag))
+	}
+	require.NoError(t, writer.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: []ldstoretypes.KeyedSerializedItemDescriptor{
+			{Key: "flagkey", Item: makeFlag(1, false)},
+		}},
+	}))
+
+	client, err := ld.MakeCustomClient("sdk-key", ld.Config{
+		DataSource: ldcomponents.ExternalUpdatesOnly(),
+		DataStore: ldcomponents.PersistentDataStore(NewConsulDataStoreFactory(fake.address())).
+			CacheTime(time.Hour),
+		Events:  ldcomponents.NoEvents(),
+		Logging: ldcomponents.NoLogging(),
+	}, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+	context := ldcontext.New("userkey")
+	value, _ := client.BoolVariation("flagkey", context, true)
+	require.False(t, value)
+
+	_, err = writer.Upsert(datakinds.Features, "flagkey", makeFlag(2, true))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		value, _ := client.BoolVariation("flagkey", context, false)
+		return value
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func boolVariation(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
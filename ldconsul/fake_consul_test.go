@@ -0,0 +1,186 @@
+package ldconsul
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// fakeConsul implements the parts of the Consul HTTP API that the data store uses, so that the tests
+// do not need a Consul agent.
+type fakeConsul struct {
+	server    *httptest.Server
+	index     uint64
+	pairs     map[string]*api.KVPair
+	changedCh chan struct{} // closed and replaced whenever the data changes
+	failing   bool
+	lock      sync.Mutex
+}
+
+func newFakeConsul(t *testing.T) *fakeConsul {
+	f := &fakeConsul{index: 1, pairs: make(map[string]*api.KVPair), changedCh: make(chan struct{})}
+	f.server = httptest.NewServer(f)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeConsul) address() string {
+	return f.server.URL
+}
+
+func (f *fakeConsul) setFailing(failing bool) {
+	f.lock.Lock()
+	f.failing = failing
+	f.lock.Unlock()
+}
+
+func (f *fakeConsul) deleteTree(prefix string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for key := range f.pairs {
+		if strings.HasPrefix(key, prefix) {
+			delete(f.pairs, key)
+		}
+	}
+	f.changed()
+}
+
+// changed must be called with the lock held.
+func (f *fakeConsul) changed() {
+	f.index++
+	close(f.changedCh)
+	f.changedCh = make(chan struct{})
+}
+
+// set must be called with the lock held.
+func (f *fakeConsul) set(key string, value []byte) {
+	pair := &api.KVPair{Key: key, Value: value, CreateIndex: f.index + 1, ModifyIndex: f.index + 1}
+	if old, ok := f.pairs[key]; ok {
+		pair.CreateIndex = old.CreateIndex
+	}
+	f.pairs[key] = pair
+	f.changed()
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	switch {
+	case r.URL.Path == "/v1/txn" && r.Method == http.MethodPut:
+		var ops api.TxnOps
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, op := range ops {
+			switch op.KV.Verb {
+			case api.KVSet:
+				f.set(op.KV.Key, op.KV.Value)
+			case api.KVDelete:
+				delete(f.pairs, op.KV.Key)
+				f.changed()
+			}
+		}
+		f.writeJSON(w, api.TxnResponse{})
+	case strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		switch r.Method {
+		case http.MethodGet:
+			if query.Has("index") {
+				f.waitForChange(r, query.Get("index"))
+			}
+			f.get(w, key, query)
+		case http.MethodPut:
+			value, _ := io.ReadAll(r.Body)
+			if query.Has("cas") {
+				cas, _ := strconv.ParseUint(query.Get("cas"), 10, 64)
+				old, exists := f.pairs[key]
+				if (cas == 0 && exists) || (cas != 0 && (!exists || old.ModifyIndex != cas)) {
+					f.writeJSON(w, false)
+					return
+				}
+			}
+			f.set(key, value)
+			f.writeJSON(w, true)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// waitForChange implements a blocking query; it must be called with the lock held.
+func (f *fakeConsul) waitForChange(r *http.Request, indexParam string) {
+	index, _ := strconv.ParseUint(indexParam, 10, 64)
+	timeout := time.After(5 * time.Second)
+	for f.index <= index {
+		changedCh := f.changedCh
+		f.lock.Unlock()
+		select {
+		case <-changedCh:
+		case <-timeout:
+		case <-r.Context().Done():
+		}
+		f.lock.Lock()
+		if r.Context().Err() != nil {
+			return
+		}
+		select {
+		case <-timeout:
+			return
+		default:
+		}
+	}
+}
+
+func (f *fakeConsul) get(w http.ResponseWriter, key string, query url.Values) {
+	var results []*api.KVPair
+	for k, pair := range f.pairs {
+		if k == key || ((query.Has("recurse") || query.Has("keys")) && strings.HasPrefix(k, key)) {
+			results = append(results, pair)
+		}
+	}
+	if len(results) == 0 {
+		f.writeHeaders(w)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	if query.Has("keys") {
+		keys := make([]string, 0, len(results))
+		for _, pair := range results {
+			keys = append(keys, pair.Key)
+		}
+		f.writeJSON(w, keys)
+		return
+	}
+	f.writeJSON(w, results)
+}
+
+func (f *fakeConsul) writeHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	w.Header().Set("X-Consul-LastContact", "0")
+	w.Header().Set("X-Consul-KnownLeader", "true")
+}
+
+func (f *fakeConsul) writeJSON(w http.ResponseWriter, value interface{}) {
+	f.writeHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,6 @@
+// Package ldconsul provides a persistent data store that keeps feature flags and segments in the key-value
+// store of HashiCorp Consul, for applications that already use Consul for service configuration.
+//
+// See [NewConsulDataStoreFactory] for how to use it. This is a separate Go module, so that applications
+// that do not use it do not get the Consul client as a dependency of the SDK.
+package ldconsul
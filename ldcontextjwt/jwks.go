@@ -0,0 +1,196 @@
+package ldcontextjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSCacheTime is the default length of time that [HTTPJWKSProvider] keeps the keys it has
+// fetched.
+const DefaultJWKSCacheTime = time.Hour
+
+// minimumJWKSRefreshInterval limits how often an unknown key ID can cause the key set to be fetched again.
+const minimumJWKSRefreshInterval = 10 * time.Second
+
+// JWKSProvider provides the public keys that are used to verify token signatures.
+type JWKSProvider interface {
+	// GetKey returns the public key with the given key ID, which is the "kid" header of a token. The key
+	// must be an *rsa.PublicKey or an *ecdsa.PublicKey.
+	GetKey(keyID string) (crypto.PublicKey, error)
+}
+
+// HTTPJWKSProvider is a [JWKSProvider] that gets keys from a JWKS endpoint, such as the jwks_uri of an
+// OpenID Connect provider.
+//
+// The key set is fetched the first time a key is needed, and again when the cache time has elapsed. If a
+// token refers to a key ID that is not in the cached key set, the key set is fetched again, in case the
+// keys have been rotated; this happens at most once every 10 seconds. It is safe for concurrent use.
+type HTTPJWKSProvider struct {
+	url         string
+	httpClient  *http.Client
+	cacheTime   time.Duration
+	keys        map[string]crypto.PublicKey
+	lastFetched time.Time
+	lock        sync.Mutex
+}
+
+// NewHTTPJWKSProvider creates an [HTTPJWKSProvider] for the specified JWKS URL.
+//
+// If httpClient is nil, http.DefaultClient is used. If cacheTime is zero or negative, it is set to
+// [DefaultJWKSCacheTime].
+func NewHTTPJWKSProvider(url string, httpClient *http.Client, cacheTime time.Duration) *HTTPJWKSProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if cacheTime <= 0 {
+		cacheTime = DefaultJWKSCacheTime
+	}
+	return &HTTPJWKSProvider{url: url, httpClient: httpClient, cacheTime: cacheTime}
+}
+
+// GetKey returns the public key with the given key ID, fetching the key set if necessary.
+func (p *HTTPJWKSProvider) GetKey(keyID string) (crypto.PublicKey, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sinceFetched := time.Since(p.lastFetched)
+	key, found := p.keys[keyID]
+	if p.keys == nil || sinceFetched > p.cacheTime || (!found && sinceFetched > minimumJWKSRefreshInterval) {
+		keys, err := p.fetch()
+		if err != nil {
+			if found {
+				return key, nil // keep using the cached key if the endpoint is temporarily unavailable
+			}
+			return nil, err
+		}
+		p.keys = keys
+		p.lastFetched = time.Now()
+		key, found = keys[keyID]
+	}
+	if !found {
+		return nil, fmt.Errorf("no key with ID %q in key set", keyID)
+	}
+	return key, nil
+}
+
+func (p *HTTPJWKSProvider) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := p.httpClient.Get(p.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key set: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get key set: HTTP status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set: %w", err)
+	}
+	return ParseJWKS(body)
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// ParseJWKS parses a JSON Web Key Set and returns its RSA and elliptic-curve public keys, indexed by key
+// ID. Keys of other types, and keys that are only for encryption, are ignored.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.KeyType {
+		case "RSA":
+			key, err = parseRSAKey(jwk)
+		case "EC":
+			key, err = parseECKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in key set: %w", jwk.KeyID, err)
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+		return nil, errors.New("RSA exponent is too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+	}
+	x, err := decodeBigInt(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) { //nolint:staticcheck // there is no non-deprecated way to check big.Int coordinates
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
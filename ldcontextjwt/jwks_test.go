@@ -0,0 +1,138 @@
+package ldcontextjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) ldvalue.Value {
+	return ldvalue.ObjectBuild().SetString("kty", "RSA").SetString("kid", kid).
+		SetString("n", encodeBigInt(key.N)).SetString("e", encodeBigInt(big.NewInt(int64(key.E)))).Build()
+}
+
+func ecJWK(kid, crv string, key *ecdsa.PublicKey) ldvalue.Value {
+	return ldvalue.ObjectBuild().SetString("kty", "EC").SetString("kid", kid).SetString("crv", crv).
+		SetString("x", encodeBigInt(key.X)).SetString("y", encodeBigInt(key.Y)).Build()
+}
+
+func makeJWKS(keys ...ldvalue.Value) []byte {
+	return []byte(ldvalue.ObjectBuild().Set("keys", ldvalue.ArrayOf(keys...)).Build().JSONString())
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey := makeRSAKey(t)
+	ecKey := makeECKey(t, elliptic.P384())
+	data := makeJWKS(
+		rsaJWK("r", &rsaKey.PublicKey),
+		ecJWK("e", "P-384", &ecKey.PublicKey),
+		ldvalue.ObjectBuild().SetString("kty", "oct").SetString("kid", "symmetric").Build(),
+		ldvalue.ObjectBuild().SetString("kty", "RSA").SetString("kid", "enc").SetString("use", "enc").Build(),
+	)
+
+	keys, err := ParseJWKS(data)
+
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.True(t, rsaKey.PublicKey.Equal(keys["r"]))
+	assert.True(t, ecKey.PublicKey.Equal(keys["e"]))
+}
+
+func TestParseJWKSRejectsInvalidKeys(t *testing.T) {
+	ecKey := makeECKey(t, elliptic.P256())
+	badPoint := ecKey.PublicKey
+	badPoint.Y = new(big.Int).Add(badPoint.Y, big.NewInt(1))
+
+	for name, data := range map[string][]byte{
+		"malformed JSON":  []byte("{"),
+		"missing modulus": makeJWKS(ldvalue.ObjectBuild().SetString("kty", "RSA").SetString("e", "AQAB").Build()),
+		"unknown curve":   makeJWKS(ecJWK("e", "P-1", &ecKey.PublicKey)),
+		"point off curve": makeJWKS(ecJWK("e", "P-256", &badPoint)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJWKS(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHTTPJWKSProvider(t *testing.T) {
+	key1, key2 := makeRSAKey(t), makeRSAKey(t)
+	var requests int32
+	var keySet atomic.Value
+	keySet.Store(makeJWKS(rsaJWK("kid1", &key1.PublicKey)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write(keySet.Load().([]byte))
+	}))
+	defer server.Close()
+
+	t.Run("caches key set", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		p := NewHTTPJWKSProvider(server.URL, nil, 0)
+		for i := 0; i < 3; i++ {
+			key, err := p.GetKey("kid1")
+			require.NoError(t, err)
+			assert.True(t, key1.PublicKey.Equal(key))
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("does not refetch immediately for unknown key ID", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		p := NewHTTPJWKSProvider(server.URL, nil, 0)
+		_, err := p.GetKey("kid1")
+		require.NoError(t, err)
+		_, err = p.GetKey("kid2")
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("refetches for unknown key ID after refresh interval", func(t *testing.T) {
+		p := NewHTTPJWKSProvider(server.URL, nil, 0)
+		_, err := p.GetKey("kid1")
+		require.NoError(t, err)
+		keySet.Store(makeJWKS(rsaJWK("kid1", &key1.PublicKey), rsaJWK("kid2", &key2.PublicKey)))
+		p.lastFetched = p.lastFetched.Add(-minimumJWKSRefreshInterval - time.Second)
+
+		key, err := p.GetKey("kid2")
+
+		require.NoError(t, err)
+		assert.True(t, key2.PublicKey.Equal(key))
+	})
+
+	t.Run("FromJWT with provider", func(t *testing.T) {
+		p := NewHTTPJWKSProvider(server.URL, server.Client(), time.Minute)
+		token := makeToken(t, "RS256", "kid1", key1, basicClaims().Build())
+		c, err := FromJWT(token, p, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "user-key", c.Key())
+	})
+}
+
+func TestHTTPJWKSProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPJWKSProvider(server.URL, nil, 0).GetKey("kid1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP status 500")
+}
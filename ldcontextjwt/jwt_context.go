@@ -0,0 +1,200 @@
+package ldcontextjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// clockSkew is how far the token's time claims may be off from the local clock.
+const clockSkew = time.Minute
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// FromJWT verifies a JWT and creates an evaluation context from its claims.
+//
+// The token's signature is checked with the key from jwks that matches the token's "kid" header, and the
+// token is rejected if its "exp" or "nbf" claims show that it is not currently valid. The context has the
+// default kind "user", and its key is the "sub" claim, which must be a non-empty string.
+//
+// Each of the claims named in claims that is present in the token becomes a context attribute with the
+// same name. Only string, number, and boolean claims, and arrays of those, are used; any other claim,
+// such as one whose value is a JSON object, is skipped and a warning is logged. Claim names that are not
+// valid attribute names, or that would replace the context kind or key ("kind", "key", and "_meta"), are
+// skipped in the same way.
+//
+// Warnings are logged with ldlog.NewDefaultLoggers(). Use [FromJWTWithLoggers] to specify other loggers.
+func FromJWT(tokenString string, jwks JWKSProvider, claims []string) (ldcontext.Context, error) {
+	return FromJWTWithLoggers(tokenString, jwks, claims, ldlog.NewDefaultLoggers())
+}
+
+// FromJWTWithLoggers is the same as [FromJWT], but logs warnings with the specified loggers.
+func FromJWTWithLoggers(
+	tokenString string,
+	jwks JWKSProvider,
+	claims []string,
+	loggers ldlog.Loggers,
+) (ldcontext.Context, error) {
+	tokenClaims, err := verifyToken(tokenString, jwks, time.Now())
+	if err != nil {
+		return ldcontext.Context{}, err
+	}
+
+	subject := tokenClaims["sub"]
+	if subject.Type() != ldvalue.StringType || subject.StringValue() == "" {
+		return ldcontext.Context{}, errors.New("token has no \"sub\" claim")
+	}
+	builder := ldcontext.NewBuilder(subject.StringValue())
+	for _, name := range claims {
+		value, found := tokenClaims[name]
+		if !found {
+			continue
+		}
+		if !isUsableClaimValue(value) {
+			loggers.Warnf("Skipping JWT claim %q because its value is a JSON %s", name, value.Type())
+			continue
+		}
+		if name == "kind" || name == "key" || name == "_meta" || !builder.TrySetValue(name, value) {
+			loggers.Warnf("Skipping JWT claim %q because it cannot be used as a context attribute", name)
+		}
+	}
+	return builder.Build(), nil
+}
+
+func isUsableClaimValue(value ldvalue.Value) bool {
+	switch value.Type() {
+	case ldvalue.StringType, ldvalue.NumberType, ldvalue.BoolType:
+		return true
+	case ldvalue.ArrayType:
+		for _, element := range value.AsValueArray().AsSlice() {
+			if t := element.Type(); t != ldvalue.StringType && t != ldvalue.NumberType && t != ldvalue.BoolType {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func verifyToken(tokenString string, jwks JWKSProvider, now time.Time) (map[string]ldvalue.Value, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a signed JWT")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature: %w", err)
+	}
+	key, err := jwks.GetKey(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	var claims map[string]ldvalue.Value
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	if exp, ok := claims["exp"]; ok && exp.IsNumber() && now.After(unixTime(exp).Add(clockSkew)) {
+		return nil, errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"]; ok && nbf.IsNumber() && now.Add(clockSkew).Before(unixTime(nbf)) {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+func unixTime(value ldvalue.Value) time.Time {
+	return time.Unix(int64(value.Float64Value()), 0)
+}
+
+func verifySignature(algorithm string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	var curve elliptic.Curve // for ES algorithms, which each require a specific curve
+	switch algorithm[min(2, len(algorithm)):] {
+	case "256":
+		hash, curve = crypto.SHA256, elliptic.P256()
+	case "384":
+		hash, curve = crypto.SHA384, elliptic.P384()
+	case "512":
+		hash, curve = crypto.SHA512, elliptic.P521()
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(algorithm, "RS"), strings.HasPrefix(algorithm, "PS"):
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key for %s signature is not an RSA key", algorithm)
+		}
+		if algorithm[0] == 'R' {
+			err := rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+			return wrapSignatureError(err)
+		}
+		err := rsa.VerifyPSS(rsaKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		return wrapSignatureError(err)
+	case strings.HasPrefix(algorithm, "ES"):
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key for %s signature is not an elliptic-curve key", algorithm)
+		}
+		if ecKey.Curve != curve {
+			return fmt.Errorf("key for %s signature must use curve %s, not %s", algorithm,
+				curve.Params().Name, ecKey.Curve.Params().Name)
+		}
+		// A JWS ECDSA signature is the two integers r and s, each padded to the size of the curve
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("token signature is invalid")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("token signature is invalid")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", algorithm)
+	}
+}
+
+func wrapSignatureError(err error) error {
+	if err != nil {
+		return errors.New("token signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package ldcontextjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeys map[string]crypto.PublicKey
+
+func (s staticKeys) GetKey(keyID string) (crypto.PublicKey, error) {
+	if key, ok := s[keyID]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown key")
+}
+
+func encodePart(value ldvalue.Value) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value.JSONString()))
+}
+
+func makeToken(t *testing.T, alg, kid string, key crypto.Signer, claims ldvalue.Value) string {
+	header := ldvalue.ObjectBuild().SetString("alg", alg).SetString("kid", kid).SetString("typ", "JWT").Build()
+	signed := encodePart(header) + "." + encodePart(claims)
+
+	hash := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}[alg[2:]]
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg[0] == 'P' {
+			signature, err = rsa.SignPSS(rand.Reader, k, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func makeRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func makeECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func basicClaims() *ldvalue.ObjectBuilder {
+	return ldvalue.ObjectBuild().SetString("sub", "user-key").
+		SetFloat64("exp", float64(time.Now().Add(time.Hour).Unix()))
+}
+
+func TestFromJWTWithSupportedAlgorithms(t *testing.T) {
+	rsaKey := makeRSAKey(t)
+	keys := map[string]crypto.Signer{
+		"RS256": rsaKey, "RS384": rsaKey, "RS512": rsaKey,
+		"PS256": rsaKey, "PS384": rsaKey, "PS512": rsaKey,
+		"ES256": makeECKey(t, elliptic.P256()),
+		"ES384": makeECKey(t, elliptic.P384()),
+		"ES512": makeECKey(t, elliptic.P521()),
+	}
+	for alg, key := range keys {
+		t.Run(alg, func(t *testing.T) {
+			jwks := staticKeys{"kid1": key.Public()}
+			token := makeToken(t, alg, "kid1", key, basicClaims().Build())
+
+			c, err := FromJWT(token, jwks, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, ldcontext.New("user-key"), c)
+		})
+	}
+}
+
+func TestFromJWTSetsRequestedClaimsAsAttributes(t *testing.T) {
+	key := makeRSAKey(t)
+	jwks := staticKeys{"kid1": key.Public()}
+	claims := basicClaims().
+		SetString("email", "a@example.com").
+		SetBool("email_verified", true).
+		SetInt("level", 3).
+		Set("groups", ldvalue.ArrayOf(ldvalue.String("a"), ldvalue.String("b"))).
+		SetString("unrequested", "x").
+		Build()
+	token := makeToken(t, "RS256", "kid1", key, claims)
+
+	c, err := FromJWT(token, jwks, []string{"email", "email_verified", "level", "groups", "missing"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-key", c.Key())
+	assert.Equal(t, ldvalue.String("a@example.com"), c.GetValue("email"))
+	assert.Equal(t, ldvalue.Bool(true), c.GetValue("email_verified"))
+	assert.Equal(t, ldvalue.Int(3), c.GetValue("level"))
+	assert.Equal(t, ldvalue.ArrayOf(ldvalue.String("a"), ldvalue.String("b")), c.GetValue("groups"))
+	assert.Equal(t, ldvalue.Null(), c.GetValue("unrequested"))
+	assert.Equal(t, ldvalue.Null(), c.GetValue("missing"))
+}
+
+func TestFromJWTSkipsClaimsWithUnsupportedValues(t *testing.T) {
+	key := makeRSAKey(t)
+	jwks := staticKeys{"kid1": key.Public()}
+	claims := basicClaims().
+		Set("address", ldvalue.ObjectBuild().SetString("country", "US").Build()).
+		Set("nested", ldvalue.ArrayOf(ldvalue.ArrayOf())).
+		SetString("kind", "org").
+		SetString("key", "other-key").
+		SetString("name", "Lucy").
+		Build()
+	token := makeToken(t, "RS256", "kid1", key, claims)
+	mockLog := ldlogtest.NewMockLog()
+
+	c, err := FromJWTWithLoggers(token, jwks, []string{"address", "nested", "kind", "key", "name"}, mockLog.Loggers)
+
+	require.NoError(t, err)
+	assert.Equal(t, ldcontext.DefaultKind, c.Kind())
+	assert.Equal(t, "user-key", c.Key())
+	assert.Equal(t, "Lucy", c.Name().StringValue())
+	assert.Equal(t, ldvalue.Null(), c.GetValue("address"))
+	assert.Equal(t, ldvalue.Null(), c.GetValue("nested"))
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `claim "address" because its value is a JSON object`)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `claim "nested" because its value is a JSON array`)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `claim "kind" because it cannot be used`)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `claim "key" because it cannot be used`)
+}
+
+func TestFromJWTRejectsInvalidTokens(t *testing.T) {
+	key := makeRSAKey(t)
+	otherKey := makeRSAKey(t)
+	ecKey := makeECKey(t, elliptic.P256())
+	jwks := staticKeys{"kid1": key.Public(), "ec": ecKey.Public()}
+	goodToken := strings.Split(makeToken(t, "RS256", "kid1", key, basicClaims().Build()), ".")
+	alteredPayload := goodToken[0] + "." + encodePart(basicClaims().SetString("sub", "other").Build()) + "." + goodToken[2]
+	notYetValid := basicClaims().SetFloat64("nbf", float64(time.Now().Add(time.Hour).Unix())).Build()
+	noneHeader := encodePart(ldvalue.ObjectBuild().SetString("alg", "none").SetString("kid", "kid1").Build())
+
+	tokens := map[string]string{
+		"malformed":       "not-a-token",
+		"wrong key":       makeToken(t, "RS256", "kid1", otherKey, basicClaims().Build()),
+		"unknown key ID":  makeToken(t, "RS256", "kid2", key, basicClaims().Build()),
+		"altered payload": alteredPayload,
+		"unsigned":        noneHeader + "." + encodePart(basicClaims().Build()) + ".",
+		"mismatched key":  makeToken(t, "RS256", "ec", ecKey, basicClaims().Build()),
+		"wrong curve":     makeToken(t, "ES384", "ec", ecKey, basicClaims().Build()),
+		"no subject":      makeToken(t, "RS256", "kid1", key, ldvalue.ObjectBuild().Build()),
+		"non-string sub":  makeToken(t, "RS256", "kid1", key, ldvalue.ObjectBuild().SetInt("sub", 1).Build()),
+		"expired":         makeToken(t, "RS256", "kid1", key, basicClaims().SetInt("exp", 1000).Build()),
+		"not yet valid":   makeToken(t, "RS256", "kid1", key, notYetValid),
+	}
+	for name, token := range tokens {
+		t.Run(name, func(t *testing.T) {
+			_, err := FromJWT(token, jwks, nil)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,9 @@
+// Package ldcontextjwt creates evaluation contexts from the claims of JWT bearer tokens.
+//
+// API servers often authenticate each request with a JSON Web Token, and the token's claims describe the
+// caller well enough to evaluate flags for them. [FromJWT] verifies a token's signature with keys from a
+// JSON Web Key Set (JWKS) endpoint, and builds an [ldcontext.Context] whose key is the token's "sub" claim.
+//
+// Only asymmetric signature algorithms are supported: RS256, RS384, RS512, PS256, PS384, PS512, ES256,
+// ES384, and ES512.
+package ldcontextjwt
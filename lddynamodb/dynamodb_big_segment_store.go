@@ -0,0 +1,149 @@
+package lddynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	partitionKeyAttr = "contextHash"
+	sortKeyAttr      = "segmentRef"
+	includedAttr     = "included"
+	lastUpToDateAttr = "lastUpToDate"
+
+	// The metadata item's partition key cannot be mistaken for a context hash, since "_" is not a
+	// base64 character.
+	metadataPartitionKey = "big_segments_metadata"
+	metadataSortKey      = "metadata"
+)
+
+type dynamoDBBigSegmentStoreFactory struct {
+	tableName string
+	options   dynamoDBOptions
+}
+
+type dynamoDBBigSegmentStore struct {
+	client           *dynamodb.Client
+	tableName        string
+	prefix           string
+	operationTimeout time.Duration
+}
+
+// BigSegmentStore returns a configuration for a Big Segment store that reads from the specified
+// DynamoDB table. To use it, pass it to ldcomponents.BigSegments and store the result in the
+// BigSegments field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    BigSegments: ldcomponents.BigSegments(lddynamodb.BigSegmentStore("my-big-segments-table")),
+//	}
+//
+// The table must have a string partition key named "contextHash" and a string sort key named
+// "segmentRef". Whatever synchronizes the Big Segments writes one item for each segment that a context
+// is included in or excluded from: its partition key is the base64-encoded hash of the context key
+// that the SDK passes to the store, its sort key is the segment reference, and its boolean "included"
+// attribute is false if the context is excluded. The SDK reads all of a context's items with one
+// Query. The item whose partition key is "big_segments_metadata" and sort key is "metadata" has a
+// numeric "lastUpToDate" attribute, the time the data was last brought up to date in milliseconds
+// since the Unix epoch.
+func BigSegmentStore(
+	tableName string,
+	opts ...DynamoDBOption,
+) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+	return &dynamoDBBigSegmentStoreFactory{tableName: tableName, options: makeDynamoDBOptions(opts)}
+}
+
+// Build is called internally by the SDK.
+func (f *dynamoDBBigSegmentStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.BigSegmentStore, error) {
+	if f.tableName == "" {
+		return nil, errors.New("a DynamoDB table name is required")
+	}
+	client, err := f.options.newClient()
+	if err != nil {
+		return nil, err
+	}
+	return &dynamoDBBigSegmentStore{
+		client:           client,
+		tableName:        f.tableName,
+		prefix:           f.options.keyPrefix(),
+		operationTimeout: f.options.operationTimeout,
+	}, nil
+}
+
+func (store *dynamoDBBigSegmentStore) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.operationTimeout)
+	defer cancel()
+	result, err := store.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(store.tableName),
+		Key: map[string]types.AttributeValue{
+			partitionKeyAttr: &types.AttributeValueMemberS{Value: store.prefix + metadataPartitionKey},
+			sortKeyAttr:      &types.AttributeValueMemberS{Value: metadataSortKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return subsystems.BigSegmentStoreMetadata{}, err
+	}
+	value, ok := result.Item[lastUpToDateAttr].(*types.AttributeValueMemberN)
+	if !ok {
+		return subsystems.BigSegmentStoreMetadata{}, nil // the store has never been synchronized
+	}
+	millis, err := strconv.ParseUint(value.Value, 10, 64)
+	if err != nil {
+		return subsystems.BigSegmentStoreMetadata{}, err
+	}
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: ldtime.UnixMillisecondTime(millis)}, nil
+}
+
+func (store *dynamoDBBigSegmentStore) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.operationTimeout)
+	defer cancel()
+	var included, excluded []string
+	pages := dynamodb.NewQueryPaginator(store.client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.tableName),
+		KeyConditionExpression: aws.String("#hash = :hash"),
+		ProjectionExpression:   aws.String("#ref, #included"),
+		ExpressionAttributeNames: map[string]string{
+			"#hash":     partitionKeyAttr,
+			"#ref":      sortKeyAttr,
+			"#included": includedAttr,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hash": &types.AttributeValueMemberS{Value: store.prefix + contextHash},
+		},
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			ref, ok := item[sortKeyAttr].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			if flag, ok := item[includedAttr].(*types.AttributeValueMemberBOOL); ok && !flag.Value {
+				excluded = append(excluded, ref.Value)
+			} else {
+				included = append(included, ref.Value)
+			}
+		}
+	}
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(included, excluded), nil
+}
+
+// Close is called automatically when the client is closed.
+func (store *dynamoDBBigSegmentStore) Close() error {
+	return nil
+}
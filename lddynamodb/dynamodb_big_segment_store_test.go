@@ -0,0 +1,256 @@
+package lddynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTableName = "test-big-segments"
+
+func makeTestContext(loggers ldlog.Loggers) subsystems.ClientContext {
+	return subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: loggers}}
+}
+
+func testConfig() aws.Config {
+	return aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}
+}
+
+func withEndpoint(endpoint string) DynamoDBOption {
+	return WithClientOptions(func(o *dynamodb.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+	})
+}
+
+func newTestClient(endpoint string) *dynamodb.Client {
+	return dynamodb.NewFromConfig(testConfig(), func(o *dynamodb.Options) { o.BaseEndpoint = aws.String(endpoint) })
+}
+
+func buildStore(t *testing.T, endpoint string, opts ...DynamoDBOption) subsystems.BigSegmentStore {
+	store, err := BigSegmentStore(testTableName, append([]DynamoDBOption{WithConfig(testConfig()),
+		withEndpoint(endpoint)}, opts...)...).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func prefixedKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + ":" + key
+}
+
+func runBigSegmentStoreTestSuite(t *testing.T, endpoint string) {
+	client := newTestClient(endpoint)
+	require.NoError(t, createTestTable(client))
+
+	storetest.NewBigSegmentStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+			return BigSegmentStore(testTableName, WithPrefix(prefix), WithConfig(testConfig()), withEndpoint(endpoint))
+		},
+		func(prefix string) error {
+			return clearTestTable(client)
+		},
+		func(prefix string, metadata subsystems.BigSegmentStoreMetadata) error {
+			return putTestItem(client, map[string]types.AttributeValue{
+				partitionKeyAttr: &types.AttributeValueMemberS{Value: prefixedKey(prefix, metadataPartitionKey)},
+				sortKeyAttr:      &types.AttributeValueMemberS{Value: metadataSortKey},
+				lastUpToDateAttr: &types.AttributeValueMemberN{
+					Value: strconv.FormatUint(uint64(metadata.LastUpToDate), 10),
+				},
+			})
+		},
+		func(prefix string, contextHash string, included []string, excluded []string) error {
+			return putTestMemberships(client, prefixedKey(prefix, contextHash), included, excluded)
+		},
+	).Run(t)
+}
+
+func createTestTable(client *dynamodb.Client) error {
+	return createTable(client, testTableName, partitionKeyAttr, sortKeyAttr)
+}
+
+func createTable(client *dynamodb.Client, tableName, partitionKey, sortKey string) error {
+	_, err := client.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(partitionKey), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(sortKey), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(partitionKey), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(sortKey), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	var inUse *types.ResourceInUseException
+	if errors.As(err, &inUse) {
+		return nil // the table was created by an earlier test
+	}
+	return err
+}
+
+func clearTestTable(client *dynamodb.Client) error {
+	return clearTable(client, testTableName, partitionKeyAttr, sortKeyAttr)
+}
+
+func clearTable(client *dynamodb.Client, tableName, partitionKey, sortKey string) error {
+	pages := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	var keys []map[string]types.AttributeValue
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			keys = append(keys, map[string]types.AttributeValue{
+				partitionKey: item[partitionKey],
+				sortKey:      item[sortKey],
+			})
+		}
+	}
+	for _, key := range keys {
+		if _, err := client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+			TableName: aws.String(tableName),
+			Key:       key,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putTestItem(client *dynamodb.Client, item map[string]types.AttributeValue) error {
+	_, err := client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(testTableName),
+		Item:      item,
+	})
+	return err
+}
+
+func putTestMemberships(client *dynamodb.Client, partitionKey string, included, excluded []string) error {
+	// A segment that is in both lists is included, so the items for included segments are written last.
+	for _, refs := range []struct {
+		refs     []string
+		included bool
+	}{{excluded, false}, {included, true}} {
+		for _, ref := range refs.refs {
+			if err := putTestItem(client, map[string]types.AttributeValue{
+				partitionKeyAttr: &types.AttributeValueMemberS{Value: partitionKey},
+				sortKeyAttr:      &types.AttributeValueMemberS{Value: ref},
+				includedAttr:     &types.AttributeValueMemberBOOL{Value: refs.included},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestDynamoDBBigSegmentStoreWithFakeDynamoDB(t *testing.T) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	runBigSegmentStoreTestSuite(t, fake.server.URL)
+}
+
+// TestDynamoDBBigSegmentStoreWithDynamoDB runs the same tests against a real DynamoDB, if the
+// DYNAMODB_ENDPOINT environment variable is set to its URL; for instance, after
+// "docker run -p 8000:8000 amazon/dynamodb-local", set it to "http://localhost:8000".
+func TestDynamoDBBigSegmentStoreWithDynamoDB(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+	runBigSegmentStoreTestSuite(t, endpoint)
+}
+
+func TestGetMembershipQueriesAllPagesOfOneContext(t *testing.T) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	client := newTestClient(fake.server.URL)
+	require.NoError(t, createTestTable(client))
+	var included []string
+	for i := 0; i < 5; i++ {
+		included = append(included, fmt.Sprintf("included%d.g1", i))
+	}
+	require.NoError(t, putTestMemberships(client, "test:hash1", included, []string{"excluded.g1"}))
+	require.NoError(t, putTestMemberships(client, "test:hash2", nil, []string{"included0.g1"}))
+	store := buildStore(t, fake.server.URL, WithPrefix("test"))
+	opsBefore := len(fake.operations())
+
+	membership, err := store.GetMembership("hash1")
+	require.NoError(t, err)
+
+	for _, ref := range included {
+		assert.Equal(t, ldvalue.NewOptionalBool(true), membership.CheckMembership(ref))
+	}
+	assert.Equal(t, ldvalue.NewOptionalBool(false), membership.CheckMembership("excluded.g1"))
+	assert.Equal(t, ldvalue.OptionalBool{}, membership.CheckMembership("other.g1"))
+	assert.Equal(t, []string{"Query", "Query", "Query"}, fake.operations()[opsBefore:]) // 6 items, 2 per page
+}
+
+func TestGetMetadataWithInvalidTimestamp(t *testing.T) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	client := newTestClient(fake.server.URL)
+	require.NoError(t, createTestTable(client))
+	require.NoError(t, putTestItem(client, map[string]types.AttributeValue{
+		partitionKeyAttr: &types.AttributeValueMemberS{Value: metadataPartitionKey},
+		sortKeyAttr:      &types.AttributeValueMemberS{Value: metadataSortKey},
+		lastUpToDateAttr: &types.AttributeValueMemberN{Value: "1.5"},
+	}))
+	store := buildStore(t, fake.server.URL)
+
+	_, err := store.GetMetadata()
+	assert.Error(t, err)
+}
+
+func TestErrorsFromDynamoDB(t *testing.T) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	store := buildStore(t, fake.server.URL) // the table doesn't exist
+
+	_, err := store.GetMetadata()
+	assert.Error(t, err)
+	_, err = store.GetMembership("hash1")
+	assert.Error(t, err)
+}
+
+func TestBuildOptions(t *testing.T) {
+	t.Run("no table name", func(t *testing.T) {
+		_, err := BigSegmentStore("", WithConfig(testConfig())).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		assert.Error(t, err)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		store := buildStore(t, "http://localhost:1", WithPrefix("test"))
+		assert.Equal(t, "test:", store.(*dynamoDBBigSegmentStore).prefix)
+	})
+
+	t.Run("operation timeout", func(t *testing.T) {
+		store := buildStore(t, "http://localhost:1")
+		assert.Equal(t, DefaultOperationTimeout, store.(*dynamoDBBigSegmentStore).operationTimeout)
+		store = buildStore(t, "http://localhost:1", WithOperationTimeout(time.Second))
+		assert.Equal(t, time.Second, store.(*dynamoDBBigSegmentStore).operationTimeout)
+	})
+}
@@ -0,0 +1,542 @@
+package lddynamodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	tablePartitionKeyAttr = "namespace"
+	tableSortKeyAttr      = "key"
+	versionAttr           = "version"
+	itemJSONAttr          = "item"
+	deletedAttr           = "deleted"
+	chunkSetAttr          = "chunkSet"
+	chunkCountAttr        = "chunks"
+	chunkIndexAttr        = "chunkIndex"
+	chunkDataAttr         = "data"
+
+	initedKey = "$inited"
+
+	// DynamoDB does not allow an item to be larger than 400KB, including its attribute names and key. A
+	// serialized flag or segment larger than this is split into chunks of this size, to leave room for
+	// the rest of the item.
+	defaultMaxItemDataSize = 350 * 1024
+
+	// maxBatchWriteItems is the most requests that DynamoDB allows in one BatchWriteItem.
+	maxBatchWriteItems = 25
+
+	maxUnprocessedRetries = 5
+	maxChunkReadAttempts  = 3
+)
+
+// errIncompleteItem means that some of the chunks of an item were missing, or belonged to a different
+// version of the item.
+var errIncompleteItem = errors.New("DynamoDB item is incomplete")
+
+type dynamoDBDataStoreFactory struct {
+	tableName string
+	options   dynamoDBOptions
+}
+
+type dynamoDBDataStore struct {
+	client           *dynamodb.Client
+	tableName        string
+	prefix           string
+	operationTimeout time.Duration
+	maxItemDataSize  int
+	loggers          ldlog.Loggers
+	testUpsertHook   func()
+	inited           bool
+	lock             sync.Mutex
+}
+
+// DataStore returns a configuration for a persistent data store that keeps feature flags and segments in
+// the specified DynamoDB table. To use it, pass it to ldcomponents.PersistentDataStore and store the result
+// in the DataStore field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(lddynamodb.DataStore("my-flags-table")),
+//	}
+//
+// The table must have a string partition key named "namespace" and a string sort key named "key". Each
+// flag or segment is an item whose partition key is the kind of data, such as "features", and whose sort
+// key is the flag or segment key; its "item" attribute is the same JSON that other persistent data stores
+// use, and its numeric "version" attribute is the item's version. Updates are conditional on the version,
+// so that a newer version that another process has written is never replaced with an older one.
+//
+// DynamoDB does not allow an item to be larger than 400KB, which a segment with a long list of included
+// contexts can exceed. So if the JSON is larger than 350KB, it is split across items in a separate
+// partition, "features$chunks" or "segments$chunks", and the flag or segment's own item says how many
+// chunks there are instead of holding the JSON. The chunks are always written first, so that an item never
+// refers to chunks that are not there yet: if an update is interrupted, the previous version is still
+// complete and is what the SDK reads. The chunks of the previous version are deleted once the new version
+// has been written. If the SDK does find a chunk missing, which should only happen if another process
+// replaced the item while it was being read, it reads the item again, and if the chunks are still
+// incomplete it returns an error rather than a truncated item.
+func DataStore(
+	tableName string,
+	opts ...DynamoDBOption,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	return &dynamoDBDataStoreFactory{tableName: tableName, options: makeDynamoDBOptions(opts)}
+}
+
+// Build is called internally by the SDK.
+func (f *dynamoDBDataStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	if f.tableName == "" {
+		return nil, errors.New("a DynamoDB table name is required")
+	}
+	client, err := f.options.newClient()
+	if err != nil {
+		return nil, err
+	}
+	loggers := clientContext.GetLogging().Loggers
+	loggers.SetPrefix("DynamoDBDataStore:")
+	return &dynamoDBDataStore{
+		client:           client,
+		tableName:        f.tableName,
+		prefix:           f.options.keyPrefix(),
+		operationTimeout: f.options.operationTimeout,
+		maxItemDataSize:  f.options.maxItemDataSize,
+		loggers:          loggers,
+	}, nil
+}
+
+func (store *dynamoDBDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	// DynamoDB has no transaction large enough for the whole data set, so as PersistentDataStore.Init
+	// requires, the items are written first, then the old items are deleted, and the store is only marked
+	// as initialized at the end. Within each kind, the chunks of large items are written before any of the
+	// items that refer to them.
+	for _, coll := range allData {
+		oldItems, err := store.queryAll(store.itemNamespace(coll.Kind))
+		if err != nil {
+			return err
+		}
+		var chunkWrites, itemWrites, deletes []types.WriteRequest
+		newChunkSets := make(map[string]string, len(coll.Items))
+		for _, item := range coll.Items {
+			row, chunks, err := store.makeItemRows(coll.Kind, item.Key, item.Item)
+			if err != nil {
+				return err
+			}
+			for _, chunk := range chunks {
+				chunkWrites = append(chunkWrites, types.WriteRequest{PutRequest: &types.PutRequest{Item: chunk}})
+			}
+			itemWrites = append(itemWrites, types.WriteRequest{PutRequest: &types.PutRequest{Item: row}})
+			newChunkSets[item.Key] = stringAttr(row, chunkSetAttr)
+		}
+		for _, old := range oldItems {
+			key := stringAttr(old, tableSortKeyAttr)
+			newChunkSet, stillExists := newChunkSets[key]
+			if !stillExists {
+				deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{
+					Key: store.rowKey(store.itemNamespace(coll.Kind), key),
+				}})
+			}
+			if oldChunkSet := stringAttr(old, chunkSetAttr); oldChunkSet != "" && oldChunkSet != newChunkSet {
+				for _, chunkKey := range store.chunkKeys(coll.Kind, key, old) {
+					deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: chunkKey}})
+				}
+			}
+		}
+		for _, writes := range [][]types.WriteRequest{chunkWrites, itemWrites, deletes} {
+			if err := store.batchWrite(writes); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, cancel := store.requestContext()
+	defer cancel()
+	initedRow := store.rowKey(store.prefix+initedKey, initedKey)
+	if _, err := store.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.tableName),
+		Item:      initedRow,
+	}); err != nil {
+		return err
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *dynamoDBDataStore) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	for attempt := 1; ; attempt++ {
+		row, err := store.getRow(store.rowKey(store.itemNamespace(kind), key))
+		if err != nil || row == nil {
+			return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+		}
+		item, err := store.decodeItem(kind, key, row)
+		if errors.Is(err, errIncompleteItem) && attempt < maxChunkReadAttempts {
+			// Another process probably replaced the item, and deleted the old chunks, after we read it.
+			store.loggers.Debugf("Chunks of %q were missing; reading it again", key)
+			continue
+		}
+		return item, err
+	}
+}
+
+func (store *dynamoDBDataStore) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	rows, err := store.queryAll(store.itemNamespace(kind))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(rows))
+	for _, row := range rows {
+		key := stringAttr(row, tableSortKeyAttr)
+		item, err := store.decodeItem(kind, key, row)
+		if errors.Is(err, errIncompleteItem) {
+			item, err = store.Get(kind, key) // this reads the item again
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{Key: key, Item: item})
+	}
+	return results, nil
+}
+
+func (store *dynamoDBDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	row, chunks, err := store.makeItemRows(kind, key, newItem)
+	if err != nil {
+		return false, err
+	}
+	for _, chunk := range chunks {
+		if err := store.putRow(chunk); err != nil {
+			store.deleteChunks(kind, key, row)
+			return false, err
+		}
+	}
+
+	if store.testUpsertHook != nil { // instrumentation for unit tests
+		store.testUpsertHook()
+	}
+
+	ctx, cancel := store.requestContext()
+	defer cancel()
+	result, err := store.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(store.tableName),
+		Item:                row,
+		ConditionExpression: aws.String("attribute_not_exists(#namespace) OR #version < :version"),
+		ExpressionAttributeNames: map[string]string{
+			"#namespace": tablePartitionKeyAttr,
+			"#version":   versionAttr,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(newItem.Version)},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		// Whether or not the item was rejected because its version is not newer, nothing refers to the
+		// chunks that we wrote for it.
+		store.deleteChunks(kind, key, row)
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return false, nil
+		}
+		return false, err
+	}
+	// Now that nothing refers to the previous version's chunks, if it had any, they can be deleted.
+	store.deleteChunks(kind, key, result.Attributes)
+	return true, nil
+}
+
+func (store *dynamoDBDataStore) IsInitialized() bool {
+	store.lock.Lock()
+	inited := store.inited
+	store.lock.Unlock()
+	if inited {
+		return true
+	}
+	row, err := store.getRow(store.rowKey(store.prefix+initedKey, initedKey))
+	if err != nil || row == nil {
+		return false
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return true
+}
+
+func (store *dynamoDBDataStore) IsStoreAvailable() bool {
+	_, err := store.getRow(store.rowKey(store.prefix+initedKey, initedKey))
+	return err == nil
+}
+
+// Close is called automatically when the client is closed.
+func (store *dynamoDBDataStore) Close() error {
+	return nil
+}
+
+// makeItemRows returns the item to write for a flag or segment, and the chunks to write before it if the
+// serialized data is too large for one item.
+func (store *dynamoDBDataStore) makeItemRows(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.SerializedItemDescriptor,
+) (map[string]types.AttributeValue, []map[string]types.AttributeValue, error) {
+	version := &types.AttributeValueMemberN{Value: strconv.Itoa(item.Version)}
+	row := store.rowKey(store.itemNamespace(kind), key)
+	row[versionAttr] = version
+	if item.Deleted {
+		row[deletedAttr] = &types.AttributeValueMemberBOOL{Value: true}
+	}
+	data := item.SerializedItem
+	if len(data) <= store.maxItemDataSize {
+		row[itemJSONAttr] = &types.AttributeValueMemberS{Value: string(data)}
+		return row, nil, nil
+	}
+
+	// Each version's chunks have their own keys, so that writing them does not affect readers of the
+	// previous version, and a random ID keeps them apart from the chunks of a concurrent write of the same
+	// version by another process.
+	chunkSet, err := newChunkSetID()
+	if err != nil {
+		return nil, nil, err
+	}
+	count := (len(data) + store.maxItemDataSize - 1) / store.maxItemDataSize
+	row[chunkSetAttr] = &types.AttributeValueMemberS{Value: chunkSet}
+	row[chunkCountAttr] = &types.AttributeValueMemberN{Value: strconv.Itoa(count)}
+	chunks := make([]map[string]types.AttributeValue, 0, count)
+	for i := 0; i < count; i++ {
+		chunk := store.rowKey(store.chunkNamespace(kind), chunkSortKey(key, chunkSet, i))
+		chunk[versionAttr] = version
+		chunk[chunkIndexAttr] = &types.AttributeValueMemberN{Value: strconv.Itoa(i)}
+		chunk[chunkCountAttr] = &types.AttributeValueMemberN{Value: strconv.Itoa(count)}
+		chunk[chunkDataAttr] = &types.AttributeValueMemberB{
+			Value: data[i*store.maxItemDataSize : min((i+1)*store.maxItemDataSize, len(data))],
+		}
+		chunks = append(chunks, chunk)
+	}
+	return row, chunks, nil
+}
+
+// decodeItem returns the flag or segment that an item describes, reading its chunks if it has any.
+func (store *dynamoDBDataStore) decodeItem(
+	kind ldstoretypes.DataKind,
+	key string,
+	row map[string]types.AttributeValue,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	version, err := numberAttr(row, versionAttr)
+	if err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}, err
+	}
+	deleted, _ := row[deletedAttr].(*types.AttributeValueMemberBOOL)
+	result := ldstoretypes.SerializedItemDescriptor{Version: version, Deleted: deleted != nil && deleted.Value}
+	if value, ok := row[itemJSONAttr].(*types.AttributeValueMemberS); ok {
+		result.SerializedItem = []byte(value.Value)
+		return result, nil
+	}
+	if stringAttr(row, chunkSetAttr) == "" {
+		return result, nil
+	}
+
+	count, err := numberAttr(row, chunkCountAttr)
+	if err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}, err
+	}
+	var data []byte
+	for i, chunkKey := range store.chunkKeys(kind, key, row) {
+		chunk, err := store.getRow(chunkKey)
+		if err != nil {
+			return ldstoretypes.SerializedItemDescriptor{}, err
+		}
+		chunkVersion, _ := numberAttr(chunk, versionAttr)
+		chunkIndex, _ := numberAttr(chunk, chunkIndexAttr)
+		chunkCount, _ := numberAttr(chunk, chunkCountAttr)
+		chunkData, ok := chunk[chunkDataAttr].(*types.AttributeValueMemberB)
+		if chunk == nil || !ok || chunkVersion != version || chunkIndex != i || chunkCount != count {
+			return ldstoretypes.SerializedItemDescriptor{}, fmt.Errorf("%w: chunk %d of %d of %q version %d",
+				errIncompleteItem, i+1, count, key, version)
+		}
+		data = append(data, chunkData.Value...)
+	}
+	result.SerializedItem = data
+	return result, nil
+}
+
+// chunkKeys returns the keys of the chunks that an item refers to, if any.
+func (store *dynamoDBDataStore) chunkKeys(
+	kind ldstoretypes.DataKind,
+	key string,
+	row map[string]types.AttributeValue,
+) []map[string]types.AttributeValue {
+	chunkSet := stringAttr(row, chunkSetAttr)
+	count, _ := numberAttr(row, chunkCountAttr)
+	if chunkSet == "" || count <= 0 {
+		return nil
+	}
+	keys := make([]map[string]types.AttributeValue, 0, count)
+	for i := 0; i < count; i++ {
+		keys = append(keys, store.rowKey(store.chunkNamespace(kind), chunkSortKey(key, chunkSet, i)))
+	}
+	return keys
+}
+
+// deleteChunks deletes the chunks that an item refers to, if any. A failure is only logged, since the
+// chunks take up space but do no other harm.
+func (store *dynamoDBDataStore) deleteChunks(
+	kind ldstoretypes.DataKind,
+	key string,
+	row map[string]types.AttributeValue,
+) {
+	for _, chunkKey := range store.chunkKeys(kind, key, row) {
+		ctx, cancel := store.requestContext()
+		_, err := store.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(store.tableName),
+			Key:       chunkKey,
+		})
+		cancel()
+		if err != nil {
+			store.loggers.Warnf("Unable to delete unused chunks of %q: %s", key, err)
+			return
+		}
+	}
+}
+
+func (store *dynamoDBDataStore) getRow(key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	ctx, cancel := store.requestContext()
+	defer cancel()
+	result, err := store.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.tableName),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Item, nil
+}
+
+func (store *dynamoDBDataStore) putRow(row map[string]types.AttributeValue) error {
+	ctx, cancel := store.requestContext()
+	defer cancel()
+	_, err := store.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(store.tableName), Item: row})
+	return err
+}
+
+func (store *dynamoDBDataStore) queryAll(namespace string) ([]map[string]types.AttributeValue, error) {
+	var rows []map[string]types.AttributeValue
+	pages := dynamodb.NewQueryPaginator(store.client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.tableName),
+		KeyConditionExpression: aws.String("#namespace = :namespace"),
+		ExpressionAttributeNames: map[string]string{
+			"#namespace": tablePartitionKeyAttr,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":namespace": &types.AttributeValueMemberS{Value: namespace},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	for pages.HasMorePages() {
+		ctx, cancel := store.requestContext()
+		page, err := pages.NextPage(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, page.Items...)
+	}
+	return rows, nil
+}
+
+// batchWrite does the writes in order, as many at a time as DynamoDB allows.
+func (store *dynamoDBDataStore) batchWrite(writes []types.WriteRequest) error {
+	for len(writes) > 0 {
+		batch := writes[:min(len(writes), maxBatchWriteItems)]
+		writes = writes[len(batch):]
+		for attempt := 0; len(batch) > 0; attempt++ {
+			if attempt > maxUnprocessedRetries {
+				return fmt.Errorf("DynamoDB did not process %d writes after %d attempts", len(batch), attempt)
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt) * 50 * time.Millisecond)
+			}
+			ctx, cancel := store.requestContext()
+			result, err := store.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{store.tableName: batch},
+			})
+			cancel()
+			if err != nil {
+				return err
+			}
+			batch = result.UnprocessedItems[store.tableName] // DynamoDB was too busy to do these
+		}
+	}
+	return nil
+}
+
+func (store *dynamoDBDataStore) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.operationTimeout)
+}
+
+func (store *dynamoDBDataStore) itemNamespace(kind ldstoretypes.DataKind) string {
+	return store.prefix + kind.GetName()
+}
+
+func (store *dynamoDBDataStore) chunkNamespace(kind ldstoretypes.DataKind) string {
+	return store.prefix + kind.GetName() + "$chunks"
+}
+
+func (store *dynamoDBDataStore) rowKey(namespace, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		tablePartitionKeyAttr: &types.AttributeValueMemberS{Value: namespace},
+		tableSortKeyAttr:      &types.AttributeValueMemberS{Value: key},
+	}
+}
+
+// chunkSortKey returns the sort key of a chunk. The chunk set ID and index cannot contain ":", so the
+// sort keys of different items' chunks are always different, even if the item keys contain ":".
+func chunkSortKey(key, chunkSet string, index int) string {
+	return key + ":" + chunkSet + ":" + strconv.Itoa(index)
+}
+
+func newChunkSetID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func stringAttr(row map[string]types.AttributeValue, name string) string {
+	if value, ok := row[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
+
+func numberAttr(row map[string]types.AttributeValue, name string) (int, error) {
+	value, ok := row[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("DynamoDB item has no numeric %q attribute", name)
+	}
+	return strconv.Atoi(value.Value)
+}
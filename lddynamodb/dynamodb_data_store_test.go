@@ -0,0 +1,250 @@
+package lddynamodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDataStoreTableName = "test-data-store"
+
+func createTestDataStoreTable(client *dynamodb.Client) error {
+	return createTable(client, testDataStoreTableName, tablePartitionKeyAttr, tableSortKeyAttr)
+}
+
+func buildDataStore(t *testing.T, endpoint string, opts ...DynamoDBOption) *dynamoDBDataStore {
+	store, err := DataStore(testDataStoreTableName, append([]DynamoDBOption{WithConfig(testConfig()),
+		withEndpoint(endpoint)}, opts...)...).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store.(*dynamoDBDataStore)
+}
+
+func runDataStoreTestSuite(t *testing.T, endpoint string) {
+	client := newTestClient(endpoint)
+	require.NoError(t, createTestDataStoreTable(client))
+
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return DataStore(testDataStoreTableName, WithPrefix(prefix), WithConfig(testConfig()), withEndpoint(endpoint))
+		},
+		func(prefix string) error {
+			return clearTable(client, testDataStoreTableName, tablePartitionKeyAttr, tableSortKeyAttr)
+		},
+	).ErrorStoreFactory(
+		DataStore("nonexistent-table", WithConfig(testConfig()), withEndpoint(endpoint)),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*dynamoDBDataStore).testUpsertHook = hook
+		},
+	).Run(t)
+}
+
+func TestDynamoDBDataStoreWithFakeDynamoDB(t *testing.T) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	runDataStoreTestSuite(t, fake.server.URL)
+}
+
+// TestDynamoDBDataStoreWithDynamoDB runs the same tests against a real DynamoDB, if the DYNAMODB_ENDPOINT
+// environment variable is set; see TestDynamoDBBigSegmentStoreWithDynamoDB.
+func TestDynamoDBDataStoreWithDynamoDB(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+	runDataStoreTestSuite(t, endpoint)
+}
+
+// makeLargeSegment returns the JSON of a segment whose included list makes it about the given size.
+func makeLargeSegment(version int, size int) ldstoretypes.SerializedItemDescriptor {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"key":"big","version":%d,"included":[`, version)
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `"context-key-%08d"`, i)
+	}
+	buf.WriteString("]}")
+	return ldstoretypes.SerializedItemDescriptor{Version: version, SerializedItem: buf.Bytes()}
+}
+
+func smallSegment(version int) ldstoretypes.SerializedItemDescriptor {
+	return ldstoretypes.SerializedItemDescriptor{
+		Version:        version,
+		SerializedItem: []byte(fmt.Sprintf(`{"key":"big","version":%d}`, version)),
+	}
+}
+
+func countChunks(t *testing.T, fake *fakeDynamoDB) int {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	return len(fake.tables[testDataStoreTableName].items[ldstoreimpl.Segments().GetName()+"$chunks"])
+}
+
+func withLargeItemTestStore(t *testing.T, action func(fake *fakeDynamoDB, store *dynamoDBDataStore)) {
+	fake := newFakeDynamoDB()
+	defer fake.close()
+	require.NoError(t, createTestDataStoreTable(newTestClient(fake.server.URL)))
+	action(fake, buildDataStore(t, fake.server.URL))
+}
+
+func TestLargeItems(t *testing.T) {
+	segments := ldstoreimpl.Segments()
+	large := makeLargeSegment(1, 1024*1024)
+
+	t.Run("are rejected by DynamoDB without chunks", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			store.maxItemDataSize = len(large.SerializedItem)
+
+			_, err := store.Upsert(segments, "big", large)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("upsert and get", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			updated, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+			assert.True(t, updated)
+			assert.Equal(t, 3, countChunks(t, fake))
+
+			item, err := store.Get(segments, "big")
+			require.NoError(t, err)
+			assert.Equal(t, large, item)
+
+			all, err := store.GetAll(segments)
+			require.NoError(t, err)
+			assert.Equal(t, []ldstoretypes.KeyedSerializedItemDescriptor{{Key: "big", Item: large}}, all)
+		})
+	})
+
+	t.Run("init and get", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+				{Kind: segments, Items: []ldstoretypes.KeyedSerializedItemDescriptor{{Key: "big", Item: large}}},
+			}))
+			assert.True(t, store.IsInitialized())
+
+			item, err := store.Get(segments, "big")
+			require.NoError(t, err)
+			assert.Equal(t, large, item)
+		})
+	})
+
+	t.Run("chunks of a previous version are deleted by upsert", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			_, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+			large2 := makeLargeSegment(2, 800*1024)
+			_, err = store.Upsert(segments, "big", large2)
+			require.NoError(t, err)
+			assert.Equal(t, 3, countChunks(t, fake))
+
+			_, err = store.Upsert(segments, "big", smallSegment(3))
+			require.NoError(t, err)
+			assert.Equal(t, 0, countChunks(t, fake))
+			item, err := store.Get(segments, "big")
+			require.NoError(t, err)
+			assert.Equal(t, smallSegment(3), item)
+		})
+	})
+
+	t.Run("chunks of a previous version are deleted by init", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			_, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+
+			require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{{Kind: segments}}))
+			assert.Equal(t, 0, countChunks(t, fake))
+		})
+	})
+
+	t.Run("chunks of a rejected older version are deleted", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			_, err := store.Upsert(segments, "big", smallSegment(2))
+			require.NoError(t, err)
+
+			updated, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+			assert.False(t, updated)
+			assert.Equal(t, 0, countChunks(t, fake))
+		})
+	})
+
+	t.Run("interrupted update leaves the previous version readable", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			_, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+			fake.setFailIf(func(op string, req *fakeRequest) bool {
+				return op == "PutItem" && req.ConditionExpression != "" // the chunks are written, the item isn't
+			})
+
+			_, err = store.Upsert(segments, "big", makeLargeSegment(2, 1024*1024))
+			assert.Error(t, err)
+			fake.setFailIf(nil)
+
+			item, err := store.Get(segments, "big")
+			require.NoError(t, err)
+			assert.Equal(t, large, item)
+		})
+	})
+
+	t.Run("missing chunk is an error", func(t *testing.T) {
+		withLargeItemTestStore(t, func(fake *fakeDynamoDB, store *dynamoDBDataStore) {
+			_, err := store.Upsert(segments, "big", large)
+			require.NoError(t, err)
+			row, err := store.getRow(store.rowKey(segments.GetName(), "big"))
+			require.NoError(t, err)
+			_, err = store.client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+				TableName: aws.String(testDataStoreTableName),
+				Key:       store.chunkKeys(segments, "big", row)[1],
+			})
+			require.NoError(t, err)
+
+			_, err = store.Get(segments, "big")
+			assert.ErrorIs(t, err, errIncompleteItem)
+			_, err = store.GetAll(segments)
+			assert.ErrorIs(t, err, errIncompleteItem)
+		})
+	})
+}
+
+func TestDataStoreBuildOptions(t *testing.T) {
+	t.Run("no table name", func(t *testing.T) {
+		_, err := DataStore("", WithConfig(testConfig())).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		assert.Error(t, err)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		store := buildDataStore(t, "http://localhost:1", WithPrefix("test"))
+		assert.Equal(t, "test:", store.prefix)
+		assert.Equal(t, "test:features", store.itemNamespace(ldstoreimpl.Features()))
+	})
+
+	t.Run("item data size", func(t *testing.T) {
+		store := buildDataStore(t, "http://localhost:1")
+		assert.Equal(t, defaultMaxItemDataSize, store.maxItemDataSize)
+		_, chunks, err := store.makeItemRows(ldstoreimpl.Segments(), "big", makeLargeSegment(1, 1024*1024))
+		require.NoError(t, err)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, len(chunk[chunkDataAttr].(*types.AttributeValueMemberB).Value), defaultMaxItemDataSize)
+		}
+	})
+}
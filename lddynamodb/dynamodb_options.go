@@ -0,0 +1,87 @@
+package lddynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DefaultOperationTimeout is how long a store waits for each request to DynamoDB, if no other timeout is
+// specified with WithOperationTimeout.
+const DefaultOperationTimeout = 10 * time.Second
+
+// DynamoDBOption is an optional parameter for [DataStore] and [BigSegmentStore].
+type DynamoDBOption func(*dynamoDBOptions)
+
+// WithPrefix sets a string that is prepended, followed by ":", to the partition key of every item that
+// the store reads or writes, so that several SDK environments can share one table. By default there is no
+// prefix.
+func WithPrefix(prefix string) DynamoDBOption {
+	return func(o *dynamoDBOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithConfig specifies the AWS configuration, such as the region and credentials, to use instead of
+// the one that config.LoadDefaultConfig finds in the environment.
+func WithConfig(cfg aws.Config) DynamoDBOption {
+	return func(o *dynamoDBOptions) {
+		o.config = &cfg
+	}
+}
+
+// WithClientOptions adds options for creating the DynamoDB client, for instance to set BaseEndpoint to
+// the address of a local DynamoDB instance.
+func WithClientOptions(opts ...func(*dynamodb.Options)) DynamoDBOption {
+	return func(o *dynamoDBOptions) {
+		o.clientOptions = append(o.clientOptions, opts...)
+	}
+}
+
+// WithOperationTimeout sets how long the store waits for each request to DynamoDB. The default is
+// [DefaultOperationTimeout].
+func WithOperationTimeout(timeout time.Duration) DynamoDBOption {
+	return func(o *dynamoDBOptions) {
+		o.operationTimeout = timeout
+	}
+}
+
+type dynamoDBOptions struct {
+	prefix           string
+	config           *aws.Config
+	clientOptions    []func(*dynamodb.Options)
+	operationTimeout time.Duration
+	maxItemDataSize  int // only changed by tests
+}
+
+func makeDynamoDBOptions(opts []DynamoDBOption) dynamoDBOptions {
+	o := dynamoDBOptions{operationTimeout: DefaultOperationTimeout, maxItemDataSize: defaultMaxItemDataSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o dynamoDBOptions) newClient() (*dynamodb.Client, error) {
+	var cfg aws.Config
+	if o.config != nil {
+		cfg = *o.config
+	} else {
+		var err error
+		if cfg, err = config.LoadDefaultConfig(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return dynamodb.NewFromConfig(cfg, o.clientOptions...), nil
+}
+
+// keyPrefix returns the prefix, if any, followed by ":".
+func (o dynamoDBOptions) keyPrefix() string {
+	if o.prefix == "" {
+		return ""
+	}
+	return o.prefix + ":"
+}
@@ -0,0 +1,299 @@
+package lddynamodb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeMaxItemSize is the largest item that DynamoDB accepts.
+const fakeMaxItemSize = 400 * 1024
+
+// fakeVersionCondition is the only condition expression that the fake understands, which is the one that
+// the data store uses for updates.
+const fakeVersionCondition = "attribute_not_exists(#namespace) OR #version < :version"
+
+type fakeAttributes map[string]map[string]interface{}
+
+// fakeDynamoDB is a minimal in-memory implementation of the DynamoDB HTTP API: just the parts that the
+// stores and the tests use, which are CreateTable, GetItem, PutItem, DeleteItem, BatchWriteItem, Scan, and
+// Query with a condition on the partition key. Items are returned in pages of at most pageSize, so that
+// the tests exercise paging, and like DynamoDB it rejects items larger than 400KB.
+type fakeDynamoDB struct {
+	server   *httptest.Server
+	tables   map[string]*fakeTable
+	pageSize int
+	ops      []string
+	failIf   func(op string, req *fakeRequest) bool // if it returns true, the request fails
+	lock     sync.Mutex
+}
+
+type fakeTable struct {
+	partitionKey, sortKey string
+	items                 map[string]map[string]fakeAttributes // partition key -> sort key -> item
+}
+
+type fakeRequest struct {
+	TableName                 string
+	KeySchema                 []struct{ AttributeName, KeyType string }
+	Key                       fakeAttributes
+	Item                      fakeAttributes
+	ConditionExpression       string
+	ExpressionAttributeValues fakeAttributes
+	ExclusiveStartKey         fakeAttributes
+	ReturnValues              string
+	RequestItems              map[string][]struct {
+		PutRequest    *struct{ Item fakeAttributes }
+		DeleteRequest *struct{ Key fakeAttributes }
+	}
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	f := &fakeDynamoDB{tables: make(map[string]*fakeTable), pageSize: 2}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *fakeDynamoDB) close() {
+	f.server.Close()
+}
+
+func (f *fakeDynamoDB) operations() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string(nil), f.ops...)
+}
+
+func (f *fakeDynamoDB) setFailIf(failIf func(op string, req *fakeRequest) bool) {
+	f.lock.Lock()
+	f.failIf = failIf
+	f.lock.Unlock()
+}
+
+func (f *fakeDynamoDB) handle(w http.ResponseWriter, r *http.Request) {
+	op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+	var req fakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFakeError(w, "SerializationException", err.Error())
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.ops = append(f.ops, op)
+	if f.failIf != nil && f.failIf(op, &req) {
+		writeFakeError(w, "ValidationException", "injected failure")
+		return
+	}
+	if op == "CreateTable" {
+		if _, ok := f.tables[req.TableName]; ok {
+			writeFakeError(w, "ResourceInUseException", "table already exists")
+			return
+		}
+		table := &fakeTable{items: make(map[string]map[string]fakeAttributes)}
+		for _, k := range req.KeySchema {
+			if k.KeyType == "HASH" {
+				table.partitionKey = k.AttributeName
+			} else {
+				table.sortKey = k.AttributeName
+			}
+		}
+		f.tables[req.TableName] = table
+		writeFakeResponse(w, map[string]interface{}{})
+		return
+	}
+	if op == "BatchWriteItem" {
+		f.batchWrite(w, &req)
+		return
+	}
+	table, ok := f.tables[req.TableName]
+	if !ok {
+		writeFakeError(w, "ResourceNotFoundException", "requested resource not found")
+		return
+	}
+
+	switch op {
+	case "GetItem":
+		item, ok := table.get(req.Key)
+		if !ok {
+			writeFakeResponse(w, map[string]interface{}{})
+			return
+		}
+		writeFakeResponse(w, map[string]interface{}{"Item": item})
+	case "PutItem":
+		if fakeItemSize(req.Item) > fakeMaxItemSize {
+			writeFakeError(w, "ValidationException", "Item size has exceeded the maximum allowed size")
+			return
+		}
+		old, exists := table.get(req.Item)
+		switch req.ConditionExpression {
+		case "":
+		case fakeVersionCondition:
+			if exists && fakeNumberAttr(old, versionAttr) >= fakeNumberAttr(req.ExpressionAttributeValues, ":version") {
+				writeFakeError(w, "ConditionalCheckFailedException", "The conditional request failed")
+				return
+			}
+		default:
+			writeFakeError(w, "ValidationException", "unsupported condition: "+req.ConditionExpression)
+			return
+		}
+		table.put(req.Item)
+		response := map[string]interface{}{}
+		if exists && req.ReturnValues == "ALL_OLD" {
+			response["Attributes"] = old
+		}
+		writeFakeResponse(w, response)
+	case "DeleteItem":
+		table.delete(req.Key)
+		writeFakeResponse(w, map[string]interface{}{})
+	case "Scan":
+		var items []fakeAttributes
+		for _, partition := range table.items {
+			for _, item := range partition {
+				items = append(items, item)
+			}
+		}
+		f.writePage(w, table, items, req.ExclusiveStartKey)
+	case "Query":
+		if len(req.ExpressionAttributeValues) != 1 {
+			writeFakeError(w, "ValidationException", "expected a condition on the partition key only")
+			return
+		}
+		var partition map[string]fakeAttributes
+		for name := range req.ExpressionAttributeValues {
+			partition = table.items[fakeStringAttr(req.ExpressionAttributeValues, name)]
+		}
+		items := make([]fakeAttributes, 0, len(partition))
+		for _, item := range partition {
+			items = append(items, item)
+		}
+		f.writePage(w, table, items, req.ExclusiveStartKey)
+	default:
+		writeFakeError(w, "UnknownOperationException", op)
+	}
+}
+
+func (f *fakeDynamoDB) batchWrite(w http.ResponseWriter, req *fakeRequest) {
+	for tableName, writes := range req.RequestItems {
+		table, ok := f.tables[tableName]
+		if !ok {
+			writeFakeError(w, "ResourceNotFoundException", "requested resource not found")
+			return
+		}
+		if len(writes) > maxBatchWriteItems {
+			writeFakeError(w, "ValidationException", "too many items in BatchWriteItem")
+			return
+		}
+		for _, write := range writes {
+			if write.PutRequest != nil && fakeItemSize(write.PutRequest.Item) > fakeMaxItemSize {
+				writeFakeError(w, "ValidationException", "Item size has exceeded the maximum allowed size")
+				return
+			}
+		}
+		for _, write := range writes {
+			if write.PutRequest != nil {
+				table.put(write.PutRequest.Item)
+			} else if write.DeleteRequest != nil {
+				table.delete(write.DeleteRequest.Key)
+			}
+		}
+	}
+	writeFakeResponse(w, map[string]interface{}{})
+}
+
+func (f *fakeDynamoDB) writePage(w http.ResponseWriter, table *fakeTable, items []fakeAttributes,
+	startKey fakeAttributes) {
+	sort.Slice(items, func(i, j int) bool { return table.compareKeys(items[i], items[j]) < 0 })
+	if startKey != nil {
+		i := sort.Search(len(items), func(i int) bool { return table.compareKeys(items[i], startKey) > 0 })
+		items = items[i:]
+	}
+	response := map[string]interface{}{}
+	if len(items) > f.pageSize {
+		items = items[:f.pageSize]
+		last := items[len(items)-1]
+		response["LastEvaluatedKey"] = fakeAttributes{
+			table.partitionKey: last[table.partitionKey],
+			table.sortKey:      last[table.sortKey],
+		}
+	}
+	response["Items"] = items
+	response["Count"] = len(items)
+	writeFakeResponse(w, response)
+}
+
+func (t *fakeTable) get(key fakeAttributes) (fakeAttributes, bool) {
+	item, ok := t.items[fakeStringAttr(key, t.partitionKey)][fakeStringAttr(key, t.sortKey)]
+	return item, ok
+}
+
+func (t *fakeTable) put(item fakeAttributes) {
+	hash := fakeStringAttr(item, t.partitionKey)
+	if t.items[hash] == nil {
+		t.items[hash] = make(map[string]fakeAttributes)
+	}
+	t.items[hash][fakeStringAttr(item, t.sortKey)] = item
+}
+
+func (t *fakeTable) delete(key fakeAttributes) {
+	delete(t.items[fakeStringAttr(key, t.partitionKey)], fakeStringAttr(key, t.sortKey))
+}
+
+func (t *fakeTable) compareKeys(a, b fakeAttributes) int {
+	if c := strings.Compare(fakeStringAttr(a, t.partitionKey), fakeStringAttr(b, t.partitionKey)); c != 0 {
+		return c
+	}
+	return strings.Compare(fakeStringAttr(a, t.sortKey), fakeStringAttr(b, t.sortKey))
+}
+
+// fakeItemSize approximates how DynamoDB measures an item: the lengths of the attribute names plus the
+// lengths of the values, where a binary value counts its decoded length.
+func fakeItemSize(item fakeAttributes) int {
+	size := 0
+	for name, value := range item {
+		size += len(name)
+		for valueType, v := range value {
+			s, _ := v.(string)
+			switch valueType {
+			case "S", "N":
+				size += len(s)
+			case "B":
+				decoded, _ := base64.StdEncoding.DecodeString(s)
+				size += len(decoded)
+			default:
+				size++
+			}
+		}
+	}
+	return size
+}
+
+func fakeStringAttr(attrs fakeAttributes, name string) string {
+	s, _ := attrs[name]["S"].(string)
+	return s
+}
+
+func fakeNumberAttr(attrs fakeAttributes, name string) int {
+	s, _ := attrs[name]["N"].(string)
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func writeFakeResponse(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeFakeError(w http.ResponseWriter, errorType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"__type":  "com.amazonaws.dynamodb.v20120810#" + errorType,
+		"message": message,
+	})
+}
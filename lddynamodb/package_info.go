@@ -0,0 +1,6 @@
+// Package lddynamodb provides a persistent data store that keeps feature flags and segments in DynamoDB,
+// and a Big Segment store that reads Big Segment memberships from DynamoDB.
+//
+// See [DataStore] and [BigSegmentStore] for how to use them. This is a separate Go module, so that
+// applications that do not use it do not get the AWS SDK as a dependency of the SDK.
+package lddynamodb
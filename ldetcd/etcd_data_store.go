@@ -0,0 +1,339 @@
+package ldetcd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	// DefaultPrefix is the string that is used as the first part of all etcd keys used by the data store,
+	// if no other prefix is specified with WithPrefix.
+	DefaultPrefix = "launchdarkly"
+
+	// DefaultOperationTimeout is how long each etcd request may take, if no other timeout is specified
+	// with WithOperationTimeout.
+	DefaultOperationTimeout = 10 * time.Second
+
+	initedKey = "$inited"
+
+	// maxTxnOps is the default limit on operations in one etcd transaction (the --max-txn-ops setting).
+	maxTxnOps = 128
+)
+
+// EtcdOption is an optional parameter for [NewEtcdDataStoreFactory].
+type EtcdOption func(*etcdDataStoreFactory)
+
+// WithPrefix sets the string that is used as the first part of all etcd keys used by the data store, so
+// that several SDK environments can share one etcd cluster. The default is [DefaultPrefix].
+func WithPrefix(prefix string) EtcdOption {
+	return func(f *etcdDataStoreFactory) {
+		f.prefix = prefix
+	}
+}
+
+// WithTLS sets the TLS configuration for connecting to etcd, such as the client certificate and the CA
+// certificates to trust. If it is not set, the connection does not use TLS unless WithConfig says so.
+func WithTLS(tlsConfig *tls.Config) EtcdOption {
+	return func(f *etcdDataStoreFactory) {
+		f.tlsConfig = tlsConfig
+	}
+}
+
+// WithConfig sets the configuration of the etcd client, for settings such as the user name and password
+// or the dial timeout. If the endpoints passed to NewEtcdDataStoreFactory are not empty, they replace the
+// Endpoints in this configuration, and WithTLS replaces its TLS configuration.
+func WithConfig(config clientv3.Config) EtcdOption {
+	return func(f *etcdDataStoreFactory) {
+		f.config = config
+	}
+}
+
+// WithOperationTimeout sets how long each etcd request may take before the data store returns an error.
+// The default is [DefaultOperationTimeout]. The etcd client waits for a connection rather than failing
+// right away when the cluster can't be reached, so this is what limits how long a flag evaluation can be
+// delayed by an etcd outage.
+func WithOperationTimeout(timeout time.Duration) EtcdOption {
+	return func(f *etcdDataStoreFactory) {
+		f.operationTimeout = timeout
+	}
+}
+
+type etcdDataStoreFactory struct {
+	endpoints        []string
+	prefix           string
+	tlsConfig        *tls.Config
+	config           clientv3.Config
+	operationTimeout time.Duration
+}
+
+type etcdDataStore struct {
+	client           *clientv3.Client
+	prefix           string
+	operationTimeout time.Duration
+	loggers          ldlog.Loggers
+	testTxHook       func()
+	inited           bool
+	lock             sync.Mutex
+}
+
+// NewEtcdDataStoreFactory returns a configuration for a persistent data store that uses the etcd cluster
+// at the specified endpoints, such as "localhost:2379". If there are no endpoints, it uses the ones from
+// WithConfig. To use it, pass it to ldcomponents.PersistentDataStore and store the result in the DataStore
+// field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldetcd.NewEtcdDataStoreFactory([]string{"localhost:2379"}),
+//	    ),
+//	}
+//
+// Each flag or segment is an etcd key, such as "/launchdarkly/features/my-flag", whose value is the same
+// JSON that other persistent data stores use. Updates use an etcd transaction that compares the key's
+// revision with the one that was read, so that a newer version of an item that another process has
+// written at the same time is never replaced with an older one. The SDK replaces the whole data set with
+// transactions of at most 128 operations, since that is etcd's default limit; so if the data set is larger
+// than that, other processes may briefly see a mix of the old and new data.
+func NewEtcdDataStoreFactory(
+	endpoints []string,
+	opts ...EtcdOption,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	f := &etcdDataStoreFactory{
+		endpoints:        endpoints,
+		prefix:           DefaultPrefix,
+		operationTimeout: DefaultOperationTimeout,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *etcdDataStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	config := f.config
+	if len(f.endpoints) != 0 {
+		config.Endpoints = f.endpoints
+	}
+	if f.tlsConfig != nil {
+		config.TLS = f.tlsConfig
+	}
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("no etcd endpoints were specified")
+	}
+	client, err := clientv3.New(config)
+	if err != nil {
+		return nil, err
+	}
+	prefix := f.prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	timeout := f.operationTimeout
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+	loggers := clientContext.GetLogging().Loggers
+	loggers.SetPrefix("EtcdDataStore:")
+	return &etcdDataStore{
+		client:           client,
+		prefix:           prefix,
+		operationTimeout: timeout,
+		loggers:          loggers,
+	}, nil
+}
+
+func (store *etcdDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	resp, err := store.client.Get(ctx, store.allPrefix(), clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return err
+	}
+	var ops []clientv3.Op
+	newKeys := make(map[string]bool)
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			key := store.itemKey(coll.Kind, item.Key)
+			newKeys[key] = true
+			ops = append(ops, clientv3.OpPut(key, string(item.Item.SerializedItem)))
+		}
+	}
+	for _, kv := range resp.Kvs {
+		if key := string(kv.Key); !newKeys[key] && key != store.initedKey() {
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+	}
+	ops = append(ops, clientv3.OpPut(store.initedKey(), ""))
+
+	// As PersistentDataStore.Init requires when the update can't be atomic, the items are written in
+	// order, followed by the deletions, and the store is only marked as initialized at the end.
+	for len(ops) > 0 {
+		batch := ops
+		if len(batch) > maxTxnOps {
+			batch = batch[:maxTxnOps]
+		}
+		ops = ops[len(batch):]
+		ctx, cancel := store.operationContext()
+		_, err := store.client.Txn(ctx).Then(batch...).Commit()
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *etcdDataStore) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	resp, err := store.client.Get(ctx, store.itemKey(kind, key))
+	if err != nil || len(resp.Kvs) == 0 {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	// The version is in the serialized item, which the SDK will deserialize anyway.
+	return ldstoretypes.SerializedItemDescriptor{SerializedItem: resp.Kvs[0].Value}, nil
+}
+
+func (store *etcdDataStore) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	kindPrefix := store.kindPrefix(kind)
+	resp, err := store.client.Get(ctx, kindPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  strings.TrimPrefix(string(kv.Key), kindPrefix),
+			Item: ldstoretypes.SerializedItemDescriptor{SerializedItem: kv.Value},
+		})
+	}
+	return results, nil
+}
+
+func (store *etcdDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	itemKey := store.itemKey(kind, key)
+	for {
+		updated, done, err := store.tryUpsert(kind, itemKey, newItem)
+		if err != nil || done {
+			return updated, err
+		}
+		// Another process changed the item after we read it, so we'll read it again and see whether
+		// our version is still newer.
+		store.loggers.Debug("Concurrent modification detected, retrying")
+	}
+}
+
+// tryUpsert does one read and compare-and-swap for Upsert. It returns done=false if the item changed
+// between the two.
+func (store *etcdDataStore) tryUpsert(
+	kind ldstoretypes.DataKind,
+	itemKey string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (updated bool, done bool, err error) {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	resp, err := store.client.Get(ctx, itemKey)
+	if err != nil {
+		return false, true, err
+	}
+	// A ModRevision of zero in the comparison means that the key does not exist.
+	var modRevision int64
+	if len(resp.Kvs) != 0 {
+		oldItem, err := kind.Deserialize(resp.Kvs[0].Value)
+		if err == nil && oldItem.Version >= newItem.Version {
+			return false, true, nil
+		}
+		// If the old item can't be parsed, it is replaced, since it is no use to anyone.
+		modRevision = resp.Kvs[0].ModRevision
+	}
+
+	if store.testTxHook != nil { // instrumentation for unit tests
+		store.testTxHook()
+	}
+
+	txnResp, err := store.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(itemKey), "=", modRevision)).
+		Then(clientv3.OpPut(itemKey, string(newItem.SerializedItem))).
+		Commit()
+	if err != nil {
+		return false, true, err
+	}
+	return txnResp.Succeeded, txnResp.Succeeded, nil
+}
+
+func (store *etcdDataStore) IsInitialized() bool {
+	store.lock.Lock()
+	inited := store.inited
+	store.lock.Unlock()
+	if inited {
+		return true
+	}
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	resp, err := store.client.Get(ctx, store.initedKey(), clientv3.WithCountOnly())
+	if err != nil || resp.Count == 0 {
+		return false
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return true
+}
+
+func (store *etcdDataStore) IsStoreAvailable() bool {
+	ctx, cancel := store.operationContext()
+	defer cancel()
+	_, err := store.client.Get(ctx, store.initedKey(), clientv3.WithCountOnly())
+	return err == nil
+}
+
+// Close is called automatically when the client is closed.
+func (store *etcdDataStore) Close() error {
+	return store.client.Close()
+}
+
+func (store *etcdDataStore) operationContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.operationTimeout)
+}
+
+func (store *etcdDataStore) allPrefix() string {
+	return "/" + store.prefix + "/"
+}
+
+func (store *etcdDataStore) kindPrefix(kind ldstoretypes.DataKind) string {
+	return store.allPrefix() + kind.GetName() + "/"
+}
+
+func (store *etcdDataStore) itemKey(kind ldstoretypes.DataKind, key string) string {
+	return store.kindPrefix(kind) + key
+}
+
+func (store *etcdDataStore) initedKey() string {
+	return store.allPrefix() + initedKey
+}
@@ -0,0 +1,187 @@
+package ldetcd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// testEndpoint is the client address of the etcd server that TestMain starts. The tests use etcd's own
+// server code, embedded in the test process, rather than a fake.
+var testEndpoint string //nolint:gochecknoglobals
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithEtcdServer(m))
+}
+
+func runWithEtcdServer(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "ldetcd-test")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := embed.NewConfig()
+	config.Dir = dir
+	config.LogLevel = "error"
+	clientURL, peerURL := localURL(), localURL()
+	config.ListenClientUrls, config.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	config.ListenPeerUrls, config.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	config.InitialCluster = config.InitialClusterFromName(config.Name)
+	server, err := embed.StartEtcd(config)
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(30 * time.Second):
+		panic("etcd server did not start")
+	}
+	testEndpoint = clientURL.Host
+	return m.Run()
+}
+
+// localURL returns an address on a port that is free, by briefly listening on it.
+func localURL() url.URL {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+	return url.URL{Scheme: "http", Host: listener.Addr().String()}
+}
+
+func closedServerAddress() string {
+	return localURL().Host
+}
+
+func makeTestContext(loggers ldlog.Loggers) subsystems.ClientContext {
+	return subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: loggers}}
+}
+
+func buildStore(t *testing.T, opts ...EtcdOption) *etcdDataStore {
+	store, err := NewEtcdDataStoreFactory([]string{testEndpoint}, opts...).
+		Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store.(*etcdDataStore)
+}
+
+func TestEtcdDataStore(t *testing.T) {
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{testEndpoint}})
+	require.NoError(t, err)
+	defer client.Close()
+
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewEtcdDataStoreFactory([]string{testEndpoint}, WithPrefix(prefix))
+		},
+		func(prefix string) error {
+			if prefix == "" {
+				prefix = DefaultPrefix
+			}
+			_, err := client.Delete(context.Background(), "/"+prefix+"/", clientv3.WithPrefix())
+			return err
+		},
+	).ErrorStoreFactory(
+		NewEtcdDataStoreFactory([]string{closedServerAddress()}, WithOperationTimeout(100*time.Millisecond)),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*etcdDataStore).testTxHook = hook
+		},
+	).Run(t)
+}
+
+func TestKeysFollowPrefixKindKeyPattern(t *testing.T) {
+	store := buildStore(t, WithPrefix("keytest"))
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(1).Build()
+	_, err := store.Upsert(datakinds.Features, flag.Key,
+		ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)))
+	require.NoError(t, err)
+	require.NoError(t, store.Init(nil)) // deletes the flag, and writes the $inited key
+
+	resp, err := store.client.Get(context.Background(), "/keytest/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "/keytest/$inited", string(resp.Kvs[0].Key))
+
+	_, err = store.Upsert(datakinds.Features, flag.Key,
+		ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)))
+	require.NoError(t, err)
+	resp, err = store.client.Get(context.Background(), "/keytest/features/flagkey")
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 1)
+}
+
+func TestInitWritesMoreItemsThanOneTransactionAllows(t *testing.T) {
+	store := buildStore(t, WithPrefix("largeinit"))
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(300, 1)},
+	}))
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(200, 2)},
+	}))
+
+	items, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, items, 200)
+	for _, item := range items {
+		desc, err := datakinds.Features.Deserialize(item.Item.SerializedItem)
+		require.NoError(t, err)
+		assert.Equal(t, 2, desc.Version)
+	}
+}
+
+func makeFlagItems(count int, version int) []ldstoretypes.KeyedSerializedItemDescriptor {
+	items := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, count)
+	for i := 0; i < count; i++ {
+		flag := ldbuilders.NewFlagBuilder(fmt.Sprintf("flag%d", i)).Version(version).Build()
+		items = append(items, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  flag.Key,
+			Item: ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)),
+		})
+	}
+	return items
+}
+
+func TestBuildOptions(t *testing.T) {
+	t.Run("no endpoints", func(t *testing.T) {
+		_, err := NewEtcdDataStoreFactory(nil).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		assert.Error(t, err)
+	})
+
+	t.Run("endpoints and TLS replace the ones in WithConfig", func(t *testing.T) {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		store := buildStore(t, WithTLS(tlsConfig),
+			WithConfig(clientv3.Config{Endpoints: []string{"elsewhere:2379"}}))
+		assert.Equal(t, []string{testEndpoint}, store.client.Endpoints())
+	})
+
+	t.Run("endpoints from WithConfig", func(t *testing.T) {
+		store, err := NewEtcdDataStoreFactory(nil, WithConfig(clientv3.Config{Endpoints: []string{testEndpoint}})).
+			Build(makeTestContext(ldlog.NewDisabledLoggers()))
+		require.NoError(t, err)
+		defer store.Close()
+		assert.Equal(t, []string{testEndpoint}, store.(*etcdDataStore).client.Endpoints())
+	})
+}
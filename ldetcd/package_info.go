@@ -0,0 +1,6 @@
+// Package ldetcd provides a persistent data store that keeps feature flags and segments in etcd, for
+// applications that run in Kubernetes or other environments that already have an etcd cluster.
+//
+// See [NewEtcdDataStoreFactory] for how to use it. This is a separate Go module, so that applications
+// that do not use it do not get the etcd client as a dependency of the SDK.
+package ldetcd
@@ -0,0 +1,131 @@
+package ldexpvar
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// dataStoreBucketBounds are the upper bounds of the buckets in the data store duration histograms. They
+// are coarser than the ones for evaluations, since each of these is a database round trip.
+var dataStoreBucketBounds = []time.Duration{ //nolint:gochecknoglobals // read-only list of bucket bounds
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// allDataStoreOperations are the operations that DataStoreMetrics has variables for.
+var allDataStoreOperations = []subsystems.DataStoreOperation{ //nolint:gochecknoglobals // read-only list
+	subsystems.DataStoreOperationInit,
+	subsystems.DataStoreOperationGet,
+	subsystems.DataStoreOperationGetAll,
+	subsystems.DataStoreOperationUpsert,
+	subsystems.DataStoreOperationAvailable,
+}
+
+// DataStoreMetrics is a persistent data store metrics sink that stores its values in expvar variables.
+//
+// The variables are grouped in a single [expvar.Map] with one entry for each operation ("init", "get",
+// "getAll", "upsert", and "available"), each with this structure:
+//
+//	{
+//	  "count": <count of store calls>,
+//	  "errors": <count of store calls that failed>,
+//	  "cacheHits": <count of queries answered from the cache>,
+//	  "duration": {
+//	    "count": <count>,
+//	    "sumNanos": <total nanoseconds>,
+//	    "buckets": { "100µs": <count>, "500µs": <count>, ..., "inf": <count> }
+//	  }
+//	}
+//
+// The duration histogram only includes store calls, not cache hits, so that it shows the latency of the
+// database itself. Each bucket counts the calls that took no longer than its bound and longer than the
+// previous bucket's bound; the "inf" bucket counts everything slower than 1s.
+type DataStoreMetrics struct {
+	root       *expvar.Map
+	operations map[subsystems.DataStoreOperation]*dataStoreOperationMetrics
+}
+
+type dataStoreOperationMetrics struct {
+	count     *expvar.Int
+	errors    *expvar.Int
+	cacheHits *expvar.Int
+	duration  *durationHistogram
+}
+
+// NewDataStoreMetrics creates a [DataStoreMetrics] instance whose variables are not yet published.
+//
+// To make the variables visible through expvar, call [DataStoreMetrics.Publish], or add the value of
+// [DataStoreMetrics.Map] to a map of your own. Then pass the instance to the Metrics method of the
+// persistent data store builder:
+//
+//	metrics := ldexpvar.NewDataStoreMetrics()
+//	metrics.Publish("launchdarkly-store")
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(ldredis.DataStore()).Metrics(metrics),
+//	}
+func NewDataStoreMetrics() *DataStoreMetrics {
+	m := &DataStoreMetrics{
+		root:       new(expvar.Map),
+		operations: make(map[subsystems.DataStoreOperation]*dataStoreOperationMetrics),
+	}
+	for _, op := range allDataStoreOperations {
+		om := &dataStoreOperationMetrics{
+			count:     new(expvar.Int),
+			errors:    new(expvar.Int),
+			cacheHits: new(expvar.Int),
+			duration:  newDurationHistogram(dataStoreBucketBounds),
+		}
+		opMap := new(expvar.Map)
+		opMap.Set("count", om.count)
+		opMap.Set("errors", om.errors)
+		opMap.Set("cacheHits", om.cacheHits)
+		opMap.Set("duration", om.duration.root)
+		m.root.Set(string(op), opMap)
+		m.operations[op] = om
+	}
+	return m
+}
+
+// Map returns the [expvar.Map] that contains all of the metrics.
+func (m *DataStoreMetrics) Map() *expvar.Map {
+	return m.root
+}
+
+// Publish publishes the metrics as a top-level expvar variable with the specified name.
+//
+// Like [expvar.Publish], this panics if a variable with the same name has already been published, so it
+// should be called only once for each name.
+func (m *DataStoreMetrics) Publish(name string) {
+	expvar.Publish(name, m.root)
+}
+
+// ObserveDataStoreOperation is called by the SDK after each persistent data store operation.
+func (m *DataStoreMetrics) ObserveDataStoreOperation(
+	operation subsystems.DataStoreOperation,
+	duration time.Duration,
+	failed bool,
+	cacheHit bool,
+) {
+	om := m.operations[operation]
+	if om == nil {
+		return // an operation that was added to the SDK after this code was written
+	}
+	if cacheHit {
+		om.cacheHits.Add(1)
+		return
+	}
+	om.count.Add(1)
+	if failed {
+		om.errors.Add(1)
+	}
+	om.duration.observe(duration)
+}
@@ -0,0 +1,61 @@
+package ldexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dataStoreOperationValues struct {
+	Count     int `json:"count"`
+	Errors    int `json:"errors"`
+	CacheHits int `json:"cacheHits"`
+	Duration  struct {
+		Count    int            `json:"count"`
+		SumNanos int64          `json:"sumNanos"`
+		Buckets  map[string]int `json:"buckets"`
+	} `json:"duration"`
+}
+
+func TestDataStoreMetrics(t *testing.T) {
+	m := NewDataStoreMetrics()
+
+	m.ObserveDataStoreOperation(subsystems.DataStoreOperationGet, 200*time.Microsecond, false, false)
+	m.ObserveDataStoreOperation(subsystems.DataStoreOperationGet, 2*time.Second, true, false)
+	m.ObserveDataStoreOperation(subsystems.DataStoreOperationGet, time.Microsecond, false, true)
+	m.ObserveDataStoreOperation(subsystems.DataStoreOperationUpsert, 3*time.Millisecond, false, false)
+	m.ObserveDataStoreOperation(subsystems.DataStoreOperation("unknown"), time.Millisecond, false, false)
+
+	var values map[string]dataStoreOperationValues
+	require.NoError(t, json.Unmarshal([]byte(m.Map().String()), &values))
+	assert.Len(t, values, len(allDataStoreOperations))
+
+	get := values["get"]
+	assert.Equal(t, 2, get.Count)
+	assert.Equal(t, 1, get.Errors)
+	assert.Equal(t, 1, get.CacheHits)
+	assert.Equal(t, 2, get.Duration.Count) // cache hits are not included in the histogram
+	assert.Equal(t, int64(2*time.Second+200*time.Microsecond), get.Duration.SumNanos)
+	assert.Equal(t, 1, get.Duration.Buckets["500µs"])
+	assert.Equal(t, 1, get.Duration.Buckets["inf"])
+	assert.Equal(t, 0, get.Duration.Buckets["100µs"])
+	assert.Len(t, get.Duration.Buckets, len(dataStoreBucketBounds)+1)
+
+	upsert := values["upsert"]
+	assert.Equal(t, 1, upsert.Count)
+	assert.Equal(t, 1, upsert.Duration.Buckets["5ms"])
+
+	assert.Equal(t, 0, values["init"].Count)
+}
+
+func TestDataStoreMetricsPublish(t *testing.T) {
+	m := NewDataStoreMetrics()
+	m.Publish("ldexpvar-data-store-test")
+	assert.Same(t, m.Map(), expvar.Get("ldexpvar-data-store-test"))
+}
@@ -0,0 +1,59 @@
+package ldexpvar
+
+import (
+	"expvar"
+	"time"
+)
+
+// durationHistogram is a set of expvar variables with this structure:
+//
+//	{
+//	  "count": <count>,
+//	  "sumNanos": <total nanoseconds>,
+//	  "buckets": { "<bound>": <count>, ..., "inf": <count> }
+//	}
+//
+// Each bucket counts the durations that were no longer than its bound and longer than the previous
+// bucket's bound; the "inf" bucket counts everything longer than the last bound.
+type durationHistogram struct {
+	bounds  []time.Duration
+	count   *expvar.Int
+	sum     *expvar.Int
+	buckets []*expvar.Int
+	root    *expvar.Map
+}
+
+func newDurationHistogram(bounds []time.Duration) *durationHistogram {
+	h := &durationHistogram{
+		bounds: bounds,
+		count:  new(expvar.Int),
+		sum:    new(expvar.Int),
+		root:   new(expvar.Map),
+	}
+	buckets := new(expvar.Map)
+	for _, bound := range bounds {
+		v := new(expvar.Int)
+		buckets.Set(bound.String(), v)
+		h.buckets = append(h.buckets, v)
+	}
+	inf := new(expvar.Int)
+	buckets.Set("inf", inf)
+	h.buckets = append(h.buckets, inf)
+
+	h.root.Set("count", h.count)
+	h.root.Set("sumNanos", h.sum)
+	h.root.Set("buckets", buckets)
+	return h
+}
+
+func (h *durationHistogram) observe(duration time.Duration) {
+	h.count.Add(1)
+	h.sum.Add(int64(duration))
+	for i, bound := range h.bounds {
+		if duration <= bound {
+			h.buckets[i].Add(1)
+			return
+		}
+	}
+	h.buckets[len(h.bounds)].Add(1)
+}
@@ -0,0 +1,100 @@
+package ldexpvar
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+)
+
+// durationBucketBounds are the upper bounds of the buckets in the evaluation duration histogram.
+var durationBucketBounds = []time.Duration{ //nolint:gochecknoglobals // read-only list of bucket bounds
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// EvaluationMetrics is an evaluation metrics sink that stores its values in expvar variables.
+//
+// The variables are grouped in a single [expvar.Map] with this structure:
+//
+//	{
+//	  "evaluations": { "<flag key>": <count>, ... },
+//	  "errors": { "<error kind>": <count>, ... },
+//	  "errorsByFlag": { "<flag key>": <count>, ... },
+//	  "duration": {
+//	    "count": <count>,
+//	    "sumNanos": <total nanoseconds>,
+//	    "buckets": { "10µs": <count>, "50µs": <count>, ..., "inf": <count> }
+//	  }
+//	}
+//
+// Each duration bucket counts the evaluations that took no longer than its bound and longer than the
+// previous bucket's bound; the "inf" bucket counts everything slower than 100ms.
+type EvaluationMetrics struct {
+	root         *expvar.Map
+	evaluations  *expvar.Map
+	errors       *expvar.Map
+	errorsByFlag *expvar.Map
+	duration     *durationHistogram
+}
+
+// NewEvaluationMetrics creates an [EvaluationMetrics] instance whose variables are not yet published.
+//
+// To make the variables visible through expvar, call [EvaluationMetrics.Publish], or add the value of
+// [EvaluationMetrics.Map] to a map of your own. Then pass the instance to ldcomponents.EvaluationMetrics():
+//
+//	metrics := ldexpvar.NewEvaluationMetrics()
+//	metrics.Publish("launchdarkly")
+//	config := ld.Config{
+//	    EvaluationMetrics: ldcomponents.EvaluationMetrics(metrics),
+//	}
+func NewEvaluationMetrics() *EvaluationMetrics {
+	m := &EvaluationMetrics{
+		root:         new(expvar.Map),
+		evaluations:  new(expvar.Map),
+		errors:       new(expvar.Map),
+		errorsByFlag: new(expvar.Map),
+		duration:     newDurationHistogram(durationBucketBounds),
+	}
+	m.root.Set("evaluations", m.evaluations)
+	m.root.Set("errors", m.errors)
+	m.root.Set("errorsByFlag", m.errorsByFlag)
+	m.root.Set("duration", m.duration.root)
+	return m
+}
+
+// Map returns the [expvar.Map] that contains all of the metrics.
+func (m *EvaluationMetrics) Map() *expvar.Map {
+	return m.root
+}
+
+// Publish publishes the metrics as a top-level expvar variable with the specified name.
+//
+// Like [expvar.Publish], this panics if a variable with the same name has already been published, so it
+// should be called only once for each name.
+func (m *EvaluationMetrics) Publish(name string) {
+	expvar.Publish(name, m.root)
+}
+
+// IncEvaluationCount is called by the SDK for each flag evaluation.
+func (m *EvaluationMetrics) IncEvaluationCount(flagKey string) {
+	m.evaluations.Add(flagKey, 1)
+}
+
+// IncErrorCount is called by the SDK for each flag evaluation that returned an error.
+func (m *EvaluationMetrics) IncErrorCount(flagKey string, errorKind ldreason.EvalErrorKind) {
+	m.errors.Add(string(errorKind), 1)
+	m.errorsByFlag.Add(flagKey, 1)
+}
+
+// ObserveEvaluationDuration is called by the SDK with the duration of each flag evaluation.
+func (m *EvaluationMetrics) ObserveEvaluationDuration(flagKey string, duration time.Duration) {
+	m.duration.observe(duration)
+}
@@ -0,0 +1,54 @@
+package ldexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationMetrics(t *testing.T) {
+	m := NewEvaluationMetrics()
+
+	m.IncEvaluationCount("flag1")
+	m.IncEvaluationCount("flag1")
+	m.IncEvaluationCount("flag2")
+	m.IncErrorCount("flag2", ldreason.EvalErrorWrongType)
+	m.ObserveEvaluationDuration("flag1", 5*time.Microsecond)
+	m.ObserveEvaluationDuration("flag1", 200*time.Microsecond)
+	m.ObserveEvaluationDuration("flag2", time.Second)
+
+	var values struct {
+		Evaluations  map[string]int `json:"evaluations"`
+		Errors       map[string]int `json:"errors"`
+		ErrorsByFlag map[string]int `json:"errorsByFlag"`
+		Duration     struct {
+			Count    int            `json:"count"`
+			SumNanos int64          `json:"sumNanos"`
+			Buckets  map[string]int `json:"buckets"`
+		} `json:"duration"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(m.Map().String()), &values))
+
+	assert.Equal(t, map[string]int{"flag1": 2, "flag2": 1}, values.Evaluations)
+	assert.Equal(t, map[string]int{"WRONG_TYPE": 1}, values.Errors)
+	assert.Equal(t, map[string]int{"flag2": 1}, values.ErrorsByFlag)
+	assert.Equal(t, 3, values.Duration.Count)
+	assert.Equal(t, int64(time.Second+205*time.Microsecond), values.Duration.SumNanos)
+	assert.Equal(t, 1, values.Duration.Buckets["10µs"])
+	assert.Equal(t, 1, values.Duration.Buckets["500µs"])
+	assert.Equal(t, 1, values.Duration.Buckets["inf"])
+	assert.Equal(t, 0, values.Duration.Buckets["1ms"])
+	assert.Len(t, values.Duration.Buckets, len(durationBucketBounds)+1)
+}
+
+func TestEvaluationMetricsPublish(t *testing.T) {
+	m := NewEvaluationMetrics()
+	m.Publish("ldexpvar-test")
+	assert.Same(t, m.Map(), expvar.Get("ldexpvar-test"))
+}
@@ -0,0 +1,8 @@
+// Package ldexpvar provides implementations of
+// [github.com/launchdarkly/go-server-sdk/v7/subsystems.EvaluationMetricsSink] and
+// [github.com/launchdarkly/go-server-sdk/v7/subsystems.DataStoreMetricsSink] that publish flag evaluation
+// and persistent data store metrics through the standard expvar package, so that they can be read from the
+// /debug/vars endpoint or by any tool that understands expvar.
+//
+// See [NewEvaluationMetrics] and [NewDataStoreMetrics] for details.
+package ldexpvar
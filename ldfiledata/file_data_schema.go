@@ -0,0 +1,19 @@
+package ldfiledata
+
+import (
+	_ "embed" // needed for the go:embed directive
+)
+
+//go:embed schema.json
+var jsonSchema []byte
+
+// JSONSchema returns a JSON Schema (draft-07) document that describes the format of a flag data file:
+// the "flags", "flagValues", and "segments" properties, and the structure of the flags and segments in
+// them.
+//
+// The schema can be registered in an editor such as VS Code or IntelliJ to validate flag files as you
+// edit them. It describes the properties that this version of the SDK understands; the SDK ignores
+// any other properties of flags and segments, so the schema does not treat them as errors.
+func JSONSchema() []byte {
+	return append([]byte(nil), jsonSchema...)
+}
@@ -0,0 +1,79 @@
+package ldfiledata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseJSONSchema(t *testing.T) ldvalue.Value {
+	schema := ldvalue.Parse(JSONSchema())
+	require.Equal(t, ldvalue.ObjectType, schema.Type())
+	return schema
+}
+
+func TestJSONSchemaIsDraft07AndDescribesTopLevelProperties(t *testing.T) {
+	schema := parseJSONSchema(t)
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema.GetByKey("$schema").StringValue())
+	assert.ElementsMatch(t, []string{"flags", "flagValues", "segments"}, schema.GetByKey("properties").Keys(nil))
+	assert.Equal(t, ldvalue.Bool(false), schema.GetByKey("additionalProperties"))
+}
+
+func TestJSONSchemaReferencesAreDefined(t *testing.T) {
+	schema := parseJSONSchema(t)
+	definitions := schema.GetByKey("definitions")
+	var refs []string
+	var findRefs func(ldvalue.Value)
+	findRefs = func(v ldvalue.Value) {
+		switch v.Type() {
+		case ldvalue.ObjectType:
+			for _, key := range v.Keys(nil) {
+				if key == "$ref" {
+					refs = append(refs, v.GetByKey(key).StringValue())
+				} else {
+					findRefs(v.GetByKey(key))
+				}
+			}
+		case ldvalue.ArrayType:
+			for i := 0; i < v.Count(); i++ {
+				findRefs(v.GetByIndex(i))
+			}
+		}
+	}
+	findRefs(schema)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		assert.True(t, definitions.GetByKey(name).IsDefined() && definitions.GetByKey(name).Type() == ldvalue.ObjectType,
+			"undefined reference %s", ref)
+	}
+}
+
+func TestJSONSchemaListsAllOperators(t *testing.T) {
+	schema := parseJSONSchema(t)
+	ops := schema.GetByKey("definitions").GetByKey("clause").GetByKey("properties").GetByKey("op").GetByKey("enum")
+	var names []string
+	for i := 0; i < ops.Count(); i++ {
+		names = append(names, ops.GetByIndex(i).StringValue())
+	}
+	for _, op := range []ldmodel.Operator{
+		ldmodel.OperatorIn, ldmodel.OperatorEndsWith, ldmodel.OperatorStartsWith, ldmodel.OperatorMatches,
+		ldmodel.OperatorContains, ldmodel.OperatorLessThan, ldmodel.OperatorLessThanOrEqual,
+		ldmodel.OperatorGreaterThan, ldmodel.OperatorGreaterThanOrEqual, ldmodel.OperatorBefore,
+		ldmodel.OperatorAfter, ldmodel.OperatorSegmentMatch, ldmodel.OperatorSemVerEqual,
+		ldmodel.OperatorSemVerLessThan, ldmodel.OperatorSemVerGreaterThan,
+	} {
+		assert.Contains(t, names, string(op))
+	}
+}
+
+func TestJSONSchemaReturnsCopy(t *testing.T) {
+	schema := JSONSchema()
+	schema[0] = 'x'
+	assert.Equal(t, byte('{'), JSONSchema()[0])
+}
@@ -2,6 +2,7 @@ package ldfiledata
 
 import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 )
 
@@ -36,6 +37,7 @@ type DataSourceBuilder struct {
 	filePaths             []string
 	duplicateKeysHandling DuplicateKeysHandling
 	reloaderFactory       ReloaderFactory
+	validateStrict        bool
 }
 
 // DataSource returns a configurable builder for a file-based data source.
@@ -71,8 +73,24 @@ func (b *DataSourceBuilder) Reloader(reloaderFactory ReloaderFactory) *DataSourc
 	return b
 }
 
+// ValidateStrict specifies whether to check every flag and segment in the files for configuration errors,
+// with [github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl.ValidateFlag] and
+// [github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl.ValidateSegment].
+//
+// If this is true and any flag or segment has a problem, the data is rejected in the same way as if a
+// file could not be parsed: none of it is loaded, and the errors are logged. The default is false.
+func (b *DataSourceBuilder) ValidateStrict(validateStrict bool) *DataSourceBuilder {
+	b.validateStrict = validateStrict
+	return b
+}
+
 // Build is called internally by the SDK.
 func (b *DataSourceBuilder) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
 	return newFileDataSourceImpl(context, context.GetDataSourceUpdateSink(), b.filePaths,
-		b.duplicateKeysHandling, b.reloaderFactory)
+		b.duplicateKeysHandling, b.reloaderFactory, b.validateStrict)
+}
+
+// DataUpdateSource is used internally by the SDK to describe this data source to a DataUpdateListener.
+func (b *DataSourceBuilder) DataUpdateSource() interfaces.DataUpdateSource {
+	return interfaces.DataUpdateSourceFile
 }
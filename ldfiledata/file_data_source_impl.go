@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 
 	"gopkg.in/ghodss/yaml.v1"
@@ -27,6 +29,7 @@ type fileDataSource struct {
 	absFilePaths          []string
 	duplicateKeysHandling DuplicateKeysHandling
 	reloaderFactory       ReloaderFactory
+	validateStrict        bool
 	loggers               ldlog.Loggers
 	isInitialized         bool
 	readyCh               chan<- struct{}
@@ -41,6 +44,7 @@ func newFileDataSourceImpl(
 	filePaths []string,
 	duplicateKeysHandling DuplicateKeysHandling,
 	reloaderFactory ReloaderFactory,
+	validateStrict bool,
 ) (subsystems.DataSource, error) {
 	abs, err := absFilePaths(filePaths)
 	if err != nil {
@@ -53,6 +57,7 @@ func newFileDataSourceImpl(
 		absFilePaths:          abs,
 		duplicateKeysHandling: duplicateKeysHandling,
 		reloaderFactory:       reloaderFactory,
+		validateStrict:        validateStrict,
 		loggers:               context.GetLogging().Loggers,
 	}
 	fs.loggers.SetPrefix("FileDataSource:")
@@ -107,6 +112,9 @@ func (fs *fileDataSource) reload() {
 		}
 	}
 	storeData, err := mergeFileData(fs.duplicateKeysHandling, filesData...)
+	if err == nil && fs.validateStrict {
+		err = validateData(storeData)
+	}
 	if err == nil {
 		if fs.dataSourceUpdates.Init(storeData) {
 			fs.signalStartComplete(true)
@@ -151,6 +159,9 @@ type fileData struct {
 	Flags      *map[string]ldmodel.FeatureFlag
 	FlagValues *map[string]ldvalue.Value
 	Segments   *map[string]ldmodel.Segment
+
+	// Additional contains the items of data kinds that were registered with ldstoreimpl.RegisterDataKind.
+	Additional map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor `json:"-"`
 }
 
 func insertData(
@@ -173,23 +184,76 @@ func insertData(
 }
 
 func readFile(path string) (fileData, error) {
+	rawData, err := os.ReadFile(path) //nolint:gosec // G304: ok to read file into variable
+	if err != nil {
+		return fileData{}, fmt.Errorf("unable to read file: %s", err)
+	}
+	data, err := parseFileData(rawData)
+	if err != nil {
+		err = fmt.Errorf("error parsing file: %s", err)
+	}
+	return data, err
+}
+
+func parseFileData(rawData []byte) (fileData, error) {
 	var data fileData
-	var rawData []byte
 	var err error
-	if rawData, err = os.ReadFile(path); err != nil { //nolint:gosec // G304: ok to read file into variable
-		return data, fmt.Errorf("unable to read file: %s", err)
-	}
-	if detectJSON(rawData) {
+	isJSON := detectJSON(rawData)
+	if isJSON {
 		err = json.Unmarshal(rawData, &data)
 	} else {
 		err = yaml.Unmarshal(rawData, &data)
 	}
-	if err != nil {
-		err = fmt.Errorf("error parsing file: %s", err)
+	if err == nil {
+		data.Additional, err = parseAdditionalData(rawData, isJSON)
 	}
 	return data, err
 }
 
+// parseAdditionalData reads the items of data kinds that were registered with ldstoreimpl.RegisterDataKind,
+// which are in the properties named for their payload names, using each kind's Deserialize method.
+func parseAdditionalData(
+	rawData []byte,
+	isJSON bool,
+) (map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor, error) {
+	kinds := datakinds.AdditionalDataKinds()
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	jsonData := rawData
+	if !isJSON {
+		var err error
+		if jsonData, err = yaml.YAMLToJSON(rawData); err != nil {
+			return nil, err // COVERAGE: can't happen, the same YAML was parsed before this
+		}
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &properties); err != nil {
+		return nil, err // COVERAGE: can't happen, the same JSON was parsed before this
+	}
+	ret := make(map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor)
+	for _, k := range kinds {
+		itemsJSON, ok := properties[k.PayloadName]
+		if !ok {
+			continue
+		}
+		var itemsMap map[string]json.RawMessage
+		if err := json.Unmarshal(itemsJSON, &itemsMap); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", k.PayloadName, err)
+		}
+		items := make(map[string]ldstoretypes.ItemDescriptor, len(itemsMap))
+		for key, itemJSON := range itemsMap {
+			item, err := k.Kind.Deserialize(itemJSON)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s '%s': %s", k.Kind, key, err)
+			}
+			items[key] = item
+		}
+		ret[k.Kind] = items
+	}
+	return ret, nil
+}
+
 func detectJSON(rawData []byte) bool {
 	// A valid JSON file for our purposes must be an object, i.e. it must start with '{'
 	return strings.HasPrefix(strings.TrimLeftFunc(string(rawData), unicode.IsSpace), "{")
@@ -199,9 +263,9 @@ func mergeFileData(
 	duplicateKeysHandling DuplicateKeysHandling,
 	allFileData ...fileData,
 ) ([]ldstoretypes.Collection, error) {
-	all := map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor{
-		datakinds.Features: {},
-		datakinds.Segments: {},
+	all := make(map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor)
+	for _, kind := range datakinds.AllDataKinds() {
+		all[kind] = make(map[string]ldstoretypes.ItemDescriptor)
 	}
 	for _, d := range allFileData {
 		if d.Flags != nil {
@@ -231,6 +295,13 @@ func mergeFileData(
 				}
 			}
 		}
+		for kind, items := range d.Additional {
+			for key, data := range items {
+				if err := insertData(all, kind, key, data, duplicateKeysHandling); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 	ret := []ldstoretypes.Collection{}
 	for kind, itemsMap := range all {
@@ -243,6 +314,29 @@ func mergeFileData(
 	return ret, nil
 }
 
+func validateData(allData []ldstoretypes.Collection) error {
+	var problems []string
+	for _, coll := range allData {
+		for _, keyedItem := range coll.Items {
+			var errs []ldstoreimpl.ValidationError
+			switch item := keyedItem.Item.Item.(type) {
+			case *ldmodel.FeatureFlag:
+				errs = ldstoreimpl.ValidateFlag(item)
+			case *ldmodel.Segment:
+				errs = ldstoreimpl.ValidateSegment(item)
+			}
+			for _, e := range errs {
+				problems = append(problems, fmt.Sprintf("%s '%s': %s", coll.Kind, keyedItem.Key, e))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid data: %s", strings.Join(problems, "; "))
+}
+
 func makeFlagWithValue(key string, v interface{}) *ldmodel.FeatureFlag {
 	flag := ldbuilders.NewFlagBuilder(key).SingleVariation(ldvalue.CopyArbitraryValue(v)).Build()
 	return &flag
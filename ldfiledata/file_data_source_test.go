@@ -16,6 +16,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 
 	th "github.com/launchdarkly/go-test-helpers/v3"
 
@@ -175,6 +176,46 @@ func TestDuplicateKeysHandlingCanSuppressErrors(t *testing.T) {
 	})
 }
 
+func TestNewFileDataSourceWithRegisteredDataKind(t *testing.T) {
+	require.NoError(t, datakinds.RegisterAdditionalDataKind(mocks.MockJSONData, "mockItems"))
+	defer datakinds.ResetAdditionalDataKinds()
+	file1Data := `{"flags": {"flag1": {"on": true}}, "mockItems": {"item1": {"version": 1}}}`
+	file2Data := "mockItems:\n  item2:\n    version: 2\n"
+
+	th.WithTempFileData([]byte(file1Data), func(filename1 string) {
+		th.WithTempFileData([]byte(file2Data), func(filename2 string) {
+			withFileDataSourceTestParams(DataSource().FilePaths(filename1, filename2), func(p fileDataSourceTestParams) {
+				p.waitForStart()
+				require.True(t, p.dataSource.IsInitialized())
+
+				requireFlag(t, p.updates.DataStore, "flag1")
+				items, err := p.updates.DataStore.GetAll(mocks.MockJSONData)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, []ldstoretypes.KeyedItemDescriptor{
+					{Key: "item1", Item: ldstoretypes.ItemDescriptor{Version: 1, Item: ldvalue.Parse([]byte(`{"version":1}`))}},
+					{Key: "item2", Item: ldstoretypes.ItemDescriptor{Version: 2, Item: ldvalue.Parse([]byte(`{"version":2}`))}},
+				}, items)
+			})
+		})
+
+		th.WithTempFileData([]byte(`{"mockItems": {"item1": {"version": 3}}}`), func(filename2 string) {
+			withFileDataSourceTestParams(DataSource().FilePaths(filename1, filename2), func(p fileDataSourceTestParams) {
+				p.waitForStart()
+				require.False(t, p.dataSource.IsInitialized())
+				p.mockLog.AssertMessageMatch(t, true, ldlog.Error, "mockJSON 'item1' is specified by multiple files")
+			})
+		})
+	})
+
+	th.WithTempFileData([]byte(`{"mockItems": {"item1": "not an object"}}`), func(filename string) {
+		withFileDataSourceTestParams(DataSource().FilePaths(filename), func(p fileDataSourceTestParams) {
+			p.waitForStart()
+			require.False(t, p.dataSource.IsInitialized())
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Error, "invalid mockJSON 'item1'")
+		})
+	})
+}
+
 func TestNewFileDataSourceBadData(t *testing.T) {
 	th.WithTempFileData([]byte(`bad data`), func(filename string) {
 		factory := DataSource().FilePaths(filename)
@@ -209,6 +250,52 @@ func TestStatusIsInterruptedAfterUnsuccessfulLoad(t *testing.T) {
 	})
 }
 
+func TestValidateStrictRejectsInvalidFlagsAndSegments(t *testing.T) {
+	data := []byte(`{
+		"flags": {"flag1": {"key": "flag1", "on": true, "variations": [true, false], "fallthrough": {"variation": 2}}},
+		"segments": {"seg1": {"key": "seg1", "rules": [{"clauses": []}]}}
+	}`)
+	th.WithTempFileData(data, func(filename string) {
+		t.Run("not validated by default", func(t *testing.T) {
+			withFileDataSourceTestParams(DataSource().FilePaths(filename), func(p fileDataSourceTestParams) {
+				p.waitForStart()
+				assert.True(t, p.dataSource.IsInitialized())
+			})
+		})
+
+		t.Run("rejected with ValidateStrict", func(t *testing.T) {
+			factory := DataSource().FilePaths(filename).ValidateStrict(true)
+			withFileDataSourceTestParams(factory, func(p fileDataSourceTestParams) {
+				p.waitForStart()
+				assert.False(t, p.dataSource.IsInitialized())
+
+				status := p.updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+				assert.Equal(t, interfaces.DataSourceErrorKindInvalidData, status.LastError.Kind)
+				assert.Equal(t, "invalid data: features 'flag1': fallthrough.variation: variation index 2 is out of range; "+
+					"the flag has 2 variations; segments 'seg1': rules[0].clauses: segment rule must have at least one clause",
+					status.LastError.Message)
+				assert.Len(t, p.mockLog.GetOutput(ldlog.Error), 1)
+			})
+		})
+	})
+}
+
+func TestValidateStrictAcceptsValidData(t *testing.T) {
+	data := []byte(`{
+		"flags": {"flag1": {"key": "flag1", "on": true, "variations": [true, false], "fallthrough": {"variation": 1}}},
+		"flagValues": {"flag2": "value2"},
+		"segments": {"seg1": {"key": "seg1", "included": ["user1"]}}
+	}`)
+	th.WithTempFileData(data, func(filename string) {
+		factory := DataSource().FilePaths(filename).ValidateStrict(true)
+		withFileDataSourceTestParams(factory, func(p fileDataSourceTestParams) {
+			p.waitForStart()
+			assert.True(t, p.dataSource.IsInitialized())
+			p.updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+		})
+	})
+}
+
 func TestNewFileDataSourceYamlValues(t *testing.T) {
 	fileData := `
 ---
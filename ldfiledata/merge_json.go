@@ -0,0 +1,94 @@
+package ldfiledata
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+)
+
+type mergedFileData struct {
+	Flags      map[string]ldmodel.FeatureFlag `json:"flags,omitempty"`
+	FlagValues map[string]ldvalue.Value       `json:"flagValues,omitempty"`
+	Segments   map[string]ldmodel.Segment     `json:"segments,omitempty"`
+}
+
+// MergeJSON combines several sets of flag data into one, without using the filesystem. This is useful if
+// an application generates flag data itself, for instance from a database, in more than one piece.
+//
+// Each source must be in the same format as a flag data file: JSON or YAML, with any of the
+// "flags", "flagValues", and "segments" properties. The result is a JSON document in that format,
+// which contains all of the flags, simplified flag values, and segments from every source. It is an
+// error for the same flag key or segment key to be in more than one source, as it is for files with
+// DuplicateKeysFail; a key in "flags" in one source and in "flagValues" in another is also a duplicate.
+// Items of data kinds that were registered with ldstoreimpl.RegisterDataKind are merged the same way.
+func MergeJSON(sources ...[]byte) ([]byte, error) {
+	merged := mergedFileData{
+		Flags:      make(map[string]ldmodel.FeatureFlag),
+		FlagValues: make(map[string]ldvalue.Value),
+		Segments:   make(map[string]ldmodel.Segment),
+	}
+	flagKeys := make(map[string]bool)
+	additional := make(map[string]map[string]json.RawMessage)
+	for i, source := range sources {
+		data, err := parseFileData(source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing source %d: %s", i, err)
+		}
+		if data.Flags != nil {
+			for key, flag := range *data.Flags {
+				if flagKeys[key] {
+					return nil, duplicateKeyError("flag", key)
+				}
+				flagKeys[key] = true
+				merged.Flags[key] = flag
+			}
+		}
+		if data.FlagValues != nil {
+			for key, value := range *data.FlagValues {
+				if flagKeys[key] {
+					return nil, duplicateKeyError("flag", key)
+				}
+				flagKeys[key] = true
+				merged.FlagValues[key] = value
+			}
+		}
+		if data.Segments != nil {
+			for key, segment := range *data.Segments {
+				if _, exists := merged.Segments[key]; exists {
+					return nil, duplicateKeyError("segment", key)
+				}
+				merged.Segments[key] = segment
+			}
+		}
+		for _, k := range datakinds.AdditionalDataKinds() {
+			for key, item := range data.Additional[k.Kind] {
+				if additional[k.PayloadName] == nil {
+					additional[k.PayloadName] = make(map[string]json.RawMessage)
+				}
+				if _, exists := additional[k.PayloadName][key]; exists {
+					return nil, duplicateKeyError(k.Kind.GetName(), key)
+				}
+				additional[k.PayloadName][key] = k.Kind.Serialize(item)
+			}
+		}
+	}
+	result, err := json.Marshal(merged)
+	if err != nil || len(additional) == 0 {
+		return result, err
+	}
+	var properties map[string]interface{}
+	if err := json.Unmarshal(result, &properties); err != nil {
+		return nil, err // COVERAGE: can't happen, we just marshaled it
+	}
+	for payloadName, items := range additional {
+		properties[payloadName] = items
+	}
+	return json.Marshal(properties)
+}
+
+func duplicateKeyError(kind, key string) error {
+	return fmt.Errorf("%s '%s' is specified by multiple sources", kind, key)
+}
@@ -0,0 +1,113 @@
+package ldfiledata
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeJSON(t *testing.T) {
+	t.Run("combines flags, flag values, and segments", func(t *testing.T) {
+		merged, err := MergeJSON(
+			[]byte(`{"flags": {"flag1": {"key": "flag1", "on": true, "variations": ["a", "b"]}}}`),
+			[]byte(`{"flagValues": {"flag2": "value2"}, "segments": {"seg1": {"key": "seg1", "included": ["user1"]}}}`),
+			[]byte("flagValues:\n  flag3: 3\n"),
+		)
+		require.NoError(t, err)
+
+		value := ldvalue.Parse(merged)
+		assert.ElementsMatch(t, []string{"flags", "flagValues", "segments"}, value.Keys(nil))
+		assert.Equal(t, []string{"flag1"}, value.GetByKey("flags").Keys(nil))
+		flag1 := value.GetByKey("flags").GetByKey("flag1")
+		assert.True(t, flag1.GetByKey("on").BoolValue())
+		assert.Equal(t, ldvalue.ArrayOf(ldvalue.String("a"), ldvalue.String("b")), flag1.GetByKey("variations"))
+		assert.Equal(t, ldvalue.ObjectBuild().Set("flag2", ldvalue.String("value2")).Set("flag3", ldvalue.Int(3)).Build(),
+			value.GetByKey("flagValues"))
+		assert.Equal(t, []string{"seg1"}, value.GetByKey("segments").Keys(nil))
+	})
+
+	t.Run("result can be used by the data source", func(t *testing.T) {
+		merged, err := MergeJSON(
+			[]byte(`{"flags": {"flag1": {"key": "flag1", "on": true, "variations": ["a", "b"]}}}`),
+			[]byte(`{"flagValues": {"flag2": "value2"}, "segments": {"seg1": {"key": "seg1", "included": ["user1"]}}}`),
+		)
+		require.NoError(t, err)
+		th.WithTempFileData(merged, func(filename string) {
+			withFileDataSourceTestParams(DataSource().FilePaths(filename), func(p fileDataSourceTestParams) {
+				p.waitForStart()
+				require.True(t, p.dataSource.IsInitialized())
+				for _, key := range []string{"flag1", "flag2"} {
+					item, _ := p.updates.DataStore.Get(datakinds.Features, key)
+					assert.NotNil(t, item.Item, key)
+				}
+				item, _ := p.updates.DataStore.Get(datakinds.Segments, "seg1")
+				assert.NotNil(t, item.Item)
+			})
+		})
+	})
+
+	t.Run("no sources", func(t *testing.T) {
+		merged, err := MergeJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{}`, string(merged))
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		for name, sources := range map[string][]string{
+			"flags":          {`{"flags": {"x": {"key": "x"}}}`, `{"flags": {"x": {"key": "x"}}}`},
+			"flag values":    {`{"flagValues": {"x": 1}}`, `{"flagValues": {"x": 2}}`},
+			"flag and value": {`{"flags": {"x": {"key": "x"}}}`, `{"flagValues": {"x": 2}}`},
+			"in one source":  {`{"flags": {"x": {"key": "x"}}, "flagValues": {"x": 2}}`},
+			"segments":       {`{"segments": {"y": {"key": "y"}}}`, `{"segments": {"y": {"key": "y"}}}`},
+			"value and flag": {`{"flagValues": {"x": 2}}`, `{"flags": {"x": {"key": "x"}}}`},
+		} {
+			t.Run(name, func(t *testing.T) {
+				var data [][]byte
+				for _, s := range sources {
+					data = append(data, []byte(s))
+				}
+				merged, err := MergeJSON(data...)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "is specified by multiple sources")
+				assert.Nil(t, merged)
+			})
+		}
+	})
+
+	t.Run("flags and segments can have the same key", func(t *testing.T) {
+		_, err := MergeJSON([]byte(`{"segments": {"x": {"key": "x"}}}`), []byte(`{"flags": {"x": {"key": "x"}}}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		_, err := MergeJSON([]byte(`{"flagValues": {}}`), []byte(`{"flags": [`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error parsing source 1")
+	})
+
+	t.Run("registered data kinds", func(t *testing.T) {
+		require.NoError(t, datakinds.RegisterAdditionalDataKind(mocks.MockJSONData, "mockItems"))
+		defer datakinds.ResetAdditionalDataKinds()
+
+		merged, err := MergeJSON(
+			[]byte(`{"flagValues": {"flag1": true}, "mockItems": {"item1": {"version": 1}}}`),
+			[]byte("mockItems:\n  item2:\n    version: 2\n"),
+		)
+		require.NoError(t, err)
+		value := ldvalue.Parse(merged)
+		assert.ElementsMatch(t, []string{"flagValues", "mockItems"}, value.Keys(nil))
+		assert.Equal(t, ldvalue.Parse([]byte(`{"item1": {"version": 1}, "item2": {"version": 2}}`)),
+			value.GetByKey("mockItems"))
+
+		_, err = MergeJSON([]byte(`{"mockItems": {"x": {}}}`), []byte(`{"mockItems": {"x": {}}}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mockJSON 'x' is specified by multiple sources")
+	})
+}
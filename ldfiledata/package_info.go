@@ -23,6 +23,9 @@
 //   - "flagValues": Simplified feature flags that contain only a value.
 //   - "segments": User segment definitions.
 //
+// If other data kinds have been registered with ldstoreimpl.RegisterDataKind, the file may also have a
+// property for each of them, named for its payload name.
+//
 // The format of the data in "flags" and "segments" is defined by the LaunchDarkly application and is
 // subject to change. Rather than trying to construct these objects yourself, it is simpler to request
 // existing flags directly from the LaunchDarkly server in JSON format, and use this output as the starting
@@ -72,6 +75,9 @@
 // segment key more than once, either in a single file or across multiple files, unless you specify
 // otherwise with the DuplicateKeysHandling method.
 //
+// [JSONSchema] returns a JSON Schema for this file format, which you can register in an editor to check
+// flag files as you edit them.
+//
 // If the data source encounters any error in any file-- malformed content, a missing file, or a
 // duplicate key-- it will not load flags from any of the files.
 package ldfiledata
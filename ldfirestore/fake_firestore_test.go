@@ -0,0 +1,307 @@
+package ldfirestore
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeFirestore is a minimal in-memory implementation of the Firestore gRPC API: just the parts that the
+// data store uses, which are getting documents, equality queries on one field, commits, and transactions.
+// Transactions are optimistic: a commit fails with Aborted if any document that the transaction read has
+// changed since then, and the Firestore client then runs the transaction again.
+type fakeFirestore struct {
+	pb.UnimplementedFirestoreServer
+	server       *grpc.Server
+	listener     net.Listener
+	docs         map[string]*fakeDocument
+	transactions map[string]map[string]int64 // transaction ID -> document name -> revision when read
+	revision     int64
+	nextTxID     int
+	failing      bool
+	lock         sync.Mutex
+}
+
+type fakeDocument struct {
+	doc      *pb.Document
+	revision int64
+}
+
+func newFakeFirestore(t *testing.T) *fakeFirestore {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeFirestore{
+		server:       grpc.NewServer(),
+		listener:     listener,
+		docs:         make(map[string]*fakeDocument),
+		transactions: make(map[string]map[string]int64),
+	}
+	pb.RegisterFirestoreServer(f.server, f)
+	go func() { _ = f.server.Serve(listener) }()
+	t.Cleanup(f.server.Stop)
+	return f
+}
+
+// clientOptions returns the options for a Firestore client that connects to this server. Each call
+// makes a new connection, since the client closes its connection when it is closed.
+func (f *fakeFirestore) clientOptions() []option.ClientOption {
+	conn, err := grpc.NewClient(f.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		panic(err) // can't happen, NewClient does not connect
+	}
+	return []option.ClientOption{option.WithGRPCConn(conn)}
+}
+
+// setFailing makes every request fail with a non-retryable error.
+func (f *fakeFirestore) setFailing(failing bool) {
+	f.lock.Lock()
+	f.failing = failing
+	f.lock.Unlock()
+}
+
+// deleteCollection deletes every document in the named collection.
+func (f *fakeFirestore) deleteCollection(name string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for docName := range f.docs {
+		if collectionOf(docName) == name {
+			f.bumpRevision()
+			delete(f.docs, docName)
+		}
+	}
+}
+
+// documentFields returns the fields of a document, or nil if it does not exist.
+func (f *fakeFirestore) documentFields(collection, id string) map[string]*pb.Value {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for name, d := range f.docs {
+		if collectionOf(name) == collection && name[strings.LastIndex(name, "/")+1:] == id {
+			return d.doc.Fields
+		}
+	}
+	return nil
+}
+
+func (f *fakeFirestore) documentCount(collection string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	count := 0
+	for name := range f.docs {
+		if collectionOf(name) == collection {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeFirestore) documentNames() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var names []string
+	for name := range f.docs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func collectionOf(docName string) string {
+	parts := strings.Split(docName, "/")
+	return parts[len(parts)-2]
+}
+
+func (f *fakeFirestore) bumpRevision() int64 {
+	f.revision++
+	return f.revision
+}
+
+func (f *fakeFirestore) checkFailing() error {
+	if f.failing {
+		return status.Error(codes.PermissionDenied, "sorry")
+	}
+	return nil
+}
+
+func (f *fakeFirestore) BatchGetDocuments(
+	req *pb.BatchGetDocumentsRequest,
+	stream pb.Firestore_BatchGetDocumentsServer,
+) error {
+	f.lock.Lock()
+	if err := f.checkFailing(); err != nil {
+		f.lock.Unlock()
+		return err
+	}
+	reads := f.transactions[string(req.GetTransaction())]
+	var responses []*pb.BatchGetDocumentsResponse
+	for _, name := range req.Documents {
+		resp := &pb.BatchGetDocumentsResponse{ReadTime: timestamppb.Now()}
+		if d, ok := f.docs[name]; ok {
+			resp.Result = &pb.BatchGetDocumentsResponse_Found{Found: proto.Clone(d.doc).(*pb.Document)}
+			if reads != nil {
+				reads[name] = d.revision
+			}
+		} else {
+			resp.Result = &pb.BatchGetDocumentsResponse_Missing{Missing: name}
+			if reads != nil {
+				reads[name] = 0
+			}
+		}
+		responses = append(responses, resp)
+	}
+	f.lock.Unlock()
+	for _, resp := range responses {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeFirestore) RunQuery(req *pb.RunQueryRequest, stream pb.Firestore_RunQueryServer) error {
+	f.lock.Lock()
+	if err := f.checkFailing(); err != nil {
+		f.lock.Unlock()
+		return err
+	}
+	query := req.GetStructuredQuery()
+	if query == nil || len(query.From) != 1 {
+		f.lock.Unlock()
+		return status.Error(codes.Unimplemented, "unsupported query")
+	}
+	filters, err := fieldFilters(query.Where)
+	if err != nil {
+		f.lock.Unlock()
+		return err
+	}
+	prefix := req.Parent + "/" + query.From[0].CollectionId + "/"
+	var docs []*pb.Document
+	for name, d := range f.docs {
+		if !strings.HasPrefix(name, prefix) || strings.Contains(strings.TrimPrefix(name, prefix), "/") {
+			continue
+		}
+		matched := true
+		for _, filter := range filters {
+			if !proto.Equal(d.doc.Fields[filter.Field.FieldPath], filter.Value) {
+				matched = false
+			}
+		}
+		if matched {
+			docs = append(docs, proto.Clone(d.doc).(*pb.Document))
+		}
+	}
+	f.lock.Unlock()
+	for _, doc := range docs {
+		if err := stream.Send(&pb.RunQueryResponse{Document: doc, ReadTime: timestamppb.Now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldFilters(where *pb.StructuredQuery_Filter) ([]*pb.StructuredQuery_FieldFilter, error) {
+	if where == nil {
+		return nil, nil
+	}
+	if ff := where.GetFieldFilter(); ff != nil {
+		if ff.Op != pb.StructuredQuery_FieldFilter_EQUAL {
+			return nil, status.Error(codes.Unimplemented, "unsupported filter")
+		}
+		return []*pb.StructuredQuery_FieldFilter{ff}, nil
+	}
+	if cf := where.GetCompositeFilter(); cf != nil && cf.Op == pb.StructuredQuery_CompositeFilter_AND {
+		var ret []*pb.StructuredQuery_FieldFilter
+		for _, sub := range cf.Filters {
+			subFilters, err := fieldFilters(sub)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, subFilters...)
+		}
+		return ret, nil
+	}
+	return nil, status.Error(codes.Unimplemented, "unsupported filter")
+}
+
+func (f *fakeFirestore) BeginTransaction(
+	ctx context.Context,
+	req *pb.BeginTransactionRequest,
+) (*pb.BeginTransactionResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkFailing(); err != nil {
+		return nil, err
+	}
+	f.nextTxID++
+	id := fmt.Sprintf("tx%d", f.nextTxID)
+	f.transactions[id] = make(map[string]int64)
+	return &pb.BeginTransactionResponse{Transaction: []byte(id)}, nil
+}
+
+func (f *fakeFirestore) Rollback(ctx context.Context, req *pb.RollbackRequest) (*emptypb.Empty, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.transactions, string(req.Transaction))
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeFirestore) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkFailing(); err != nil {
+		return nil, err
+	}
+	if req.Transaction != nil {
+		reads, ok := f.transactions[string(req.Transaction)]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "unknown transaction")
+		}
+		delete(f.transactions, string(req.Transaction))
+		for name, revision := range reads {
+			var current int64
+			if d, ok := f.docs[name]; ok {
+				current = d.revision
+			}
+			if current != revision {
+				return nil, status.Error(codes.Aborted, "document was changed by another transaction")
+			}
+		}
+	}
+	now := timestamppb.New(time.Now())
+	resp := &pb.CommitResponse{CommitTime: now}
+	for _, w := range req.Writes {
+		switch op := w.Operation.(type) {
+		case *pb.Write_Update:
+			if w.UpdateMask != nil || w.CurrentDocument != nil || len(w.UpdateTransforms) != 0 {
+				return nil, status.Error(codes.Unimplemented, "unsupported write")
+			}
+			doc := proto.Clone(op.Update).(*pb.Document)
+			doc.CreateTime, doc.UpdateTime = now, now
+			if old, ok := f.docs[doc.Name]; ok {
+				doc.CreateTime = old.doc.CreateTime
+			}
+			f.docs[doc.Name] = &fakeDocument{doc: doc, revision: f.bumpRevision()}
+		case *pb.Write_Delete:
+			f.bumpRevision()
+			delete(f.docs, op.Delete)
+		default:
+			return nil, status.Error(codes.Unimplemented, "unsupported write")
+		}
+		resp.WriteResults = append(resp.WriteResults, &pb.WriteResult{UpdateTime: now})
+	}
+	return resp, nil
+}
@@ -0,0 +1,304 @@
+package ldfirestore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultCollection is the name of the Firestore collection that is used by the data store, if an
+	// empty collection name is passed to NewFirestoreDataStoreFactory.
+	DefaultCollection = "launchdarkly"
+
+	namespaceField = "namespace"
+	keyField       = "key"
+	versionField   = "version"
+	itemField      = "item"
+
+	initedDocumentID = "$inited"
+
+	// maxTransactionWrites is the most writes that Firestore allows in one transaction.
+	maxTransactionWrites = 500
+)
+
+// FirestoreOption is an optional parameter for [NewFirestoreDataStoreFactory].
+type FirestoreOption func(*firestoreDataStoreFactory)
+
+// WithClientOptions adds options for creating the Firestore client, such as option.WithCredentialsFile
+// to use a service account key instead of Application Default Credentials.
+func WithClientOptions(opts ...option.ClientOption) FirestoreOption {
+	return func(f *firestoreDataStoreFactory) {
+		f.clientOptions = append(f.clientOptions, opts...)
+	}
+}
+
+// WithDatabase specifies a Firestore database other than the project's default database.
+func WithDatabase(databaseID string) FirestoreOption {
+	return func(f *firestoreDataStoreFactory) {
+		f.databaseID = databaseID
+	}
+}
+
+type firestoreDataStoreFactory struct {
+	projectID     string
+	collection    string
+	databaseID    string
+	clientOptions []option.ClientOption
+}
+
+type firestoreDataStore struct {
+	client     *firestore.Client
+	collection *firestore.CollectionRef
+	loggers    ldlog.Loggers
+	testTxHook func()
+	inited     bool
+	lock       sync.Mutex
+	closeOnce  sync.Once
+}
+
+// NewFirestoreDataStoreFactory returns a configuration for a persistent data store that uses the specified
+// Firestore collection, or [DefaultCollection] if the collection name is empty. If the project ID is
+// empty, it is detected from the credentials, as with firestore.DetectProjectID. To use it, pass it to
+// ldcomponents.PersistentDataStore and store the result in the DataStore field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldfirestore.NewFirestoreDataStoreFactory("my-project", "launchdarkly"),
+//	    ),
+//	}
+//
+// By default, the client authenticates with Application Default Credentials; use WithClientOptions to
+// change that. If the FIRESTORE_EMULATOR_HOST environment variable is set, the client connects to the
+// Firestore emulator instead, as described for firestore.NewClient.
+//
+// Each flag or segment is a document in the collection, with the fields "namespace" ("features" or
+// "segments"), "key", "version", and "item" (the same JSON that other persistent data stores use).
+// Updates use a Firestore transaction that reads the current version, so that a newer version of an
+// item that another process has written at the same time is never replaced with an older one. The SDK
+// replaces the whole data set with transactions of at most 500 writes, since that is Firestore's limit;
+// so if the data set is larger than that, other processes may briefly see a mix of the old and new data.
+func NewFirestoreDataStoreFactory(
+	projectID string,
+	collection string,
+	opts ...FirestoreOption,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	f := &firestoreDataStoreFactory{projectID: projectID, collection: collection}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *firestoreDataStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	projectID := f.projectID
+	if projectID == "" {
+		projectID = firestore.DetectProjectID
+	}
+	var client *firestore.Client
+	var err error
+	if f.databaseID == "" {
+		client, err = firestore.NewClient(context.Background(), projectID, f.clientOptions...)
+	} else {
+		client, err = firestore.NewClientWithDatabase(context.Background(), projectID, f.databaseID,
+			f.clientOptions...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	collection := f.collection
+	if collection == "" {
+		collection = DefaultCollection
+	}
+	loggers := clientContext.GetLogging().Loggers
+	loggers.SetPrefix("FirestoreDataStore:")
+	return &firestoreDataStore{
+		client:     client,
+		collection: client.Collection(collection),
+		loggers:    loggers,
+	}, nil
+}
+
+func (store *firestoreDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	ctx := context.Background()
+	oldDocs, err := store.collection.Select().Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	var writes []func(*firestore.Transaction) error
+	newIDs := make(map[string]bool)
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			ref := store.itemRef(coll.Kind, item.Key)
+			newIDs[ref.ID] = true
+			data := itemData(coll.Kind, item.Key, item.Item)
+			writes = append(writes, func(tx *firestore.Transaction) error { return tx.Set(ref, data) })
+		}
+	}
+	for _, doc := range oldDocs {
+		if ref := doc.Ref; !newIDs[ref.ID] && ref.ID != initedDocumentID {
+			writes = append(writes, func(tx *firestore.Transaction) error { return tx.Delete(ref) })
+		}
+	}
+	initedRef := store.collection.Doc(initedDocumentID)
+	initedData := map[string]interface{}{namespaceField: initedDocumentID, keyField: initedDocumentID}
+	writes = append(writes, func(tx *firestore.Transaction) error { return tx.Set(initedRef, initedData) })
+
+	// As PersistentDataStore.Init requires when the update can't be atomic, the items are written in
+	// order, followed by the deletions, and the store is only marked as initialized at the end.
+	for len(writes) > 0 {
+		batch := writes
+		if len(batch) > maxTransactionWrites {
+			batch = batch[:maxTransactionWrites]
+		}
+		writes = writes[len(batch):]
+		err := store.client.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+			for _, write := range batch {
+				if err := write(tx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return nil
+}
+
+func (store *firestoreDataStore) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	doc, err := store.itemRef(kind, key).Get(context.Background())
+	if status.Code(err) == codes.NotFound {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), nil
+	}
+	if err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return itemFromDocument(doc), nil
+}
+
+func (store *firestoreDataStore) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	query := store.collection.Where(namespaceField, "==", kind.GetName())
+	docs, err := query.Documents(context.Background()).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(docs))
+	for _, doc := range docs {
+		key, _ := doc.Data()[keyField].(string)
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{Key: key, Item: itemFromDocument(doc)})
+	}
+	return results, nil
+}
+
+func (store *firestoreDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	ref := store.itemRef(kind, key)
+	var updated bool
+	// RunTransaction calls the function again if another process changed the document after it was read
+	// and before the transaction was committed, so the version comparison is always up to date.
+	err := store.client.RunTransaction(context.Background(), func(_ context.Context, tx *firestore.Transaction) error {
+		updated = false
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil && itemFromDocument(doc).Version >= newItem.Version {
+			return nil
+		}
+
+		if store.testTxHook != nil { // instrumentation for unit tests
+			store.testTxHook()
+		}
+
+		if err := tx.Set(ref, itemData(kind, key, newItem)); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+func (store *firestoreDataStore) IsInitialized() bool {
+	store.lock.Lock()
+	inited := store.inited
+	store.lock.Unlock()
+	if inited {
+		return true
+	}
+	if _, err := store.collection.Doc(initedDocumentID).Get(context.Background()); err != nil {
+		return false
+	}
+	store.lock.Lock()
+	store.inited = true
+	store.lock.Unlock()
+	return true
+}
+
+func (store *firestoreDataStore) IsStoreAvailable() bool {
+	_, err := store.collection.Doc(initedDocumentID).Get(context.Background())
+	return err == nil || status.Code(err) == codes.NotFound
+}
+
+// Close is called automatically when the client is closed.
+func (store *firestoreDataStore) Close() error {
+	var err error
+	store.closeOnce.Do(func() {
+		err = store.client.Close()
+	})
+	return err
+}
+
+// itemRef returns the document for an item. Firestore document IDs cannot contain slashes, but flag and
+// segment keys cannot either, so the key can be used as it is.
+func (store *firestoreDataStore) itemRef(kind ldstoretypes.DataKind, key string) *firestore.DocumentRef {
+	return store.collection.Doc(kind.GetName() + ":" + key)
+}
+
+func itemData(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.SerializedItemDescriptor,
+) map[string]interface{} {
+	return map[string]interface{}{
+		namespaceField: kind.GetName(),
+		keyField:       key,
+		versionField:   item.Version,
+		itemField:      string(item.SerializedItem),
+	}
+}
+
+func itemFromDocument(doc *firestore.DocumentSnapshot) ldstoretypes.SerializedItemDescriptor {
+	data := doc.Data()
+	version, _ := data[versionField].(int64)
+	item, _ := data[itemField].(string)
+	return ldstoretypes.SerializedItemDescriptor{Version: int(version), SerializedItem: []byte(item)}
+}
@@ -0,0 +1,170 @@
+package ldfirestore
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProjectID = "test-project"
+
+func makeTestContext(loggers ldlog.Loggers) subsystems.ClientContext {
+	return subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: loggers}}
+}
+
+func buildStore(t *testing.T, fake *fakeFirestore, collection string) *firestoreDataStore {
+	store, err := NewFirestoreDataStoreFactory(testProjectID, collection, WithClientOptions(fake.clientOptions()...)).
+		Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store.(*firestoreDataStore)
+}
+
+// collectionForPrefix is how the test suite's prefixes are applied: each one is a separate collection.
+func collectionForPrefix(prefix string) string {
+	if prefix == "" {
+		return DefaultCollection
+	}
+	return prefix
+}
+
+func TestFirestoreDataStoreWithFakeFirestore(t *testing.T) {
+	fake := newFakeFirestore(t)
+	failing := newFakeFirestore(t)
+	failing.setFailing(true)
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewFirestoreDataStoreFactory(testProjectID, collectionForPrefix(prefix),
+				WithClientOptions(fake.clientOptions()...))
+		},
+		func(prefix string) error {
+			fake.deleteCollection(collectionForPrefix(prefix))
+			return nil
+		},
+	).ErrorStoreFactory(
+		NewFirestoreDataStoreFactory(testProjectID, "", WithClientOptions(failing.clientOptions()...)),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*firestoreDataStore).testTxHook = hook
+		},
+	).Run(t)
+}
+
+// TestFirestoreDataStoreWithEmulator runs the same tests against the Firestore emulator, if the standard
+// FIRESTORE_EMULATOR_HOST environment variable is set; for instance, after "gcloud emulators firestore
+// start --host-port=localhost:8080", set it to "localhost:8080".
+//
+// The concurrent modification tests are left out here. Their hook runs the competing update inside the
+// first update's transaction, which in Firestore holds a lock on the document until it finishes; that
+// can't happen when the updates come from different processes.
+func TestFirestoreDataStoreWithEmulator(t *testing.T) {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+	client, err := firestore.NewClient(context.Background(), testProjectID)
+	require.NoError(t, err)
+	defer client.Close()
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return NewFirestoreDataStoreFactory(testProjectID, collectionForPrefix(prefix))
+		},
+		func(prefix string) error {
+			docs, err := client.Collection(collectionForPrefix(prefix)).Documents(context.Background()).GetAll()
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				if _, err := doc.Ref.Delete(context.Background()); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	).Run(t)
+}
+
+func TestItemIsStoredWithVersionField(t *testing.T) {
+	fake := newFakeFirestore(t)
+	store := buildStore(t, fake, "flags")
+	flag := ldbuilders.NewFlagBuilder("flagkey").Version(3).Build()
+	_, err := store.Upsert(datakinds.Features, flag.Key,
+		ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)))
+	require.NoError(t, err)
+
+	fields := fake.documentFields("flags", "features:flagkey")
+	require.NotNil(t, fields)
+	assert.Equal(t, "features", fields[namespaceField].GetStringValue())
+	assert.Equal(t, "flagkey", fields[keyField].GetStringValue())
+	assert.Equal(t, int64(3), fields[versionField].GetIntegerValue())
+	desc, err := datakinds.Features.Deserialize([]byte(fields[itemField].GetStringValue()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, desc.Version)
+}
+
+func TestInitWritesMoreItemsThanOneTransactionAllows(t *testing.T) {
+	fake := newFakeFirestore(t)
+	store := buildStore(t, fake, "")
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(700, 1)},
+	}))
+	assert.Equal(t, 701, fake.documentCount(DefaultCollection)) // including the $inited document
+
+	require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+		{Kind: datakinds.Features, Items: makeFlagItems(300, 2)},
+	}))
+	assert.Equal(t, 301, fake.documentCount(DefaultCollection))
+
+	items, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, items, 300)
+	for _, item := range items {
+		assert.Equal(t, 2, item.Item.Version)
+	}
+}
+
+func makeFlagItems(count int, version int) []ldstoretypes.KeyedSerializedItemDescriptor {
+	items := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, count)
+	for i := 0; i < count; i++ {
+		flag := ldbuilders.NewFlagBuilder("flag" + string(rune('a'+i/26)) + string(rune('a'+i%26))).
+			Version(version).Build()
+		items = append(items, ldstoretypes.KeyedSerializedItemDescriptor{
+			Key:  flag.Key,
+			Item: ldstoreimpl.SerializeDescriptor(datakinds.Features, ldstoreimpl.MakeFlagDescriptor(flag)),
+		})
+	}
+	return items
+}
+
+func TestIsStoreAvailable(t *testing.T) {
+	fake := newFakeFirestore(t)
+	store := buildStore(t, fake, "")
+	assert.True(t, store.IsStoreAvailable()) // even though nothing has been stored yet
+
+	fake.setFailing(true)
+	assert.False(t, store.IsStoreAvailable())
+}
+
+func TestBuildWithDatabase(t *testing.T) {
+	fake := newFakeFirestore(t)
+	store, err := NewFirestoreDataStoreFactory(testProjectID, "", WithDatabase("other"),
+		WithClientOptions(fake.clientOptions()...)).Build(makeTestContext(ldlog.NewDisabledLoggers()))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.Init(nil))
+	assert.Equal(t, []string{"projects/test-project/databases/other/documents/launchdarkly/$inited"},
+		fake.documentNames())
+}
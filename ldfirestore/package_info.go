@@ -0,0 +1,6 @@
+// Package ldfirestore provides a persistent data store that keeps feature flags and segments in Google
+// Cloud Firestore, for applications that already use Firestore on Google Cloud.
+//
+// See [NewFirestoreDataStoreFactory] for how to use it. This is a separate Go module, so that applications
+// that do not use it do not get the Google Cloud client libraries as a dependency of the SDK.
+package ldfirestore
@@ -0,0 +1,95 @@
+package ldgrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ContextMetadataKey is the gRPC metadata key that [PropagateContext] and [ExtractContext] use.
+const ContextMetadataKey = "ld-context"
+
+// PropagateContext returns a copy of ctx whose outgoing gRPC metadata includes an evaluation context, so
+// that a service that receives the call can get the same evaluation context with [ExtractContext].
+//
+// This is for calls from a service that evaluated flags for a request to the other services that it calls
+// while handling that request, so that they can evaluate flags with exactly the same context attributes.
+// The value is a base64-encoded protobuf message, a google.protobuf.Struct with the same properties as the
+// JSON representation of the context. Private attribute designations are kept, so that the receiving
+// service also redacts those attributes in analytics events. If ldCtx is invalid, ctx is returned
+// unchanged.
+func PropagateContext(ctx context.Context, ldCtx ldcontext.Context) context.Context {
+	encoded, err := encodeContext(ldCtx)
+	if err != nil {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(ContextMetadataKey, encoded)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// ExtractContext returns the evaluation context that [PropagateContext] added to the metadata of an
+// incoming gRPC call. The second return value is false if there is no such metadata, or if it is not a
+// valid context.
+//
+// This can be used in a [FlagConfig.ContextExtractor], so that the service evaluates flags for the
+// context that its caller used.
+func ExtractContext(ctx context.Context) (ldcontext.Context, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ContextMetadataKey)
+	if len(values) == 0 {
+		return ldcontext.Context{}, false
+	}
+	ldCtx, err := decodeContext(values[0])
+	if err != nil {
+		return ldcontext.Context{}, false
+	}
+	return ldCtx, true
+}
+
+func encodeContext(ldCtx ldcontext.Context) (string, error) {
+	if err := ldCtx.Err(); err != nil {
+		return "", err
+	}
+	jsonData, err := json.Marshal(ldCtx)
+	if err != nil {
+		return "", err // COVERAGE: can't happen for a valid context
+	}
+	var s structpb.Struct
+	if err := protojson.Unmarshal(jsonData, &s); err != nil {
+		return "", err // COVERAGE: can't happen, since the context's JSON representation is always an object
+	}
+	protoData, err := proto.Marshal(&s)
+	if err != nil {
+		return "", err // COVERAGE: can't happen for a Struct that was parsed from JSON
+	}
+	return base64.StdEncoding.EncodeToString(protoData), nil
+}
+
+func decodeContext(encoded string) (ldcontext.Context, error) {
+	protoData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ldcontext.Context{}, err
+	}
+	var s structpb.Struct
+	if err := proto.Unmarshal(protoData, &s); err != nil {
+		return ldcontext.Context{}, err
+	}
+	jsonData, err := protojson.Marshal(&s)
+	if err != nil {
+		return ldcontext.Context{}, err // COVERAGE: can't happen for a Struct that was unmarshaled successfully
+	}
+	var ldCtx ldcontext.Context
+	if err := json.Unmarshal(jsonData, &ldCtx); err != nil {
+		return ldcontext.Context{}, err
+	}
+	return ldCtx, nil
+}
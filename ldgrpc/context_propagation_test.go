@@ -0,0 +1,109 @@
+package ldgrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// receive simulates sending a call with the outgoing metadata of ctx, and returns the incoming context
+// that the server would see.
+func receive(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestPropagateAndExtractContext(t *testing.T) {
+	contexts := map[string]ldcontext.Context{
+		"simple": ldcontext.New("user-key"),
+		"with attributes": ldcontext.NewBuilder("user-key").
+			Name("Lucy").
+			Anonymous(true).
+			SetValue("age", ldvalue.Int(40)).
+			SetValue("groups", ldvalue.ArrayOf(ldvalue.String("a"), ldvalue.String("b"))).
+			SetValue("address", ldvalue.ObjectBuild().SetString("city", "Oakland").Build()).
+			Private("age", "/address/city").
+			Build(),
+		"multi-kind": ldcontext.NewMulti(
+			ldcontext.New("user-key"),
+			ldcontext.NewBuilder("org-key").Kind("org").Name("Acme").Build(),
+		),
+	}
+	for name, ldCtx := range contexts {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, ldCtx.Err())
+			ctx := PropagateContext(context.Background(), ldCtx)
+
+			extracted, ok := ExtractContext(receive(ctx))
+			require.True(t, ok)
+			assert.Equal(t, ldCtx, extracted)
+		})
+	}
+}
+
+func TestPropagateContextEncodesStructAsBase64Protobuf(t *testing.T) {
+	ctx := PropagateContext(context.Background(), ldcontext.NewBuilder("user-key").Name("Lucy").Build())
+	md, _ := metadata.FromOutgoingContext(ctx)
+	values := md.Get(ContextMetadataKey)
+	require.Len(t, values, 1)
+
+	data, err := base64.StdEncoding.DecodeString(values[0])
+	require.NoError(t, err)
+	var s structpb.Struct
+	require.NoError(t, proto.Unmarshal(data, &s))
+	assert.Equal(t, map[string]interface{}{"kind": "user", "key": "user-key", "name": "Lucy"}, s.AsMap())
+}
+
+func TestPropagateContextKeepsOtherMetadata(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "other", "value")
+	ctx = PropagateContext(ctx, ldcontext.New("first-key"))
+	ctx = PropagateContext(ctx, ldcontext.New("second-key"))
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	assert.Equal(t, []string{"value"}, md.Get("other"))
+	assert.Len(t, md.Get(ContextMetadataKey), 1)
+	extracted, ok := ExtractContext(receive(ctx))
+	require.True(t, ok)
+	assert.Equal(t, ldcontext.New("second-key"), extracted)
+}
+
+func TestPropagateInvalidContextDoesNothing(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, PropagateContext(ctx, ldcontext.New("")))
+}
+
+func TestExtractContextFailures(t *testing.T) {
+	t.Run("no metadata", func(t *testing.T) {
+		_, ok := ExtractContext(context.Background())
+		assert.False(t, ok)
+	})
+
+	for name, value := range map[string]string{
+		"not base64":          "?",
+		"not protobuf":        base64.StdEncoding.EncodeToString([]byte{0xff, 0xff}),
+		"not a valid context": base64.StdEncoding.EncodeToString(mustMarshalStruct(t, map[string]interface{}{"kind": "user"})),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ContextMetadataKey, value))
+			_, ok := ExtractContext(ctx)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func mustMarshalStruct(t *testing.T, m map[string]interface{}) []byte {
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	data, err := proto.Marshal(s)
+	require.NoError(t, err)
+	return data
+}
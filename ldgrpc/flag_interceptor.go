@@ -0,0 +1,63 @@
+package ldgrpc
+
+import (
+	"context"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+
+	"google.golang.org/grpc"
+)
+
+// FlagConfig describes a flag that [UnaryFlagInterceptor] evaluates.
+type FlagConfig struct {
+	// FlagKey is the key of the flag. The result is stored in the call's context under this key.
+	FlagKey string
+
+	// DefaultValue is the value to use if the flag cannot be evaluated.
+	DefaultValue ldvalue.Value
+
+	// ContextExtractor returns the evaluation context to evaluate the flag for. It receives the call's
+	// context, from which it can get the incoming metadata with metadata.FromIncomingContext, and the
+	// request message.
+	ContextExtractor func(ctx context.Context, req interface{}) ldcontext.Context
+}
+
+// flagContextKey is the type of the context keys that the interceptor uses, so that they cannot collide
+// with string keys from any other package.
+type flagContextKey string
+
+// UnaryFlagInterceptor creates a gRPC interceptor that evaluates flags for each unary call, and makes
+// the results available to the handler through the call's context.
+//
+// Each flag is evaluated as if by JSONVariation, for the evaluation context that its ContextExtractor
+// returns. The handler can get the value by calling [FlagFromContext] with the flag key. If an evaluation
+// fails, for instance because the flag does not exist, the value is the flag's DefaultValue. The client
+// parameter is normally an *ldclient.LDClient.
+//
+//	server := grpc.NewServer(grpc.UnaryInterceptor(ldgrpc.UnaryFlagInterceptor(client, []ldgrpc.FlagConfig{
+//	    {FlagKey: "new-search", DefaultValue: ldvalue.Bool(false), ContextExtractor: contextFromMetadata},
+//	})))
+func UnaryFlagInterceptor(client interfaces.ReadonlyLDClient, flagConfigs []FlagConfig) grpc.UnaryServerInterceptor {
+	configs := append([]FlagConfig(nil), flagConfigs...)
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		for _, fc := range configs {
+			value, _ := client.JSONVariation(fc.FlagKey, fc.ContextExtractor(ctx, req), fc.DefaultValue)
+			ctx = context.WithValue(ctx, flagContextKey(fc.FlagKey), value)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// FlagFromContext returns the value of a flag that [UnaryFlagInterceptor] stored in a call's context.
+// The second return value is false, and the value is null, if the interceptor did not evaluate that flag.
+func FlagFromContext(ctx context.Context, flagKey string) (ldvalue.Value, bool) {
+	value, ok := ctx.Value(flagContextKey(flagKey)).(ldvalue.Value)
+	return value, ok
+}
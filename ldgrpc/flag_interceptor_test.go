@@ -0,0 +1,148 @@
+package ldgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldclient "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func makeTestClient(t *testing.T, data *ldtestdata.TestDataSource) *ldclient.LDClient {
+	config := ldclient.Config{
+		DataSource: data,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.NoLogging(),
+	}
+	client, err := ldclient.MakeCustomClient("sdk_key", config, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func contextFromMetadata(ctx context.Context, req interface{}) ldcontext.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get("user-key"); len(keys) != 0 {
+		return ldcontext.New(keys[0])
+	}
+	return ldcontext.New("")
+}
+
+func callInterceptor(
+	interceptor grpc.UnaryServerInterceptor,
+	ctx context.Context,
+	req interface{},
+) (handlerCtx context.Context, resp interface{}, err error) {
+	resp, err = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerCtx = ctx
+			return "response", nil
+		})
+	return
+}
+
+func TestUnaryFlagInterceptorEvaluatesFlagsForEachCall(t *testing.T) {
+	data := ldtestdata.DataSource()
+	data.Update(data.Flag("bool-flag").BooleanFlag().VariationForUser("user-a", true).FallthroughVariation(false))
+	data.Update(data.Flag("string-flag").ValueForAll(ldvalue.String("on")))
+	client := makeTestClient(t, data)
+
+	interceptor := UnaryFlagInterceptor(client, []FlagConfig{
+		{FlagKey: "bool-flag", DefaultValue: ldvalue.Bool(false), ContextExtractor: contextFromMetadata},
+		{FlagKey: "string-flag", DefaultValue: ldvalue.String("off"), ContextExtractor: contextFromMetadata},
+	})
+
+	for _, p := range []struct {
+		userKey  string
+		expected ldvalue.Value
+	}{
+		{"user-a", ldvalue.Bool(true)},
+		{"user-b", ldvalue.Bool(false)},
+	} {
+		t.Run(p.userKey, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-key", p.userKey))
+			handlerCtx, resp, err := callInterceptor(interceptor, ctx, "request")
+			require.NoError(t, err)
+			assert.Equal(t, "response", resp)
+
+			value, ok := FlagFromContext(handlerCtx, "bool-flag")
+			assert.True(t, ok)
+			assert.Equal(t, p.expected, value)
+			value, ok = FlagFromContext(handlerCtx, "string-flag")
+			assert.True(t, ok)
+			assert.Equal(t, ldvalue.String("on"), value)
+
+			md, _ := metadata.FromIncomingContext(handlerCtx)
+			assert.Equal(t, []string{p.userKey}, md.Get("user-key"))
+		})
+	}
+}
+
+func TestUnaryFlagInterceptorPassesRequestToContextExtractor(t *testing.T) {
+	data := ldtestdata.DataSource()
+	data.Update(data.Flag("flag").BooleanFlag().VariationForUser("user-a", true).FallthroughVariation(false))
+	client := makeTestClient(t, data)
+
+	interceptor := UnaryFlagInterceptor(client, []FlagConfig{
+		{
+			FlagKey:      "flag",
+			DefaultValue: ldvalue.Bool(false),
+			ContextExtractor: func(ctx context.Context, req interface{}) ldcontext.Context {
+				return ldcontext.New(req.(string))
+			},
+		},
+	})
+
+	handlerCtx, _, err := callInterceptor(interceptor, context.Background(), "user-a")
+	require.NoError(t, err)
+	value, _ := FlagFromContext(handlerCtx, "flag")
+	assert.Equal(t, ldvalue.Bool(true), value)
+}
+
+func TestUnaryFlagInterceptorUsesDefaultValueIfEvaluationFails(t *testing.T) {
+	data := ldtestdata.DataSource()
+	data.Update(data.Flag("flag").BooleanFlag())
+	client := makeTestClient(t, data)
+
+	interceptor := UnaryFlagInterceptor(client, []FlagConfig{
+		{FlagKey: "unknown-flag", DefaultValue: ldvalue.String("default"), ContextExtractor: contextFromMetadata},
+		{FlagKey: "flag", DefaultValue: ldvalue.String("default"), ContextExtractor: contextFromMetadata},
+	})
+
+	// no metadata, so the evaluation context is invalid
+	handlerCtx, _, err := callInterceptor(interceptor, context.Background(), "request")
+	require.NoError(t, err)
+
+	value, ok := FlagFromContext(handlerCtx, "unknown-flag")
+	assert.True(t, ok)
+	assert.Equal(t, ldvalue.String("default"), value)
+	value, ok = FlagFromContext(handlerCtx, "flag")
+	assert.True(t, ok)
+	assert.Equal(t, ldvalue.String("default"), value)
+}
+
+func TestUnaryFlagInterceptorReturnsHandlerError(t *testing.T) {
+	client := makeTestClient(t, ldtestdata.DataSource())
+	interceptor := UnaryFlagInterceptor(client, nil)
+
+	handlerErr := assert.AnError
+	_, err := interceptor(context.Background(), "request", &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, handlerErr
+		})
+	assert.Equal(t, handlerErr, err)
+}
+
+func TestFlagFromContextForFlagThatWasNotEvaluated(t *testing.T) {
+	value, ok := FlagFromContext(context.Background(), "flag")
+	assert.False(t, ok)
+	assert.Equal(t, ldvalue.Null(), value)
+}
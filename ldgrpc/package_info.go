@@ -0,0 +1,8 @@
+// Package ldgrpc provides gRPC helpers for evaluating feature flags in services: a server interceptor that
+// evaluates flags for each incoming call, and functions for passing an evaluation context from one service
+// to another in the call metadata.
+//
+// Like the HTTP middleware in [github.com/launchdarkly/go-server-sdk/v7/ldhttp], this saves every method
+// of a service from having to evaluate the same flags itself. It is a separate Go module, so that
+// applications that do not use gRPC do not get it as a dependency of the SDK.
+package ldgrpc
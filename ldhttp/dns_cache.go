@@ -0,0 +1,105 @@
+package ldhttp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsCache wraps a dialer so that resolved addresses are reused for the configured TTL. When an entry
+// expires, the next connection still uses the old addresses while a background lookup refreshes them;
+// if that lookup fails, the old addresses are kept. This keeps an intermittently failing DNS server from
+// causing connection failures, as long as the previously resolved addresses are still valid.
+type dnsCache struct {
+	ttl        time.Duration
+	dialer     *net.Dialer
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	entries    map[string]*dnsCacheEntry
+	lock       sync.Mutex
+}
+
+type dnsCacheEntry struct {
+	addrs      []string
+	expires    time.Time
+	refreshing bool
+}
+
+func newDNSCache(ttl time.Duration, dialer *net.Dialer) *dnsCache {
+	return &dnsCache{
+		ttl:        ttl,
+		dialer:     dialer,
+		lookupHost: net.DefaultResolver.LookupHost,
+		entries:    make(map[string]*dnsCacheEntry),
+	}
+}
+
+func (c *dnsCache) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := c.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 { // the system resolver reports this as an error, but lookupHost might not
+		return nil, &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+	}
+	var conn net.Conn
+	for _, addr := range addrs {
+		conn, err = c.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[host]
+	if ok {
+		if !entry.refreshing && time.Now().After(entry.expires) {
+			entry.refreshing = true
+			go c.refresh(host)
+		}
+		addrs := entry.addrs
+		c.lock.Unlock()
+		return addrs, nil
+	}
+	c.lock.Unlock()
+
+	addrs, err := c.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.store(host, addrs)
+	return addrs, nil
+}
+
+func (c *dnsCache) refresh(host string) {
+	timeout := c.dialer.Timeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := c.lookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		c.lock.Lock()
+		if entry, ok := c.entries[host]; ok {
+			entry.refreshing = false
+			entry.expires = time.Now().Add(c.ttl) // keep using the old addresses for now
+		}
+		c.lock.Unlock()
+		return
+	}
+	c.store(host, addrs)
+}
+
+func (c *dnsCache) store(host string, addrs []string) {
+	c.lock.Lock()
+	c.entries[host] = &dnsCacheEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.lock.Unlock()
+}
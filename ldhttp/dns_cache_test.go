@@ -0,0 +1,125 @@
+package ldhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	addrs   []string
+	err     error
+	lookups chan string
+	lock    sync.Mutex
+}
+
+func (r *fakeResolver) lookupHost(ctx context.Context, host string) ([]string, error) {
+	r.lock.Lock()
+	addrs, err := r.addrs, r.err
+	r.lock.Unlock()
+	r.lookups <- host
+	return addrs, err
+}
+
+func (r *fakeResolver) fail(err error) {
+	r.lock.Lock()
+	r.err = err
+	r.lock.Unlock()
+}
+
+func TestDNSCache(t *testing.T) {
+	httphelpers.WithServer(httphelpers.HandlerWithStatus(200), func(server *httptest.Server) {
+		serverURL, _ := url.Parse(server.URL)
+		_, port, _ := net.SplitHostPort(serverURL.Host)
+		fakeHostURL := "http://fake-host:" + port
+
+		makeClient := func(ttl time.Duration) (*http.Client, *fakeResolver) {
+			transport, dialer, err := NewHTTPTransport()
+			require.NoError(t, err)
+			resolver := &fakeResolver{addrs: []string{"127.0.0.1"}, lookups: make(chan string, 10)}
+			cache := newDNSCache(ttl, dialer)
+			cache.lookupHost = resolver.lookupHost
+			transport.DialContext = cache.dialContext
+			transport.DisableKeepAlives = true // so that every request needs a new connection
+			return &http.Client{Transport: transport}, resolver
+		}
+
+		get := func(t *testing.T, client *http.Client) error {
+			resp, err := client.Get(fakeHostURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}
+
+		t.Run("addresses are reused within TTL", func(t *testing.T) {
+			client, resolver := makeClient(time.Hour)
+			require.NoError(t, get(t, client))
+			require.NoError(t, get(t, client))
+			assert.Equal(t, "fake-host", <-resolver.lookups)
+			assert.Len(t, resolver.lookups, 0)
+		})
+
+		t.Run("initial lookup failure is returned", func(t *testing.T) {
+			client, resolver := makeClient(time.Hour)
+			resolver.fail(errors.New("no DNS today"))
+			assert.Error(t, get(t, client))
+		})
+
+		t.Run("expired addresses are used while refreshing in background", func(t *testing.T) {
+			client, resolver := makeClient(time.Millisecond)
+			require.NoError(t, get(t, client))
+			<-resolver.lookups
+
+			time.Sleep(time.Millisecond * 10)
+			resolver.fail(errors.New("no DNS today"))
+			require.NoError(t, get(t, client)) // served from the cache despite the lookup failure
+			select {
+			case <-resolver.lookups:
+			case <-time.After(time.Second):
+				assert.Fail(t, "timed out waiting for background refresh")
+			}
+			require.NoError(t, get(t, client))
+		})
+
+		t.Run("empty lookup result is an error", func(t *testing.T) {
+			client, resolver := makeClient(time.Hour)
+			resolver.addrs = nil
+			err := get(t, client)
+			var dnsErr *net.DNSError
+			require.True(t, errors.As(err, &dnsErr))
+			assert.Equal(t, "fake-host", dnsErr.Name)
+		})
+
+		t.Run("IP addresses are not looked up", func(t *testing.T) {
+			client, resolver := makeClient(time.Hour)
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Len(t, resolver.lookups, 0)
+		})
+	})
+}
+
+func TestDNSCacheTTLOption(t *testing.T) {
+	transport, _, err := NewHTTPTransport(DNSCacheTTLOption(time.Minute))
+	require.NoError(t, err)
+	httphelpers.WithServer(httphelpers.HandlerWithStatus(200), func(server *httptest.Server) {
+		client := &http.Client{Transport: transport}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+}
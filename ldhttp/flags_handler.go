@@ -0,0 +1,95 @@
+package ldhttp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+)
+
+// NewAllFlagsStateHandler creates an HTTP handler that responds with the state of all flags for an
+// evaluation context, as returned by AllFlagsState, in JSON. This is the same format that front-end
+// JavaScript SDKs accept for bootstrapping flag values.
+//
+// The client parameter is normally an *ldclient.LDClient. The context for each request is whatever
+// contextExtractor returns for it, such as a context created by
+// [github.com/launchdarkly/go-server-sdk/v7/ldhttp/ldhttpcontext.FromHTTPRequest], and the options are passed
+// to AllFlagsState: for instance, use [flagstate.OptionClientSideOnly] if the response is going to a browser.
+//
+//	http.Handle("/flags", ldhttp.NewAllFlagsStateHandler(client, getContext, flagstate.OptionClientSideOnly()))
+//
+// Responses have the header "Cache-Control: no-cache", since flag values can change at any time. If the
+// client is not initialized, the handler responds with status 503 instead; if the extracted context is
+// not valid, it responds with status 400.
+func NewAllFlagsStateHandler(
+	client interfaces.ReadonlyLDClient,
+	contextExtractor func(*http.Request) ldcontext.Context,
+	options ...flagstate.Option,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		if !client.Initialized() {
+			http.Error(w, "LaunchDarkly client is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		context := contextExtractor(r)
+		if err := context.Err(); err != nil {
+			http.Error(w, "invalid evaluation context: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, err := json.Marshal(client.AllFlagsState(context, options...))
+		if err != nil {
+			// COVERAGE: AllFlags can always be marshaled, so this can't happen in unit tests
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
+
+// flagContextKey is the type of the request context keys that FlagMiddleware uses, so that they cannot
+// collide with string keys from any other package.
+type flagContextKey string
+
+// FlagMiddleware creates HTTP middleware that evaluates a flag for each request, and makes the result
+// available to the next handler through the request's context.
+//
+// The flag is evaluated as if by JSONVariation, for the evaluation context that contextExtractor returns
+// for the request. The next handler, and anything else that gets the request's context, can then get the
+// value by calling [FlagFromContext] with the same contextKey. If the evaluation fails, for instance
+// because the flag does not exist, the value is defaultValue.
+//
+//	handler := ldhttp.FlagMiddleware(client, "new-checkout", ldvalue.Bool(false), "checkout", getContext)(
+//	    http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        value, _ := ldhttp.FlagFromContext(r.Context(), "checkout")
+//	        if value.BoolValue() {
+//	            // ...
+//	        }
+//	    }))
+func FlagMiddleware(
+	client interfaces.ReadonlyLDClient,
+	flagKey string,
+	defaultValue ldvalue.Value,
+	contextKey string,
+	contextExtractor func(*http.Request) ldcontext.Context,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, _ := client.JSONVariation(flagKey, contextExtractor(r), defaultValue)
+			ctx := gocontext.WithValue(r.Context(), flagContextKey(contextKey), value)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FlagFromContext returns the flag value that [FlagMiddleware] stored in a request context under
+// contextKey. The second return value is false, and the value is null, if there is no such value.
+func FlagFromContext(ctx gocontext.Context, contextKey string) (ldvalue.Value, bool) {
+	value, ok := ctx.Value(flagContextKey(contextKey)).(ldvalue.Value)
+	return value, ok
+}
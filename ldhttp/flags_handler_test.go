@@ -0,0 +1,138 @@
+package ldhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFlagsClient implements only the ReadonlyLDClient methods that the handlers use.
+type fakeFlagsClient struct {
+	interfaces.ReadonlyLDClient
+	initialized bool
+	values      map[string]ldvalue.Value
+	contexts    []ldcontext.Context
+	options     []flagstate.Option
+}
+
+func (c *fakeFlagsClient) Initialized() bool { return c.initialized }
+
+func (c *fakeFlagsClient) AllFlagsState(context ldcontext.Context, options ...flagstate.Option) flagstate.AllFlags {
+	c.contexts = append(c.contexts, context)
+	c.options = options
+	b := flagstate.NewAllFlagsBuilder(options...)
+	for key, value := range c.values {
+		b.AddFlag(key, flagstate.FlagState{Value: value, Variation: ldvalue.NewOptionalInt(0), Version: 1})
+	}
+	return b.Build()
+}
+
+func (c *fakeFlagsClient) JSONVariation(key string, context ldcontext.Context, defaultVal ldvalue.Value) (
+	ldvalue.Value, error) {
+	c.contexts = append(c.contexts, context)
+	if value, ok := c.values[key]; ok {
+		return value, nil
+	}
+	return defaultVal, errors.New("unknown flag")
+}
+
+func contextFromQuery(r *http.Request) ldcontext.Context {
+	return ldcontext.New(r.URL.Query().Get("key"))
+}
+
+func TestAllFlagsStateHandler(t *testing.T) {
+	t.Run("serves flag state for the context", func(t *testing.T) {
+		client := &fakeFlagsClient{initialized: true, values: map[string]ldvalue.Value{"flag1": ldvalue.Bool(true)}}
+		handler := NewAllFlagsStateHandler(client, contextFromQuery, flagstate.OptionClientSideOnly())
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/flags?key=user1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		body := ldvalue.Parse(w.Body.Bytes())
+		assert.Equal(t, ldvalue.Bool(true), body.GetByKey("flag1"))
+		assert.Equal(t, ldvalue.Bool(true), body.GetByKey("$valid"))
+		require.Len(t, client.contexts, 1)
+		assert.Equal(t, ldcontext.New("user1"), client.contexts[0])
+		assert.Equal(t, []flagstate.Option{flagstate.OptionClientSideOnly()}, client.options)
+	})
+
+	t.Run("returns 503 if client is not initialized", func(t *testing.T) {
+		client := &fakeFlagsClient{}
+		handler := NewAllFlagsStateHandler(client, contextFromQuery)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/flags?key=user1", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		assert.Len(t, client.contexts, 0)
+	})
+
+	t.Run("returns 400 for invalid context", func(t *testing.T) {
+		client := &fakeFlagsClient{initialized: true}
+		handler := NewAllFlagsStateHandler(client, contextFromQuery)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/flags", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Len(t, client.contexts, 0)
+	})
+}
+
+func TestFlagMiddleware(t *testing.T) {
+	client := &fakeFlagsClient{initialized: true, values: map[string]ldvalue.Value{"flag1": ldvalue.String("on")}}
+
+	serve := func(flagKey string) (ldvalue.Value, bool) {
+		var value ldvalue.Value
+		var ok bool
+		middleware := FlagMiddleware(client, flagKey, ldvalue.String("default"), "my-flag", contextFromQuery)
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, ok = FlagFromContext(r.Context(), "my-flag")
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/page?key=user1", nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		return value, ok
+	}
+
+	t.Run("stores flag value in request context", func(t *testing.T) {
+		client.contexts = nil
+		value, ok := serve("flag1")
+		assert.True(t, ok)
+		assert.Equal(t, ldvalue.String("on"), value)
+		assert.Equal(t, []ldcontext.Context{ldcontext.New("user1")}, client.contexts)
+	})
+
+	t.Run("stores default value if evaluation fails", func(t *testing.T) {
+		value, ok := serve("unknown-flag")
+		assert.True(t, ok)
+		assert.Equal(t, ldvalue.String("default"), value)
+	})
+
+	t.Run("FlagFromContext without a value", func(t *testing.T) {
+		value, ok := FlagFromContext(context.Background(), "my-flag")
+		assert.False(t, ok)
+		assert.Equal(t, ldvalue.Null(), value)
+	})
+
+	t.Run("key does not collide with a plain string key", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "my-flag", ldvalue.String("x")) //nolint:staticcheck
+		_, ok := FlagFromContext(ctx, "my-flag")
+		assert.False(t, ok)
+	})
+}
@@ -1,7 +1,8 @@
-// Package ldhttp provides internal helper functions for custom HTTP configuration.
+// Package ldhttp provides internal helper functions for custom HTTP configuration, and HTTP handlers for
+// serving flag data from an application's own web server.
 //
-// Applications will not normally need to use this package. Use the HTTP configuration options provided by
-// ldcomponents.HTTPConfiguration() instead.
+// Applications will not normally need to use the HTTP configuration functions in this package. Use the HTTP
+// configuration options provided by ldcomponents.HTTPConfiguration() instead.
 package ldhttp
 
 import (
@@ -20,7 +21,9 @@ const defaultConnectTimeout = 10 * time.Second
 
 type transportExtraOptions struct {
 	caCerts        *x509.CertPool
+	clientCerts    []tls.Certificate
 	connectTimeout time.Duration
+	dnsCacheTTL    time.Duration
 	proxyURL       *url.URL
 }
 
@@ -44,6 +47,22 @@ func ConnectTimeoutOption(timeout time.Duration) TransportOption {
 	return connectTimeoutOption{timeout: timeout}
 }
 
+type dnsCacheTTLOption struct {
+	ttl time.Duration
+}
+
+func (o dnsCacheTTLOption) apply(opts *transportExtraOptions) error {
+	opts.dnsCacheTTL = o.ttl
+	return nil
+}
+
+// DNSCacheTTLOption specifies that resolved IP addresses should be cached for the given length of time,
+// when used with NewHTTPTransport. After that, they are refreshed in the background while connections
+// continue to use the cached addresses. A value of zero or less disables caching.
+func DNSCacheTTLOption(ttl time.Duration) TransportOption {
+	return dnsCacheTTLOption{ttl: ttl}
+}
+
 type caCertOption struct {
 	certData []byte
 }
@@ -86,6 +105,26 @@ func CACertFileOption(filePath string) TransportOption {
 	return caCertFileOption{filePath: filePath}
 }
 
+type clientCertificateOption struct {
+	certData []byte
+	keyData  []byte
+}
+
+func (o clientCertificateOption) apply(opts *transportExtraOptions) error {
+	cert, err := tls.X509KeyPair(o.certData, o.keyData)
+	if err != nil {
+		return fmt.Errorf("invalid client certificate or key data: %v", err)
+	}
+	opts.clientCerts = append(opts.clientCerts, cert)
+	return nil
+}
+
+// ClientCertificateOption specifies a client certificate and private key, both in PEM format, to be
+// presented to the server for TLS mutual authentication, when used with NewHTTPTransport.
+func ClientCertificateOption(certData, keyData []byte) TransportOption {
+	return clientCertificateOption{certData: certData, keyData: keyData}
+}
+
 // ProxyOption specifies a proxy URL to be used for all requests, when used with NewHTTPTransport.
 // This overrides any setting of the HTTP_PROXY, HTTPS_PROXY, or NO_PROXY environment variables.
 func ProxyOption(url url.URL) TransportOption {
@@ -122,8 +161,14 @@ func NewHTTPTransport(options ...TransportOption) (*http.Transport, *net.Dialer,
 	}
 	transport := newDefaultTransport()
 	transport.DialContext = dialer.DialContext
-	if extraOptions.caCerts != nil {
-		transport.TLSClientConfig = &tls.Config{RootCAs: extraOptions.caCerts} //nolint:gosec // not setting TLS.MinVersion
+	if extraOptions.dnsCacheTTL > 0 {
+		transport.DialContext = newDNSCache(extraOptions.dnsCacheTTL, dialer).dialContext
+	}
+	if extraOptions.caCerts != nil || len(extraOptions.clientCerts) != 0 {
+		transport.TLSClientConfig = &tls.Config{ //nolint:gosec // not setting TLS.MinVersion
+			RootCAs:      extraOptions.caCerts,
+			Certificates: extraOptions.clientCerts,
+		}
 	}
 	if extraOptions.proxyURL != nil {
 		transport.Proxy = http.ProxyURL(extraOptions.proxyURL)
@@ -1,13 +1,21 @@
 package ldhttp
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,3 +95,64 @@ func TestCanSetProxyURL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, url, urlOut)
 }
+
+func TestClientCertificateIsSentToServer(t *testing.T) {
+	certPEM, keyPEM := makeClientCertificate(t)
+
+	var receivedCerts int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedCerts = len(r.TLS.PeerCertificates)
+		w.WriteHeader(200)
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert} //nolint:gosec // test server
+	server.StartTLS()
+	defer server.Close()
+	serverCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	t.Run("request fails without client certificate", func(t *testing.T) {
+		transport, _, err := NewHTTPTransport(CACertOption(serverCertPEM))
+		require.NoError(t, err)
+
+		client := *http.DefaultClient
+		client.Transport = transport
+		_, err = client.Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("request succeeds with client certificate", func(t *testing.T) {
+		transport, _, err := NewHTTPTransport(CACertOption(serverCertPEM), ClientCertificateOption(certPEM, keyPEM))
+		require.NoError(t, err)
+
+		client := *http.DefaultClient
+		client.Transport = transport
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 1, receivedCerts)
+	})
+}
+
+func TestErrorForBadClientCertificateData(t *testing.T) {
+	_, _, err := NewHTTPTransport(ClientCertificateOption([]byte("sorry"), []byte("sorry")))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid client certificate or key data")
+}
+
+func makeClientCertificate(t *testing.T) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
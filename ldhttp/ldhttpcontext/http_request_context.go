@@ -0,0 +1,103 @@
+package ldhttpcontext
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+// These are the names of the context attributes that FromHTTPRequest sets.
+const (
+	// IPAttribute is the attribute for the client's IP address.
+	IPAttribute = "ip"
+	// CountryAttribute is the attribute for the region subtag of the client's preferred language.
+	CountryAttribute = "country"
+	// UserAgentAttribute is the attribute for the User-Agent header.
+	UserAgentAttribute = "userAgent"
+	// RefererAttribute is the attribute for the Referer header.
+	RefererAttribute = "referer"
+)
+
+// FromHTTPRequest creates an evaluation context describing the client that made an HTTP request.
+//
+// The context key is whatever keyFn returns for the request. The application would normally use keyFn
+// to get the key from a session cookie or from the claims of an authentication token. If the key is
+// empty, the returned context is invalid and its Err method returns an error.
+//
+// The context has the default kind "user" and the following attributes, each of which is omitted if the
+// request does not provide a value for it:
+//   - "ip": the first address in the X-Forwarded-For header, or else the host part of the request's
+//     RemoteAddr.
+//   - "country": the region subtag, such as "US", of the first language tag in the Accept-Language
+//     header.
+//   - "userAgent": the User-Agent header.
+//   - "referer": the Referer header.
+func FromHTTPRequest(r *http.Request, keyFn func(*http.Request) string) ldcontext.Context {
+	builder := ldcontext.NewBuilder(keyFn(r))
+	setIfNotEmpty(builder, IPAttribute, clientIP(r))
+	setIfNotEmpty(builder, CountryAttribute, regionFromAcceptLanguage(r.Header.Get("Accept-Language")))
+	setIfNotEmpty(builder, UserAgentAttribute, r.UserAgent())
+	setIfNotEmpty(builder, RefererAttribute, r.Referer())
+	return builder.Build()
+}
+
+func setIfNotEmpty(builder *ldcontext.Builder, name, value string) {
+	if value != "" {
+		builder.SetString(name, value)
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// regionFromAcceptLanguage returns the region subtag of the first language tag in an Accept-Language
+// header. Per BCP 47, the region is the first subtag after the language that consists of two letters or
+// three digits; extended language and script subtags may come before it, as in "zh-Hant-TW".
+func regionFromAcceptLanguage(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	tag, _, _ := strings.Cut(first, ";")
+	subtags := strings.Split(strings.TrimSpace(tag), "-")
+	for _, subtag := range subtags[1:] {
+		switch {
+		case len(subtag) == 2 && isAlpha(subtag):
+			return strings.ToUpper(subtag)
+		case len(subtag) == 3 && isDigits(subtag):
+			return subtag
+		case (len(subtag) == 3 || len(subtag) == 4) && isAlpha(subtag):
+			continue // extended language or script subtag
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isAlpha(s string) bool {
+	for _, ch := range s {
+		if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
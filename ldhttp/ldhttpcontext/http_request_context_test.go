@@ -0,0 +1,73 @@
+package ldhttpcontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keyFromCookie(r *http.Request) string {
+	if c, err := r.Cookie("session"); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+func TestFromHTTPRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/page", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "session-key"})
+	r.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	r.Header.Set("User-Agent", "test-agent")
+	r.Header.Set("Referer", "https://example.com/")
+
+	c := FromHTTPRequest(r, keyFromCookie)
+
+	assert.NoError(t, c.Err())
+	assert.Equal(t, ldcontext.DefaultKind, c.Kind())
+	assert.Equal(t, "session-key", c.Key())
+	assert.Equal(t, ldvalue.String("203.0.113.5"), c.GetValue(IPAttribute))
+	assert.Equal(t, ldvalue.String("US"), c.GetValue(CountryAttribute))
+	assert.Equal(t, ldvalue.String("test-agent"), c.GetValue(UserAgentAttribute))
+	assert.Equal(t, ldvalue.String("https://example.com/"), c.GetValue(RefererAttribute))
+}
+
+func TestFromHTTPRequestWithoutOptionalHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/page", nil)
+	r.RemoteAddr = "198.51.100.7:4321"
+
+	c := FromHTTPRequest(r, func(*http.Request) string { return "key" })
+
+	assert.NoError(t, c.Err())
+	assert.Equal(t, ldvalue.String("198.51.100.7"), c.GetValue(IPAttribute))
+	assert.Equal(t, []string{IPAttribute}, c.GetOptionalAttributeNames(nil))
+}
+
+func TestFromHTTPRequestWithEmptyKeyIsInvalid(t *testing.T) {
+	r := httptest.NewRequest("GET", "/page", nil)
+	assert.Error(t, FromHTTPRequest(r, keyFromCookie).Err())
+}
+
+func TestRegionFromAcceptLanguage(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                "",
+		"en":              "",
+		"en-gb":           "GB",
+		"fr-CA;q=0.8, en": "CA",
+		"zh-Hant-TW":      "TW",
+		"zh-yue-HK":       "HK",
+		"es-419":          "419",
+		"*":               "",
+		"en-x-private":    "",
+		"de-DE-1996":      "DE",
+	} {
+		t.Run(header, func(t *testing.T) {
+			assert.Equal(t, expected, regionFromAcceptLanguage(header))
+		})
+	}
+}
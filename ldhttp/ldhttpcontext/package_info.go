@@ -0,0 +1,5 @@
+// Package ldhttpcontext builds evaluation contexts from incoming HTTP requests.
+//
+// Server applications often evaluate flags for the visitor who made the current request. [FromHTTPRequest]
+// creates an [ldcontext.Context] for that visitor, with attributes taken from standard request headers.
+package ldhttpcontext
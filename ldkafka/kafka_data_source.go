@@ -0,0 +1,280 @@
+package ldkafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+)
+
+// KafkaDataSourceOption is an optional parameter for [NewKafkaDataSourceFactory].
+type KafkaDataSourceOption func(*kafkaDataSourceFactory)
+
+// WithClientOptions adds options for the franz-go Kafka client, such as kgo.SASL or kgo.DialTLSConfig
+// for connecting to a secured cluster. Options that control which records are consumed, such as
+// kgo.ConsumerGroup, should not be used.
+func WithClientOptions(opts ...kgo.Opt) KafkaDataSourceOption {
+	return func(f *kafkaDataSourceFactory) {
+		f.clientOpts = append(f.clientOpts, opts...)
+	}
+}
+
+type kafkaDataSourceFactory struct {
+	brokers    []string
+	topic      string
+	clientOpts []kgo.Opt
+	newSource  func(brokers []string, topic string, clientOpts []kgo.Opt) (recordSource, error)
+	retryDelay time.Duration
+}
+
+type kafkaDataSource struct {
+	source      recordSource
+	topic       string
+	sink        subsystems.DataSourceUpdateSink
+	loggers     ldlog.Loggers
+	retryDelay  time.Duration
+	initialized bool
+	interrupted bool
+	cancel      context.CancelFunc
+	doneCh      chan struct{}
+	lock        sync.Mutex
+	closeOnce   sync.Once
+}
+
+// NewKafkaDataSourceFactory returns a data source configuration that gets flags and segments from a
+// Kafka topic. Store it in the DataSource field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataSource: ldkafka.NewKafkaDataSourceFactory([]string{"kafka:9092"}, "launchdarkly-flags"),
+//	}
+//
+// Each message's value is one flag or segment, in this format:
+//
+//	{"kind": "features", "key": "my-flag", "item": {"key": "my-flag", "version": 2, ...}}
+//
+// The kind is "features" or "segments". The item is in the JSON format that the SDK uses for flags and
+// segments in persistent data stores; it can also be a string containing that JSON, as in the "item"
+// attribute of the DynamoDB store. To delete an item, send a newer version of it with "deleted": true.
+// Updates with a version that is not newer than the current one are ignored. A message that cannot be
+// parsed is logged and skipped.
+//
+// Every SDK instance needs every flag, so the data source reads all of the topic's partitions itself
+// rather than joining a consumer group, and it starts from the beginning of each partition. The topic
+// should use log compaction, keyed by kind and item key, so that it keeps the current version of every
+// item. The data source is initialized once it has read as far as the end of each partition was when it
+// started; then it replaces the contents of the data store with what it has read, and applies each
+// later message as it arrives. Partitions that are added later are picked up automatically.
+//
+// Closing the client closes the Kafka connection.
+func NewKafkaDataSourceFactory(
+	brokers []string,
+	topic string,
+	opts ...KafkaDataSourceOption,
+) subsystems.ComponentConfigurer[subsystems.DataSource] {
+	f := &kafkaDataSourceFactory{
+		brokers:    brokers,
+		topic:      topic,
+		newSource:  newKgoRecordSource,
+		retryDelay: initialRetryDelay,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *kafkaDataSourceFactory) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
+	if len(f.brokers) == 0 {
+		return nil, errors.New("no Kafka brokers were specified")
+	}
+	if f.topic == "" {
+		return nil, errors.New("no Kafka topic was specified")
+	}
+	source, err := f.newSource(f.brokers, f.topic, f.clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	loggers := context.GetLogging().Loggers
+	loggers.SetPrefix("KafkaDataSource:")
+	return &kafkaDataSource{
+		source:     source,
+		topic:      f.topic,
+		sink:       context.GetDataSourceUpdateSink(),
+		loggers:    loggers,
+		retryDelay: f.retryDelay,
+		doneCh:     make(chan struct{}),
+	}, nil
+}
+
+func (d *kafkaDataSource) IsInitialized() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.initialized
+}
+
+func (d *kafkaDataSource) Start(closeWhenReady chan<- struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.lock.Lock()
+	d.cancel = cancel
+	d.lock.Unlock()
+	go d.run(ctx, closeWhenReady)
+}
+
+// Close is called automatically when the client is closed.
+func (d *kafkaDataSource) Close() error {
+	d.closeOnce.Do(func() {
+		d.lock.Lock()
+		cancel := d.cancel
+		d.lock.Unlock()
+		if cancel != nil {
+			cancel()
+			<-d.doneCh
+		}
+		d.source.close()
+	})
+	return nil
+}
+
+func (d *kafkaDataSource) run(ctx context.Context, closeWhenReady chan<- struct{}) {
+	defer close(d.doneCh)
+
+	pending, ok := d.getEndOffsets(ctx)
+	if !ok {
+		return
+	}
+	// Until the data source is initialized, items are collected here rather than sent to the store.
+	collected := map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor{}
+	for _, kind := range ldstoreimpl.AllKinds() {
+		collected[kind] = map[string]ldstoretypes.ItemDescriptor{}
+	}
+	if len(pending) == 0 && d.initialize(collected, closeWhenReady) {
+		collected = nil
+	}
+
+	for {
+		records, err := d.source.poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		for _, record := range records {
+			d.processRecord(record, collected)
+			if end, ok := pending[record.Partition]; ok && record.Offset >= end-1 {
+				delete(pending, record.Partition)
+			}
+		}
+		if err != nil {
+			d.reportError(err)
+			continue
+		}
+		if collected != nil && len(pending) == 0 {
+			if d.initialize(collected, closeWhenReady) {
+				collected = nil
+			}
+		} else if d.interrupted {
+			d.interrupted = false
+			d.sink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+		}
+	}
+}
+
+// getEndOffsets returns the partitions that have records, with their end offsets, retrying until it
+// succeeds or the data source is closed.
+func (d *kafkaDataSource) getEndOffsets(ctx context.Context) (map[int32]int64, bool) {
+	delay := d.retryDelay
+	for {
+		ends, err := d.source.endOffsets(ctx)
+		if err == nil {
+			pending := make(map[int32]int64)
+			for partition, end := range ends {
+				if end > 0 {
+					pending[partition] = end
+				}
+			}
+			return pending, true
+		}
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		d.reportError(err)
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+func (d *kafkaDataSource) processRecord(
+	record *kgo.Record,
+	collected map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor,
+) {
+	if record.Value == nil {
+		return // a tombstone that marks a key for compaction; it has no item to apply
+	}
+	kind, key, item, err := ldstoreimpl.ParseItemUpdateMessage(record.Value)
+	if err != nil {
+		d.loggers.Errorf("Skipping invalid message at offset %d in partition %d of topic %q: %s",
+			record.Offset, record.Partition, d.topic, err)
+		return
+	}
+	if collected == nil {
+		d.sink.Upsert(kind, key, item)
+		return
+	}
+	if existing, ok := collected[kind][key]; !ok || item.Version > existing.Version {
+		collected[kind][key] = item
+	}
+}
+
+// initialize puts the collected items in the data store, returning false if the store could not be
+// updated, in which case it will be tried again after the next poll.
+func (d *kafkaDataSource) initialize(
+	collected map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor,
+	closeWhenReady chan<- struct{},
+) bool {
+	allData := make([]ldstoretypes.Collection, 0, len(collected))
+	for kind, items := range collected {
+		coll := ldstoretypes.Collection{Kind: kind}
+		for key, item := range items {
+			coll.Items = append(coll.Items, ldstoretypes.KeyedItemDescriptor{Key: key, Item: item})
+		}
+		allData = append(allData, coll)
+	}
+	if !d.sink.Init(allData) {
+		return false
+	}
+	d.lock.Lock()
+	d.initialized = true
+	d.lock.Unlock()
+	d.interrupted = false
+	d.sink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+	close(closeWhenReady)
+	return true
+}
+
+func (d *kafkaDataSource) reportError(err error) {
+	d.loggers.Warnf("Error reading from topic %q: %s", d.topic, err)
+	d.interrupted = true
+	d.sink.UpdateStatus(interfaces.DataSourceStateInterrupted, interfaces.DataSourceErrorInfo{
+		Kind:    interfaces.DataSourceErrorKindNetworkError,
+		Message: err.Error(),
+		Time:    time.Now(),
+	})
+}
@@ -0,0 +1,245 @@
+package ldkafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type fakeBatch struct {
+	records []*kgo.Record
+	err     error
+}
+
+type fakeRecordSource struct {
+	ends       map[int32]int64
+	endsErrors []error
+	batches    chan fakeBatch
+	closedCh   chan struct{}
+}
+
+func newFakeRecordSource(ends map[int32]int64) *fakeRecordSource {
+	return &fakeRecordSource{ends: ends, batches: make(chan fakeBatch, 10), closedCh: make(chan struct{})}
+}
+
+func (s *fakeRecordSource) endOffsets(context.Context) (map[int32]int64, error) {
+	if len(s.endsErrors) > 0 {
+		err := s.endsErrors[0]
+		s.endsErrors = s.endsErrors[1:]
+		return nil, err
+	}
+	return s.ends, nil
+}
+
+func (s *fakeRecordSource) poll(ctx context.Context) ([]*kgo.Record, error) {
+	select {
+	case b := <-s.batches:
+		return b.records, b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *fakeRecordSource) close() {
+	close(s.closedCh)
+}
+
+func (s *fakeRecordSource) send(records ...*kgo.Record) {
+	s.batches <- fakeBatch{records: records}
+}
+
+func flagRecord(partition int32, offset int64, flag ldmodel.FeatureFlag) *kgo.Record {
+	item := datakinds.Features.Serialize(ldstoretypes.ItemDescriptor{Version: flag.Version, Item: &flag})
+	value := `{"kind":"features","key":"` + flag.Key + `","item":` + string(item) + `}`
+	return &kgo.Record{Partition: partition, Offset: offset, Value: []byte(value)}
+}
+
+type dataSourceTestParams struct {
+	source  *fakeRecordSource
+	updates *mocks.MockDataSourceUpdates
+	mockLog *ldlogtest.MockLog
+	ds      subsystems.DataSource
+	readyCh chan struct{}
+}
+
+func withDataSource(t *testing.T, source *fakeRecordSource, action func(p dataSourceTestParams)) {
+	p := dataSourceTestParams{
+		source:  source,
+		updates: mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())),
+		mockLog: ldlogtest.NewMockLog(),
+		readyCh: make(chan struct{}),
+	}
+	factory := NewKafkaDataSourceFactory([]string{"broker:9092"}, "flags").(*kafkaDataSourceFactory)
+	factory.newSource = func([]string, string, []kgo.Opt) (recordSource, error) { return source, nil }
+	factory.retryDelay = time.Millisecond
+	ds, err := factory.Build(subsystems.BasicClientContext{
+		DataSourceUpdateSink: p.updates,
+		Logging:              subsystems.LoggingConfiguration{Loggers: p.mockLog.Loggers},
+	})
+	require.NoError(t, err)
+	p.ds = ds
+	defer ds.Close()
+	ds.Start(p.readyCh)
+	action(p)
+}
+
+func initDataAsMap(collections []ldstoretypes.Collection) map[string]map[string]int {
+	ret := make(map[string]map[string]int)
+	for _, coll := range collections {
+		items := make(map[string]int)
+		for _, item := range coll.Items {
+			items[item.Key] = item.Item.Version
+		}
+		ret[coll.Kind.GetName()] = items
+	}
+	return ret
+}
+
+func TestKafkaDataSourceInitializesAfterReadingToEndOfEachPartition(t *testing.T) {
+	source := newFakeRecordSource(map[int32]int64{0: 2, 1: 1, 2: 0})
+	withDataSource(t, source, func(p dataSourceTestParams) {
+		segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(5).Build()
+		segmentItem := datakinds.Segments.Serialize(ldstoretypes.ItemDescriptor{Version: 5, Item: &segment})
+		p.source.send(
+			flagRecord(0, 0, ldbuilders.NewFlagBuilder("flag1").Version(1).Build()),
+			&kgo.Record{Partition: 1, Offset: 0,
+				Value: []byte(`{"kind":"segments","key":"segmentkey","item":` + string(segmentItem) + `}`)},
+		)
+		p.source.send(flagRecord(0, 1, ldbuilders.NewFlagBuilder("flag2").Version(1).Build()))
+
+		inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+		assert.Equal(t, map[string]map[string]int{
+			"features": {"flag1": 1, "flag2": 1},
+			"segments": {"segmentkey": 5},
+		}, initDataAsMap(inited))
+		p.updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+		th.AssertChannelClosed(t, p.readyCh, time.Second)
+		assert.True(t, p.ds.IsInitialized())
+	})
+}
+
+func TestKafkaDataSourceKeepsNewestVersionBeforeInitializing(t *testing.T) {
+	source := newFakeRecordSource(map[int32]int64{0: 3})
+	withDataSource(t, source, func(p dataSourceTestParams) {
+		p.source.send(
+			flagRecord(0, 0, ldbuilders.NewFlagBuilder("flag1").Version(2).Build()),
+			flagRecord(0, 1, ldbuilders.NewFlagBuilder("flag1").Version(1).Build()),
+			flagRecord(0, 2, ldbuilders.NewFlagBuilder("flag1").Version(3).Deleted(true).Build()),
+		)
+
+		inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+		assert.Equal(t, map[string]int{"flag1": 3}, initDataAsMap(inited)["features"])
+		flag, err := p.updates.DataStore.Get(datakinds.Features, "flag1")
+		require.NoError(t, err)
+		assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 3}, flag)
+	})
+}
+
+func TestKafkaDataSourceWithEmptyTopicInitializesImmediately(t *testing.T) {
+	withDataSource(t, newFakeRecordSource(map[int32]int64{0: 0}), func(p dataSourceTestParams) {
+		inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+		assert.Equal(t, map[string]map[string]int{"features": {}, "segments": {}}, initDataAsMap(inited))
+		th.AssertChannelClosed(t, p.readyCh, time.Second)
+	})
+}
+
+func TestKafkaDataSourceUpsertsLaterMessages(t *testing.T) {
+	withDataSource(t, newFakeRecordSource(map[int32]int64{0: 0}), func(p dataSourceTestParams) {
+		p.updates.DataStore.WaitForNextInit(t, time.Second)
+
+		p.source.send(flagRecord(0, 0, ldbuilders.NewFlagBuilder("flag1").Version(1).Build()))
+		p.updates.DataStore.WaitForUpsert(t, datakinds.Features, "flag1", 1, time.Second)
+
+		p.source.send(flagRecord(0, 1, ldbuilders.NewFlagBuilder("flag1").Version(2).Deleted(true).Build()))
+		p.updates.DataStore.WaitForDelete(t, datakinds.Features, "flag1", 2, time.Second)
+	})
+}
+
+func TestKafkaDataSourceSkipsInvalidMessages(t *testing.T) {
+	withDataSource(t, newFakeRecordSource(map[int32]int64{0: 0}), func(p dataSourceTestParams) {
+		p.updates.DataStore.WaitForNextInit(t, time.Second)
+
+		p.source.send(
+			&kgo.Record{Partition: 0, Offset: 0, Value: []byte(`{"kind":"widgets"}`)},
+			&kgo.Record{Partition: 0, Offset: 1, Value: nil}, // a compaction tombstone is ignored silently
+			flagRecord(0, 2, ldbuilders.NewFlagBuilder("flag1").Version(1).Build()),
+		)
+		p.updates.DataStore.WaitForUpsert(t, datakinds.Features, "flag1", 1, time.Second)
+		assert.Equal(t, []string{`KafkaDataSource: Skipping invalid message at offset 0 in partition 0 of topic "flags": ` +
+			`unknown data kind "widgets"`}, p.mockLog.GetOutput(ldlog.Error))
+	})
+}
+
+func TestKafkaDataSourceRetriesGettingEndOffsets(t *testing.T) {
+	source := newFakeRecordSource(map[int32]int64{0: 0})
+	source.endsErrors = []error{errors.New("no brokers"), errors.New("no brokers")}
+	withDataSource(t, source, func(p dataSourceTestParams) {
+		status := p.updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+		assert.Equal(t, interfaces.DataSourceErrorKindNetworkError, status.LastError.Kind)
+		assert.Equal(t, "no brokers", status.LastError.Message)
+
+		p.updates.DataStore.WaitForNextInit(t, time.Second)
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, `Error reading from topic "flags": no brokers`)
+	})
+}
+
+func TestKafkaDataSourceReportsPollErrors(t *testing.T) {
+	withDataSource(t, newFakeRecordSource(map[int32]int64{0: 0}), func(p dataSourceTestParams) {
+		p.updates.DataStore.WaitForNextInit(t, time.Second)
+		p.updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+
+		p.source.batches <- fakeBatch{err: errors.New("broker went away")}
+		status := p.updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+		assert.Equal(t, "broker went away", status.LastError.Message)
+
+		p.source.send()
+		p.updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+	})
+}
+
+func TestKafkaDataSourceCloseClosesClient(t *testing.T) {
+	source := newFakeRecordSource(map[int32]int64{0: 1})
+	withDataSource(t, source, func(p dataSourceTestParams) {
+		require.NoError(t, p.ds.Close())
+		th.AssertChannelClosed(t, source.closedCh, time.Second)
+		assert.False(t, p.ds.IsInitialized())
+	})
+}
+
+func TestKafkaDataSourceFactoryValidatesParameters(t *testing.T) {
+	context := subsystems.BasicClientContext{DataSourceUpdateSink: mocks.NewMockDataSourceUpdates(nil)}
+
+	_, err := NewKafkaDataSourceFactory(nil, "flags").Build(context)
+	assert.EqualError(t, err, "no Kafka brokers were specified")
+
+	_, err = NewKafkaDataSourceFactory([]string{"broker:9092"}, "").Build(context)
+	assert.EqualError(t, err, "no Kafka topic was specified")
+}
+
+func TestWithClientOptions(t *testing.T) {
+	factory := NewKafkaDataSourceFactory([]string{"broker:9092"}, "flags",
+		WithClientOptions(kgo.ClientID("a")), WithClientOptions(kgo.ClientID("b"))).(*kafkaDataSourceFactory)
+	assert.Len(t, factory.clientOpts, 2)
+
+	ds, err := factory.Build(subsystems.BasicClientContext{DataSourceUpdateSink: mocks.NewMockDataSourceUpdates(nil)})
+	require.NoError(t, err)
+	assert.NoError(t, ds.Close())
+}
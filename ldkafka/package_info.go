@@ -0,0 +1,7 @@
+// Package ldkafka provides a data source that receives feature flag and segment updates from a Kafka
+// topic, for applications whose flag management system publishes its changes to Kafka.
+//
+// The messages are in the same JSON format that the SDK uses for individual items in persistent data
+// stores, wrapped with the kind and key of the item; see [NewKafkaDataSourceFactory]. This is a separate
+// Go module, so that applications that do not use it do not get a Kafka client as a dependency of the SDK.
+package ldkafka
@@ -0,0 +1,73 @@
+package ldkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// recordSource is the part of the Kafka client that kafkaDataSource uses, so that the data source can
+// be tested without a Kafka cluster.
+type recordSource interface {
+	// endOffsets returns the offset after the last record in each partition of the topic.
+	endOffsets(ctx context.Context) (map[int32]int64, error)
+	// poll waits for more records. It can return records and an error at the same time.
+	poll(ctx context.Context) ([]*kgo.Record, error)
+	close()
+}
+
+type kgoRecordSource struct {
+	client *kgo.Client
+	admin  *kadm.Client
+	topic  string
+}
+
+func newKgoRecordSource(brokers []string, topic string, clientOpts []kgo.Opt) (recordSource, error) {
+	opts := append([]kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	}, clientOpts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &kgoRecordSource{client: client, admin: kadm.NewClient(client), topic: topic}, nil
+}
+
+func (s *kgoRecordSource) endOffsets(ctx context.Context) (map[int32]int64, error) {
+	listed, err := s.admin.ListEndOffsets(ctx, s.topic)
+	if err != nil {
+		return nil, err
+	}
+	if err := listed.Error(); err != nil {
+		return nil, err
+	}
+	ret := make(map[int32]int64)
+	listed.Each(func(o kadm.ListedOffset) {
+		ret[o.Partition] = o.Offset
+	})
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("topic %q has no partitions", s.topic)
+	}
+	return ret, nil
+}
+
+func (s *kgoRecordSource) poll(ctx context.Context) ([]*kgo.Record, error) {
+	fetches := s.client.PollFetches(ctx)
+	var err error
+	for _, e := range fetches.Errors() {
+		if !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, kgo.ErrClientClosed) {
+			err = fmt.Errorf("partition %d: %w", e.Partition, e.Err)
+			break
+		}
+	}
+	return fetches.Records(), err
+}
+
+func (s *kgoRecordSource) close() {
+	s.client.Close()
+}
@@ -0,0 +1,8 @@
+// Package ldlambda helps AWS Lambda functions keep one SDK client for the whole life of their execution
+// environment, instead of paying the cost of initializing a new client in every invocation.
+//
+// Lambda runs a function's initialization code, such as a package-level variable initializer or an init
+// function, once per execution environment, and then reuses the environment for many invocations until
+// it shuts the environment down. [NewPersistentClient] creates a client in that phase, and delivers any
+// pending analytics events when Lambda sends the SIGTERM signal to shut the environment down.
+package ldlambda
@@ -0,0 +1,121 @@
+package ldlambda
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// InitTimeout is how long [NewPersistentClient] waits for the client to initialize. Lambda allows ten
+// seconds for a function's initialization phase.
+const InitTimeout = 5 * time.Second
+
+// ShutdownFlushTimeout is the longest time that a [PersistentClient] waits for analytics events to be
+// delivered when the execution environment is shutting down. Lambda allows 500 milliseconds between the
+// SIGTERM signal and stopping the process.
+const ShutdownFlushTimeout = 400 * time.Millisecond
+
+// PersistentClient holds an SDK client that lasts for the life of a Lambda execution environment.
+//
+// Create a PersistentClient with [NewPersistentClient] or [NewPersistentClientWithDataStore]. All
+// PersistentClient methods are safe for concurrent use.
+type PersistentClient struct {
+	client    *ld.LDClient
+	signalCh  chan os.Signal
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	exit      func(int)
+}
+
+// NewPersistentClient creates an SDK client that can be reused across Lambda invocations. It should be
+// called during the initialization phase, so that it only happens once per execution environment:
+//
+//	var ldClient, _ = ldlambda.NewPersistentClient(os.Getenv("LD_SDK_KEY"), ld.Config{})
+//
+//	func handler(ctx context.Context, event MyEvent) (string, error) {
+//	    enabled, _ := ldClient.Client().BoolVariation("my-flag", contextFor(event), false)
+//	    ...
+//	}
+//
+// The sdkKey and config parameters are the same as for
+// [github.com/launchdarkly/go-server-sdk/v7.MakeCustomClient], which it calls with a waitFor time of
+// [InitTimeout]; the return values are also the same, except that the PersistentClient is nil only if
+// the client is. Both the client and the error should be checked, as for MakeCustomClient.
+//
+// When the process receives SIGTERM, the PersistentClient calls FlushAndWait on the client, waiting up to
+// [ShutdownFlushTimeout], closes it, and exits the process. Lambda only sends SIGTERM to a function
+// that has at least one extension registered; without one, undelivered events from the last few
+// invocations can be lost when the environment shuts down, unless the handler calls Flush.
+func NewPersistentClient(sdkKey string, config ld.Config) (*PersistentClient, error) {
+	client, err := ld.MakeCustomClient(sdkKey, config, InitTimeout)
+	if client == nil {
+		return nil, err
+	}
+	return newPersistentClient(client), err
+}
+
+// NewPersistentClientWithDataStore is like [NewPersistentClient], but the client reads flags from a
+// persistent data store, such as DynamoDB, that is kept up to date by the Relay Proxy, instead of
+// connecting to LaunchDarkly.
+//
+// Since the data store already has the flags, the client is ready as soon as it is created, so this
+// makes the cold start of a function as fast as possible. The dataStore parameter replaces config.DataStore,
+// and config.DataSource is set to [ldcomponents.ExternalUpdatesOnly].
+//
+//	var ldClient, _ = ldlambda.NewPersistentClientWithDataStore(os.Getenv("LD_SDK_KEY"), ld.Config{},
+//	    ldcomponents.PersistentDataStore(lddynamodb.DataStore("my-table")).CacheForever())
+func NewPersistentClientWithDataStore(
+	sdkKey string,
+	config ld.Config,
+	dataStore subsystems.ComponentConfigurer[subsystems.DataStore],
+) (*PersistentClient, error) {
+	config.DataStore = dataStore
+	config.DataSource = ldcomponents.ExternalUpdatesOnly()
+	return NewPersistentClient(sdkKey, config)
+}
+
+func newPersistentClient(client *ld.LDClient) *PersistentClient {
+	p := &PersistentClient{
+		client:   client,
+		signalCh: make(chan os.Signal, 1),
+		closeCh:  make(chan struct{}),
+		exit:     os.Exit,
+	}
+	signal.Notify(p.signalCh, syscall.SIGTERM)
+	go p.awaitShutdown()
+	return p
+}
+
+// Client returns the SDK client.
+func (p *PersistentClient) Client() *ld.LDClient {
+	return p.client
+}
+
+// Close delivers any pending analytics events, waiting up to [ShutdownFlushTimeout], and closes the
+// client. It also stops the PersistentClient from handling SIGTERM. A function does not normally need to
+// call this, but it can be useful in tests or when the same code runs outside of Lambda.
+func (p *PersistentClient) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		signal.Stop(p.signalCh)
+		close(p.closeCh)
+		p.client.FlushAndWait(ShutdownFlushTimeout)
+		err = p.client.Close()
+	})
+	return err
+}
+
+func (p *PersistentClient) awaitShutdown() {
+	select {
+	case <-p.signalCh:
+		_ = p.Close()
+		p.exit(0)
+	case <-p.closeCh:
+	}
+}
@@ -0,0 +1,113 @@
+package ldlambda
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flushRecordingEventProcessor struct {
+	ldevents.EventProcessor
+	flushTimeouts chan time.Duration
+	closed        chan struct{}
+}
+
+func newFlushRecordingEventProcessor() *flushRecordingEventProcessor {
+	return &flushRecordingEventProcessor{
+		EventProcessor: ldevents.NewNullEventProcessor(),
+		flushTimeouts:  make(chan time.Duration, 10),
+		closed:         make(chan struct{}),
+	}
+}
+
+func (e *flushRecordingEventProcessor) FlushBlocking(timeout time.Duration) bool {
+	e.flushTimeouts <- timeout
+	return true
+}
+
+func (e *flushRecordingEventProcessor) Close() error {
+	close(e.closed)
+	return nil
+}
+
+func makeTestConfig(events ldevents.EventProcessor) ld.Config {
+	return ld.Config{
+		DataSource: ldcomponents.ExternalUpdatesOnly(),
+		Events:     mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: events},
+		Logging:    ldcomponents.NoLogging(),
+	}
+}
+
+func TestPersistentClientFlushesAndExitsOnSIGTERM(t *testing.T) {
+	events := newFlushRecordingEventProcessor()
+	p, err := NewPersistentClient("sdk-key", makeTestConfig(events))
+	require.NoError(t, err)
+	exitCodes := make(chan int, 1)
+	p.exit = func(code int) { exitCodes <- code }
+
+	p.signalCh <- syscall.SIGTERM
+
+	assert.Equal(t, 0, th.RequireValue(t, exitCodes, time.Second))
+	assert.Equal(t, ShutdownFlushTimeout, th.RequireValue(t, events.flushTimeouts, time.Second))
+	th.AssertChannelClosed(t, events.closed, time.Second)
+}
+
+func TestPersistentClientClose(t *testing.T) {
+	events := newFlushRecordingEventProcessor()
+	p, err := NewPersistentClient("sdk-key", makeTestConfig(events))
+	require.NoError(t, err)
+	p.exit = func(int) { assert.Fail(t, "should not have exited") }
+
+	require.NoError(t, p.Close())
+	assert.Equal(t, ShutdownFlushTimeout, th.RequireValue(t, events.flushTimeouts, time.Second))
+	th.AssertChannelClosed(t, events.closed, time.Second)
+
+	// closing again does nothing
+	require.NoError(t, p.Close())
+	th.AssertNoMoreValues(t, events.flushTimeouts, 10*time.Millisecond)
+}
+
+func TestPersistentClientWithDataStoreUsesDataStoreWithoutDataSource(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flagkey").On(false).OffVariation(0).
+		Variations(ldvalue.Bool(true), ldvalue.Bool(false)).Build()
+	store, err := testhelpers.NewInMemoryDataStoreWithItems([]ldmodel.FeatureFlag{flag}, nil)
+	require.NoError(t, err)
+
+	config := makeTestConfig(newFlushRecordingEventProcessor())
+	config.DataSource = nil
+	p, err := NewPersistentClientWithDataStore("sdk-key", config,
+		mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store})
+	require.NoError(t, err)
+	defer p.Close()
+
+	assert.True(t, p.Client().Initialized())
+	value, err := p.Client().BoolVariation("flagkey", ldcontext.New("userkey"), false)
+	require.NoError(t, err)
+	assert.True(t, value)
+}
+
+func TestPersistentClientWithInvalidConfig(t *testing.T) {
+	config := makeTestConfig(newFlushRecordingEventProcessor())
+	config.DataStore = mocks.ComponentConfigurerThatReturnsError[subsystems.DataStore]{Err: errors.New("sorry")}
+	p, err := NewPersistentClient("sdk-key", config)
+	assert.Nil(t, p)
+	assert.Error(t, err)
+}
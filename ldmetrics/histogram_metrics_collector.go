@@ -0,0 +1,236 @@
+package ldmetrics
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// HistogramFlagKeyLimit is the maximum number of distinct flag keys that a [HistogramMetricsCollector]
+// keeps a histogram for. Evaluations of any other flags are counted together under the key
+// subsystems.EvaluationMetricsOtherFlagKey.
+const HistogramFlagKeyLimit = 1000
+
+// The histograms use the same layout as an HDR histogram with two significant digits: durations below
+// 2*subBucketCount nanoseconds are counted exactly, and each higher power of two is divided into
+// subBucketCount buckets of equal width, so that a recorded value is never off by more than 1/64.
+const (
+	subBucketBits  = 6
+	subBucketCount = 1 << subBucketBits
+	// Durations of 2^maxValueBits nanoseconds (about 69 seconds) or more are counted in the last bucket.
+	maxValueBits = 36
+	maxValue     = 1<<maxValueBits - 1
+	bucketCount  = 2*subBucketCount + (maxValueBits-subBucketBits-1)*subBucketCount
+)
+
+// HistogramMetricsCollector is an evaluation metrics collector that keeps a histogram of evaluation
+// durations for each flag key.
+//
+// Recording an evaluation does not allocate or take a lock, except the first time a flag key is seen. Each
+// histogram uses about 16KB of memory.
+type HistogramMetricsCollector struct {
+	histograms map[string]*histogram
+	lock       sync.RWMutex
+}
+
+// HistogramSnapshot is a copy of the state of one flag's histogram, as returned by
+// [HistogramMetricsCollector.Snapshot]. Its zero value describes a flag that has not been evaluated.
+type HistogramSnapshot struct {
+	counts     []int64
+	total      int64
+	errorCount int64
+	sumNanos   int64
+	minNanos   int64
+	maxNanos   int64
+}
+
+type histogram struct {
+	counts     [bucketCount]atomic.Int64
+	errorCount atomic.Int64
+	sumNanos   atomic.Int64
+	minNanos   atomic.Int64
+	maxNanos   atomic.Int64
+}
+
+// NewHistogramMetricsCollector creates a [HistogramMetricsCollector] with no data. To use it, put it in the
+// Metrics field of the SDK configuration:
+//
+//	collector := ldmetrics.NewHistogramMetricsCollector()
+//	config := ld.Config{
+//	    Metrics: collector,
+//	}
+func NewHistogramMetricsCollector() *HistogramMetricsCollector {
+	return &HistogramMetricsCollector{histograms: make(map[string]*histogram)}
+}
+
+// RecordEvaluation is called by the SDK for each flag evaluation.
+func (c *HistogramMetricsCollector) RecordEvaluation(
+	flagKey string,
+	durationNano int64,
+	variation int,
+	errorKind ldreason.EvalErrorKind,
+) {
+	h := c.histogramFor(flagKey)
+	h.counts[bucketIndex(durationNano)].Add(1)
+	if errorKind != "" {
+		h.errorCount.Add(1)
+	}
+	h.sumNanos.Add(durationNano)
+	for minNanos := h.minNanos.Load(); durationNano < minNanos; minNanos = h.minNanos.Load() {
+		if h.minNanos.CompareAndSwap(minNanos, durationNano) {
+			break
+		}
+	}
+	for maxNanos := h.maxNanos.Load(); durationNano > maxNanos; maxNanos = h.maxNanos.Load() {
+		if h.maxNanos.CompareAndSwap(maxNanos, durationNano) {
+			break
+		}
+	}
+}
+
+// FlagKeys returns the keys of all flags that have a histogram, in alphabetical order.
+func (c *HistogramMetricsCollector) FlagKeys() []string {
+	c.lock.RLock()
+	keys := make([]string, 0, len(c.histograms))
+	for key := range c.histograms {
+		keys = append(keys, key)
+	}
+	c.lock.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of the current state of the histogram for a flag key.
+//
+// Evaluations that happen while the snapshot is being made may or may not be included.
+func (c *HistogramMetricsCollector) Snapshot(flagKey string) HistogramSnapshot {
+	c.lock.RLock()
+	h := c.histograms[flagKey]
+	c.lock.RUnlock()
+	if h == nil {
+		return HistogramSnapshot{}
+	}
+	s := HistogramSnapshot{
+		counts:     make([]int64, bucketCount),
+		errorCount: h.errorCount.Load(),
+		sumNanos:   h.sumNanos.Load(),
+		minNanos:   h.minNanos.Load(),
+		maxNanos:   h.maxNanos.Load(),
+	}
+	for i := range h.counts {
+		s.counts[i] = h.counts[i].Load()
+		s.total += s.counts[i]
+	}
+	return s
+}
+
+func (c *HistogramMetricsCollector) histogramFor(flagKey string) *histogram {
+	c.lock.RLock()
+	h, ok := c.histograms[flagKey]
+	if !ok && len(c.histograms) >= HistogramFlagKeyLimit {
+		h, ok = c.histograms[subsystems.EvaluationMetricsOtherFlagKey]
+	}
+	c.lock.RUnlock()
+	if ok {
+		return h
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if h, ok := c.histograms[flagKey]; ok {
+		return h
+	}
+	if len(c.histograms) >= HistogramFlagKeyLimit {
+		flagKey = subsystems.EvaluationMetricsOtherFlagKey
+		if h, ok := c.histograms[flagKey]; ok {
+			return h
+		}
+	} else {
+		flagKey = strings.Clone(flagKey) // don't retain a reference to whatever larger string the key came from
+	}
+	h = &histogram{}
+	h.minNanos.Store(math.MaxInt64)
+	c.histograms[flagKey] = h
+	return h
+}
+
+// Count returns the number of evaluations.
+func (s HistogramSnapshot) Count() int64 {
+	return s.total
+}
+
+// ErrorCount returns the number of evaluations whose result was an error.
+func (s HistogramSnapshot) ErrorCount() int64 {
+	return s.errorCount
+}
+
+// Min returns the shortest evaluation duration, or zero if there were no evaluations.
+func (s HistogramSnapshot) Min() time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	return time.Duration(s.minNanos)
+}
+
+// Max returns the longest evaluation duration, or zero if there were no evaluations.
+func (s HistogramSnapshot) Max() time.Duration {
+	return time.Duration(s.maxNanos)
+}
+
+// Mean returns the average evaluation duration, or zero if there were no evaluations.
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	return time.Duration(s.sumNanos / s.total)
+}
+
+// ValueAtPercentile returns the evaluation duration that the specified percentage of evaluations did not
+// exceed, such as 99 for the 99th percentile. The result is the highest duration that falls in the same
+// histogram bucket, so it may be up to 1/64 higher than the actual value, but is never higher than [Max].
+//
+// This returns zero if there were no evaluations.
+func (s HistogramSnapshot) ValueAtPercentile(percentile float64) time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	percentile = math.Max(0, math.Min(percentile, 100))
+	target := int64(math.Ceil(percentile / 100 * float64(s.total)))
+	if target < 1 {
+		target = 1
+	}
+	var cumulative int64
+	for i, count := range s.counts {
+		cumulative += count
+		if cumulative >= target {
+			return time.Duration(min(bucketHighestValue(i), s.maxNanos))
+		}
+	}
+	return time.Duration(s.maxNanos) // COVERAGE: only reachable if the counts changed during the snapshot
+}
+
+func bucketIndex(durationNano int64) int {
+	v := uint64(max(0, min(durationNano, maxValue)))
+	if v < 2*subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - (subBucketBits + 1)
+	return 2*subBucketCount + (shift-1)*subBucketCount + int(v>>shift) - subBucketCount
+}
+
+func bucketHighestValue(index int) int64 {
+	if index < 2*subBucketCount {
+		return int64(index)
+	}
+	k := index - 2*subBucketCount
+	shift := k/subBucketCount + 1
+	top := int64(k%subBucketCount + subBucketCount)
+	return (top+1)<<shift - 1
+}
@@ -0,0 +1,111 @@
+package ldmetrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramMetricsCollector(t *testing.T) {
+	c := NewHistogramMetricsCollector()
+	c.RecordEvaluation("flag1", int64(10*time.Microsecond), 0, "")
+	c.RecordEvaluation("flag1", int64(30*time.Microsecond), 1, "")
+	c.RecordEvaluation("flag2", int64(time.Millisecond), -1, ldreason.EvalErrorWrongType)
+
+	assert.Equal(t, []string{"flag1", "flag2"}, c.FlagKeys())
+
+	s1 := c.Snapshot("flag1")
+	assert.Equal(t, int64(2), s1.Count())
+	assert.Equal(t, int64(0), s1.ErrorCount())
+	assert.Equal(t, 10*time.Microsecond, s1.Min())
+	assert.Equal(t, 30*time.Microsecond, s1.Max())
+	assert.Equal(t, 20*time.Microsecond, s1.Mean())
+
+	s2 := c.Snapshot("flag2")
+	assert.Equal(t, int64(1), s2.Count())
+	assert.Equal(t, int64(1), s2.ErrorCount())
+}
+
+func TestHistogramSnapshotOfUnknownFlag(t *testing.T) {
+	s := NewHistogramMetricsCollector().Snapshot("flag")
+	assert.Equal(t, int64(0), s.Count())
+	assert.Equal(t, time.Duration(0), s.Min())
+	assert.Equal(t, time.Duration(0), s.Max())
+	assert.Equal(t, time.Duration(0), s.Mean())
+	assert.Equal(t, time.Duration(0), s.ValueAtPercentile(99))
+}
+
+func TestHistogramValueAtPercentile(t *testing.T) {
+	c := NewHistogramMetricsCollector()
+	for i := 1; i <= 100; i++ {
+		c.RecordEvaluation("flag", int64(i)*int64(time.Microsecond), 0, "")
+	}
+	s := c.Snapshot("flag")
+
+	for _, p := range []float64{1, 50, 90, 99} {
+		t.Run(fmt.Sprintf("p%v", p), func(t *testing.T) {
+			expected := time.Duration(p) * time.Microsecond
+			actual := s.ValueAtPercentile(p)
+			assert.GreaterOrEqual(t, actual, expected)
+			assert.LessOrEqual(t, actual, expected+expected/subBucketCount)
+		})
+	}
+	assert.Equal(t, 100*time.Microsecond, s.ValueAtPercentile(100))
+	assert.Equal(t, 100*time.Microsecond, s.ValueAtPercentile(150))
+	assert.Equal(t, s.ValueAtPercentile(1), s.ValueAtPercentile(0))
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 129, 1000, 123456789, maxValue} {
+		t.Run(fmt.Sprint(v), func(t *testing.T) {
+			i := bucketIndex(v)
+			assert.Less(t, i, bucketCount)
+			highest := bucketHighestValue(i)
+			assert.GreaterOrEqual(t, highest, v)
+			assert.LessOrEqual(t, highest, v+v/subBucketCount)
+			if i > 0 {
+				assert.Less(t, bucketHighestValue(i-1), v)
+			}
+		})
+	}
+	assert.Equal(t, bucketCount-1, bucketIndex(maxValue))
+	assert.Equal(t, bucketCount-1, bucketIndex(maxValue*10))
+	assert.Equal(t, 0, bucketIndex(-1))
+}
+
+func TestHistogramFlagKeyLimit(t *testing.T) {
+	c := NewHistogramMetricsCollector()
+	for i := 0; i < HistogramFlagKeyLimit+5; i++ {
+		c.RecordEvaluation(fmt.Sprintf("flag%d", i), 1, 0, "")
+	}
+	keys := c.FlagKeys()
+	assert.Len(t, keys, HistogramFlagKeyLimit+1)
+	assert.Contains(t, keys, subsystems.EvaluationMetricsOtherFlagKey)
+	assert.NotContains(t, keys, fmt.Sprintf("flag%d", HistogramFlagKeyLimit))
+	assert.Equal(t, int64(5), c.Snapshot(subsystems.EvaluationMetricsOtherFlagKey).Count())
+}
+
+func TestHistogramConcurrentRecording(t *testing.T) {
+	c := NewHistogramMetricsCollector()
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				c.RecordEvaluation("flag", int64(g*100+i), 0, "")
+			}
+		}(g)
+	}
+	wg.Wait()
+	s := c.Snapshot("flag")
+	assert.Equal(t, int64(1000), s.Count())
+	assert.Equal(t, time.Duration(1), s.Min())
+	assert.Equal(t, time.Duration(1000), s.Max())
+}
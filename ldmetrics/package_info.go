@@ -0,0 +1,7 @@
+// Package ldmetrics provides an implementation of
+// [github.com/launchdarkly/go-server-sdk/v7/subsystems.EvaluationMetricsCollector] that keeps a latency
+// histogram in memory for each flag key, so that an application can report evaluation percentiles however
+// it likes.
+//
+// See [NewHistogramMetricsCollector] for details.
+package ldmetrics
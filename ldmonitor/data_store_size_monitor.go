@@ -0,0 +1,199 @@
+package ldmonitor
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// DataStoreSizeWarnFunc is called by a data store size monitor when the number of items of some kind in
+// the data store becomes greater than the configured maximum. The count parameter is the current number of
+// items of that kind, including placeholders for deleted items.
+type DataStoreSizeWarnFunc func(kind ldstoretypes.DataKind, count int)
+
+type dataStoreSizeMonitorFactory struct {
+	wrapped  subsystems.ComponentConfigurer[subsystems.DataStore]
+	maxItems int
+	warnFn   DataStoreSizeWarnFunc
+}
+
+type dataStoreSizeMonitor struct {
+	wrapped  subsystems.DataStore
+	maxItems int
+	warnFn   DataStoreSizeWarnFunc
+	keys     map[ldstoretypes.DataKind]map[string]struct{}
+	lock     sync.Mutex
+}
+
+// DataStoreSizeMonitor returns a configurable factory for a data store that warns when it holds more than
+// maxItems items of any one kind, such as flags or segments. This can help to detect runaway flag creation,
+// which makes the data store grow without bound and evaluations slower.
+//
+// The wrapped parameter is the data store configuration that would otherwise be used, such as
+// ldcomponents.PersistentDataStore(); if it is nil, the default in-memory data store is used. If warnFn is
+// nil, the SDK logs a warning that includes the current count and the maximum.
+//
+//	config := ld.Config{
+//	    DataStore: ldmonitor.DataStoreSizeMonitor(ldcomponents.PersistentDataStore(store), 5000, nil),
+//	}
+//
+// See [NewDataStoreSizeMonitor] for when the warning is produced.
+func DataStoreSizeMonitor(
+	wrapped subsystems.ComponentConfigurer[subsystems.DataStore],
+	maxItems int,
+	warnFn DataStoreSizeWarnFunc,
+) subsystems.ComponentConfigurer[subsystems.DataStore] {
+	if wrapped == nil {
+		wrapped = ldcomponents.InMemoryDataStore()
+	}
+	return dataStoreSizeMonitorFactory{wrapped: wrapped, maxItems: maxItems, warnFn: warnFn}
+}
+
+// Build is called internally by the SDK.
+func (f dataStoreSizeMonitorFactory) Build(context subsystems.ClientContext) (subsystems.DataStore, error) {
+	store, err := f.wrapped.Build(context)
+	if err != nil {
+		return nil, err
+	}
+	warnFn := f.warnFn
+	if warnFn == nil {
+		warnFn = makeDefaultWarnFunc(context.GetLogging().Loggers, f.maxItems)
+	}
+	return NewDataStoreSizeMonitor(store, f.maxItems, warnFn), nil
+}
+
+// UnwrapDataStoreFactory lets the SDK see the configuration of the monitored data store.
+func (f dataStoreSizeMonitorFactory) UnwrapDataStoreFactory() subsystems.ComponentConfigurer[subsystems.DataStore] {
+	return f.wrapped
+}
+
+// DescribeConfiguration is used internally by the SDK to inspect the configuration.
+func (f dataStoreSizeMonitorFactory) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+	if dd, ok := f.wrapped.(subsystems.DiagnosticDescription); ok {
+		return dd.DescribeConfiguration(context)
+	}
+	return ldvalue.Null()
+}
+
+// NewDataStoreSizeMonitor wraps a data store so that warnFn is called whenever the number of items of
+// some kind in the store becomes greater than maxItems. Use this instead of [DataStoreSizeMonitor] if you
+// are creating the data store yourself, as with ld.MakeClientFromComponents. If warnFn is nil, a warning
+// is logged with ldlog's default loggers.
+//
+// The monitor keeps track of the item keys that are passed to the store's Init and Upsert methods, so it
+// does not know about items that are put into a persistent store by some other process. It calls warnFn
+// once when a kind's count goes over the maximum, and again only if the count has gone back down to the
+// maximum in the meantime, which can only happen by way of Init.
+func NewDataStoreSizeMonitor(
+	wrapped subsystems.DataStore,
+	maxItems int,
+	warnFn DataStoreSizeWarnFunc,
+) subsystems.DataStore {
+	if warnFn == nil {
+		warnFn = makeDefaultWarnFunc(ldlog.NewDefaultLoggers(), maxItems)
+	}
+	return &dataStoreSizeMonitor{
+		wrapped:  wrapped,
+		maxItems: maxItems,
+		warnFn:   warnFn,
+		keys:     make(map[ldstoretypes.DataKind]map[string]struct{}),
+	}
+}
+
+func makeDefaultWarnFunc(loggers ldlog.Loggers, maxItems int) DataStoreSizeWarnFunc {
+	return func(kind ldstoretypes.DataKind, count int) {
+		loggers.Warnf("Data store holds %d items of kind %q, which is more than the configured maximum of %d",
+			count, kind.GetName(), maxItems)
+	}
+}
+
+func (m *dataStoreSizeMonitor) Init(allData []ldstoretypes.Collection) error {
+	if err := m.wrapped.Init(allData); err != nil {
+		return err
+	}
+	type warning struct {
+		kind  ldstoretypes.DataKind
+		count int
+	}
+	var warnings []warning
+	m.lock.Lock()
+	oldKeys := m.keys
+	m.keys = make(map[ldstoretypes.DataKind]map[string]struct{}, len(allData))
+	for _, coll := range allData {
+		keys := make(map[string]struct{}, len(coll.Items))
+		for _, item := range coll.Items {
+			keys[item.Key] = struct{}{}
+		}
+		m.keys[coll.Kind] = keys
+		if len(keys) > m.maxItems && len(oldKeys[coll.Kind]) <= m.maxItems {
+			warnings = append(warnings, warning{coll.Kind, len(keys)})
+		}
+	}
+	m.lock.Unlock()
+	for _, w := range warnings {
+		m.warnFn(w.kind, w.count)
+	}
+	return nil
+}
+
+func (m *dataStoreSizeMonitor) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
+	return m.wrapped.Get(kind, key)
+}
+
+func (m *dataStoreSizeMonitor) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
+	return m.wrapped.GetAll(kind)
+}
+
+func (m *dataStoreSizeMonitor) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.ItemDescriptor,
+) (bool, error) {
+	updated, err := m.wrapped.Upsert(kind, key, item)
+	if err != nil || !updated {
+		return updated, err
+	}
+	m.lock.Lock()
+	keys := m.keys[kind]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		m.keys[kind] = keys
+	}
+	_, existed := keys[key]
+	keys[key] = struct{}{}
+	count := len(keys)
+	m.lock.Unlock()
+	if !existed && count == m.maxItems+1 {
+		m.warnFn(kind, count)
+	}
+	return updated, nil
+}
+
+func (m *dataStoreSizeMonitor) IsInitialized() bool {
+	return m.wrapped.IsInitialized()
+}
+
+func (m *dataStoreSizeMonitor) IsStatusMonitoringEnabled() bool {
+	return m.wrapped.IsStatusMonitoringEnabled()
+}
+
+func (m *dataStoreSizeMonitor) Close() error {
+	return m.wrapped.Close()
+}
+
+// UnwrapDataStore lets the SDK see what kind of data store is being monitored.
+func (m *dataStoreSizeMonitor) UnwrapDataStore() subsystems.DataStore {
+	return m.wrapped
+}
+
+// Preload lets the SDK preload a persistent data store through the monitor.
+func (m *dataStoreSizeMonitor) Preload() {
+	if preloadable, ok := m.wrapped.(datastore.PreloadableDataStore); ok {
+		preloadable.Preload()
+	}
+}
@@ -0,0 +1,165 @@
+package ldmonitor
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sizeWarning struct {
+	kind  ldstoretypes.DataKind
+	count int
+}
+
+type sizeWarningRecorder struct {
+	warnings []sizeWarning
+}
+
+func (r *sizeWarningRecorder) warn(kind ldstoretypes.DataKind, count int) {
+	r.warnings = append(r.warnings, sizeWarning{kind, count})
+}
+
+func makeFlagData(keys ...string) []ldstoretypes.Collection {
+	builder := sharedtest.NewDataSetBuilder()
+	for _, key := range keys {
+		builder.Flags(ldbuilders.NewFlagBuilder(key).Version(1).Build())
+	}
+	return builder.Build()
+}
+
+func upsertFlag(t *testing.T, store subsystems.DataStore, key string, version int) bool {
+	updated, err := store.Upsert(datakinds.Features, key,
+		sharedtest.FlagDescriptor(ldbuilders.NewFlagBuilder(key).Version(version).Build()))
+	require.NoError(t, err)
+	return updated
+}
+
+func TestDataStoreSizeMonitorDelegatesToWrappedStore(t *testing.T) {
+	wrapped := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	store := NewDataStoreSizeMonitor(wrapped, 10, (&sizeWarningRecorder{}).warn)
+
+	assert.False(t, store.IsInitialized())
+	require.NoError(t, store.Init(makeFlagData("flag1")))
+	assert.True(t, store.IsInitialized())
+	assert.True(t, wrapped.IsInitialized())
+
+	assert.True(t, upsertFlag(t, store, "flag2", 1))
+	item, err := wrapped.Get(datakinds.Features, "flag2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, item.Version)
+
+	all, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.NoError(t, store.Close())
+}
+
+func TestDataStoreSizeMonitorWarnsAfterInit(t *testing.T) {
+	recorder := &sizeWarningRecorder{}
+	store := NewDataStoreSizeMonitor(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers()), 2, recorder.warn)
+
+	require.NoError(t, store.Init(makeFlagData("flag1", "flag2")))
+	assert.Len(t, recorder.warnings, 0)
+
+	require.NoError(t, store.Init(makeFlagData("flag1", "flag2", "flag3")))
+	assert.Equal(t, []sizeWarning{{datakinds.Features, 3}}, recorder.warnings)
+
+	require.NoError(t, store.Init(makeFlagData("flag1", "flag2", "flag3", "flag4")))
+	assert.Len(t, recorder.warnings, 1)
+
+	require.NoError(t, store.Init(makeFlagData("flag1")))
+	require.NoError(t, store.Init(makeFlagData("flag1", "flag2", "flag3")))
+	assert.Len(t, recorder.warnings, 2)
+}
+
+func TestDataStoreSizeMonitorWarnsAfterUpsert(t *testing.T) {
+	recorder := &sizeWarningRecorder{}
+	store := NewDataStoreSizeMonitor(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers()), 2, recorder.warn)
+	require.NoError(t, store.Init(makeFlagData("flag1")))
+
+	upsertFlag(t, store, "flag2", 1)
+	upsertFlag(t, store, "flag2", 2)
+	assert.Len(t, recorder.warnings, 0)
+
+	assert.False(t, upsertFlag(t, store, "flag1", 1)) // not newer than the existing version
+	assert.Len(t, recorder.warnings, 0)
+
+	upsertFlag(t, store, "flag3", 1)
+	assert.Equal(t, []sizeWarning{{datakinds.Features, 3}}, recorder.warnings)
+
+	upsertFlag(t, store, "flag4", 1)
+	upsertFlag(t, store, "flag3", 2)
+	assert.Len(t, recorder.warnings, 1)
+}
+
+func TestDataStoreSizeMonitorCountsKindsSeparately(t *testing.T) {
+	recorder := &sizeWarningRecorder{}
+	store := NewDataStoreSizeMonitor(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers()), 1, recorder.warn)
+	require.NoError(t, store.Init(makeFlagData("flag1")))
+
+	_, err := store.Upsert(datakinds.Segments, "segment1",
+		sharedtest.SegmentDescriptor(ldbuilders.NewSegmentBuilder("segment1").Version(1).Build()))
+	require.NoError(t, err)
+	assert.Len(t, recorder.warnings, 0)
+
+	upsertFlag(t, store, "flag2", 1)
+	assert.Equal(t, []sizeWarning{{datakinds.Features, 2}}, recorder.warnings)
+}
+
+func TestDataStoreSizeMonitorFactory(t *testing.T) {
+	t.Run("default warning is logged", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		context := subsystems.BasicClientContext{Logging: subsystems.LoggingConfiguration{Loggers: mockLog.Loggers}}
+		store, err := DataStoreSizeMonitor(nil, 1, nil).Build(context)
+		require.NoError(t, err)
+		require.NoError(t, store.Init(makeFlagData("flag1", "flag2")))
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn,
+			`Data store holds 2 items of kind "features", which is more than the configured maximum of 1`)
+	})
+
+	t.Run("wrapped configurer is used", func(t *testing.T) {
+		recorder := &sizeWarningRecorder{}
+		factory := DataStoreSizeMonitor(ldcomponents.InMemoryDataStore(), 1, recorder.warn)
+		store, err := factory.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		require.NoError(t, store.Init(makeFlagData("flag1", "flag2")))
+		assert.Len(t, recorder.warnings, 1)
+	})
+
+	t.Run("description comes from wrapped configurer", func(t *testing.T) {
+		factory := DataStoreSizeMonitor(ldcomponents.InMemoryDataStore(), 1, nil)
+		expected := ldcomponents.InMemoryDataStore().(subsystems.DiagnosticDescription).
+			DescribeConfiguration(subsystems.BasicClientContext{})
+		assert.Equal(t, expected, factory.(subsystems.DiagnosticDescription).
+			DescribeConfiguration(subsystems.BasicClientContext{}))
+	})
+}
+
+type preloadableTestStore struct {
+	subsystems.DataStore
+	preloaded bool
+}
+
+func (s *preloadableTestStore) Preload() {
+	s.preloaded = true
+}
+
+func TestDataStoreSizeMonitorForwardsPreload(t *testing.T) {
+	wrapped := &preloadableTestStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+	store := NewDataStoreSizeMonitor(wrapped, 1, nil)
+	preloadable, ok := store.(datastore.PreloadableDataStore)
+	require.True(t, ok)
+	preloadable.Preload()
+	assert.True(t, wrapped.preloaded)
+}
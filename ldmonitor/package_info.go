@@ -0,0 +1,5 @@
+// Package ldmonitor provides optional wrappers for SDK components that watch for signs of trouble, such as
+// a data store that keeps growing.
+//
+// See [DataStoreSizeMonitor] for details.
+package ldmonitor
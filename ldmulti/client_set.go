@@ -0,0 +1,161 @@
+package ldmulti
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldhttp"
+)
+
+// DefaultMaxConcurrentEventDeliveries is the maximum number of analytics event payloads that the clients
+// in a [ClientSet] will deliver at the same time, across all environments. Any further deliveries wait
+// until one of the current ones has finished.
+const DefaultMaxConcurrentEventDeliveries = 5
+
+// ClientSet is a group of SDK clients, one for each LaunchDarkly environment, that share resources.
+//
+// Create a ClientSet with [NewClientSet]. All ClientSet methods are safe for concurrent use.
+type ClientSet struct {
+	clients   map[string]*ld.LDClient
+	transport *http.Transport
+	closeOnce sync.Once
+}
+
+// NewClientSet creates a client for each of the specified environments, using a shared HTTP connection
+// pool and a shared limit on concurrent analytics event deliveries.
+//
+// The keys of the configs map are the SDK keys of the environments, and the values are the configuration
+// for each environment's client, exactly as they would be passed to
+// [github.com/launchdarkly/go-server-sdk/v7.MakeCustomClient]. A configuration whose HTTP field is nil
+// will use the shared HTTP connection pool, with the default HTTP settings; a configuration that has its
+// own HTTP settings is left unchanged, and that client will have its own connection pool.
+//
+//	clients, err := ldmulti.NewClientSet(map[string]ld.Config{
+//	    "sdk-key-for-production": {},
+//	    "sdk-key-for-staging":    {Events: ldcomponents.NoEvents()},
+//	}, 5*time.Second)
+//	value, err := clients.Get("sdk-key-for-production").BoolVariation("my-flag", context, false)
+//
+// The waitFor parameter has the same meaning as in MakeCustomClient, except that the clients are started
+// concurrently, so the total time spent waiting is no greater than waitFor.
+//
+// If any client could not be created at all because of an invalid configuration, all of the clients that
+// were created are closed, and NewClientSet returns a nil ClientSet and an error. If every client was
+// created but one or more of them could not initialize before the timeout, or failed to initialize, it
+// returns the ClientSet along with an error for which errors.Is will match the same error that
+// MakeCustomClient would have returned ([github.com/launchdarkly/go-server-sdk/v7.ErrInitializationTimeout]
+// or [github.com/launchdarkly/go-server-sdk/v7.ErrInitializationFailed]). Error messages never include
+// SDK keys.
+func NewClientSet(configs map[string]ld.Config, waitFor time.Duration) (*ClientSet, error) {
+	transport, _, err := ldhttp.NewHTTPTransport(ldhttp.ConnectTimeoutOption(ldcomponents.DefaultConnectTimeout))
+	if err != nil {
+		return nil, err // COVERAGE: can't cause this condition in unit tests
+	}
+	sharedRoundTripper := newEventDeliveryLimiter(transport, DefaultMaxConcurrentEventDeliveries)
+	sharedHTTP := ldcomponents.HTTPConfiguration().HTTPClientFactory(func() *http.Client {
+		// Each component gets its own Client, since some of them modify the Client's properties, but
+		// they all use the same underlying connection pool.
+		return &http.Client{Timeout: ldcomponents.DefaultConnectTimeout, Transport: sharedRoundTripper}
+	})
+
+	type result struct {
+		sdkKey string
+		client *ld.LDClient
+		err    error
+	}
+	results := make(chan result, len(configs))
+	for sdkKey, config := range configs {
+		if config.HTTP == nil {
+			config.HTTP = sharedHTTP
+		}
+		go func(sdkKey string, config ld.Config) {
+			client, err := ld.MakeCustomClient(sdkKey, config, waitFor)
+			results <- result{sdkKey, client, err}
+		}(sdkKey, config)
+	}
+
+	set := &ClientSet{clients: make(map[string]*ld.LDClient, len(configs)), transport: transport}
+	var configErrs, initErrs []error
+	for range configs {
+		r := <-results
+		switch {
+		case r.client == nil:
+			configErrs = append(configErrs, r.err)
+		case r.err != nil:
+			initErrs = append(initErrs, r.err)
+			set.clients[r.sdkKey] = r.client
+		default:
+			set.clients[r.sdkKey] = r.client
+		}
+	}
+	if len(configErrs) > 0 {
+		_ = set.Close()
+		return nil, errors.Join(configErrs...)
+	}
+	return set, errors.Join(initErrs...)
+}
+
+// Get returns the client for the environment with the specified SDK key, or nil if there is no such
+// environment in this ClientSet.
+func (s *ClientSet) Get(sdkKey string) *ld.LDClient {
+	return s.clients[sdkKey]
+}
+
+// SDKKeys returns the SDK keys of all the environments in this ClientSet, in sorted order.
+func (s *ClientSet) SDKKeys() []string {
+	keys := make([]string, 0, len(s.clients))
+	for k := range s.clients {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Close shuts down all of the clients in this ClientSet, waiting for each of them to deliver any pending
+// analytics events, and then releases the shared resources. Calling Close more than once has no
+// additional effect.
+func (s *ClientSet) Close() error {
+	s.closeOnce.Do(func() {
+		var wg sync.WaitGroup
+		for _, client := range s.clients {
+			wg.Add(1)
+			go func(client *ld.LDClient) {
+				defer wg.Done()
+				_ = client.Close()
+			}(client)
+		}
+		wg.Wait()
+		s.transport.CloseIdleConnections()
+	})
+	return nil
+}
+
+// eventDeliveryLimiter is a RoundTripper that limits how many event payloads can be in flight at once.
+// Event payloads are the only thing the SDK sends with POST; stream and polling requests are not
+// limited, since a stream request stays open indefinitely.
+type eventDeliveryLimiter struct {
+	transport http.RoundTripper
+	slots     chan struct{}
+}
+
+func newEventDeliveryLimiter(transport http.RoundTripper, maxConcurrent int) *eventDeliveryLimiter {
+	return &eventDeliveryLimiter{transport: transport, slots: make(chan struct{}, maxConcurrent)}
+}
+
+func (l *eventDeliveryLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost {
+		return l.transport.RoundTrip(req)
+	}
+	select {
+	case l.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-l.slots }()
+	return l.transport.RoundTrip(req)
+}
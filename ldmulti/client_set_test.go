@@ -0,0 +1,141 @@
+package ldmulti
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestConfig(data *ldtestdata.TestDataSource, eventsURI string) ld.Config {
+	return ld.Config{
+		DataSource:       data,
+		Events:           ldcomponents.SendEvents(),
+		DiagnosticOptOut: true,
+		Logging:          ldcomponents.NoLogging(),
+		ServiceEndpoints: interfaces.ServiceEndpoints{Events: eventsURI}.WithPartialSpecification(),
+	}
+}
+
+func TestClientSetEvaluatesEachEnvironmentSeparately(t *testing.T) {
+	data1, data2 := ldtestdata.DataSource(), ldtestdata.DataSource()
+	data1.Update(data1.Flag("flag").VariationForAll(true))
+	data2.Update(data2.Flag("flag").VariationForAll(false))
+
+	set, err := NewClientSet(map[string]ld.Config{
+		"key1": {DataSource: data1, Events: ldcomponents.NoEvents(), Logging: ldcomponents.NoLogging()},
+		"key2": {DataSource: data2, Events: ldcomponents.NoEvents(), Logging: ldcomponents.NoLogging()},
+	}, time.Second)
+	require.NoError(t, err)
+	defer set.Close()
+
+	assert.Equal(t, []string{"key1", "key2"}, set.SDKKeys())
+	assert.Nil(t, set.Get("key3"))
+
+	context := ldcontext.New("user-key")
+	value1, _ := set.Get("key1").BoolVariation("flag", context, false)
+	value2, _ := set.Get("key2").BoolVariation("flag", context, true)
+	assert.True(t, value1)
+	assert.False(t, value2)
+}
+
+func TestClientSetDoesNotMixEventsBetweenEnvironments(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		set, err := NewClientSet(map[string]ld.Config{
+			"key1": makeTestConfig(ldtestdata.DataSource(), server.URL),
+			"key2": makeTestConfig(ldtestdata.DataSource(), server.URL),
+		}, time.Second)
+		require.NoError(t, err)
+
+		require.NoError(t, set.Get("key1").Identify(ldcontext.New("user1")))
+		require.NoError(t, set.Get("key2").Identify(ldcontext.New("user2")))
+		require.NoError(t, set.Close())
+		require.NoError(t, set.Close()) // second call has no effect
+
+		bodiesByKey := make(map[string]string)
+		for i := 0; i < 2; i++ {
+			select {
+			case r := <-requestsCh:
+				bodiesByKey[r.Request.Header.Get("Authorization")] = string(r.Body)
+			case <-time.After(time.Second):
+				require.Fail(t, "timed out waiting for event payload")
+			}
+		}
+		assert.Contains(t, bodiesByKey["key1"], `"user1"`)
+		assert.NotContains(t, bodiesByKey["key1"], `"user2"`)
+		assert.Contains(t, bodiesByKey["key2"], `"user2"`)
+		assert.NotContains(t, bodiesByKey["key2"], `"user1"`)
+	})
+}
+
+func TestClientSetReturnsErrorForInvalidConfiguration(t *testing.T) {
+	set, err := NewClientSet(map[string]ld.Config{
+		"key1": {DataSource: ldtestdata.DataSource(), Logging: ldcomponents.NoLogging()},
+		"key2": {HTTP: ldcomponents.HTTPConfiguration().CACert([]byte{1}), Logging: ldcomponents.NoLogging()},
+	}, 0)
+	require.Error(t, err)
+	assert.Nil(t, set)
+	assert.NotContains(t, err.Error(), "key2")
+}
+
+func TestClientSetReturnsInitializationError(t *testing.T) {
+	logging := ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers())
+	set, err := NewClientSet(map[string]ld.Config{
+		"key1": {DataSource: ldtestdata.DataSource(), Events: ldcomponents.NoEvents(), Logging: logging},
+		"key2": {
+			DataSource:       ldcomponents.StreamingDataSource(),
+			Events:           ldcomponents.NoEvents(),
+			Logging:          logging,
+			ServiceEndpoints: ldcomponents.RelayProxyEndpoints("http://localhost:1"),
+		},
+	}, 100*time.Millisecond)
+	require.NotNil(t, set)
+	defer set.Close()
+	assert.ErrorIs(t, err, ld.ErrInitializationTimeout)
+	assert.True(t, set.Get("key1").Initialized())
+	assert.False(t, set.Get("key2").Initialized())
+}
+
+func TestEventDeliveryLimiterOnlyLimitsPosts(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(200))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		limiter := newEventDeliveryLimiter(http.DefaultTransport, 1)
+		limiter.slots <- struct{}{} // occupy the only slot
+		client := &http.Client{Transport: limiter}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		<-requestsCh
+
+		posted := make(chan struct{})
+		go func() {
+			resp, err := client.Post(server.URL, "application/json", nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+			close(posted)
+		}()
+		select {
+		case <-posted:
+			require.Fail(t, "POST should have waited for a free slot")
+		case <-time.After(50 * time.Millisecond):
+		}
+		<-limiter.slots
+		<-posted
+		<-requestsCh
+	})
+}
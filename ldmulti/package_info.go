@@ -0,0 +1,14 @@
+// Package ldmulti allows an application to run SDK clients for several LaunchDarkly environments in
+// the same process, while sharing some of the resources that would otherwise be duplicated by each
+// client.
+//
+// Normally, an application creates a single [github.com/launchdarkly/go-server-sdk/v7.LDClient] for one
+// environment (that is, one SDK key). If the same process needs to evaluate flags in several environments,
+// it can create several independent clients, but each of them will then have its own HTTP connection pool
+// and will deliver its analytics events without regard to the others. [NewClientSet] creates a group of
+// clients that share these resources, and that can be shut down together with a single call to
+// [ClientSet.Close].
+//
+// Each client still has its own data source, data store, and event buffer, so flag data and analytics
+// events are never mixed between environments.
+package ldmulti
@@ -0,0 +1,354 @@
+package ldnats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+	requestTimeout    = 10 * time.Second
+)
+
+// NATSOption is an optional parameter for [NewNATSDataSourceFactory].
+type NATSOption func(*natsDataSourceFactory)
+
+// WithConnectionOptions adds options for the NATS connection, such as nats.UserCredentials or
+// nats.Secure for connecting to a secured server. By default, the connection retries forever if the
+// server cannot be reached, including when the data source starts; these options can change that.
+func WithConnectionOptions(opts ...nats.Option) NATSOption {
+	return func(f *natsDataSourceFactory) {
+		f.connOpts = append(f.connOpts, opts...)
+	}
+}
+
+// WithStream sets the name of the JetStream stream that contains the subject. If it is not set, the
+// data source asks the server which stream that is.
+func WithStream(name string) NATSOption {
+	return func(f *natsDataSourceFactory) {
+		f.stream = name
+	}
+}
+
+type natsDataSourceFactory struct {
+	serverURL  string
+	subject    string
+	stream     string
+	connOpts   []nats.Option
+	retryDelay time.Duration
+}
+
+type natsDataSource struct {
+	conn        *nats.Conn
+	js          jetstream.JetStream
+	subject     string
+	stream      string
+	sink        subsystems.DataSourceUpdateSink
+	loggers     ldlog.Loggers
+	retryDelay  time.Duration
+	initialized bool
+	messages    jetstream.MessagesContext
+	cancel      context.CancelFunc
+	doneCh      chan struct{}
+	lock        sync.Mutex
+	closeOnce   sync.Once
+}
+
+// NewNATSDataSourceFactory returns a data source configuration that gets flags and segments from a NATS
+// JetStream stream. Store it in the DataSource field of [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    DataSource: ldnats.NewNATSDataSourceFactory("nats://nats:4222", "launchdarkly.flags.>"),
+//	}
+//
+// The subject can contain wildcards, and it must be part of a JetStream stream. Each flag or segment
+// should be published on a subject of its own within it, such as "launchdarkly.flags.features.my-flag",
+// and each message is one version of that item, in this format:
+//
+//	{"kind": "features", "key": "my-flag", "item": {"key": "my-flag", "version": 2, ...}}
+//
+// The kind is "features" or "segments". The item is in the JSON format that the SDK uses for flags and
+// segments in persistent data stores; it can also be a string containing that JSON, as in the "item"
+// attribute of the DynamoDB store. To delete an item, send a newer version of it with "deleted": true.
+// Updates with a version that is not newer than the current one are ignored. A message that cannot be
+// parsed is logged and skipped.
+//
+// When it starts, the data source asks the stream for the last message on each of its subjects, so the
+// stream only needs to keep one message per subject (MaxMsgsPerSubject: 1), as a JetStream key-value
+// bucket does. Once it has read those, it replaces the contents of the data store with them, and then it
+// applies each later message as it arrives.
+//
+// Closing the client closes the NATS connection.
+func NewNATSDataSourceFactory(
+	serverURL string,
+	subject string,
+	opts ...NATSOption,
+) subsystems.ComponentConfigurer[subsystems.DataSource] {
+	f := &natsDataSourceFactory{
+		serverURL:  serverURL,
+		subject:    subject,
+		retryDelay: initialRetryDelay,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *natsDataSourceFactory) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
+	if f.serverURL == "" {
+		return nil, errors.New("no NATS server URL was specified")
+	}
+	if f.subject == "" {
+		return nil, errors.New("no NATS subject was specified")
+	}
+	connOpts := append([]nats.Option{
+		nats.Name("LaunchDarkly Go SDK"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}, f.connOpts...)
+	conn, err := nats.Connect(f.serverURL, connOpts...)
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close() // COVERAGE: jetstream.New only fails if it is given invalid options
+		return nil, err
+	}
+	loggers := context.GetLogging().Loggers
+	loggers.SetPrefix("NATSDataSource:")
+	return &natsDataSource{
+		conn:       conn,
+		js:         js,
+		subject:    f.subject,
+		stream:     f.stream,
+		sink:       context.GetDataSourceUpdateSink(),
+		loggers:    loggers,
+		retryDelay: f.retryDelay,
+		doneCh:     make(chan struct{}),
+	}, nil
+}
+
+func (d *natsDataSource) IsInitialized() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.initialized
+}
+
+func (d *natsDataSource) Start(closeWhenReady chan<- struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.lock.Lock()
+	d.cancel = cancel
+	d.lock.Unlock()
+	go d.watchConnection(ctx, d.conn.StatusChanged(nats.DISCONNECTED, nats.CONNECTED))
+	go d.run(ctx, closeWhenReady)
+}
+
+// Close is called automatically when the client is closed.
+func (d *natsDataSource) Close() error {
+	d.closeOnce.Do(func() {
+		d.lock.Lock()
+		cancel, messages := d.cancel, d.messages
+		d.lock.Unlock()
+		if cancel != nil {
+			cancel()
+			if messages != nil {
+				messages.Stop()
+			}
+			<-d.doneCh
+		}
+		d.conn.Close()
+	})
+	return nil
+}
+
+func (d *natsDataSource) run(ctx context.Context, closeWhenReady chan<- struct{}) {
+	defer close(d.doneCh)
+
+	// Until the data source is initialized, items are collected here rather than sent to the store.
+	collected := map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor{}
+	for _, kind := range ldstoreimpl.AllKinds() {
+		collected[kind] = map[string]ldstoretypes.ItemDescriptor{}
+	}
+	for {
+		messages, pending, ok := d.subscribe(ctx)
+		if !ok {
+			return
+		}
+		if collected != nil && pending == 0 && d.initialize(collected, closeWhenReady) {
+			collected = nil
+		}
+		for {
+			msg, err := messages.Next()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				// The consumer recovers from most problems by itself; if it can't, we start over with a
+				// new one, which gets the last message on each subject again.
+				d.reportError(err)
+				messages.Stop()
+				break
+			}
+			d.processMessage(msg, collected)
+			if collected != nil {
+				if meta, err := msg.Metadata(); err == nil && meta.NumPending == 0 &&
+					d.initialize(collected, closeWhenReady) {
+					collected = nil
+				}
+			}
+		}
+	}
+}
+
+// subscribe creates a consumer that starts with the last message on each subject, retrying until it
+// succeeds or the data source is closed. It returns the consumer's messages, and how many messages
+// there were to read when it was created.
+func (d *natsDataSource) subscribe(ctx context.Context) (jetstream.MessagesContext, uint64, bool) {
+	delay := d.retryDelay
+	for {
+		messages, pending, err := d.trySubscribe(ctx)
+		if err == nil {
+			d.lock.Lock()
+			if ctx.Err() != nil {
+				// Close was called while we were subscribing, so it didn't know about this consumer.
+				d.lock.Unlock()
+				messages.Stop()
+				return nil, 0, false
+			}
+			d.messages = messages
+			initialized := d.initialized
+			d.lock.Unlock()
+			if initialized {
+				d.sink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+			}
+			return messages, pending, true
+		}
+		if ctx.Err() != nil {
+			return nil, 0, false
+		}
+		d.reportError(err)
+		select {
+		case <-ctx.Done():
+			return nil, 0, false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+func (d *natsDataSource) trySubscribe(ctx context.Context) (jetstream.MessagesContext, uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	stream := d.stream
+	if stream == "" {
+		var err error
+		if stream, err = d.js.StreamNameBySubject(ctx, d.subject); err != nil {
+			return nil, 0, err
+		}
+	}
+	consumer, err := d.js.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{d.subject},
+		DeliverPolicy:  jetstream.DeliverLastPerSubjectPolicy,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	messages, err := consumer.Messages()
+	if err != nil {
+		return nil, 0, err // COVERAGE: can't cause this in unit tests
+	}
+	var pending uint64
+	if info := consumer.CachedInfo(); info != nil {
+		pending = info.NumPending
+	}
+	return messages, pending, nil
+}
+
+func (d *natsDataSource) processMessage(
+	msg jetstream.Msg,
+	collected map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor,
+) {
+	kind, key, item, err := ldstoreimpl.ParseItemUpdateMessage(msg.Data())
+	if err != nil {
+		d.loggers.Errorf("Skipping invalid message on subject %q: %s", msg.Subject(), err)
+		return
+	}
+	if collected == nil {
+		d.sink.Upsert(kind, key, item)
+		return
+	}
+	if existing, ok := collected[kind][key]; !ok || item.Version > existing.Version {
+		collected[kind][key] = item
+	}
+}
+
+// initialize puts the collected items in the data store, returning false if the store could not be
+// updated, in which case it will be tried again after the next message.
+func (d *natsDataSource) initialize(
+	collected map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor,
+	closeWhenReady chan<- struct{},
+) bool {
+	allData := make([]ldstoretypes.Collection, 0, len(collected))
+	for kind, items := range collected {
+		coll := ldstoretypes.Collection{Kind: kind}
+		for key, item := range items {
+			coll.Items = append(coll.Items, ldstoretypes.KeyedItemDescriptor{Key: key, Item: item})
+		}
+		allData = append(allData, coll)
+	}
+	if !d.sink.Init(allData) {
+		return false
+	}
+	d.lock.Lock()
+	d.initialized = true
+	d.lock.Unlock()
+	d.sink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+	close(closeWhenReady)
+	return true
+}
+
+// watchConnection reports the data source as interrupted while the NATS connection is down. The
+// consumer picks up where it left off when the connection comes back.
+func (d *natsDataSource) watchConnection(ctx context.Context, statuses <-chan nats.Status) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case status := <-statuses:
+			switch status {
+			case nats.DISCONNECTED:
+				d.reportError(errors.New("lost connection to NATS server"))
+			case nats.CONNECTED:
+				if d.IsInitialized() {
+					d.sink.UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
+				}
+			}
+		}
+	}
+}
+
+func (d *natsDataSource) reportError(err error) {
+	d.loggers.Warnf("Error reading from subject %q: %s", d.subject, err)
+	d.sink.UpdateStatus(interfaces.DataSourceStateInterrupted, interfaces.DataSourceErrorInfo{
+		Kind:    interfaces.DataSourceErrorKindNetworkError,
+		Message: err.Error(),
+		Time:    time.Now(),
+	})
+}
@@ -0,0 +1,238 @@
+package ldnats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testStream  = "FLAGS"
+	testSubject = "flags.>"
+)
+
+type dataSourceTestParams struct {
+	server  *server.Server
+	js      jetstream.JetStream
+	updates *mocks.MockDataSourceUpdates
+	mockLog *ldlogtest.MockLog
+	ds      subsystems.DataSource
+	readyCh chan struct{}
+}
+
+func runServer(t *testing.T) *server.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natstest.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func connectJetStream(t *testing.T, s *server.Server) jetstream.JetStream {
+	conn, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	return js
+}
+
+func createStream(t *testing.T, js jetstream.JetStream) {
+	_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:              testStream,
+		Subjects:          []string{testSubject},
+		MaxMsgsPerSubject: 5, // more than one, to show that only the last message on each subject is used
+	})
+	require.NoError(t, err)
+}
+
+func (p dataSourceTestParams) publish(t *testing.T, subject string, data string) {
+	_, err := p.js.Publish(context.Background(), subject, []byte(data))
+	require.NoError(t, err)
+}
+
+func (p dataSourceTestParams) publishFlag(t *testing.T, flag ldmodel.FeatureFlag) {
+	item := datakinds.Features.Serialize(ldstoretypes.ItemDescriptor{Version: flag.Version, Item: &flag})
+	p.publish(t, "flags.features."+flag.Key, `{"kind":"features","key":"`+flag.Key+`","item":`+string(item)+`}`)
+}
+
+func newTestParams(t *testing.T) dataSourceTestParams {
+	s := runServer(t)
+	return dataSourceTestParams{
+		server:  s,
+		js:      connectJetStream(t, s),
+		updates: mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())),
+		mockLog: ldlogtest.NewMockLog(),
+		readyCh: make(chan struct{}),
+	}
+}
+
+func (p *dataSourceTestParams) start(t *testing.T, opts ...NATSOption) {
+	factory := NewNATSDataSourceFactory(p.server.ClientURL(), testSubject, opts...).(*natsDataSourceFactory)
+	factory.retryDelay = 10 * time.Millisecond
+	ds, err := factory.Build(subsystems.BasicClientContext{
+		DataSourceUpdateSink: p.updates,
+		Logging:              subsystems.LoggingConfiguration{Loggers: p.mockLog.Loggers},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ds.Close() })
+	p.ds = ds
+	ds.Start(p.readyCh)
+}
+
+func initDataAsMap(collections []ldstoretypes.Collection) map[string]map[string]int {
+	ret := make(map[string]map[string]int)
+	for _, coll := range collections {
+		items := make(map[string]int)
+		for _, item := range coll.Items {
+			items[item.Key] = item.Item.Version
+		}
+		ret[coll.Kind.GetName()] = items
+	}
+	return ret
+}
+
+func TestNATSDataSourceInitializesFromLastMessageOnEachSubject(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag2").Version(1).Build())
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(2).Build())
+	segment := ldbuilders.NewSegmentBuilder("segmentkey").Version(5).Build()
+	segmentItem := datakinds.Segments.Serialize(ldstoretypes.ItemDescriptor{Version: 5, Item: &segment})
+	p.publish(t, "flags.segments.segmentkey", `{"kind":"segments","key":"segmentkey","item":`+string(segmentItem)+`}`)
+
+	p.start(t)
+
+	inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+	assert.Equal(t, map[string]map[string]int{
+		"features": {"flag1": 2, "flag2": 1},
+		"segments": {"segmentkey": 5},
+	}, initDataAsMap(inited))
+	p.updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+	th.AssertChannelClosed(t, p.readyCh, time.Second)
+	assert.True(t, p.ds.IsInitialized())
+}
+
+func TestNATSDataSourceWithEmptyStreamInitializesImmediately(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+
+	p.start(t)
+
+	inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+	assert.Equal(t, map[string]map[string]int{"features": {}, "segments": {}}, initDataAsMap(inited))
+	th.AssertChannelClosed(t, p.readyCh, time.Second)
+}
+
+func TestNATSDataSourceUpsertsLaterMessages(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+	p.start(t)
+	p.updates.DataStore.WaitForNextInit(t, time.Second)
+
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+	p.updates.DataStore.WaitForUpsert(t, datakinds.Features, "flag1", 1, time.Second)
+
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(2).Deleted(true).Build())
+	p.updates.DataStore.WaitForDelete(t, datakinds.Features, "flag1", 2, time.Second)
+}
+
+func TestNATSDataSourceSkipsInvalidMessages(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+	p.publish(t, "flags.bad", `{"kind":"widgets"}`)
+	p.start(t)
+	p.updates.DataStore.WaitForNextInit(t, time.Second)
+
+	p.publish(t, "flags.bad", `not JSON`)
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+	p.updates.DataStore.WaitForUpsert(t, datakinds.Features, "flag1", 1, time.Second)
+
+	errors := p.mockLog.GetOutput(ldlog.Error)
+	require.Len(t, errors, 2)
+	assert.Equal(t, `NATSDataSource: Skipping invalid message on subject "flags.bad": unknown data kind "widgets"`,
+		errors[0])
+	assert.Contains(t, errors[1], `NATSDataSource: Skipping invalid message on subject "flags.bad": `)
+}
+
+func TestNATSDataSourceRetriesUntilStreamExists(t *testing.T) {
+	p := newTestParams(t)
+	p.start(t)
+
+	status := p.updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+	assert.Equal(t, interfaces.DataSourceErrorKindNetworkError, status.LastError.Kind)
+	assert.False(t, p.ds.IsInitialized())
+
+	createStream(t, p.js)
+	p.updates.DataStore.WaitForNextInit(t, 5*time.Second)
+	th.AssertChannelClosed(t, p.readyCh, time.Second)
+}
+
+func TestNATSDataSourceWithStreamName(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+	p.publishFlag(t, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+
+	p.start(t, WithStream(testStream))
+
+	inited := p.updates.DataStore.WaitForNextInit(t, time.Second)
+	assert.Equal(t, map[string]int{"flag1": 1}, initDataAsMap(inited)["features"])
+}
+
+func TestNATSDataSourceCloseClosesConnection(t *testing.T) {
+	p := newTestParams(t)
+	createStream(t, p.js)
+	p.start(t)
+	p.updates.DataStore.WaitForNextInit(t, time.Second)
+
+	require.NoError(t, p.ds.Close())
+	assert.True(t, p.ds.(*natsDataSource).conn.IsClosed())
+}
+
+func TestNATSDataSourceCloseWithoutStart(t *testing.T) {
+	s := runServer(t)
+	ds, err := NewNATSDataSourceFactory(s.ClientURL(), testSubject).Build(
+		subsystems.BasicClientContext{DataSourceUpdateSink: mocks.NewMockDataSourceUpdates(nil)})
+	require.NoError(t, err)
+	require.NoError(t, ds.Close())
+	assert.True(t, ds.(*natsDataSource).conn.IsClosed())
+}
+
+func TestNATSDataSourceFactoryValidatesParameters(t *testing.T) {
+	context := subsystems.BasicClientContext{DataSourceUpdateSink: mocks.NewMockDataSourceUpdates(nil)}
+
+	_, err := NewNATSDataSourceFactory("", testSubject).Build(context)
+	assert.EqualError(t, err, "no NATS server URL was specified")
+
+	_, err = NewNATSDataSourceFactory("nats://localhost:4222", "").Build(context)
+	assert.EqualError(t, err, "no NATS subject was specified")
+}
+
+func TestWithConnectionOptions(t *testing.T) {
+	factory := NewNATSDataSourceFactory("nats://localhost:4222", testSubject,
+		WithConnectionOptions(nats.Name("a")), WithConnectionOptions(nats.Name("b"))).(*natsDataSourceFactory)
+	assert.Len(t, factory.connOpts, 2)
+}
@@ -0,0 +1,8 @@
+// Package ldnats provides a data source that receives feature flag and segment updates from a NATS
+// JetStream stream, for applications whose flag management system publishes its changes to NATS.
+//
+// The messages are in the same format as for the ldkafka data source: the JSON that the SDK uses for
+// individual items in persistent data stores, wrapped with the kind and key of the item; see
+// [NewNATSDataSourceFactory]. This is a separate Go module, so that applications that do not use it do
+// not get a NATS client as a dependency of the SDK.
+package ldnats
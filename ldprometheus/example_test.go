@@ -0,0 +1,68 @@
+package ldprometheus_test
+
+import (
+	"bufio"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldclient "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldprometheus"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func ExampleNewCollector() {
+	// A real application would use ldclient.MakeClient with its SDK key; test data keeps this self-contained.
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("my-flag").BooleanFlag())
+	client, _ := ldclient.MakeCustomClient("sdk-key", ldclient.Config{
+		DataSource: td,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}, 5*time.Second)
+	defer client.Close()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(ldprometheus.NewCollector(client,
+		ldprometheus.WithConstLabels(prometheus.Labels{"environment": "production"})))
+
+	context := ldcontext.New("user-key")
+	_, _ = client.BoolVariation("my-flag", context, false)
+	_, _ = client.BoolVariation("unknown-flag", context, false)
+
+	// Serve the metrics the way an application would, and scrape them once.
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// Only some of the metrics are printed here, since the others depend on timing.
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "launchdarkly_evaluations_total") ||
+			strings.HasPrefix(line, `launchdarkly_evaluation_errors_total{environment="production",error_kind="FLAG_`) ||
+			strings.HasPrefix(line, "launchdarkly_data_source_state") {
+			fmt.Println(line)
+		}
+	}
+
+	// Output:
+	// launchdarkly_data_source_state{environment="production",state="INITIALIZING"} 0
+	// launchdarkly_data_source_state{environment="production",state="INTERRUPTED"} 0
+	// launchdarkly_data_source_state{environment="production",state="OFF"} 0
+	// launchdarkly_data_source_state{environment="production",state="VALID"} 1
+	// launchdarkly_evaluation_errors_total{environment="production",error_kind="FLAG_NOT_FOUND"} 1
+	// launchdarkly_evaluations_total{environment="production"} 2
+}
@@ -0,0 +1,7 @@
+// Package ldprometheus provides a Prometheus collector that reports what the SDK client is doing: how many
+// evaluations it has done and how many of them failed, the state of the analytics event buffer, the data
+// source and data store, and the Big Segment store.
+//
+// See [NewCollector] for how to use it. This is a separate Go module, so that applications that do not use
+// it do not get the Prometheus client library as a dependency of the SDK.
+package ldprometheus
@@ -0,0 +1,193 @@
+package ldprometheus
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	ldclient "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "launchdarkly"
+
+// allErrorKinds are the error kinds that always have a value in the evaluation errors metric, so that a
+// query for one of them does not come up empty just because that error has not happened yet.
+var allErrorKinds = []ldreason.EvalErrorKind{ //nolint:gochecknoglobals // read-only list
+	ldreason.EvalErrorClientNotReady,
+	ldreason.EvalErrorFlagNotFound,
+	ldreason.EvalErrorMalformedFlag,
+	ldreason.EvalErrorUserNotSpecified,
+	ldreason.EvalErrorWrongType,
+	ldreason.EvalErrorException,
+}
+
+// allDataSourceStates are the states that the data source state metric has a series for.
+var allDataSourceStates = []interfaces.DataSourceState{ //nolint:gochecknoglobals // read-only list
+	interfaces.DataSourceStateInitializing,
+	interfaces.DataSourceStateValid,
+	interfaces.DataSourceStateInterrupted,
+	interfaces.DataSourceStateOff,
+}
+
+// CollectorOption is an optional parameter for [NewCollector].
+type CollectorOption func(*collectorOptions)
+
+// WithConstLabels adds labels with fixed values to every metric, for instance to tell apart the clients
+// for several LaunchDarkly environments in one application.
+func WithConstLabels(labels prometheus.Labels) CollectorOption {
+	return func(o *collectorOptions) {
+		for name, value := range labels {
+			o.constLabels[name] = value
+		}
+	}
+}
+
+type collectorOptions struct {
+	constLabels prometheus.Labels
+}
+
+// Collector is a [prometheus.Collector] that reports metrics about an SDK client. It gets the values from
+// [ldclient.LDClient.GetStats] and from the client's status providers each time the metrics are collected,
+// so it does not keep any state of its own.
+//
+// These are the metrics:
+//   - launchdarkly_evaluations_total: the number of flag evaluations.
+//   - launchdarkly_evaluation_errors_total: the number of evaluations that returned an error, with an
+//     "error_kind" label such as "FLAG_NOT_FOUND".
+//   - launchdarkly_events_queued: the number of analytics events recorded since the last payload was sent.
+//   - launchdarkly_events_dropped_total: the number of analytics events discarded because the buffer was
+//     full. This is only updated when a diagnostic event is sent.
+//   - launchdarkly_data_source_state: 1 for the current state of the data source and 0 for the others,
+//     with a "state" label such as "VALID".
+//   - launchdarkly_data_source_last_update_age_seconds: the time since the data source last stored data.
+//   - launchdarkly_data_store_cache_hits_total and launchdarkly_data_store_cache_misses_total: the number
+//     of persistent data store queries that were and were not answered from the cache.
+//   - launchdarkly_data_store_cache_hit_ratio: hits divided by the total of hits and misses.
+//   - launchdarkly_big_segment_store_available and launchdarkly_big_segment_store_stale: 1 if the Big
+//     Segment store status has that property, otherwise 0.
+//
+// A metric that does not apply to the client's configuration is left out: the event metrics if events are
+// not being sent with [ldcomponents.SendEvents], the cache metrics if there is no persistent data store or
+// it is not cached, and the Big Segment metrics if there is no Big Segment store. The last update age and
+// the hit ratio are also left out until there is a value to report.
+type Collector struct {
+	client *ldclient.LDClient
+	now    func() time.Time
+
+	evaluations      *prometheus.Desc
+	evaluationErrors *prometheus.Desc
+	eventsQueued     *prometheus.Desc
+	eventsDropped    *prometheus.Desc
+	dataSourceState  *prometheus.Desc
+	lastUpdateAge    *prometheus.Desc
+	cacheHits        *prometheus.Desc
+	cacheMisses      *prometheus.Desc
+	cacheHitRatio    *prometheus.Desc
+	bigSegmentsAvail *prometheus.Desc
+	bigSegmentsStale *prometheus.Desc
+}
+
+// NewCollector creates a [Collector] for the specified client. To use it, register it with a Prometheus
+// registry:
+//
+//	client, _ := ld.MakeClient(sdkKey, 5*time.Second)
+//	registry := prometheus.NewRegistry()
+//	registry.MustRegister(ldprometheus.NewCollector(client))
+//
+// The collector does not close the client; it should not be used after the client is closed.
+func NewCollector(client *ldclient.LDClient, options ...CollectorOption) *Collector {
+	o := collectorOptions{constLabels: prometheus.Labels{}}
+	for _, option := range options {
+		option(&o)
+	}
+	desc := func(name, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, variableLabels, o.constLabels)
+	}
+	return &Collector{
+		client: client,
+		now:    time.Now,
+		evaluations: desc("evaluations_total",
+			"Number of feature flag evaluations."),
+		evaluationErrors: desc("evaluation_errors_total",
+			"Number of feature flag evaluations that returned an error, by kind of error.", "error_kind"),
+		eventsQueued: desc("events_queued",
+			"Number of analytics events recorded since the last payload was sent."),
+		eventsDropped: desc("events_dropped_total",
+			"Number of analytics events discarded because the event buffer was full."),
+		dataSourceState: desc("data_source_state",
+			"1 for the current state of the data source, 0 for the other states.", "state"),
+		lastUpdateAge: desc("data_source_last_update_age_seconds",
+			"Time since the data source last stored flag data successfully."),
+		cacheHits: desc("data_store_cache_hits_total",
+			"Number of persistent data store queries answered from the cache."),
+		cacheMisses: desc("data_store_cache_misses_total",
+			"Number of persistent data store queries that had to go to the database."),
+		cacheHitRatio: desc("data_store_cache_hit_ratio",
+			"Proportion of persistent data store queries answered from the cache."),
+		bigSegmentsAvail: desc("big_segment_store_available",
+			"1 if the Big Segment store is available, otherwise 0."),
+		bigSegmentsStale: desc("big_segment_store_stale",
+			"1 if the Big Segment store has not been updated recently, otherwise 0."),
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.evaluations, c.evaluationErrors, c.eventsQueued, c.eventsDropped, c.dataSourceState, c.lastUpdateAge,
+		c.cacheHits, c.cacheMisses, c.cacheHitRatio, c.bigSegmentsAvail, c.bigSegmentsStale,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements [prometheus.Collector].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.GetStats()
+
+	ch <- prometheus.MustNewConstMetric(c.evaluations, prometheus.CounterValue, float64(stats.Evaluations))
+	for _, kind := range allErrorKinds {
+		ch <- prometheus.MustNewConstMetric(c.evaluationErrors, prometheus.CounterValue,
+			float64(stats.EvaluationErrors[kind]), string(kind))
+	}
+
+	if stats.EventStatsAvailable {
+		ch <- prometheus.MustNewConstMetric(c.eventsQueued, prometheus.GaugeValue, float64(stats.QueuedEvents))
+		ch <- prometheus.MustNewConstMetric(c.eventsDropped, prometheus.CounterValue, float64(stats.DroppedEvents))
+	}
+
+	dataSourceState := c.client.GetDataSourceStatusProvider().GetStatus().State
+	for _, state := range allDataSourceStates {
+		ch <- prometheus.MustNewConstMetric(c.dataSourceState, prometheus.GaugeValue,
+			boolToFloat(state == dataSourceState), string(state))
+	}
+	if !stats.LastSuccessfulDataUpdate.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.lastUpdateAge, prometheus.GaugeValue,
+			c.now().Sub(stats.LastSuccessfulDataUpdate).Seconds())
+	}
+
+	if stats.DataStoreCacheStatsAvailable {
+		ch <- prometheus.MustNewConstMetric(c.cacheHits, prometheus.CounterValue, float64(stats.DataStoreCacheHits))
+		ch <- prometheus.MustNewConstMetric(c.cacheMisses, prometheus.CounterValue,
+			float64(stats.DataStoreCacheMisses))
+		if total := stats.DataStoreCacheHits + stats.DataStoreCacheMisses; total > 0 {
+			ch <- prometheus.MustNewConstMetric(c.cacheHitRatio, prometheus.GaugeValue,
+				float64(stats.DataStoreCacheHits)/float64(total))
+		}
+	}
+
+	if stats.BigSegmentsEnabled {
+		status := c.client.GetBigSegmentStoreStatusProvider().GetStatus()
+		ch <- prometheus.MustNewConstMetric(c.bigSegmentsAvail, prometheus.GaugeValue, boolToFloat(status.Available))
+		ch <- prometheus.MustNewConstMetric(c.bigSegmentsStale, prometheus.GaugeValue, boolToFloat(status.Stale))
+	}
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
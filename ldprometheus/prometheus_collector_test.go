@@ -0,0 +1,226 @@
+package ldprometheus
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	ldclient "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testContext = ldcontext.New("user-key") //nolint:gochecknoglobals
+
+func makeTestClient(t *testing.T, modConfig func(*ldclient.Config)) *ldclient.LDClient {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flag-key").BooleanFlag())
+	config := ldclient.Config{
+		DataSource: td,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}
+	if modConfig != nil {
+		modConfig(&config)
+	}
+	client, err := ldclient.MakeCustomClient("sdk-key", config, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// makeTestCollector returns a collector whose clock is always five seconds after the client's last data
+// update, so that the reported age is predictable.
+func makeTestCollector(client *ldclient.LDClient, options ...CollectorOption) *Collector {
+	c := NewCollector(client, options...)
+	c.now = func() time.Time { return client.GetStats().LastSuccessfulDataUpdate.Add(5 * time.Second) }
+	return c
+}
+
+func assertMetrics(t *testing.T, c prometheus.Collector, expected string, metricNames ...string) {
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), metricNames...))
+}
+
+func TestCollectorReportsEvaluations(t *testing.T) {
+	client := makeTestClient(t, nil)
+	_, _ = client.BoolVariation("flag-key", testContext, false)
+	_, _ = client.StringVariation("flag-key", testContext, "")
+	_, _ = client.BoolVariation("no-such-flag", testContext, false)
+
+	assertMetrics(t, makeTestCollector(client), `
+# HELP launchdarkly_evaluations_total Number of feature flag evaluations.
+# TYPE launchdarkly_evaluations_total counter
+launchdarkly_evaluations_total 3
+# HELP launchdarkly_evaluation_errors_total Number of feature flag evaluations that returned an error, by kind of error.
+# TYPE launchdarkly_evaluation_errors_total counter
+launchdarkly_evaluation_errors_total{error_kind="CLIENT_NOT_READY"} 0
+launchdarkly_evaluation_errors_total{error_kind="EXCEPTION"} 0
+launchdarkly_evaluation_errors_total{error_kind="FLAG_NOT_FOUND"} 1
+launchdarkly_evaluation_errors_total{error_kind="MALFORMED_FLAG"} 0
+launchdarkly_evaluation_errors_total{error_kind="USER_NOT_SPECIFIED"} 0
+launchdarkly_evaluation_errors_total{error_kind="WRONG_TYPE"} 1
+`, "launchdarkly_evaluations_total", "launchdarkly_evaluation_errors_total")
+}
+
+func TestCollectorReportsDataSource(t *testing.T) {
+	client := makeTestClient(t, nil)
+
+	assertMetrics(t, makeTestCollector(client), `
+# HELP launchdarkly_data_source_state 1 for the current state of the data source, 0 for the other states.
+# TYPE launchdarkly_data_source_state gauge
+launchdarkly_data_source_state{state="INITIALIZING"} 0
+launchdarkly_data_source_state{state="INTERRUPTED"} 0
+launchdarkly_data_source_state{state="OFF"} 0
+launchdarkly_data_source_state{state="VALID"} 1
+# HELP launchdarkly_data_source_last_update_age_seconds Time since the data source last stored flag data successfully.
+# TYPE launchdarkly_data_source_last_update_age_seconds gauge
+launchdarkly_data_source_last_update_age_seconds 5
+`, "launchdarkly_data_source_state", "launchdarkly_data_source_last_update_age_seconds")
+}
+
+func TestCollectorOmitsMetricsThatDoNotApply(t *testing.T) {
+	client := makeTestClient(t, nil)
+
+	count := testutil.CollectAndCount(makeTestCollector(client),
+		"launchdarkly_events_queued",
+		"launchdarkly_events_dropped_total",
+		"launchdarkly_data_store_cache_hits_total",
+		"launchdarkly_data_store_cache_misses_total",
+		"launchdarkly_data_store_cache_hit_ratio",
+		"launchdarkly_big_segment_store_available",
+		"launchdarkly_big_segment_store_stale",
+	)
+	assert.Equal(t, 0, count)
+}
+
+func TestCollectorOmitsLastUpdateAgeBeforeFirstUpdate(t *testing.T) {
+	client, _ := ldclient.MakeCustomClient("sdk-key", ldclient.Config{
+		DataSource: mocks.DataSourceThatNeverInitializes(),
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(ldlog.NewDisabledLoggers()),
+	}, 0)
+	defer client.Close()
+
+	c := NewCollector(client)
+	assert.Equal(t, 0, testutil.CollectAndCount(c, "launchdarkly_data_source_last_update_age_seconds"))
+	assertMetrics(t, c, `
+# HELP launchdarkly_data_source_state 1 for the current state of the data source, 0 for the other states.
+# TYPE launchdarkly_data_source_state gauge
+launchdarkly_data_source_state{state="INITIALIZING"} 1
+launchdarkly_data_source_state{state="INTERRUPTED"} 0
+launchdarkly_data_source_state{state="OFF"} 0
+launchdarkly_data_source_state{state="VALID"} 0
+`, "launchdarkly_data_source_state")
+}
+
+func TestCollectorReportsEvents(t *testing.T) {
+	handler, _ := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		client := makeTestClient(t, func(c *ldclient.Config) {
+			c.DiagnosticOptOut = true
+			c.Events = ldcomponents.SendEvents().FlushInterval(time.Hour)
+			c.ServiceEndpoints = interfaces.ServiceEndpoints{Events: server.URL}
+		})
+		_ = client.Identify(testContext)
+		_ = client.TrackEvent("event-key", testContext)
+
+		assertMetrics(t, makeTestCollector(client), `
+# HELP launchdarkly_events_queued Number of analytics events recorded since the last payload was sent.
+# TYPE launchdarkly_events_queued gauge
+launchdarkly_events_queued 2
+# HELP launchdarkly_events_dropped_total Number of analytics events discarded because the event buffer was full.
+# TYPE launchdarkly_events_dropped_total counter
+launchdarkly_events_dropped_total 0
+`, "launchdarkly_events_queued", "launchdarkly_events_dropped_total")
+	})
+}
+
+func TestCollectorReportsDataStoreCache(t *testing.T) {
+	client := makeTestClient(t, func(c *ldclient.Config) {
+		c.DataStore = ldcomponents.PersistentDataStore(
+			mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{
+				Instance: mocks.NewMockPersistentDataStore(),
+			},
+		)
+	})
+	c := makeTestCollector(client)
+	assert.Equal(t, 0, testutil.CollectAndCount(c, "launchdarkly_data_store_cache_hit_ratio"))
+
+	for i := 0; i < 4; i++ {
+		_, _ = client.BoolVariation("flag-key", testContext, false)
+	}
+	stats := client.GetStats()
+	require.True(t, stats.DataStoreCacheStatsAvailable)
+	require.Greater(t, stats.DataStoreCacheHits, int64(0))
+
+	assertMetrics(t, c, fmt.Sprintf(`
+# HELP launchdarkly_data_store_cache_hits_total Number of persistent data store queries answered from the cache.
+# TYPE launchdarkly_data_store_cache_hits_total counter
+launchdarkly_data_store_cache_hits_total %d
+# HELP launchdarkly_data_store_cache_misses_total Number of persistent data store queries that had to go to the database.
+# TYPE launchdarkly_data_store_cache_misses_total counter
+launchdarkly_data_store_cache_misses_total %d
+# HELP launchdarkly_data_store_cache_hit_ratio Proportion of persistent data store queries answered from the cache.
+# TYPE launchdarkly_data_store_cache_hit_ratio gauge
+launchdarkly_data_store_cache_hit_ratio %g
+`, stats.DataStoreCacheHits, stats.DataStoreCacheMisses,
+		float64(stats.DataStoreCacheHits)/float64(stats.DataStoreCacheHits+stats.DataStoreCacheMisses)),
+		"launchdarkly_data_store_cache_hits_total", "launchdarkly_data_store_cache_misses_total",
+		"launchdarkly_data_store_cache_hit_ratio")
+}
+
+func TestCollectorReportsBigSegmentStore(t *testing.T) {
+	bsStore := &mocks.MockBigSegmentStore{}
+	bsStore.TestSetMetadataToCurrentTime()
+	client := makeTestClient(t, func(c *ldclient.Config) {
+		c.BigSegments = ldcomponents.BigSegments(
+			mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: bsStore},
+		)
+	})
+	require.Eventually(t, func() bool {
+		return client.GetBigSegmentStoreStatusProvider().GetStatus().Available
+	}, time.Second, 10*time.Millisecond)
+
+	assertMetrics(t, makeTestCollector(client), `
+# HELP launchdarkly_big_segment_store_available 1 if the Big Segment store is available, otherwise 0.
+# TYPE launchdarkly_big_segment_store_available gauge
+launchdarkly_big_segment_store_available 1
+# HELP launchdarkly_big_segment_store_stale 1 if the Big Segment store has not been updated recently, otherwise 0.
+# TYPE launchdarkly_big_segment_store_stale gauge
+launchdarkly_big_segment_store_stale 0
+`, "launchdarkly_big_segment_store_available", "launchdarkly_big_segment_store_stale")
+}
+
+func TestCollectorWithConstLabels(t *testing.T) {
+	client := makeTestClient(t, nil)
+
+	assertMetrics(t, makeTestCollector(client, WithConstLabels(prometheus.Labels{"environment": "production"})), `
+# HELP launchdarkly_evaluations_total Number of feature flag evaluations.
+# TYPE launchdarkly_evaluations_total counter
+launchdarkly_evaluations_total{environment="production"} 0
+`, "launchdarkly_evaluations_total")
+}
+
+func TestCollectorsForSeveralClientsCanBeRegistered(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(NewCollector(makeTestClient(t, nil),
+		WithConstLabels(prometheus.Labels{"environment": "a"}))))
+	require.NoError(t, registry.Register(NewCollector(makeTestClient(t, nil),
+		WithConstLabels(prometheus.Labels{"environment": "b"}))))
+
+	assert.Error(t, registry.Register(NewCollector(makeTestClient(t, nil),
+		WithConstLabels(prometheus.Labels{"environment": "a"}))))
+}
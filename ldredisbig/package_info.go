@@ -0,0 +1,5 @@
+// Package ldredisbig provides a Big Segment store that reads Big Segment memberships from Redis.
+//
+// See [NewRedisBigSegmentStoreFactory] for how to use it. This is a separate Go module, so that
+// applications that do not use it do not get the Redis client as a dependency of the SDK.
+package ldredisbig
@@ -0,0 +1,153 @@
+package ldredisbig
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultURL is the Redis URL that is used if an empty URL is passed to NewRedisBigSegmentStoreFactory.
+	DefaultURL = "redis://localhost:6379"
+
+	// DefaultPrefix is the string that is used as the first part of all Redis keys used by the store, if no
+	// other prefix is specified with WithPrefix.
+	DefaultPrefix = "launchdarkly"
+
+	includeKeyPart    = ":big_segment_include:"
+	excludeKeyPart    = ":big_segment_exclude:"
+	metadataKeyPart   = ":big_segments_metadata"
+	lastUpToDateField = "lastUpToDate"
+)
+
+// RedisOption is an optional parameter for [NewRedisBigSegmentStoreFactory].
+type RedisOption func(*redisBigSegmentStoreFactory)
+
+// WithPrefix sets the string that is used as the first part of all Redis keys used by the store, so that
+// several SDK environments can share one Redis database. The default is [DefaultPrefix].
+func WithPrefix(prefix string) RedisOption {
+	return func(f *redisBigSegmentStoreFactory) {
+		f.prefix = prefix
+	}
+}
+
+// WithClient makes the store use an existing Redis client instead of creating one for the URL, for
+// instance a redis.ClusterClient, or a client that the application also uses for other things. The
+// store does not close it.
+func WithClient(client redis.UniversalClient) RedisOption {
+	return func(f *redisBigSegmentStoreFactory) {
+		f.client = client
+	}
+}
+
+type redisBigSegmentStoreFactory struct {
+	url    string
+	prefix string
+	client redis.UniversalClient
+}
+
+type redisBigSegmentStore struct {
+	client    redis.UniversalClient
+	ownClient bool
+	prefix    string
+}
+
+// NewRedisBigSegmentStoreFactory returns a configuration for a Big Segment store that uses the Redis
+// database at the specified URL, such as "redis://localhost:6379"; a "rediss" URL connects with TLS. The
+// URL can also set the password, the database number, and timeouts, as described for redis.ParseURL. To
+// use it, pass it to ldcomponents.BigSegments and store the result in the BigSegments field of
+// [github.com/launchdarkly/go-server-sdk/v7.Config]:
+//
+//	config := ld.Config{
+//	    BigSegments: ldcomponents.BigSegments(
+//	        ldredisbig.NewRedisBigSegmentStoreFactory("redis://my-redis:6379"),
+//	    ),
+//	}
+//
+// The store only reads from Redis; whatever synchronizes the Big Segments must write them in this
+// layout, where prefix is [DefaultPrefix] unless WithPrefix says otherwise:
+//
+//   - "<prefix>:big_segment_include:<context hash>" is a Redis Set of the segment references that the
+//     context is included in, and "<prefix>:big_segment_exclude:<context hash>" is a Set of the ones it is
+//     excluded from. The context hash is the base64-encoded hash of the context key that the SDK passes
+//     to the store.
+//   - "<prefix>:big_segments_metadata" is a Redis Hash whose "lastUpToDate" field is the time the data
+//     was last brought up to date, in milliseconds since the Unix epoch.
+//
+// Both Sets are read in one pipelined round trip each time the SDK queries a context's memberships.
+func NewRedisBigSegmentStoreFactory(
+	url string,
+	opts ...RedisOption,
+) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+	f := &redisBigSegmentStoreFactory{url: url, prefix: DefaultPrefix}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Build is called internally by the SDK.
+func (f *redisBigSegmentStoreFactory) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.BigSegmentStore, error) {
+	client, ownClient := f.client, false
+	if client == nil {
+		url := f.url
+		if url == "" {
+			url = DefaultURL
+		}
+		options, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, err
+		}
+		client, ownClient = redis.NewClient(options), true
+	}
+	prefix := f.prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &redisBigSegmentStore{client: client, ownClient: ownClient, prefix: prefix}, nil
+}
+
+func (store *redisBigSegmentStore) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	value, err := store.client.HGet(context.Background(), store.prefix+metadataKeyPart, lastUpToDateField).Result()
+	if errors.Is(err, redis.Nil) {
+		return subsystems.BigSegmentStoreMetadata{}, nil // the store has never been synchronized
+	}
+	if err != nil {
+		return subsystems.BigSegmentStoreMetadata{}, err
+	}
+	millis, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return subsystems.BigSegmentStoreMetadata{}, err
+	}
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: ldtime.UnixMillisecondTime(millis)}, nil
+}
+
+func (store *redisBigSegmentStore) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	ctx := context.Background()
+	var included, excluded *redis.StringSliceCmd
+	_, err := store.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		included = pipe.SMembers(ctx, store.prefix+includeKeyPart+contextHash)
+		excluded = pipe.SMembers(ctx, store.prefix+excludeKeyPart+contextHash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(included.Val(), excluded.Val()), nil
+}
+
+// Close is called automatically when the client is closed.
+func (store *redisBigSegmentStore) Close() error {
+	if store.ownClient {
+		return store.client.Close()
+	}
+	return nil
+}
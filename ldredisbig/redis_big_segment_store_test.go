@@ -0,0 +1,167 @@
+package ldredisbig
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/storetest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestContext() subsystems.ClientContext {
+	return subsystems.BasicClientContext{
+		Logging: subsystems.LoggingConfiguration{Loggers: ldlog.NewDisabledLoggers()},
+	}
+}
+
+func prefixOrDefault(prefix string) string {
+	if prefix == "" {
+		return DefaultPrefix
+	}
+	return prefix
+}
+
+func TestRedisBigSegmentStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	url := "redis://" + server.Addr()
+
+	storetest.NewBigSegmentStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+			return NewRedisBigSegmentStoreFactory(url, WithPrefix(prefix))
+		},
+		func(prefix string) error {
+			for _, key := range server.Keys() {
+				if strings.HasPrefix(key, prefixOrDefault(prefix)+":") {
+					server.Del(key)
+				}
+			}
+			return nil
+		},
+		func(prefix string, metadata subsystems.BigSegmentStoreMetadata) error {
+			server.HSet(prefixOrDefault(prefix)+":big_segments_metadata",
+				"lastUpToDate", strconv.FormatUint(uint64(metadata.LastUpToDate), 10))
+			return nil
+		},
+		func(prefix string, contextHash string, included []string, excluded []string) error {
+			for _, ref := range included {
+				if _, err := server.SAdd(prefixOrDefault(prefix)+":big_segment_include:"+contextHash, ref); err != nil {
+					return err
+				}
+			}
+			for _, ref := range excluded {
+				if _, err := server.SAdd(prefixOrDefault(prefix)+":big_segment_exclude:"+contextHash, ref); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	).Run(t)
+}
+
+// countingHook counts the commands that the client sends, and how many round trips they take.
+type countingHook struct {
+	roundTrips, commands int
+}
+
+func (h *countingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *countingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.roundTrips++
+		h.commands++
+		return next(ctx, cmd)
+	}
+}
+
+func (h *countingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.roundTrips++
+		h.commands += len(cmds)
+		return next(ctx, cmds)
+	}
+}
+
+func TestGetMembershipReadsBothSetsInOneRoundTrip(t *testing.T) {
+	server := miniredis.RunT(t)
+	_, _ = server.SAdd("test:big_segment_include:hash1", "seg1")
+	_, _ = server.SAdd("test:big_segment_exclude:hash1", "seg2")
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	hook := &countingHook{}
+	client.AddHook(hook)
+
+	store, err := NewRedisBigSegmentStoreFactory("", WithClient(client), WithPrefix("test")).Build(makeTestContext())
+	require.NoError(t, err)
+	membership, err := store.GetMembership("hash1")
+	require.NoError(t, err)
+
+	assert.Equal(t, ldvalue.NewOptionalBool(true), membership.CheckMembership("seg1"))
+	assert.Equal(t, ldvalue.NewOptionalBool(false), membership.CheckMembership("seg2"))
+	assert.Equal(t, ldvalue.OptionalBool{}, membership.CheckMembership("seg3"))
+	assert.Equal(t, 1, hook.roundTrips)
+	assert.Equal(t, 2, hook.commands)
+
+	require.NoError(t, store.Close())
+	assert.NoError(t, client.Ping(context.Background()).Err()) // the store did not close a client it was given
+}
+
+func TestGetMetadataWithInvalidTimestamp(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.HSet(DefaultPrefix+":big_segments_metadata", "lastUpToDate", "yesterday")
+	store, err := NewRedisBigSegmentStoreFactory("redis://" + server.Addr()).Build(makeTestContext())
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.GetMetadata()
+	assert.Error(t, err)
+}
+
+func TestErrorsFromRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := NewRedisBigSegmentStoreFactory("redis://" + server.Addr()).Build(makeTestContext())
+	require.NoError(t, err)
+	defer store.Close()
+	server.SetError("server is unavailable")
+
+	_, err = store.GetMetadata()
+	assert.Error(t, err)
+	_, err = store.GetMembership("hash1")
+	assert.Error(t, err)
+}
+
+func TestBuildOptions(t *testing.T) {
+	t.Run("default URL", func(t *testing.T) {
+		store, err := NewRedisBigSegmentStoreFactory("").Build(makeTestContext())
+		require.NoError(t, err)
+		defer store.Close()
+		assert.Equal(t, "localhost:6379", store.(*redisBigSegmentStore).client.(*redis.Client).Options().Addr)
+		assert.Equal(t, DefaultPrefix, store.(*redisBigSegmentStore).prefix)
+	})
+
+	t.Run("URL with database and password", func(t *testing.T) {
+		store, err := NewRedisBigSegmentStoreFactory("rediss://:secret@my-redis:6380/2").Build(makeTestContext())
+		require.NoError(t, err)
+		defer store.Close()
+		options := store.(*redisBigSegmentStore).client.(*redis.Client).Options()
+		assert.Equal(t, "my-redis:6380", options.Addr)
+		assert.Equal(t, "secret", options.Password)
+		assert.Equal(t, 2, options.DB)
+		assert.NotNil(t, options.TLSConfig)
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		_, err := NewRedisBigSegmentStoreFactory("http://my-redis").Build(makeTestContext())
+		assert.Error(t, err)
+	})
+}
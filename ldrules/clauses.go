@@ -0,0 +1,38 @@
+package ldrules
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// UserInSegment returns a clause that matches if the context is included in the segment with the
+// specified key.
+func UserInSegment(segmentKey string) ldmodel.Clause {
+	return ldbuilders.SegmentMatchClause(segmentKey)
+}
+
+// AttributeEquals returns a clause that matches if the specified attribute of the context of the
+// specified kind is equal to value.
+//
+// The attribute is a simple attribute name, not a path reference. If the attribute's value is an array,
+// the clause matches if any element of the array is equal to value.
+func AttributeEquals(kind ldcontext.Kind, attr string, value ldvalue.Value) ldmodel.Clause {
+	return ldbuilders.ClauseWithKind(kind, attr, ldmodel.OperatorIn, value)
+}
+
+// AttributeStartsWith returns a clause that matches if the specified attribute of the context of the
+// specified kind is a string that begins with prefix.
+func AttributeStartsWith(kind ldcontext.Kind, attr string, prefix string) ldmodel.Clause {
+	return ldbuilders.ClauseWithKind(kind, attr, ldmodel.OperatorStartsWith, ldvalue.String(prefix))
+}
+
+// AttributeMatchesRegex returns a clause that matches if the specified attribute of the context of the
+// specified kind is a string that matches the regular expression pattern.
+//
+// The pattern uses the syntax of the Go regexp package, and it can match any part of the string unless it
+// is anchored with ^ or $. If the pattern is not a valid regular expression, the clause never matches.
+func AttributeMatchesRegex(kind ldcontext.Kind, attr string, pattern string) ldmodel.Clause {
+	return ldbuilders.ClauseWithKind(kind, attr, ldmodel.OperatorMatches, ldvalue.String(pattern))
+}
@@ -0,0 +1,74 @@
+package ldrules
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type segmentProvider map[string]*ldmodel.Segment
+
+func (s segmentProvider) GetFeatureFlag(key string) *ldmodel.FeatureFlag { return nil }
+
+func (s segmentProvider) GetSegment(key string) *ldmodel.Segment { return s[key] }
+
+func clauseMatches(clause ldmodel.Clause, context ldcontext.Context, segments segmentProvider) bool {
+	flag := ldbuilders.NewFlagBuilder("flag").On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule").Variation(1).Clauses(clause)).
+		FallthroughVariation(0).
+		Build()
+	result := evaluation.NewEvaluator(segments).Evaluate(&flag, context, nil)
+	return result.Detail.Value.BoolValue()
+}
+
+func TestUserInSegment(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment1").Included("a").Build()
+	segments := segmentProvider{"segment1": &segment}
+
+	clause := UserInSegment("segment1")
+
+	assert.Equal(t, ldmodel.OperatorSegmentMatch, clause.Op)
+	assert.Equal(t, []ldvalue.Value{ldvalue.String("segment1")}, clause.Values)
+	assert.True(t, clauseMatches(clause, ldcontext.New("a"), segments))
+	assert.False(t, clauseMatches(clause, ldcontext.New("b"), segments))
+}
+
+func TestAttributeEquals(t *testing.T) {
+	clause := AttributeEquals("org", "plan", ldvalue.String("enterprise"))
+
+	assert.Equal(t, ldcontext.Kind("org"), clause.ContextKind)
+	assert.Equal(t, ldattr.NewLiteralRef("plan"), clause.Attribute)
+	assert.Equal(t, ldmodel.OperatorIn, clause.Op)
+	org := func(plan string) ldcontext.Context {
+		return ldcontext.NewBuilder("o").Kind("org").SetString("plan", plan).Build()
+	}
+	assert.True(t, clauseMatches(clause, org("enterprise"), nil))
+	assert.False(t, clauseMatches(clause, org("free"), nil))
+	assert.False(t, clauseMatches(clause, ldcontext.NewBuilder("u").SetString("plan", "enterprise").Build(), nil))
+}
+
+func TestAttributeStartsWith(t *testing.T) {
+	clause := AttributeStartsWith(ldcontext.DefaultKind, "email", "admin@")
+
+	assert.Equal(t, ldmodel.OperatorStartsWith, clause.Op)
+	assert.True(t, clauseMatches(clause, ldcontext.NewBuilder("u").SetString("email", "admin@example.com").Build(), nil))
+	assert.False(t, clauseMatches(clause, ldcontext.NewBuilder("u").SetString("email", "me@example.com").Build(), nil))
+}
+
+func TestAttributeMatchesRegex(t *testing.T) {
+	clause := AttributeMatchesRegex(ldcontext.DefaultKind, "email", `@example\.(com|org)$`)
+
+	assert.Equal(t, ldmodel.OperatorMatches, clause.Op)
+	assert.True(t, clauseMatches(clause, ldcontext.NewBuilder("u").SetString("email", "a@example.org").Build(), nil))
+	assert.False(t, clauseMatches(clause, ldcontext.NewBuilder("u").SetString("email", "a@example.net").Build(), nil))
+	assert.False(t, clauseMatches(AttributeMatchesRegex(ldcontext.DefaultKind, "email", "("),
+		ldcontext.NewBuilder("u").SetString("email", "(").Build(), nil))
+}
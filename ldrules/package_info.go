@@ -0,0 +1,19 @@
+// Package ldrules contains helpers for building common flag and segment targeting clauses.
+//
+// Each function returns an ldmodel.Clause that can be passed to the Clauses method of
+// ldbuilders.RuleBuilder or ldbuilders.SegmentRuleBuilder, so that code which constructs flags does not
+// need to fill in ldmodel.Clause fields or choose operators by hand:
+//
+//	flag := ldbuilders.NewFlagBuilder("my-flag").
+//	    On(true).
+//	    Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+//	    AddRule(ldbuilders.NewRuleBuilder().ID("rule1").Variation(1).Clauses(
+//	        ldrules.AttributeStartsWith(ldcontext.DefaultKind, "email", "admin@"),
+//	        ldrules.UserInSegment("beta-testers"),
+//	    )).
+//	    FallthroughVariation(0).
+//	    Build()
+//
+// A clause applies to a single context kind. If the context being evaluated is a multi-kind context, the
+// clause looks at the individual context of that kind; if there is none, the clause does not match.
+package ldrules
@@ -31,6 +31,7 @@ type MigrationOpTracker struct {
 	errors           map[ldmigration.Origin]struct{}
 	latencyMs        map[ldmigration.Origin]int
 	sampler          *ldsampling.RatioSampler
+	nonConsenting    bool // see LDClient.TrackMigrationOp
 
 	lock sync.Mutex
 }
@@ -134,10 +135,14 @@ func (t *MigrationOpTracker) Build() (*ldevents.MigrationOpEventData, error) {
 		return nil, err
 	}
 
+	eventContext := ldevents.Context(t.context)
+	if t.nonConsenting {
+		eventContext = nonConsentingMigrationContext
+	}
 	event := ldevents.MigrationOpEventData{
 		BaseEvent: ldevents.BaseEvent{
 			CreationDate: ldtime.UnixMillisNow(),
-			Context:      ldevents.Context(t.context),
+			Context:      eventContext,
 		},
 		Op:               *t.op,
 		FlagKey:          t.key,
@@ -121,6 +121,13 @@ func TestDiagnosticEventCustomConfig(t *testing.T) {
 			b.SetInt("connectTimeoutMillis", 1000)
 			b.SetInt("socketTimeoutMillis", 1000)
 		})
+	doTest(
+		func(c *Config) {
+			c.HTTP = ldcomponents.HTTPConfiguration().RequestTimeout(time.Minute)
+		},
+		func(b *ldvalue.ObjectBuilder) {
+			b.SetInt("socketTimeoutMillis", 60000)
+		})
 	doTest(
 		func(c *Config) {
 			c.HTTP = ldcomponents.HTTPConfiguration().ProxyURL("http://proxyhost")
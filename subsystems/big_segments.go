@@ -31,6 +31,23 @@ type BigSegmentsConfiguration interface {
 	GetStaleAfter() time.Duration
 }
 
+// CacheEvictionPolicy is an interface for a bounded cache of Big Segment memberships by context key, which
+// decides which entries to discard when it is full. It can be set with
+// ldcomponents.BigSegmentsConfigurationBuilder.ContextCacheEvictionPolicy. The SDK provides
+// ldcomponents.LRUEvictionPolicy and ldcomponents.LFUEvictionPolicy.
+//
+// The SDK may store its own implementation of BigSegmentMembership that wraps the one returned by the
+// BigSegmentStore, so that it can also keep track of when each entry expires. Implementations must be safe
+// for concurrent use by multiple goroutines.
+type CacheEvictionPolicy interface {
+	// Add stores a value for a key, replacing any existing value, and discards other entries if necessary.
+	Add(key string, value BigSegmentMembership)
+
+	// Get returns the value for a key and true, or nil and false if there is no such entry. Implementations
+	// may use this call to record that the key was accessed.
+	Get(key string) (BigSegmentMembership, bool)
+}
+
 // BigSegmentStore is an interface for a read-only data store that allows querying of context
 // membership in Big Segments.
 //
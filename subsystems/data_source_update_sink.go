@@ -46,7 +46,8 @@ type DataSourceUpdateSink interface {
 	//
 	// A special case is that if newState is DataSourceStateInterrupted, but the previous state was
 	// but the previous state was DataSourceStateInitializing, the state will remain at Initializing
-	// because Interrupted is only meaningful after a successful startup.
+	// because Interrupted is only meaningful after a successful startup. The same is true if the previous
+	// state was DataSourceStateInitializedFromStore.
 	UpdateStatus(newState interfaces.DataSourceState, newError interfaces.DataSourceErrorInfo)
 
 	// GetDataStoreStatusProvider returns an object that provides status tracking for the data store, if
@@ -0,0 +1,43 @@
+package subsystems
+
+import "time"
+
+// DataStoreOperation identifies the kind of persistent data store operation that is passed to a
+// [DataStoreMetricsSink].
+type DataStoreOperation string
+
+const (
+	// DataStoreOperationInit is PersistentDataStore.Init.
+	DataStoreOperationInit DataStoreOperation = "init"
+
+	// DataStoreOperationGet is PersistentDataStore.Get, or a Get that was answered from the cache.
+	DataStoreOperationGet DataStoreOperation = "get"
+
+	// DataStoreOperationGetAll is PersistentDataStore.GetAll, or a GetAll that was answered from the cache.
+	DataStoreOperationGetAll DataStoreOperation = "getAll"
+
+	// DataStoreOperationUpsert is PersistentDataStore.Upsert.
+	DataStoreOperationUpsert DataStoreOperation = "upsert"
+
+	// DataStoreOperationAvailable is PersistentDataStore.IsStoreAvailable, which the SDK calls at
+	// intervals after the store has returned an error, until the store is working again.
+	DataStoreOperationAvailable DataStoreOperation = "available"
+)
+
+// DataStoreMetricsSink is an interface for receiving the duration of persistent data store operations,
+// such as for finding out how database latency affects flag evaluations. It is set with
+// ldcomponents.PersistentDataStoreBuilder.Metrics; see ldexpvar.DataStoreMetrics for an implementation.
+//
+// The SDK calls ObserveDataStoreOperation synchronously after each operation, from whatever goroutine did
+// the operation, so implementations should be fast and must be safe for concurrent use.
+type DataStoreMetricsSink interface {
+	// ObserveDataStoreOperation is called after each call that the SDK makes to the persistent data store,
+	// and each time that a Get or GetAll is answered from the SDK's cache instead.
+	//
+	// The failed parameter is true if the store returned an error, or, for DataStoreOperationAvailable, if
+	// the store was not available. The cacheHit parameter is true if the store was not called because the
+	// data was in the cache; in that case the duration is only the time it took to read the cache. When
+	// several goroutines ask for the same uncached item at once, the SDK does one query for all of them, and
+	// only that query is observed.
+	ObserveDataStoreOperation(operation DataStoreOperation, duration time.Duration, failed bool, cacheHit bool)
+}
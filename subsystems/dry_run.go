@@ -0,0 +1,15 @@
+package subsystems
+
+import "io"
+
+// DryRunConfiguration encapsulates the SDK's dry-run configuration.
+//
+// See ldcomponents.DryRunConfigurationBuilder for more details on these properties.
+type DryRunConfiguration struct {
+	// Overrides creates the data source for the flags and segments that should be evaluated instead of
+	// the ones in the data store.
+	Overrides ComponentConfigurer[DataSource]
+
+	// EventWriter is the destination for analytics events, or nil if events should be discarded.
+	EventWriter io.Writer
+}
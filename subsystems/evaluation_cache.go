@@ -0,0 +1,14 @@
+package subsystems
+
+import "time"
+
+// EvaluationCacheConfiguration encapsulates the SDK's evaluation cache configuration.
+//
+// See ldcomponents.EvaluationCacheConfigurationBuilder for more details on these properties.
+type EvaluationCacheConfiguration struct {
+	// Capacity is the maximum number of evaluation results to keep, or zero if the cache is disabled.
+	Capacity int
+
+	// TTL is the maximum age of a cached result that may be returned.
+	TTL time.Duration
+}
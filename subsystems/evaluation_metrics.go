@@ -0,0 +1,63 @@
+package subsystems
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+)
+
+// EvaluationMetricsOtherFlagKey is the flag key label that is passed to an [EvaluationMetricsSink] for any
+// flag beyond the configured limit on the number of distinct flag keys.
+const EvaluationMetricsOtherFlagKey = "(other)"
+
+// EvaluationMetricsSink is an interface for receiving metrics about flag evaluations, such as for
+// exporting them to Prometheus.
+//
+// The SDK calls these methods synchronously from each variation method, such as LDClient.BoolVariation,
+// so implementations should be fast and must be safe for concurrent use. The SDK never holds a lock while
+// calling them.
+//
+// The flagKey parameter is the key of the evaluated flag, unless the number of distinct keys has exceeded
+// the configured limit (see ldcomponents.EvaluationMetricsConfigurationBuilder.FlagKeyLimit), in which
+// case it is [EvaluationMetricsOtherFlagKey]. The SDK passes the same string instance every time for a given
+// key, so it can be used as a map key without copying.
+type EvaluationMetricsSink interface {
+	// IncEvaluationCount is called once for every flag evaluation.
+	IncEvaluationCount(flagKey string)
+
+	// IncErrorCount is called, in addition to IncEvaluationCount, for every flag evaluation whose result
+	// was an error.
+	IncErrorCount(flagKey string, errorKind ldreason.EvalErrorKind)
+
+	// ObserveEvaluationDuration is called for every flag evaluation with the time that it took.
+	ObserveEvaluationDuration(flagKey string, duration time.Duration)
+}
+
+// EvaluationMetricsConfiguration encapsulates the SDK's evaluation metrics configuration.
+//
+// See ldcomponents.EvaluationMetricsConfigurationBuilder for more details on these properties.
+type EvaluationMetricsConfiguration struct {
+	// Sink is the destination for metrics, or nil if metrics are disabled.
+	Sink EvaluationMetricsSink
+
+	// FlagKeyLimit is the maximum number of distinct flag keys that will be passed to the Sink.
+	FlagKeyLimit int
+}
+
+// EvaluationMetricsCollector is an interface for receiving a record of every flag evaluation, for
+// applications that want to keep their own in-process statistics, such as latency histograms for each
+// flag. It is set with the Metrics field of the SDK configuration; see ldmetrics.HistogramMetricsCollector
+// for an implementation.
+//
+// Unlike [EvaluationMetricsSink], the collector receives the actual key of every evaluated flag, with no
+// limit on the number of distinct keys. The SDK calls RecordEvaluation synchronously from each variation
+// method, such as LDClient.BoolVariation, so implementations should be fast and must be safe for concurrent
+// use.
+type EvaluationMetricsCollector interface {
+	// RecordEvaluation is called once for every flag evaluation, after the result is known.
+	//
+	// The durationNano parameter is the time that the evaluation took, in nanoseconds. The variation
+	// parameter is the index of the result variation, or -1 if the result was the application's default
+	// value. The errorKind parameter is empty unless the evaluation returned an error.
+	RecordEvaluation(flagKey string, durationNano int64, variation int, errorKind ldreason.EvalErrorKind)
+}
@@ -1,6 +1,8 @@
 package ldstoreimpl
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -36,7 +38,10 @@ type BigSegmentStoreWrapper struct {
 	statusUpdateFn func(interfaces.BigSegmentStoreStatus)
 	staleTime      time.Duration
 	contextCache   *ccache.Cache
+	evictionPolicy subsystems.CacheEvictionPolicy
+	cacheGen       uint64
 	cacheTTL       time.Duration
+	maxQueries     int
 	pollInterval   time.Duration
 	haveStatus     bool
 	lastStatus     interfaces.BigSegmentStoreStatus
@@ -66,13 +71,17 @@ func NewBigSegmentStoreWrapperWithConfig(
 		store:          config.Store,
 		statusUpdateFn: statusUpdateFn,
 		staleTime:      config.StaleAfter,
-		contextCache:   ccache.New(ccache.Configure().MaxSize(int64(config.ContextCacheSize))),
+		evictionPolicy: config.ContextCacheEvictionPolicy,
 		cacheTTL:       config.ContextCacheTime,
+		maxQueries:     config.MaxConcurrentStoreQueries,
 		pollInterval:   config.StatusPollInterval,
 		pollCloser:     pollCloser,
 		pollingActive:  config.StartPolling,
 		loggers:        loggers,
 	}
+	if w.evictionPolicy == nil {
+		w.contextCache = ccache.New(ccache.Configure().MaxSize(int64(config.ContextCacheSize)))
+	}
 
 	if config.StartPolling {
 		go w.runPollTask(config.StatusPollInterval, pollCloser)
@@ -114,38 +123,20 @@ func (w *BigSegmentStoreWrapper) Close() {
 func (w *BigSegmentStoreWrapper) GetMembership(
 	contextKey string,
 ) (ldeval.BigSegmentMembership, ldreason.BigSegmentsStatus) {
-	entry := w.safeCacheGet(contextKey)
-	var result ldeval.BigSegmentMembership
-	if entry == nil || entry.Expired() {
-		// Use singleflight to ensure that we'll only do this query once even if multiple goroutines are
-		// requesting it
-		value, err, _ := w.requests.Do(contextKey, func() (interface{}, error) {
-			hash := bigsegments.HashForContextKey(contextKey)
-			w.loggers.Debugf("querying Big Segment state for context hash %q", hash)
-			return w.store.GetMembership(hash)
-		})
+	result, found, ok := w.safeCacheGet(contextKey)
+	if !ok {
+		return nil, ldreason.BigSegmentsStoreError // COVERAGE: can't cause this condition in unit tests
+	}
+	if !found {
+		membership, err := w.queryMembership(contextKey)
 		if err != nil {
 			w.loggers.Errorf("Big Segment store returned error: %s", err)
 			return nil, ldreason.BigSegmentsStoreError
 		}
-		if value == nil {
-			w.safeCacheSet(contextKey, nil, w.cacheTTL) // we cache the "not found" status
+		if membership == nil {
 			return nil, ldreason.BigSegmentsHealthy
 		}
-		if membership, ok := value.(subsystems.BigSegmentMembership); ok {
-			w.safeCacheSet(contextKey, membership, w.cacheTTL)
-			result = membership
-		} else {
-			w.loggers.Error("BigSegmentStoreWrapper got wrong value type from request - this should not be possible")
-			return nil, ldreason.BigSegmentsStoreError
-		}
-	} else if entry.Value() != nil { // nil is a cached "not found" state
-		if membership, ok := entry.Value().(subsystems.BigSegmentMembership); ok {
-			result = membership
-		} else {
-			w.loggers.Error("BigSegmentStoreWrapper got wrong value type from cache - this should not be possible")
-			return nil, ldreason.BigSegmentsStoreError // COVERAGE: can't cause this condition in unit tests
-		}
+		result = membership
 	}
 
 	status := ldreason.BigSegmentsHealthy
@@ -156,6 +147,52 @@ func (w *BigSegmentStoreWrapper) GetMembership(
 	return result, status
 }
 
+// Prewarm queries the store for the Big Segment membership state of each of the specified context keys,
+// and caches the results, so that the first evaluations for those contexts do not have to wait for the
+// store. This is meant for applications that know ahead of time which contexts will be evaluated most
+// often; they can call it after the SDK client has started and before serving traffic.
+//
+// The queries are done concurrently, but no more than config.MaxConcurrentStoreQueries at a time. Entries
+// that are added this way are subject to the same cache size limit and expiration time as any others, so
+// there is no point in prewarming more contexts than the cache can hold.
+//
+// If any of the queries fail, Prewarm still waits for the rest of them, and then returns an error that
+// includes each failure.
+func (w *BigSegmentStoreWrapper) Prewarm(contextKeys []string) error {
+	maxQueries := w.maxQueries
+	if maxQueries <= 0 {
+		maxQueries = 1
+	}
+	slots := make(chan struct{}, maxQueries)
+	var wg sync.WaitGroup
+	var errsLock sync.Mutex
+	var errs []error
+	for _, contextKey := range contextKeys {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(contextKey string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if _, err := w.queryMembership(contextKey); err != nil {
+				errsLock.Lock()
+				errs = append(errs, fmt.Errorf("context key %q: %w", contextKey, err))
+				errsLock.Unlock()
+			}
+		}(contextKey)
+	}
+	wg.Wait()
+
+	w.loggers.Infof("Loaded Big Segment state for %d of %d contexts into the cache",
+		len(contextKeys)-len(errs), len(contextKeys))
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d Big Segment store queries failed: %w", len(errs), len(contextKeys),
+			errors.Join(errs...))
+	}
+	return nil
+}
+
 // GetStatus returns a BigSegmentStoreStatus describing whether the store seems to be available
 // (that is, the last query to it did not return an error) and whether it is stale (that is, the last
 // known update time is too far in the past).
@@ -187,6 +224,8 @@ func (w *BigSegmentStoreWrapper) ClearCache() {
 	if w.contextCache != nil {
 		w.contextCache.Clear()
 	}
+	// An eviction policy can't be cleared, so instead we ignore any entries that were added before now.
+	w.cacheGen++
 	w.lock.Unlock()
 	w.loggers.Debug("invalidated cache")
 }
@@ -265,22 +304,85 @@ func (w *BigSegmentStoreWrapper) runPollTask(pollInterval time.Duration, pollClo
 	}
 }
 
+// queryMembership queries the store for a context's membership state and caches the result. A nil
+// membership with no error means that the store has no state for the context; that is cached too.
+func (w *BigSegmentStoreWrapper) queryMembership(contextKey string) (subsystems.BigSegmentMembership, error) {
+	// Use singleflight to ensure that we'll only do this query once even if multiple goroutines are
+	// requesting it
+	value, err, _ := w.requests.Do(contextKey, func() (interface{}, error) {
+		hash := bigsegments.HashForContextKey(contextKey)
+		w.loggers.Debugf("querying Big Segment state for context hash %q", hash)
+		return w.store.GetMembership(hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		w.safeCacheSet(contextKey, nil) // we cache the "not found" status
+		return nil, nil
+	}
+	membership, ok := value.(subsystems.BigSegmentMembership)
+	if !ok {
+		return nil, errors.New("BigSegmentStoreWrapper got wrong value type from request - this should not be possible")
+	}
+	w.safeCacheSet(contextKey, membership)
+	return membership, nil
+}
+
+// cachedMembership is what we store in an eviction policy, since unlike ccache it doesn't know about
+// expiration times. A nil membership is a cached "not found" state.
+type cachedMembership struct {
+	subsystems.BigSegmentMembership
+	expires    time.Time
+	generation uint64
+}
+
+// safeCacheGet returns the cached membership state for a context key, and whether there was one that has
+// not expired. The last return value is false if the cache contained something other than a membership,
+// which should not be possible.
+//
 // safeCacheGet and safeCacheSet are necessary because trying to use a ccache.Cache after it's been shut
 // down can cause a panic, so we nil it out on Close() and guard it with our lock.
-func (w *BigSegmentStoreWrapper) safeCacheGet(key string) *ccache.Item {
-	var ret *ccache.Item
+func (w *BigSegmentStoreWrapper) safeCacheGet(key string) (subsystems.BigSegmentMembership, bool, bool) {
 	w.lock.RLock()
-	if w.contextCache != nil {
-		ret = w.contextCache.Get(key)
+	contextCache, policy, generation := w.contextCache, w.evictionPolicy, w.cacheGen
+	var item *ccache.Item
+	if contextCache != nil {
+		item = contextCache.Get(key)
 	}
 	w.lock.RUnlock()
-	return ret
+
+	if policy != nil {
+		value, found := policy.Get(key)
+		entry, ok := value.(cachedMembership)
+		if !found || !ok || entry.generation != generation || time.Now().After(entry.expires) {
+			return nil, false, true
+		}
+		return entry.BigSegmentMembership, true, true
+	}
+	if item == nil || item.Expired() {
+		return nil, false, true
+	}
+	if item.Value() == nil {
+		return nil, true, true
+	}
+	membership, ok := item.Value().(subsystems.BigSegmentMembership)
+	if !ok {
+		w.loggers.Error("BigSegmentStoreWrapper got wrong value type from cache - this should not be possible")
+	}
+	return membership, true, ok
 }
 
-func (w *BigSegmentStoreWrapper) safeCacheSet(key string, value interface{}, ttl time.Duration) {
+func (w *BigSegmentStoreWrapper) safeCacheSet(key string, membership subsystems.BigSegmentMembership) {
 	w.lock.RLock()
-	if w.contextCache != nil {
-		w.contextCache.Set(key, value, ttl)
+	defer w.lock.RUnlock()
+	if w.evictionPolicy != nil {
+		w.evictionPolicy.Add(key, cachedMembership{
+			BigSegmentMembership: membership,
+			expires:              time.Now().Add(w.cacheTTL),
+			generation:           w.cacheGen,
+		})
+	} else if w.contextCache != nil {
+		w.contextCache.Set(key, membership, w.cacheTTL)
 	}
-	w.lock.RUnlock()
 }
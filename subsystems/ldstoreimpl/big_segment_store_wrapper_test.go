@@ -2,6 +2,7 @@ package ldstoreimpl
 
 import (
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -24,8 +25,10 @@ import (
 func TestBigSegmentStoreWrapper(t *testing.T) {
 	t.Run("queries store with hashed user key", testBigSegmentStoreWrapperMembershipQuery)
 	t.Run("caches membership state", testBigSegmentStoreWrapperMembershipCaching)
+	t.Run("caches membership state with eviction policy", testBigSegmentStoreWrapperEvictionPolicy)
 	t.Run("sends status updates", testBigSegmentStoreWrapperStatusUpdates)
 	t.Run("control methods", testBigSegmentStoreWrapperControlMethods)
+	t.Run("prewarm", testBigSegmentStoreWrapperPrewarm)
 }
 
 type storeWrapperTestParams struct {
@@ -156,6 +159,98 @@ func testBigSegmentStoreWrapperMembershipCaching(t *testing.T) {
 	})
 }
 
+type mapEvictionPolicy struct {
+	values map[string]subsystems.BigSegmentMembership
+	lock   sync.Mutex
+}
+
+func newMapEvictionPolicy() *mapEvictionPolicy {
+	return &mapEvictionPolicy{values: make(map[string]subsystems.BigSegmentMembership)}
+}
+
+func (m *mapEvictionPolicy) Add(key string, value subsystems.BigSegmentMembership) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.values[key] = value
+}
+
+func (m *mapEvictionPolicy) Get(key string) (subsystems.BigSegmentMembership, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func testBigSegmentStoreWrapperEvictionPolicy(t *testing.T) {
+	userKey := "userkey"
+	userHash := bigsegments.HashForContextKey(userKey)
+	expectedMembership := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+
+	t.Run("results are cached in policy", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		policy := newMapEvictionPolicy()
+		p.config.ContextCacheEvictionPolicy = policy
+		p.run(func(p *storeWrapperTestParams) {
+			p.store.TestSetMembership(userHash, expectedMembership)
+
+			p.assertMembership(userKey, expectedMembership)
+			p.assertMembership(userKey, expectedMembership)
+			p.assertMembership("otherkey", nil)
+			p.assertMembership("otherkey", nil)
+			p.assertUserHashesQueried(userHash, bigsegments.HashForContextKey("otherkey"))
+			assert.Len(t, policy.values, 2)
+			assert.Nil(t, p.wrapper.contextCache)
+		})
+	})
+
+	t.Run("entries in policy expire", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextCacheEvictionPolicy = newMapEvictionPolicy()
+		p.config.ContextCacheTime = time.Millisecond * 10
+		p.run(func(p *storeWrapperTestParams) {
+			p.store.TestSetMembership(userHash, expectedMembership)
+
+			p.assertMembership(userKey, expectedMembership)
+			<-time.After(time.Millisecond * 20)
+			p.assertMembership(userKey, expectedMembership)
+			p.assertUserHashesQueried(userHash, userHash)
+		})
+	})
+
+	t.Run("entry discarded by policy is queried again", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		policy := newMapEvictionPolicy()
+		p.config.ContextCacheEvictionPolicy = policy
+		p.run(func(p *storeWrapperTestParams) {
+			p.store.TestSetMembership(userHash, expectedMembership)
+
+			p.assertMembership(userKey, expectedMembership)
+			policy.lock.Lock()
+			delete(policy.values, userKey)
+			policy.lock.Unlock()
+			p.assertMembership(userKey, expectedMembership)
+			p.assertUserHashesQueried(userHash, userHash)
+		})
+	})
+
+	t.Run("can clear cache", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextCacheEvictionPolicy = newMapEvictionPolicy()
+		p.run(func(p *storeWrapperTestParams) {
+			p.store.TestSetMembership(userHash, expectedMembership)
+			p.assertMembership(userKey, expectedMembership)
+
+			expectedMembership2 := NewBigSegmentMembershipFromSegmentRefs([]string{"maybe"}, []string{"no"})
+			p.store.TestSetMembership(userHash, expectedMembership2)
+			p.wrapper.ClearCache()
+
+			p.assertMembership(userKey, expectedMembership2)
+			p.assertMembership(userKey, expectedMembership2)
+			p.assertUserHashesQueried(userHash, userHash)
+		})
+	})
+}
+
 func testBigSegmentStoreWrapperStatusUpdates(t *testing.T) {
 	t.Run("polling detects store unavailability", func(t *testing.T) {
 		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
@@ -251,3 +346,101 @@ func testBigSegmentStoreWrapperControlMethods(t *testing.T) {
 		})
 	})
 }
+
+// concurrencyTrackingBigSegmentStore records the largest number of membership queries that were in
+// progress at once.
+type concurrencyTrackingBigSegmentStore struct {
+	*mocks.MockBigSegmentStore
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (s *concurrencyTrackingBigSegmentStore) GetMembership(
+	contextHash string,
+) (subsystems.BigSegmentMembership, error) {
+	active := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		maxActive := s.maxActive.Load()
+		if active <= maxActive || s.maxActive.CompareAndSwap(maxActive, active) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond * 10)
+	return s.MockBigSegmentStore.GetMembership(contextHash)
+}
+
+func testBigSegmentStoreWrapperPrewarm(t *testing.T) {
+	t.Run("caches memberships", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			userHash1, userHash2 := bigsegments.HashForContextKey("userkey1"), bigsegments.HashForContextKey("userkey2")
+			expectedMembership := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+			p.store.TestSetMembership(userHash1, expectedMembership)
+
+			require.NoError(t, p.wrapper.Prewarm([]string{"userkey1", "userkey2"}))
+			assert.ElementsMatch(t, []string{userHash1, userHash2}, p.store.TestGetMembershipQueries())
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Loaded Big Segment state for 2 of 2 contexts")
+
+			p.assertMembership("userkey1", expectedMembership)
+			p.assertMembership("userkey2", nil)
+			assert.Len(t, p.store.TestGetMembershipQueries(), 2) // both were answered from the cache
+		})
+	})
+
+	t.Run("caches memberships with eviction policy", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		policy := newMapEvictionPolicy()
+		p.config.ContextCacheEvictionPolicy = policy
+		p.run(func(p *storeWrapperTestParams) {
+			require.NoError(t, p.wrapper.Prewarm([]string{"userkey"}))
+			_, cached := policy.Get("userkey")
+			assert.True(t, cached)
+		})
+	})
+
+	t.Run("returns error for failed queries", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			storeErr := errors.New("sorry")
+			p.store.TestSetMembershipError(storeErr)
+
+			err := p.wrapper.Prewarm([]string{"userkey1", "userkey2"})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, storeErr)
+			assert.Contains(t, err.Error(), "2 of 2 Big Segment store queries failed")
+			assert.Len(t, p.store.TestGetMembershipQueries(), 2)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Loaded Big Segment state for 0 of 2 contexts")
+
+			p.store.TestSetMembershipError(nil)
+			p.assertMembership("userkey1", nil)
+			assert.Len(t, p.store.TestGetMembershipQueries(), 3) // the failure was not cached
+		})
+	})
+
+	t.Run("limits concurrent queries", func(t *testing.T) {
+		for _, maxQueries := range []int{0, 1, 3} {
+			store := &concurrencyTrackingBigSegmentStore{MockBigSegmentStore: &mocks.MockBigSegmentStore{}}
+			store.TestSetMetadataToCurrentTime()
+			wrapper := NewBigSegmentStoreWrapperWithConfig(BigSegmentsConfigurationProperties{
+				Store:                     store,
+				ContextCacheSize:          100,
+				ContextCacheTime:          time.Hour,
+				MaxConcurrentStoreQueries: maxQueries,
+				StaleAfter:                time.Hour,
+			}, nil, ldlog.NewDisabledLoggers())
+
+			keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+			require.NoError(t, wrapper.Prewarm(keys))
+			wrapper.Close()
+
+			assert.Len(t, store.TestGetMembershipQueries(), len(keys))
+			expectedMax := int32(maxQueries)
+			if maxQueries == 0 {
+				expectedMax = 1
+			}
+			assert.LessOrEqual(t, store.maxActive.Load(), expectedMax, "max queries: %d", maxQueries)
+			if maxQueries == 3 {
+				assert.Greater(t, store.maxActive.Load(), int32(1), "queries were not concurrent")
+			}
+		}
+	})
+}
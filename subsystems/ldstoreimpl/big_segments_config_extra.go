@@ -23,6 +23,14 @@ type BigSegmentsConfigurationProperties struct {
 	// by the SDK.
 	ContextCacheTime time.Duration
 
+	// ContextCacheEvictionPolicy is the cache that holds the Big Segment state of contexts. If nil, the SDK
+	// uses a least-recently-used cache whose maximum size is ContextCacheSize.
+	ContextCacheEvictionPolicy subsystems.CacheEvictionPolicy
+
+	// MaxConcurrentStoreQueries is the maximum number of store queries that BigSegmentStoreWrapper.Prewarm
+	// does at once. If it is zero or negative, Prewarm does one query at a time.
+	MaxConcurrentStoreQueries int
+
 	// StatusPollInterval is the interval at which the SDK will poll the Big Segment store to make sure
 	// it is available and to determine how long ago it was updated
 	StatusPollInterval time.Duration
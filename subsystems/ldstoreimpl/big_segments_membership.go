@@ -43,6 +43,25 @@ func NewBigSegmentMembershipFromSegmentRefs(
 	return ret
 }
 
+// NewBigSegmentMembershipFromMap creates a BigSegmentMembership from a map whose keys are segment
+// references: true means the context is included in that segment, and false means it is excluded.
+// This is for Big Segment store implementations whose database returns memberships in that form, so
+// that they do not need to build separate lists for NewBigSegmentMembershipFromSegmentRefs.
+//
+// The returned object's CheckMembership method will return ldvalue.NewOptionalBool(value) for any
+// segmentRef that is in the map, and ldvalue.OptionalBool{} (undefined) for all others. The map is
+// copied, so the caller may change it afterward.
+func NewBigSegmentMembershipFromMap(memberships map[string]bool) subsystems.BigSegmentMembership {
+	if len(memberships) == 0 {
+		return bigSegmentMembershipMapImpl(nil)
+	}
+	ret := make(bigSegmentMembershipMapImpl, len(memberships))
+	for segmentRef, included := range memberships {
+		ret[segmentRef] = included
+	}
+	return ret
+}
+
 // This is the standard internal implementation of BigSegmentMembership. The map contains a true
 // value for included keys and a false value for excluded keys that are not also included (inclusions
 // override exclusions). If there are no keys at all, we store nil instead of allocating an empty map.
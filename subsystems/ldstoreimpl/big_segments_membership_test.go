@@ -59,3 +59,20 @@ func TestMembershipWithIncludedAndExcludedKeys(t *testing.T) {
 	assert.Equal(t, ldvalue.NewOptionalBool(false), m.CheckMembership("key3"))
 	assert.Equal(t, ldvalue.OptionalBool{}, m.CheckMembership("key4"))
 }
+
+func TestMembershipFromMap(t *testing.T) {
+	source := map[string]bool{"key1": true, "key2": false}
+	m := NewBigSegmentMembershipFromMap(source)
+	source["key3"] = true // the map was copied
+
+	assert.Equal(t, bigSegmentMembershipMapImpl(map[string]bool{"key1": true, "key2": false}), m)
+	assert.Equal(t, ldvalue.NewOptionalBool(true), m.CheckMembership("key1"))
+	assert.Equal(t, ldvalue.NewOptionalBool(false), m.CheckMembership("key2"))
+	assert.Equal(t, ldvalue.OptionalBool{}, m.CheckMembership("key3"))
+}
+
+func TestMembershipFromEmptyMap(t *testing.T) {
+	assert.Equal(t, bigSegmentMembershipMapImpl(nil), NewBigSegmentMembershipFromMap(nil))
+	assert.Equal(t, bigSegmentMembershipMapImpl(nil), NewBigSegmentMembershipFromMap(map[string]bool{}))
+	assert.Equal(t, ldvalue.OptionalBool{}, NewBigSegmentMembershipFromMap(nil).CheckMembership("key"))
+}
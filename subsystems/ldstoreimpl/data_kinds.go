@@ -9,8 +9,9 @@ import (
 // these things in order to support development of custom database integrations and internal LD
 // components, but we don't want to expose the underlying global variables.
 
-// AllKinds returns a list of supported StoreDataKinds. Among other things, this list might
-// be used by data stores to know what data (namespaces) to expect.
+// AllKinds returns a list of supported StoreDataKinds: flags, segments, and any that were registered with
+// [RegisterDataKind]. Among other things, this list might be used by data stores to know what data
+// (namespaces) to expect.
 func AllKinds() []ldstoretypes.DataKind {
 	return datakinds.AllDataKinds()
 }
@@ -24,3 +25,33 @@ func Features() ldstoretypes.DataKind {
 func Segments() ldstoretypes.DataKind {
 	return datakinds.Segments
 }
+
+// RegisterDataKind adds a kind of data, other than flags and segments, that the SDK should keep in its
+// data store when it receives it from LaunchDarkly, for applications such as relay-like products that pass
+// that data on to others. Data of kinds that have not been registered is ignored, as before.
+//
+// The kind's GetName is its namespace in the data store, as "features" is for flags, and its Serialize and
+// Deserialize methods convert items to and from the JSON representation in the data from LaunchDarkly.
+// The payload name is the name of the property that contains the items of this kind in streaming and
+// polling data and in flag data files, as "flags" contains the flags; it is also the first part of the
+// path for an update of a single item, as in "/flags/my-flag". It returns an error if the name or the
+// payload name is empty or already used, or if the payload name contains a slash.
+//
+// The registration applies to every SDK client in the process, so it should be done before creating any
+// clients, for instance in an init function. Once registered, the kind is included in [AllKinds].
+func RegisterDataKind(kind ldstoretypes.DataKind, payloadName string) error {
+	return datakinds.RegisterAdditionalDataKind(kind, payloadName)
+}
+
+// ParseItemUpdateMessage parses a message that describes an update of a single item, for data sources that
+// receive updates from a message stream, as ldkafka and ldnats do. The message is a JSON object like this:
+//
+//	{"kind": "features", "key": "my-flag", "item": {"key": "my-flag", "version": 2, ...}}
+//
+// The kind is the name of one of the kinds in [AllKinds]. The item is in the format that the kind's
+// Serialize method produces, which is also how persistent data stores store it; it can be either a JSON
+// object or a string containing one. A deleted item is the placeholder that Serialize produces for it, such
+// as {"key": "my-flag", "version": 3, "deleted": true}, and is returned as an ItemDescriptor whose Item is nil.
+func ParseItemUpdateMessage(data []byte) (ldstoretypes.DataKind, string, ldstoretypes.ItemDescriptor, error) {
+	return datakinds.ParseItemUpdateMessage(data)
+}
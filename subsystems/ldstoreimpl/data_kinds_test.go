@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest/mocks"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
@@ -17,3 +19,26 @@ func TestDataKinds(t *testing.T) {
 	assert.Equal(t, datakinds.Segments, Segments())
 	assert.Equal(t, []ldstoretypes.DataKind{Features(), Segments()}, AllKinds())
 }
+
+func TestRegisterDataKind(t *testing.T) {
+	defer datakinds.ResetAdditionalDataKinds()
+
+	require.NoError(t, RegisterDataKind(mocks.MockJSONData, "mockItems"))
+	assert.Equal(t, []ldstoretypes.DataKind{Features(), Segments(), mocks.MockJSONData}, AllKinds())
+
+	assert.Error(t, RegisterDataKind(mocks.MockJSONData, "otherItems"))
+	assert.Error(t, RegisterDataKind(mocks.MockData, "flags"))
+}
+
+func TestParseItemUpdateMessage(t *testing.T) {
+	// The message format is tested in internal/datakinds; this only verifies that the public API uses it.
+	kind, key, item, err := ParseItemUpdateMessage([]byte(
+		`{"kind":"segments","key":"segmentkey","item":{"key":"segmentkey","version":3}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Segments(), kind)
+	assert.Equal(t, "segmentkey", key)
+	assert.Equal(t, 3, item.Version)
+
+	_, _, _, err = ParseItemUpdateMessage([]byte(`{"kind":"unknown","key":"x","item":{}}`))
+	assert.Error(t, err)
+}
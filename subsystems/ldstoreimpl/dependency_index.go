@@ -0,0 +1,105 @@
+package ldstoreimpl
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datadeps"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// DependencyIndex answers questions about how the flags and segments in a data store refer to each
+// other, such as which flags would be affected by a change to a segment.
+//
+// The references that it knows about are flag prerequisites, and segmentMatch clauses in flag rules and
+// segment rules. The index is built from the contents of the data store when it is created. To keep it
+// up to date, either call Rebuild to read the data store again, or call Update for each item that has
+// changed. It is safe for concurrent use.
+//
+// Normal use of the SDK does not require this type. It uses the same dependency logic as the SDK's
+// flag change notifications.
+type DependencyIndex struct {
+	store   subsystems.DataStore
+	tracker *datadeps.DependencyTracker
+	lock    sync.RWMutex
+}
+
+// NewDependencyIndex creates a DependencyIndex from the current contents of a data store.
+//
+// It returns an error if the flags or segments could not be read from the store.
+func NewDependencyIndex(store subsystems.DataStore) (*DependencyIndex, error) {
+	d := &DependencyIndex{store: store, tracker: datadeps.NewDependencyTracker()}
+	if err := d.Rebuild(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Rebuild discards the current index and builds it again from the contents of the data store.
+//
+// If the flags or segments could not be read from the store, it returns an error and the index is not
+// changed.
+func (d *DependencyIndex) Rebuild() error {
+	tracker := datadeps.NewDependencyTracker()
+	for _, kind := range []st.DataKind{datakinds.Features, datakinds.Segments} {
+		items, err := d.store.GetAll(kind)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			tracker.UpdateDependenciesFrom(kind, item.Key, item.Item)
+		}
+	}
+	d.lock.Lock()
+	d.tracker = tracker
+	d.lock.Unlock()
+	return nil
+}
+
+// Update changes the index to reflect a new version of a single flag or segment. The item can be a
+// deleted item placeholder.
+func (d *DependencyIndex) Update(kind st.DataKind, key string, item st.ItemDescriptor) {
+	d.lock.Lock()
+	d.tracker.UpdateDependenciesFrom(kind, key, item)
+	d.lock.Unlock()
+}
+
+// DependentsOf returns the keys of all flags that directly or indirectly depend on the specified flag or
+// segment, in sorted order.
+//
+// For a segment, this includes flags that refer to any other segment that refers to it. The result does
+// not include the specified flag itself.
+func (d *DependencyIndex) DependentsOf(kind st.DataKind, key string) []string {
+	start := datadeps.KindAndKey{Kind: kind, Key: key}
+	affected := make(datadeps.KindAndKeySet)
+	d.lock.RLock()
+	d.tracker.AddAffectedItems(affected, start)
+	d.lock.RUnlock()
+	delete(affected, start)
+	return sortedKeysOfKind(affected, datakinds.Features)
+}
+
+// PrerequisitesOf returns the keys of all flags that are direct or indirect prerequisites of the
+// specified flag, in sorted order. The result does not include the specified flag itself.
+func (d *DependencyIndex) PrerequisitesOf(flagKey string) []string {
+	start := datadeps.KindAndKey{Kind: datakinds.Features, Key: flagKey}
+	dependencies := make(datadeps.KindAndKeySet)
+	d.lock.RLock()
+	d.tracker.AddDependencies(dependencies, start)
+	d.lock.RUnlock()
+	delete(dependencies, start)
+	return sortedKeysOfKind(dependencies, datakinds.Features)
+}
+
+func sortedKeysOfKind(items datadeps.KindAndKeySet, kind st.DataKind) []string {
+	var keys []string
+	for item := range items {
+		if item.Kind == kind {
+			keys = append(keys, item.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package ldstoreimpl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeDependencyIndexTestStore(t *testing.T) subsystems.DataStore {
+	store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+	data := sharedtest.NewDataSetBuilder().
+		Segments(
+			ldbuilders.NewSegmentBuilder("segment1").
+				AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(ldbuilders.SegmentMatchClause("segment2"))).
+				Build(),
+			ldbuilders.NewSegmentBuilder("segment2").Build(),
+		).
+		Flags(
+			ldbuilders.NewFlagBuilder("flag1").AddPrerequisite("flag2", 0).Build(),
+			ldbuilders.NewFlagBuilder("flag2").AddPrerequisite("flag3", 0).Build(),
+			ldbuilders.NewFlagBuilder("flag3").
+				AddRule(ldbuilders.NewRuleBuilder().Clauses(ldbuilders.SegmentMatchClause("segment1"))).
+				Build(),
+			ldbuilders.NewFlagBuilder("flag4").
+				AddRule(ldbuilders.NewRuleBuilder().Clauses(ldbuilders.SegmentMatchClause("segment2"))).
+				Build(),
+		).
+		Build()
+	require.NoError(t, store.Init(data))
+	return store
+}
+
+func TestDependencyIndexDependentsOf(t *testing.T) {
+	index, err := NewDependencyIndex(makeDependencyIndexTestStore(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"flag1", "flag2", "flag3", "flag4"}, index.DependentsOf(Segments(), "segment2"))
+	assert.Equal(t, []string{"flag1", "flag2", "flag3"}, index.DependentsOf(Segments(), "segment1"))
+	assert.Equal(t, []string{"flag1"}, index.DependentsOf(Features(), "flag2"))
+	assert.Len(t, index.DependentsOf(Features(), "flag1"), 0)
+	assert.Len(t, index.DependentsOf(Features(), "unknown"), 0)
+}
+
+func TestDependencyIndexPrerequisitesOf(t *testing.T) {
+	index, err := NewDependencyIndex(makeDependencyIndexTestStore(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"flag2", "flag3"}, index.PrerequisitesOf("flag1"))
+	assert.Equal(t, []string{"flag3"}, index.PrerequisitesOf("flag2"))
+	assert.Len(t, index.PrerequisitesOf("flag3"), 0)
+	assert.Len(t, index.PrerequisitesOf("unknown"), 0)
+}
+
+func TestDependencyIndexUpdate(t *testing.T) {
+	index, err := NewDependencyIndex(makeDependencyIndexTestStore(t))
+	require.NoError(t, err)
+
+	flag4 := ldbuilders.NewFlagBuilder("flag4").Version(2).AddPrerequisite("flag1", 0).Build()
+	index.Update(Features(), flag4.Key, sharedtest.FlagDescriptor(flag4))
+	index.Update(Features(), "flag1", st.ItemDescriptor{Version: 2, Item: nil})
+
+	assert.Equal(t, []string{"flag4"}, index.DependentsOf(Features(), "flag1"))
+	assert.Len(t, index.PrerequisitesOf("flag1"), 0)
+	assert.Equal(t, []string{"flag2", "flag3"}, index.DependentsOf(Segments(), "segment2"))
+}
+
+func TestDependencyIndexRebuild(t *testing.T) {
+	store := makeDependencyIndexTestStore(t)
+	index, err := NewDependencyIndex(store)
+	require.NoError(t, err)
+
+	flag5 := ldbuilders.NewFlagBuilder("flag5").AddPrerequisite("flag1", 0).Build()
+	_, err = store.Upsert(Features(), flag5.Key, sharedtest.FlagDescriptor(flag5))
+	require.NoError(t, err)
+	assert.Len(t, index.DependentsOf(Features(), "flag1"), 0)
+
+	require.NoError(t, index.Rebuild())
+	assert.Equal(t, []string{"flag5"}, index.DependentsOf(Features(), "flag1"))
+}
+
+type dataStoreWithGetAllError struct {
+	subsystems.DataStore
+	err error
+}
+
+func (s dataStoreWithGetAllError) GetAll(kind st.DataKind) ([]st.KeyedItemDescriptor, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.DataStore.GetAll(kind)
+}
+
+func TestDependencyIndexReturnsStoreError(t *testing.T) {
+	fakeError := errors.New("sorry")
+	_, err := NewDependencyIndex(dataStoreWithGetAllError{makeDependencyIndexTestStore(t), fakeError})
+	assert.Equal(t, fakeError, err)
+
+	store := &dataStoreWithGetAllError{DataStore: makeDependencyIndexTestStore(t)}
+	index, err := NewDependencyIndex(store)
+	require.NoError(t, err)
+	store.err = fakeError
+	assert.Equal(t, fakeError, index.Rebuild())
+	assert.Equal(t, []string{"flag2", "flag3"}, index.PrerequisitesOf("flag1")) // index is unchanged
+}
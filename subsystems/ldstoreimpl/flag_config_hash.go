@@ -0,0 +1,42 @@
+package ldstoreimpl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// FlagConfigHash returns a hash of the parts of a flag's configuration that determine how it is evaluated,
+// as a hexadecimal SHA-256 string. It can be used, for instance by a caching layer, to tell whether a
+// flag's targeting has changed without comparing the whole flag.
+//
+// The hash covers the flag key and version, whether targeting is on, prerequisites, individual targets,
+// rules, the fallthrough and off variations, the variation values, and the salt. It does not cover
+// properties that only affect analytics events or client-side availability; but since LaunchDarkly
+// increments the version for every change to a flag, a change to one of those also changes the hash once
+// the new version is received. The result is the same for equal configurations in any SDK instance.
+func FlagConfigHash(flag *ldmodel.FeatureFlag) string {
+	targeting := *flag
+	targeting.Deleted = false
+	targeting.ClientSideAvailability = ldmodel.ClientSideAvailability{}
+	targeting.TrackEvents = false
+	targeting.TrackEventsFallthrough = false
+	targeting.DebugEventsUntilDate = ldtime.UnixMillisecondTime(0)
+	targeting.Migration = nil
+	targeting.SamplingRatio = ldvalue.OptionalInt{}
+	targeting.ExcludeFromSummaries = false
+	if len(flag.Rules) > 0 {
+		targeting.Rules = make([]ldmodel.FlagRule, len(flag.Rules))
+		for i, rule := range flag.Rules {
+			rule.TrackEvents = false
+			targeting.Rules[i] = rule
+		}
+	}
+	// The JSON representation always writes properties in the same order, so it is deterministic.
+	data, _ := ldmodel.NewJSONDataModelSerialization().MarshalFeatureFlag(targeting)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
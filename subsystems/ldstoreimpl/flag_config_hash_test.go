@@ -0,0 +1,78 @@
+package ldstoreimpl
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeFlagConfigHashTestFlag() *ldbuilders.FlagBuilder {
+	return ldbuilders.NewFlagBuilder("flagkey").Version(1).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+		OffVariation(0).FallthroughVariation(1).
+		AddPrerequisite("prereq", 1).
+		AddTarget(1, "user-key").
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule0").Variation(0).
+			Clauses(ldbuilders.Clause("name", ldmodel.OperatorIn, ldvalue.String("x")))).
+		Salt("salt")
+}
+
+func TestFlagConfigHashIsDeterministic(t *testing.T) {
+	flag1 := makeFlagConfigHashTestFlag().Build()
+	flag2 := makeFlagConfigHashTestFlag().Build()
+	hash := FlagConfigHash(&flag1)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, FlagConfigHash(&flag2))
+}
+
+func TestFlagConfigHashIgnoresNonTargetingProperties(t *testing.T) {
+	flag := makeFlagConfigHashTestFlag().Build()
+	hash := FlagConfigHash(&flag)
+
+	changes := map[string]func(*ldmodel.FeatureFlag){
+		"trackEvents":            func(f *ldmodel.FeatureFlag) { f.TrackEvents = true },
+		"trackEventsFallthrough": func(f *ldmodel.FeatureFlag) { f.TrackEventsFallthrough = true },
+		"debugEventsUntilDate":   func(f *ldmodel.FeatureFlag) { f.DebugEventsUntilDate = 1000 },
+		"clientSide":             func(f *ldmodel.FeatureFlag) { f.ClientSideAvailability.UsingEnvironmentID = true },
+		"samplingRatio":          func(f *ldmodel.FeatureFlag) { f.SamplingRatio = ldvalue.NewOptionalInt(10) },
+		"excludeFromSummaries":   func(f *ldmodel.FeatureFlag) { f.ExcludeFromSummaries = true },
+		"rule trackEvents":       func(f *ldmodel.FeatureFlag) { f.Rules[0].TrackEvents = true },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			changed := makeFlagConfigHashTestFlag().Build()
+			change(&changed)
+			assert.Equal(t, hash, FlagConfigHash(&changed))
+		})
+	}
+	assert.False(t, flag.Rules[0].TrackEvents)
+}
+
+func TestFlagConfigHashChangesWithTargeting(t *testing.T) {
+	flag := makeFlagConfigHashTestFlag().Build()
+	hash := FlagConfigHash(&flag)
+
+	changes := map[string]func(*ldmodel.FeatureFlag){
+		"on":            func(f *ldmodel.FeatureFlag) { f.On = false },
+		"key":           func(f *ldmodel.FeatureFlag) { f.Key = "otherkey" },
+		"version":       func(f *ldmodel.FeatureFlag) { f.Version = 2 },
+		"prerequisites": func(f *ldmodel.FeatureFlag) { f.Prerequisites[0].Variation = 0 },
+		"targets":       func(f *ldmodel.FeatureFlag) { f.Targets[0].Values = []string{"other-key"} },
+		"rules":         func(f *ldmodel.FeatureFlag) { f.Rules[0].Clauses[0].Negate = true },
+		"fallthrough":   func(f *ldmodel.FeatureFlag) { f.Fallthrough.Variation = ldvalue.NewOptionalInt(0) },
+		"offVariation":  func(f *ldmodel.FeatureFlag) { f.OffVariation = ldvalue.NewOptionalInt(1) },
+		"variations":    func(f *ldmodel.FeatureFlag) { f.Variations[1] = ldvalue.String("true") },
+		"salt":          func(f *ldmodel.FeatureFlag) { f.Salt = "other" },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			changed := makeFlagConfigHashTestFlag().Build()
+			change(&changed)
+			assert.NotEqual(t, hash, FlagConfigHash(&changed))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package ldstoreimpl
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// NewInMemoryDataStoreWithData creates an instance of the SDK's default in-memory data store that is
+// already initialized with the specified data, in the same way that a data source would initialize it
+// by calling Init. This is useful for setting up flags and segments for a test, or for evaluating flags
+// with ldclient.MakeClientFromComponents, without calling Upsert for each item.
+//
+// It returns an error if the same data kind, or the same key within a kind, appears more than once, or
+// if an item is a flag or segment whose Deleted property is true; a deleted item should be represented
+// by a tombstone (see [MakeTombstone]) instead. Tombstones are allowed.
+func NewInMemoryDataStoreWithData(allData []ldstoretypes.Collection) (subsystems.DataStore, error) {
+	return datastore.NewInMemoryDataStoreWithData(allData, ldlog.NewDisabledLoggers())
+}
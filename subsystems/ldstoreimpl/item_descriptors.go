@@ -0,0 +1,129 @@
+package ldstoreimpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// This file contains helpers for converting between the data model types, ItemDescriptor, and
+// SerializedItemDescriptor, for use by custom data store integrations.
+//
+// A deleted item is represented by a "tombstone": an ItemDescriptor whose Item is nil, but whose Version is
+// the version of the deletion. A store must keep tombstones, so that an older version of the item that
+// arrives later is not mistaken for a new one. ItemDescriptor.NotFound, whose Version is -1, is not a
+// tombstone; it means that the store has no information about the item at all.
+
+// ErrItemDeleted is returned by DescriptorToFlag and DescriptorToSegment if the descriptor is a tombstone.
+var ErrItemDeleted = errors.New("item has been deleted")
+
+// MakeFlagDescriptor returns an ItemDescriptor for a feature flag, with the flag's version.
+func MakeFlagDescriptor(flag ldmodel.FeatureFlag) ldstoretypes.ItemDescriptor {
+	return ldstoretypes.ItemDescriptor{Version: flag.Version, Item: &flag}
+}
+
+// MakeSegmentDescriptor returns an ItemDescriptor for a segment, with the segment's version.
+func MakeSegmentDescriptor(segment ldmodel.Segment) ldstoretypes.ItemDescriptor {
+	return ldstoretypes.ItemDescriptor{Version: segment.Version, Item: &segment}
+}
+
+// MakeTombstone returns an ItemDescriptor that means that an item of any kind was deleted at the
+// specified version.
+func MakeTombstone(version int) ldstoretypes.ItemDescriptor {
+	return ldstoretypes.ItemDescriptor{Version: version, Item: nil}
+}
+
+// IsTombstone returns true if the descriptor represents a deleted item. It returns false for a descriptor
+// that has an item, and for ItemDescriptor.NotFound.
+func IsTombstone(item ldstoretypes.ItemDescriptor) bool {
+	return item.Item == nil && item.Version >= 0
+}
+
+// DescriptorToFlag returns the feature flag in an ItemDescriptor. It returns [ErrItemDeleted] if the
+// descriptor is a tombstone, or another error if it does not contain a feature flag.
+func DescriptorToFlag(item ldstoretypes.ItemDescriptor) (*ldmodel.FeatureFlag, error) {
+	if item.Item == nil {
+		return nil, descriptorHasNoItemError(item)
+	}
+	flag, ok := item.Item.(*ldmodel.FeatureFlag)
+	if !ok {
+		return nil, fmt.Errorf("expected a feature flag, but item was of type %T", item.Item)
+	}
+	return flag, nil
+}
+
+// DescriptorToSegment returns the segment in an ItemDescriptor. It returns [ErrItemDeleted] if the
+// descriptor is a tombstone, or another error if it does not contain a segment.
+func DescriptorToSegment(item ldstoretypes.ItemDescriptor) (*ldmodel.Segment, error) {
+	if item.Item == nil {
+		return nil, descriptorHasNoItemError(item)
+	}
+	segment, ok := item.Item.(*ldmodel.Segment)
+	if !ok {
+		return nil, fmt.Errorf("expected a segment, but item was of type %T", item.Item)
+	}
+	return segment, nil
+}
+
+// SerializeDescriptor converts an ItemDescriptor to the SerializedItemDescriptor that the SDK passes to a
+// PersistentDataStore. For a tombstone, Deleted is true, and SerializedItem is a placeholder that has the
+// version number; a store that cannot save the version and deleted state separately can save just that.
+func SerializeDescriptor(
+	kind ldstoretypes.DataKind,
+	item ldstoretypes.ItemDescriptor,
+) ldstoretypes.SerializedItemDescriptor {
+	return datastore.SerializeItem(kind, item)
+}
+
+// DeserializeDescriptor converts a SerializedItemDescriptor returned by a PersistentDataStore to an
+// ItemDescriptor, in the same way that the SDK does. This is the reverse of [SerializeDescriptor].
+//
+// If Deleted is true or SerializedItem is nil, the result is a tombstone. If Version is non-zero, it
+// overrides the version in the serialized data.
+func DeserializeDescriptor(
+	kind ldstoretypes.DataKind,
+	item ldstoretypes.SerializedItemDescriptor,
+) (ldstoretypes.ItemDescriptor, error) {
+	return datastore.DeserializeItem(kind, item)
+}
+
+// ValidateSerializedRoundTrip checks that a SerializedItemDescriptor that a PersistentDataStore returned
+// is equivalent to the original item that was serialized with [SerializeDescriptor] and given to the store.
+// It returns an error describing the first difference, or nil if there is none.
+//
+// This is meant for the tests of a data store implementation. It allows for the same differences that the
+// SDK allows for: the store may return a zero Version, if it can only get the version from the serialized
+// data, and may return either the placeholder data or no data for a tombstone.
+func ValidateSerializedRoundTrip(
+	kind ldstoretypes.DataKind,
+	original ldstoretypes.ItemDescriptor,
+	stored ldstoretypes.SerializedItemDescriptor,
+) error {
+	result, err := DeserializeDescriptor(kind, stored)
+	if err != nil {
+		return fmt.Errorf("stored %s item could not be deserialized: %w", kind, err)
+	}
+	if result.Version != original.Version {
+		return fmt.Errorf("stored %s item has version %d, expected %d", kind, result.Version, original.Version)
+	}
+	if IsTombstone(result) != IsTombstone(original) {
+		return fmt.Errorf("stored %s item has deleted state %t, expected %t", kind, IsTombstone(result),
+			IsTombstone(original))
+	}
+	if !bytes.Equal(kind.Serialize(result), kind.Serialize(original)) {
+		return fmt.Errorf("stored %s item has data %s, expected %s", kind, kind.Serialize(result),
+			kind.Serialize(original))
+	}
+	return nil
+}
+
+func descriptorHasNoItemError(item ldstoretypes.ItemDescriptor) error {
+	if IsTombstone(item) {
+		return ErrItemDeleted
+	}
+	return errors.New("item was not found")
+}
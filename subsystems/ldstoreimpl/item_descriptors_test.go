@@ -0,0 +1,138 @@
+package ldstoreimpl
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeDescriptors(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+	flagDesc := MakeFlagDescriptor(flag)
+	assert.Equal(t, 1, flagDesc.Version)
+	assert.Equal(t, &flag, flagDesc.Item)
+
+	segment := ldbuilders.NewSegmentBuilder("segment").Version(2).Build()
+	segmentDesc := MakeSegmentDescriptor(segment)
+	assert.Equal(t, 2, segmentDesc.Version)
+	assert.Equal(t, &segment, segmentDesc.Item)
+
+	assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 3}, MakeTombstone(3))
+}
+
+func TestIsTombstone(t *testing.T) {
+	assert.True(t, IsTombstone(MakeTombstone(0)))
+	assert.True(t, IsTombstone(MakeTombstone(3)))
+	assert.False(t, IsTombstone(ldstoretypes.ItemDescriptor{}.NotFound()))
+	assert.False(t, IsTombstone(MakeFlagDescriptor(ldbuilders.NewFlagBuilder("flag").Version(1).Build())))
+}
+
+func TestDescriptorToFlag(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+	result, err := DescriptorToFlag(MakeFlagDescriptor(flag))
+	require.NoError(t, err)
+	assert.Equal(t, flag, *result)
+
+	_, err = DescriptorToFlag(MakeTombstone(2))
+	assert.Equal(t, ErrItemDeleted, err)
+
+	_, err = DescriptorToFlag(ldstoretypes.ItemDescriptor{}.NotFound())
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrItemDeleted, err)
+
+	_, err = DescriptorToFlag(MakeSegmentDescriptor(ldbuilders.NewSegmentBuilder("segment").Build()))
+	assert.Error(t, err)
+}
+
+func TestDescriptorToSegment(t *testing.T) {
+	segment := ldbuilders.NewSegmentBuilder("segment").Version(1).Build()
+	result, err := DescriptorToSegment(MakeSegmentDescriptor(segment))
+	require.NoError(t, err)
+	assert.Equal(t, segment, *result)
+
+	_, err = DescriptorToSegment(MakeTombstone(2))
+	assert.Equal(t, ErrItemDeleted, err)
+
+	_, err = DescriptorToSegment(ldstoretypes.ItemDescriptor{}.NotFound())
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrItemDeleted, err)
+
+	_, err = DescriptorToSegment(MakeFlagDescriptor(ldbuilders.NewFlagBuilder("flag").Build()))
+	assert.Error(t, err)
+}
+
+func TestSerializeAndDeserializeDescriptor(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+	flagDesc := MakeFlagDescriptor(flag)
+	serialized := SerializeDescriptor(Features(), flagDesc)
+	assert.Equal(t, 1, serialized.Version)
+	assert.False(t, serialized.Deleted)
+	assert.Equal(t, Features().Serialize(flagDesc), serialized.SerializedItem)
+
+	result, err := DeserializeDescriptor(Features(), serialized)
+	require.NoError(t, err)
+	assert.Equal(t, flagDesc, result)
+
+	serializedTombstone := SerializeDescriptor(Segments(), MakeTombstone(2))
+	assert.Equal(t, 2, serializedTombstone.Version)
+	assert.True(t, serializedTombstone.Deleted)
+
+	result, err = DeserializeDescriptor(Segments(), serializedTombstone)
+	require.NoError(t, err)
+	assert.Equal(t, MakeTombstone(2), result)
+
+	_, err = DeserializeDescriptor(Features(), ldstoretypes.SerializedItemDescriptor{
+		Version: 1, SerializedItem: []byte("{no"),
+	})
+	assert.Error(t, err)
+}
+
+func TestValidateSerializedRoundTrip(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+	flagDesc := MakeFlagDescriptor(flag)
+	serialized := SerializeDescriptor(Features(), flagDesc)
+
+	t.Run("unchanged item", func(t *testing.T) {
+		assert.NoError(t, ValidateSerializedRoundTrip(Features(), flagDesc, serialized))
+	})
+
+	t.Run("version only in serialized data", func(t *testing.T) {
+		assert.NoError(t, ValidateSerializedRoundTrip(Features(), flagDesc,
+			ldstoretypes.SerializedItemDescriptor{SerializedItem: serialized.SerializedItem}))
+	})
+
+	t.Run("tombstone with or without placeholder data", func(t *testing.T) {
+		tombstone := MakeTombstone(2)
+		assert.NoError(t, ValidateSerializedRoundTrip(Features(), tombstone,
+			SerializeDescriptor(Features(), tombstone)))
+		assert.NoError(t, ValidateSerializedRoundTrip(Features(), tombstone,
+			ldstoretypes.SerializedItemDescriptor{Version: 2, Deleted: true}))
+	})
+
+	t.Run("wrong version", func(t *testing.T) {
+		changed := serialized
+		changed.Version = 2
+		assert.Error(t, ValidateSerializedRoundTrip(Features(), flagDesc, changed))
+	})
+
+	t.Run("wrong deleted state", func(t *testing.T) {
+		changed := serialized
+		changed.Deleted = true
+		assert.Error(t, ValidateSerializedRoundTrip(Features(), flagDesc, changed))
+	})
+
+	t.Run("wrong data", func(t *testing.T) {
+		otherFlag := ldbuilders.NewFlagBuilder("flag").Version(1).On(true).Build()
+		changed := SerializeDescriptor(Features(), MakeFlagDescriptor(otherFlag))
+		assert.Error(t, ValidateSerializedRoundTrip(Features(), flagDesc, changed))
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		assert.Error(t, ValidateSerializedRoundTrip(Features(), flagDesc,
+			ldstoretypes.SerializedItemDescriptor{Version: 1, SerializedItem: []byte("{no")}))
+	})
+}
@@ -0,0 +1,319 @@
+package ldstoreimpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// totalRolloutWeight is the sum of the weights in a rollout that divides all contexts among its variations.
+const totalRolloutWeight = 100000
+
+// ValidationError describes a problem in a flag or segment configuration that was found by [ValidateFlag]
+// or [ValidateSegment].
+type ValidationError struct {
+	// Path is the location of the problem within the flag or segment, using the property names of the flag's JSON
+	// representation; for instance, "rules[0].clauses[1].values[2]".
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error returns a description of the problem that includes its location.
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidateFlag checks a flag configuration for problems that would cause evaluation errors, or that would
+// make a clause never match, and returns a ValidationError for each one. If it finds no problems it
+// returns nil.
+//
+// This is a static check that does not look at any other flags or segments: for instance, it verifies that
+// each prerequisite has a flag key, but not that such a flag exists; [ValidateDataSet] does that. It checks
+// that every variation index in the flag is within the range of its variations, that every rollout has at
+// least one variation and weights that add up to 100000, that no two rules have the same ID, and that each
+// clause has a known operator and at least one value, of a type that the operator can match. It can be used
+// to check exported flag data before it is put into a data store or a file that the SDK reads, since the SDK
+// itself does not reject such flags unless ldfiledata.DataSourceBuilder.ValidateStrict is enabled.
+func ValidateFlag(flag *ldmodel.FeatureFlag) []ValidationError {
+	v := validator{variationCount: len(flag.Variations)}
+	for i, p := range flag.Prerequisites {
+		path := fmt.Sprintf("prerequisites[%d]", i)
+		if p.Key == "" {
+			v.add(path+".key", "prerequisite flag key must not be empty")
+		}
+		v.checkVariation(path+".variation", p.Variation)
+	}
+	for i, t := range flag.Targets {
+		v.checkVariation(fmt.Sprintf("targets[%d].variation", i), t.Variation)
+	}
+	for i, t := range flag.ContextTargets {
+		v.checkVariation(fmt.Sprintf("contextTargets[%d].variation", i), t.Variation)
+	}
+	ruleIndexByID := make(map[string]int, len(flag.Rules))
+	for i, rule := range flag.Rules {
+		path := fmt.Sprintf("rules[%d]", i)
+		if rule.ID != "" {
+			if first, ok := ruleIndexByID[rule.ID]; ok {
+				v.add(path+".id", fmt.Sprintf("rule ID %q is also used by rules[%d]", rule.ID, first))
+			} else {
+				ruleIndexByID[rule.ID] = i
+			}
+		}
+		for j, clause := range rule.Clauses {
+			v.checkClause(fmt.Sprintf("%s.clauses[%d]", path, j), clause)
+		}
+		v.checkVariationOrRollout(path, rule.VariationOrRollout)
+	}
+	if flag.On || flag.Fallthrough.Variation.IsDefined() || len(flag.Fallthrough.Rollout.Variations) > 0 {
+		// A flag that is off does not use its fallthrough, so it does not need to have one
+		v.checkVariationOrRollout("fallthrough", flag.Fallthrough)
+	}
+	if flag.OffVariation.IsDefined() {
+		v.checkVariation("offVariation", flag.OffVariation.IntValue())
+	}
+	return v.errors
+}
+
+// ValidateSegment checks a segment configuration for problems that would make its rules behave
+// unexpectedly, and returns a ValidationError for each one. If it finds no problems it returns nil.
+//
+// Like [ValidateFlag], this is a static check that does not look at any other flags or segments. It checks
+// that every rule has at least one clause and, if it has a weight, that the weight is between 0 and
+// 100000, and it checks each clause in the same way as ValidateFlag.
+func ValidateSegment(segment *ldmodel.Segment) []ValidationError {
+	v := validator{}
+	for i, rule := range segment.Rules {
+		path := fmt.Sprintf("rules[%d]", i)
+		if len(rule.Clauses) == 0 {
+			v.add(path+".clauses", "segment rule must have at least one clause")
+		}
+		for j, clause := range rule.Clauses {
+			v.checkClause(fmt.Sprintf("%s.clauses[%d]", path, j), clause)
+		}
+		if rule.Weight.IsDefined() && (rule.Weight.IntValue() < 0 || rule.Weight.IntValue() > totalRolloutWeight) {
+			v.add(path+".weight", fmt.Sprintf("weight %d is not between 0 and %d", rule.Weight.IntValue(),
+				totalRolloutWeight))
+		}
+	}
+	return v.errors
+}
+
+// DataSetValidationError describes a problem in one flag or segment that was found by [ValidateDataSet].
+type DataSetValidationError struct {
+	// Kind is the kind of item that has the problem: [Features] or [Segments].
+	Kind ldstoretypes.DataKind
+
+	// Key is the key of the flag or segment.
+	Key string
+
+	ValidationError
+}
+
+// Error returns a description of the problem that includes the kind and key of the item, and the location
+// of the problem within it.
+func (e DataSetValidationError) Error() string {
+	return fmt.Sprintf("%s '%s': %s", e.Kind, e.Key, e.ValidationError)
+}
+
+// ValidateDataSet checks a complete set of flags and segments, such as the contents of the files that a
+// file data source reads, and returns a DataSetValidationError for each problem. If it finds no problems it
+// returns nil.
+//
+// It checks each flag with [ValidateFlag] and each segment with [ValidateSegment], and also checks that
+// every prerequisite refers to a flag in the data set, and that every segmentMatch clause refers to
+// segments in the data set, and that there are no cycles of prerequisites or of segments that refer to each
+// other, which the SDK treats as malformed data. Items that are deleted are treated as if they were not in
+// the data set.
+func ValidateDataSet(flags []*ldmodel.FeatureFlag, segments []*ldmodel.Segment) []DataSetValidationError {
+	flagKeys := make(map[string]bool, len(flags))
+	prerequisiteKeys := make(map[string][]string, len(flags))
+	for _, flag := range flags {
+		if !flag.Deleted {
+			flagKeys[flag.Key] = true
+			for _, p := range flag.Prerequisites {
+				prerequisiteKeys[flag.Key] = append(prerequisiteKeys[flag.Key], p.Key)
+			}
+		}
+	}
+	segmentKeys := make(map[string]bool, len(segments))
+	segmentReferences := make(map[string][]string, len(segments))
+	for _, segment := range segments {
+		if !segment.Deleted {
+			segmentKeys[segment.Key] = true
+			for _, rule := range segment.Rules {
+				segmentReferences[segment.Key] = append(segmentReferences[segment.Key], segmentMatchKeys(rule.Clauses)...)
+			}
+		}
+	}
+	var errors []DataSetValidationError
+	addAll := func(kind ldstoretypes.DataKind, key string, errs []ValidationError) {
+		for _, e := range errs {
+			errors = append(errors, DataSetValidationError{Kind: kind, Key: key, ValidationError: e})
+		}
+	}
+	for _, flag := range flags {
+		if flag.Deleted {
+			continue
+		}
+		addAll(datakinds.Features, flag.Key, ValidateFlag(flag))
+		v := validator{}
+		for i, p := range flag.Prerequisites {
+			if p.Key != "" && !flagKeys[p.Key] {
+				v.add(fmt.Sprintf("prerequisites[%d].key", i), fmt.Sprintf("prerequisite flag %q is not in the data set",
+					p.Key))
+			}
+		}
+		if cycle := findReferenceCycle(flag.Key, prerequisiteKeys); cycle != nil {
+			v.add("prerequisites", "prerequisites form a cycle: "+strings.Join(cycle, " -> "))
+		}
+		for i, rule := range flag.Rules {
+			v.checkSegmentReferences(fmt.Sprintf("rules[%d]", i), rule.Clauses, segmentKeys)
+		}
+		addAll(datakinds.Features, flag.Key, v.errors)
+	}
+	for _, segment := range segments {
+		if segment.Deleted {
+			continue
+		}
+		addAll(datakinds.Segments, segment.Key, ValidateSegment(segment))
+		v := validator{}
+		for i, rule := range segment.Rules {
+			v.checkSegmentReferences(fmt.Sprintf("rules[%d]", i), rule.Clauses, segmentKeys)
+		}
+		if cycle := findReferenceCycle(segment.Key, segmentReferences); cycle != nil {
+			v.add("rules", "segments refer to each other in a cycle: "+strings.Join(cycle, " -> "))
+		}
+		addAll(datakinds.Segments, segment.Key, v.errors)
+	}
+	return errors
+}
+
+type validator struct {
+	variationCount int
+	errors         []ValidationError
+}
+
+func (v *validator) add(path, message string) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: message})
+}
+
+func (v *validator) checkVariation(path string, index int) {
+	if index < 0 || index >= v.variationCount {
+		v.add(path, fmt.Sprintf("variation index %d is out of range; the flag has %d variations",
+			index, v.variationCount))
+	}
+}
+
+func (v *validator) checkVariationOrRollout(path string, vr ldmodel.VariationOrRollout) {
+	if vr.Variation.IsDefined() {
+		v.checkVariation(path+".variation", vr.Variation.IntValue())
+		return
+	}
+	if len(vr.Rollout.Variations) == 0 {
+		v.add(path, "must have either a variation or a rollout with at least one variation")
+		return
+	}
+	totalWeight := 0
+	for i, wv := range vr.Rollout.Variations {
+		v.checkVariation(fmt.Sprintf("%s.rollout.variations[%d].variation", path, i), wv.Variation)
+		totalWeight += wv.Weight
+	}
+	if totalWeight != totalRolloutWeight {
+		v.add(path+".rollout", fmt.Sprintf("rollout weights add up to %d instead of %d", totalWeight, totalRolloutWeight))
+	}
+}
+
+func (v *validator) checkClause(path string, clause ldmodel.Clause) {
+	var allowed func(ldvalue.Value) bool
+	var expected string
+	switch clause.Op {
+	case ldmodel.OperatorIn:
+		allowed = func(ldvalue.Value) bool { return true } // any kind of value can be matched for equality
+	case ldmodel.OperatorEndsWith, ldmodel.OperatorStartsWith, ldmodel.OperatorMatches, ldmodel.OperatorContains,
+		ldmodel.OperatorSegmentMatch,
+		ldmodel.OperatorSemVerEqual, ldmodel.OperatorSemVerLessThan, ldmodel.OperatorSemVerGreaterThan:
+		allowed, expected = ldvalue.Value.IsString, "a string"
+	case ldmodel.OperatorLessThan, ldmodel.OperatorLessThanOrEqual,
+		ldmodel.OperatorGreaterThan, ldmodel.OperatorGreaterThanOrEqual:
+		allowed, expected = ldvalue.Value.IsNumber, "a number"
+	case ldmodel.OperatorBefore, ldmodel.OperatorAfter:
+		allowed = func(value ldvalue.Value) bool { return value.IsNumber() || value.IsString() }
+		expected = "a number or a date string"
+	default:
+		v.add(path+".op", fmt.Sprintf("unknown operator %q", clause.Op))
+		return
+	}
+	if len(clause.Values) == 0 {
+		v.add(path+".values", "clause must have at least one value")
+	}
+	for i, value := range clause.Values {
+		if !allowed(value) {
+			v.add(fmt.Sprintf("%s.values[%d]", path, i),
+				fmt.Sprintf("operator %q requires %s, but the value is %s", clause.Op, expected, value.JSONString()))
+		}
+	}
+}
+
+func (v *validator) checkSegmentReferences(path string, clauses []ldmodel.Clause, segmentKeys map[string]bool) {
+	for i, clause := range clauses {
+		if clause.Op != ldmodel.OperatorSegmentMatch {
+			continue
+		}
+		for j, value := range clause.Values {
+			// A value that is not a string has already been reported by checkClause
+			if value.IsString() && !segmentKeys[value.StringValue()] {
+				v.add(fmt.Sprintf("%s.clauses[%d].values[%d]", path, i, j),
+					fmt.Sprintf("segment %q is not in the data set", value.StringValue()))
+			}
+		}
+	}
+}
+
+func segmentMatchKeys(clauses []ldmodel.Clause) []string {
+	var keys []string
+	for _, clause := range clauses {
+		if clause.Op == ldmodel.OperatorSegmentMatch {
+			for _, value := range clause.Values {
+				if value.IsString() {
+					keys = append(keys, value.StringValue())
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// findReferenceCycle returns the keys in a chain of references that leads from the start key back to
+// itself, beginning and ending with the start key, or nil if there is no such chain.
+func findReferenceCycle(start string, references map[string][]string) []string {
+	visited := make(map[string]bool)
+	var chain []string
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		chain = append(chain, key)
+		for _, next := range references[key] {
+			if next == start {
+				chain = append(chain, next)
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		chain = chain[:len(chain)-1]
+		return false
+	}
+	if visit(start) {
+		return chain
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package ldstoreimpl
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeValidFlag() ldmodel.FeatureFlag {
+	return ldbuilders.NewFlagBuilder("flagkey").Version(1).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+		OffVariation(0).Fallthrough(ldbuilders.Rollout(ldbuilders.Bucket(0, 40000), ldbuilders.Bucket(1, 60000))).
+		AddPrerequisite("prereq", 1).
+		AddTarget(1, "user-key").
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule0").Variation(0).Clauses(
+			ldbuilders.Clause("name", ldmodel.OperatorIn, ldvalue.String("x"), ldvalue.Int(1)),
+			ldbuilders.Clause("name", ldmodel.OperatorStartsWith, ldvalue.String("x")),
+			ldbuilders.Clause("age", ldmodel.OperatorGreaterThan, ldvalue.Int(21)),
+			ldbuilders.Clause("created", ldmodel.OperatorBefore, ldvalue.String("2020-01-01T00:00:00Z"), ldvalue.Int(0)),
+			ldbuilders.SegmentMatchClause("segmentkey"),
+		)).
+		Build()
+}
+
+func TestValidateFlagReturnsNilForValidFlag(t *testing.T) {
+	flag := makeValidFlag()
+	assert.Nil(t, ValidateFlag(&flag))
+}
+
+func TestValidateFlagReportsProblems(t *testing.T) {
+	doTest := func(name string, change func(*ldmodel.FeatureFlag), expected ...ValidationError) {
+		t.Run(name, func(t *testing.T) {
+			flag := makeValidFlag()
+			change(&flag)
+			assert.Equal(t, expected, ValidateFlag(&flag))
+		})
+	}
+
+	doTest("negative fallthrough variation",
+		func(f *ldmodel.FeatureFlag) {
+			f.Fallthrough = ldmodel.VariationOrRollout{Variation: ldvalue.NewOptionalInt(-1)}
+		},
+		ValidationError{"fallthrough.variation", "variation index -1 is out of range; the flag has 2 variations"})
+
+	doTest("off variation too high",
+		func(f *ldmodel.FeatureFlag) { f.OffVariation = ldvalue.NewOptionalInt(2) },
+		ValidationError{"offVariation", "variation index 2 is out of range; the flag has 2 variations"})
+
+	doTest("target variation out of range",
+		func(f *ldmodel.FeatureFlag) { f.Targets[0].Variation = 5 },
+		ValidationError{"targets[0].variation", "variation index 5 is out of range; the flag has 2 variations"})
+
+	doTest("rule variation out of range",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].Variation = ldvalue.NewOptionalInt(3) },
+		ValidationError{"rules[0].variation", "variation index 3 is out of range; the flag has 2 variations"})
+
+	doTest("rollout variation out of range",
+		func(f *ldmodel.FeatureFlag) { f.Fallthrough.Rollout.Variations[1].Variation = 2 },
+		ValidationError{"fallthrough.rollout.variations[1].variation",
+			"variation index 2 is out of range; the flag has 2 variations"})
+
+	doTest("neither variation nor rollout",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].VariationOrRollout = ldmodel.VariationOrRollout{} },
+		ValidationError{"rules[0]", "must have either a variation or a rollout with at least one variation"})
+
+	doTest("no fallthrough",
+		func(f *ldmodel.FeatureFlag) { f.Fallthrough = ldmodel.VariationOrRollout{} },
+		ValidationError{"fallthrough", "must have either a variation or a rollout with at least one variation"})
+
+	doTest("no fallthrough in a flag that is off",
+		func(f *ldmodel.FeatureFlag) {
+			f.On = false
+			f.Fallthrough = ldmodel.VariationOrRollout{}
+		})
+
+	doTest("rollout weights do not add up",
+		func(f *ldmodel.FeatureFlag) { f.Fallthrough.Rollout.Variations[0].Weight = 1 },
+		ValidationError{"fallthrough.rollout", "rollout weights add up to 60001 instead of 100000"})
+
+	doTest("empty prerequisite key",
+		func(f *ldmodel.FeatureFlag) { f.Prerequisites[0].Key = "" },
+		ValidationError{"prerequisites[0].key", "prerequisite flag key must not be empty"})
+
+	doTest("prerequisite variation out of range",
+		func(f *ldmodel.FeatureFlag) { f.Prerequisites[0].Variation = -1 },
+		ValidationError{"prerequisites[0].variation", "variation index -1 is out of range; the flag has 2 variations"})
+
+	doTest("string operator with non-string value",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].Clauses[1].Values = []ldvalue.Value{ldvalue.Int(1)} },
+		ValidationError{"rules[0].clauses[1].values[0]", `operator "startsWith" requires a string, but the value is 1`})
+
+	doTest("numeric operator with non-numeric value",
+		func(f *ldmodel.FeatureFlag) {
+			f.Rules[0].Clauses[2].Values = []ldvalue.Value{ldvalue.Int(1), ldvalue.String("21")}
+		},
+		ValidationError{"rules[0].clauses[2].values[1]", `operator "greaterThan" requires a number, but the value is "21"`})
+
+	doTest("date operator with boolean value",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].Clauses[3].Values = []ldvalue.Value{ldvalue.Bool(true)} },
+		ValidationError{"rules[0].clauses[3].values[0]",
+			`operator "before" requires a number or a date string, but the value is true`})
+
+	doTest("clause with no values",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].Clauses[0].Values = nil },
+		ValidationError{"rules[0].clauses[0].values", "clause must have at least one value"})
+
+	doTest("unknown operator",
+		func(f *ldmodel.FeatureFlag) { f.Rules[0].Clauses[0].Op = "isOneOf" },
+		ValidationError{"rules[0].clauses[0].op", `unknown operator "isOneOf"`})
+
+	doTest("duplicate rule ID",
+		func(f *ldmodel.FeatureFlag) {
+			rule := ldbuilders.NewRuleBuilder().Variation(1).Clauses(
+				ldbuilders.Clause("name", ldmodel.OperatorIn, ldvalue.String("y")))
+			f.Rules = append(f.Rules, rule.ID("rule1").Build(), rule.ID("rule0").Build(), rule.ID("").Build(),
+				rule.ID("").Build())
+		},
+		ValidationError{"rules[2].id", `rule ID "rule0" is also used by rules[0]`})
+
+	doTest("multiple problems",
+		func(f *ldmodel.FeatureFlag) {
+			f.Variations = f.Variations[:1]
+			f.Prerequisites[0].Key = ""
+		},
+		ValidationError{"prerequisites[0].key", "prerequisite flag key must not be empty"},
+		ValidationError{"prerequisites[0].variation", "variation index 1 is out of range; the flag has 1 variations"},
+		ValidationError{"targets[0].variation", "variation index 1 is out of range; the flag has 1 variations"},
+		ValidationError{"fallthrough.rollout.variations[1].variation",
+			"variation index 1 is out of range; the flag has 1 variations"})
+}
+
+func makeValidSegment() ldmodel.Segment {
+	return ldbuilders.NewSegmentBuilder("segmentkey").Version(1).
+		Included("user-key").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().ID("rule0").Clauses(
+			ldbuilders.Clause("name", ldmodel.OperatorEndsWith, ldvalue.String("x")),
+		)).
+		AddRule(ldbuilders.NewSegmentRuleBuilder().ID("rule1").Weight(50000).Clauses(
+			ldbuilders.Clause("age", ldmodel.OperatorLessThan, ldvalue.Int(21)),
+		)).
+		Build()
+}
+
+func TestValidateSegmentReturnsNilForValidSegment(t *testing.T) {
+	segment := makeValidSegment()
+	assert.Nil(t, ValidateSegment(&segment))
+}
+
+func TestValidateSegmentReportsProblems(t *testing.T) {
+	doTest := func(name string, change func(*ldmodel.Segment), expected ...ValidationError) {
+		t.Run(name, func(t *testing.T) {
+			segment := makeValidSegment()
+			change(&segment)
+			assert.Equal(t, expected, ValidateSegment(&segment))
+		})
+	}
+
+	doTest("rule with no clauses",
+		func(s *ldmodel.Segment) { s.Rules[0].Clauses = nil },
+		ValidationError{"rules[0].clauses", "segment rule must have at least one clause"})
+
+	doTest("negative weight",
+		func(s *ldmodel.Segment) { s.Rules[1].Weight = ldvalue.NewOptionalInt(-1) },
+		ValidationError{"rules[1].weight", "weight -1 is not between 0 and 100000"})
+
+	doTest("weight too high",
+		func(s *ldmodel.Segment) { s.Rules[1].Weight = ldvalue.NewOptionalInt(100001) },
+		ValidationError{"rules[1].weight", "weight 100001 is not between 0 and 100000"})
+
+	doTest("unknown operator",
+		func(s *ldmodel.Segment) { s.Rules[0].Clauses[0].Op = "" },
+		ValidationError{"rules[0].clauses[0].op", `unknown operator ""`})
+
+	doTest("clause with no values",
+		func(s *ldmodel.Segment) { s.Rules[1].Clauses[0].Values = []ldvalue.Value{} },
+		ValidationError{"rules[1].clauses[0].values", "clause must have at least one value"})
+
+	doTest("clause value of wrong type",
+		func(s *ldmodel.Segment) { s.Rules[1].Clauses[0].Values = []ldvalue.Value{ldvalue.String("21")} },
+		ValidationError{"rules[1].clauses[0].values[0]", `operator "lessThan" requires a number, but the value is "21"`})
+}
+
+func TestValidateDataSetReturnsNilForValidData(t *testing.T) {
+	flag, prereq, segment := makeValidFlag(), ldbuilders.NewFlagBuilder("prereq").Variations(ldvalue.Bool(true),
+		ldvalue.Bool(false)).OffVariation(0).Build(), makeValidSegment()
+	assert.Nil(t, ValidateDataSet([]*ldmodel.FeatureFlag{&flag, &prereq}, []*ldmodel.Segment{&segment}))
+}
+
+func TestValidateDataSetReportsProblems(t *testing.T) {
+	flag := makeValidFlag()
+	flag.Variations = flag.Variations[:1]
+	flag.Fallthrough = ldmodel.VariationOrRollout{Variation: ldvalue.NewOptionalInt(0)}
+	flag.Targets = nil
+	deletedFlag := ldbuilders.NewFlagBuilder("prereq").Deleted(true).Build()
+	segment := makeValidSegment()
+	segment.Rules[0].Clauses = append(segment.Rules[0].Clauses,
+		ldbuilders.SegmentMatchClause("othersegment"))
+	segment.Rules[1].Weight = ldvalue.NewOptionalInt(-1)
+
+	errs := ValidateDataSet([]*ldmodel.FeatureFlag{&flag, &deletedFlag}, []*ldmodel.Segment{&segment})
+	assert.Equal(t, []DataSetValidationError{
+		{datakinds.Features, "flagkey",
+			ValidationError{"prerequisites[0].variation", "variation index 1 is out of range; the flag has 1 variations"}},
+		{datakinds.Features, "flagkey",
+			ValidationError{"prerequisites[0].key", `prerequisite flag "prereq" is not in the data set`}},
+		{datakinds.Segments, "segmentkey",
+			ValidationError{"rules[1].weight", "weight -1 is not between 0 and 100000"}},
+		{datakinds.Segments, "segmentkey",
+			ValidationError{"rules[0].clauses[1].values[0]", `segment "othersegment" is not in the data set`}},
+	}, errs)
+}
+
+func TestValidateDataSetChecksSegmentReferencesInFlags(t *testing.T) {
+	flag := makeValidFlag()
+	flag.Prerequisites = nil
+	flag.Rules[0].Clauses[4].Values = append(flag.Rules[0].Clauses[4].Values, ldvalue.Int(1))
+
+	errs := ValidateDataSet([]*ldmodel.FeatureFlag{&flag}, nil)
+	assert.Equal(t, []DataSetValidationError{
+		{datakinds.Features, "flagkey", ValidationError{"rules[0].clauses[4].values[1]",
+			`operator "segmentMatch" requires a string, but the value is 1`}},
+		{datakinds.Features, "flagkey",
+			ValidationError{"rules[0].clauses[4].values[0]", `segment "segmentkey" is not in the data set`}},
+	}, errs)
+}
+
+func TestValidateDataSetReportsPrerequisiteCycles(t *testing.T) {
+	flag1 := ldbuilders.NewFlagBuilder("flag1").Variations(ldvalue.Bool(true)).OffVariation(0).
+		AddPrerequisite("flag2", 0).Build()
+	flag2 := ldbuilders.NewFlagBuilder("flag2").Variations(ldvalue.Bool(true)).OffVariation(0).
+		AddPrerequisite("flag3", 0).Build()
+	flag3 := ldbuilders.NewFlagBuilder("flag3").Variations(ldvalue.Bool(true)).OffVariation(0).
+		AddPrerequisite("flag2", 0).Build()
+
+	errs := ValidateDataSet([]*ldmodel.FeatureFlag{&flag1, &flag2, &flag3}, nil)
+	assert.Equal(t, []DataSetValidationError{
+		{datakinds.Features, "flag2",
+			ValidationError{"prerequisites", "prerequisites form a cycle: flag2 -> flag3 -> flag2"}},
+		{datakinds.Features, "flag3",
+			ValidationError{"prerequisites", "prerequisites form a cycle: flag3 -> flag2 -> flag3"}},
+	}, errs)
+}
+
+func TestValidateDataSetReportsSegmentCycles(t *testing.T) {
+	makeSegment := func(key string, refs ...string) ldmodel.Segment {
+		return ldbuilders.NewSegmentBuilder(key).
+			AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(ldbuilders.SegmentMatchClause(refs...))).Build()
+	}
+	segment1, segment2 := makeSegment("segment1", "segment1"), makeSegment("segment2", "segment1")
+
+	errs := ValidateDataSet(nil, []*ldmodel.Segment{&segment1, &segment2})
+	assert.Equal(t, []DataSetValidationError{
+		{datakinds.Segments, "segment1",
+			ValidationError{"rules", "segments refer to each other in a cycle: segment1 -> segment1"}},
+	}, errs)
+}
+
+func TestDataSetValidationErrorMessageIncludesKindAndKey(t *testing.T) {
+	err := DataSetValidationError{Kind: datakinds.Segments, Key: "segmentkey",
+		ValidationError: ValidationError{Path: "rules[0]", Message: "bad"}}
+	assert.Equal(t, "segments 'segmentkey': rules[0]: bad", err.Error())
+}
+
+func TestValidationErrorMessageIncludesPath(t *testing.T) {
+	err := ValidationError{Path: "rules[0]", Message: "bad"}
+	assert.Equal(t, "rules[0]: bad", err.Error())
+}
@@ -1,6 +1,7 @@
 package subsystems
 
 import (
+	gocontext "context"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -23,4 +24,12 @@ type LoggingConfiguration struct {
 
 	// LogContextKeyInErrors is true if context keys may be included in logging.
 	LogContextKeyInErrors bool
+
+	// LogContextAttributes is true if Debug-level logging may include the attributes of evaluation
+	// contexts. See LoggingConfigurationBuilder.LogContextAttributes().
+	LogContextAttributes bool
+
+	// ContextExtractor, if not nil, returns a prefix for log messages from an evaluation that was
+	// done with the given Go context. See LoggingConfigurationBuilder.WithContextExtractor().
+	ContextExtractor func(ctx gocontext.Context) string
 }
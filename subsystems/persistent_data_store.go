@@ -84,3 +84,16 @@ type PersistentDataStore interface {
 	// IsStoreAvailable() at intervals until it returns true.
 	IsStoreAvailable() bool
 }
+
+// PersistentDataStoreChangeNotifier is an optional interface for a PersistentDataStore that can tell when
+// items in the database are changed by another process, such as the Relay Proxy. If the store implements
+// it and the SDK is caching items with a limited TTL, the SDK removes each item from its cache as soon as
+// the store reports a change, rather than keeping the old version until the TTL expires. This is most
+// useful with ldcomponents.ExternalUpdatesOnly, where the SDK only learns of changes from the database.
+type PersistentDataStoreChangeNotifier interface {
+	// SetItemChangedHandler is called by the SDK once, after it creates the store and before using it.
+	// From then on, the store should call the handler whenever it sees that an item in the database has
+	// been added, updated, or removed; it can do so from any goroutine, and it can report items that the
+	// SDK itself has just written.
+	SetItemChangedHandler(handler func(kind ldstoretypes.DataKind, key string))
+}
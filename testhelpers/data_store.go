@@ -0,0 +1,47 @@
+package testhelpers
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v7/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// NewInMemoryDataStoreWithItems creates an in-memory data store that is already initialized with the
+// specified flags and segments, with each item's version taken from its Version property.
+//
+// This is a shortcut for
+// [github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl.NewInMemoryDataStoreWithData], and it
+// returns an error in the same cases: for instance, if two flags have the same key.
+//
+//	store, err := testhelpers.NewInMemoryDataStoreWithItems(
+//	    []ldmodel.FeatureFlag{ldbuilders.NewFlagBuilder("flag-key").Version(1).Build()},
+//	    nil,
+//	)
+func NewInMemoryDataStoreWithItems(
+	flags []ldmodel.FeatureFlag,
+	segments []ldmodel.Segment,
+) (subsystems.DataStore, error) {
+	flagItems := make([]ldstoretypes.KeyedItemDescriptor, 0, len(flags))
+	for i := range flags {
+		flag := flags[i]
+		flagItems = append(flagItems, ldstoretypes.KeyedItemDescriptor{
+			Key:  flag.Key,
+			Item: ldstoretypes.ItemDescriptor{Version: flag.Version, Item: &flag},
+		})
+	}
+	segmentItems := make([]ldstoretypes.KeyedItemDescriptor, 0, len(segments))
+	for i := range segments {
+		segment := segments[i]
+		segmentItems = append(segmentItems, ldstoretypes.KeyedItemDescriptor{
+			Key:  segment.Key,
+			Item: ldstoretypes.ItemDescriptor{Version: segment.Version, Item: &segment},
+		})
+	}
+	return datastore.NewInMemoryDataStoreWithData([]ldstoretypes.Collection{
+		{Kind: datakinds.Features, Items: flagItems},
+		{Kind: datakinds.Segments, Items: segmentItems},
+	}, ldlog.NewDisabledLoggers())
+}
@@ -0,0 +1,324 @@
+package ldservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+)
+
+const (
+	mockServerFlagsKind    = "flags"
+	mockServerSegmentsKind = "segments"
+
+	mockServerRequestsCapacity = 1000
+)
+
+type mockServerFailureMode int
+
+const (
+	mockServerNoFailure mockServerFailureMode = iota
+	mockServerFailWithStatus
+	mockServerFailWithBrokenConnection
+)
+
+// MockServer is an embedded HTTP server that simulates all of the LaunchDarkly service endpoints used by
+// the server-side SDK: the streaming endpoint at /all, the polling endpoint at /sdk/latest-all, and the
+// event endpoints at /bulk and /diagnostic.
+//
+// Every stream connection starts with a "put" event containing the server's current data, and every
+// polling request receives the current data. The data can be changed during a test with PutData,
+// PatchFlag, PatchSegment, DeleteFlag, and DeleteSegment; each of these also pushes the corresponding
+// event to any open stream connections.
+//
+//	server := ldservices.NewMockServer(ldservices.NewServerSDKData().Flags(flag1))
+//	defer server.Close()
+//	config := ld.Config{ServiceEndpoints: server.ServiceEndpoints()}
+//	client, _ := ld.MakeCustomClient(sdkKey, config, 5*time.Second)
+//	server.PatchFlag(updatedFlag1) // the client receives the update over its stream connection
+//	r := <-server.EventRequests()   // an event payload that the client posted
+//
+// Network problems can be simulated with FailWithStatus, FailWithBrokenConnection, and DropStreams.
+//
+// Requests are recorded on buffered channels with a capacity of 1000; if a test makes more requests than
+// that without reading from the channels, the server will block.
+type MockServer struct {
+	server          *httptest.Server
+	data            *ServerSDKData
+	streams         map[chan []byte]struct{}
+	failureMode     mockServerFailureMode
+	failureStatus   int
+	dataRequestsCh  chan httphelpers.HTTPRequestInfo
+	eventRequestsCh chan httphelpers.HTTPRequestInfo
+	lock            sync.Mutex
+}
+
+// NewMockServer creates and starts a MockServer with the specified initial data. If data is nil, the
+// server starts out with no flags or segments.
+//
+// The server makes its own copy of the data, so changing the ServerSDKData afterward has no effect; use
+// PutData instead.
+func NewMockServer(data *ServerSDKData) *MockServer {
+	s := &MockServer{
+		data:            copyServerSDKData(data),
+		streams:         make(map[chan []byte]struct{}),
+		dataRequestsCh:  make(chan httphelpers.HTTPRequestInfo, mockServerRequestsCapacity),
+		eventRequestsCh: make(chan httphelpers.HTTPRequestInfo, mockServerRequestsCapacity),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL returns the base URL of the server.
+func (s *MockServer) URL() string {
+	return s.server.URL
+}
+
+// ServiceEndpoints returns a ServiceEndpoints configuration that directs all SDK requests to this server.
+func (s *MockServer) ServiceEndpoints() interfaces.ServiceEndpoints {
+	return interfaces.ServiceEndpoints{
+		Streaming: s.server.URL,
+		Polling:   s.server.URL,
+		Events:    s.server.URL,
+	}
+}
+
+// DataRequests returns a channel that receives every request made to the streaming or polling endpoint.
+func (s *MockServer) DataRequests() <-chan httphelpers.HTTPRequestInfo {
+	return s.dataRequestsCh
+}
+
+// EventRequests returns a channel that receives every request made to the event endpoints, including the
+// request body.
+func (s *MockServer) EventRequests() <-chan httphelpers.HTTPRequestInfo {
+	return s.eventRequestsCh
+}
+
+// PutData replaces all of the server's data and sends a "put" event to any open stream connections.
+func (s *MockServer) PutData(data *ServerSDKData) {
+	s.lock.Lock()
+	s.data = copyServerSDKData(data)
+	event := s.data.ToPutEvent()
+	s.lock.Unlock()
+	s.send(event)
+}
+
+// PatchFlag adds or replaces a flag and sends a "patch" event to any open stream connections.
+//
+// As with ServerSDKData.Flags, the flag can be any object that has a "key" property when converted to JSON.
+func (s *MockServer) PatchFlag(flag interface{}) {
+	s.patch(mockServerFlagsKind, flag)
+}
+
+// PatchSegment adds or replaces a segment and sends a "patch" event to any open stream connections.
+//
+// As with ServerSDKData.Segments, the segment can be any object that has a "key" property when converted
+// to JSON.
+func (s *MockServer) PatchSegment(segment interface{}) {
+	s.patch(mockServerSegmentsKind, segment)
+}
+
+// DeleteFlag removes a flag and sends a "delete" event to any open stream connections.
+func (s *MockServer) DeleteFlag(key string, version int) {
+	s.delete(mockServerFlagsKind, key, version)
+}
+
+// DeleteSegment removes a segment and sends a "delete" event to any open stream connections.
+func (s *MockServer) DeleteSegment(key string, version int) {
+	s.delete(mockServerSegmentsKind, key, version)
+}
+
+// FailWithStatus causes all subsequent requests to any endpoint to receive the specified HTTP status,
+// such as 401 or 503, until Recover is called. Any open stream connections are closed.
+func (s *MockServer) FailWithStatus(status int) {
+	s.setFailureMode(mockServerFailWithStatus, status)
+}
+
+// FailWithBrokenConnection causes all subsequent requests to any endpoint to have their connections
+// closed without a response, until Recover is called. Any open stream connections are closed.
+func (s *MockServer) FailWithBrokenConnection() {
+	s.setFailureMode(mockServerFailWithBrokenConnection, 0)
+}
+
+// Recover undoes the effect of FailWithStatus or FailWithBrokenConnection.
+func (s *MockServer) Recover() {
+	s.setFailureMode(mockServerNoFailure, 0)
+}
+
+// DropStreams closes any open stream connections, without affecting subsequent requests. This simulates
+// a stream connection being interrupted; the SDK should reconnect and receive a new "put" event.
+func (s *MockServer) DropStreams() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closeStreamsLocked()
+}
+
+// Close closes any open connections and shuts down the server.
+func (s *MockServer) Close() {
+	s.DropStreams()
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+func (s *MockServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var requestsCh chan httphelpers.HTTPRequestInfo
+	switch {
+	case r.URL.Path == ServerSideSDKStreamingPath && r.Method == http.MethodGet,
+		r.URL.Path == serverSideSDKPollingPath && r.Method == http.MethodGet:
+		requestsCh = s.dataRequestsCh
+	case r.URL.Path == serverSideEventsPath && r.Method == http.MethodPost,
+		r.URL.Path == serverSideDiagnosticEventsPath && r.Method == http.MethodPost:
+		requestsCh = s.eventRequestsCh
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+	requestsCh <- httphelpers.HTTPRequestInfo{Request: r, Body: body}
+
+	s.lock.Lock()
+	failureMode, failureStatus := s.failureMode, s.failureStatus
+	s.lock.Unlock()
+	switch failureMode {
+	case mockServerFailWithStatus:
+		w.WriteHeader(failureStatus)
+		return
+	case mockServerFailWithBrokenConnection:
+		httphelpers.BrokenConnectionHandler().ServeHTTP(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case ServerSideSDKStreamingPath:
+		s.serveStream(w, r)
+	case serverSideSDKPollingPath:
+		s.lock.Lock()
+		payload := []byte(s.data.String())
+		s.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(payload)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *MockServer) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan []byte, mockServerRequestsCapacity)
+	s.lock.Lock()
+	initialEvent := s.data.ToPutEvent()
+	s.streams[ch] = struct{}{}
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(initialEvent.Bytes())
+	flusher.Flush()
+
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			_, _ = w.Write(data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			s.lock.Lock()
+			if _, open := s.streams[ch]; open {
+				delete(s.streams, ch)
+				close(ch)
+			}
+			s.lock.Unlock()
+			return
+		}
+	}
+}
+
+func (s *MockServer) patch(kind string, item interface{}) {
+	key := getKeyFromJSON(item)
+	if key == "" {
+		return
+	}
+	itemJSON, _ := json.Marshal(item)
+	s.lock.Lock()
+	s.itemsLocked(kind)[key] = item
+	s.lock.Unlock()
+	s.send(httphelpers.SSEEvent{
+		Event: "patch",
+		Data:  fmt.Sprintf(`{"path": "/%s/%s", "data": %s}`, kind, key, itemJSON),
+	})
+}
+
+func (s *MockServer) delete(kind, key string, version int) {
+	s.lock.Lock()
+	delete(s.itemsLocked(kind), key)
+	s.lock.Unlock()
+	s.send(httphelpers.SSEEvent{
+		Event: "delete",
+		Data:  fmt.Sprintf(`{"path": "/%s/%s", "version": %d}`, kind, key, version),
+	})
+}
+
+func (s *MockServer) itemsLocked(kind string) map[string]interface{} {
+	if kind == mockServerSegmentsKind {
+		return s.data.SegmentsMap
+	}
+	return s.data.FlagsMap
+}
+
+func (s *MockServer) send(event httphelpers.SSEEvent) {
+	data := event.Bytes()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for ch := range s.streams {
+		select {
+		case ch <- data:
+		default: // this stream isn't keeping up; it would be unusual for a test to queue this many events
+		}
+	}
+}
+
+func (s *MockServer) setFailureMode(mode mockServerFailureMode, status int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failureMode, s.failureStatus = mode, status
+	if mode != mockServerNoFailure {
+		s.closeStreamsLocked()
+	}
+}
+
+func (s *MockServer) closeStreamsLocked() {
+	for ch := range s.streams {
+		close(ch)
+	}
+	s.streams = make(map[chan []byte]struct{})
+}
+
+func copyServerSDKData(data *ServerSDKData) *ServerSDKData {
+	ret := NewServerSDKData()
+	if data != nil {
+		for k, v := range data.FlagsMap {
+			ret.FlagsMap[k] = v
+		}
+		for k, v := range data.SegmentsMap {
+			ret.SegmentsMap[k] = v
+		}
+	}
+	return ret
+}
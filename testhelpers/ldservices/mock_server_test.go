@@ -0,0 +1,160 @@
+package ldservices
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSSELines(t *testing.T, reader *bufio.Reader, count int) []string {
+	var lines []string
+	for len(lines) < count {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestMockServerServiceEndpoints(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	endpoints := server.ServiceEndpoints()
+	assert.Equal(t, server.URL(), endpoints.Streaming)
+	assert.Equal(t, server.URL(), endpoints.Polling)
+	assert.Equal(t, server.URL(), endpoints.Events)
+}
+
+func TestMockServerPollingEndpointReturnsCurrentData(t *testing.T) {
+	data := NewServerSDKData().Flags(KeyAndVersionItem("flag1", 1))
+	server := NewMockServer(data)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL() + serverSideSDKPollingPath)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, data.String(), string(body))
+
+	server.PatchFlag(KeyAndVersionItem("flag2", 1))
+	server.DeleteFlag("flag1", 2)
+
+	resp, err = http.Get(server.URL() + serverSideSDKPollingPath)
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.JSONEq(t, NewServerSDKData().Flags(KeyAndVersionItem("flag2", 1)).String(), string(body))
+
+	assert.Len(t, server.DataRequests(), 2)
+}
+
+func TestMockServerStreamingEndpointSendsEvents(t *testing.T) {
+	data := NewServerSDKData().Flags(KeyAndVersionItem("flag1", 1))
+	server := NewMockServer(data)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL() + ServerSideSDKStreamingPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	reader := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, []string{"event: put", "data: " + `{"path": "/", "data": ` + data.String() + "}"},
+		readSSELines(t, reader, 2))
+
+	server.PatchSegment(KeyAndVersionItem("segment1", 1))
+	assert.Equal(t,
+		[]string{"event: patch", `data: {"path": "/segments/segment1", "data": {"key":"segment1","version":1}}`},
+		readSSELines(t, reader, 2))
+
+	server.DeleteFlag("flag1", 2)
+	assert.Equal(t, []string{"event: delete", `data: {"path": "/flags/flag1", "version": 2}`},
+		readSSELines(t, reader, 2))
+}
+
+func TestMockServerDropStreamsClosesConnection(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL() + ServerSideSDKStreamingPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	readSSELines(t, reader, 2)
+
+	server.DropStreams()
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.ReadAll(reader)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "timed out waiting for stream to close")
+	}
+}
+
+func TestMockServerRecordsEventRequests(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL()+serverSideEventsPath, "application/json", bytes.NewBufferString("[]"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 202, resp.StatusCode)
+
+	r := <-server.EventRequests()
+	assert.Equal(t, serverSideEventsPath, r.Request.URL.Path)
+	assert.Equal(t, "[]", string(r.Body))
+}
+
+func TestMockServerFailWithStatus(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	server.FailWithStatus(503)
+	for _, path := range []string{ServerSideSDKStreamingPath, serverSideSDKPollingPath} {
+		resp, err := http.Get(server.URL() + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, 503, resp.StatusCode)
+	}
+
+	server.Recover()
+	resp, err := http.Get(server.URL() + serverSideSDKPollingPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestMockServerFailWithBrokenConnection(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	server.FailWithBrokenConnection()
+	_, err := http.Get(server.URL() + serverSideSDKPollingPath)
+	assert.Error(t, err)
+}
+
+func TestMockServerReturns404ForUnknownPath(t *testing.T) {
+	server := NewMockServer(nil)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL() + "/other")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Len(t, server.DataRequests(), 0)
+}
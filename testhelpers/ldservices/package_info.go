@@ -3,4 +3,7 @@
 // This is mainly intended for use in the Go SDK's unit tests. It is also used in unit tests for the
 // LaunchDarkly Relay Proxy, and could be useful in testing other applications that use the Go SDK if it
 // is desirable to use real HTTP rather than other kinds of test fixtures.
+//
+// For integration tests of an application that uses the SDK, MockServer provides a complete embedded
+// server that simulates the streaming, polling, and event endpoints together.
 package ldservices
@@ -27,6 +27,8 @@ type FlagBuilder struct {
 	variations           []ldvalue.Value
 	targets              map[ldcontext.Kind]map[int]map[string]bool
 	rules                []*RuleBuilder
+	trackEvents          bool
+	trackFallthrough     bool
 }
 
 // RuleBuilder is a builder for feature flag rules to be used with [TestDataSource.]
@@ -40,9 +42,10 @@ type FlagBuilder struct {
 // methods such as [RuleBuilder.AndMatch]. Finally, call [RuleBuilder.ThenReturn] or
 // [RuleBuilder.ThenReturnIndex] to finish defining the rule.
 type RuleBuilder struct {
-	owner     *FlagBuilder
-	variation int
-	clauses   []ldmodel.Clause
+	owner       *FlagBuilder
+	variation   int
+	clauses     []ldmodel.Clause
+	trackEvents bool
 }
 
 func newFlagBuilder(key string) *FlagBuilder {
@@ -334,6 +337,26 @@ func (f *FlagBuilder) IfNotMatchContext(
 	return newTestFlagRuleBuilder(f).AndNotMatchContext(contextKind, attribute, values...)
 }
 
+// TrackEvents sets whether the SDK should send full feature events, with evaluation reasons, for every
+// evaluation of this flag. This is the same as the flag's TrackEvents property in the LaunchDarkly model,
+// which is normally set when the flag is part of an experiment.
+//
+// The default is false, in which case evaluations of the flag are only counted in summary events.
+func (f *FlagBuilder) TrackEvents(trackEvents bool) *FlagBuilder {
+	f.trackEvents = trackEvents
+	return f
+}
+
+// TrackEventsFallthrough sets whether the SDK should send full feature events, with evaluation reasons,
+// for evaluations of this flag that return the fallthrough variation. This is the same as the flag's
+// TrackEventsFallthrough property in the LaunchDarkly model.
+//
+// The default is false. To do the same for a rule, use [RuleBuilder.TrackEvents].
+func (f *FlagBuilder) TrackEventsFallthrough(trackEventsFallthrough bool) *FlagBuilder {
+	f.trackFallthrough = trackEventsFallthrough
+	return f
+}
+
 // ClearRules removes any existing rules from the flag. This undoes the effect of methods like
 // [FlagBuilder.IfMatch].
 func (f *FlagBuilder) ClearRules() *FlagBuilder {
@@ -358,7 +381,9 @@ func (f *FlagBuilder) createFlag(version int) ldmodel.FeatureFlag {
 	fb := ldbuilders.NewFlagBuilder(f.key).
 		Version(version).
 		On(f.on).
-		Variations(f.variations...)
+		Variations(f.variations...).
+		TrackEvents(f.trackEvents).
+		TrackEventsFallthrough(f.trackFallthrough)
 	if f.offVariation.IsDefined() {
 		fb.OffVariation(f.offVariation.IntValue())
 	}
@@ -399,7 +424,8 @@ func (f *FlagBuilder) createFlag(version int) ldmodel.FeatureFlag {
 		fb.AddRule(ldbuilders.NewRuleBuilder().
 			ID(fmt.Sprintf("rule%d", i)).
 			Variation(r.variation).
-			Clauses(r.clauses...),
+			Clauses(r.clauses...).
+			TrackEvents(r.trackEvents),
 		)
 	}
 	return fb.Build()
@@ -410,7 +436,7 @@ func newTestFlagRuleBuilder(owner *FlagBuilder) *RuleBuilder {
 }
 
 func copyTestFlagRuleBuilder(from *RuleBuilder, owner *FlagBuilder) *RuleBuilder {
-	r := RuleBuilder{owner: owner, variation: from.variation}
+	r := RuleBuilder{owner: owner, variation: from.variation, trackEvents: from.trackEvents}
 	r.clauses = slices.Clone(from.clauses)
 	return &r
 }
@@ -482,6 +508,16 @@ func (r *RuleBuilder) AndNotMatchContext(
 	return r
 }
 
+// TrackEvents sets whether the SDK should send full feature events, with evaluation reasons, for
+// evaluations of the flag that match this rule. This is the same as the rule's TrackEvents property in the
+// LaunchDarkly model.
+//
+// The default is false. To do the same for the fallthrough, use [FlagBuilder.TrackEventsFallthrough].
+func (r *RuleBuilder) TrackEvents(trackEvents bool) *RuleBuilder {
+	r.trackEvents = trackEvents
+	return r
+}
+
 // ThenReturn finishes defining the rule, specifying the result value as a boolean.
 func (r *RuleBuilder) ThenReturn(variation bool) *FlagBuilder {
 	r.owner.BooleanFlag()
@@ -81,6 +81,15 @@ func TestFlagConfig(t *testing.T) {
 		}, basicBool().On(true).FallthroughVariation(trueVar))
 	})
 
+	t.Run("event tracking", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) { f.TrackEvents(true) },
+			basicBool().On(true).FallthroughVariation(trueVar).TrackEvents(true))
+		verifyFlag(t, func(f *FlagBuilder) { f.TrackEventsFallthrough(true) },
+			basicBool().On(true).FallthroughVariation(trueVar).TrackEventsFallthrough(true))
+		verifyFlag(t, func(f *FlagBuilder) { f.TrackEvents(true).TrackEvents(false) },
+			basicBool().On(true).FallthroughVariation(trueVar))
+	})
+
 	t.Run("flag with string variations", func(t *testing.T) {
 		verifyFlag(t, func(f *FlagBuilder) {
 			f.Variations(threeStringValues...).OffVariationIndex(0).FallthroughVariationIndex(2)
@@ -257,6 +266,21 @@ func TestRuleConfig(t *testing.T) {
 		))
 	})
 
+	t.Run("rule with event tracking", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfMatch("name", ldvalue.String("Lucy")).TrackEvents(true).ThenReturn(true).
+				IfMatch("name", ldvalue.String("Mina")).ThenReturn(true)
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).TrackEvents(true).Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorIn, ldvalue.String("Lucy")),
+			),
+		).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule1").Variation(trueVar).Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorIn, ldvalue.String("Mina")),
+			),
+		))
+	})
+
 	t.Run("simple match with context kind", func(t *testing.T) {
 		matchReturnsVariation0 := basicBool().On(true).FallthroughVariation(0).AddRule(
 			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).Clauses(
@@ -19,6 +19,7 @@ import (
 	sh "github.com/launchdarkly/go-server-sdk/v7/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	ssys "github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	st "github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v7/testhelpers"
 
@@ -138,6 +139,7 @@ func (s *PersistentDataStoreTestSuite) runInternal(t testbox.TestingT) {
 		t.Run("Get", s.runGetTests)
 		t.Run("Upsert", s.runUpsertTests)
 		t.Run("Delete", s.runDeleteTests)
+		t.Run("serialization round trip", s.runSerializationRoundTripTests)
 
 		t.Run("IsStoreAvailable", func(t testbox.TestingT) {
 			// The store should always be available during this test suite
@@ -455,6 +457,61 @@ func (s *PersistentDataStoreTestSuite) runDeleteTests(t testbox.TestingT) {
 	})
 }
 
+func (s *PersistentDataStoreTestSuite) runSerializationRoundTripTests(t testbox.TestingT) {
+	// The other tests use mock data items; these use real flags and segments, to verify that the store
+	// returns whatever the SDK gave it without changing it in a way that the SDK could not read back.
+	flag := ldbuilders.NewFlagBuilder("flag").Version(10).On(true).
+		Variations(ldvalue.Bool(false), ldvalue.Bool(true)).FallthroughVariation(1).
+		AddRule(ldbuilders.NewRuleBuilder().ID("rule").Variation(0).
+			Clauses(ldbuilders.SegmentMatchClause("segment"))).
+		Build()
+	segment := ldbuilders.NewSegmentBuilder("segment").Version(20).Included("a", "b").Build()
+
+	items := []struct {
+		name string
+		kind st.DataKind
+		key  string
+		item st.ItemDescriptor
+	}{
+		{"flag", datakinds.Features, flag.Key, ldstoreimpl.MakeFlagDescriptor(flag)},
+		{"segment", datakinds.Segments, segment.Key, ldstoreimpl.MakeSegmentDescriptor(segment)},
+		{"deleted flag", datakinds.Features, "deleted-flag", ldstoreimpl.MakeTombstone(30)},
+		{"deleted segment", datakinds.Segments, "deleted-segment", ldstoreimpl.MakeTombstone(40)},
+	}
+
+	for _, p := range items {
+		t.Run(p.name+" from Init", func(t testbox.TestingT) {
+			s.clearData(t, "")
+			s.withDefaultStore(t, func(store ssys.PersistentDataStore) {
+				allData := []st.SerializedCollection{
+					{Kind: p.kind, Items: []st.KeyedSerializedItemDescriptor{
+						{Key: p.key, Item: ldstoreimpl.SerializeDescriptor(p.kind, p.item)},
+					}},
+				}
+				require.NoError(t, store.Init(allData))
+
+				result, err := store.Get(p.kind, p.key)
+				require.NoError(t, err)
+				assert.NoError(t, ldstoreimpl.ValidateSerializedRoundTrip(p.kind, p.item, result))
+			})
+		})
+
+		t.Run(p.name+" from Upsert", func(t testbox.TestingT) {
+			s.withDefaultInitedStore(t, func(store ssys.PersistentDataStore) {
+				updated, err := store.Upsert(p.kind, p.key, ldstoreimpl.SerializeDescriptor(p.kind, p.item))
+				require.NoError(t, err)
+				assert.True(t, updated)
+
+				results, err := store.GetAll(p.kind)
+				require.NoError(t, err)
+				result, ok := itemDescriptorsToMap(results)[p.key]
+				require.True(t, ok)
+				assert.NoError(t, ldstoreimpl.ValidateSerializedRoundTrip(p.kind, p.item, result))
+			})
+		})
+	}
+}
+
 func (s *PersistentDataStoreTestSuite) runPrefixIndependenceTests(t testbox.TestingT) {
 	runWithPrefixes := func(
 		t testbox.TestingT,
@@ -601,11 +658,8 @@ func (s *PersistentDataStoreTestSuite) runErrorTests(t testbox.TestingT) {
 	})
 
 	t.Run("Upsert", func(t testbox.TestingT) {
-		desc := sh.FlagDescriptor(ldbuilders.NewFlagBuilder("key").Build())
-		sdesc := st.SerializedItemDescriptor{
-			Version:        1,
-			SerializedItem: datakinds.Features.Serialize(desc),
-		}
+		desc := ldstoreimpl.MakeFlagDescriptor(ldbuilders.NewFlagBuilder("key").Version(1).Build())
+		sdesc := ldstoreimpl.SerializeDescriptor(datakinds.Features, desc)
 		_, err := store.Upsert(datakinds.Features, "key", sdesc)
 		require.Error(t, err)
 		errorValidator(t, err)
@@ -720,10 +774,10 @@ func (s *PersistentDataStoreTestSuite) runLDClientEndToEndTests(t testbox.Testin
 
 	data := []st.Collection{
 		{Kind: datakinds.Features, Items: []st.KeyedItemDescriptor{
-			{Key: flagKey, Item: sh.FlagDescriptor(flag)},
+			{Key: flagKey, Item: ldstoreimpl.MakeFlagDescriptor(flag)},
 		}},
 		{Kind: datakinds.Segments, Items: []st.KeyedItemDescriptor{
-			{Key: segmentKey, Item: sh.SegmentDescriptor(segment)},
+			{Key: segmentKey, Item: ldstoreimpl.MakeSegmentDescriptor(segment)},
 		}},
 	}
 	dataSourceConfigurer := &mocks.ComponentConfigurerThatCapturesClientContext[ssys.DataSource]{
@@ -761,7 +815,7 @@ func (s *PersistentDataStoreTestSuite) runLDClientEndToEndTests(t testbox.Testin
 	t.Run("update flag", func(t testbox.TestingT) {
 		flagv2 := makeFlagThatReturnsVariationForSegmentMatch(2, goodVariation2)
 		dataSourceUpdateSink.Upsert(datakinds.Features, flagKey,
-			sh.FlagDescriptor(flagv2))
+			ldstoreimpl.MakeFlagDescriptor(flagv2))
 
 		flagShouldHaveValueForUser(user, goodValue2)
 		flagShouldHaveValueForUser(otherUser, badValue)
@@ -770,21 +824,21 @@ func (s *PersistentDataStoreTestSuite) runLDClientEndToEndTests(t testbox.Testin
 	t.Run("update segment", func(t testbox.TestingT) {
 		segmentv2 := makeSegmentThatMatchesUserKeys(2, userKey, otherUserKey)
 		dataSourceUpdateSink.Upsert(datakinds.Segments, segmentKey,
-			sh.SegmentDescriptor(segmentv2))
+			ldstoreimpl.MakeSegmentDescriptor(segmentv2))
 		flagShouldHaveValueForUser(otherUser, goodValue2) // otherUser is now matched by the segment
 	})
 
 	t.Run("delete segment", func(t testbox.TestingT) {
 		// deleting the segment should cause the flag that uses it to stop matching
 		dataSourceUpdateSink.Upsert(datakinds.Segments, segmentKey,
-			st.ItemDescriptor{Version: 3, Item: nil})
+			ldstoreimpl.MakeTombstone(3))
 		flagShouldHaveValueForUser(user, badValue)
 	})
 
 	t.Run("delete flag", func(t testbox.TestingT) {
 		// deleting the flag should cause the flag to become unknown
 		dataSourceUpdateSink.Upsert(datakinds.Features, flagKey,
-			st.ItemDescriptor{Version: 3, Item: nil})
+			ldstoreimpl.MakeTombstone(3))
 		value, detail, err := client.JSONVariationDetail(flagKey, user, ldvalue.Null())
 		assert.Error(t, err)
 		assert.Equal(t, ldvalue.Null(), value)
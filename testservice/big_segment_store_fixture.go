@@ -1,8 +1,8 @@
 package main
 
 import (
-	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	cf "github.com/launchdarkly/go-server-sdk/v7/testservice/servicedef/callbackfixtures"
 )
 
@@ -10,8 +10,6 @@ type BigSegmentStoreFixture struct {
 	service *callbackService
 }
 
-type bigSegmentMembershipMap map[string]bool
-
 func (b *BigSegmentStoreFixture) Build(context subsystems.ClientContext) (subsystems.BigSegmentStore, error) {
 	return b, nil
 }
@@ -34,12 +32,5 @@ func (b *BigSegmentStoreFixture) GetMembership(contextHash string) (subsystems.B
 	if err := b.service.post(cf.BigSegmentStorePathGetMembership, params, &resp); err != nil {
 		return nil, err
 	}
-	return bigSegmentMembershipMap(resp.Values), nil
-}
-
-func (m bigSegmentMembershipMap) CheckMembership(segmentRef string) ldvalue.OptionalBool {
-	if value, ok := m[segmentRef]; ok {
-		return ldvalue.NewOptionalBool(value)
-	}
-	return ldvalue.OptionalBool{}
+	return ldstoreimpl.NewBigSegmentMembershipFromMap(resp.Values), nil
 }
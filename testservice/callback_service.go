@@ -15,8 +15,12 @@ type callbackService struct {
 
 func (c *callbackService) close() error {
 	req, _ := http.NewRequest("DELETE", c.baseURL, nil)
-	_, err := http.DefaultClient.Do(req)
-	return err
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
 }
 
 func (c *callbackService) post(path string, params interface{}, responseOut interface{}) error {
@@ -0,0 +1,92 @@
+package ldclient
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// EvalErrorInconsistentVariationType is the error kind in the EvaluationReason when a
+// [VariationTypeChecker] finds that a flag was evaluated with a different type than the first time it
+// was evaluated.
+const EvalErrorInconsistentVariationType ldreason.EvalErrorKind = "INCONSISTENT_VARIATION_TYPE"
+
+// DefaultVariationTypeCheckerMaxFlags is the default number of flag keys that a [VariationTypeChecker]
+// will keep track of.
+const DefaultVariationTypeCheckerMaxFlags = 10000
+
+// VariationTypeChecker detects code that evaluates the same flag as different types, such as calling
+// BoolVariation for a flag in one place and StringVariation for it in another.
+//
+// This is meant to be used in tests. To use it, set the VariationTypeChecker field of [Config]. The checker
+// remembers which type was used the first time each flag was evaluated with a typed variation method. If
+// the same flag is later evaluated with a different type, the client logs a warning and returns the
+// default value, with the error kind [EvalErrorInconsistentVariationType]; this happens even if the flag
+// does not exist, so it also works in tests that do not provide any flag data. JSONVariation and
+// JSONVariationDetail are not checked, since they accept any type; IntVariation and Float64Variation are
+// treated as the same type.
+//
+// A single VariationTypeChecker can be shared by all of the clients created during a test run, so that
+// inconsistencies between different parts of the application are detected. Call
+// [VariationTypeChecker.Reset] to forget everything it has recorded. It is safe for concurrent use.
+type VariationTypeChecker struct {
+	maxFlags int
+	types    map[string]ldvalue.ValueType
+	lock     sync.Mutex
+}
+
+// NewVariationTypeChecker creates a [VariationTypeChecker].
+//
+// The checker keeps track of at most maxFlags flag keys; flags that are first evaluated after that many
+// keys have been recorded are not checked. If maxFlags is zero or negative, it is set to
+// [DefaultVariationTypeCheckerMaxFlags].
+func NewVariationTypeChecker(maxFlags int) *VariationTypeChecker {
+	if maxFlags <= 0 {
+		maxFlags = DefaultVariationTypeCheckerMaxFlags
+	}
+	return &VariationTypeChecker{
+		maxFlags: maxFlags,
+		types:    make(map[string]ldvalue.ValueType),
+	}
+}
+
+// Reset forgets all of the flag types that have been recorded.
+func (c *VariationTypeChecker) Reset() {
+	c.lock.Lock()
+	c.types = make(map[string]ldvalue.ValueType)
+	c.lock.Unlock()
+}
+
+// check records the type for a flag key if it is the first time that key has been seen, and returns
+// false if a different type was previously recorded.
+func (c *VariationTypeChecker) check(flagKey string, valueType ldvalue.ValueType, loggers ldlog.Loggers) bool {
+	c.lock.Lock()
+	previousType, found := c.types[flagKey]
+	if !found && len(c.types) < c.maxFlags {
+		c.types[flagKey] = valueType
+	}
+	c.lock.Unlock()
+
+	if found && previousType != valueType {
+		loggers.Warnf(
+			"Flag %q was evaluated with %s, but it was previously evaluated with %s; returning default value",
+			flagKey, variationMethodForType(valueType), variationMethodForType(previousType))
+		return false
+	}
+	return true
+}
+
+func variationMethodForType(valueType ldvalue.ValueType) string {
+	switch valueType {
+	case ldvalue.BoolType:
+		return "BoolVariation"
+	case ldvalue.NumberType:
+		return "IntVariation or Float64Variation"
+	case ldvalue.StringType:
+		return "StringVariation"
+	default:
+		return "a " + valueType.String() + " variation"
+	}
+}
@@ -0,0 +1,119 @@
+package ldclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeClientWithVariationTypeChecker(
+	t *testing.T,
+	checker *VariationTypeChecker,
+	mockLog *ldlogtest.MockLog,
+) *LDClient {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("bool-flag").BooleanFlag().VariationForAll(true))
+	td.Update(td.Flag("string-flag").ValueForAll(ldvalue.String("x")))
+	client, err := MakeCustomClient(testSdkKey, Config{
+		DataSource:           td,
+		Events:               ldcomponents.NoEvents(),
+		Logging:              ldcomponents.Logging().Loggers(mockLog.Loggers),
+		VariationTypeChecker: checker,
+	}, time.Second)
+	require.NoError(t, err)
+	return client
+}
+
+func TestVariationTypeChecker(t *testing.T) {
+	t.Run("same type is allowed", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		client := makeClientWithVariationTypeChecker(t, NewVariationTypeChecker(0), mockLog)
+		defer client.Close()
+
+		for i := 0; i < 2; i++ {
+			value, detail, err := client.BoolVariationDetail("bool-flag", evalTestUser, false)
+			assert.NoError(t, err)
+			assert.True(t, value)
+			assert.Equal(t, ldreason.EvalReasonFallthrough, detail.Reason.GetKind())
+		}
+		assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+	})
+
+	t.Run("different type returns default value", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		client := makeClientWithVariationTypeChecker(t, NewVariationTypeChecker(0), mockLog)
+		defer client.Close()
+
+		_, _ = client.BoolVariation("bool-flag", evalTestUser, false)
+		value, detail, err := client.StringVariationDetail("bool-flag", evalTestUser, "default")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "default", value)
+		assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorInconsistentVariationType), detail.Reason)
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn,
+			`Flag "bool-flag" was evaluated with StringVariation, but it was previously evaluated with BoolVariation`)
+	})
+
+	t.Run("JSON variations are not checked", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		client := makeClientWithVariationTypeChecker(t, NewVariationTypeChecker(0), mockLog)
+		defer client.Close()
+
+		_, _ = client.StringVariation("string-flag", evalTestUser, "")
+		value, err := client.JSONVariation("string-flag", evalTestUser, ldvalue.Bool(false))
+		assert.NoError(t, err)
+		assert.Equal(t, ldvalue.String("x"), value)
+	})
+
+	t.Run("checker is shared between clients", func(t *testing.T) {
+		checker := NewVariationTypeChecker(0)
+		client1 := makeClientWithVariationTypeChecker(t, checker, ldlogtest.NewMockLog())
+		defer client1.Close()
+		client2 := makeClientWithVariationTypeChecker(t, checker, ldlogtest.NewMockLog())
+		defer client2.Close()
+
+		_, _ = client1.BoolVariation("bool-flag", evalTestUser, false)
+		_, detail, _ := client2.IntVariationDetail("bool-flag", evalTestUser, 0)
+		assert.Equal(t, ldreason.NewEvalReasonError(EvalErrorInconsistentVariationType), detail.Reason)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		checker := NewVariationTypeChecker(0)
+		client := makeClientWithVariationTypeChecker(t, checker, ldlogtest.NewMockLog())
+		defer client.Close()
+
+		_, _ = client.StringVariation("bool-flag", evalTestUser, "")
+		checker.Reset()
+		value, err := client.BoolVariation("bool-flag", evalTestUser, false)
+		assert.NoError(t, err)
+		assert.True(t, value)
+	})
+
+	t.Run("number of flags is bounded", func(t *testing.T) {
+		checker := NewVariationTypeChecker(1)
+		client := makeClientWithVariationTypeChecker(t, checker, ldlogtest.NewMockLog())
+		defer client.Close()
+
+		_, _ = client.StringVariation("string-flag", evalTestUser, "")
+		_, _ = client.StringVariation("bool-flag", evalTestUser, "") // not recorded
+		value, err := client.BoolVariation("bool-flag", evalTestUser, false)
+		assert.NoError(t, err)
+		assert.True(t, value)
+		assert.Len(t, checker.types, 1)
+	})
+}
+
+func TestNewVariationTypeCheckerDefaultMaxFlags(t *testing.T) {
+	assert.Equal(t, DefaultVariationTypeCheckerMaxFlags, NewVariationTypeChecker(0).maxFlags)
+	assert.Equal(t, DefaultVariationTypeCheckerMaxFlags, NewVariationTypeChecker(-1).maxFlags)
+	assert.Equal(t, 5, NewVariationTypeChecker(5).maxFlags)
+}